@@ -77,8 +77,9 @@ class OnePassTests(unittest.TestCase):
         shutil.rmtree(self.vault_path)
 
     def _createVault(self):
-        # Setup a new vault
-        (self.exec_1pass('new')
+        # Setup a new vault. The test passwords are deliberately
+        # short, so the master password strength check is skipped
+        (self.exec_1pass('new --force')
           .expect('Creating new vault.*' + self.vault_path)
           .expect('Enter master password')
           .sendline(TEST_PASSWD)
@@ -302,6 +303,41 @@ class OnePassTests(unittest.TestCase):
          .wait())
         self.assertEqual(clipboard.paste(), 'myuser')
 
+    def testVerifyPassword(self):
+        self._createVault()
+        self._addLoginItem('mysite', 'myuser', 'mypass', 'mysite.com')
+
+        (self.exec_1pass('verify-password mysite')
+         .expect('Password to verify')
+         .sendline('mypass')
+         .expect("The password matches 'password' for item 'mysite'")
+         .wait())
+        (self.exec_1pass('verify-password mysite')
+         .expect('Password to verify')
+         .sendline('wrongpass')
+         .expect("The password does not match 'password' for item 'mysite'")
+         .wait(expect_status=1))
+
+        # Verify a field other than the password
+        (self.exec_1pass('verify-password mysite user')
+         .expect('Password to verify')
+         .sendline('myuser')
+         .expect('The password matches')
+         .wait())
+
+        clipboard.copy('mypass')
+        if clipboard.paste() != 'mypass':
+            # running on a system without clipboard support
+            return
+
+        (self.exec_1pass('verify-password mysite --clipboard')
+         .expect('The password matches')
+         .wait())
+        clipboard.copy('wrongpass')
+        (self.exec_1pass('verify-password mysite --clipboard')
+         .expect('The password does not match')
+         .wait(expect_status=1))
+
     def testExport(self):
         self._createVault()
         self._addLoginItem('mysite', 'myuser', 'mypass', 'mysite.com')
@@ -338,7 +374,7 @@ class OnePassTests(unittest.TestCase):
     def testChangePassword(self):
         self._createVault()
 
-        (self.exec_1pass('set-password')
+        (self.exec_1pass('set-password --force')
           .expect('Current master password')
           .sendline(TEST_PASSWD)
           .expect('New master password')
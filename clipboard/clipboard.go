@@ -0,0 +1,272 @@
+// Package clipboard reads and writes the system clipboard using the
+// tools available on the current platform: wl-clipboard on Wayland,
+// xclip or xsel on X11, pbcopy and pbpaste on macOS and clip.exe and
+// PowerShell on Windows and WSL. Where none of those are available,
+// such as in an SSH session, text is copied to the clipboard of the
+// local terminal using the OSC 52 escape sequence. A particular
+// backend can be selected with ByName().
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/securetmp"
+)
+
+// Timeout is the maximum time allowed for a clipboard
+// tool to read or write the clipboard
+var Timeout = 5 * time.Second
+
+// ErrUnavailable is returned if no way of accessing
+// the clipboard is available
+var ErrUnavailable = errors.New("No clipboard tool found. Install wl-clipboard (Wayland), " +
+	"xclip or xsel (X11), or use a terminal which supports OSC 52")
+
+// Backend reads and writes the clipboard using a particular
+// tool or terminal feature
+type Backend struct {
+	Name string
+	// commands which write stdin to the clipboard and write
+	// the contents of the clipboard to stdout
+	copyCommand  []string
+	pasteCommand []string
+	// if set, used instead of copyCommand
+	write func(text string) error
+}
+
+// environment used to choose a backend, which
+// is replaced by tests
+type environment struct {
+	goos     string
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+	isWSL    func() bool
+	hasTTY   func() bool
+}
+
+func systemEnvironment() environment {
+	return environment{
+		goos:     runtime.GOOS,
+		getenv:   os.Getenv,
+		lookPath: exec.LookPath,
+		isWSL:    isWSL,
+		hasTTY:   hasTTY,
+	}
+}
+
+// Detect returns the backend to use on the current system
+func Detect() (*Backend, error) {
+	return detect(systemEnvironment())
+}
+
+// backends returns the backends which can be selected
+// with ByName(), keyed by name
+func backends(env environment) map[string]Backend {
+	return map[string]Backend{
+		"pbcopy": {Name: "pbcopy", copyCommand: []string{"pbcopy"}, pasteCommand: []string{"pbpaste"}},
+		"clip.exe": {
+			Name:         "clip.exe",
+			copyCommand:  []string{"clip.exe"},
+			pasteCommand: []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"},
+		},
+		"wl-clipboard": {
+			Name:         "wl-clipboard",
+			copyCommand:  []string{"wl-copy"},
+			pasteCommand: []string{"wl-paste", "--no-newline"},
+		},
+		"xclip": {
+			Name:         "xclip",
+			copyCommand:  []string{"xclip", "-in", "-selection", "clipboard"},
+			pasteCommand: []string{"xclip", "-out", "-selection", "clipboard"},
+		},
+		"xsel": {
+			Name:         "xsel",
+			copyCommand:  []string{"xsel", "--input", "--clipboard"},
+			pasteCommand: []string{"xsel", "--output", "--clipboard"},
+		},
+		"osc52": {Name: "osc52", write: func(text string) error {
+			return writeOSC52(text, env.getenv("TMUX") != "")
+		}},
+	}
+}
+
+// BackendNames lists the names accepted by ByName()
+var BackendNames = []string{"auto", "wl-clipboard", "xclip", "xsel", "pbcopy", "clip.exe", "osc52"}
+
+// ByName returns the backend with a given name from BackendNames.
+// 'auto' or an empty name selects the backend using Detect().
+func ByName(name string) (*Backend, error) {
+	return byName(systemEnvironment(), name)
+}
+
+func byName(env environment, name string) (*Backend, error) {
+	switch name {
+	case "", "auto":
+		return detect(env)
+	case "wl-copy":
+		name = "wl-clipboard"
+	}
+	backend, ok := backends(env)[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown clipboard backend '%s'. Use one of: %s", name, strings.Join(BackendNames, ", "))
+	}
+	return &backend, nil
+}
+
+func detect(env environment) (*Backend, error) {
+	has := func(name string) bool {
+		_, err := env.lookPath(name)
+		return err == nil
+	}
+	name := ""
+	switch {
+	case env.goos == "darwin":
+		name = "pbcopy"
+	case env.goos == "windows" || (env.isWSL() && has("clip.exe")):
+		name = "clip.exe"
+	case env.getenv("WAYLAND_DISPLAY") != "" && has("wl-copy"):
+		name = "wl-clipboard"
+	case env.getenv("DISPLAY") != "" && has("xclip"):
+		name = "xclip"
+	case env.getenv("DISPLAY") != "" && has("xsel"):
+		name = "xsel"
+	case env.hasTTY():
+		name = "osc52"
+	default:
+		return nil, ErrUnavailable
+	}
+	backend := backends(env)[name]
+	return &backend, nil
+}
+
+// WriteAll copies text to the clipboard
+func WriteAll(text string) error {
+	backend, err := Detect()
+	if err != nil {
+		return err
+	}
+	return backend.WriteAll(text)
+}
+
+// ReadAll returns the contents of the clipboard
+func ReadAll() (string, error) {
+	backend, err := Detect()
+	if err != nil {
+		return "", err
+	}
+	return backend.ReadAll()
+}
+
+// WriteAll copies text to the clipboard
+func (backend *Backend) WriteAll(text string) error {
+	if backend.write != nil {
+		return backend.write(text)
+	}
+	_, err := runCommand(backend.copyCommand, text, false)
+	return err
+}
+
+// ReadAll returns the contents of the clipboard
+func (backend *Backend) ReadAll() (string, error) {
+	if len(backend.pasteCommand) == 0 {
+		return "", fmt.Errorf("Reading the clipboard is not supported with %s", backend.Name)
+	}
+	text, err := runCommand(backend.pasteCommand, "", true)
+	if err != nil {
+		return "", err
+	}
+	if backend.Name == "clip.exe" {
+		// Get-Clipboard adds a line ending
+		text = strings.TrimSuffix(text, "\r\n")
+	}
+	return text, nil
+}
+
+// runCommand runs a clipboard tool with input on stdin and returns
+// its output if readOutput is set, failing if it takes longer than
+// Timeout
+func runCommand(command []string, input string, readOutput bool) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	// tools such as xclip and wl-copy leave a process running in
+	// the background to serve the clipboard, which inherits stderr.
+	// Errors are written to a file rather than a pipe so that
+	// waiting for the tool does not wait for that process.
+	stderr, err := securetmp.CreateUnlinked("clipboard")
+	if err != nil {
+		return "", err
+	}
+	defer stderr.Close()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = stderr.File
+	if readOutput {
+		cmd.Stdout = &stdout
+	}
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("'%s' did not finish within %v", command[0], Timeout)
+	}
+	if err != nil {
+		stderr.Seek(0, io.SeekStart)
+		message, _ := ioutil.ReadAll(stderr)
+		if len(bytes.TrimSpace(message)) > 0 {
+			return "", fmt.Errorf("'%s' failed: %v: %s", command[0], err, bytes.TrimSpace(message))
+		}
+		return "", fmt.Errorf("'%s' failed: %v", command[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// osc52Sequence returns the escape sequence which asks
+// the terminal to copy text to the clipboard. Inside tmux, the
+// sequence is wrapped so that tmux passes it on to the terminal,
+// which requires tmux's 'allow-passthrough' option to be set.
+func osc52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		seq = "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	}
+	return seq
+}
+
+func writeOSC52(text string, tmux bool) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("Unable to open the terminal to copy with OSC 52: %v", err)
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(osc52Sequence(text, tmux))
+	return err
+}
+
+func isWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	version, err := ioutil.ReadFile("/proc/version")
+	return err == nil && strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
+
+func hasTTY() bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	return true
+}
@@ -0,0 +1,132 @@
+package clipboard
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEnvironment(goos string, vars map[string]string, tools ...string) environment {
+	return environment{
+		goos:   goos,
+		getenv: func(name string) string { return vars[name] },
+		lookPath: func(name string) (string, error) {
+			for _, tool := range tools {
+				if tool == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+		isWSL:  func() bool { return vars["WSL_DISTRO_NAME"] != "" },
+		hasTTY: func() bool { return vars["TTY"] != "" },
+	}
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		env     environment
+		backend string
+	}{
+		{testEnvironment("darwin", nil), "pbcopy"},
+		{testEnvironment("windows", nil), "clip.exe"},
+		{testEnvironment("linux", map[string]string{"WSL_DISTRO_NAME": "Ubuntu", "DISPLAY": ":0"}, "clip.exe", "xclip"), "clip.exe"},
+		{testEnvironment("linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, "wl-copy", "xclip"), "wl-clipboard"},
+		{testEnvironment("linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, "xclip"), "xclip"},
+		{testEnvironment("linux", map[string]string{"DISPLAY": ":0"}, "xsel"), "xsel"},
+		{testEnvironment("linux", map[string]string{"TTY": "1"}, "xclip"), "osc52"},
+		{testEnvironment("linux", nil), ""},
+	}
+	for _, tc := range cases {
+		backend, err := detect(tc.env)
+		if tc.backend == "" {
+			if err != ErrUnavailable {
+				t.Errorf("Expected no backend, got %v", backend)
+			}
+			continue
+		}
+		if err != nil || backend.Name != tc.backend {
+			t.Errorf("Expected backend %s, got %v (%v)", tc.backend, backend, err)
+		}
+	}
+}
+
+func TestCommandBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "1pass-clipboard-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "clipboard")
+
+	backend := Backend{
+		Name:         "test",
+		copyCommand:  []string{"sh", "-c", "cat > " + path},
+		pasteCommand: []string{"cat", path},
+	}
+	err = backend.WriteAll("secret text")
+	if err != nil {
+		t.Fatalf("Failed to write clipboard: %v", err)
+	}
+	text, err := backend.ReadAll()
+	if err != nil || text != "secret text" {
+		t.Errorf("Unexpected clipboard contents '%s': %v", text, err)
+	}
+
+	backend.copyCommand = []string{"sh", "-c", "echo Error: cannot open display >&2; exit 1"}
+	err = backend.WriteAll("secret text")
+	if err == nil || !strings.Contains(err.Error(), "cannot open display") {
+		t.Errorf("Expected error from clipboard tool, got %v", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { Timeout = timeout }(Timeout)
+	Timeout = 50 * time.Millisecond
+
+	backend := Backend{Name: "test", copyCommand: []string{"sleep", "5"}}
+	err := backend.WriteAll("secret text")
+	if err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestByName(t *testing.T) {
+	env := testEnvironment("linux", map[string]string{"DISPLAY": ":0"}, "xclip")
+	cases := []struct {
+		name    string
+		backend string
+	}{
+		{"", "xclip"},
+		{"auto", "xclip"},
+		{"wl-copy", "wl-clipboard"},
+		{"osc52", "osc52"},
+		{"pbcopy", "pbcopy"},
+		{"klipper", ""},
+	}
+	for _, tc := range cases {
+		backend, err := byName(env, tc.name)
+		if tc.backend == "" {
+			if err == nil {
+				t.Errorf("Expected error for unknown backend '%s'", tc.name)
+			}
+			continue
+		}
+		if err != nil || backend.Name != tc.backend {
+			t.Errorf("Expected backend %s for '%s', got %v (%v)", tc.backend, tc.name, backend, err)
+		}
+	}
+}
+
+func TestOSC52Sequence(t *testing.T) {
+	if seq := osc52Sequence("hello", false); seq != "\x1b]52;c;aGVsbG8=\a" {
+		t.Errorf("Unexpected OSC 52 sequence %q", seq)
+	}
+	if seq := osc52Sequence("hello", true); seq != "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\a\x1b\\" {
+		t.Errorf("Unexpected OSC 52 sequence for tmux %q", seq)
+	}
+}
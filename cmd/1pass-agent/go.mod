@@ -0,0 +1,12 @@
+module github.com/robertknight/1pass/cmd/1pass-agent
+
+go 1.26.0
+
+require github.com/robertknight/1pass/onepass v0.1.0
+
+require (
+	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
+	github.com/robertknight/1pass v0.1.0 // indirect
+	golang.org/x/crypto v0.57.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+)
@@ -0,0 +1,28 @@
+// Command 1pass-agent runs the 1pass agent, which holds the keys of
+// unlocked vaults for the 1pass CLI and other programs which use the
+// onepass/client package. It is equivalent to '1pass -agent' and can
+// be installed on its own where the CLI is not needed.
+//
+// Set 'AgentBinary' in ~/.1pass to the path of this command to have
+// the CLI start it instead of '1pass -agent'.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/onepass/agent"
+)
+
+func main() {
+	logFileFlag := flag.String("log-file", "", "Path of the log file to write to. Defaults to stderr")
+	logLevelFlag := flag.String("log-level", "info", "Minimum level of log entries to write")
+	flag.Parse()
+
+	err := agent.Run(*logFileFlag, *logLevelFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// printAgentLogs prints the last 'count' entries
+// from the agent's log file
+func printAgentLogs(path string, count int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > count {
+			lines = lines[1:]
+		}
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func setAliasHelp() string {
+	return `Gives an item a short alias, such as 'gh', which can be used anywhere
+a pattern is accepted to refer to that item alone, even if the alias
+also matches the titles of other items. This lets scripts refer to
+items unambiguously without using their IDs.
+
+Each alias can only be used by one item and an item has at most one
+alias. Aliases cannot contain spaces, ':' or wildcards, or be the name
+of an item type. Use 'none' to remove an item's alias. Without any
+arguments, lists the items which have aliases.
+
+Aliases are stored unencrypted with the item and are ignored by other
+1Password apps.`
+}
+
+// checkAlias returns an error if alias cannot be used
+// as the alias of an item
+func checkAlias(alias string) error {
+	if strings.ContainsAny(alias, " \t:*?") {
+		return fmt.Errorf("Aliases cannot contain spaces, ':' or wildcards")
+	}
+	if typeFromAlias(alias) != "" || alias == lastItemPattern {
+		return fmt.Errorf("'%s' cannot be used as an alias", alias)
+	}
+	return nil
+}
+
+// setItemAlias sets or, if alias is 'none', removes
+// the alias of the item matching pattern
+func setItemAlias(vault *onepass.Vault, pattern string, alias string) {
+	if alias == "none" {
+		alias = ""
+	} else if err := checkAlias(alias); err != nil {
+		fatalErr(err, "")
+	}
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item")
+	}
+	if alias != "" {
+		items, err := vault.ListItems()
+		if err != nil {
+			fatalErr(err, "Unable to list vault items")
+		}
+		for _, other := range items {
+			if other.Uuid != item.Uuid && strings.EqualFold(other.OpenContents.Alias, alias) {
+				fatalErr(fmt.Errorf("The alias '%s' is already used by '%s'", alias, other.Title), "")
+			}
+		}
+	}
+	logItemAction("Setting alias of item", item)
+	item.OpenContents.Alias = alias
+	err = item.Save()
+	if err != nil {
+		fatalErr(err, "Unable to save item")
+	}
+}
+
+// listItemAliases lists the items which have aliases
+func listItemAliases(vault *onepass.Vault) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, item := range items {
+		if item.OpenContents.Alias != "" && !item.Trashed {
+			fmt.Fprintf(out, "%s\t%s (%s, %s)\n", item.OpenContents.Alias, item.Title, item.Type(), item.Uuid[0:4])
+		}
+	}
+	out.Flush()
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/pwstrength"
+)
+
+func auditHelp() string {
+	return `Decrypts the passwords of the vault's items and prints a report of one
+kind of problem, so that you can decide which credentials to change first.
+
+  weak    Passwords which are easy to guess, weakest first. Passwords
+          with a score below 3 of 4 are listed, or all passwords with
+          '--all'. See 'gen-password --analyze' for how they are scored.
+  reused  Groups of items which share the same password, largest group
+          first, with the website of each item. Passwords are compared
+          by their SHA-256 hashes and are never printed.
+
+Items in the trash and items whose password is derived with
+'derive-password' are not audited. 'health' summarises the results of
+these and other checks as a score.
+
+With '--exporter', no report is printed. Instead the metrics described
+in 'help health' are served for Prometheus, as for 'health --exporter'.`
+}
+
+// showAuditReport prints one of the 'audit' reports
+func showAuditReport(vault *onepass.Vault, report string, flags cmdmodes.FlagValues) {
+	limit := 0
+	if flags.Bool("limit") {
+		var err error
+		limit, err = strconv.Atoi(flags.String("limit"))
+		if err != nil {
+			fatalErr(err, "Invalid number of items")
+		}
+	}
+	switch report {
+	case "weak":
+		showWeakPasswords(vault, flags.Bool("all"), limit, flags.Bool("json"))
+	case "reused":
+		showReusedPasswords(vault, limit, flags.Bool("json"))
+	case "":
+		fatalErr(fmt.Errorf("Specify a report, 'weak' or 'reused', or use '--exporter'"), "")
+	default:
+		fatalErr(fmt.Errorf("Unknown report '%s'. Use 'weak' or 'reused'", report), "")
+	}
+}
+
+// weakPassword is an entry in the 'audit weak' report
+type weakPassword struct {
+	Uuid    string  `json:"uuid"`
+	Title   string  `json:"title"`
+	Score   int     `json:"score"`
+	Bits    float64 `json:"bits"`
+	Warning string  `json:"warning,omitempty"`
+}
+
+// auditedPasswords calls fn with each item whose password is
+// audited by 'audit' and 'health'. contents[i] is the decrypted
+// content of items[i].
+func auditedPasswords(items []onepass.Item, contents []onepass.ItemContent, fn func(item onepass.Item, content *onepass.ItemContent)) {
+	for i, item := range items {
+		if item.Trashed || contents[i].PasswordRecipe != nil || contents[i].Password() == "" {
+			continue
+		}
+		fn(item, &contents[i])
+	}
+}
+
+// findWeakPasswords returns the items whose passwords have a lower
+// score than minScore, weakest first
+func findWeakPasswords(items []onepass.Item, contents []onepass.ItemContent, minScore int) []weakPassword {
+	weak := []weakPassword{}
+	auditedPasswords(items, contents, func(item onepass.Item, content *onepass.ItemContent) {
+		strength := onepass.EstimateItemEntropy(item, content)
+		if strength.Score < minScore {
+			weak = append(weak, weakPassword{
+				Uuid:    item.Uuid,
+				Title:   item.Title,
+				Score:   strength.Score,
+				Bits:    strength.Bits,
+				Warning: strength.Warning,
+			})
+		}
+	})
+	sort.SliceStable(weak, func(i, j int) bool {
+		return weak[i].Bits < weak[j].Bits
+	})
+	return weak
+}
+
+// reusedPassword is a group of items in the 'audit reused'
+// report which share a password
+type reusedPassword struct {
+	// first 8 hex digits of the SHA-256 hash of the password,
+	// which identify the group in reports
+	Id    string       `json:"id"`
+	Items []reusedItem `json:"items"`
+}
+
+type reusedItem struct {
+	Uuid  string `json:"uuid"`
+	Title string `json:"title"`
+	Site  string `json:"site,omitempty"`
+}
+
+// findReusedPasswords returns the groups of items which share
+// a password, largest first
+func findReusedPasswords(items []onepass.Item, contents []onepass.ItemContent) []reusedPassword {
+	groups := map[[sha256.Size]byte]*reusedPassword{}
+	order := [][sha256.Size]byte{}
+	auditedPasswords(items, contents, func(item onepass.Item, content *onepass.ItemContent) {
+		hash := sha256.Sum256([]byte(content.Password()))
+		group, ok := groups[hash]
+		if !ok {
+			group = &reusedPassword{Id: hex.EncodeToString(hash[:4])}
+			groups[hash] = group
+			order = append(order, hash)
+		}
+		group.Items = append(group.Items, reusedItem{Uuid: item.Uuid, Title: item.Title, Site: content.PrimaryURL()})
+	})
+	reused := []reusedPassword{}
+	for _, hash := range order {
+		if len(groups[hash].Items) > 1 {
+			reused = append(reused, *groups[hash])
+		}
+	}
+	sort.SliceStable(reused, func(i, j int) bool {
+		return len(reused[i].Items) > len(reused[j].Items)
+	})
+	return reused
+}
+
+// decryptAuditedItems returns the vault's items
+// and their decrypted contents
+func decryptAuditedItems(vault *onepass.Vault) ([]onepass.Item, []onepass.ItemContent) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	contents, err := vault.ItemContents(items)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+	return items, contents
+}
+
+// printJsonReport prints a report from 'audit --json'
+func printJsonReport(report interface{}) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fatalErr(err, "")
+	}
+	fmt.Println(string(data))
+}
+
+// showWeakPasswords prints the 'audit weak' report
+func showWeakPasswords(vault *onepass.Vault, all bool, limit int, asJson bool) {
+	items, contents := decryptAuditedItems(vault)
+	minScore := minPasswordScore
+	if all {
+		minScore = math.MaxInt32
+	}
+	weak := findWeakPasswords(items, contents, minScore)
+	if limit > 0 && len(weak) > limit {
+		weak = weak[:limit]
+	}
+	if asJson {
+		printJsonReport(weak)
+		return
+	}
+	if len(weak) == 0 {
+		fmt.Printf("No weak passwords found\n")
+		return
+	}
+	var table bytes.Buffer
+	out := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(out, "STRENGTH\tGUESSES\tITEM\tWARNING\n")
+	for _, entry := range weak {
+		fmt.Fprintf(out, "%s\t2^%.0f\t%s (%s)\t%s\n", pwstrength.ScoreDescription(entry.Score), entry.Bits,
+			entry.Title, entry.Uuid[0:4], entry.Warning)
+	}
+	out.Flush()
+	theme := currentTheme()
+	fmt.Print(theme.styleLines(table.Bytes(), theme.Warning))
+}
+
+// showReusedPasswords prints the 'audit reused' report
+func showReusedPasswords(vault *onepass.Vault, limit int, asJson bool) {
+	items, contents := decryptAuditedItems(vault)
+	reused := findReusedPasswords(items, contents)
+	if limit > 0 && len(reused) > limit {
+		reused = reused[:limit]
+	}
+	if asJson {
+		printJsonReport(reused)
+		return
+	}
+	if len(reused) == 0 {
+		fmt.Printf("No reused passwords found\n")
+		return
+	}
+	theme := currentTheme()
+	for i, group := range reused {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(theme.warning(fmt.Sprintf("Password %s is used by %d items:", group.Id, len(group.Items))))
+		out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, item := range group.Items {
+			fmt.Fprintf(out, "  %s (%s)\t%s\n", item.Title, item.Uuid[0:4], item.Site)
+		}
+		out.Flush()
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestFindWeakPasswords(t *testing.T) {
+	items := []onepass.Item{}
+	contents := []onepass.ItemContent{}
+	add := func(title string, password string) *onepass.Item {
+		items = append(items, onepass.Item{Uuid: title, Title: title, TypeName: "webforms.WebForm"})
+		contents = append(contents, onepass.NewLoginContent("alice", password, "https://example.com"))
+		return &items[len(items)-1]
+	}
+	add("strong", "Xq7#vL2!pR9z-Mw4$kT8")
+	add("fair", "Tr0ub4dor&3")
+	add("weakest", "password")
+	add("weak", "pin1234")
+	add("none", "")
+	add("trashed", "123456").Trashed = true
+
+	weak := findWeakPasswords(items, contents, minPasswordScore)
+	if len(weak) != 2 || weak[0].Title != "weakest" || weak[1].Title != "weak" {
+		t.Errorf("Unexpected weak passwords %+v", weak)
+	}
+
+	all := findWeakPasswords(items, contents, 5)
+	if len(all) != 4 || all[3].Title != "strong" {
+		t.Errorf("Expected all passwords, strongest last, got %+v", all)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Bits < all[i-1].Bits {
+			t.Errorf("Passwords are not sorted by strength: %+v", all)
+		}
+	}
+}
+
+func TestFindReusedPasswords(t *testing.T) {
+	items := []onepass.Item{}
+	contents := []onepass.ItemContent{}
+	add := func(title string, password string) *onepass.Item {
+		items = append(items, onepass.Item{Uuid: title, Title: title, TypeName: "webforms.WebForm"})
+		contents = append(contents, onepass.NewLoginContent("alice", password, "https://"+title+".com"))
+		return &items[len(items)-1]
+	}
+	add("mail", "shared-pair")
+	add("bank", "unique")
+	add("shop", "shared-three")
+	add("forum", "shared-pair")
+	add("news", "shared-three")
+	add("blog", "shared-three")
+	add("old", "shared-pair").Trashed = true
+
+	reused := findReusedPasswords(items, contents)
+	if len(reused) != 2 || len(reused[0].Items) != 3 || len(reused[1].Items) != 2 {
+		t.Fatalf("Unexpected reused passwords %+v", reused)
+	}
+	if reused[1].Items[0].Title != "mail" || reused[1].Items[1].Site != "https://forum.com" {
+		t.Errorf("Unexpected items sharing a password %+v", reused[1])
+	}
+	for _, group := range reused {
+		if strings.Contains(group.Id, "shared") || len(group.Id) != 8 {
+			t.Errorf("Unexpected group ID '%s'", group.Id)
+		}
+	}
+}
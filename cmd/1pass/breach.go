@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// name and title of the section in which the previous password
+// is kept when 'respond-breach' rotates an item's password
+const breachSectionName = "breach_response"
+const breachSectionTitle = "Breach Response"
+
+func respondBreachHelp() string {
+	return `Lists items with a website on [domain], or a subdomain of it, and offers to
+replace the password of each with a new random password, which meets the
+rules set for the item with 'set-policy'. Rotated items
+are tagged 'rotated-<date>' and keep their previous password in a
+'Breach Response' section, so that you can log in to change it.
+
+The new passwords are only saved in the vault. A report of the items
+which still need their password changing on the website is printed at
+the end.`
+}
+
+// itemMatchesDomain returns true if any of the item's
+// websites is on the given registrable domain
+func itemMatchesDomain(item onepass.Item, content onepass.ItemContent, domain string) bool {
+	if urlDomain(item.Location) == domain {
+		return true
+	}
+	for _, url := range content.Urls {
+		if urlDomain(url.Url) == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// rotateItemPassword replaces the password in an item's content,
+// keeping the previous password in a separate section
+func rotateItemPassword(content *onepass.ItemContent, newPassword string, now time.Time) bool {
+	oldPassword := content.Password()
+	if !content.SetPassword(newPassword) {
+		return false
+	}
+	content.Sections = append(content.Sections, onepass.ItemSection{
+		Name:  breachSectionName,
+		Title: breachSectionTitle,
+		Fields: []onepass.ItemField{
+			{Kind: "concealed", Name: "previous_password", Title: "previous password", Value: oldPassword},
+			{Kind: "date", Name: "rotated_at", Title: "rotated", Value: now.Unix()},
+		},
+	})
+	return true
+}
+
+func respondToBreach(vault *onepass.Vault, domain string) {
+	domain = urlDomain(domain)
+	if domain == "" {
+		fatalErr(fmt.Errorf("Invalid domain"), "")
+	}
+
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	candidates := []onepass.Item{}
+	for _, item := range items {
+		if !item.Trashed && item.TypeName != folderTypeName && item.TypeName != "system.folder.SavedSearch" {
+			candidates = append(candidates, item)
+		}
+	}
+	contents, err := vault.ItemContents(candidates)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+
+	now := time.Now()
+	rotatedTag := "rotated-" + now.Format("2006-01-02")
+	private := readConfig().PrivateTags
+	var rotated, skipped, noPassword []onepass.Item
+	for i, item := range candidates {
+		content := contents[i]
+		if !itemMatchesDomain(item, content, domain) {
+			continue
+		}
+		if content.Password() == "" {
+			noPassword = append(noPassword, item)
+			continue
+		}
+		fmt.Printf("Replace the password for '%s' (%s)? Y/N\n", item.Title, content.Username())
+		if !readConfirmation() {
+			skipped = append(skipped, item)
+			continue
+		}
+		var newPassword string
+		if policy := itemPasswordPolicy(item, content); policy != nil {
+			newPassword = genPasswordForPolicy(*policy, "respond-breach")
+		} else {
+			newPassword = genDefaultPassword("respond-breach")
+		}
+		rotateItemPassword(&content, newPassword, now)
+		err = item.SetContent(content)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to update item '%s'", item.Title))
+		}
+		tags := itemTags(&item, private)
+		if !hasTag(tags, rotatedTag) {
+			tags = append(tags, rotatedTag)
+		}
+		setItemTags(&item, tags, private)
+		rotated = append(rotated, item)
+	}
+
+	if len(rotated)+len(skipped)+len(noPassword) == 0 {
+		fmt.Printf("No items found for '%s'\n", domain)
+		return
+	}
+	printSection := func(heading string, items []onepass.Item) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", heading)
+		for _, item := range items {
+			fmt.Printf("  %s (%s, %s) %s\n", item.Title, item.Type(), item.Uuid[0:4], item.Location)
+		}
+	}
+	printSection("Change the password on the website to the new password", rotated)
+	printSection("Not rotated, change the password on the website and update the item", skipped)
+	printSection("No password field, check the item manually", noPassword)
+	if len(skipped)+len(noPassword) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestRotateItemPassword(t *testing.T) {
+	content := onepass.ItemContent{
+		Urls: []onepass.ItemUrl{{Label: "website", Url: "https://accounts.example.com/login"}},
+		FormFields: []onepass.WebFormField{
+			{Name: "user", Designation: "username", Value: "alice"},
+			{Name: "pass", Designation: "password", Value: "old-password"},
+		},
+	}
+	item := onepass.Item{Title: "Example", Location: "https://www.other.com"}
+	if !itemMatchesDomain(item, content, "example.com") {
+		t.Errorf("Expected item to match domain from its websites")
+	}
+	if itemMatchesDomain(item, content, "another.com") {
+		t.Errorf("Expected item not to match other domain")
+	}
+
+	if !rotateItemPassword(&content, "new-password", time.Now()) {
+		t.Fatalf("Unable to rotate password")
+	}
+	if content.Password() != "new-password" {
+		t.Errorf("Password not updated: %s", content.Password())
+	}
+	if content.FieldValue("previous_password") != "old-password" {
+		t.Errorf("Previous password not kept: %s", content.FieldValue("previous_password"))
+	}
+
+	note := onepass.ItemContent{Notes: "no password here"}
+	if rotateItemPassword(&note, "new-password", time.Now()) {
+		t.Errorf("Expected rotation to fail for item without a password field")
+	}
+}
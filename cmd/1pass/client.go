@@ -0,0 +1,4015 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/robertknight/1pass/clipboard"
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/jsonutil"
+	"github.com/robertknight/1pass/locale"
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/agent"
+	"github.com/robertknight/1pass/onepass/browser"
+	"github.com/robertknight/1pass/onepass/client"
+	"github.com/robertknight/1pass/rangeutil"
+	"github.com/robertknight/1pass/securetmp"
+)
+
+var commandModes = []cmdmodes.Mode{
+	{
+		Command:     "new",
+		Description: "Create a new vault",
+		ArgNames:    []string{"[path]"},
+		Flags: []cmdmodes.Flag{
+			{Name: "aes256", Description: "Encrypt the vault's keys using AES-256 and PBKDF2-SHA256. The vault can then only be unlocked by 1pass"},
+			{Name: "kdf", ArgName: "pbkdf2|scrypt|argon2id", Description: "Function used to derive the key which encrypts the vault's keys from the master password. " +
+				"Vaults which do not use PBKDF2 can only be unlocked by 1pass"},
+			{Name: "keyfile", ArgName: "path", Description: "Require the contents of a key file in addition to the master password to unlock the vault"},
+			{Name: "force", Description: "Use the master password even if it is weak"},
+		},
+		ExtraHelp: newVaultHelp,
+	},
+	{
+		Command:     "gen-password",
+		Description: "Generate a new random password",
+		ExtraHelp:   genPasswordHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "recipe", ArgName: "name", Description: "Form of password to generate, see below"},
+			{Name: "length", ArgName: "chars", Description: "Length of the password. Defaults to the recipe's length"},
+			{Name: "symbols", Description: "Include symbols"},
+			{Name: "no-digits", Description: "Leave out digits"},
+			{Name: "exclude-ambiguous", Description: "Leave out characters which are easily confused, such as 'l', '1', 'O' and '0'"},
+			{Name: "require", ArgName: "classes", Description: "Comma-separated classes of character which the password must contain: lower, upper, digit or symbol"},
+			{Name: "chars", ArgName: "set", Description: "Characters to choose from, instead of letters, digits and symbols"},
+			{Name: "words", ArgName: "count", Description: "Generate a passphrase of <count> random words instead, see below"},
+			{Name: "wordlist", ArgName: "file", Description: "File of words to choose passphrase words from. Defaults to the 'Wordlist' setting"},
+			{Name: "analyze", Description: "Estimate the strength of a password read from stdin instead of generating one"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "--recipe symbols --length 32", Description: "Generate a 32 character password including symbols"},
+			{Args: "--symbols --exclude-ambiguous --require digit,symbol", Description: "Generate a password with at least one digit and symbol which is easy to read"},
+			{Args: "--words 6 --wordlist ~/wordlists/de.txt", Description: "Generate a passphrase of 6 words from a German wordlist"},
+			{Args: "--analyze < password.txt", Description: "Estimate how easy the password in password.txt is to guess"},
+		},
+	},
+	{
+		Command:     "set-vault",
+		Description: "Set the path to the 1Password vault",
+		ArgNames:    []string{"[path]"},
+		ExtraHelp:   setVaultHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "name", ArgName: "name", Description: "Save the path as a named vault for use with '-vault-name' instead of as the default vault"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "~/Dropbox/1Password/1Password.agilekeychain", Description: "Use the vault in Dropbox by default"},
+			{Args: "--name work /mnt/work/Work.agilekeychain", Description: "Add a vault named 'work', used with '1pass -vault-name work <command>'"},
+			{Args: "--name work", Description: "Remove the vault named 'work'"},
+		},
+	},
+	{
+		Command:     "list-plugins",
+		Description: "List plugins found on $PATH and whether they are enabled",
+		ExtraHelp:   listPluginsHelp,
+	},
+	{
+		Command:     "list-vaults",
+		Description: "List the default vault and the vaults added with 'set-vault --name'",
+	},
+	{
+		Command:     "list-profiles",
+		Description: "List the profiles in the vault",
+	},
+	{
+		Command:     "set-profile",
+		Description: "Set the profile within the vault to use",
+		ArgNames:    []string{"[name]"},
+		ExtraHelp:   setProfileHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "work", Description: "Use the 'work' profile of the vault"},
+			{Args: "", Description: "Use the vault's default profile again"},
+		},
+	},
+	{
+		Command:     "info",
+		Description: "Display info about the current vault",
+	},
+	{
+		Command:     "lock",
+		Description: "Lock the vault",
+		ExtraHelp:   lockHelp,
+	},
+	{
+		Command:     "list",
+		Description: "List items in the vault",
+		ArgNames:    []string{"[pattern...]"},
+		ExtraHelp:   listHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+			{Name: "recent", Description: "List recently shown or copied items, most recent first"},
+			{Name: "sort", ArgName: "order", Description: "Sort items by 'title', 'frequency' or 'frecency'"},
+			{Name: "expiring", Description: "List software licenses which have expired or expire within 30 days, soonest first"},
+			{Name: "details", Description: "Include the username and website of each item, and the field set with 'set-summary'"},
+			{Name: "username", ArgName: "value", Description: "List only items with the given username or email address"},
+			{Name: "field", ArgName: "key=field:value", Description: "List only items with a matching field, see below"},
+			{Name: "types", Description: "List the types of the matching items with the number of items of each type"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "git", Description: "List items whose title contains 'git'"},
+			{Args: "card:", Description: "List all credit cards"},
+			{Args: "'git*' bitbucket", Description: "List items whose title starts with 'git' or contains 'bitbucket'"},
+			{Args: "login: --exclude google", Description: "List logins except those whose title contains 'google'"},
+			{Args: "--recent", Description: "List recently shown or copied items"},
+			{Args: "--sort frecency login:", Description: "List logins, most frequently and recently used first"},
+			{Args: "license --expiring", Description: "List software licenses which need renewing"},
+			{Args: "--types", Description: "List the types of item in the vault and how many there are of each"},
+			{Args: "--details google", Description: "List items matching 'google' with their usernames, to tell them apart"},
+			{Args: "--username alice@example.com", Description: "List all accounts registered with 'alice@example.com'"},
+			{Args: "--field title=account number:12345678", Description: "List items with an 'account number' field of '12345678'"},
+		},
+	},
+	{
+		Command:     "search",
+		Description: "Search the titles and notes of items",
+		ArgNames:    []string{"text"},
+		ExtraHelp:   searchHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "context", ArgName: "lines", Description: "Number of lines of notes to show before and after each match. Defaults to 2"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "failover", Description: "Find the runbook notes which mention 'failover'"},
+			{Args: "--context 5 'vpn config'", Description: "Show more of the notes around each match"},
+		},
+	},
+	{
+		Command:     "list-folder",
+		Description: "List items in a folder",
+		ArgNames:    []string{"pattern"},
+	},
+	{
+		Command:     "tree",
+		Description: "List folders hierarchically with the items they contain",
+		ArgNames:    []string{"[folder-pattern]"},
+		Flags: []cmdmodes.Flag{
+			{Name: "folders", Description: "List only folders"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "", Description: "List all folders and items, with items outside of folders last"},
+			{Args: "--folders Work", Description: "List the subfolders of the 'Work' folder"},
+		},
+	},
+	{
+		Command:     "list-tag",
+		Description: "List items with a given tag",
+		ArgNames:    []string{"tag"},
+	},
+	{
+		Command:     "list-tags",
+		Description: "List all tags",
+	},
+	{
+		Command:     "show-json",
+		Description: "Show the raw decrypted JSON for the given item",
+		ArgNames:    []string{"pattern..."},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+	},
+	{
+		Command:     "show",
+		Description: "Display the details of the given item",
+		ArgNames:    []string{"pattern..."},
+		ExtraHelp:   themeHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+			{Name: "history", Description: "Also show the item's previous passwords and when they were changed"},
+			{Name: "field", ArgName: "name", Description: "Show only the value of the field matching <name>, as for 'copy'"},
+			{Name: "raw", Description: "Write only the field's value, without a trailing newline. Shows the password if '--field' is not given"},
+			{Name: "render", Description: "Render the item's notes as Markdown. Use '--field notes --raw' for the literal text"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "github", Description: "Show the item whose title contains 'github'"},
+			{Args: "last", Description: "Show the most recently used item again"},
+			{Args: "github --history", Description: "Show the item along with its previous passwords"},
+			{Args: "github --field username", Description: "Print only the username for the 'github' item"},
+			{Args: "'deploy key' --field 'private key' --raw | ssh-add -", Description: "Add a key stored in an item to ssh-agent without copying it to the clipboard"},
+			{Args: "'db failover' --render", Description: "Show a runbook stored in a secure note with its headings, lists and code blocks formatted"},
+		},
+	},
+	{
+		Command:     "add",
+		Description: "Add a new item to the vault",
+		ArgNames:    []string{"type", "[title]"},
+		ExtraHelp:   addItemHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "from-url", ArgName: "url", Description: "Fill in the website, title and login form fields of a new login from the site's page"},
+			{Name: "offline", Description: "With '--from-url', do not fetch the site's page and use its host name as the title"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "login 'GitHub'", Description: "Add a new login, prompting for the username, password and website"},
+			{Args: "login --from-url https://github.com/login", Description: "Add a new login for a site, prompting only for the username and password"},
+			{Args: "note 'Wifi details'", Description: "Add a new secure note"},
+		},
+	},
+
+	{
+		Command:     "note",
+		Description: "Create a Secure Note containing text read from stdin",
+		ArgNames:    []string{"title"},
+		Flags: []cmdmodes.Flag{
+			{Name: "tag", ArgName: "tag", Description: "Tag to add to the note. May be repeated"},
+			{Name: "folder", ArgName: "path", Description: "Folder to add the note to, which is created if it does not exist"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "'Server setup' < notes.txt", Description: "Create a note containing the contents of 'notes.txt'"},
+			{Args: "'AWS keys' --tag aws --folder Work", Description: "Create a note from text typed until Ctrl+D, in the 'Work' folder"},
+		},
+	},
+	{
+		Command:     "edit",
+		Description: "Edit an existing item",
+		ArgNames:    []string{"pattern"},
+		Flags: []cmdmodes.Flag{
+			{Name: "json", Description: "Edit the item's content as JSON using $EDITOR"},
+		},
+	},
+	{
+		Command:     "move",
+		Description: "Move items to a folder",
+		ArgNames:    []string{"item-pattern", "[folder-pattern]"},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "github Work", Description: "Move items matching 'github' into the 'Work' folder"},
+		},
+	},
+	{
+		Command:     "set-expiry",
+		Description: "Set the date after which items are no longer needed",
+		ArgNames:    []string{"pattern", "date|never"},
+		ExtraHelp:   setExpiryHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "'contractor vpn' 31/03/27", Description: "Mark a temporary account as expiring on 31st March 2027"},
+			{Args: "'contractor vpn' never", Description: "Remove the expiry date from an item"},
+		},
+	},
+	{
+		Command:     "set-summary",
+		Description: "Set a field whose value is shown by 'list --details'",
+		ArgNames:    []string{"pattern", "field|none"},
+		ExtraHelp:   setSummaryHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "'bank:' 'account number'", Description: "Show the last four digits of each bank account's number when listing them"},
+			{Args: "license: expiry", Description: "Show the expiry date of software licenses"},
+			{Args: "'current account' none", Description: "Stop showing a field for an item"},
+		},
+	},
+	{
+		Command:     "set-alias",
+		Description: "Set a short alias which refers to an item in patterns",
+		ArgNames:    []string{"[pattern]", "[alias|none]"},
+		ExtraHelp:   setAliasHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "'work github' gh", Description: "Refer to the 'work github' item as 'gh', eg. 'copy gh'"},
+			{Args: "gh none", Description: "Remove the alias from an item"},
+			{Args: "", Description: "List the items which have aliases"},
+		},
+	},
+	{
+		Command:     "set-policy",
+		Description: "Set the rules which an item's website applies to passwords",
+		ArgNames:    []string{"pattern", "rules|none"},
+		ExtraHelp:   setPolicyHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "'my bank' 'min 8, max 16, at least one symbol, no spaces'", Description: "Generate passwords of at most 16 characters with a symbol for an item"},
+			{Args: "'my bank' '6-10 characters, symbols: !#'", Description: "Only use the symbols '!' and '#' in passwords for an item"},
+			{Args: "'my bank' none", Description: "Remove the password rules from an item"},
+		},
+	},
+	{
+		Command:     "expired",
+		Description: "List items which have passed their expiry date",
+		ExtraHelp:   expiredHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "within", ArgName: "days", Description: "Also warn about items which expire within this many days"},
+			{Name: "trash", Description: "Move expired items to the trash"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "--within 7", Description: "List items which have expired or will expire in the next week"},
+			{Args: "--trash", Description: "Move expired items to the trash, eg. from a daily cron job"},
+		},
+	},
+	{
+		Command:     "derive-password",
+		Description: "Derive an item's password from the vault key instead of storing it",
+		ArgNames:    []string{"pattern"},
+		ExtraHelp:   derivePasswordHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "site", ArgName: "name", Description: "Site name used to derive the password. Defaults to the domain of the item's website"},
+			{Name: "counter", ArgName: "number", Description: "Counter used to derive the password"},
+			{Name: "length", ArgName: "chars", Description: "Length of the password. Defaults to 16"},
+			{Name: "symbols", Description: "Include symbols in the password"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "github", Description: "Derive the password for the 'github' item, or change it if it is already derived"},
+			{Args: "--length 12 --counter 1 bank", Description: "Derive a 12 character password for the 'bank' item"},
+		},
+	},
+	{
+		Command:     "remove",
+		Description: "Remove items from the vault matching the given pattern",
+		ArgNames:    []string{"pattern..."},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+	},
+	{
+		Command:     "trash",
+		Description: "Move items to the trash",
+		ArgNames:    []string{"pattern..."},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "'old-*' --exclude old-keep", Description: "Trash items whose title starts with 'old-', except 'old-keep'"},
+			{Args: "dropbox box.com", Description: "Trash items matching 'dropbox' or 'box.com'"},
+		},
+	},
+	{
+		Command:     "restore",
+		Description: "Restore items from the trash",
+		ArgNames:    []string{"pattern..."},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+	},
+	{
+		Command:     "list-trash",
+		Description: "List items in the trash",
+	},
+	{
+		Command:     "empty-trash",
+		Description: "Remove all items in the trash from the vault",
+	},
+	{
+		Command:     "trash-retention",
+		Description: "Remove items from the trash automatically after a number of days",
+		ArgNames:    []string{"days|off"},
+		ExtraHelp:   trashRetentionHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "30", Description: "Remove items which have been in the trash for more than 30 days"},
+			{Args: "off", Description: "Keep items in the trash until 'empty-trash' is used"},
+		},
+	},
+	{
+		Command:     "normalize-urls",
+		Description: "Convert the websites of items to a canonical form and remove duplicates",
+		ArgNames:    []string{"[pattern...]"},
+		ExtraHelp:   normalizeUrlsHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "https", Description: "Change 'http' websites to 'https'"},
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "", Description: "Normalize the websites of all items"},
+			{Args: "--https login:", Description: "Normalize the websites of logins and change them to https where possible"},
+		},
+	},
+	{
+		Command:     "rename",
+		Description: "Renames an item in the vault",
+		ArgNames:    []string{"pattern", "new-title"},
+	},
+	{
+		Command:     "copy",
+		Description: "Copy information from the given item to the clipboard",
+		ArgNames:    []string{"pattern", "[field]"},
+		ExtraHelp:   copyItemHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "github", Description: "Copy the password for the 'github' item"},
+			{Args: "github username", Description: "Copy the username for the 'github' item"},
+			{Args: "last website", Description: "Copy the website of the most recently used item"},
+			{Args: "github otp", Description: "Copy the current one-time password for the 'github' item"},
+		},
+	},
+	{
+		Command:     "totp",
+		Description: "Display the current one-time password for an item",
+		ArgNames:    []string{"pattern"},
+		ExtraHelp:   totpHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "github", Description: "Display the two-factor authentication code for the 'github' item"},
+		},
+	},
+	{
+		Command:     "verify-password",
+		Description: "Check whether a password matches the one stored in an item",
+		ArgNames:    []string{"pattern", "[field]"},
+		ExtraHelp:   verifyPasswordHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "clipboard", Description: "Read the password to verify from the clipboard instead of prompting for it"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "github", Description: "Check a password against the one for the 'github' item"},
+			{Args: "router 'admin password' --clipboard", Description: "Check the copied password against the 'admin password' field"},
+		},
+	},
+	{
+		Command:     "connection-string",
+		Description: "Display a connection string (DSN) for the database described by a Database item",
+		ArgNames:    []string{"pattern"},
+		Flags: []cmdmodes.Flag{
+			{Name: "driver", ArgName: "postgres|mysql", Description: "Format of the connection string. Defaults to the item's database type"},
+			{Name: "copy", Description: "Copy the connection string to the clipboard instead of displaying it"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "staging-db", Description: "Display a DSN for the 'staging-db' item"},
+			{Args: "staging-db --driver mysql --copy", Description: "Copy a MySQL DSN for the 'staging-db' item"},
+		},
+	},
+	{
+		Command:     "ssh",
+		Description: "Connect to the host of a Unix Server item using ssh",
+		ArgNames:    []string{"pattern", "[command...]"},
+		ExtraHelp:   sshHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "webserver", Description: "Open a shell on the host of the 'webserver' item"},
+			{Args: "webserver -- uptime", Description: "Run 'uptime' on the host of the 'webserver' item"},
+		},
+	},
+	{
+		Command:     "email-config",
+		Description: "Display mail client settings for the servers and credentials in an Email Account item",
+		ArgNames:    []string{"pattern"},
+		Flags: []cmdmodes.Flag{
+			{Name: "format", ArgName: "mutt|thunderbird", Description: "Mail client to create settings for. Defaults to 'mutt'"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "work-mail >> ~/.muttrc", Description: "Add settings for the 'work-mail' account to mutt's config"},
+			{Args: "work-mail --format thunderbird", Description: "Display Thunderbird preferences for the 'work-mail' account"},
+		},
+	},
+	{
+		Command:     "license",
+		Description: "Display the license key, registered email and download link for a software license",
+		ArgNames:    []string{"product"},
+		ExtraHelp:   licenseHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "sublime", Description: "Display the license for Sublime Text"},
+			{Args: "jbidea", Description: "Display the license for 'JetBrains IntelliJ IDEA'"},
+		},
+	},
+	{
+		Command:     "export",
+		Description: "Export item to an unencrypted '1Password Interchange Format' directory",
+		ArgNames:    []string{"pattern", "path"},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "login: logins", Description: "Export all logins to 'logins.1pif'"},
+		},
+	},
+	{
+		Command:     "export-bitwarden",
+		Description: "Export items to an unencrypted Bitwarden JSON file",
+		ArgNames:    []string{"pattern", "path"},
+		ExtraHelp:   exportBitwardenHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "'' bitwarden.json", Description: "Export all items to 'bitwarden.json'"},
+			{Args: "login: logins.json", Description: "Export all logins to 'logins.json'"},
+		},
+	},
+	{
+		Command:     "export-pass",
+		Description: "Export items to a GPG-encrypted 'pass' password store",
+		ArgNames:    []string{"pattern", "[store-dir]"},
+		ExtraHelp:   exportPassHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "gpg-id", ArgName: "id", Description: "GPG key to encrypt entries for. May be repeated"},
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "login:", Description: "Export all logins to ~/.password-store"},
+			{Args: "server: /srv/pass --gpg-id ops@example.com", Description: "Create a store in /srv/pass containing server items"},
+		},
+	},
+	{
+		Command:     "health",
+		Description: "Audit the vault's items and settings and give it a score",
+		ExtraHelp:   healthHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "max-age", ArgName: "days", Description: "Report passwords which have not been changed for this many days. Defaults to 365"},
+			{Name: "json", Description: "Print the report as JSON"},
+			{Name: "exporter", Description: "Serve the results as Prometheus metrics instead of printing a report"},
+			{Name: "listen", ArgName: "address", Description: "Address for '--exporter' to listen on. Defaults to " + defaultExporterAddr},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "", Description: "Show the vault's health score and how to improve it"},
+			{Args: "--json --max-age 180", Description: "Print a report for a dashboard, treating passwords older than 6 months as old"},
+			{Args: "--exporter --listen :9915", Description: "Serve metrics for Prometheus to scrape on port 9915"},
+		},
+	},
+	{
+		Command:     "audit",
+		Description: "List items with weak or reused passwords",
+		ArgNames:    []string{"[weak|reused]"},
+		ExtraHelp:   auditHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "all", Description: "Include every audited item, not only those with problems"},
+			{Name: "limit", ArgName: "count", Description: "List at most this many items"},
+			{Name: "json", Description: "Print the report as JSON"},
+			{Name: "exporter", Description: "Serve the results as Prometheus metrics instead of printing a report"},
+			{Name: "listen", ArgName: "address", Description: "Address for '--exporter' to listen on. Defaults to " + defaultExporterAddr},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "weak --limit 10", Description: "List the ten weakest passwords in the vault"},
+			{Args: "reused", Description: "List the groups of items which share a password"},
+			{Args: "--exporter", Description: "Serve metrics for Prometheus to scrape on " + defaultExporterAddr},
+		},
+	},
+	{
+		Command:     "mirror",
+		Description: "Create a read-only vault containing a subset of items for automation",
+		ExtraHelp:   mirrorHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "filter", ArgName: "tag:<tag>|pattern", Description: "Items to copy to the mirror. May be repeated"},
+			{Name: "out", ArgName: "path", Description: "Path of the mirror vault"},
+			{Name: "refresh", Description: "Update an existing mirror with the current versions of its items"},
+			{Name: "force", Description: "Use the mirror's password even if it is weak"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "--filter tag:ci --out ci.agilekeychain", Description: "Create a vault for CI jobs with the items tagged 'ci'"},
+			{Args: "--refresh --out ci.agilekeychain", Description: "Update the CI vault after changing its items"},
+		},
+	},
+	{
+		Command:     "check",
+		Description: "Check the vault's data files for problems",
+		ExtraHelp:   checkVaultHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "repair", Description: "Rebuild the vault's index to fix problems with it"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "--repair", Description: "Check the vault and fix problems with its index"},
+		},
+	},
+	{
+		Command:     "rebuild-index",
+		Description: "Regenerate the vault's index (contents.js) from its item files",
+		ExtraHelp:   rebuildIndexHelp,
+	},
+	{
+		Command:     "conflicts",
+		Description: "List conflicted copies of items created by Dropbox or other file syncing services",
+		ExtraHelp:   resolveConflictHelp,
+	},
+	{
+		Command:     "resolve",
+		Description: "Choose which version of an item with a conflicted copy to keep",
+		ArgNames:    []string{"conflict", "[current|copy|both]"},
+		ExtraHelp:   resolveConflictHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "1", Description: "Compare the first conflicted copy with the current version and choose one"},
+			{Args: "github copy", Description: "Replace the 'github' item with its conflicted copy"},
+		},
+	},
+	{
+		Command:     "sync",
+		Description: "Copy new and updated items between the vault and another copy of it",
+		ArgNames:    []string{"other-vault-path"},
+		ExtraHelp:   syncVaultsHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "dry-run", Description: "Report the changes which would be made without changing either vault"},
+			{Name: "resolve", Description: "Compare the versions of each conflicting item and choose which to keep"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "~/Dropbox/1Password.agilekeychain", Description: "Sync the vault with the copy in Dropbox"},
+			{Args: "--resolve ~/Dropbox/1Password.agilekeychain", Description: "Sync the vault with the copy in Dropbox and resolve conflicts"},
+		},
+	},
+	{
+		Command:     "emergency-kit",
+		Description: "Create a password-protected PDF for printing with the details needed to recover the vault",
+		ArgNames:    []string{"path", "[pattern]"},
+		ExtraHelp:   emergencyKitHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "kit.pdf", Description: "Create a kit with the vault location and password hint"},
+			{Args: "kit.pdf recovery", Description: "Also include items whose title contains 'recovery'"},
+		},
+	},
+	{
+		Command:     "import",
+		Description: "Import items from an unencrypted '1Password Interchange Format' file or directory or another supported format",
+		ArgNames:    []string{"path"},
+		ExtraHelp:   importHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "format", ArgName: "format", Description: "Format of the file to import. Defaults to '1pif'"},
+			{Name: "update-existing", Description: "Merge imported items into existing items with the same ID, or the same type and title, instead of adding them"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "logins.1pif", Description: "Import items from the 'logins.1pif' export directory"},
+			{Args: "--format 1password-csv export.csv", Description: "Import items from a CSV file exported by 1Password 8"},
+			{Args: "--format firefox ~/.mozilla/firefox/abcd1234.default", Description: "Import saved logins from a Firefox profile"},
+			{Args: "--format bookmarks bookmarks.html", Description: "Create Logins for bookmarked websites, to fill in later"},
+			{Args: "--update-existing logins.1pif", Description: "Update items in the vault from an export of another copy of it"},
+		},
+	},
+	{
+		Command:     "import-csv",
+		Description: "Import logins from a CSV file exported by a web browser or another password manager",
+		ArgNames:    []string{"path"},
+		ExtraHelp:   importCsvHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "mapping", ArgName: "path", Description: "YAML file specifying the columns to import"},
+			{Name: "title", ArgName: "column", Description: "Column containing the item title"},
+			{Name: "url", ArgName: "column", Description: "Column containing the website"},
+			{Name: "username", ArgName: "column", Description: "Column containing the username"},
+			{Name: "password", ArgName: "column", Description: "Column containing the password"},
+			{Name: "notes", ArgName: "column", Description: "Column containing notes"},
+			{Name: "tags", ArgName: "column", Description: "Column containing comma-separated tags"},
+			{Name: "otp", ArgName: "column", Description: "Column containing a one-time password secret or URI"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "passwords.csv", Description: "Import logins, finding columns from the CSV file's header"},
+			{Args: "--title Name --url 'Login URI' logins.csv", Description: "Import logins using the 'Name' and 'Login URI' columns for the title and website"},
+			{Args: "--mapping keepass.yaml export.csv", Description: "Import logins using the columns listed in 'keepass.yaml'"},
+		},
+	},
+	{
+		Command:     "import-lastpass",
+		Description: "Import items from a CSV file exported by LastPass",
+		ArgNames:    []string{"path"},
+		ExtraHelp:   importLastpassHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "lastpass_export.csv", Description: "Import logins, secure notes and folders exported from LastPass"},
+		},
+	},
+	{
+		Command:     "coverage",
+		Description: "List frequently visited websites from browser history which have no item in the vault",
+		ArgNames:    []string{"history"},
+		ExtraHelp:   coverageHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "min-visits", ArgName: "count", Description: "Only list sites visited at least this many times. Defaults to 5"},
+			{Name: "all", Description: "Include sites without a login page in the history"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "~/.mozilla/firefox/abcd1234.default/places.sqlite", Description: "Find sites from Firefox's history which are missing from the vault"},
+			{Args: "--min-visits 20 ~/.config/chromium/Default/History", Description: "Find sites visited at least 20 times in Chromium"},
+		},
+	},
+	{
+		Command:     "dump-structure",
+		Description: "Write the vault's folders and item tags to a YAML file",
+		ArgNames:    []string{"[path]"},
+		ExtraHelp:   dumpStructureHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "layout.yaml", Description: "Save the vault's folders and tags to 'layout.yaml'"},
+		},
+	},
+	{
+		Command:     "apply-structure",
+		Description: "Create folders and set item folders and tags from a 'dump-structure' file",
+		ArgNames:    []string{"path"},
+		ExtraHelp:   applyStructureHelp,
+	},
+	{
+		Command:     "retag",
+		Description: "Add tags to items and move them to folders according to a rules file",
+		ExtraHelp:   retagHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "rules", ArgName: "path", Description: "YAML file of rules to apply"},
+			{Name: "dry-run", Description: "List the changes which would be made without saving them"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "--rules rules.yaml --dry-run", Description: "Show which items would be changed by the rules in 'rules.yaml'"},
+		},
+	},
+	{
+		Command:     "respond-breach",
+		Description: "Replace the passwords of items for a breached website",
+		ArgNames:    []string{"domain"},
+		ExtraHelp:   respondBreachHelp,
+		Examples: []cmdmodes.Example{
+			{Args: "linkedin.com", Description: "Offer to rotate passwords for all items on linkedin.com"},
+		},
+	},
+	{
+		Command:     "set-password",
+		Description: "Change the master password for the vault",
+		ExtraHelp:   setPasswordHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "keyfile", ArgName: "path", Description: "Require a new key file in addition to the new master password to unlock the vault"},
+			{Name: "no-keyfile", Description: "Stop requiring a key file to unlock the vault"},
+			{Name: "force", Description: "Use the new master password even if it is weak"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "--keyfile ~/vault.key", Description: "Change the master password and require '~/vault.key' to unlock the vault"},
+		},
+	},
+	{
+		Command:     "upgrade-kdf",
+		Description: "Strengthen the encryption of the vault's keys with the master password",
+		ExtraHelp:   upgradeKdfHelp,
+		Flags:       upgradeKdfFlags,
+	},
+	{
+		Command:     "strengthen",
+		Description: "Alias for 'upgrade-kdf'",
+		ExtraHelp:   upgradeKdfHelp,
+		Flags:       upgradeKdfFlags,
+	},
+	{
+		Command:     "rotate-keys",
+		Description: "Replace the vault's encryption keys and re-encrypt all items",
+		ExtraHelp:   rotateKeyHelp,
+	},
+	{
+		Command:     "rotate-key",
+		Description: "Alias for 'rotate-keys'",
+		Internal:    true,
+	},
+	{
+		Command:     "split-key",
+		Description: "Split the vault's master keys into share files for trustees",
+		ArgNames:    []string{"dir"},
+		ExtraHelp:   splitKeyHelp,
+		Flags: []cmdmodes.Flag{
+			{Name: "shares", ArgName: "count", Description: "Number of shares to create. Defaults to 5"},
+			{Name: "threshold", ArgName: "count", Description: "Number of shares needed to recover the keys. Defaults to 3"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "shares --shares 5 --threshold 3", Description: "Write 5 share files to 'shares/', any 3 of which can recover the vault"},
+		},
+	},
+	{
+		Command:     "recover-key",
+		Description: "Reset the master password using share files created by 'split-key'",
+		ArgNames:    []string{"share-file", "[share-file...]"},
+		Flags: []cmdmodes.Flag{
+			{Name: "force", Description: "Use the new master password even if it is weak"},
+		},
+		Examples: []cmdmodes.Example{
+			{Args: "share-1.json share-3.json share-4.json", Description: "Combine three shares and set a new master password"},
+		},
+	},
+	{
+		Command:     "agent-logs",
+		Description: "Display recent entries from the agent's log file",
+		Flags: []cmdmodes.Flag{
+			{Name: "lines", ArgName: "count", Description: "Number of entries to display. Defaults to 20"},
+		},
+	},
+	{
+		Command:     "help",
+		Description: "Display usage information",
+		ArgNames:    []string{"[command]"},
+		Flags: []cmdmodes.Flag{
+			{Name: "examples", Description: "Display examples of using the command"},
+			{Name: "json", Description: "Print a JSON description of all commands"},
+		},
+	},
+	{
+		Command:     "export-item-templates",
+		Description: "Create item templates from items matching the given pattern",
+		ArgNames:    []string{"pattern"},
+		Internal:    true,
+	},
+	{
+		Command:     "add-tag",
+		Description: "Add a tag to an item",
+		ArgNames:    []string{"pattern", "tag"},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+	},
+	{
+		Command:     "remove-tag",
+		Description: "Remove tags from an item",
+		ArgNames:    []string{"pattern", "tag"},
+		Flags: []cmdmodes.Flag{
+			{Name: "exclude", ArgName: "pattern", Description: "Exclude items matching <pattern>. May be repeated"},
+		},
+	},
+	{
+		Command:     "private-titles",
+		Description: "Store item titles and websites encrypted instead of in plaintext",
+		ArgNames:    []string{"on|off"},
+		ExtraHelp:   privateTitlesHelp,
+	},
+	{
+		Command:     "sign-items",
+		Description: "Sign item files so that changes made without the master password are detected",
+		ArgNames:    []string{"on|off"},
+		ExtraHelp:   signItemsHelp,
+	},
+	{
+		Command:     "private-tags",
+		Description: "Store item tags encrypted instead of in plaintext",
+		ArgNames:    []string{"on|off"},
+		ExtraHelp:   privateTagsHelp,
+	},
+	{
+		Command:     "private-usage",
+		Description: "Store item usage counts encrypted instead of in plaintext",
+		ArgNames:    []string{"on|off"},
+		ExtraHelp:   privateUsageHelp,
+	},
+	{
+		Command:     "keep-history",
+		Description: "Keep the previous version of an item whenever it is changed",
+		ArgNames:    []string{"on|off"},
+		ExtraHelp:   keepHistoryHelp,
+	},
+	{
+		Command:     "history",
+		Description: "List the previous versions of an item",
+		ArgNames:    []string{"pattern"},
+		ExtraHelp:   keepHistoryHelp,
+	},
+	{
+		Command:     "revert",
+		Description: "Restore a previous version of an item",
+		ArgNames:    []string{"pattern", "revision"},
+		Examples: []cmdmodes.Example{
+			{Args: "github 3", Description: "Restore revision 3 of the 'github' item, as listed by 'history'"},
+		},
+	},
+}
+
+type clientConfig struct {
+	VaultDir string
+
+	// Alternative vault paths which are tried in order if
+	// VaultDir does not exist, eg. for a vault which is synced
+	// to different folders on different computers
+	VaultDirs []string
+
+	// Map of name -> path for vaults which are selected using
+	// the '-vault-name' flag. See 'set-vault'
+	Vaults map[string]string
+
+	// Profile within the vault to use, for vaults which have
+	// several. Defaults to the 'default' profile or the vault's
+	// only profile. See 'set-profile'
+	Profile string
+
+	// Directories which are searched for vaults if no vault
+	// is configured or the configured vault has been moved.
+	// Defaults to ~/Dropbox, ~/Library/CloudStorage and
+	// the home directory
+	VaultSearchRoots []string
+
+	// Store item tags in the encrypted item content rather than
+	// in plaintext. See 'private-tags'
+	PrivateTags bool
+
+	// Normalize the websites of items when they are saved and
+	// optionally change 'http' websites to 'https'.
+	// See 'normalize-urls'
+	NormalizeUrls      bool
+	UpgradeUrlsToHttps bool
+
+	// Path of the agent's log file. Defaults to
+	// $XDG_STATE_HOME/1pass/agent.log
+	AgentLogFile string
+	// Minimum level of agent log entries to record:
+	// 'debug', 'info', 'warn' or 'error'
+	AgentLogLevel string
+	// Path of a separately installed '1pass-agent' binary to
+	// run instead of '1pass -agent'. A running agent started by
+	// another version of this binary is reused if it is compatible.
+	AgentBinary string
+
+	// Map of vault path or name -> period after which the
+	// agent locks the vault if it is not used, eg. '8h'. '*'
+	// sets the period for other vaults. Defaults to 2m.
+	// See 'lock'
+	UnlockDurations map[string]string
+
+	// Maximum time for which the vault stays unlocked after
+	// a command which reveals or exports secrets, eg. '30s'
+	SensitiveUnlockDuration string
+
+	// Path of the file of words from which 'gen-password --words'
+	// and recipes which set 'Words' choose passphrase words
+	Wordlist string
+
+	// Map of vault path or name -> recipe used by 'gen-password'
+	// and when '-' is entered for a new password. '*' sets the
+	// recipe for other vaults. See 'gen-password'
+	PasswordRecipes map[string]onepass.GenRecipe
+
+	// Number of days after which 'rotate-keys' and 'set-password'
+	// reminders are shown. Defaults to 365. Set to a negative
+	// number to disable the reminders.
+	RotationReminderDays int
+
+	// Item types, eg. 'outdoor', which are left out of the types
+	// listed by 'help add' and 'help list' and by 'help --json'.
+	// Items of these types can still be added and listed.
+	HiddenItemTypes []string
+
+	// Tool used to read and write the clipboard: 'auto' (the
+	// default), 'wl-clipboard', 'xclip', 'xsel', 'pbcopy',
+	// 'clip.exe' or 'osc52'. See 'help copy'
+	ClipboardBackend string
+
+	// Styles and date format used for the output of 'list',
+	// 'show', 'tree' and 'audit'. See 'help show'
+	Theme outputTheme
+
+	// Map of item type (eg. 'login') -> field name -> template
+	// used to fill in the field automatically when adding an
+	// item of that type. See 'add'
+	FieldTemplates map[string]map[string]onepass.FieldTemplate
+
+	// Local URL to which a JSON event is posted whenever an
+	// item is added, updated or removed or the vault's keys
+	// are changed. Events include the item's ID and type but
+	// not its title or content.
+	WebhookUrl string
+
+	// Names of enabled plugins, eg. 'notify' for a '1pass-notify'
+	// executable on $PATH. See 'list-plugins'
+	Plugins []string
+
+	// Language for prompts, help text and the titles of
+	// fields, eg. 'de'. Defaults to the language from $LANG
+	Language string
+
+	// Map of vault path + '|' + other vault path -> UNIX time
+	// when the vaults were last synced. See 'sync'
+	LastSyncTimes map[string]int64
+
+	// IDs of recently shown or copied items,
+	// most recent first
+	RecentItems []string
+
+	// Map of vault path -> item ID -> usage statistics
+	// for items in the vault. See 'list --sort'
+	ItemUsage map[string]map[string]itemUsage
+
+	// Map of vault path -> item usage statistics for vaults
+	// where 'private-usage' is enabled, encrypted with the
+	// vault's keys
+	EncryptedItemUsage map[string][]byte
+
+	// Map of vault path -> true for vaults whose item usage
+	// statistics are stored encrypted. See 'private-usage'
+	PrivateUsage map[string]bool
+
+	// Map of vault path -> true for vaults where 'sign-items' was
+	// turned on. Signatures are checked for these vaults even if
+	// signing is turned off in the vault's synced options file.
+	SignedVaults map[string]bool
+}
+
+// pattern which refers to the most recently
+// shown or copied item
+const lastItemPattern = "last"
+
+// maximum number of entries stored in the
+// recently used items list
+const maxRecentItems = 20
+
+var configPath = os.Getenv("HOME") + "/.1pass"
+
+// displays a prompt and reads a line of input
+func readLinePrompt(prompt string, args ...interface{}) string {
+	fmt.Printf(fmt.Sprintf("%s: ", prompt), args...)
+	return readLine()
+}
+
+// reads a line of input from stdin
+func readLine() string {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return scanner.Text()
+}
+
+func readConfig() clientConfig {
+	var config clientConfig
+	_ = jsonutil.ReadFile(configPath, &config)
+	return config
+}
+
+func writeConfig(config *clientConfig) {
+	_ = jsonutil.WriteFile(configPath, config)
+}
+
+// vaultSettingKeys returns the keys under which settings for the
+// vault at vaultPath are looked up in maps such as UnlockDurations,
+// in order of preference: the vault's path, its names from
+// 'set-vault --name' and '*'
+func vaultSettingKeys(config clientConfig, vaultPath string) []string {
+	keys := []string{vaultPath}
+	for name, path := range config.Vaults {
+		if path == vaultPath {
+			keys = append(keys, name)
+		}
+	}
+	return append(keys, "*")
+}
+
+func logItemAction(action string, item onepass.Item) {
+	fmt.Printf("%s '%s' (%s)\n", locale.T(action), item.Title, item.Uuid[0:4])
+}
+
+// genPassword generates a random password using the agent. purpose
+// describes what the password is for, for the agent's log.
+func genPassword(recipe string, length int, purpose string) string {
+	agentClient := connectToAgent(readConfig(), "")
+	password, err := agentClient.GenPassword(recipe, length, purpose)
+	if err != nil {
+		fatalErr(err, "Unable to generate password")
+	}
+	return password
+}
+
+// genDefaultPassword generates a password using the recipe from the
+// 'PasswordRecipes' setting for the current vault or the 'default'
+// recipe, adapted to the rules in genPasswordPolicy if set
+func genDefaultPassword(purpose string) string {
+	if genPasswordPolicy != nil {
+		return genPasswordForPolicy(*genPasswordPolicy, purpose)
+	}
+	if recipe, ok := configuredGenRecipe(readConfig(), recipeVaultPath); ok {
+		return genPasswordWithRecipe(recipe, 0, purpose)
+	}
+	return genPassword("default", 0, purpose)
+}
+
+type listOptions struct {
+	// list only recently used items
+	recent bool
+	// order in which to list items, one of 'title' (the default),
+	// 'frequency' or 'frecency'
+	sortOrder string
+	// list only Software License items which have expired
+	// or are about to expire
+	expiring bool
+	// include the username, website and summary
+	// field of each item
+	details bool
+	// list only items whose content matches these filters
+	fieldFilters []fieldFilter
+	// list the types of matching items with the
+	// number of items of each type
+	types bool
+}
+
+func listMatchingItems(vault *onepass.Vault, selection itemSelection, opts listOptions) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to list vault items: %v\n", err)
+		os.Exit(1)
+	}
+
+	printItems := printItemList
+	if opts.details {
+		printItems = func(items []onepass.Item) {
+			printItemDetails(vault, items)
+		}
+	}
+
+	if len(opts.fieldFilters) > 0 {
+		items = filterItemsByField(vault, items, opts.fieldFilters)
+	}
+
+	if opts.types {
+		listItemTypes(items)
+		return
+	}
+	if opts.recent {
+		printItems(recentItems(items))
+		return
+	}
+	if opts.expiring {
+		listExpiringLicenses(items)
+		return
+	}
+
+	switch opts.sortOrder {
+	case "", "title":
+		sortItemsByTitle(items)
+		printItems(items)
+	case "frequency", "frecency":
+		sortItemsByUsage(vault, items, opts.sortOrder == "frecency")
+		printItems(items)
+	default:
+		fatalErr(fmt.Errorf("Unknown sort order '%s'", opts.sortOrder), "")
+	}
+}
+
+// returns the entries from items which appear in the recently
+// used items list, ordered from most to least recently used
+func recentItems(items []onepass.Item) []onepass.Item {
+	recent := []onepass.Item{}
+	for _, uuid := range readConfig().RecentItems {
+		index := rangeutil.IndexIn(0, len(items), func(i int) bool {
+			return items[i].Uuid == uuid
+		})
+		if index != -1 {
+			recent = append(recent, items[index])
+		}
+	}
+	return recent
+}
+
+func sortItemsByTitle(items []onepass.Item) {
+	rangeutil.Sort(0, len(items), func(i, k int) bool {
+		return strings.ToLower(items[i].Title) < strings.ToLower(items[k].Title)
+	},
+		func(i, k int) {
+			items[i], items[k] = items[k], items[i]
+		})
+}
+
+func listItems(vault *onepass.Vault, items []onepass.Item) {
+	sortItemsByTitle(items)
+	printItemList(items)
+}
+
+func printItemList(items []onepass.Item) {
+	theme := currentTheme()
+	for _, item := range items {
+		fmt.Printf("%s%s%s\n", theme.itemSummary(item), theme.trashState(item),
+			expiryState(item, time.Now()))
+	}
+}
+
+// printItemDetails lists items along with the username, primary
+// website and summary field from their decrypted content. See
+// 'set-summary'
+func printItemDetails(vault *onepass.Vault, items []onepass.Item) {
+	contents, err := vault.ItemContents(items)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for i, item := range items {
+		location := item.Location
+		if location == "" {
+			location = contents[i].PrimaryURL()
+		}
+		fmt.Fprintf(out, "%s (%s, %s)\t%s\t%s\t%s\n", item.Title, item.Type(), item.Uuid[0:4],
+			contents[i].Username(), location, contents[i].SummaryValue(item.OpenContents.SummaryField))
+	}
+	out.Flush()
+}
+
+func listFolder(vault *onepass.Vault, pattern string) {
+	pattern = "folder:" + pattern
+	folder, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find folder")
+	}
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Failed to list items")
+	}
+	itemsInFolder := []onepass.Item{}
+	for _, item := range items {
+		if item.FolderUuid == folder.Uuid {
+			itemsInFolder = append(itemsInFolder, item)
+		}
+	}
+	listItems(vault, itemsInFolder)
+}
+
+func prettyJson(src []byte) []byte {
+	var buffer bytes.Buffer
+	json.Indent(&buffer, src, "", "  ")
+	return buffer.Bytes()
+}
+
+func showItems(vault *onepass.Vault, selection itemSelection, asJson bool, showHistory bool, renderNotes bool) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintf(os.Stderr, "No matching items\n")
+	}
+
+	for i, item := range items {
+		if i > 0 {
+			fmt.Println()
+		}
+		if asJson {
+			showItemJson(item)
+		} else {
+			showItem(vault, item, showHistory, renderNotes)
+		}
+		recordItemUse(vault, item)
+	}
+}
+
+func showItem(vault *onepass.Vault, item onepass.Item, showHistory bool, renderNotes bool) {
+	typeName := item.TypeName
+	itemType, ok := onepass.ItemTypes[item.TypeName]
+	if ok {
+		typeName = itemType.Name
+	}
+
+	theme := currentTheme()
+	fmt.Printf("%s %s%s\n", theme.title(item.Title), theme.detail("("+typeName+")"), theme.trashState(item))
+	fmt.Printf("%s\n", theme.label("Info:"))
+	fmt.Printf("  %s %s\n", theme.label("ID:"), item.Uuid)
+
+	updateTime := int64(item.UpdatedAt)
+	if updateTime == 0 {
+		updateTime = int64(item.CreatedAt)
+	}
+	fmt.Printf("  %s %s\n", theme.label("Updated:"), theme.formatDate(time.Unix(updateTime, 0)))
+
+	if len(item.FolderUuid) > 0 {
+		folder, err := vault.LoadItem(item.FolderUuid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Item folder '%s' not found", item.FolderUuid)
+			// continue
+		}
+		fmt.Printf("  %s %s\n", theme.label("Folder:"), folder.Title)
+	}
+
+	tags, err := item.Tags(readConfig().PrivateTags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read item tags: %s: %v", item.Title, err)
+	}
+	if len(tags) > 0 {
+		fmt.Printf("  %s %s\n", theme.label("Tags:"), strings.Join(tags, ", "))
+	}
+
+	fmt.Println()
+
+	content, err := item.Content()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to decrypt item: %s: %v", item.Title, err)
+		return
+	}
+	details := themedContent(localizedContent(content), theme).String()
+	fmt.Print(details)
+	if content.Notes != "" {
+		if details != "" {
+			fmt.Println()
+		}
+		fmt.Printf("%s\n", theme.label("Notes:"))
+		if renderNotes {
+			fmt.Print(newTerminalMarkdownRenderer().render(content.Notes))
+		} else {
+			fmt.Println(strings.TrimRight(content.Notes, "\n"))
+		}
+	}
+	if showHistory {
+		printPasswordHistory(content, theme)
+	}
+}
+
+// printPasswordHistory lists an item's previous
+// passwords, most recent first
+func printPasswordHistory(content onepass.ItemContent, theme outputTheme) {
+	fmt.Printf("\n%s\n", theme.label("Password History:"))
+	if len(content.PasswordHistory) == 0 {
+		fmt.Printf("  No previous passwords\n")
+	}
+	for i := len(content.PasswordHistory) - 1; i >= 0; i-- {
+		entry := content.PasswordHistory[i]
+		fmt.Printf("  %s: %s\n", theme.formatDate(time.Unix(entry.Time, 0)), entry.Value)
+	}
+}
+
+func showItemJson(item onepass.Item) {
+	fmt.Printf("%s: %s: %s\n", item.Title, item.Uuid, item.ContentsHash)
+	decrypted, err := item.ContentJson()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to decrypt item: %s: %v", item.Title, err)
+		return
+	}
+	fmt.Println(string(prettyJson([]byte(decrypted))))
+}
+
+func readFieldValue(field onepass.ItemField) interface{} {
+	var newValue interface{}
+	for newValue == nil {
+		var valueStr string
+		kind := field.Kind
+		if field.IsOTP() {
+			kind = "otp"
+			valueStr = readLinePrompt("%s (otpauth:// URI or secret)", locale.T(field.Title))
+		} else if field.Kind == "concealed" {
+			valueStr, _ = readNewPassword(locale.T(field.Title))
+		} else if field.Kind == "address" {
+			newValue = onepass.ItemAddress{
+				Street:  readLinePrompt(locale.T("Street")),
+				City:    readLinePrompt(locale.T("City")),
+				Zip:     readLinePrompt(locale.T("Zip")),
+				State:   readLinePrompt(locale.T("State")),
+				Country: readLinePrompt(locale.T("Country")),
+			}
+		} else {
+			valueStr = readLinePrompt("%s (%s)", locale.T(field.Title), field.Kind)
+		}
+		if len(valueStr) == 0 {
+			break
+		}
+		if newValue == nil {
+			var err error
+			newValue, err = onepass.FieldValueFromString(kind, valueStr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+			}
+		}
+	}
+	return newValue
+}
+
+func readFormFieldValue(field onepass.WebFormField) string {
+	var newValue string
+	if field.Type == "P" {
+		for {
+			var err error
+			newValue, err = readNewPassword(locale.T(field.Name))
+			if err == nil {
+				break
+			}
+		}
+	} else {
+		newValue = readLinePrompt("%s (%s)", locale.T(field.Name), field.Type)
+	}
+	return newValue
+}
+
+func addItemHelp() string {
+	return itemTypesHelp() + `
+
+Fields can be filled in automatically instead of prompting for their
+values by adding templates for them to the 'FieldTemplates' setting in
+~/.1pass, keyed by item type and then by field name or title. A template
+either specifies a default value, which may reference environment
+variables, or a generator for a random password using a recipe from
+'gen-password' and an optional length:
+
+  "FieldTemplates": {
+    "login": {
+      "username": {"default": "$USER"},
+      "password": {"generate": "password:20"}
+    },
+    "server": {
+      "admin password": {"generate": "symbols:24"}
+    }
+  }`
+}
+
+// templateFieldValue returns the value for a field of a new item
+// from the first template in templates matching one of names, or
+// false if there is no template and the user should be prompted
+func templateFieldValue(templates map[string]onepass.FieldTemplate, title string, names ...string) (string, bool) {
+	template, ok := onepass.FindFieldTemplate(templates, names...)
+	if !ok {
+		return "", false
+	}
+	if template.Generate != "" {
+		recipe, length, err := onepass.ParseGenerator(template.Generate)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Invalid template for '%s'", title))
+		}
+		fmt.Printf("%s: %s\n", title, locale.T("(Random new password generated)"))
+		return genPassword(recipe, length, title), true
+	}
+	value := template.DefaultValue()
+	fmt.Printf("%s: %s\n", title, value)
+	return value, true
+}
+
+// addItem adds an item, prompting for the values of its fields. If
+// site is set, the URL and login form field names of a new Login are
+// taken from it instead.
+func addItem(vault *onepass.Vault, title string, shortTypeName string, site *siteMetadata) {
+	itemContent := onepass.ItemContent{}
+	var typeName string
+	for typeKey, itemType := range onepass.ItemTypes {
+		if itemType.ShortAlias == shortTypeName {
+			itemContent = onepass.ItemContent{}
+			typeName = typeKey
+		}
+	}
+	if len(typeName) == 0 {
+		fatalErr(fmt.Errorf("Unknown item type '%s'", shortTypeName), "")
+	}
+
+	template, ok := onepass.StandardTemplate(typeName)
+	if !ok {
+		fatalErr(fmt.Errorf("No template for item type '%s'", shortTypeName), "")
+	}
+	fieldTemplates := readConfig().FieldTemplates[shortTypeName]
+
+	// read sections
+	for _, sectionTemplate := range template.Sections {
+		section := onepass.ItemSection{
+			Name:   sectionTemplate.Name,
+			Title:  sectionTemplate.Title,
+			Fields: []onepass.ItemField{},
+		}
+		for _, fieldTemplate := range sectionTemplate.Fields {
+			field := onepass.ItemField{
+				Name:  fieldTemplate.Name,
+				Title: fieldTemplate.Title,
+				Kind:  fieldTemplate.Kind,
+			}
+			if value, ok := templateFieldValue(fieldTemplates, locale.T(field.Title), field.Name, field.Title); ok {
+				var err error
+				field.Value, err = onepass.FieldValueFromString(field.Kind, value)
+				if err != nil {
+					fatalErr(err, fmt.Sprintf("Invalid value for '%s'", field.Title))
+				}
+			} else {
+				field.Value = readFieldValue(field)
+			}
+
+			section.Fields = append(section.Fields, field)
+		}
+		itemContent.Sections = append(itemContent.Sections, section)
+	}
+
+	// read form fields
+	for _, formFieldTemplate := range template.FormFields {
+		field := onepass.WebFormField{
+			Name:        formFieldTemplate.Name,
+			Id:          formFieldTemplate.Id,
+			Type:        formFieldTemplate.Type,
+			Designation: formFieldTemplate.Designation,
+		}
+		if value, ok := templateFieldValue(fieldTemplates, locale.T(field.Name), field.Name, field.Designation); ok {
+			field.Value = value
+		} else {
+			field.Value = readFormFieldValue(field)
+		}
+		if site != nil {
+			if name, id, ok := loginFormFieldFromSite(site, field.Designation); ok {
+				field.Name = name
+				field.Id = id
+			}
+		}
+
+		itemContent.FormFields = append(itemContent.FormFields, field)
+	}
+
+	// read URLs
+	for _, urlTemplate := range template.Urls {
+		url := onepass.ItemUrl{
+			Label: urlTemplate.Label,
+		}
+		if site != nil {
+			url.Url = site.Url
+		} else if value, ok := templateFieldValue(fieldTemplates, locale.T(url.Label), url.Label); ok {
+			url.Url = value
+		} else {
+			url.Url = readLinePrompt("%s (URL)", locale.T(url.Label))
+		}
+		itemContent.Urls = append(itemContent.Urls, url)
+	}
+
+	event := newItemHookEvent(vault, onepass.Item{Title: title, TypeName: typeName})
+	event.Content = &itemContent
+	runHooks("before-add", event)
+
+	// save item to vault
+	item, err := vault.AddItem(title, typeName, itemContent)
+	if err != nil {
+		fatalErr(err, "Unable to add item")
+	}
+	logItemAction("Added new item", item)
+
+	event.Item.Uuid = item.Uuid
+	runHooks("after-add", event)
+}
+
+// editItemJson opens the decrypted JSON content of an item in the
+// user's editor and saves the result if it is valid for the item's type
+func editItemJson(vault *onepass.Vault, pattern string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item")
+	}
+	content, err := item.ContentJson()
+	if err != nil {
+		fatalErr(err, "Unable to read item content")
+	}
+	previous, err := item.Content()
+	if err != nil {
+		fatalErr(err, "Unable to read item content")
+	}
+
+	tempFile, err := securetmp.CreateFile("edit-*.json")
+	if err != nil {
+		fatalErr(err, "Unable to create temporary file")
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = tempFile.Write(prettyJson([]byte(content)))
+	tempFile.Close()
+	if err != nil {
+		fatalErr(err, "Unable to write temporary file")
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	for {
+		editorCmd := exec.Command(editor, tempFile.Name())
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+		err = editorCmd.Run()
+		if err != nil {
+			fatalErr(err, "Failed to run editor")
+		}
+		newContent, err := ioutil.ReadFile(tempFile.Name())
+		if err != nil {
+			fatalErr(err, "Unable to read edited content")
+		}
+		if bytes.Equal(bytes.TrimSpace(newContent), bytes.TrimSpace(prettyJson([]byte(content)))) {
+			fmt.Printf("Item not changed\n")
+			return
+		}
+		err = item.SetContentJson(string(newContent))
+		if err == nil {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "%v\nEdit again? Y/N\n", err)
+		if !readConfirmation() {
+			os.Exit(1)
+		}
+	}
+
+	// keep the item's location in sync with its website,
+	// as SetContent() does
+	if newContent, err := item.Content(); err == nil {
+		for _, url := range newContent.Urls {
+			if url.Label == "website" {
+				item.Location = url.Url
+			}
+		}
+		historyLen := len(newContent.PasswordHistory)
+		newContent.RecordPasswordChanges(previous, time.Now())
+		if len(newContent.PasswordHistory) > historyLen {
+			err = item.SetContent(newContent)
+			if err != nil {
+				fatalErr(err, "Unable to save password history")
+			}
+		}
+	}
+
+	event := newItemHookEvent(vault, item)
+	if newContent, err := item.Content(); err == nil {
+		event.Content = &newContent
+	}
+	runHooks("before-edit", event)
+
+	logItemAction("Editing item", item)
+	err = item.Save()
+	if err != nil {
+		fatalErr(err, "Unable to save item")
+	}
+	runHooks("after-edit", event)
+}
+
+func editItem(vault *onepass.Vault, pattern string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item")
+	}
+
+	logItemAction("Editing item", item)
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, "Unable to read item content")
+	}
+	previous, err := item.Content()
+	if err != nil {
+		fatalErr(err, "Unable to read item content")
+	}
+	genPasswordPolicy = itemPasswordPolicy(item, content)
+
+	formSectionId := len(content.Sections) + 1
+	urlSectionId := len(content.Sections) + 2
+
+	for i, section := range content.Sections {
+		fmt.Printf("%d : %s\n", i+1, section.Title)
+	}
+	fmt.Printf("%d : Web Form fields\n", formSectionId)
+	fmt.Printf("%d : URLs\n", urlSectionId)
+
+	var section *onepass.ItemSection
+	var field *onepass.ItemField
+
+	sectionIdStr := readLinePrompt("Section (or title of new section)")
+	sectionId, err := strconv.Atoi(sectionIdStr)
+	if err != nil {
+		// new section
+		content.Sections = append(content.Sections, onepass.ItemSection{
+			Name:   sectionIdStr,
+			Title:  sectionIdStr,
+			Fields: []onepass.ItemField{},
+		})
+		section = &content.Sections[len(content.Sections)-1]
+	} else if sectionId > 0 && sectionId <= len(content.Sections) {
+		section = &content.Sections[sectionId-1]
+	} else if sectionId != formSectionId && sectionId != urlSectionId {
+		fatalErr(nil, "Unknown section number")
+	}
+
+	if section != nil {
+		for i, field := range section.Fields {
+			fmt.Printf("%d : %s (%s)\n", i+1, field.Title, field.ValueString())
+		}
+		fieldIdStr := readLinePrompt("Field (or title of new field)")
+		fieldId, err := strconv.Atoi(fieldIdStr)
+		if isOTPFieldTitle(fieldIdStr) {
+			// new one-time password field, named as by
+			// the official 1Password apps
+			section.Fields = append(section.Fields, onepass.NewOtpField(""))
+			field = &section.Fields[len(section.Fields)-1]
+		} else if err != nil {
+			// new field
+			section.Fields = append(section.Fields, onepass.ItemField{
+				Name:  fieldIdStr,
+				Kind:  "string",
+				Title: fieldIdStr,
+			})
+			field = &section.Fields[len(section.Fields)-1]
+		} else if fieldId > 0 && fieldId <= len(section.Fields) {
+			field = &section.Fields[fieldId-1]
+		} else {
+			fatalErr(nil, "Unknown field number")
+		}
+		field.Value = readFieldValue(*field)
+
+	} else if sectionId == formSectionId {
+		for i, field := range content.FormFields {
+			fmt.Printf("%d : %s (%s)\n", i+1, field.Name, field.Value)
+		}
+		fieldIdStr := readLinePrompt("Field")
+		fieldId, err := strconv.Atoi(fieldIdStr)
+		if err == nil && fieldId > 0 && fieldId <= len(content.FormFields) {
+			content.FormFields[fieldId-1].Value = readFormFieldValue(content.FormFields[fieldId-1])
+		} else {
+			fatalErr(nil, "Unknown field number")
+		}
+	} else if sectionId == urlSectionId {
+		for i, url := range content.Urls {
+			fmt.Printf("%d : %s (%s)\n", i+1, url.Label, url.Url)
+		}
+		var url *onepass.ItemUrl
+		urlIdStr := readLinePrompt("URL (or label of new URL)")
+		urlId, err := strconv.Atoi(urlIdStr)
+		if err != nil {
+			// new URL
+			content.Urls = append(content.Urls, onepass.ItemUrl{
+				Label: urlIdStr,
+			})
+			url = &content.Urls[len(content.Urls)-1]
+		} else if urlId > 0 && urlId <= len(content.Urls) {
+			url = &content.Urls[urlId-1]
+		} else {
+			fatalErr(nil, "Unknown URL number")
+		}
+
+		url.Url = readLinePrompt("%s", url.Label)
+	}
+	content.RecordPasswordChanges(previous, time.Now())
+
+	event := newItemHookEvent(vault, item)
+	event.Content = &content
+	runHooks("before-edit", event)
+
+	err = item.SetContent(content)
+	if err != nil {
+		fatalErr(err, "Unable to save updated content")
+	}
+	err = item.Save()
+	if err != nil {
+		fatalErr(err, "Unable to save updated item")
+	}
+	runHooks("after-edit", event)
+}
+
+func listHelp() string {
+	result := `[pattern] is an optional pattern which can match
+part of an item's title, part of an item's ID or the type of item.
+
+You can also specify both an item type and a title/ID pattern
+using '<item type>:<pattern>'.
+
+A title pattern containing '*' or '?' must match the whole title,
+where '*' matches any text and '?' matches any single character.
+For example 'git*' matches titles starting with 'git'.
+
+The pattern 'last' refers to the item which was most recently
+shown or copied. An alias set with 'set-alias' refers to that item
+alone.
+
+The output can be styled with the 'Theme' setting, see 'help show'.
+
+Commands which accept several patterns select items matching any
+of them. Items matching an '--exclude' pattern are left out.
+
+'--field' filters items by the value of a field, ignoring case.
+The filter has the form '<key>=<field>:<value>' where <key> is
+'designation' or 'name' to match login form fields, for example
+'designation=username:alice@example.com', or 'name' or 'title' to
+match other fields.
+
+'--types' lists the types of the matching items and the number of
+items of each type instead of the items themselves.
+
+'--sort frequency' lists the items which have been shown or copied
+most often first. '--sort frecency' also favors items which have been
+used recently. See 'help private-usage' for how usage counts are stored.
+
+`
+
+	result += itemTypesHelp()
+	return result
+}
+
+func itemTypesHelp() string {
+	typeAliases := map[string]onepass.ItemType{}
+	sortedAliases := []string{}
+	hidden := hiddenItemTypes(readConfig())
+	for code, itemType := range onepass.ItemTypes {
+		if code == "system.Tombstone" || hidden[code] {
+			continue
+		}
+		typeAliases[itemType.ShortAlias] = itemType
+		sortedAliases = append(sortedAliases, itemType.ShortAlias)
+	}
+	sort.Strings(sortedAliases)
+
+	result := locale.T("Item Types:") + "\n\n"
+	for i, alias := range sortedAliases {
+		if i > 0 {
+			result += "\n"
+		}
+		result = result + fmt.Sprintf("  %s - %s", alias, locale.T(typeAliases[alias].Name))
+	}
+	if len(hidden) > 0 {
+		result += "\n\n" + fmt.Sprintf(locale.T("(%d types hidden by the 'HiddenItemTypes' setting)"), len(hidden))
+	}
+	return result
+}
+
+func copyItemHelp() string {
+	return `[field] specifies a pattern for the name of the field, form field or URL
+to copy. If omitted, defaults to 'password'.
+
+[field] patterns are matched against the field names in
+the same way that item name patterns are matched against item titles.
+
+The 'password', 'username' and 'url' fields copy the item's main
+password, username or website, wherever it is stored for the item's
+type. The 'otp' field copies the current one-time password generated
+from the item's one-time password field, see 'totp'.
+
+The clipboard is accessed with wl-copy on Wayland, xclip or xsel on
+X11, pbcopy on macOS and clip.exe on Windows. Where none of these are
+available, such as over SSH, text is copied to the clipboard of the
+local terminal with the OSC 52 escape sequence, which is passed through
+tmux if its 'allow-passthrough' option is on. The 'ClipboardBackend'
+setting in ~/.1pass selects one of 'wl-clipboard', 'xclip', 'xsel',
+'pbcopy', 'clip.exe' or 'osc52' instead.`
+}
+
+// fields which 'copy' looks up using the typed ItemContent accessors
+// before matching against field names
+var copyFieldAccessors = map[string]func(*onepass.ItemContent) string{
+	"password": (*onepass.ItemContent).Password,
+	"username": (*onepass.ItemContent).Username,
+	"url":      (*onepass.ItemContent).PrimaryURL,
+	"notes":    func(content *onepass.ItemContent) string { return content.Notes },
+}
+
+func sshHelp() string {
+	return `The server's URL and username fields determine the host to connect to.
+The stored password is supplied to ssh if the server asks for one.
+
+Arguments following '--' are passed to ssh as the command to run on
+the server.`
+}
+
+// Returns the type code associated with a given alias.
+// eg. 'folder' => 'system.Folder'.
+// Returns an empty string if the given alias does not
+// correspond to any known item type
+func typeFromAlias(alias string) string {
+	for key, itemType := range onepass.ItemTypes {
+		if itemType.ShortAlias == alias {
+			return key
+		}
+		for _, extraAlias := range itemType.Aliases {
+			if extraAlias == alias {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+func lookupItems(vault *onepass.Vault, pattern string) ([]onepass.Item, error) {
+	return selectItems(vault, itemSelection{patterns: []string{pattern}})
+}
+
+// read a response to a yes/no question from stdin
+func readConfirmation() bool {
+	var response string
+	count, err := fmt.Scanln(&response)
+	return err == nil && count > 0 && strings.ToLower(response) == "y"
+}
+
+func fatalErr(err error, context string) {
+	if err == nil {
+		err = fmt.Errorf("")
+	}
+	if context == "" {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", context, err)
+	}
+	os.Exit(1)
+}
+
+func readNewPassword(passType string) (string, error) {
+	fmt.Printf(locale.T("%s (or '-' for a random new %s)")+": ", passType, passType)
+	pwd, _ := terminal.ReadPassword(0)
+	if len(pwd) == 0 {
+		fmt.Println()
+		return "", nil
+	}
+	if string(pwd) == "-" {
+		pwd = []byte(genDefaultPassword(passType))
+		fmt.Print(locale.T("(Random new password generated)"))
+	} else {
+		fmt.Printf("\n"+locale.T("Re-enter %s")+": ", passType)
+		pwd2, _ := terminal.ReadPassword(0)
+		if string(pwd) != string(pwd2) {
+			return "", errors.New(locale.T("Passwords do not match"))
+		}
+		fmt.Println()
+		warnIfWeakPassword(string(pwd))
+		if genPasswordPolicy != nil {
+			if err := genPasswordPolicy.Check(string(pwd)); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v. Use '-' to generate a password which meets the site's rules.\n", err)
+			}
+		}
+		return string(pwd), nil
+	}
+	fmt.Println()
+	return string(pwd), nil
+}
+
+func createNewVault(path string, lowSecurity bool, security onepass.VaultSecurity, force bool) {
+	if !strings.HasSuffix(path, ".agilekeychain") {
+		path += ".agilekeychain"
+	}
+	fmt.Printf("Creating new vault in %s\n", path)
+	fmt.Printf("%s: ", locale.T("Enter master password"))
+	masterPwd, err := terminal.ReadPassword(0)
+	fmt.Printf("\n%s: ", locale.T("Re-enter master password"))
+	masterPwd2, _ := terminal.ReadPassword(0)
+	if !bytes.Equal(masterPwd, masterPwd2) {
+		fatalErr(nil, locale.T("Passwords do not match"))
+	}
+	fmt.Println()
+	checkMasterPasswordStrength(masterPwd, path, force)
+
+	security.MasterPwd = string(masterPwd)
+	if lowSecurity {
+		// use fewer PBKDF2 iterations or cheaper KDF parameters
+		// to speed up master key decryption
+		security.Iterations = 10
+		switch security.Kdf {
+		case onepass.KdfScrypt:
+			security.KdfParams = &onepass.KdfParams{N: 1024, R: 8, P: 1}
+		case onepass.KdfArgon2id:
+			security.KdfParams = &onepass.KdfParams{Time: 1, Memory: 1024, Threads: 1}
+		}
+	}
+
+	_, err = onepass.NewVault(path, security)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create new vault: %v", err)
+	}
+}
+
+func setPassword(vault *onepass.Vault, currentPwd []byte, newKeyFile string, force bool) {
+	// TODO - Prompt for hint and save that to the .password.hint file
+	fmt.Printf("%s: ", locale.T("New master password"))
+	newPwd, err := terminal.ReadPassword(0)
+	fmt.Printf("\n%s: ", locale.T("Re-enter new master password"))
+	newPwd2, err := terminal.ReadPassword(0)
+	fmt.Println()
+	if !bytes.Equal(newPwd, newPwd2) {
+		fatalErr(nil, locale.T("Passwords do not match"))
+	}
+	onepass.WipeBytes(newPwd2)
+	checkMasterPasswordStrength(newPwd, vault.Path, force)
+	combinedPwd := withKeyFile(newPwd, newKeyFile)
+	err = vault.SetMasterPassword(currentPwd, combinedPwd)
+	onepass.WipeBytes(combinedPwd)
+	onepass.WipeBytes(newPwd)
+	if err != nil {
+		fatalErr(err, "Failed to change master password")
+	}
+
+	fmt.Printf("The master password has been updated.\n\n")
+	fmt.Printf(setPasswordSyncNote)
+}
+
+const setPasswordSyncNote = `Note that after changing the password,
+other 1Password apps may still expect the old password until
+you unlock the vault with them and your new password is synced.
+`
+
+func genPasswordHelp() string {
+	names := []string{}
+	for name := range onepass.GenRecipes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := "Passwords are generated by the 1pass agent. Available recipes are:\n\n"
+	for _, name := range names {
+		result += fmt.Sprintf("  %-10s %s\n", name, onepass.GenRecipes[name].Description)
+	}
+	return result + "\n" + genRecipeHelp() + "\n\n" + analyzePasswordHelp()
+}
+
+func setPasswordHelp() string {
+	return masterPasswordStrengthHelp() + "\n\n" + setPasswordSyncNote
+}
+
+func moveItemsToFolder(vault *onepass.Vault, selection itemSelection, folderPattern string) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items to move")
+	}
+	folderPattern = "folder:" + folderPattern
+
+	var folder onepass.Item
+	if len(folderPattern) > 0 {
+		folder, err = lookupSingleItem(vault, folderPattern)
+	}
+	for _, item := range items {
+		logItemAction("Moving item", item)
+		item.FolderUuid = folder.Uuid
+		err = item.Save()
+		if err != nil {
+			fatalErr(err, "Failed to move item to folder")
+		}
+	}
+}
+
+func removeItems(vault *onepass.Vault, selection itemSelection) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items to remove")
+	}
+
+	for _, item := range items {
+		if !vault.DryRun {
+			fmt.Printf("Remove '%s' from vault? This cannot be undone. Y/N\n", item.Title)
+		}
+		if vault.DryRun || readConfirmation() {
+			err = item.Remove()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to remove item: %s\n", err)
+			}
+		}
+	}
+}
+
+func trashItems(vault *onepass.Vault, selection itemSelection) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items to trash")
+	}
+	for _, item := range items {
+		logItemAction("Trashing item", item)
+		item.Trashed = true
+		err = item.Save()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to trash item: %s\n", err)
+		}
+	}
+}
+
+func restoreItems(vault *onepass.Vault, selection itemSelection) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items to restore")
+	}
+	for _, item := range items {
+		logItemAction("Restoring item", item)
+		item.Trashed = false
+		err = item.Save()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to restore item: %s\n", err)
+		}
+	}
+}
+
+func lookupSingleItem(vault *onepass.Vault, pattern string) (onepass.Item, error) {
+	return selectSingleItem(vault, itemSelection{patterns: []string{pattern}})
+}
+
+func selectSingleItem(vault *onepass.Vault, selection itemSelection) (onepass.Item, error) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+
+	if len(items) == 0 {
+		return onepass.Item{}, fmt.Errorf("No matching items")
+	}
+
+	if len(items) > 1 {
+		fmt.Fprintf(os.Stderr, "Multiple matching items:\n")
+		for _, item := range items {
+			fmt.Fprintf(os.Stderr, "  %s (%s)\n", item.Title, item.Uuid)
+		}
+		return onepass.Item{}, fmt.Errorf("Multiple matching items")
+	}
+
+	return items[0], nil
+}
+
+func renameItem(vault *onepass.Vault, pattern string, newTitle string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item to rename")
+	}
+	logItemAction("Renaming item", item)
+	item.Title = newTitle
+	err = item.Save()
+	if err != nil {
+		fatalErr(err, "Failed to rename item")
+	}
+}
+
+// itemFieldValue looks up the field, form field or URL of an item
+// which matches fieldPattern, as described in copyItemHelp(), and
+// returns its title and value. fieldPattern defaults to 'password'.
+func itemFieldValue(item onepass.Item, fieldPattern string) (fieldTitle string, value string) {
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+
+	if fieldPattern == "" {
+		fieldPattern = "password"
+	}
+
+	field := content.FieldByPattern(fieldPattern)
+	if content.PasswordRecipe != nil && fieldPattern == "password" {
+		fieldTitle = "password"
+		value, err = item.DerivedPassword()
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Failed to derive password for item '%s'", item.Title))
+		}
+	} else if fieldPattern == "otp" && content.OTPSecret() != "" {
+		fieldTitle = "one-time password"
+		value, _ = itemOTPCode(item, content)
+	} else if accessor, ok := copyFieldAccessors[fieldPattern]; ok && accessor(&content) != "" {
+		// prefer the item's main password, username etc. over other
+		// fields whose names contain the pattern, such as the previous
+		// password saved by 'respond-breach'
+		fieldTitle = fieldPattern
+		value = accessor(&content)
+	} else if field != nil {
+		fieldTitle = field.Title
+		value = field.ValueString()
+	} else {
+		formField := content.FormFieldByPattern(fieldPattern)
+		if formField != nil {
+			fieldTitle = formField.Name
+			value = formField.Value
+		} else {
+			urlField := content.UrlByPattern(fieldPattern)
+			if urlField != nil {
+				fieldTitle = urlField.Label
+				value = urlField.Url
+			}
+		}
+	}
+
+	if len(value) == 0 {
+		fatalErr(fmt.Errorf("onepass.Item has no fields, web form fields or websites matching pattern '%s'\n", fieldPattern), "")
+	}
+	return fieldTitle, value
+}
+
+// showField writes the value of a single field of an item to stdout,
+// followed by a newline unless raw is set, so that it can be used in
+// command substitutions or piped to other tools
+func showField(vault *onepass.Vault, selection itemSelection, fieldPattern string, raw bool) {
+	item, err := selectSingleItem(vault, selection)
+	if err != nil {
+		fatalErr(err, "Failed to find item to show")
+	}
+	_, value := itemFieldValue(item, fieldPattern)
+	if raw {
+		_, err = os.Stdout.Write([]byte(value))
+	} else {
+		_, err = fmt.Println(value)
+	}
+	if err != nil {
+		fatalErr(err, "Failed to write field")
+	}
+	recordItemUse(vault, item)
+}
+
+// openClipboard returns the clipboard backend from the
+// 'ClipboardBackend' setting or the one detected for
+// the current system
+func openClipboard() *clipboard.Backend {
+	backend, err := clipboard.ByName(readConfig().ClipboardBackend)
+	if err != nil {
+		fatalErr(err, "")
+	}
+	return backend
+}
+
+func copyToClipboard(vault *onepass.Vault, pattern string, fieldPattern string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item to copy")
+	}
+	fieldTitle, value := itemFieldValue(item, fieldPattern)
+	event := newItemHookEvent(vault, item)
+	event.Field = fieldTitle
+	runHooks("before-copy", event)
+
+	err = openClipboard().WriteAll(value)
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to copy '%s' field to clipboard", fieldTitle))
+	}
+
+	fmt.Printf("Copied '%s' to clipboard for item '%s'\n", fieldTitle, item.Title)
+	recordItemUse(vault, item)
+	runHooks("after-copy", event)
+}
+
+func verifyPasswordHelp() string {
+	return `Reads a candidate password from a hidden prompt and reports whether
+it matches the item's password, without displaying either of them.
+If the password matches, the exit status is 0, otherwise it is 1.
+
+[field] specifies the field to compare against, in the same way as
+for the 'copy' command. If omitted, defaults to 'password'.`
+}
+
+// verifyPassword compares a candidate password, read from a hidden
+// prompt or the clipboard, with the value of a field of an item.
+// Neither value is printed.
+func verifyPassword(vault *onepass.Vault, pattern string, fieldPattern string, fromClipboard bool) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item to verify")
+	}
+	fieldTitle, value := itemFieldValue(item, fieldPattern)
+
+	var candidate []byte
+	if fromClipboard {
+		text, err := openClipboard().ReadAll()
+		if err != nil {
+			fatalErr(err, "Failed to read password from clipboard")
+		}
+		candidate = []byte(text)
+	} else {
+		fmt.Printf("%s: ", locale.T("Password to verify"))
+		candidate, err = readTerminalPassword()
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+	}
+
+	if subtle.ConstantTimeCompare(candidate, []byte(value)) != 1 {
+		fmt.Printf("The password does not match '%s' for item '%s'\n", fieldTitle, item.Title)
+		os.Exit(1)
+	}
+	fmt.Printf("The password matches '%s' for item '%s'\n", fieldTitle, item.Title)
+}
+
+func showConnectionString(vault *onepass.Vault, pattern string, driver string, copy bool) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find database item")
+	}
+	if item.TypeName != "wallet.computer.Database" {
+		fatalErr(fmt.Errorf("'%s' is not a Database item", item.Title), "")
+	}
+
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+	dsn, err := content.ConnectionString(driver)
+	if err != nil {
+		fatalErr(err, "Unable to create connection string")
+	}
+
+	if copy {
+		err = openClipboard().WriteAll(dsn)
+		if err != nil {
+			fatalErr(err, "Failed to copy connection string to clipboard")
+		}
+		fmt.Printf("Copied connection string to clipboard for item '%s'\n", item.Title)
+	} else {
+		fmt.Println(dsn)
+	}
+	recordItemUse(vault, item)
+}
+
+// create a set of item templates based on existing
+// items in a vault
+func exportItemTemplates(vault *onepass.Vault, pattern string) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+
+	typeTemplates := map[string]onepass.ItemContent{}
+	for _, item := range items {
+		typeTemplate := onepass.ItemContent{
+			Sections:   []onepass.ItemSection{},
+			FormFields: []onepass.WebFormField{},
+		}
+		if !strings.HasPrefix(strings.ToLower(item.Title), pattern) {
+			continue
+		}
+
+		content, err := item.Content()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to decrypt item: %v\n", err)
+		}
+
+		// section templates
+		for _, section := range content.Sections {
+			sectionTemplate := onepass.ItemSection{
+				Name:   section.Name,
+				Title:  section.Title,
+				Fields: []onepass.ItemField{},
+			}
+			for _, field := range section.Fields {
+				fieldTemplate := onepass.ItemField{
+					Name:  field.Name,
+					Title: field.Title,
+					Kind:  field.Kind,
+				}
+				sectionTemplate.Fields = append(sectionTemplate.Fields, fieldTemplate)
+			}
+			typeTemplate.Sections = append(typeTemplate.Sections, sectionTemplate)
+		}
+
+		// web form field templates
+		for _, formField := range content.FormFields {
+			formTemplate := onepass.WebFormField{
+				Name:        formField.Name,
+				Id:          formField.Id,
+				Type:        formField.Type,
+				Designation: formField.Designation,
+			}
+			typeTemplate.FormFields = append(typeTemplate.FormFields, formTemplate)
+		}
+
+		// URL templates
+		for _, url := range content.Urls {
+			urlTemplate := onepass.ItemUrl{Label: url.Label}
+			typeTemplate.Urls = append(typeTemplate.Urls, urlTemplate)
+		}
+
+		typeTemplates[item.TypeName] = typeTemplate
+	}
+
+	data, err := json.Marshal(typeTemplates)
+	if err != nil {
+		fatalErr(err, "Unable to export item templates")
+	}
+	_, _ = os.Stdout.Write(prettyJson(data))
+}
+
+func exportItems(vault *onepass.Vault, selection itemSelection, path string) {
+	if !strings.HasSuffix(path, ".1pif") {
+		path += ".1pif"
+	}
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	for _, item := range items {
+		logItemAction("Exporting item", item)
+	}
+	err = onepass.ExportItems(items, path)
+	if err != nil {
+		fatalErr(err, "Unable to export items")
+	}
+}
+
+func importLastpassHelp() string {
+	return `Imports the CSV file created by LastPass's 'Export' option. Entries are
+added to folders matching their LastPass groups, which are created if
+they do not already exist.
+
+Secure notes are imported as Secure Notes, except for notes created from
+LastPass's templates, such as credit cards, bank accounts and servers,
+which are imported as the equivalent item type. Fields with no
+equivalent are added to a 'Custom Fields' section.`
+}
+
+func exportBitwardenHelp() string {
+	return `Writes matching items to a JSON file which can be imported into Bitwarden
+using its 'Bitwarden (json)' import format. Logins, Secure Notes, Credit
+Cards and Identities are converted to the equivalent Bitwarden item types
+and other items are exported as secure notes. Fields with no Bitwarden
+equivalent are exported as custom fields. Tags are not exported.
+
+The file is not encrypted and should be deleted once it has been
+imported. Use 'import --format bitwarden-json' to import a Bitwarden
+export into a vault.`
+}
+
+func exportBitwarden(vault *onepass.Vault, selection itemSelection, path string) {
+	allItems, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	matches, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	items := []onepass.Item{}
+	for _, item := range matches {
+		if !item.Trashed && !strings.HasPrefix(item.TypeName, "system.") {
+			logItemAction("Exporting item", item)
+			items = append(items, item)
+		}
+	}
+	err = onepass.ExportBitwarden(items, folderPaths(allItems), path)
+	if err != nil {
+		fatalErr(err, "Unable to export items")
+	}
+}
+
+// importFormat describes a file format which
+// items can be imported from
+type importFormat struct {
+	description string
+	read        func(path string) ([]onepass.ExportedItem, error)
+
+	// for encrypted sources, the function to read items given
+	// the source path and password. The user is prompted for
+	// the password, which may be empty.
+	readEncrypted  func(path string, password string) ([]onepass.ExportedItem, error)
+	passwordPrompt string
+}
+
+var importFormats = map[string]importFormat{
+	"1pif": {
+		description: "1Password Interchange Format file or directory",
+		read:        onepass.ImportItems,
+	},
+	"1password-csv": {
+		description: "CSV file exported by 1Password 8",
+		read:        onepass.Import1PasswordCSV,
+	},
+	"apple-csv": {
+		description: "Passwords CSV file exported by Safari or macOS",
+		read:        onepass.ImportApplePasswordsCSV,
+	},
+	"bitwarden-json": {
+		description: "Unencrypted JSON file exported by Bitwarden",
+		read:        onepass.ImportBitwarden,
+	},
+	"bookmarks": {
+		description: "Bookmarks HTML file exported from a web browser. Creates Logins without credentials tagged 'needs-password'",
+		read:        onepass.ImportBookmarks,
+	},
+	"chromium": {
+		description: "'Login Data' file from a Chrome or Chromium profile",
+		read:        browser.ImportChromium,
+	},
+	"dashlane-json": {
+		description: "JSON file exported by Dashlane",
+		read:        onepass.ImportDashlaneJSON,
+	},
+	"dashlane-csv": {
+		description: "credentials.csv, securenotes.csv or payments.csv file exported by Dashlane",
+		read:        onepass.ImportDashlaneCSV,
+	},
+	"enpass-json": {
+		description: "JSON file exported by Enpass",
+		read:        onepass.ImportEnpassJSON,
+	},
+	"firefox": {
+		description:    "Saved logins from a Firefox profile directory",
+		readEncrypted:  browser.ImportFirefox,
+		passwordPrompt: "Firefox primary password (leave empty if not set)",
+	},
+}
+
+func importHelp() string {
+	formats := []string{}
+	for name, _ := range importFormats {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+
+	result := "Supported formats:\n\n"
+	for i, name := range formats {
+		if i > 0 {
+			result += "\n"
+		}
+		result += fmt.Sprintf("  %s - %s", name, importFormats[name].description)
+	}
+	return result
+}
+
+func importItems(vault *onepass.Vault, path string, formatName string, updateExisting bool) {
+	if formatName == "" {
+		formatName = "1pif"
+	}
+	format, ok := importFormats[formatName]
+	if !ok {
+		fatalErr(fmt.Errorf("Unknown import format '%s'", formatName), "")
+	}
+	var items []onepass.ExportedItem
+	var err error
+	if format.readEncrypted != nil {
+		fmt.Printf("%s: ", format.passwordPrompt)
+		password, _ := terminal.ReadPassword(0)
+		fmt.Println()
+		items, err = format.readEncrypted(path, string(password))
+	} else {
+		items, err = format.read(path)
+	}
+	if err != nil {
+		fatalErr(err, "Unable to import items")
+	}
+	addImportedItems(vault, items, updateExisting)
+}
+
+// addImportedItems adds items read by one of the importers to the vault.
+// Imported folders are merged with existing folders which have the
+// same path. If updateExisting is set, items which match an existing
+// item are merged into it. See findImportedItem()
+func addImportedItems(vault *onepass.Vault, items []onepass.ExportedItem, updateExisting bool) {
+	// check all items before adding any so that
+	// a failed import does not leave a partial copy
+	importedFolders := []onepass.Item{}
+	for _, importedItem := range items {
+		if importedItem.TypeName == folderTypeName {
+			importedFolders = append(importedFolders, importedItem.Item)
+			continue
+		}
+		err := onepass.ValidateContent(importedItem.TypeName, importedItem.SecureContents)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to import item '%s'", importedItem.Title))
+		}
+	}
+
+	// map of imported folder UUID -> UUID of folder in vault
+	folderUuids := map[string]string{}
+	if len(importedFolders) > 0 {
+		vaultItems, err := vault.ListItems()
+		if err != nil {
+			fatalErr(err, "Unable to list vault items")
+		}
+		creator := newFolderCreator(vault, vaultItems)
+		for uuid, path := range folderPaths(importedFolders) {
+			folderUuids[uuid], err = creator.folderUuid(path)
+			if err != nil {
+				fatalErr(err, fmt.Sprintf("Unable to create folder '%s'", path))
+			}
+		}
+	}
+
+	var vaultItems []onepass.Item
+	if updateExisting {
+		var err error
+		vaultItems, err = vault.ListItems()
+		if err != nil {
+			fatalErr(err, "Unable to list vault items")
+		}
+	}
+
+	privateTags := readConfig().PrivateTags
+	vault.BeginBatch()
+	for _, importedItem := range items {
+		if importedItem.TypeName == folderTypeName {
+			continue
+		}
+		if existing, ok := findImportedItem(vaultItems, importedItem); ok {
+			updateImportedItem(existing, importedItem, privateTags)
+			continue
+		}
+		item, err := vault.AddItem(importedItem.Title, importedItem.TypeName, importedItem.SecureContents)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to import item '%s'", importedItem.Title))
+		}
+		folderUuid := folderUuids[importedItem.FolderUuid]
+		if len(importedItem.OpenContents.Tags) > 0 || importedItem.Trashed ||
+			folderUuid != "" || importedItem.FaveIndex != 0 {
+			err = item.SetTags(importedItem.OpenContents.Tags, privateTags)
+			if err != nil {
+				fatalErr(err, fmt.Sprintf("Unable to set tags for item '%s'", item.Title))
+			}
+			item.Trashed = importedItem.Trashed
+			item.FolderUuid = folderUuid
+			item.FaveIndex = importedItem.FaveIndex
+			err = item.Save()
+			if err != nil {
+				fatalErr(err, fmt.Sprintf("Unable to save item '%s'", item.Title))
+			}
+		}
+		logItemAction("Imported item", item)
+	}
+	endBatch(vault)
+}
+
+// endBatch ends a batch of changes started with vault.BeginBatch()
+// and writes the changes to the vault's index
+func endBatch(vault *onepass.Vault) {
+	err := vault.EndBatch()
+	if err != nil {
+		fatalErr(err, "Unable to update vault index")
+	}
+}
+
+// itemTags returns an item's tags, including those stored
+// in its encrypted content if private tags are enabled
+func itemTags(item *onepass.Item, private bool) []string {
+	tags, err := item.Tags(private)
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Unable to read tags for item '%s'", item.Title))
+	}
+	return tags
+}
+
+func setItemTags(item *onepass.Item, tags []string, private bool) {
+	err := item.SetTags(tags, private)
+	if err == nil {
+		err = item.Save()
+	}
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Unable to save item '%s'", item.Title))
+	}
+}
+
+func listTag(vault *onepass.Vault, tag string) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	private := readConfig().PrivateTags
+	itemsWithTag := []onepass.Item{}
+	for _, item := range items {
+		tags := itemTags(&item, private)
+		hasTag := rangeutil.Contains(0, len(tags), func(i int) bool {
+			return tags[i] == tag
+		})
+		if hasTag {
+			itemsWithTag = append(itemsWithTag, item)
+		}
+	}
+	listItems(vault, itemsWithTag)
+}
+
+func listTags(vault *onepass.Vault) {
+	uniqTags := map[string]bool{}
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	private := readConfig().PrivateTags
+	for _, item := range items {
+		for _, tag := range itemTags(&item, private) {
+			uniqTags[tag] = true
+		}
+	}
+	tags := []string{}
+	for tag, _ := range uniqTags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Printf("%s\n", tag)
+	}
+}
+
+func addTag(vault *onepass.Vault, selection itemSelection, tag string) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	private := readConfig().PrivateTags
+	vault.BeginBatch()
+	for _, item := range items {
+		tags := itemTags(&item, private)
+		hasTag := rangeutil.Contains(0, len(tags), func(i int) bool {
+			return tags[i] == tag
+		})
+		if !hasTag {
+			logItemAction("Tagging item", item)
+			setItemTags(&item, append(tags, tag), private)
+		}
+	}
+	endBatch(vault)
+}
+
+func removeTag(vault *onepass.Vault, selection itemSelection, tag string) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	private := readConfig().PrivateTags
+	vault.BeginBatch()
+	for _, item := range items {
+		tags := itemTags(&item, private)
+		hasTag := rangeutil.Contains(0, len(tags), func(i int) bool {
+			return tags[i] == tag
+		})
+		if hasTag {
+			logItemAction("Untagging item", item)
+			newTags := []string{}
+			for _, existingTag := range tags {
+				if existingTag != tag {
+					newTags = append(newTags, existingTag)
+				}
+			}
+			setItemTags(&item, newTags, private)
+		}
+	}
+	endBatch(vault)
+}
+
+// setPrivateTags enables or disables private tags and moves the
+// tags of existing items into or out of their encrypted content
+func setPrivateTags(vault *onepass.Vault, enabled bool) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	vault.BeginBatch()
+	for _, item := range items {
+		if item.TypeName == "system.folder.Regular" || item.TypeName == "system.folder.SavedSearch" {
+			continue
+		}
+		tags := itemTags(&item, true)
+		if len(tags) == 0 {
+			continue
+		}
+		setItemTags(&item, tags, enabled)
+	}
+	endBatch(vault)
+
+	config := readConfig()
+	config.PrivateTags = enabled
+	writeConfig(&config)
+	if enabled {
+		fmt.Printf("Tags are now stored in items' encrypted content\n")
+	} else {
+		fmt.Printf("Tags are now stored unencrypted\n")
+	}
+}
+
+func privateTitlesHelp() string {
+	return fmt.Sprintf(`When private titles are enabled for a vault, the title and primary website
+of each item are stored only in its encrypted content. The unencrypted
+item files and index contain '%s' instead, so the vault must be
+unlocked to list items.
+
+This setting is intended for vaults which are only used with 1pass.
+Other 1Password apps will show placeholder titles for all items.`, onepass.PrivateTitlePlaceholder)
+}
+
+func privateTagsHelp() string {
+	return `When private tags are enabled, tags are stored in each item's encrypted
+content instead of in plaintext, so tag names cannot be read without the
+master password. Listing tags then requires decrypting every item.
+
+Other 1Password apps do not display private tags and may discard them
+when editing an item. Enabling or disabling private tags moves the tags
+of existing items.`
+}
+
+func handleVaultCmd(vault *onepass.Vault, mode string, cmdArgs []string) {
+	parser := cmdmodes.NewParser(commandModes)
+	flags, cmdArgs, err := parser.ParseCmdFlags(mode, cmdArgs)
+	if err != nil {
+		fatalErr(err, "")
+	}
+	switch mode {
+	case "list":
+		fieldFilters := []fieldFilter{}
+		if flags.Bool("username") {
+			fieldFilters = append(fieldFilters, fieldFilter{key: "username", value: flags.String("username")})
+		}
+		if flags.Bool("field") {
+			filter, err := parseFieldFilter(flags.String("field"))
+			if err != nil {
+				fatalErr(err, "")
+			}
+			fieldFilters = append(fieldFilters, filter)
+		}
+		listMatchingItems(vault, selectPatternArgs(cmdArgs, flags, false), listOptions{
+			recent:    flags.Bool("recent"),
+			sortOrder: flags.String("sort"),
+			expiring:  flags.Bool("expiring"),
+			details:   flags.Bool("details"),
+			types:     flags.Bool("types"),
+
+			fieldFilters: fieldFilters,
+		})
+
+	case "tree":
+		var folderPattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &folderPattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		printFolderTree(vault, folderPattern, flags.Bool("folders"))
+
+	case "search":
+		var query string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &query)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		context := defaultSearchContext
+		if flags.Bool("context") {
+			context, err = strconv.Atoi(flags.String("context"))
+			if err != nil || context < 0 {
+				fatalErr(fmt.Errorf("Invalid number of lines '%s'", flags.String("context")), "")
+			}
+		}
+		searchItems(vault, query, context)
+
+	case "list-folder":
+		var pattern string
+		parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		listFolder(vault, pattern)
+
+	case "show-json":
+		fallthrough
+	case "show":
+		selection := selectPatternArgs(cmdArgs, flags, true)
+		if flags.Bool("field") || flags.Bool("raw") {
+			showField(vault, selection, flags.String("field"), flags.Bool("raw"))
+		} else {
+			showItems(vault, selection, mode == "show-json", flags.Bool("history"), flags.Bool("render"))
+		}
+
+	case "add":
+		var itemType string
+		var title string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &itemType, &title)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		var site *siteMetadata
+		if flags.Bool("from-url") {
+			if itemType != "login" {
+				fatalErr(fmt.Errorf("'--from-url' can only be used to add logins"), "")
+			}
+			site = newLoginSite(flags.String("from-url"), flags.Bool("offline"))
+			if title == "" {
+				title = site.Title
+			}
+		}
+		if title == "" {
+			fatalErr(fmt.Errorf("Missing arguments: title"), "")
+		}
+		addItem(vault, title, itemType, site)
+
+	case "note":
+		var title string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &title)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		addNote(vault, title, os.Stdin, flags.Strings("tag"), flags.String("folder"))
+
+	case "edit":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if flags.Bool("json") {
+			editItemJson(vault, pattern)
+		} else {
+			editItem(vault, pattern)
+		}
+
+	case "remove":
+		removeItems(vault, selectPatternArgs(cmdArgs, flags, true))
+
+	case "trash":
+		trashItems(vault, selectPatternArgs(cmdArgs, flags, true))
+
+	case "restore":
+		restoreItems(vault, selectPatternArgs(cmdArgs, flags, true))
+
+	case "list-trash":
+		listTrash(vault)
+
+	case "normalize-urls":
+		normalizeItemUrls(vault, selectPatternArgs(cmdArgs, flags, false), flags.Bool("https"))
+
+	case "empty-trash":
+		emptyTrash(vault)
+
+	case "trash-retention":
+		var setting string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &setting)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setTrashRetention(vault, setting)
+
+	case "rename":
+		var pattern string
+		var newTitle string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &newTitle)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		renameItem(vault, pattern, newTitle)
+
+	case "copy":
+		var pattern string
+		var field string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &field)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		copyToClipboard(vault, pattern, field)
+
+	case "check":
+		checkVault(vault, flags.Bool("repair"))
+
+	case "health":
+		maxAge := defaultMaxPasswordAge
+		if flags.Bool("max-age") {
+			days, err := strconv.Atoi(flags.String("max-age"))
+			if err != nil {
+				fatalErr(err, "Invalid number of days")
+			}
+			maxAge = time.Duration(days) * 24 * time.Hour
+		}
+		if flags.Bool("exporter") {
+			serveHealthMetrics(vault, maxAge, exporterAddr(flags))
+		} else {
+			showHealthReport(vault, maxAge, flags.Bool("json"))
+		}
+
+	case "mirror":
+		if !flags.Bool("out") {
+			fatalErr(fmt.Errorf("Use '--out' to give the path of the mirror vault"), "")
+		}
+		if flags.Bool("refresh") {
+			refreshMirror(vault, flags.String("out"))
+		} else {
+			createMirror(vault, flags.Strings("filter"), flags.String("out"), flags.Bool("force"))
+		}
+
+	case "audit":
+		var report string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &report)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if flags.Bool("exporter") {
+			serveHealthMetrics(vault, defaultMaxPasswordAge, exporterAddr(flags))
+		} else {
+			showAuditReport(vault, report, flags)
+		}
+
+	case "sync":
+		var otherPath string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &otherPath)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		syncVaults(vault, otherPath, flags.Bool("dry-run") || dryRun, flags.Bool("resolve"))
+
+	case "conflicts":
+		listConflicts(vault)
+
+	case "resolve":
+		var selector string
+		var keep string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &selector, &keep)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		resolveConflict(vault, selector, keep)
+
+	case "verify-password":
+		var pattern string
+		var field string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &field)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		verifyPassword(vault, pattern, field, flags.Bool("clipboard"))
+
+	case "connection-string":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		showConnectionString(vault, pattern, flags.String("driver"), flags.Bool("copy"))
+
+	case "totp":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		showOTPCode(vault, pattern)
+
+	case "ssh":
+		if len(cmdArgs) == 0 {
+			var pattern string
+			fatalErr(parser.ParseCmdArgs(mode, cmdArgs, &pattern), "")
+		}
+		sshToServer(vault, cmdArgs[0], cmdArgs[1:])
+
+	case "email-config":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		showEmailConfig(vault, pattern, flags.String("format"))
+
+	case "license":
+		var product string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &product)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		showLicense(vault, product)
+
+	case "emergency-kit":
+		var path string
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &path, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		createEmergencyKit(vault, path, pattern)
+
+	case "coverage":
+		var historyPath string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &historyPath)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		minVisits := 5
+		if flags.Bool("min-visits") {
+			minVisits, err = strconv.Atoi(flags.String("min-visits"))
+			if err != nil {
+				fatalErr(err, "Invalid number of visits")
+			}
+		}
+		showCoverage(vault, historyPath, minVisits, flags.Bool("all"))
+
+	case "dump-structure":
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		dumpStructure(vault, path)
+
+	case "apply-structure":
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		applyStructure(vault, path)
+
+	case "retag":
+		if !flags.Bool("rules") {
+			fatalErr(fmt.Errorf("A rules file must be specified with --rules"), "")
+		}
+		retagItems(vault, flags.String("rules"), flags.Bool("dry-run") || dryRun)
+
+	case "respond-breach":
+		var domain string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &domain)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		respondToBreach(vault, domain)
+
+	case "import":
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		importItems(vault, path, flags.String("format"), flags.Bool("update-existing"))
+
+	case "import-csv":
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		mapping := onepass.CSVMapping{}
+		if flags.Bool("mapping") {
+			mapping = readCsvMapping(flags.String("mapping"))
+		}
+		for _, column := range []struct {
+			flag string
+			spec *string
+		}{
+			{"title", &mapping.Title},
+			{"url", &mapping.Url},
+			{"username", &mapping.Username},
+			{"password", &mapping.Password},
+			{"notes", &mapping.Notes},
+			{"tags", &mapping.Tags},
+			{"otp", &mapping.Otp},
+		} {
+			if flags.Bool(column.flag) {
+				*column.spec = flags.String(column.flag)
+			}
+		}
+		importCsv(vault, path, mapping)
+
+	case "import-lastpass":
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		items, err := onepass.ImportLastPassCSV(path)
+		if err != nil {
+			fatalErr(err, "Unable to import items")
+		}
+		addImportedItems(vault, items, false)
+
+	case "export":
+		var pattern string
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		selection := selectPattern(pattern, flags)
+		event := hookEvent{Vault: vault.Path, Pattern: selection.String(), Path: path, Format: "1pif"}
+		runHooks("before-export", event)
+		exportItems(vault, selection, path)
+		runHooks("after-export", event)
+
+	case "export-bitwarden":
+		var pattern string
+		var path string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &path)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		selection := selectPattern(pattern, flags)
+		event := hookEvent{Vault: vault.Path, Pattern: selection.String(), Path: path, Format: "bitwarden"}
+		runHooks("before-export", event)
+		exportBitwarden(vault, selection, path)
+		runHooks("after-export", event)
+
+	case "export-pass":
+		var pattern string
+		var storeDir string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &storeDir)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		selection := selectPattern(pattern, flags)
+		event := hookEvent{Vault: vault.Path, Pattern: selection.String(), Path: storeDir, Format: "pass"}
+		runHooks("before-export", event)
+		exportPass(vault, selection, storeDir, flags.Strings("gpg-id"))
+		runHooks("after-export", event)
+
+	case "export-item-templates":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		exportItemTemplates(vault, pattern)
+
+	case "set-expiry":
+		var pattern string
+		var date string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &date)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setItemExpiry(vault, pattern, date)
+
+	case "set-summary":
+		var pattern string
+		var fieldName string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &fieldName)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setItemSummaryField(vault, pattern, fieldName)
+
+	case "set-alias":
+		var pattern string
+		var alias string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &alias)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if pattern == "" {
+			listItemAliases(vault)
+		} else if alias == "" {
+			fatalErr(fmt.Errorf("Missing arguments: alias"), "")
+		} else {
+			setItemAlias(vault, pattern, alias)
+		}
+
+	case "set-policy":
+		var pattern string
+		var rules string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &rules)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setItemPasswordPolicy(vault, pattern, rules)
+
+	case "derive-password":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		intFlag := func(name string) int {
+			if !flags.Bool(name) {
+				return 0
+			}
+			value, err := strconv.Atoi(flags.String(name))
+			if err != nil {
+				fatalErr(err, fmt.Sprintf("Invalid value for --%s", name))
+			}
+			return value
+		}
+		setDerivedPassword(vault, pattern, onepass.PasswordRecipe{
+			Site:    flags.String("site"),
+			Counter: intFlag("counter"),
+			Length:  intFlag("length"),
+			Symbols: flags.Bool("symbols"),
+		})
+
+	case "move":
+		var folderPattern string
+		var itemPattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &itemPattern, &folderPattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		moveItemsToFolder(vault, selectPattern(itemPattern, flags), folderPattern)
+
+	case "list-tag":
+		var tag string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &tag)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		listTag(vault, tag)
+
+	case "private-titles":
+		var setting string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &setting)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if setting != "on" && setting != "off" {
+			fatalErr(fmt.Errorf("Expected 'on' or 'off'"), "")
+		}
+		err = vault.SetPrivateTitles(setting == "on")
+		if err != nil {
+			fatalErr(err, "Unable to update item titles")
+		}
+		if setting == "on" {
+			fmt.Printf("Item titles and websites are now stored in items' encrypted content\n")
+		} else {
+			fmt.Printf("Item titles and websites are now stored unencrypted\n")
+		}
+
+	case "sign-items":
+		var setting string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &setting)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setSignItems(vault, setting)
+
+	case "private-tags":
+		var setting string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &setting)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if setting != "on" && setting != "off" {
+			fatalErr(fmt.Errorf("Expected 'on' or 'off'"), "")
+		}
+		setPrivateTags(vault, setting == "on")
+
+	case "private-usage":
+		var setting string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &setting)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setPrivateUsage(vault, setting)
+
+	case "keep-history":
+		var setting string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &setting)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setKeepHistory(vault, setting)
+
+	case "history":
+		var pattern string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		showItemHistory(vault, pattern)
+
+	case "revert":
+		var pattern string
+		var revision string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &revision)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		revertItem(vault, pattern, revision)
+
+	case "list-tags":
+		listTags(vault)
+
+	case "add-tag":
+		var pattern string
+		var tag string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &tag)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		addTag(vault, selectPattern(pattern, flags), tag)
+
+	case "remove-tag":
+		var pattern string
+		var tag string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &pattern, &tag)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		removeTag(vault, selectPattern(pattern, flags), tag)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", mode)
+		os.Exit(1)
+	}
+}
+
+func initVaultConfig(config *clientConfig) {
+	keyChains, opVaults := findKeyChainDirs(config.VaultSearchRoots)
+	if len(keyChains) == 0 {
+		exitVaultNotFound(opVaults)
+	}
+	config.VaultDir = keyChains[0]
+	fmt.Printf("Using the password vault in '%s'\n", config.VaultDir)
+	writeConfig(config)
+}
+
+func setVaultHelp() string {
+	return `Other vaults can be given names with '--name' and then used by passing
+'-vault-name <name>' before the command, eg. '1pass -vault-name work list'.
+
+If the default vault is later moved or renamed, 1pass searches for vaults the next
+time it is run and offers to use one of those instead. Vaults are found
+using the 'locate' and Spotlight indexes where available and by scanning
+the directories listed in the 'VaultSearchRoots' setting in ~/.1pass,
+which defaults to ~/Dropbox, ~/Library/CloudStorage and the home
+directory.
+
+For a vault which is synced to different folders on different computers,
+list the alternative paths in the 'VaultDirs' setting in ~/.1pass. The
+first path which contains a vault is used.`
+}
+
+// setNamedVault saves the path of a vault for use with the
+// '-vault-name' flag or removes the vault if path is empty
+func setNamedVault(name string, path string) {
+	config := readConfig()
+	if config.Vaults == nil {
+		config.Vaults = map[string]string{}
+	}
+	if path == "" {
+		if _, ok := config.Vaults[name]; !ok {
+			fatalErr(fmt.Errorf("No vault named '%s'", name), "")
+		}
+		delete(config.Vaults, name)
+		fmt.Printf("Removed vault '%s'\n", name)
+	} else {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			fatalErr(err, "Invalid vault path")
+		}
+		config.Vaults[name] = absPath
+		fmt.Printf("Saved vault '%s' as %s\n", name, absPath)
+	}
+	writeConfig(&config)
+}
+
+// listVaults displays the default vault and named vaults
+// with their paths
+func listVaults(config clientConfig) {
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	printVault := func(name string, path string) {
+		status := ""
+		if onepass.CheckVault(path) != nil {
+			status = " (not found)"
+		}
+		fmt.Fprintf(out, "%s\t%s%s\n", name, path, status)
+	}
+	if config.VaultDir != "" {
+		printVault("(default)", config.VaultDir)
+	}
+	names := []string{}
+	for name, _ := range config.Vaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printVault(name, config.Vaults[name])
+	}
+	out.Flush()
+}
+
+func exitVaultNotFound(opVaults []string) {
+	for _, path := range opVaults {
+		fmt.Fprintf(os.Stderr, "Found a vault in the OPVault format, which is not supported: %s\n", path)
+	}
+	fmt.Fprintf(os.Stderr,
+		`Unable to locate a 1Password vault automatically, use '%s set-vault <path>'
+to specify an existing vault or '%s new <path>' to create a new one
+`, os.Args[0], os.Args[0])
+	os.Exit(1)
+}
+
+// selectVaultDir sets config.VaultDir to the first of the configured
+// vault paths which contains a vault. If none of them do, eg. because
+// the folder containing the vault has been moved or renamed, it
+// searches for vaults and offers to use one of those instead.
+func selectVaultDir(config *clientConfig) {
+	candidates := []string{}
+	if config.VaultDir != "" {
+		candidates = append(candidates, config.VaultDir)
+	}
+	candidates = append(candidates, config.VaultDirs...)
+	if len(candidates) == 0 {
+		initVaultConfig(config)
+		return
+	}
+	for _, path := range candidates {
+		if onepass.CheckVault(path) == nil {
+			config.VaultDir = path
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "No vault was found in '%s'. It may have been moved or renamed.\n",
+		strings.Join(candidates, "', '"))
+	keyChains, opVaults := findKeyChainDirs(config.VaultSearchRoots)
+	for _, path := range keyChains {
+		fmt.Printf("Use the vault in '%s' instead? (y/n) ", path)
+		if readConfirmation() {
+			config.VaultDir = path
+			writeConfig(config)
+			return
+		}
+	}
+	exitVaultNotFound(opVaults)
+}
+
+func agentLogPath(config clientConfig) string {
+	if config.AgentLogFile != "" {
+		return config.AgentLogFile
+	}
+	return agent.DefaultLogPath()
+}
+
+// readTerminalPassword reads a password without echoing it. If stdin
+// is not a terminal, eg. because data is being piped to 1pass, the
+// password is read from the controlling terminal instead.
+func readTerminalPassword() ([]byte, error) {
+	if terminal.IsTerminal(0) {
+		return terminal.ReadPassword(0)
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+	return terminal.ReadPassword(int(tty.Fd()))
+}
+
+// agentCommand returns the command used to start the
+// 1pass agent daemon
+func agentCommand(config clientConfig) []string {
+	command := []string{os.Args[0], "-agent"}
+	if config.AgentBinary != "" {
+		command = []string{config.AgentBinary}
+	}
+	command = append(command, "-log-file", agentLogPath(config))
+	if config.AgentLogLevel != "" {
+		command = append(command, "-log-level", config.AgentLogLevel)
+	}
+	return command
+}
+
+// commands which process many items, such as exports, and which
+// hold a lease on the vault so that it is not auto-locked if the
+// command runs for longer than the vault's unlock timeout
+var leasedModes = map[string]bool{
+	"audit":            true,
+	"export":           true,
+	"export-bitwarden": true,
+	"export-pass":      true,
+	"health":           true,
+	"import":           true,
+	"import-csv":       true,
+	"import-lastpass":  true,
+	"mirror":           true,
+	"retag":            true,
+	"sign-items":       true,
+	"sync":             true,
+}
+
+// connectToAgent connects to the 1pass agent daemon. The agent is
+// started automatically if not already running or the agent/client
+// versions do not match
+func connectToAgent(config clientConfig, vaultPath string) *client.AgentClient {
+	agentClient, err := client.ConnectAgent(vaultPath, client.AgentConfig{
+		Command:         agentCommand(config),
+		ReuseCompatible: config.AgentBinary != "",
+		Log:             os.Stderr,
+	})
+	if err != nil {
+		fatalErr(err, "Unable to connect to 1pass keychain agent")
+	}
+	return agentClient
+}
+
+func main() {
+	if askPassSock := os.Getenv(askPassSockEnv); askPassSock != "" {
+		prompt := ""
+		if len(os.Args) > 1 {
+			prompt = os.Args[1]
+		}
+		runAskPass(askPassSock, prompt)
+		return
+	}
+
+	if language := readConfig().Language; language != "" {
+		err := locale.SetLanguage(locale.Language(language))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	} else {
+		// fall back to English if there are no translations
+		// for the user's locale
+		_ = locale.SetLanguage(locale.Language(""))
+	}
+	localizeModes(commandModes)
+
+	banner := fmt.Sprintf("%s is a tool for managing 1Password vaults.", os.Args[0])
+	parser := cmdmodes.NewParser(commandModes)
+	agentFlag := flag.Bool("agent", false, "Start 1pass in agent mode")
+	vaultPathFlag := flag.String("vault", "", "Custom vault path")
+	vaultNameFlag := flag.String("vault-name", "", "Name of the vault to use, from 'list-vaults'")
+	profileFlag := flag.String("profile", "", "Profile within the vault to use, from 'list-profiles'")
+	lowSecFlag := flag.Bool("low-security", false, "Use lower security but faster encryption for the master password")
+	logFileFlag := flag.String("log-file", "", "Path of the log file to write to in agent mode. Defaults to stderr")
+	logLevelFlag := flag.String("log-level", "info", "Minimum level of log entries to write in agent mode")
+	dryRunFlag := flag.Bool("dry-run", false, "Show the changes which a command would make to the vault without making them")
+	keyFileFlag := flag.String("keyfile", "", "Key file needed in addition to the master password to unlock the vault")
+	noIndexFlag := flag.Bool("no-index", false, "Do not update the vault's contents.js index when changing items, eg. if it is damaged. See 'rebuild-index'")
+
+	flag.Usage = func() {
+		parser.PrintHelp(banner, "")
+	}
+	flag.Parse()
+
+	if *agentFlag {
+		err := agent.Run(*logFileFlag, *logLevelFlag)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		return
+	}
+
+	config := readConfig()
+	if *vaultPathFlag != "" {
+		config.VaultDir = *vaultPathFlag
+	} else if *vaultNameFlag != "" {
+		path, ok := config.Vaults[*vaultNameFlag]
+		if !ok {
+			fatalErr(fmt.Errorf("No vault named '%s'. Use 'list-vaults' to list named vaults", *vaultNameFlag), "")
+		}
+		config.VaultDir = path
+	}
+	if *profileFlag != "" {
+		config.Profile = *profileFlag
+	}
+	keyFilePath = *keyFileFlag
+	recipeVaultPath = config.VaultDir
+
+	if len(flag.Args()) < 1 || flag.Args()[0] == "help" {
+		var helpFlags cmdmodes.FlagValues
+		var helpArgs []string
+		if len(flag.Args()) > 1 {
+			var err error
+			helpFlags, helpArgs, err = parser.ParseCmdFlags("help", flag.Args()[1:])
+			if err != nil {
+				fatalErr(err, "")
+			}
+		}
+		command := ""
+		if len(helpArgs) > 0 {
+			command = helpArgs[0]
+		}
+		if helpFlags.Bool("json") {
+			err := parser.WriteJson(os.Stdout)
+			if err != nil {
+				fatalErr(err, "Unable to write command descriptions")
+			}
+			return
+		} else if helpFlags.Bool("examples") {
+			if command == "" {
+				fatalErr(nil, "Missing command name for 'help --examples'")
+			}
+			parser.PrintExamples(command)
+		} else {
+			parser.PrintHelp(banner, command)
+		}
+		os.Exit(1)
+	}
+
+	mode := flag.Args()[0]
+	cmdArgs := flag.Args()[1:]
+
+	// run '1pass-<command>' from $PATH for commands
+	// which are not built in
+	if !isBuiltinCommand(mode) {
+		if path, ok := findPlugins()[mode]; ok {
+			runExternalCommand(path, config, cmdArgs)
+			return
+		}
+	}
+
+	if *dryRunFlag {
+		if !dryRunModes[mode] {
+			fatalErr(fmt.Errorf("'%s' does not support -dry-run", mode), "")
+		}
+		dryRun = true
+	}
+
+	// handle commands which do not require
+	// an existing vault
+	handled := true
+	switch mode {
+	case "new":
+		flags, cmdArgs, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		var path string
+		if *vaultPathFlag != "" {
+			path = *vaultPathFlag
+		} else {
+			_ = parser.ParseCmdArgs(mode, cmdArgs, &path)
+			if len(path) == 0 {
+				path = os.Getenv("HOME") + "/Dropbox/1Password/1Password.agilekeychain"
+			}
+		}
+		security := onepass.VaultSecurity{KeyScheme: onepass.KeySchemeAes128Sha1, Kdf: onepass.KdfPbkdf2}
+		if flags.Bool("aes256") {
+			security.KeyScheme = onepass.KeySchemeAes256Sha256
+		}
+		if flags.Bool("kdf") {
+			security.Kdf = flags.String("kdf")
+		}
+		security.KeyFilePath = flags.String("keyfile")
+		createNewVault(path, *lowSecFlag, security, flags.Bool("force"))
+	case "gen-password":
+		flags, _, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if flags.Bool("analyze") {
+			password, err := readAnalyzedPassword(os.Stdin)
+			if err != nil {
+				fatalErr(err, "Unable to read password")
+			}
+			analyzePassword(password)
+			break
+		}
+		recipeName := "default"
+		recipe, custom := configuredGenRecipe(config, config.VaultDir)
+		if flags.Bool("recipe") {
+			recipeName = flags.String("recipe")
+			custom = false
+		}
+		if !custom {
+			var ok bool
+			recipe, ok = onepass.GenRecipes[recipeName]
+			if !ok {
+				fatalErr(fmt.Errorf("Unknown password recipe '%s'", recipeName), "")
+			}
+		}
+		recipe, changed, err := applyGenRecipeFlags(recipe, flags)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		recipe, passphrase, err := applyPassphraseFlags(recipe, flags)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		changed = changed || passphrase
+		length := 0
+		if flags.Bool("length") {
+			length, err = strconv.Atoi(flags.String("length"))
+			if err != nil {
+				fatalErr(err, "Invalid password length")
+			}
+		}
+		if custom || changed {
+			fmt.Printf("%s\n", genPasswordWithRecipe(recipe, length, "gen-password"))
+		} else {
+			fmt.Printf("%s\n", genPassword(recipeName, length, "gen-password"))
+		}
+	case "agent-logs":
+		flags, _, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		count := 20
+		if flags.Bool("lines") {
+			count, err = strconv.Atoi(flags.String("lines"))
+			if err != nil {
+				fatalErr(err, "Invalid number of lines")
+			}
+		}
+		err = printAgentLogs(agentLogPath(config), count)
+		if err != nil {
+			fatalErr(err, "Unable to read agent log")
+		}
+	case "set-vault":
+		flags, cmdArgs, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		var newPath string
+		_ = parser.ParseCmdArgs(mode, cmdArgs, &newPath)
+		if flags.Bool("name") {
+			setNamedVault(flags.String("name"), newPath)
+		} else {
+			config.VaultDir = newPath
+			writeConfig(&config)
+		}
+	case "list-vaults":
+		listVaults(readConfig())
+	case "list-plugins":
+		listPlugins(readConfig())
+	default:
+		handled = false
+	}
+	if handled {
+		return
+	}
+
+	// handle commands which require a connected but not
+	// unlocked vault
+	if *vaultPathFlag == "" && *vaultNameFlag == "" {
+		selectVaultDir(&config)
+		recipeVaultPath = config.VaultDir
+	}
+	if mode == "set-profile" {
+		var profile string
+		err := parser.ParseCmdArgs(mode, cmdArgs, &profile)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		setProfile(config.VaultDir, profile)
+		return
+	}
+	vault, err := openVaultProfile(config.VaultDir, config.Profile)
+	if err != nil {
+		fatalErr(err, "Unable to setup vault")
+	}
+	vault.NormalizeUrls = config.NormalizeUrls
+	vault.UpgradeUrlsToHttps = config.UpgradeUrlsToHttps
+	vault.RequireSignatures = config.SignedVaults[vault.Path]
+	vault.NoIndex = *noIndexFlag
+	hook := watchVault(config, &vault)
+	if dryRun {
+		hook = nil
+		vault.DryRun = true
+		vault.OnItemChange = printDryRunChange
+	}
+	// postKeyChange notifies the webhook, if configured,
+	// after commands which change the vault's keys
+	postKeyChange := func() {
+		if hook != nil {
+			hook.post(mode, "", "")
+		}
+	}
+
+	if mode == "expired" {
+		flags, _, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		within := 0
+		if flags.Bool("within") {
+			within, err = strconv.Atoi(flags.String("within"))
+			if err != nil {
+				fatalErr(err, "Invalid number of days")
+			}
+		}
+		listExpiredItems(&vault, time.Duration(within)*24*time.Hour, flags.Bool("trash"))
+		return
+	}
+
+	if mode == "info" {
+		printVaultInfo(&vault, config.RotationReminderDays)
+		return
+	}
+	if mode == "list-profiles" {
+		listProfiles(&vault)
+		return
+	}
+	if mode == "rebuild-index" {
+		_, indexErr := os.Stat(vault.DataDir() + "/contents.js")
+		err = vault.RebuildIndex()
+		if err != nil {
+			fatalErr(err, "Unable to rebuild vault index")
+		}
+		fmt.Printf("Rebuilt the vault's index\n")
+		if indexErr == nil {
+			fmt.Printf("The previous index was saved to contents.js.bak\n")
+		}
+		return
+	}
+	if mode != "upgrade-kdf" && mode != "strengthen" {
+		warnIfWeakKdf(&vault)
+		warnIfRotationOverdue(&vault, config.RotationReminderDays)
+	}
+
+	// remaining commands require an unlocked vault
+
+	// connect to the 1pass agent daemon
+	agentClient := connectToAgent(config, config.VaultDir)
+	agentClient.Profile = vault.Profile
+	agentClient.ExpireAfter, err = unlockDuration(config, config.VaultDir)
+	if err != nil {
+		fatalErr(err, "")
+	}
+
+	if mode == "lock" {
+		err = agentClient.Lock()
+		if err != nil {
+			fatalErr(err, "Failed to lock keychain")
+		}
+		return
+	}
+
+	if mode == "split-key" {
+		flags, cmdArgs, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		var dir string
+		err = parser.ParseCmdArgs(mode, cmdArgs, &dir)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		shareCount, threshold := 5, 3
+		if flags.Bool("shares") {
+			shareCount, err = strconv.Atoi(flags.String("shares"))
+		}
+		if err == nil && flags.Bool("threshold") {
+			threshold, err = strconv.Atoi(flags.String("threshold"))
+		}
+		if err != nil {
+			fatalErr(err, "Invalid number of shares")
+		}
+		fmt.Printf("%s: ", locale.T("Master password"))
+		masterPwd, err := terminal.ReadPassword(0)
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+		pwd := masterPassword(masterPwd)
+		splitKey(&vault, pwd, dir, shareCount, threshold)
+		onepass.WipeBytes(pwd)
+		onepass.WipeBytes(masterPwd)
+		return
+	}
+
+	if mode == "recover-key" {
+		flags, cmdArgs, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if len(cmdArgs) == 0 {
+			var path string
+			fatalErr(parser.ParseCmdArgs(mode, cmdArgs, &path), "")
+		}
+		recoverKey(&vault, cmdArgs, flags.Bool("force"))
+		postKeyChange()
+		return
+	}
+
+	if mode == "upgrade-kdf" || mode == "strengthen" {
+		flags, _, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		iterations := onepass.RecommendedPbkdfIterations
+		if flags.Bool("iterations") {
+			iterations, err = strconv.Atoi(flags.String("iterations"))
+			if err != nil {
+				fatalErr(err, "Invalid number of iterations")
+			}
+		}
+		fmt.Printf("%s: ", locale.T("Master password"))
+		masterPwd, err := terminal.ReadPassword(0)
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+		pwd := masterPassword(masterPwd)
+		upgradeKdf(&vault, pwd, iterations)
+		onepass.WipeBytes(pwd)
+		onepass.WipeBytes(masterPwd)
+		postKeyChange()
+		return
+	}
+
+	if mode == "rotate-keys" || mode == "rotate-key" {
+		fmt.Printf("%s: ", locale.T("Master password"))
+		masterPwd, err := terminal.ReadPassword(0)
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+		pwd := masterPassword(masterPwd)
+		rotateKeys(&vault, agentClient, pwd)
+		onepass.WipeBytes(pwd)
+		onepass.WipeBytes(masterPwd)
+		postKeyChange()
+		return
+	}
+
+	if mode == "set-password" {
+		flags, _, err := parser.ParseCmdFlags(mode, cmdArgs)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		newKeyFile := keyFilePath
+		if flags.Bool("keyfile") {
+			newKeyFile = flags.String("keyfile")
+		} else if flags.Bool("no-keyfile") {
+			newKeyFile = ""
+		}
+		checkKeyFile(&vault)
+		fmt.Printf("%s: ", locale.T("Current master password"))
+		masterPwd, err := terminal.ReadPassword(0)
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+		pwd := masterPassword(masterPwd)
+		setPassword(&vault, pwd, newKeyFile, flags.Bool("force"))
+		onepass.WipeBytes(pwd)
+		onepass.WipeBytes(masterPwd)
+		postKeyChange()
+		return
+	}
+
+	var masterPwd []byte
+	locked, err := agentClient.IsLocked()
+	if err != nil {
+		fatalErr(err, "Failed to check lock status")
+	}
+
+	if locked {
+		checkKeyFile(&vault)
+		fmt.Printf("%s: ", locale.T("Master password"))
+		masterPwd, err = readTerminalPassword()
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+
+		err = agentClient.Unlock(masterPassword(masterPwd))
+		onepass.WipeBytes(masterPwd)
+		if err != nil {
+			if _, ok := err.(onepass.DecryptError); ok {
+				hint, err := vault.PasswordHint()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Unable to read password hint: %v\n", err)
+				}
+				fmt.Fprintf(os.Stderr, "Incorrect password (hint: %s)\n", hint)
+				os.Exit(1)
+			} else {
+				fatalErr(err, "Unable to unlock vault")
+			}
+		}
+	}
+	err = agentClient.RefreshAccess()
+	if err != nil {
+		fatalErr(err, "Unable to refresh vault access")
+	}
+	sensitiveFlags, _, _ := parser.ParseCmdFlags(mode, cmdArgs)
+	if isSensitiveCommand(mode, sensitiveFlags.Bool("raw")) {
+		maxRemaining, err := sensitiveUnlockDuration(config)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		if maxRemaining > 0 {
+			err = agentClient.LimitAccess(maxRemaining)
+			if err != nil {
+				fatalErr(err, "Unable to limit vault access")
+			}
+		}
+	}
+	if leasedModes[mode] {
+		// the lease is released when the connection
+		// to the agent is closed on exit
+		err = agentClient.AcquireLease()
+		if err != nil {
+			fatalErr(err, "Unable to acquire vault lease")
+		}
+	}
+	vault.CryptoAgent = agentClient
+	if mode != "sign-items" {
+		warnAboutTamperedItems(&vault)
+	}
+	if mode != "conflicts" && mode != "resolve" {
+		warnAboutConflictedCopies(&vault)
+	}
+	if !dryRun {
+		purgeExpiredTrash(&vault)
+	}
+	handleVaultCmd(&vault, mode, cmdArgs)
+	if vault.IndexOutdated() {
+		fmt.Fprintf(os.Stderr, "Warning: The vault's index was not updated with the changes to its items. Run 'rebuild-index' afterwards\n")
+	}
+}
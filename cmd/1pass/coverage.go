@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/browser"
+	"github.com/robertknight/1pass/rangeutil"
+)
+
+// words in a URL which suggest that a site has a login page
+var loginUrlWords = []string{"login", "log-in", "logon", "signin", "sign-in", "sign_in",
+	"auth", "sso", "account", "session"}
+
+func coverageHelp() string {
+	return `Lists websites from a browser's history which have login pages but no
+matching item in the vault, most visited first.
+
+[history] is a Firefox 'places.sqlite' file, a Chrome or Chromium 'History'
+file or a CSV file with a 'url' column and an optional 'visit_count' column.`
+}
+
+// siteDomain returns the registrable domain for a host, eg.
+// 'accounts.google.com' => 'google.com'. Country-code second level
+// domains such as 'co.uk' are approximated by treating any short
+// label before a two-letter TLD as part of the suffix.
+func siteDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	labels := strings.Split(host, ".")
+	keep := 2
+	if len(labels) > 2 && len(labels[len(labels)-1]) == 2 && len(labels[len(labels)-2]) <= 3 {
+		keep = 3
+	}
+	if len(labels) <= keep {
+		return host
+	}
+	return strings.Join(labels[len(labels)-keep:], ".")
+}
+
+// urlHost returns the lowercased host name from a URL,
+// which may omit the scheme
+func urlHost(rawUrl string) string {
+	if !strings.Contains(rawUrl, "://") {
+		rawUrl = "http://" + rawUrl
+	}
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+func urlDomain(rawUrl string) string {
+	host := urlHost(rawUrl)
+	if host == "" {
+		return ""
+	}
+	return siteDomain(host)
+}
+
+func isLoginUrl(rawUrl string) bool {
+	lowerUrl := strings.ToLower(rawUrl)
+	for _, word := range loginUrlWords {
+		if strings.Contains(lowerUrl, word) {
+			return true
+		}
+	}
+	return false
+}
+
+type siteVisits struct {
+	domain    string
+	visits    int
+	loginPage bool
+}
+
+// untrackedSites returns the sites in history with at least minVisits
+// visits whose domain does not match any of vaultDomains. Unless all
+// is true, only sites with a login page in the history are included.
+func untrackedSites(history []onepass.HistoryEntry, vaultDomains map[string]bool, minVisits int, all bool) []siteVisits {
+	sites := map[string]*siteVisits{}
+	for _, entry := range history {
+		lowerUrl := strings.ToLower(entry.Url)
+		if !strings.HasPrefix(lowerUrl, "http://") && !strings.HasPrefix(lowerUrl, "https://") {
+			continue
+		}
+		domain := urlDomain(entry.Url)
+		if domain == "" || vaultDomains[domain] {
+			continue
+		}
+		site, ok := sites[domain]
+		if !ok {
+			site = &siteVisits{domain: domain}
+			sites[domain] = site
+		}
+		site.visits += entry.Visits
+		site.loginPage = site.loginPage || isLoginUrl(entry.Url)
+	}
+
+	result := []siteVisits{}
+	for _, site := range sites {
+		if site.visits >= minVisits && (all || site.loginPage) {
+			result = append(result, *site)
+		}
+	}
+	rangeutil.Sort(0, len(result), func(i, k int) bool {
+		if result[i].visits != result[k].visits {
+			return result[i].visits > result[k].visits
+		}
+		return result[i].domain < result[k].domain
+	},
+		func(i, k int) {
+			result[i], result[k] = result[k], result[i]
+		})
+	return result
+}
+
+func showCoverage(vault *onepass.Vault, historyPath string, minVisits int, all bool) {
+	history, err := browser.ReadHistory(historyPath)
+	if err != nil {
+		fatalErr(err, "Unable to read browser history")
+	}
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	vaultDomains := map[string]bool{}
+	for _, item := range items {
+		if !item.Trashed && item.Location != "" {
+			vaultDomains[urlDomain(item.Location)] = true
+		}
+	}
+
+	sites := untrackedSites(history, vaultDomains, minVisits, all)
+	if len(sites) == 0 {
+		fmt.Printf("All frequently visited sites with logins have items in the vault\n")
+		return
+	}
+	for _, site := range sites {
+		fmt.Printf("%s (%d visits)\n", site.domain, site.visits)
+	}
+}
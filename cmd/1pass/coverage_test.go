@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestSiteDomain(t *testing.T) {
+	cases := map[string]string{
+		"accounts.google.com": "google.com",
+		"github.com":          "github.com",
+		"www.bbc.co.uk":       "bbc.co.uk",
+		"localhost":           "localhost",
+	}
+	for host, expected := range cases {
+		if domain := siteDomain(host); domain != expected {
+			t.Errorf("siteDomain(%s) = %s, expected %s", host, domain, expected)
+		}
+	}
+}
+
+func TestUntrackedSites(t *testing.T) {
+	history := []onepass.HistoryEntry{
+		{Url: "https://github.com/login", Visits: 3},
+		{Url: "https://github.com/robertknight/1pass", Visits: 20},
+		{Url: "https://accounts.example.org/signin", Visits: 2},
+		{Url: "https://www.example.org/", Visits: 10},
+		{Url: "https://news.ycombinator.com/", Visits: 50},
+		{Url: "https://bank.co.uk/account", Visits: 1},
+		{Url: "file:///home/user/login.html", Visits: 100},
+	}
+	vaultDomains := map[string]bool{"github.com": true}
+
+	sites := untrackedSites(history, vaultDomains, 5, false)
+	if len(sites) != 1 || sites[0].domain != "example.org" || sites[0].visits != 12 {
+		t.Errorf("Unexpected untracked sites: %v", sites)
+	}
+
+	sites = untrackedSites(history, vaultDomains, 1, true)
+	if len(sites) != 3 || sites[0].domain != "ycombinator.com" {
+		t.Errorf("Unexpected untracked sites with 'all': %v", sites)
+	}
+}
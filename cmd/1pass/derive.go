@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+const defaultDerivedPasswordLength = 16
+
+func derivePasswordHelp() string {
+	return `Replaces the stored password of an item with a recipe from which the
+password is computed using the vault's key, the site name and a counter.
+The password itself is not saved in the vault. 'copy' computes it when
+needed.
+
+Running this command again for an item which already has a derived
+password increments the counter, which changes the password. The
+passwords do not change when the master password is changed, but they
+cannot be recovered without the vault's keys. 'rotate-keys' stores the
+derived passwords in their items, since new keys would change them.
+
+Other 1Password apps will show an empty password for these items.`
+}
+
+// setDerivedPassword stores a password recipe in an item, replacing its
+// password. Unset fields in recipe are taken from the item's existing
+// recipe, if any, whose counter is incremented.
+func setDerivedPassword(vault *onepass.Vault, pattern string, recipe onepass.PasswordRecipe) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item")
+	}
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+
+	if existing := content.PasswordRecipe; existing != nil {
+		if recipe.Site == "" {
+			recipe.Site = existing.Site
+		}
+		if recipe.Counter == 0 {
+			recipe.Counter = existing.Counter + 1
+		}
+		if recipe.Length == 0 {
+			recipe.Length = existing.Length
+		}
+		recipe.Symbols = recipe.Symbols || existing.Symbols
+	}
+	if recipe.Site == "" {
+		recipe.Site = urlDomain(item.Location)
+		if recipe.Site == "" {
+			recipe.Site = urlDomain(content.PrimaryURL())
+		}
+		if recipe.Site == "" {
+			fatalErr(fmt.Errorf("Item has no website, specify a site name with --site"), "")
+		}
+	}
+	if recipe.Counter == 0 {
+		recipe.Counter = 1
+	}
+	if recipe.Length == 0 {
+		recipe.Length = defaultDerivedPasswordLength
+	}
+
+	if !content.SetPassword("") {
+		fatalErr(fmt.Errorf("Item '%s' has no password field", item.Title), "")
+	}
+	content.PasswordRecipe = &recipe
+	err = item.SetContent(content)
+	if err == nil {
+		// check that the password can be derived before saving
+		_, err = item.DerivedPassword()
+	}
+	if err == nil {
+		err = item.Save()
+	}
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Unable to update item '%s'", item.Title))
+	}
+	fmt.Printf("The password for '%s' is now derived from site '%s', counter %d.\n", item.Title, recipe.Site, recipe.Counter)
+	fmt.Printf("Use 'copy %s' to copy it and set it as the password on the website.\n", item.Uuid[0:4])
+}
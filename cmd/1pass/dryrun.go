@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// set by the '-dry-run' flag. When set, changes to the vault
+// are printed instead of being made and plugins are not run
+var dryRun bool
+
+// commands which support the '-dry-run' flag
+var dryRunModes = map[string]bool{
+	"add-tag":         true,
+	"edit":            true,
+	"empty-trash":     true,
+	"import":          true,
+	"import-csv":      true,
+	"import-lastpass": true,
+	"move":            true,
+	"normalize-urls":  true,
+	"remove":          true,
+	"remove-tag":      true,
+	"rename":          true,
+	"restore":         true,
+	"retag":           true,
+	"rotate-key":      true,
+	"rotate-keys":     true,
+	"sync":            true,
+	"trash":           true,
+}
+
+// printDryRunChange prints a change which would have been made
+// to an item, for vaults with DryRun set. The values of
+// passwords and other secret fields are not shown.
+func printDryRunChange(change onepass.ItemChange) {
+	id := change.Uuid[0:4]
+	switch change.Action {
+	case onepass.ItemAdded:
+		fmt.Printf("Would add '%s' (%s)\n", change.Title, id)
+	case onepass.ItemRemoved:
+		fmt.Printf("Would remove '%s' (%s)\n", change.Title, id)
+	default:
+		if len(change.Changes) == 0 {
+			fmt.Printf("Would save '%s' (%s) without changes\n", change.Title, id)
+			return
+		}
+		fmt.Printf("Would update '%s' (%s)\n", change.Title, id)
+		for _, diff := range change.Changes {
+			fmt.Printf("  %s\n", diff)
+		}
+	}
+}
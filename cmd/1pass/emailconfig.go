@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// mailServer holds the connection settings for an incoming
+// or outgoing mail server from an Email Account item
+type mailServer struct {
+	host     string
+	port     string
+	username string
+	password string
+	// one of 'ssl', 'starttls' or 'none'
+	security string
+}
+
+// mailServerSecurity normalizes the value of an Email Account
+// item's 'security' field, guessing from the port if unset
+func mailServerSecurity(value string, port string) string {
+	switch strings.ToLower(value) {
+	case "ssl":
+		return "ssl"
+	case "tls", "starttls":
+		return "starttls"
+	case "none", "off":
+		return "none"
+	}
+	switch port {
+	case "993", "995", "465":
+		return "ssl"
+	}
+	return "starttls"
+}
+
+func readMailServer(content *onepass.ItemContent, prefix string, defaultPorts map[string]string) mailServer {
+	server := mailServer{
+		host:     content.FieldValue(prefix + "_server"),
+		port:     content.FieldValue(prefix + "_port"),
+		username: content.FieldValue(prefix + "_username"),
+		password: content.FieldValue(prefix + "_password"),
+	}
+	server.security = mailServerSecurity(content.FieldValue(prefix+"_security"), server.port)
+	if server.port == "" {
+		server.port = defaultPorts[server.security]
+	}
+	return server
+}
+
+// quotes a string for use in a muttrc file
+func muttQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(value) + `"`
+}
+
+func muttConfig(protocol string, incoming mailServer, outgoing mailServer) string {
+	config := ""
+	scheme := protocol
+	if incoming.security == "ssl" {
+		scheme += "s"
+	}
+	if protocol == "imap" {
+		config += fmt.Sprintf("set imap_user = %s\n", muttQuote(incoming.username))
+		config += fmt.Sprintf("set imap_pass = %s\n", muttQuote(incoming.password))
+		config += fmt.Sprintf("set folder = %s\n", muttQuote(fmt.Sprintf("%s://%s:%s/", scheme, incoming.host, incoming.port)))
+		config += "set spoolfile = \"+INBOX\"\n"
+	} else {
+		config += fmt.Sprintf("set pop_user = %s\n", muttQuote(incoming.username))
+		config += fmt.Sprintf("set pop_pass = %s\n", muttQuote(incoming.password))
+		config += fmt.Sprintf("set pop_host = %s\n", muttQuote(fmt.Sprintf("%s://%s:%s", scheme, incoming.host, incoming.port)))
+	}
+	if outgoing.host != "" {
+		smtpScheme := "smtp"
+		if outgoing.security == "ssl" {
+			smtpScheme = "smtps"
+		}
+		config += fmt.Sprintf("set smtp_url = %s\n", muttQuote(fmt.Sprintf("%s://%s@%s:%s/", smtpScheme,
+			strings.Replace(outgoing.username, "@", "%40", -1), outgoing.host, outgoing.port)))
+		config += fmt.Sprintf("set smtp_pass = %s\n", muttQuote(outgoing.password))
+	}
+	if incoming.security == "starttls" || outgoing.security == "starttls" {
+		config += "set ssl_starttls = yes\n"
+	}
+	if incoming.security != "none" && outgoing.security != "none" {
+		config += "set ssl_force_tls = yes\n"
+	}
+	return config
+}
+
+// Thunderbird 'socketType' pref values for each
+// security setting
+var thunderbirdSocketTypes = map[string]int{
+	"none":     0,
+	"starttls": 2,
+	"ssl":      3,
+}
+
+func thunderbirdConfig(protocol string, incoming mailServer, outgoing mailServer) string {
+	config := "// Thunderbird does not store passwords in preferences. It will\n" +
+		"// prompt for the password when first connecting to each server.\n"
+	pref := func(name string, value interface{}) {
+		if str, ok := value.(string); ok {
+			if number, err := strconv.Atoi(str); err == nil && strings.HasSuffix(name, ".port") {
+				value = number
+			} else {
+				value = fmt.Sprintf("%q", str)
+			}
+		}
+		config += fmt.Sprintf("user_pref(\"%s\", %v);\n", name, value)
+	}
+	pref("mail.server.server1.type", map[string]string{"imap": "imap", "pop": "pop3"}[protocol])
+	pref("mail.server.server1.hostname", incoming.host)
+	pref("mail.server.server1.port", incoming.port)
+	pref("mail.server.server1.userName", incoming.username)
+	pref("mail.server.server1.socketType", thunderbirdSocketTypes[incoming.security])
+	if outgoing.host != "" {
+		pref("mail.smtpservers", "smtp1")
+		pref("mail.smtpserver.smtp1.hostname", outgoing.host)
+		pref("mail.smtpserver.smtp1.port", outgoing.port)
+		pref("mail.smtpserver.smtp1.username", outgoing.username)
+		pref("mail.smtpserver.smtp1.try_ssl", thunderbirdSocketTypes[outgoing.security])
+	}
+	return config
+}
+
+// renderEmailConfig returns the configuration for an email client
+// using the incoming and outgoing mail servers from an
+// Email Account item
+func renderEmailConfig(content *onepass.ItemContent, format string) (string, error) {
+	protocol := "imap"
+	incomingPorts := map[string]string{"ssl": "993", "starttls": "143", "none": "143"}
+	if strings.HasPrefix(strings.ToLower(content.FieldValue("pop_type")), "pop") {
+		protocol = "pop"
+		incomingPorts = map[string]string{"ssl": "995", "starttls": "110", "none": "110"}
+	}
+	incoming := readMailServer(content, "pop", incomingPorts)
+	if incoming.host == "" {
+		return "", fmt.Errorf("Email account has no incoming mail server")
+	}
+	outgoing := readMailServer(content, "smtp", map[string]string{"ssl": "465", "starttls": "587", "none": "25"})
+	if outgoing.username == "" {
+		outgoing.username = incoming.username
+	}
+	if outgoing.password == "" {
+		outgoing.password = incoming.password
+	}
+
+	switch format {
+	case "", "mutt":
+		return muttConfig(protocol, incoming, outgoing), nil
+	case "thunderbird":
+		return thunderbirdConfig(protocol, incoming, outgoing), nil
+	default:
+		return "", fmt.Errorf("Unknown email client format '%s'. Supported formats are 'mutt' and 'thunderbird'", format)
+	}
+}
+
+func showEmailConfig(vault *onepass.Vault, pattern string, format string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find email account item")
+	}
+	if item.TypeName != "wallet.onlineservices.Email.v2" {
+		fatalErr(fmt.Errorf("'%s' is not an Email Account item", item.Title), "")
+	}
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+	config, err := renderEmailConfig(&content, format)
+	if err != nil {
+		fatalErr(err, "")
+	}
+	fmt.Print(config)
+	recordItemUse(vault, item)
+}
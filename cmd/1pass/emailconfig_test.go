@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestRenderEmailConfig(t *testing.T) {
+	content, _ := onepass.StandardTemplate("wallet.onlineservices.Email.v2")
+	values := map[string]string{
+		"pop_type":     "IMAP",
+		"pop_server":   "imap.example.com",
+		"pop_username": "jim@example.com",
+		"pop_password": `pa"ss`,
+		"pop_security": "SSL",
+		"smtp_server":  "smtp.example.com",
+		"smtp_port":    "587",
+	}
+	for i, section := range content.Sections {
+		for j, field := range section.Fields {
+			if value, ok := values[field.Name]; ok {
+				content.Sections[i].Fields[j].Value = value
+			}
+		}
+	}
+
+	config, err := renderEmailConfig(&content, "mutt")
+	if err != nil {
+		t.Fatalf("Unable to render mutt config: %v", err)
+	}
+	for _, line := range []string{
+		`set imap_pass = "pa\"ss"`,
+		`set folder = "imaps://imap.example.com:993/"`,
+		`set smtp_url = "smtp://jim%40example.com@smtp.example.com:587/"`,
+		`set ssl_starttls = yes`,
+	} {
+		if !strings.Contains(config, line+"\n") {
+			t.Errorf("Expected mutt config to contain '%s', got:\n%s", line, config)
+		}
+	}
+
+	config, err = renderEmailConfig(&content, "thunderbird")
+	if err != nil {
+		t.Fatalf("Unable to render Thunderbird config: %v", err)
+	}
+	if !strings.Contains(config, `user_pref("mail.server.server1.port", 993);`) ||
+		!strings.Contains(config, `user_pref("mail.server.server1.socketType", 3);`) ||
+		!strings.Contains(config, `user_pref("mail.smtpserver.smtp1.try_ssl", 2);`) {
+		t.Errorf("Unexpected Thunderbird config:\n%s", config)
+	}
+	if strings.Contains(config, "pa\\\"ss") {
+		t.Errorf("Thunderbird config should not include the password")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/pdf"
+)
+
+func emergencyKitHelp() string {
+	return `The emergency kit is a password-protected PDF containing the vault's
+location and password hint, a space to write the master password and
+the contents of any items matching [pattern].
+
+The PDF password must be different from the master password. If no
+password is entered, a random password is generated. Store the PDF
+password separately from the printed kit.`
+}
+
+// createEmergencyKit writes a printable, password-protected PDF
+// with the details needed to recover access to the vault and the
+// contents of the items matching pattern, if not empty
+func createEmergencyKit(vault *onepass.Vault, path string, pattern string) {
+	var items []onepass.Item
+	if pattern != "" {
+		var err error
+		items, err = lookupItems(vault, pattern)
+		if err != nil {
+			fatalErr(err, "Unable to list vault items")
+		}
+		if len(items) == 0 {
+			fatalErr(fmt.Errorf("No items match '%s'", pattern), "")
+		}
+	}
+
+	password, err := readNewPassword("PDF password")
+	if err != nil {
+		fatalErr(err, "")
+	}
+	generated := false
+	if password == "" {
+		password = genPassword("default", 20, "emergency-kit")
+		generated = true
+	}
+	if _, err := onepass.UnlockProfileKeys(vault.Path, vault.Profile, masterPassword([]byte(password))); err == nil {
+		fatalErr(fmt.Errorf("The PDF password must be different from the master password"), "")
+	}
+
+	hint, err := vault.PasswordHint()
+	if err != nil {
+		hint = ""
+	}
+
+	doc := pdf.NewDocument()
+	doc.AddLine("1Password Emergency Kit", pdf.Heading)
+	doc.AddLine(fmt.Sprintf("Created %s", time.Now().Format("02/01/2006 15:04")), pdf.Normal)
+	doc.AddSpace()
+	doc.AddLine(fmt.Sprintf("Vault: %s", vault.Path), pdf.Normal)
+	doc.AddLine(fmt.Sprintf("Password hint: %s", hint), pdf.Normal)
+	doc.AddSpace()
+	doc.AddLine("Master password: ____________________________________________", pdf.Normal)
+	doc.AddSpace()
+	if required, _ := vault.RequiresKeyFile(); required {
+		doc.AddLine("The vault also requires a key file, which is not included in this kit.", pdf.Normal)
+		doc.AddSpace()
+	}
+
+	for _, item := range items {
+		content, err := item.Content()
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+		}
+		doc.AddSpace()
+		doc.AddLine(fmt.Sprintf("%s (%s)", item.Title, item.Type()), pdf.Heading)
+		doc.AddLine(content.String(), pdf.Mono)
+		if content.Notes != "" {
+			doc.AddLine("Notes:", pdf.Mono)
+			doc.AddLine(content.Notes, pdf.Mono)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fatalErr(err, "Unable to create emergency kit")
+	}
+	err = doc.Write(file, password)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatalErr(err, "Unable to write emergency kit")
+	}
+
+	fmt.Printf("Saved emergency kit with %d item(s) to %s\n", len(items), path)
+	if generated {
+		fmt.Printf("PDF password: %s\n", password)
+		fmt.Printf("Write this password down and store it separately from the printed kit.\n")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/rangeutil"
+)
+
+func setExpiryHelp() string {
+	return `Sets the date, in the format DD/MM/YY, after which matching items are no
+longer needed, eg. for contractor accounts or trial license keys. The
+date is shown in 'list' output and 'expired' reports or trashes items
+once it has passed.`
+}
+
+func expiredHelp() string {
+	return `Lists items whose expiry date, set with 'set-expiry', has passed. This
+command does not require the vault to be unlocked unless private titles
+are enabled, so it can be run periodically from cron to warn about or
+clean up expired temporary credentials.
+
+Exits with a non-zero status if any items have expired.`
+}
+
+func itemExpiry(item onepass.Item) time.Time {
+	return time.Unix(int64(item.ExpiresAt), 0)
+}
+
+// expiryState returns a description of an item's expiry
+// date for use in item lists
+func expiryState(item onepass.Item, now time.Time) string {
+	if item.ExpiresAt == 0 {
+		return ""
+	}
+	expiry := itemExpiry(item)
+	if expiry.Before(now) {
+		return fmt.Sprintf(" (expired %s)", expiry.Format("02/01/06"))
+	}
+	return fmt.Sprintf(" (expires %s)", expiry.Format("02/01/06"))
+}
+
+func setItemExpiry(vault *onepass.Vault, pattern string, date string) {
+	var expiresAt uint64
+	if date != "never" {
+		value, err := onepass.FieldValueFromString("date", date)
+		if err != nil {
+			fatalErr(err, "Invalid expiry date")
+		}
+		// items expire at the end of the given day
+		expiresAt = uint64(value.(int64)) + 24*60*60 - 1
+	}
+
+	items, err := lookupItems(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	if len(items) == 0 {
+		fatalErr(fmt.Errorf("No matching items"), "")
+	}
+	for _, item := range items {
+		logItemAction("Setting expiry date of item", item)
+		item.ExpiresAt = expiresAt
+		err = item.Save()
+		if err != nil {
+			fatalErr(err, "Unable to save item")
+		}
+	}
+}
+
+// expiredItems returns the items from items which are not in the
+// trash and which expire before 'before', earliest first
+func expiredItems(items []onepass.Item, before time.Time) []onepass.Item {
+	expired := []onepass.Item{}
+	for _, item := range items {
+		if item.ExpiresAt != 0 && !item.Trashed && itemExpiry(item).Before(before) {
+			expired = append(expired, item)
+		}
+	}
+	rangeutil.Sort(0, len(expired), func(i, k int) bool {
+		return expired[i].ExpiresAt < expired[k].ExpiresAt
+	},
+		func(i, k int) {
+			expired[i], expired[k] = expired[k], expired[i]
+		})
+	return expired
+}
+
+// listExpiredItems lists items which have expired or expire within
+// 'within' and optionally moves expired items to the trash
+func listExpiredItems(vault *onepass.Vault, within time.Duration, trash bool) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	now := time.Now()
+	anyExpired := false
+	for _, item := range expiredItems(items, now.Add(within)) {
+		expired := itemExpiry(item).Before(now)
+		anyExpired = anyExpired || expired
+		if expired && trash {
+			logItemAction("Trashing expired item", item)
+			item.Trashed = true
+			err = item.Save()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to trash item: %s\n", err)
+			}
+			continue
+		}
+		fmt.Printf("%s (%s, %s)%s\n", item.Title, item.Type(), item.Uuid[0:4], expiryState(item, now))
+	}
+	if anyExpired && !trash {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestExpiredItems(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)
+	day := uint64(24 * 60 * 60)
+	nowUnix := uint64(now.Unix())
+	items := []onepass.Item{
+		{Title: "No expiry"},
+		{Title: "Next week", ExpiresAt: nowUnix + 7*day},
+		{Title: "Yesterday", ExpiresAt: nowUnix - day},
+		{Title: "Last month", ExpiresAt: nowUnix - 30*day},
+		{Title: "Trashed", ExpiresAt: nowUnix - day, Trashed: true},
+	}
+
+	expired := expiredItems(items, now)
+	if len(expired) != 2 || expired[0].Title != "Last month" || expired[1].Title != "Yesterday" {
+		t.Errorf("Unexpected expired items: %v", expired)
+	}
+	expiring := expiredItems(items, now.Add(10*24*time.Hour))
+	if len(expiring) != 3 || expiring[2].Title != "Next week" {
+		t.Errorf("Unexpected expiring items: %v", expiring)
+	}
+
+	if state := expiryState(items[0], now); state != "" {
+		t.Errorf("Unexpected expiry state for item without expiry: '%s'", state)
+	}
+	if state := expiryState(items[2], now); state != " (expired 31/05/26)" {
+		t.Errorf("Unexpected expiry state for expired item: '%s'", state)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// fieldFilter matches items which have a field with a given value,
+// eg. to find all accounts registered with an email address
+type fieldFilter struct {
+	// how fields are selected. One of 'designation' or 'name' for
+	// form fields, 'name' or 'title' for fields in item sections or
+	// 'username' to match the item's username
+	key   string
+	field string
+	value string
+}
+
+// parseFieldFilter parses a filter in the form 'key=field:value',
+// eg. 'designation=username:alice@example.com'
+func parseFieldFilter(spec string) (fieldFilter, error) {
+	eqPos := strings.Index(spec, "=")
+	colonPos := strings.Index(spec, ":")
+	if eqPos == -1 || colonPos < eqPos {
+		return fieldFilter{}, fmt.Errorf("Field filter '%s' is not in the form 'key=field:value'", spec)
+	}
+	filter := fieldFilter{
+		key:   spec[:eqPos],
+		field: spec[eqPos+1 : colonPos],
+		value: spec[colonPos+1:],
+	}
+	switch filter.key {
+	case "designation", "name", "title":
+		return filter, nil
+	default:
+		return fieldFilter{}, fmt.Errorf("Unknown field filter key '%s'. Use 'designation', 'name' or 'title'", filter.key)
+	}
+}
+
+func (filter fieldFilter) matches(content *onepass.ItemContent) bool {
+	if filter.key == "username" {
+		return strings.EqualFold(content.Username(), filter.value)
+	}
+	for _, field := range content.FormFields {
+		var selector string
+		switch filter.key {
+		case "designation":
+			selector = field.Designation
+		case "name":
+			selector = field.Name
+		}
+		if selector == filter.field && strings.EqualFold(field.Value, filter.value) {
+			return true
+		}
+	}
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			var selector string
+			switch filter.key {
+			case "name":
+				selector = field.Name
+			case "title":
+				selector = field.Title
+			}
+			if selector == filter.field && strings.EqualFold(field.ValueString(), filter.value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterItemsByField returns the items from items whose decrypted
+// content matches all of the given filters
+func filterItemsByField(vault *onepass.Vault, items []onepass.Item, filters []fieldFilter) []onepass.Item {
+	candidates := []onepass.Item{}
+	for _, item := range items {
+		if !strings.HasPrefix(item.TypeName, "system.") {
+			candidates = append(candidates, item)
+		}
+	}
+	contents, err := vault.ItemContents(candidates)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+
+	matches := []onepass.Item{}
+	for i, item := range candidates {
+		matchesAll := true
+		for _, filter := range filters {
+			if !filter.matches(&contents[i]) {
+				matchesAll = false
+				break
+			}
+		}
+		if matchesAll {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestFieldFilter(t *testing.T) {
+	login := onepass.ItemContent{
+		FormFields: []onepass.WebFormField{
+			{Name: "login_email", Designation: "username", Value: "Alice@Example.com"},
+			{Name: "pass", Designation: "password", Value: "secret"},
+		},
+	}
+	server := onepass.ItemContent{
+		Sections: []onepass.ItemSection{
+			{Fields: []onepass.ItemField{
+				{Kind: "string", Name: "username", Title: "username", Value: "root"},
+				{Kind: "string", Name: "admin_console_username", Title: "admin username", Value: "alice@example.com"},
+			}},
+		},
+	}
+
+	cases := []struct {
+		filter  string
+		content onepass.ItemContent
+		matches bool
+	}{
+		{"designation=username:alice@example.com", login, true},
+		{"designation=password:alice@example.com", login, false},
+		{"name=login_email:alice@example.com", login, true},
+		{"title=admin username:alice@example.com", server, true},
+		{"name=username:alice@example.com", server, false},
+		{"name=username:root", server, true},
+	}
+	for _, testCase := range cases {
+		filter, err := parseFieldFilter(testCase.filter)
+		if err != nil {
+			t.Fatalf("Unable to parse filter '%s': %v", testCase.filter, err)
+		}
+		if filter.matches(&testCase.content) != testCase.matches {
+			t.Errorf("Expected match %v for filter '%s'", testCase.matches, testCase.filter)
+		}
+	}
+
+	usernameFilter := fieldFilter{key: "username", value: "root"}
+	if !usernameFilter.matches(&server) || usernameFilter.matches(&login) {
+		t.Errorf("Unexpected result for username filter")
+	}
+
+	for _, invalid := range []string{"username", "designation:username=x", "color=red:x"} {
+		if _, err := parseFieldFilter(invalid); err == nil {
+			t.Errorf("Expected error parsing filter '%s'", invalid)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/onepass"
+)
+
+// path of the vault whose 'PasswordRecipes' setting is
+// used by genDefaultPassword()
+var recipeVaultPath string
+
+func genRecipeHelp() string {
+	return fmt.Sprintf(`The recipe can be adjusted with '--symbols', '--no-digits',
+'--exclude-ambiguous', '--require' and '--chars'. The recipe used when
+no '--recipe' is given, and when '-' is entered for a new password, can
+be set for each vault with the 'PasswordRecipes' setting in ~/.1pass,
+which maps vault paths or names (see 'set-vault') to recipes, eg.
+{"work": {"Length": 20, "Symbols": true, "Require": ["symbol"]}}.
+A path or name of '*' sets the recipe for other vaults.
+
+'--words' generates a passphrase of random words from the wordlist given
+by '--wordlist' or the 'Wordlist' setting in ~/.1pass, which is the path
+of a file with one word per line. Wordlists in the Diceware format, with
+a number before each word, can also be used. The list must contain at
+least %d distinct words. Recipes in 'PasswordRecipes' can set 'Words'
+and 'Separator' to generate passphrases from the 'Wordlist' file.`, onepass.MinWordlistSize)
+}
+
+// configuredGenRecipe returns the recipe set for the vault at
+// vaultPath by the 'PasswordRecipes' setting, if any
+func configuredGenRecipe(config clientConfig, vaultPath string) (onepass.GenRecipe, bool) {
+	for _, key := range vaultSettingKeys(config, vaultPath) {
+		if recipe, ok := config.PasswordRecipes[key]; ok {
+			return recipe, true
+		}
+	}
+	return onepass.GenRecipe{}, false
+}
+
+// applyGenRecipeFlags returns recipe adjusted by the flags
+// of 'gen-password' and whether any of them were used
+func applyGenRecipeFlags(recipe onepass.GenRecipe, flags cmdmodes.FlagValues) (onepass.GenRecipe, bool, error) {
+	changed := false
+	if flags.Bool("symbols") {
+		recipe.Symbols = true
+		changed = true
+	}
+	if flags.Bool("no-digits") {
+		recipe.NoDigits = true
+		changed = true
+	}
+	if flags.Bool("exclude-ambiguous") {
+		recipe.ExcludeAmbiguous = true
+		changed = true
+	}
+	if flags.Bool("chars") {
+		recipe.Chars = flags.String("chars")
+		changed = true
+	}
+	if flags.Bool("require") {
+		for _, class := range strings.Split(flags.String("require"), ",") {
+			class = strings.TrimSpace(class)
+			if _, ok := onepass.CharClasses[class]; !ok {
+				return recipe, false, fmt.Errorf("Unknown character class '%s'. Use lower, upper, digit or symbol", class)
+			}
+			recipe.Require = append(recipe.Require, class)
+		}
+		changed = true
+	}
+	return recipe, changed, nil
+}
+
+// applyPassphraseFlags returns recipe changed to generate passphrases
+// if '--words' or '--wordlist' are used, and whether it was changed
+func applyPassphraseFlags(recipe onepass.GenRecipe, flags cmdmodes.FlagValues) (onepass.GenRecipe, bool, error) {
+	if !flags.Bool("words") && !flags.Bool("wordlist") {
+		return recipe, false, nil
+	}
+	if recipe.Words == 0 {
+		recipe.Words = onepass.DefaultPassphraseWords
+	}
+	if flags.Bool("words") {
+		count, err := strconv.Atoi(flags.String("words"))
+		if err != nil || count < 1 {
+			return recipe, false, fmt.Errorf("Invalid number of words '%s'", flags.String("words"))
+		}
+		recipe.Words = count
+	}
+	if flags.Bool("wordlist") {
+		words, err := onepass.ReadWordlist(flags.String("wordlist"))
+		if err != nil {
+			return recipe, false, fmt.Errorf("Unable to read wordlist: %v", err)
+		}
+		recipe.Wordlist = words
+	}
+	return recipe, true, nil
+}
+
+// loadRecipeWordlist returns recipe with its wordlist read from
+// the 'Wordlist' setting if it generates passphrases and does
+// not already have one
+func loadRecipeWordlist(config clientConfig, recipe onepass.GenRecipe) (onepass.GenRecipe, error) {
+	if recipe.Words == 0 || len(recipe.Wordlist) > 0 {
+		return recipe, nil
+	}
+	if config.Wordlist == "" {
+		return recipe, fmt.Errorf("No wordlist for passphrases. Use '--wordlist' or set 'Wordlist' in ~/.1pass")
+	}
+	words, err := onepass.ReadWordlist(os.ExpandEnv(config.Wordlist))
+	if err != nil {
+		return recipe, fmt.Errorf("Unable to read wordlist: %v", err)
+	}
+	recipe.Wordlist = words
+	return recipe, nil
+}
+
+func genPasswordWithRecipe(recipe onepass.GenRecipe, length int, purpose string) string {
+	recipe, err := loadRecipeWordlist(readConfig(), recipe)
+	if err != nil {
+		fatalErr(err, "")
+	}
+	agentClient := connectToAgent(readConfig(), "")
+	password, err := agentClient.GenPasswordWithRecipe(recipe, length, purpose)
+	if err != nil {
+		fatalErr(err, "Unable to generate password")
+	}
+	return password
+}
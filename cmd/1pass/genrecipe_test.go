@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestConfiguredGenRecipe(t *testing.T) {
+	config := clientConfig{
+		Vaults: map[string]string{"work": "/vaults/Work.agilekeychain"},
+		PasswordRecipes: map[string]onepass.GenRecipe{
+			"work": {Length: 20, Symbols: true},
+		},
+	}
+	recipe, ok := configuredGenRecipe(config, "/vaults/Work.agilekeychain")
+	if !ok || recipe.Length != 20 || !recipe.Symbols {
+		t.Errorf("Unexpected recipe for named vault: %+v", recipe)
+	}
+	if _, ok := configuredGenRecipe(config, "/vaults/Other.agilekeychain"); ok {
+		t.Errorf("Expected no recipe for other vaults")
+	}
+	config.PasswordRecipes["*"] = onepass.GenRecipe{Length: 8}
+	if recipe, _ := configuredGenRecipe(config, "/vaults/Other.agilekeychain"); recipe.Length != 8 {
+		t.Errorf("Unexpected default recipe: %+v", recipe)
+	}
+}
+
+func TestApplyGenRecipeFlags(t *testing.T) {
+	base := onepass.GenRecipes["default"]
+	recipe, changed, err := applyGenRecipeFlags(base, cmdmodes.FlagValues{})
+	if err != nil || changed {
+		t.Errorf("Expected recipe to be unchanged without flags")
+	}
+	recipe, changed, err = applyGenRecipeFlags(base, cmdmodes.FlagValues{
+		"symbols":           {""},
+		"exclude-ambiguous": {""},
+		"require":           {"digit, symbol"},
+	})
+	if err != nil || !changed || !recipe.Symbols || !recipe.ExcludeAmbiguous || len(recipe.Require) != 2 {
+		t.Errorf("Unexpected recipe from flags: %+v, %v", recipe, err)
+	}
+	if _, _, err := applyGenRecipeFlags(base, cmdmodes.FlagValues{"require": {"emoji"}}); err == nil {
+		t.Errorf("Expected unknown character class to be rejected")
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/robertknight/1pass/cmd/1pass
+
+go 1.26.0
+
+require (
+	github.com/robertknight/1pass v0.1.0
+	github.com/robertknight/1pass/onepass v0.1.0
+	golang.org/x/crypto v0.57.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.52 // indirect
+	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	golang.org/x/term v0.46.0 // indirect
+)
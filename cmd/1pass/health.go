@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+// upgradeKdfFlags are shared by 'upgrade-kdf' and its
+// 'strengthen' alias
+var upgradeKdfFlags = []cmdmodes.Flag{
+	{Name: "iterations", ArgName: "count", Description: fmt.Sprintf("Number of PBKDF2 iterations. Defaults to %d", onepass.RecommendedPbkdfIterations)},
+}
+
+func upgradeKdfHelp() string {
+	return fmt.Sprintf(`Re-encrypts the vault's keys with the master password using more
+iterations of PBKDF2, which makes guessing the master password from a copy
+of the vault slower. Vaults with fewer than %d iterations, such as those
+created with '-low-security' or by old versions of 1Password, are
+reported as weak by 'info'.
+
+The master password and the vault's items are not changed. The time taken
+to unlock the vault before and after the change is reported, since each
+additional iteration also makes unlocking the vault slower.`, onepass.MinPbkdfIterations)
+}
+
+// vaultHealthIssues returns a list of problems with the vault's
+// configuration which weaken its security
+func vaultHealthIssues(vault *onepass.Vault) []string {
+	issues := []string{}
+	iterations, weak, err := weakKdfIterations(vault)
+	if err != nil {
+		issues = append(issues, fmt.Sprintf("Unable to read encryption keys: %v", err))
+	} else if weak {
+		issues = append(issues, fmt.Sprintf("Keys are protected with only %d PBKDF2 iterations. "+
+			"Run 'upgrade-kdf' to strengthen them.", iterations))
+	}
+	return issues
+}
+
+// weakKdfIterations returns the number of PBKDF2 iterations used to
+// protect the vault's keys and whether that is too few. Keys derived
+// using scrypt or argon2id are never reported as weak.
+func weakKdfIterations(vault *onepass.Vault) (int, bool, error) {
+	kdf, _, err := vault.KeyKdf()
+	if err != nil || kdf != onepass.KdfPbkdf2 {
+		return 0, false, err
+	}
+	iterations, err := vault.KeyIterations()
+	return iterations, err == nil && iterations < onepass.MinPbkdfIterations, err
+}
+
+// warnIfWeakKdf prints a warning if the vault's keys are
+// protected with too few PBKDF2 iterations
+func warnIfWeakKdf(vault *onepass.Vault) {
+	if iterations, weak, _ := weakKdfIterations(vault); weak {
+		fmt.Fprintf(os.Stderr, "Warning: The vault's keys are protected with only %d PBKDF2 iterations. "+
+			"Run 'upgrade-kdf' to strengthen them.\n", iterations)
+	}
+}
+
+func printVaultInfo(vault *onepass.Vault, reminderDays int) {
+	fmt.Printf("Vault path: %s\n", vault.Path)
+	if profiles, err := onepass.VaultProfiles(vault.Path); err == nil && len(profiles) > 1 {
+		fmt.Printf("Profile: %s (of %d)\n", vault.Profile, len(profiles))
+	}
+	kdf, params, err := vault.KeyKdf()
+	switch {
+	case err != nil:
+	case kdf == onepass.KdfScrypt:
+		fmt.Printf("Key derivation: scrypt, N=%d, r=%d, p=%d\n", params.N, params.R, params.P)
+	case kdf == onepass.KdfArgon2id:
+		fmt.Printf("Key derivation: argon2id, %d passes, %d KiB, %d threads\n", params.Time, params.Memory, params.Threads)
+	default:
+		if iterations, err := vault.KeyIterations(); err == nil {
+			fmt.Printf("Key derivation: PBKDF2, %d iterations\n", iterations)
+		}
+	}
+	if scheme, err := vault.KeyScheme(); err == nil {
+		fmt.Printf("Key encryption: %s\n", scheme)
+	}
+	if required, err := vault.RequiresKeyFile(); err == nil && required {
+		fmt.Printf("Key file: required\n")
+	}
+	passwordAge, ok, err := vault.PasswordAge()
+	if err == nil {
+		fmt.Printf("Master password changed: %s\n", formatAge(passwordAge, ok))
+	}
+	keyAge, ok, err := vault.KeyAge()
+	if err == nil {
+		fmt.Printf("Keys created: %s\n", formatAge(keyAge, ok))
+	}
+	issues := append(vaultHealthIssues(vault), rotationReminders(vault, reminderDays)...)
+	if len(issues) == 0 {
+		fmt.Printf("Health: OK\n")
+		return
+	}
+	fmt.Printf("Health: %d issues\n", len(issues))
+	for _, issue := range issues {
+		fmt.Printf("  %s\n", issue)
+	}
+}
+
+func rotateKeyHelp() string {
+	return `Creates new random encryption keys for the vault and re-encrypts every
+item with them, so that a copy of the old keys can no longer be used to
+read the vault. The master password is not changed.
+
+Derived passwords (see 'derive-password') are computed from the vault's
+keys, so rotating the keys would change them. Instead, each item's
+current derived password is stored in the item and its recipe removed.
+
+Back up the vault before rotating its keys. Other 1Password apps
+must be quit and the rotated vault fully synced before they are used
+again, otherwise they may save items encrypted with the old keys.
+
+'info' shows when the keys were created and a reminder is shown when
+they are older than the 'RotationReminderDays' setting in ~/.1pass,
+365 days by default.`
+}
+
+func formatAge(age time.Duration, known bool) string {
+	if !known {
+		return "unknown"
+	}
+	days := int(age.Hours() / 24)
+	return fmt.Sprintf("%s (%d days ago)", time.Now().Add(-age).Format("2006-01-02"), days)
+}
+
+// rotationReminders returns reminders to change the master password
+// or rotate the keys if they are older than reminderDays. Defaults
+// to 365 days if reminderDays is zero and disabled if it is negative.
+func rotationReminders(vault *onepass.Vault, reminderDays int) []string {
+	if reminderDays < 0 {
+		return nil
+	}
+	if reminderDays == 0 {
+		reminderDays = 365
+	}
+	limit := time.Duration(reminderDays) * 24 * time.Hour
+	reminders := []string{}
+	if age, ok, err := vault.PasswordAge(); err == nil && ok && age > limit {
+		reminders = append(reminders, fmt.Sprintf("The master password was last changed %d days ago. "+
+			"Run 'set-password' to change it.", int(age.Hours()/24)))
+	}
+	if age, ok, err := vault.KeyAge(); err == nil && ok && age > limit {
+		reminders = append(reminders, fmt.Sprintf("The vault's keys were created %d days ago. "+
+			"Run 'rotate-keys' to replace them.", int(age.Hours()/24)))
+	}
+	return reminders
+}
+
+// warnIfRotationOverdue prints reminders to change the master
+// password or keys. See rotationReminders()
+func warnIfRotationOverdue(vault *onepass.Vault, reminderDays int) {
+	for _, reminder := range rotationReminders(vault, reminderDays) {
+		fmt.Fprintf(os.Stderr, "Reminder: %s\n", reminder)
+	}
+}
+
+func rotateKeys(vault *onepass.Vault, agentClient *client.AgentClient, masterPwd []byte) {
+	err := vault.RotateKeys(masterPwd)
+	if err != nil {
+		fatalErr(err, "Unable to rotate keys")
+	}
+	if vault.DryRun {
+		return
+	}
+	// the agent holds a copy of the old keys
+	err = agentClient.Lock()
+	if err != nil {
+		fatalErr(err, "Unable to lock the vault after rotating keys")
+	}
+	fmt.Printf("The vault's keys have been replaced and all items re-encrypted.\n")
+}
+
+func upgradeKdf(vault *onepass.Vault, masterPwd []byte, iterations int) {
+	previousUnlockTime, err := measureUnlockTime(vault, masterPwd)
+	if err != nil {
+		fatalErr(err, "Unable to unlock vault")
+	}
+	err = vault.UpgradeKdf(masterPwd, iterations)
+	if err != nil {
+		fatalErr(err, "Unable to upgrade key encryption")
+	}
+	fmt.Printf("The vault's keys are now protected with %d PBKDF2 iterations.\n", iterations)
+	unlockTime, err := measureUnlockTime(vault, masterPwd)
+	if err != nil {
+		fatalErr(err, "Unable to unlock vault after upgrading key encryption")
+	}
+	fmt.Printf("Unlocking the vault now takes %v on this computer (previously %v).\n",
+		unlockTime.Round(time.Millisecond), previousUnlockTime.Round(time.Millisecond))
+}
+
+// measureUnlockTime returns the time taken to decrypt the
+// vault's keys with the master password
+func measureUnlockTime(vault *onepass.Vault, masterPwd []byte) (time.Duration, error) {
+	start := time.Now()
+	keys, err := onepass.UnlockProfileKeys(vault.Path, vault.Profile, masterPwd)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(start)
+	keys.Wipe()
+	return elapsed, nil
+}
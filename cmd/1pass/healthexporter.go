@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/onepass"
+)
+
+// default address for 'health --exporter'
+const defaultExporterAddr = "localhost:9915"
+
+// items which expire within this period are
+// counted by the 'expiring_items' metric
+const expiringItemsPeriod = 30 * 24 * time.Hour
+
+func healthExporterHelp() string {
+	return `With '--exporter', the vault is kept unlocked for as long as the
+exporter runs and the results of the checks are served as metrics in the Prometheus text format at
+http://<address>/metrics, so that the vault's health can be tracked
+over time by an existing monitoring system. The vault is audited again
+for each request. The metrics are:
+
+  health_score             The vault's health score
+  weak_password_count      Number of items with weak passwords
+  reused_password_count    Number of items with reused passwords
+  old_password_count       Number of items with old passwords
+  expiring_items           Number of items which have expired or which
+                           expire within 30 days
+  items_total{type="..."}  Number of items of each type
+
+Metrics never include the titles or content of items. The exporter
+listens on ` + defaultExporterAddr + ` unless '--listen' is used.`
+}
+
+// writeHealthMetrics writes the metrics served by 'health --exporter'
+// for the given items and checks in the Prometheus text format
+func writeHealthMetrics(out io.Writer, items []onepass.Item, report healthReport, now time.Time) {
+	failed := map[string]int{}
+	for _, check := range report.Checks {
+		failed[check.Name] = len(check.Failed)
+	}
+	gauge := func(name string, help string, value int) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+	}
+	gauge("health_score", "Health score of the vault out of 100", report.Score)
+	gauge("weak_password_count", "Number of items with weak passwords", failed["weak-passwords"])
+	gauge("reused_password_count", "Number of items with passwords used by other items", failed["reused-passwords"])
+	gauge("old_password_count", "Number of items with passwords which have not been changed recently", failed["old-passwords"])
+	gauge("expiring_items", "Number of items which have expired or expire within 30 days",
+		len(expiredItems(items, now.Add(expiringItemsPeriod))))
+
+	typeCounts := map[string]int{}
+	for _, item := range items {
+		if !item.Trashed && item.TypeName != "system.Tombstone" {
+			typeCounts[item.TypeName]++
+		}
+	}
+	typeNames := []string{}
+	for typeName := range typeCounts {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	fmt.Fprintf(out, "# HELP items_total Number of items in the vault by type\n# TYPE items_total gauge\n")
+	for _, typeName := range typeNames {
+		fmt.Fprintf(out, "items_total{type=%q} %d\n", typeName, typeCounts[typeName])
+	}
+}
+
+// exporterAddr returns the address for '--exporter' to listen on
+func exporterAddr(flags cmdmodes.FlagValues) string {
+	if flags.Bool("listen") {
+		return flags.String("listen")
+	}
+	return defaultExporterAddr
+}
+
+// serveHealthMetrics serves metrics from the vault's health report
+// at http://<addr>/metrics until the process is terminated
+func serveHealthMetrics(vault *onepass.Vault, maxAge time.Duration, addr string) {
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) {
+		items, err := vault.ListItems()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to list vault items: %v", err), http.StatusInternalServerError)
+			return
+		}
+		contents, err := vault.ItemContents(items)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to decrypt items: %v", err), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		report := scoreHealthChecks(append(auditItems(items, contents, now, maxAge), auditVaultSettings(vault)...))
+
+		var metrics bytes.Buffer
+		writeHealthMetrics(&metrics, items, report, now)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(metrics.Bytes())
+	})
+	fmt.Fprintf(os.Stderr, "Serving vault health metrics at http://%s/metrics\n", addr)
+	err := http.ListenAndServe(addr, nil)
+	if err != nil {
+		fatalErr(err, "Unable to start exporter")
+	}
+}
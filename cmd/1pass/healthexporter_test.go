@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestWriteHealthMetrics(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)
+	items := []onepass.Item{
+		{Uuid: "a", TypeName: "webforms.WebForm", ExpiresAt: uint64(now.Add(10 * 24 * time.Hour).Unix())},
+		{Uuid: "b", TypeName: "webforms.WebForm", ExpiresAt: uint64(now.Add(60 * 24 * time.Hour).Unix())},
+		{Uuid: "c", TypeName: "securenotes.SecureNote"},
+		{Uuid: "d", TypeName: "securenotes.SecureNote", Trashed: true},
+		{Uuid: "e", TypeName: "system.Tombstone"},
+	}
+	weak := newHealthCheck("weak-passwords", "", 25, "")
+	weak.fail(items[0])
+	report := healthReport{Score: 75, Checks: []healthCheck{weak}}
+
+	var out bytes.Buffer
+	writeHealthMetrics(&out, items, report, now)
+	metrics := out.String()
+	for _, expected := range []string{
+		"health_score 75\n",
+		"weak_password_count 1\n",
+		"reused_password_count 0\n",
+		"expiring_items 1\n",
+		"items_total{type=\"securenotes.SecureNote\"} 1\n",
+		"items_total{type=\"webforms.WebForm\"} 2\n",
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("Expected metrics to include %q, got:\n%s", expected, metrics)
+		}
+	}
+	if strings.Contains(metrics, "system.Tombstone") {
+		t.Errorf("Metrics should not count removed items")
+	}
+}
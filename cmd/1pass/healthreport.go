@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func healthHelp() string {
+	return `Audits the vault and its items and combines the results into a score
+out of 100. Each check deducts up to a fixed number of points, in
+proportion to the number of items it applies to which fail it:
+
+  weak-passwords     25  Passwords which are easy to guess. See 'gen-password --analyze'
+  reused-passwords   20  Passwords used by more than one item
+  old-passwords      10  Passwords which have not been changed for '--max-age' days
+  missing-2fa        10  Logins without a one-time password field
+  expired-items       5  Items which have passed their expiry date
+  plaintext-titles    5  Item titles are stored unencrypted. See 'private-titles'
+  weak-kdf           25  The vault's keys use too few PBKDF2 iterations
+
+Items in the trash are not audited. Items whose password is derived
+with 'derive-password' are not reported as weak or reused.
+
+With '--json', the report is printed as a JSON object with the score and
+the result of each check, including the IDs and titles of failing items.
+
+` + healthExporterHelp()
+}
+
+// default age after which a password is reported by the
+// 'old-passwords' check
+const defaultMaxPasswordAge = 365 * 24 * time.Hour
+
+// passwords with a lower score from onepass.EstimateItemEntropy()
+// are reported as weak
+const minPasswordScore = 3
+
+type healthItem struct {
+	Uuid  string `json:"uuid"`
+	Title string `json:"title"`
+}
+
+// healthCheck is the result of one of the checks
+// performed by 'health'
+type healthCheck struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// maximum number of points deducted by the check
+	Weight int `json:"weight"`
+	// number of points deducted from the score
+	Penalty int `json:"penalty"`
+	// number of items which the check applies to, or
+	// zero for checks of the vault's settings
+	Checked int          `json:"checked"`
+	Failed  []healthItem `json:"failed"`
+	// suggested action to fix failing items or settings
+	NextStep string `json:"nextStep,omitempty"`
+}
+
+type healthReport struct {
+	Score  int           `json:"score"`
+	Checks []healthCheck `json:"checks"`
+}
+
+// passwordChangedAt returns when an item's password was last
+// changed, from its password history or creation date
+func passwordChangedAt(item onepass.Item, content onepass.ItemContent) time.Time {
+	changedAt := int64(item.CreatedAt)
+	for _, entry := range content.PasswordHistory {
+		if entry.Time > changedAt {
+			changedAt = entry.Time
+		}
+	}
+	return time.Unix(changedAt, 0)
+}
+
+func newHealthCheck(name string, description string, weight int, nextStep string) healthCheck {
+	return healthCheck{
+		Name:        name,
+		Description: description,
+		Weight:      weight,
+		Failed:      []healthItem{},
+		NextStep:    nextStep,
+	}
+}
+
+func (check *healthCheck) fail(item onepass.Item) {
+	check.Failed = append(check.Failed, healthItem{Uuid: item.Uuid, Title: item.Title})
+}
+
+// auditItems performs the checks of the passwords and other details
+// of items. contents[i] is the decrypted content of items[i].
+func auditItems(items []onepass.Item, contents []onepass.ItemContent, now time.Time, maxAge time.Duration) []healthCheck {
+	weak := newHealthCheck("weak-passwords", "Weak passwords", 25,
+		"Generate new passwords with 'gen-password' and save them with 'edit', or use 'derive-password'")
+	reused := newHealthCheck("reused-passwords", "Reused passwords", 20,
+		"Give each item its own password, starting with the most important accounts. Run 'audit reused' to list the items sharing each password")
+	old := newHealthCheck("old-passwords", "Old passwords", 10,
+		fmt.Sprintf("Change passwords which have not been changed for %d days", int(maxAge.Hours()/24)))
+	missing2fa := newHealthCheck("missing-2fa", "Logins without two-factor authentication", 10,
+		"Enable two-factor authentication for these sites where possible and add the one-time password secret to the item")
+	expired := newHealthCheck("expired-items", "Expired items", 5,
+		"Trash items which are no longer needed with 'trash' or extend them with 'set-expiry'")
+
+	passwordUsers := map[string]int{}
+	for i, item := range items {
+		if !item.Trashed && contents[i].PasswordRecipe == nil && contents[i].Password() != "" {
+			passwordUsers[contents[i].Password()]++
+		}
+	}
+
+	for i, item := range items {
+		if item.Trashed || item.TypeName == "system.folder.Regular" {
+			continue
+		}
+		content := contents[i]
+		expired.Checked++
+		if item.ExpiresAt != 0 && itemExpiry(item).Before(now) {
+			expired.fail(item)
+		}
+		if item.TypeName == "webforms.WebForm" {
+			missing2fa.Checked++
+			if content.OTPSecret() == "" {
+				missing2fa.fail(item)
+			}
+		}
+
+		password := content.Password()
+		if password == "" {
+			continue
+		}
+		old.Checked++
+		if now.Sub(passwordChangedAt(item, content)) > maxAge {
+			old.fail(item)
+		}
+		if content.PasswordRecipe != nil {
+			continue
+		}
+		weak.Checked++
+		reused.Checked++
+		if onepass.EstimateItemEntropy(item, &content).Score < minPasswordScore {
+			weak.fail(item)
+		}
+		if passwordUsers[password] > 1 {
+			reused.fail(item)
+		}
+	}
+
+	checks := []healthCheck{weak, reused, old, missing2fa, expired}
+	for i := range checks {
+		if checks[i].Checked > 0 {
+			fraction := float64(len(checks[i].Failed)) / float64(checks[i].Checked)
+			checks[i].Penalty = int(math.Ceil(fraction * float64(checks[i].Weight)))
+		}
+	}
+	return checks
+}
+
+// auditVaultSettings performs the checks of the vault's
+// settings which apply to all items
+func auditVaultSettings(vault *onepass.Vault) []healthCheck {
+	titles := newHealthCheck("plaintext-titles", "Item titles are stored unencrypted", 5,
+		"Run 'private-titles on' to encrypt item titles and websites")
+	if options, err := vault.Options(); err == nil && !options.PrivateTitles {
+		titles.Penalty = titles.Weight
+	}
+	kdf := newHealthCheck("weak-kdf", "The vault's keys are protected with too few PBKDF2 iterations", 25,
+		"Run 'upgrade-kdf' to strengthen the vault's keys")
+	if _, weak, _ := weakKdfIterations(vault); weak {
+		kdf.Penalty = kdf.Weight
+	}
+	return []healthCheck{titles, kdf}
+}
+
+// scoreHealthChecks combines the results of checks
+// into a report with an overall score
+func scoreHealthChecks(checks []healthCheck) healthReport {
+	score := 100
+	for _, check := range checks {
+		score -= check.Penalty
+	}
+	if score < 0 {
+		score = 0
+	}
+	return healthReport{Score: score, Checks: checks}
+}
+
+func showHealthReport(vault *onepass.Vault, maxAge time.Duration, asJson bool) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	contents, err := vault.ItemContents(items)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+	checks := append(auditItems(items, contents, time.Now(), maxAge), auditVaultSettings(vault)...)
+	report := scoreHealthChecks(checks)
+
+	if asJson {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fatalErr(err, "")
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Health score: %d/100\n", report.Score)
+	for _, check := range report.Checks {
+		if check.Penalty == 0 {
+			continue
+		}
+		fmt.Println()
+		if check.Checked > 0 {
+			fmt.Printf("%s: %d of %d items (-%d)\n", check.Description, len(check.Failed), check.Checked, check.Penalty)
+		} else {
+			fmt.Printf("%s (-%d)\n", check.Description, check.Penalty)
+		}
+		for _, item := range check.Failed {
+			fmt.Printf("  %s (%s)\n", item.Title, item.Uuid[0:4])
+		}
+		fmt.Printf("  Next step: %s\n", check.NextStep)
+	}
+	if report.Score == 100 {
+		fmt.Printf("No issues found\n")
+	}
+}
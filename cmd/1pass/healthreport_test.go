@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestAuditItems(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)
+	created := uint64(now.Add(-30 * 24 * time.Hour).Unix())
+	login := func(title string, password string) (onepass.Item, onepass.ItemContent) {
+		item := onepass.Item{Uuid: title, Title: title, TypeName: "webforms.WebForm", CreatedAt: created}
+		content := onepass.ItemContent{
+			FormFields: []onepass.WebFormField{{Name: "password", Designation: "password", Type: "P", Value: password}},
+		}
+		return item, content
+	}
+	items := []onepass.Item{}
+	contents := []onepass.ItemContent{}
+	add := func(item onepass.Item, content onepass.ItemContent) {
+		items = append(items, item)
+		contents = append(contents, content)
+	}
+
+	add(login("weak", "pin1234"))
+	add(login("reused-a", "correct-Horse-battery-staple"))
+	add(login("reused-b", "correct-Horse-battery-staple"))
+	old, oldContent := login("old", "Tr0ub4dor&3-Tr0ub4dor&3")
+	old.CreatedAt = uint64(now.Add(-400 * 24 * time.Hour).Unix())
+	add(old, oldContent)
+	trashed, trashedContent := login("trashed", "pin1234")
+	trashed.Trashed = true
+	add(trashed, trashedContent)
+
+	checks := scoreHealthChecks(auditItems(items, contents, now, defaultMaxPasswordAge)).Checks
+	failed := map[string][]string{}
+	for _, check := range checks {
+		for _, item := range check.Failed {
+			failed[check.Name] = append(failed[check.Name], item.Title)
+		}
+		if check.Checked != 4 {
+			t.Errorf("Expected check '%s' to apply to 4 items, got %d", check.Name, check.Checked)
+		}
+	}
+	expected := map[string]int{"weak-passwords": 1, "reused-passwords": 2, "old-passwords": 1, "missing-2fa": 4}
+	for name, count := range expected {
+		if len(failed[name]) != count {
+			t.Errorf("Expected %d items to fail '%s', got %v", count, name, failed[name])
+		}
+	}
+	if failed["weak-passwords"][0] != "weak" || failed["old-passwords"][0] != "old" {
+		t.Errorf("Unexpected failing items: %v", failed)
+	}
+
+	report := scoreHealthChecks(checks)
+	// ceil(25/4) + ceil(20/2) + ceil(10/4) + 10
+	if report.Score != 100-7-10-3-10 {
+		t.Errorf("Unexpected score %d", report.Score)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/robertknight/1pass/onepass"
+	"gopkg.in/yaml.v2"
+)
+
+func importCsvHelp() string {
+	return `Creates a Login for each row of a CSV file with a header line. Columns
+for the title, website, username, password, notes, tags and one-time
+password are found using common column names such as 'Name', 'URL' and
+'Password'. Use the flags or a mapping file to choose other columns.
+
+Columns are specified by name or by number, starting from 1. A mapping
+file lists the columns to use in YAML format:
+
+  title: Account
+  url: Web Site
+  username: Login Name
+  password: 4
+
+Flags take precedence over the mapping file. Columns which are not
+mapped are not imported.`
+}
+
+func readCsvMapping(path string) onepass.CSVMapping {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalErr(err, "Unable to read column mapping")
+	}
+	var mapping onepass.CSVMapping
+	err = yaml.UnmarshalStrict(data, &mapping)
+	if err != nil {
+		fatalErr(err, "Unable to parse column mapping")
+	}
+	return mapping
+}
+
+func importCsv(vault *onepass.Vault, path string, mapping onepass.CSVMapping) {
+	items, err := onepass.ImportCSV(path, mapping)
+	if err != nil {
+		fatalErr(err, "Unable to import items")
+	}
+	addImportedItems(vault, items, false)
+	fmt.Printf("Imported %d items\n", len(items))
+}
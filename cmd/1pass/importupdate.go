@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// findImportedItem returns the item in items which an imported item
+// should be merged into for 'import --update-existing'. This is the
+// item with the same ID, if there is one, or otherwise the only item
+// with the same type and title.
+func findImportedItem(items []onepass.Item, imported onepass.ExportedItem) (onepass.Item, bool) {
+	var matches []onepass.Item
+	for _, item := range items {
+		if item.Trashed || item.TypeName != imported.TypeName {
+			continue
+		}
+		if imported.Uuid != "" && item.Uuid == imported.Uuid {
+			return item, true
+		}
+		if strings.EqualFold(item.Title, imported.Title) {
+			matches = append(matches, item)
+		}
+	}
+	if len(matches) != 1 {
+		return onepass.Item{}, false
+	}
+	return matches[0], true
+}
+
+// updateImportedItem merges the content and tags of an imported item
+// into an existing item. Values from the imported item replace those
+// in the existing item unless the existing item was updated more
+// recently. See onepass.MergeContent()
+func updateImportedItem(item onepass.Item, imported onepass.ExportedItem, privateTags bool) {
+	content, err := item.Content()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to decrypt item '%s': %v\n", item.Title, err)
+		return
+	}
+	strategy := onepass.PreferSecond
+	if imported.UpdatedAt != 0 {
+		strategy = onepass.NewerWins(item.UpdatedAt, imported.UpdatedAt)
+	}
+	merged := onepass.MergeContent(content, imported.SecureContents, strategy)
+	tags := itemTags(&item, privateTags)
+	tagsChanged := false
+	for _, tag := range imported.OpenContents.Tags {
+		if !hasTag(tags, tag) {
+			tags = append(tags, tag)
+			tagsChanged = true
+		}
+	}
+	if !tagsChanged && contentEqual(content, merged) {
+		return
+	}
+	err = item.SetContent(merged)
+	if err == nil {
+		err = item.SetTags(tags, privateTags)
+	}
+	if err == nil {
+		err = item.Save()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to update item '%s': %v\n", item.Title, err)
+		return
+	}
+	logItemAction("Updated item", item)
+}
+
+// contentEqual returns true if two versions of an
+// item's content would be saved identically
+func contentEqual(a onepass.ItemContent, b onepass.ItemContent) bool {
+	aJson, aErr := json.Marshal(a)
+	bJson, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJson, bJson)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func keepHistoryHelp() string {
+	return `When history is enabled, the previous version of an item is kept
+whenever its content is changed, for example by 'edit' or 'import'.
+Use 'history' to list the previous versions of an item and 'revert'
+to restore one.
+
+Previous versions are stored encrypted in a '1pass-history' folder in
+the vault. They are deleted when the item is removed, either with
+'remove' or when it is purged from the trash by 'empty-trash'. Other
+1Password apps do not keep history, so changes made by them are not
+recorded. Turning history off keeps the versions which have already
+been saved.`
+}
+
+func setKeepHistory(vault *onepass.Vault, setting string) {
+	if setting != "on" && setting != "off" {
+		fatalErr(fmt.Errorf("Expected 'on' or 'off'"), "")
+	}
+	err := vault.SetKeepHistory(setting == "on")
+	if err != nil {
+		fatalErr(err, "Unable to update vault options")
+	}
+	if setting == "on" {
+		fmt.Printf("Previous versions of items will be kept when they are changed\n")
+	} else {
+		fmt.Printf("Previous versions of items will no longer be kept\n")
+	}
+}
+
+func showItemHistory(vault *onepass.Vault, pattern string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item")
+	}
+	history, err := item.History()
+	if err != nil {
+		fatalErr(err, "Unable to read item history")
+	}
+	if len(history) == 0 {
+		fmt.Printf("No previous versions of '%s' have been kept\n", item.Title)
+		if options, err := vault.Options(); err == nil && !options.KeepHistory {
+			fmt.Printf("Use 'keep-history on' to keep previous versions of items\n")
+		}
+		return
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, revision := range history {
+		fmt.Fprintf(out, "%d\t%s\t%s\n", revision.Number,
+			time.Unix(int64(revision.UpdatedAt), 0).Format("15:04 02/01/06"), revision.Title)
+	}
+	fmt.Fprintf(out, "current\t%s\t%s\n",
+		time.Unix(int64(item.UpdatedAt), 0).Format("15:04 02/01/06"), item.Title)
+	out.Flush()
+}
+
+func revertItem(vault *onepass.Vault, pattern string, revision string) {
+	number, err := strconv.Atoi(revision)
+	if err != nil {
+		fatalErr(fmt.Errorf("'%s' is not a revision number", revision), "")
+	}
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item to revert")
+	}
+	err = item.Revert(number)
+	if err != nil {
+		fatalErr(err, "Unable to revert item")
+	}
+	fmt.Printf("Reverted '%s' (%s) to revision %d\n", item.Title, item.Uuid[0:4], number)
+}
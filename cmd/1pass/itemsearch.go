@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// default number of lines of notes shown
+// before and after each match by 'search'
+const defaultSearchContext = 2
+
+func searchHelp() string {
+	return `Searches the titles and notes of items for <text>, ignoring case.
+
+For matches within an item's notes, the matching lines are shown along
+with the lines around them, in the same way as 'grep -C'. Matching lines
+are marked with ':' after the line number and surrounding lines with
+'-'. Groups of lines which are not adjacent are separated by '--'.
+The number of surrounding lines can be changed with '--context'.`
+}
+
+// noteMatchLines returns the lines of notes which contain query,
+// ignoring case, with up to 'context' lines before and after each
+// match, formatted as by 'grep -n -C <context>'. highlight is applied
+// to each occurrence of query in matching lines.
+func noteMatchLines(notes string, query string, context int, highlight func(string) string) []string {
+	lines := strings.Split(strings.Replace(notes, "\r\n", "\n", -1), "\n")
+	queryLower := strings.ToLower(query)
+	matched := make([]bool, len(lines))
+	shown := make([]bool, len(lines))
+	for i, line := range lines {
+		if !strings.Contains(strings.ToLower(line), queryLower) {
+			continue
+		}
+		matched[i] = true
+		for k := i - context; k <= i+context; k++ {
+			if k >= 0 && k < len(lines) {
+				shown[k] = true
+			}
+		}
+	}
+
+	result := []string{}
+	for i, line := range lines {
+		if !shown[i] {
+			continue
+		}
+		if len(result) > 0 && !shown[i-1] {
+			result = append(result, "--")
+		}
+		if matched[i] {
+			result = append(result, fmt.Sprintf("%d:%s", i+1, highlightMatches(line, query, highlight)))
+		} else {
+			result = append(result, fmt.Sprintf("%d-%s", i+1, line))
+		}
+	}
+	return result
+}
+
+// highlightMatches applies highlight to each occurrence
+// of query in line, ignoring case
+func highlightMatches(line string, query string, highlight func(string) string) string {
+	if query == "" {
+		return line
+	}
+	lineLower := strings.ToLower(line)
+	queryLower := strings.ToLower(query)
+	if len(lineLower) != len(line) || len(queryLower) != len(query) {
+		// offsets in the lowercase text do not match the
+		// original for some non-ASCII characters
+		return line
+	}
+	result := ""
+	for {
+		i := strings.Index(lineLower, queryLower)
+		if i == -1 {
+			return result + line
+		}
+		result += line[:i] + highlight(line[i:i+len(query)])
+		line = line[i+len(query):]
+		lineLower = lineLower[i+len(query):]
+	}
+}
+
+// searchItems lists the items whose titles or notes contain
+// query, with the matching parts of their notes
+func searchItems(vault *onepass.Vault, query string, context int) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	searchable := []onepass.Item{}
+	for _, item := range items {
+		if !item.Trashed && item.TypeName != folderTypeName && item.TypeName != "system.Tombstone" {
+			searchable = append(searchable, item)
+		}
+	}
+	sortItemsByTitle(searchable)
+	contents, err := vault.ItemContents(searchable)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+
+	renderer := newTerminalMarkdownRenderer()
+	highlight := func(text string) string {
+		return renderer.style(ansiBold+ansiMagenta, text)
+	}
+	found := 0
+	for i, item := range searchable {
+		noteLines := noteMatchLines(contents[i].Notes, query, context, highlight)
+		if len(noteLines) == 0 && !strings.Contains(strings.ToLower(item.Title), strings.ToLower(query)) {
+			continue
+		}
+		if found > 0 && len(noteLines) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%s, %s)\n", highlightMatches(item.Title, query, highlight), item.Type(), item.Uuid[0:4])
+		for _, line := range noteLines {
+			fmt.Printf("  %s\n", line)
+		}
+		found++
+	}
+	if found == 0 {
+		fmt.Fprintf(os.Stderr, "No matching items\n")
+	}
+}
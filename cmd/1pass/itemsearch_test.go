@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNoteMatchLines(t *testing.T) {
+	notes := "# Failover\n" +
+		"1. Check replication lag\n" +
+		"2. Stop writes\n" +
+		"3. Promote the replica\n" +
+		"4. Update DNS\n" +
+		"5. Restart writes\n" +
+		"6. Check the REPLICA is healthy"
+	highlight := func(text string) string { return "[" + text + "]" }
+
+	lines := noteMatchLines(notes, "replica", 1, highlight)
+	expected := []string{
+		"1-# Failover",
+		"2:1. Check [replica]tion lag",
+		"3-2. Stop writes",
+		"4:3. Promote the [replica]",
+		"5-4. Update DNS",
+		"6-5. Restart writes",
+		"7:6. Check the [REPLICA] is healthy",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Unexpected matches:\n%q\nexpected:\n%q", lines, expected)
+	}
+
+	lines = noteMatchLines(notes, "writes", 0, highlight)
+	if !reflect.DeepEqual(lines, []string{"3:2. Stop [writes]", "--", "6:5. Restart [writes]"}) {
+		t.Errorf("Unexpected matches without context: %q", lines)
+	}
+	if lines := noteMatchLines(notes, "missing", 2, highlight); len(lines) != 0 {
+		t.Errorf("Unexpected matches for missing text: %q", lines)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/robertknight/1pass/locale"
+	"github.com/robertknight/1pass/onepass"
+)
+
+// hiddenItemTypes returns the type codes of the item types which
+// are left out of the types listed in help text, from the
+// 'HiddenItemTypes' setting
+func hiddenItemTypes(config clientConfig) map[string]bool {
+	hidden := map[string]bool{}
+	for _, name := range config.HiddenItemTypes {
+		if typeName := typeFromAlias(name); typeName != "" {
+			hidden[typeName] = true
+		} else if _, ok := onepass.ItemTypes[name]; ok {
+			hidden[name] = true
+		} else {
+			fmt.Fprintf(os.Stderr, "Ignoring unknown item type '%s' in 'HiddenItemTypes'\n", name)
+		}
+	}
+	return hidden
+}
+
+// itemTypeCount records the number of items of a type
+type itemTypeCount struct {
+	typeName string
+	count    int
+}
+
+// countItemTypes returns the number of items of each type
+// in items, most common first
+func countItemTypes(items []onepass.Item) []itemTypeCount {
+	counts := map[string]int{}
+	for _, item := range items {
+		counts[item.TypeName]++
+	}
+	result := []itemTypeCount{}
+	for typeName, count := range counts {
+		result = append(result, itemTypeCount{typeName, count})
+	}
+	sort.Slice(result, func(i, k int) bool {
+		if result[i].count != result[k].count {
+			return result[i].count > result[k].count
+		}
+		return result[i].typeName < result[k].typeName
+	})
+	return result
+}
+
+// listItemTypes prints the types of items present
+// in items and the number of items of each type
+func listItemTypes(items []onepass.Item) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, typeCount := range countItemTypes(items) {
+		alias, name := typeCount.typeName, typeCount.typeName
+		if itemType, ok := onepass.ItemTypes[typeCount.typeName]; ok {
+			alias, name = itemType.ShortAlias, locale.T(itemType.Name)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\n", alias, name, typeCount.count)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestHiddenItemTypes(t *testing.T) {
+	config := clientConfig{HiddenItemTypes: []string{"outdoor", "wallet.government.DriversLicense", "unknown"}}
+	hidden := hiddenItemTypes(config)
+	expected := map[string]bool{
+		"wallet.government.HuntingLicense": true,
+		"wallet.government.DriversLicense": true,
+	}
+	if !reflect.DeepEqual(hidden, expected) {
+		t.Errorf("Unexpected hidden types: %v", hidden)
+	}
+}
+
+func TestCountItemTypes(t *testing.T) {
+	items := []onepass.Item{
+		{TypeName: "webforms.WebForm"},
+		{TypeName: "securenotes.SecureNote"},
+		{TypeName: "webforms.WebForm"},
+		{TypeName: "wallet.financial.CreditCard"},
+	}
+	expected := []itemTypeCount{
+		{"webforms.WebForm", 2},
+		{"securenotes.SecureNote", 1},
+		{"wallet.financial.CreditCard", 1},
+	}
+	if counts := countItemTypes(items); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Unexpected type counts: %v", counts)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/rangeutil"
+)
+
+// Records how often and when an item was
+// last shown or copied
+type itemUsage struct {
+	Count    int
+	LastUsed int64
+}
+
+// frecency returns a score for an item combining
+// its usage count with how recently it was used
+func (usage itemUsage) frecency(now time.Time) float64 {
+	age := now.Sub(time.Unix(usage.LastUsed, 0))
+	day := 24 * time.Hour
+	var weight float64
+	switch {
+	case age < 4*day:
+		weight = 100
+	case age < 14*day:
+		weight = 70
+	case age < 31*day:
+		weight = 50
+	case age < 90*day:
+		weight = 30
+	default:
+		weight = 10
+	}
+	return float64(usage.Count) * weight
+}
+
+// name of the key used to encrypt the item usage
+// statistics of vaults where 'private-usage' is enabled
+const itemUsageKeyName = "SL5"
+
+func privateUsageHelp() string {
+	return `1pass counts how often each item is shown or copied, for use with
+'list --sort'. The counts are stored in ~/.1pass for each vault.
+
+By default the counts are stored in plaintext, which reveals the IDs of
+the items used most often. When private usage is enabled for a vault,
+the counts are encrypted with the vault's keys, so they can only be read
+or updated while the vault is unlocked. The counts are reset if they can
+no longer be decrypted, for example after 'rotate-keys'.
+
+The recently used items list for 'list --recent' and the 'last' pattern
+is not affected by this setting.`
+}
+
+// readItemUsage returns the usage statistics for items in the
+// vault, keyed by item ID. Statistics for vaults where
+// 'private-usage' is enabled are decrypted with the vault's keys.
+func readItemUsage(vault *onepass.Vault, config clientConfig) (map[string]itemUsage, error) {
+	usage := map[string]itemUsage{}
+	if !config.PrivateUsage[vault.Path] {
+		for uuid, entry := range config.ItemUsage[vault.Path] {
+			usage[uuid] = entry
+		}
+		return usage, nil
+	}
+	encrypted := config.EncryptedItemUsage[vault.Path]
+	if len(encrypted) == 0 {
+		return usage, nil
+	}
+	data, err := vault.CryptoAgent.Decrypt(itemUsageKeyName, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &usage)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// writeItemUsage replaces the usage statistics for items in the
+// vault in config, encrypting them if 'private-usage' is enabled
+// for the vault
+func writeItemUsage(vault *onepass.Vault, config *clientConfig, usage map[string]itemUsage) error {
+	if !config.PrivateUsage[vault.Path] {
+		if config.ItemUsage == nil {
+			config.ItemUsage = map[string]map[string]itemUsage{}
+		}
+		config.ItemUsage[vault.Path] = usage
+		delete(config.EncryptedItemUsage, vault.Path)
+		return nil
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	encrypted, err := vault.CryptoAgent.Encrypt(itemUsageKeyName, data)
+	if err != nil {
+		return err
+	}
+	if config.EncryptedItemUsage == nil {
+		config.EncryptedItemUsage = map[string][]byte{}
+	}
+	config.EncryptedItemUsage[vault.Path] = encrypted
+	delete(config.ItemUsage, vault.Path)
+	return nil
+}
+
+// records that an item has been shown or copied, moving
+// it to the front of the recently used items list and
+// updating its usage count
+func recordItemUse(vault *onepass.Vault, item onepass.Item) {
+	config := readConfig()
+	usage, err := readItemUsage(vault, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to read item usage counts, they will be reset: %v\n", err)
+		usage = map[string]itemUsage{}
+	}
+	entry := usage[item.Uuid]
+	entry.Count++
+	entry.LastUsed = time.Now().Unix()
+	usage[item.Uuid] = entry
+	err = writeItemUsage(vault, &config, usage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to save item usage counts: %v\n", err)
+	}
+
+	recent := []string{item.Uuid}
+	for _, uuid := range config.RecentItems {
+		if uuid != item.Uuid && len(recent) < maxRecentItems {
+			recent = append(recent, uuid)
+		}
+	}
+	config.RecentItems = recent
+	writeConfig(&config)
+}
+
+// sorts items by descending usage count or frecency score,
+// using the title to order items with equal scores
+func sortItemsByUsage(vault *onepass.Vault, items []onepass.Item, frecency bool) {
+	usage, err := readItemUsage(vault, readConfig())
+	if err != nil {
+		fatalErr(err, "Unable to read item usage counts")
+	}
+	now := time.Now()
+	score := func(item onepass.Item) float64 {
+		if frecency {
+			return usage[item.Uuid].frecency(now)
+		}
+		return float64(usage[item.Uuid].Count)
+	}
+	rangeutil.Sort(0, len(items), func(i, k int) bool {
+		scoreI, scoreK := score(items[i]), score(items[k])
+		if scoreI != scoreK {
+			return scoreI > scoreK
+		}
+		return strings.ToLower(items[i].Title) < strings.ToLower(items[k].Title)
+	},
+		func(i, k int) {
+			items[i], items[k] = items[k], items[i]
+		})
+}
+
+func setPrivateUsage(vault *onepass.Vault, setting string) {
+	if setting != "on" && setting != "off" {
+		fatalErr(fmt.Errorf("Expected 'on' or 'off'"), "")
+	}
+	config := readConfig()
+	usage, err := readItemUsage(vault, config)
+	if err != nil {
+		fatalErr(err, "Unable to read item usage counts")
+	}
+	if config.PrivateUsage == nil {
+		config.PrivateUsage = map[string]bool{}
+	}
+	if setting == "on" {
+		config.PrivateUsage[vault.Path] = true
+	} else {
+		delete(config.PrivateUsage, vault.Path)
+	}
+	err = writeItemUsage(vault, &config, usage)
+	if err != nil {
+		fatalErr(err, "Unable to save item usage counts")
+	}
+	writeConfig(&config)
+	if setting == "on" {
+		fmt.Printf("Item usage counts for this vault are now stored encrypted\n")
+	} else {
+		fmt.Printf("Item usage counts for this vault are now stored unencrypted\n")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// CryptoAgent which 'encrypts' data by inverting its bits
+type invertingCryptoAgent struct{}
+
+func invertBytes(in []byte) []byte {
+	out := make([]byte, len(in))
+	for i, b := range in {
+		out[i] = ^b
+	}
+	return out
+}
+
+func (agent invertingCryptoAgent) Encrypt(keyName string, in []byte) ([]byte, error) {
+	return invertBytes(in), nil
+}
+
+func (agent invertingCryptoAgent) Decrypt(keyName string, in []byte) ([]byte, error) {
+	return invertBytes(in), nil
+}
+
+func (agent invertingCryptoAgent) Lock() error {
+	return nil
+}
+
+func (agent invertingCryptoAgent) IsLocked() (bool, error) {
+	return false, nil
+}
+
+func TestItemUsage(t *testing.T) {
+	work := &onepass.Vault{Path: "/vaults/Work.agilekeychain", CryptoAgent: invertingCryptoAgent{}}
+	home := &onepass.Vault{Path: "/vaults/Home.agilekeychain", CryptoAgent: invertingCryptoAgent{}}
+	config := clientConfig{
+		PrivateUsage: map[string]bool{home.Path: true},
+	}
+
+	err := writeItemUsage(work, &config, map[string]itemUsage{"item1": {Count: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeItemUsage(home, &config, map[string]itemUsage{"item1": {Count: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.ItemUsage[home.Path]; ok {
+		t.Errorf("Private item usage was stored in plaintext")
+	}
+	if bytes.Contains(config.EncryptedItemUsage[home.Path], []byte("item1")) {
+		t.Errorf("Private item usage was not encrypted")
+	}
+
+	for _, test := range []struct {
+		vault *onepass.Vault
+		count int
+	}{
+		{work, 2},
+		{home, 5},
+	} {
+		usage, err := readItemUsage(test.vault, config)
+		if err != nil || usage["item1"].Count != test.count {
+			t.Errorf("Unexpected usage for %s: %+v, %v", test.vault.Path, usage, err)
+		}
+	}
+}
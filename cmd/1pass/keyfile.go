@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// path of the key file given with '-keyfile', which is
+// needed in addition to the master password to unlock
+// vaults created with a key file
+var keyFilePath string
+
+func newVaultHelp() string {
+	return masterPasswordStrengthHelp() + `
+
+With '--keyfile', the contents of the given file are needed in addition
+to the master password to unlock the vault, so that a copy of the vault
+cannot be unlocked by someone who learns the password alone. Use the
+'-keyfile' option to specify the file when running other commands.
+
+The key file can be any file, but should contain at least 32 random
+bytes and must not be changed. If it is lost, the vault cannot be
+unlocked, so keep a backup of it separately from the vault.`
+}
+
+// masterPassword returns the value used to unlock the vault,
+// combining pwd with the contents of the key file given by
+// '-keyfile', if any. See onepass.KeyFilePassword()
+func masterPassword(pwd []byte) []byte {
+	return withKeyFile(pwd, keyFilePath)
+}
+
+func withKeyFile(pwd []byte, path string) []byte {
+	if path == "" {
+		return pwd
+	}
+	combinedPwd, err := onepass.KeyFilePassword(pwd, path)
+	if err != nil {
+		fatalErr(err, "")
+	}
+	return combinedPwd
+}
+
+// checkKeyFile exits with an error if the vault
+// requires a key file and '-keyfile' was not used
+func checkKeyFile(vault *onepass.Vault) {
+	required, err := vault.RequiresKeyFile()
+	if err == nil && required && keyFilePath == "" {
+		fatalErr(fmt.Errorf("This vault requires a key file. Use '-keyfile <path>' to specify it"), "")
+	}
+}
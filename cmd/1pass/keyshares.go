@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/robertknight/1pass/jsonutil"
+	"github.com/robertknight/1pass/locale"
+	"github.com/robertknight/1pass/onepass"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// keyShare is the content of a share file written by
+// 'split-key'. Data is one share of the vault's master keys,
+// encoded as JSON.
+type keyShare struct {
+	Vault     string `json:"vault"`
+	Created   string `json:"created"`
+	Index     int    `json:"index"`
+	Shares    int    `json:"shares"`
+	Threshold int    `json:"threshold"`
+	Data      []byte `json:"data"`
+}
+
+func splitKeyHelp() string {
+	return `Splits the vault's master keys into a number of share files which can
+be given to different trustees. Any '--threshold' shares can then be used
+with 'recover-key' to set a new master password if the current one is
+lost. Fewer shares reveal nothing about the keys.
+
+Share files are not encrypted and must be stored securely.`
+}
+
+func splitKey(vault *onepass.Vault, masterPwd []byte, dir string, shareCount int, threshold int) {
+	keys, err := onepass.UnlockProfileKeys(vault.Path, vault.Profile, masterPwd)
+	if err != nil {
+		fatalErr(err, "Unable to unlock vault")
+	}
+	secret, err := json.Marshal(keys)
+	keys.Wipe()
+	if err != nil {
+		fatalErr(err, "")
+	}
+	defer onepass.WipeBytes(secret)
+	shares, err := onepass.SplitSecret(secret, shareCount, threshold)
+	if err != nil {
+		fatalErr(err, "Unable to split keys")
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		fatalErr(err, "Unable to create share directory")
+	}
+	for i, share := range shares {
+		path := filepath.Join(dir, fmt.Sprintf("1pass-key-share-%d-of-%d.json", i+1, shareCount))
+		err = jsonutil.WritePrivateFileAtomic(path, keyShare{
+			Vault:     vault.Path,
+			Created:   time.Now().Format(time.RFC3339),
+			Index:     i + 1,
+			Shares:    shareCount,
+			Threshold: threshold,
+			Data:      share,
+		})
+		if err != nil {
+			fatalErr(err, "Unable to write key share")
+		}
+		fmt.Printf("Wrote share %d of %d to %s\n", i+1, shareCount, path)
+	}
+	fmt.Printf("Any %d shares can be used with 'recover-key' to reset the master password.\n", threshold)
+}
+
+func recoverKey(vault *onepass.Vault, paths []string, force bool) {
+	shares := [][]byte{}
+	threshold := 0
+	for _, path := range paths {
+		var share keyShare
+		err := jsonutil.ReadFile(path, &share)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to read key share '%s'", path))
+		}
+		shares = append(shares, share.Data)
+		threshold = share.Threshold
+	}
+	if len(shares) < threshold {
+		fatalErr(fmt.Errorf("%d shares are needed to recover the keys but only %d were given", threshold, len(shares)), "")
+	}
+
+	secret, err := onepass.CombineShares(shares)
+	if err != nil {
+		fatalErr(err, "Unable to combine key shares")
+	}
+	var keys onepass.KeyDict
+	err = json.Unmarshal(secret, &keys)
+	if err != nil {
+		fatalErr(fmt.Errorf("Check that the shares all come from the same 'split-key' run"), "Unable to recover keys")
+	}
+
+	fmt.Printf("%s: ", locale.T("New master password"))
+	newPwd, _ := terminal.ReadPassword(0)
+	fmt.Printf("\n%s: ", locale.T("Re-enter new master password"))
+	newPwd2, _ := terminal.ReadPassword(0)
+	fmt.Println()
+	if len(newPwd) == 0 || !bytes.Equal(newPwd, newPwd2) {
+		fatalErr(nil, locale.T("Passwords do not match"))
+	}
+	onepass.WipeBytes(newPwd2)
+	checkMasterPasswordStrength(newPwd, vault.Path, force)
+	combinedPwd := masterPassword(newPwd)
+	err = vault.ResetMasterPassword(keys, combinedPwd)
+	onepass.WipeBytes(combinedPwd)
+	onepass.WipeBytes(newPwd)
+	keys.Wipe()
+	if err != nil {
+		fatalErr(err, "Unable to recover keys")
+	}
+	fmt.Printf("The master password has been reset.\n\n")
+	fmt.Printf(setPasswordSyncNote)
+}
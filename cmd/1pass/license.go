@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/rangeutil"
+)
+
+const licenseTypeName = "wallet.computer.License"
+
+// period before a license's expiry date during which
+// 'list --expiring' includes it
+const licenseExpiryWarning = 30 * 24 * time.Hour
+
+func licenseHelp() string {
+	return `[product] is matched against the titles of Software License items.
+If no title contains [product], licenses are matched by the letters
+of [product] appearing in order in the title, so 'jbidea' matches
+'JetBrains IntelliJ IDEA'.`
+}
+
+// fuzzyMatch returns true if the letters and digits of
+// query appear in order in text, ignoring case
+func fuzzyMatch(query string, text string) bool {
+	text = strings.ToLower(text)
+	for _, ch := range strings.ToLower(query) {
+		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) {
+			continue
+		}
+		pos := strings.IndexRune(text, ch)
+		if pos == -1 {
+			return false
+		}
+		text = text[pos+len(string(ch)):]
+	}
+	return true
+}
+
+// findLicenses returns the Software License items whose titles
+// contain product or, if there are none, fuzzily match product
+func findLicenses(vault *onepass.Vault, product string) ([]onepass.Item, error) {
+	items, err := vault.ListItems()
+	if err != nil {
+		return nil, err
+	}
+	var matches, fuzzyMatches []onepass.Item
+	for _, item := range items {
+		if item.TypeName != licenseTypeName || item.Trashed {
+			continue
+		}
+		if strings.Contains(strings.ToLower(item.Title), strings.ToLower(product)) {
+			matches = append(matches, item)
+		} else if fuzzyMatch(product, item.Title) {
+			fuzzyMatches = append(fuzzyMatches, item)
+		}
+	}
+	if len(matches) == 0 {
+		matches = fuzzyMatches
+	}
+	return matches, nil
+}
+
+func showLicense(vault *onepass.Vault, product string) {
+	items, err := findLicenses(vault, product)
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	if len(items) == 0 {
+		fatalErr(fmt.Errorf("No licenses matching '%s'", product), "")
+	}
+	if len(items) > 1 {
+		fmt.Fprintf(os.Stderr, "Multiple matching licenses:\n")
+		for _, item := range items {
+			fmt.Fprintf(os.Stderr, "  %s (%s)\n", item.Title, item.Uuid)
+		}
+		os.Exit(1)
+	}
+
+	item := items[0]
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+	fmt.Printf("%s\n", item.Title)
+	for _, field := range []struct {
+		name  string
+		title string
+	}{
+		{"product_version", "Version"},
+		{"reg_code", "License key"},
+		{"reg_name", "Licensed to"},
+		{"reg_email", "Registered email"},
+		{"download_link", "Download"},
+		{"expiry_date", "Expires"},
+	} {
+		if value := content.FieldValue(field.name); value != "" {
+			fmt.Printf("  %s: %s\n", field.title, value)
+		}
+	}
+	recordItemUse(vault, item)
+}
+
+// dateFieldValue returns the time stored in the first
+// 'date' field named name
+func dateFieldValue(content *onepass.ItemContent, name string) (time.Time, bool) {
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			if field.Name != name || field.Kind != "date" {
+				continue
+			}
+			switch value := field.Value.(type) {
+			case float64:
+				return time.Unix(int64(value), 0), true
+			case int64:
+				return time.Unix(value, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// listExpiringLicenses lists the Software License items in items
+// which have expired or will expire soon, along with their
+// version and purchase date
+func listExpiringLicenses(items []onepass.Item) {
+	type license struct {
+		item      onepass.Item
+		content   onepass.ItemContent
+		expiry    time.Time
+		purchased time.Time
+	}
+	now := time.Now()
+	licenses := []license{}
+	for _, item := range items {
+		if item.TypeName != licenseTypeName || item.Trashed {
+			continue
+		}
+		content, err := item.Content()
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+		}
+		expiry, ok := dateFieldValue(&content, "expiry_date")
+		if !ok || expiry.Sub(now) > licenseExpiryWarning {
+			continue
+		}
+		purchased, _ := dateFieldValue(&content, "order_date")
+		licenses = append(licenses, license{item, content, expiry, purchased})
+	}
+	rangeutil.Sort(0, len(licenses), func(i, k int) bool {
+		return licenses[i].expiry.Before(licenses[k].expiry)
+	},
+		func(i, k int) {
+			licenses[i], licenses[k] = licenses[k], licenses[i]
+		})
+
+	for _, license := range licenses {
+		status := "expires"
+		if license.expiry.Before(now) {
+			status = "expired"
+		}
+		details := fmt.Sprintf("%s %s", status, license.expiry.Format("02/01/06"))
+		if version := license.content.FieldValue("product_version"); version != "" {
+			details += ", version " + version
+		}
+		if !license.purchased.IsZero() {
+			details += ", purchased " + license.purchased.Format("02/01/06")
+		}
+		fmt.Printf("%s (%s) %s\n", license.item.Title, license.item.Uuid[0:4], details)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		query string
+		text  string
+		match bool
+	}{
+		{"jbidea", "JetBrains IntelliJ IDEA", true},
+		{"sublime 4", "Sublime Text 4", true},
+		{"idea jb", "JetBrains IntelliJ IDEA", false},
+		{"photoshop", "Adobe Photo", false},
+	}
+	for _, c := range cases {
+		if fuzzyMatch(c.query, c.text) != c.match {
+			t.Errorf("fuzzyMatch(%q, %q) should be %v", c.query, c.text, c.match)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/locale"
+	"github.com/robertknight/1pass/onepass"
+)
+
+// localizeModes translates the descriptions of commands,
+// their flags and examples into the current language
+func localizeModes(modes []cmdmodes.Mode) {
+	for i := range modes {
+		mode := &modes[i]
+		mode.Description = locale.T(mode.Description)
+		for j := range mode.Flags {
+			mode.Flags[j].Description = locale.T(mode.Flags[j].Description)
+		}
+		for j := range mode.Examples {
+			mode.Examples[j].Description = locale.T(mode.Examples[j].Description)
+		}
+	}
+}
+
+// localizedContent returns a copy of content for display with the
+// titles of sections, fields, URLs and form fields translated into the current
+// language. The content stored in the vault is unchanged.
+func localizedContent(content onepass.ItemContent) onepass.ItemContent {
+	sections := []onepass.ItemSection{}
+	for _, section := range content.Sections {
+		fields := []onepass.ItemField{}
+		for _, field := range section.Fields {
+			field.Title = locale.T(field.Title)
+			fields = append(fields, field)
+		}
+		section.Title = locale.T(section.Title)
+		section.Fields = fields
+		sections = append(sections, section)
+	}
+	content.Sections = sections
+
+	urls := []onepass.ItemUrl{}
+	for _, url := range content.Urls {
+		url.Label = locale.T(url.Label)
+		urls = append(urls, url)
+	}
+	content.Urls = urls
+
+	formFields := []onepass.WebFormField{}
+	for _, field := range content.FormFields {
+		field.Name = locale.T(field.Name)
+		formFields = append(formFields, field)
+	}
+	content.FormFields = formFields
+	return content
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ANSI escape sequences used when rendering Markdown
+// to a terminal
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiGreen     = "\x1b[32m"
+	ansiMagenta   = "\x1b[35m"
+	ansiCyan      = "\x1b[36m"
+)
+
+// keywords highlighted in fenced code blocks, by the language
+// named after the opening fence. Blocks in other languages are
+// highlighted using all of the keywords.
+var codeKeywords = map[string][]string{
+	"sh": {"if", "then", "else", "elif", "fi", "for", "while", "do", "done", "case", "esac",
+		"function", "in", "export", "local", "return", "sudo", "echo", "cd"},
+	"go": {"package", "import", "func", "var", "const", "type", "struct", "interface", "if", "else",
+		"for", "range", "return", "switch", "case", "default", "go", "defer", "nil", "true", "false"},
+	"python": {"def", "class", "import", "from", "as", "if", "elif", "else", "for", "while", "in",
+		"return", "with", "try", "except", "finally", "None", "True", "False", "and", "or", "not"},
+	"sql": {"select", "from", "where", "insert", "into", "values", "update", "set", "delete", "create",
+		"table", "alter", "drop", "grant", "revoke", "on", "to", "and", "or", "join", "user", "identified", "by"},
+	"json": {"true", "false", "null"},
+	"yaml": {"true", "false", "null", "yes", "no"},
+}
+
+// aliases for the languages in codeKeywords
+var codeLanguageAliases = map[string]string{
+	"bash":       "sh",
+	"shell":      "sh",
+	"zsh":        "sh",
+	"console":    "sh",
+	"golang":     "go",
+	"py":         "python",
+	"yml":        "yaml",
+	"postgresql": "sql",
+	"mysql":      "sql",
+}
+
+var (
+	mdHeadingRegex   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdListRegex      = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdRuleRegex      = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdFenceRegex     = regexp.MustCompile("^\\s*(```|~~~)\\s*([A-Za-z0-9_+-]*)")
+	mdInlineRegex    = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|\\*[^*\\s][^*]*\\*|\\[[^\\]]+\\]\\([^)]+\\)")
+	mdCodeTokenRegex = regexp.MustCompile(`"(\\.|[^"\\])*"|'(\\.|[^'\\])*'|[A-Za-z_][A-Za-z0-9_]*|\d+(\.\d+)?|.`)
+)
+
+// markdownRenderer renders Markdown text, such as the notes of
+// runbook-style Secure Notes, for display in a terminal
+type markdownRenderer struct {
+	// if set, ANSI escape sequences are used for
+	// emphasis and syntax highlighting
+	color bool
+}
+
+// newTerminalMarkdownRenderer returns a renderer which uses color
+// if stdout is a terminal and $NO_COLOR is not set
+func newTerminalMarkdownRenderer() markdownRenderer {
+	return markdownRenderer{
+		color: stdoutSupportsColor(),
+	}
+}
+
+func (r markdownRenderer) style(codes string, text string) string {
+	if !r.color || text == "" {
+		return text
+	}
+	return codes + text + ansiReset
+}
+
+// render returns the rendered form of a Markdown document. Headings,
+// emphasis, links, lists, block quotes, horizontal rules and fenced
+// code blocks are supported. Other text is shown as written.
+func (r markdownRenderer) render(text string) string {
+	var out strings.Builder
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	inCode := false
+	fence := ""
+	language := ""
+	for _, line := range lines {
+		if match := mdFenceRegex.FindStringSubmatch(line); match != nil && (!inCode || match[1] == fence) {
+			if !inCode {
+				fence = match[1]
+				language = strings.ToLower(match[2])
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out.WriteString("    " + r.highlightCode(line, language) + "\n")
+			continue
+		}
+
+		if match := mdHeadingRegex.FindStringSubmatch(line); match != nil {
+			// headings are shown in bold, without other
+			// inline styles
+			title := markdownRenderer{}.renderInline(match[2])
+			if len(match[1]) == 1 {
+				title = strings.ToUpper(title)
+			}
+			out.WriteString(r.style(ansiBold, title) + "\n")
+		} else if mdRuleRegex.MatchString(line) {
+			out.WriteString(r.style(ansiDim, strings.Repeat("─", 40)) + "\n")
+		} else if match := mdListRegex.FindStringSubmatch(line); match != nil {
+			bullet := match[2]
+			if !unicode.IsDigit(rune(bullet[0])) {
+				bullet = "•"
+			}
+			out.WriteString(fmt.Sprintf("%s  %s %s\n", match[1], r.style(ansiBold, bullet), r.renderInline(match[3])))
+		} else if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			quoted := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(line), ">"), " ")
+			out.WriteString(r.style(ansiDim, "│ ") + r.style(ansiItalic, r.renderInline(quoted)) + "\n")
+		} else {
+			out.WriteString(r.renderInline(line) + "\n")
+		}
+	}
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
+
+// renderInline renders code spans, strong and emphasized text and
+// links within a line
+func (r markdownRenderer) renderInline(text string) string {
+	return mdInlineRegex.ReplaceAllStringFunc(text, func(span string) string {
+		switch {
+		case strings.HasPrefix(span, "`"):
+			return r.style(ansiCyan, strings.Trim(span, "`"))
+		case strings.HasPrefix(span, "**") || strings.HasPrefix(span, "__"):
+			return r.style(ansiBold, span[2:len(span)-2])
+		case strings.HasPrefix(span, "*"):
+			return r.style(ansiItalic, span[1:len(span)-1])
+		default:
+			sep := strings.Index(span, "](")
+			label := span[1:sep]
+			url := span[sep+2 : len(span)-1]
+			if label == url {
+				return r.style(ansiUnderline, url)
+			}
+			return fmt.Sprintf("%s (%s)", label, r.style(ansiUnderline, url))
+		}
+	})
+}
+
+// highlightCode colors the keywords, strings, numbers and
+// comments in a line of a fenced code block
+func (r markdownRenderer) highlightCode(line string, language string) string {
+	if !r.color {
+		return line
+	}
+	if alias, ok := codeLanguageAliases[language]; ok {
+		language = alias
+	}
+	keywords, ok := codeKeywords[language]
+	if !ok {
+		keywords = []string{}
+		for _, languageKeywords := range codeKeywords {
+			keywords = append(keywords, languageKeywords...)
+		}
+	}
+
+	var out strings.Builder
+	rest := line
+	for rest != "" {
+		if isCodeComment(rest, language) {
+			out.WriteString(r.style(ansiDim, rest))
+			break
+		}
+		token := mdCodeTokenRegex.FindString(rest)
+		rest = rest[len(token):]
+		switch {
+		case strings.HasPrefix(token, `"`) || strings.HasPrefix(token, "'"):
+			out.WriteString(r.style(ansiGreen, token))
+		case unicode.IsDigit(rune(token[0])):
+			out.WriteString(r.style(ansiCyan, token))
+		case isCodeKeyword(keywords, token, language == "sql"):
+			out.WriteString(r.style(ansiMagenta+ansiBold, token))
+		default:
+			out.WriteString(token)
+		}
+	}
+	return out.String()
+}
+
+func isCodeComment(code string, language string) bool {
+	switch language {
+	case "sh", "python", "yaml":
+		return strings.HasPrefix(code, "#")
+	case "go":
+		return strings.HasPrefix(code, "//")
+	case "sql":
+		return strings.HasPrefix(code, "--")
+	case "json":
+		return false
+	default:
+		return strings.HasPrefix(code, "#") || strings.HasPrefix(code, "//")
+	}
+}
+
+func isCodeKeyword(keywords []string, token string, ignoreCase bool) bool {
+	for _, keyword := range keywords {
+		if keyword == token || (ignoreCase && strings.EqualFold(keyword, token)) {
+			return true
+		}
+	}
+	return false
+}
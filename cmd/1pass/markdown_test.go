@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	note := "# Failover\n" +
+		"Run this when the **primary** is down. See [the docs](https://example.com/db).\n" +
+		"\n" +
+		"## Steps\n" +
+		"- Check `pg_isready`\n" +
+		"  * Wait for replicas\n" +
+		"1. Promote\n" +
+		"> Never run this on staging\n" +
+		"---\n" +
+		"```sh\n" +
+		"# promote the replica\n" +
+		"sudo pg_ctl promote\n" +
+		"```\n"
+
+	plain := markdownRenderer{}.render(note)
+	expected := "FAILOVER\n" +
+		"Run this when the primary is down. See the docs (https://example.com/db).\n" +
+		"\n" +
+		"Steps\n" +
+		"  • Check pg_isready\n" +
+		"    • Wait for replicas\n" +
+		"  1. Promote\n" +
+		"│ Never run this on staging\n" +
+		strings.Repeat("─", 40) + "\n" +
+		"    # promote the replica\n" +
+		"    sudo pg_ctl promote\n"
+	if plain != expected {
+		t.Errorf("Unexpected rendered note:\n%s\nexpected:\n%s", plain, expected)
+	}
+
+	colored := markdownRenderer{color: true}.render(note)
+	for _, styled := range []string{
+		ansiBold + "FAILOVER" + ansiReset,
+		ansiBold + "primary" + ansiReset,
+		ansiCyan + "pg_isready" + ansiReset,
+		ansiDim + "# promote the replica" + ansiReset,
+		ansiMagenta + ansiBold + "sudo" + ansiReset + " pg_ctl promote",
+	} {
+		if !strings.Contains(colored, styled) {
+			t.Errorf("Expected rendered note to contain %q:\n%q", styled, colored)
+		}
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	r := markdownRenderer{color: true}
+	line := r.highlightCode(`SELECT name FROM users WHERE id = 42 -- admin`, "postgresql")
+	expected := ansiMagenta + ansiBold + "SELECT" + ansiReset + " name " +
+		ansiMagenta + ansiBold + "FROM" + ansiReset + " users " +
+		ansiMagenta + ansiBold + "WHERE" + ansiReset + " id = " +
+		ansiCyan + "42" + ansiReset + " " + ansiDim + "-- admin" + ansiReset
+	if line != expected {
+		t.Errorf("Unexpected highlighted code: %q", line)
+	}
+	if line := r.highlightCode(`echo "it's done"`, "bash"); !strings.Contains(line, ansiGreen+`"it's done"`+ansiReset) {
+		t.Errorf("Expected string to be highlighted: %q", line)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass/pwstrength"
+)
+
+// master passwords with a lower score from pwstrength.Estimate()
+// are refused unless '--force' is used
+const minMasterPasswordScore = 3
+
+func masterPasswordStrengthHelp() string {
+	return fmt.Sprintf(`The strength of the new master password is estimated from the common
+passwords, words, keyboard rows, sequences, repeats and dates which it
+contains. Passwords rated below '%s' are refused unless '--force' is used.
+Several random words, or a password from 'gen-password', make a strong
+master password.`, pwstrength.ScoreDescription(minMasterPasswordScore))
+}
+
+// masterPasswordUserInputs returns words associated with the
+// vault at vaultPath which should not be used in its password
+func masterPasswordUserInputs(vaultPath string) []string {
+	inputs := []string{"1password", "1pass", "agilekeychain", "vault", "master"}
+	name := strings.TrimSuffix(filepath.Base(vaultPath), ".agilekeychain")
+	if name != "" && name != "." {
+		inputs = append(inputs, name)
+	}
+	if user := os.Getenv("USER"); user != "" {
+		inputs = append(inputs, user)
+	}
+	return inputs
+}
+
+// checkMasterPasswordStrength prints the estimated strength of a new
+// master password for the vault at vaultPath and exits if it is too
+// weak, unless force is set
+func checkMasterPasswordStrength(pwd []byte, vaultPath string, force bool) {
+	strength := pwstrength.Estimate(string(pwd), masterPasswordUserInputs(vaultPath)...)
+	fmt.Printf("Password strength: %s\n", describeStrength(strength))
+	if strength.Warning != "" {
+		fmt.Printf("%s\n", strength.Warning)
+	}
+	if strength.Score >= minMasterPasswordScore {
+		return
+	}
+	if !force {
+		fatalErr(nil, "The master password is too weak. Choose a stronger password or use --force to use it anyway")
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Using a weak master password\n")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func mirrorHelp() string {
+	return `Creates a separate vault containing copies of only the items matching
+'--filter', encrypted with a new master password of its own. The mirror
+can be given to automation such as CI jobs, which can then read those
+items with the mirror's password without access to the rest of the vault.
+
+Each '--filter' is either 'tag:<tag>', which selects items with that tag,
+or a pattern as for 'list'. Items matching any of the filters are copied,
+together with the folders which contain them. Items in the trash are not
+copied.
+
+The mirror is read-only. With '--refresh', an existing mirror is updated
+from the current vault using the filters it was created with: changed
+items are copied again and items which no longer match are removed from
+it. You are prompted for the mirror's password.`
+}
+
+// mirrorFilterItems returns the items of the vault which
+// match any of the filters described by mirrorHelp()
+func mirrorFilterItems(vault *onepass.Vault, filters []string) []onepass.Item {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	candidates := []onepass.Item{}
+	for _, item := range items {
+		if !item.Trashed && item.TypeName != folderTypeName {
+			candidates = append(candidates, item)
+		}
+	}
+	private := readConfig().PrivateTags
+	selected := map[string]bool{}
+	for _, filter := range filters {
+		if strings.HasPrefix(filter, "tag:") {
+			tag := strings.TrimPrefix(filter, "tag:")
+			for _, item := range candidates {
+				if hasTag(itemTags(&item, private), tag) {
+					selected[item.Uuid] = true
+				}
+			}
+			continue
+		}
+		matches, err := matchPattern(candidates, filter)
+		if err != nil {
+			fatalErr(err, "")
+		}
+		for _, item := range matches {
+			selected[item.Uuid] = true
+		}
+	}
+	result := []onepass.Item{}
+	for _, item := range candidates {
+		if selected[item.Uuid] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// createMirror creates a read-only mirror vault at outPath
+// containing the items which match filters
+func createMirror(vault *onepass.Vault, filters []string, outPath string, force bool) {
+	if len(filters) == 0 {
+		fatalErr(fmt.Errorf("Use '--filter' to choose the items to mirror"), "")
+	}
+	if !strings.HasSuffix(outPath, ".agilekeychain") {
+		outPath += ".agilekeychain"
+	}
+	items := mirrorFilterItems(vault, filters)
+	if len(items) == 0 {
+		fatalErr(fmt.Errorf("No items match the filters"), "")
+	}
+
+	fmt.Printf("Password for mirror: ")
+	pwd, err := readTerminalPassword()
+	if err != nil {
+		os.Exit(1)
+	}
+	fmt.Printf("\nRe-enter password for mirror: ")
+	pwd2, _ := readTerminalPassword()
+	fmt.Println()
+	if !bytes.Equal(pwd, pwd2) {
+		fatalErr(nil, "Passwords do not match")
+	}
+	onepass.WipeBytes(pwd2)
+	checkMasterPasswordStrength(pwd, outPath, force)
+
+	mirror, err := onepass.NewVault(outPath, onepass.VaultSecurity{MasterPwd: string(pwd)})
+	if err != nil {
+		fatalErr(err, "Failed to create mirror vault")
+	}
+	updateMirror(vault, &mirror, pwd, items, filters)
+	onepass.WipeBytes(pwd)
+}
+
+// refreshMirror updates the mirror vault at outPath from the current vault
+func refreshMirror(vault *onepass.Vault, outPath string) {
+	mirror, err := onepass.OpenVault(outPath)
+	if err != nil {
+		fatalErr(err, "Unable to open mirror vault")
+	}
+	options, err := mirror.Options()
+	if err != nil {
+		fatalErr(err, "Unable to read mirror settings")
+	}
+	if options.Mirror == nil {
+		fatalErr(fmt.Errorf("'%s' is not a mirror vault", outPath), "")
+	}
+	sourcePath, _ := filepath.Abs(options.Mirror.Path)
+	vaultPath, _ := filepath.Abs(vault.Path)
+	if sourcePath != vaultPath {
+		fatalErr(fmt.Errorf("'%s' is a mirror of '%s', not the current vault", outPath, options.Mirror.Path), "")
+	}
+
+	fmt.Printf("Password for mirror: ")
+	pwd, err := readTerminalPassword()
+	fmt.Println()
+	if err != nil {
+		os.Exit(1)
+	}
+	items := mirrorFilterItems(vault, options.Mirror.Filters)
+	updateMirror(vault, &mirror, pwd, items, options.Mirror.Filters)
+	onepass.WipeBytes(pwd)
+}
+
+func updateMirror(vault *onepass.Vault, mirror *onepass.Vault, pwd []byte, items []onepass.Item, filters []string) {
+	err := mirror.Unlock(pwd)
+	if _, ok := err.(onepass.DecryptError); ok {
+		fatalErr(nil, "Incorrect password for mirror")
+	} else if err != nil {
+		fatalErr(err, "Unable to unlock mirror vault")
+	}
+	defer mirror.Lock()
+
+	result, err := onepass.UpdateMirror(vault, items, filters, mirror)
+	if err != nil {
+		fatalErr(err, "Failed to update mirror")
+	}
+	for _, item := range result.Copied {
+		fmt.Printf("Copied '%s' (%s)\n", item.Title, item.Uuid[0:4])
+	}
+	for _, item := range result.Removed {
+		fmt.Printf("Removed '%s' (%s)\n", item.Title, item.Uuid[0:4])
+	}
+	fmt.Printf("The mirror in '%s' contains %d items\n", mirror.Path, len(items))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func normalizeUrlsHelp() string {
+	return `Rewrites the websites of items in a canonical form, so that they match
+the sites visited in a browser and equivalent websites can be recognized:
+
+  - The scheme and host name are converted to lowercase
+  - Default ports, such as ':443' for https, are removed
+  - Tracking parameters such as 'utm_source' and 'fbclid' are removed
+  - Websites which are the same as another website of the item once
+    normalized are removed
+
+With '--https', 'http' websites are also changed to 'https', except
+for addresses on the local network.
+
+To normalize websites automatically whenever items are saved, set
+'NormalizeUrls' and optionally 'UpgradeUrlsToHttps' to true in ~/.1pass.`
+}
+
+// normalizeItemUrls normalizes the websites of the
+// selected items. See onepass.Item.NormalizeUrls()
+func normalizeItemUrls(vault *onepass.Vault, selection itemSelection, upgradeToHttps bool) {
+	items, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	vault.BeginBatch()
+	changed := 0
+	for _, item := range items {
+		itemChanged, err := item.NormalizeUrls(upgradeToHttps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to normalize websites of '%s': %v\n", item.Title, err)
+			continue
+		}
+		if !itemChanged {
+			continue
+		}
+		if !vault.DryRun {
+			logItemAction("Normalizing websites of item", item)
+		}
+		err = item.Save()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to save item: %v\n", err)
+			continue
+		}
+		changed++
+	}
+	endBatch(vault)
+	if changed == 0 {
+		fmt.Printf("No websites needed to be normalized\n")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// addNote creates a Secure Note from the text read from input,
+// with the given tags and in the folder at folderPath
+func addNote(vault *onepass.Vault, title string, input io.Reader, tags []string, folderPath string) {
+	text, err := ioutil.ReadAll(input)
+	if err != nil {
+		fatalErr(err, "Unable to read note")
+	}
+	if strings.TrimSpace(string(text)) == "" {
+		fatalErr(fmt.Errorf("The note is empty"), "")
+	}
+
+	folderUuid := ""
+	if folderPath != "" {
+		items, err := vault.ListItems()
+		if err != nil {
+			fatalErr(err, "Unable to list vault items")
+		}
+		folderUuid, err = newFolderCreator(vault, items).folderUuid(folderPath)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to create folder '%s'", folderPath))
+		}
+	}
+
+	item, err := vault.AddItem(title, "securenotes.SecureNote", onepass.ItemContent{Notes: string(text)})
+	if err != nil {
+		fatalErr(err, "Unable to add note")
+	}
+	if folderUuid != "" || len(tags) > 0 {
+		item.FolderUuid = folderUuid
+		setItemTags(&item, tags, readConfig().PrivateTags)
+	}
+	logItemAction("Added note", item)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func exportPassHelp() string {
+	return `Writes matching items to a 'pass' password store, with one GPG-encrypted
+file per item. The first line of each file is the item's password and
+the following lines contain its username ('login: ...'), website
+('url: ...'), other fields and notes, as used by 'pass' and its browser
+extensions. Items are placed in directories matching their folders.
+
+The store defaults to $PASSWORD_STORE_DIR or ~/.password-store. Files are
+encrypted for the GPG keys listed in the store's '.gpg-id' file, or those
+given with --gpg-id, which also creates the '.gpg-id' file for a new
+store. Existing entries are overwritten, but entries for items which
+have been removed from the vault are not deleted.`
+}
+
+// passEntryPath returns the path within a password store for an item,
+// based on its folder path and title
+func passEntryPath(folder string, title string) string {
+	clean := func(name string) string {
+		name = strings.TrimSpace(strings.Replace(name, "/", "-", -1))
+		if name == "" || name == "." || name == ".." {
+			name = "Untitled"
+		}
+		return name
+	}
+	parts := []string{}
+	if folder != "" {
+		for _, part := range strings.Split(folder, "/") {
+			parts = append(parts, clean(part))
+		}
+	}
+	parts = append(parts, clean(title))
+	return filepath.Join(parts...)
+}
+
+// formatPassEntry returns the contents of a password store entry for
+// an item: the password on the first line followed by other fields
+func formatPassEntry(content onepass.ItemContent) string {
+	var entry bytes.Buffer
+	password := content.Password()
+	fmt.Fprintf(&entry, "%s\n", password)
+	if username := content.Username(); username != "" {
+		fmt.Fprintf(&entry, "login: %s\n", username)
+	}
+	if url := content.PrimaryURL(); url != "" {
+		fmt.Fprintf(&entry, "url: %s\n", url)
+	}
+	if otp := content.OTPSecret(); otp != "" {
+		fmt.Fprintf(&entry, "%s\n", otp)
+	}
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			value := field.ValueString()
+			if value == "" || value == password || value == content.OTPSecret() ||
+				field.Name == "username" || field.Name == "url" || field.Name == "website" {
+				continue
+			}
+			title := field.Title
+			if title == "" {
+				title = field.Name
+			}
+			fmt.Fprintf(&entry, "%s: %s\n", title, strings.Replace(value, "\n", " ", -1))
+		}
+	}
+	if content.Notes != "" {
+		fmt.Fprintf(&entry, "\n%s\n", strings.TrimRight(content.Notes, "\n"))
+	}
+	return entry.String()
+}
+
+// readGpgIds returns the GPG key IDs listed in a password
+// store's .gpg-id file
+func readGpgIds(storeDir string) ([]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(storeDir, ".gpg-id"))
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ids = append(ids, line)
+		}
+	}
+	return ids, nil
+}
+
+func writeGpgFile(path string, data string, gpgIds []string) error {
+	args := []string{"--batch", "--yes", "--quiet", "--encrypt", "--output", path}
+	for _, id := range gpgIds {
+		args = append(args, "--recipient", id)
+	}
+	gpg := exec.Command("gpg", args...)
+	gpg.Stdin = strings.NewReader(data)
+	gpg.Stderr = os.Stderr
+	return gpg.Run()
+}
+
+func exportPass(vault *onepass.Vault, selection itemSelection, storeDir string, gpgIds []string) {
+	if storeDir == "" {
+		storeDir = os.Getenv("PASSWORD_STORE_DIR")
+	}
+	if storeDir == "" {
+		storeDir = os.Getenv("HOME") + "/.password-store"
+	}
+	if len(gpgIds) == 0 {
+		var err error
+		gpgIds, err = readGpgIds(storeDir)
+		if err != nil || len(gpgIds) == 0 {
+			fatalErr(fmt.Errorf("No GPG key IDs found in %s/.gpg-id, use --gpg-id to specify them", storeDir), "")
+		}
+	} else {
+		err := os.MkdirAll(storeDir, 0700)
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(storeDir, ".gpg-id"), []byte(strings.Join(gpgIds, "\n")+"\n"), 0600)
+		}
+		if err != nil {
+			fatalErr(err, "Unable to create password store")
+		}
+	}
+
+	allItems, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	paths := folderPaths(allItems)
+	matches, err := selectItems(vault, selection)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	items := []onepass.Item{}
+	for _, item := range matches {
+		if !item.Trashed && !strings.HasPrefix(item.TypeName, "system.") {
+			items = append(items, item)
+		}
+	}
+	contents, err := vault.ItemContents(items)
+	if err != nil {
+		fatalErr(err, "Unable to decrypt items")
+	}
+
+	written := map[string]bool{}
+	for i, item := range items {
+		entryPath := passEntryPath(paths[item.FolderUuid], item.Title)
+		if written[entryPath] {
+			entryPath += " (" + item.Uuid[0:4] + ")"
+		}
+		written[entryPath] = true
+
+		path := filepath.Join(storeDir, entryPath+".gpg")
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+		if err == nil {
+			err = writeGpgFile(path, formatPassEntry(contents[i]), gpgIds)
+		}
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to write '%s'", path))
+		}
+		fmt.Printf("Exported '%s' to %s\n", item.Title, entryPath)
+	}
+}
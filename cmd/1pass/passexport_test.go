@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestPassEntryPath(t *testing.T) {
+	cases := []struct {
+		folder string
+		title  string
+		path   string
+	}{
+		{"", "GitHub", "GitHub"},
+		{"Work/Cloud", "AWS", "Work/Cloud/AWS"},
+		{"", "example.com/login", "example.com-login"},
+		{"Work", "..", "Work/Untitled"},
+	}
+	for _, testCase := range cases {
+		path := passEntryPath(testCase.folder, testCase.title)
+		if path != testCase.path {
+			t.Errorf("Expected path '%s' for %q, got '%s'", testCase.path, testCase.title, path)
+		}
+	}
+}
+
+func TestFormatPassEntry(t *testing.T) {
+	content := onepass.NewLoginContent("jim", "secret", "https://github.com")
+	content.Notes = "Recovery codes\nare in the safe"
+	content.Sections = append(content.Sections, onepass.ItemSection{
+		Name:  "extra",
+		Title: "Extra",
+		Fields: []onepass.ItemField{
+			{Name: "pin", Title: "PIN", Kind: "string", Value: "1234"},
+		},
+	})
+	expected := "secret\nlogin: jim\nurl: https://github.com\nPIN: 1234\n\nRecovery codes\nare in the safe\n"
+	entry := formatPassEntry(content)
+	if entry != expected {
+		t.Errorf("Expected entry:\n%s\ngot:\n%s", expected, entry)
+	}
+}
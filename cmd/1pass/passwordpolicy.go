@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// rules for passwords generated or entered by readNewPassword(),
+// set while editing an item which has password rules
+var genPasswordPolicy *onepass.PasswordPolicy
+
+func setPolicyHelp() string {
+	return `Stores the rules which an item's website applies to passwords, so that
+random passwords generated for the item by 'edit' and 'respond-breach'
+are accepted by the site. The rules are a comma-separated list such as:
+
+  min 8, max 16, at least one symbol, no spaces, symbols: !@#$%
+
+Lengths can also be given as '8-16 characters', '8+ characters' or
+'exactly 6'. The kinds of character are lowercase, uppercase, digit,
+symbol and space. 'symbols:' lists the only symbols which the site
+allows. Use 'none' to remove the rules.
+
+The rules are stored in the item's encrypted content. A warning is shown
+if the item's current password does not meet them.`
+}
+
+// setItemPasswordPolicy sets the password rules for
+// the items matching pattern
+func setItemPasswordPolicy(vault *onepass.Vault, pattern string, description string) {
+	if description == "none" {
+		description = ""
+	}
+	policy, err := onepass.ParsePasswordPolicy(description)
+	if err != nil {
+		fatalErr(err, "")
+	}
+	items, err := lookupItems(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	if len(items) == 0 {
+		fatalErr(fmt.Errorf("No matching items"), "")
+	}
+	for _, item := range items {
+		content, err := item.Content()
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to decrypt item '%s'", item.Title))
+		}
+		if password := content.Password(); password != "" && description != "" {
+			if err := policy.Check(password); err != nil {
+				fmt.Fprintf(os.Stderr, "The password of '%s' does not meet the rules: %v\n", item.Title, err)
+			}
+		}
+		logItemAction("Setting password rules of item", item)
+		content.PasswordPolicy = description
+		err = item.SetContent(content)
+		if err != nil {
+			fatalErr(err, "Unable to update item")
+		}
+		err = item.Save()
+		if err != nil {
+			fatalErr(err, "Unable to save item")
+		}
+	}
+}
+
+// itemPasswordPolicy returns the password rules set for an item
+// with 'set-policy', or nil if it has none
+func itemPasswordPolicy(item onepass.Item, content onepass.ItemContent) *onepass.PasswordPolicy {
+	if content.PasswordPolicy == "" {
+		return nil
+	}
+	policy, err := content.Policy()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring the password rules of '%s': %v\n", item.Title, err)
+		return nil
+	}
+	return &policy
+}
+
+// genPasswordForPolicy generates a password which meets policy using
+// the recipe from the 'PasswordRecipes' setting for the current vault
+// or the 'default' recipe
+func genPasswordForPolicy(policy onepass.PasswordPolicy, purpose string) string {
+	recipe, ok := configuredGenRecipe(readConfig(), recipeVaultPath)
+	if !ok {
+		recipe = onepass.GenRecipes["default"]
+	}
+	agentClient := connectToAgent(readConfig(), "")
+	password, err := onepass.GenerateForPolicyWith(policy, recipe, 0, func(recipe onepass.GenRecipe, length int) (string, error) {
+		return agentClient.GenPasswordWithRecipe(recipe, length, purpose)
+	})
+	if err != nil {
+		fatalErr(err, "Unable to generate password")
+	}
+	return password
+}
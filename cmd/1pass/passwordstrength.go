@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/pwstrength"
+)
+
+// manually entered item passwords with a lower score from
+// onepass.EstimateEntropy() produce a warning in 'add' and 'edit'
+const minItemPasswordScore = 3
+
+func analyzePasswordHelp() string {
+	return `With '--analyze', a password is read from stdin instead and its
+strength is estimated from the common passwords, words, keyboard rows,
+sequences, repeats and dates which it contains, in the manner of
+zxcvbn. The same estimate is used by 'audit weak', the 'weak-passwords'
+check of 'health' and to warn about weak passwords entered in 'add' and
+'edit'.`
+}
+
+// describeStrength returns a one-line summary of a
+// password's estimated strength
+func describeStrength(strength pwstrength.Strength) string {
+	return fmt.Sprintf("%s (about 2^%.0f guesses)", pwstrength.ScoreDescription(strength.Score), strength.Bits)
+}
+
+// readAnalyzedPassword reads the password for 'gen-password --analyze'
+// from stdin, prompting for it if stdin is a terminal
+func readAnalyzedPassword(input io.Reader) (string, error) {
+	if input == os.Stdin && terminal.IsTerminal(0) {
+		fmt.Printf("Password: ")
+		pwd, err := terminal.ReadPassword(0)
+		fmt.Println()
+		return string(pwd), err
+	}
+	line, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// analyzePassword prints the estimated strength of a password
+// and the patterns which it is made up of
+func analyzePassword(password string) {
+	strength := onepass.EstimateEntropy(password)
+	fmt.Printf("Strength: %d of 4, %s\n", strength.Score, describeStrength(strength))
+	if len(strength.Matches) > 0 {
+		fmt.Printf("Patterns:\n")
+		for _, match := range strength.Matches {
+			fmt.Printf("  %-12s %-20q 2^%.1f guesses\n", match.Pattern, match.Token, math.Log2(match.Guesses))
+		}
+	}
+	if strength.Warning != "" {
+		fmt.Printf("Warning: %s\n", strength.Warning)
+	}
+}
+
+// warnIfWeakPassword prints a warning if a password entered
+// for an item is easy to guess
+func warnIfWeakPassword(password string) {
+	strength := onepass.EstimateEntropy(password)
+	if strength.Score >= minItemPasswordScore {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: This password is %s.", describeStrength(strength))
+	if strength.Warning != "" {
+		fmt.Fprintf(os.Stderr, " %s", strength.Warning)
+	}
+	fmt.Fprintf(os.Stderr, " Use '-' to generate a random password instead.\n")
+}
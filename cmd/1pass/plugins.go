@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+// prefix of the names of plugin executables
+const pluginPrefix = "1pass-"
+
+func listPluginsHelp() string {
+	return `Plugins are executables named '1pass-<name>' on $PATH.
+
+Running '1pass <name>', where <name> is not a built-in command, runs
+'1pass-<name>' with the remaining arguments. The path of the vault,
+the config file, the agent's socket and the 1pass binary are passed
+in the ONEPASS_VAULT, ONEPASS_CONFIG, ONEPASS_AGENT_SOCKET and
+ONEPASS_BIN environment variables, so that the command can use the
+agent while the vault is unlocked, or run 1pass itself.
+
+Plugins can also be run before and after items are added, edited,
+copied or exported. Plugins only run for these hooks once they have
+been enabled by adding their name to the 'Plugins' list in ~/.1pass.
+
+Each plugin is run with the arguments 'hook <hook-name>' and receives
+a JSON object describing the action on stdin. The hooks are:
+
+  before-add, after-add        Adding an item with 'add'
+  before-edit, after-edit      Editing an item with 'edit'
+  before-copy, after-copy      Copying a field with 'copy'
+  before-export, after-export  Exporting items with 'export',
+                               'export-bitwarden' or 'export-pass'
+
+The JSON object has the following properties:
+
+  hook     The name of the hook
+  vault    The path of the vault
+  item     The item's 'uuid', 'title', 'typeName' and 'folderUuid'
+  content  The item's decrypted content, for the add and edit hooks
+  field    The title of the copied field, for the copy hooks
+  pattern  The pattern matching the exported items, for the export hooks
+  path     The output path, for the export hooks
+  format   The export format: '1pif', 'bitwarden' or 'pass'
+
+Plugins should ignore hooks which they do not handle. If a plugin
+exits with a non-zero status from a 'before-*' hook, the action is
+cancelled. Output from plugins is written to stderr.`
+}
+
+// hookItem identifies the item passed to plugins
+type hookItem struct {
+	Uuid       string `json:"uuid"`
+	Title      string `json:"title"`
+	TypeName   string `json:"typeName"`
+	FolderUuid string `json:"folderUuid"`
+}
+
+// hookEvent is the JSON object passed to plugins
+// on stdin. See listPluginsHelp()
+type hookEvent struct {
+	Hook    string               `json:"hook"`
+	Vault   string               `json:"vault"`
+	Item    *hookItem            `json:"item,omitempty"`
+	Content *onepass.ItemContent `json:"content,omitempty"`
+	Field   string               `json:"field,omitempty"`
+	Pattern string               `json:"pattern,omitempty"`
+	Path    string               `json:"path,omitempty"`
+	Format  string               `json:"format,omitempty"`
+}
+
+// newItemHookEvent returns an event for hooks
+// which act on a single item
+func newItemHookEvent(vault *onepass.Vault, item onepass.Item) hookEvent {
+	return hookEvent{
+		Vault: vault.Path,
+		Item: &hookItem{
+			Uuid:       item.Uuid,
+			Title:      item.Title,
+			TypeName:   item.TypeName,
+			FolderUuid: item.FolderUuid,
+		},
+	}
+}
+
+// findPlugins returns a map of plugin name -> path for the plugin
+// executables on $PATH. If several directories contain a plugin
+// with the same name, the first one on $PATH is used.
+func findPlugins() map[string]string {
+	plugins := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimPrefix(entry.Name(), pluginPrefix)
+			if name == entry.Name() || name == "" || entry.IsDir() || entry.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			if _, ok := plugins[name]; !ok {
+				plugins[name] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	return plugins
+}
+
+// runHooks runs the enabled plugins for a hook. If a plugin fails
+// during a 'before-*' hook, the command exits. Failures during other
+// hooks are reported but the command continues. Plugins are not run
+// for commands run with '-dry-run'.
+func runHooks(hook string, event hookEvent) {
+	config := readConfig()
+	if len(config.Plugins) == 0 || dryRun {
+		return
+	}
+	event.Hook = hook
+	input, err := json.Marshal(event)
+	if err != nil {
+		fatalErr(err, "Unable to encode plugin input")
+	}
+
+	plugins := findPlugins()
+	for _, name := range config.Plugins {
+		path, ok := plugins[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Plugin '%s%s' was not found on $PATH\n", pluginPrefix, name)
+			continue
+		}
+		cmd := exec.Command(path, "hook", hook)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+		if err == nil {
+			continue
+		}
+		if strings.HasPrefix(hook, "before-") {
+			fatalErr(err, fmt.Sprintf("Plugin '%s' cancelled the action", name))
+		}
+		fmt.Fprintf(os.Stderr, "Plugin '%s' failed: %v\n", name, err)
+	}
+}
+
+// isBuiltinCommand returns true if name is one of 1pass's
+// own commands rather than an external command
+func isBuiltinCommand(name string) bool {
+	for _, mode := range commandModes {
+		if mode.Command == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runExternalCommand runs the '1pass-<command>' executable at path
+// for a command which is not built in and exits with its status
+func runExternalCommand(path string, config clientConfig, args []string) {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"ONEPASS_VAULT="+config.VaultDir,
+		"ONEPASS_CONFIG="+configPath,
+		"ONEPASS_AGENT_SOCKET="+client.DefaultAgentSocket(),
+		"ONEPASS_BIN="+os.Args[0],
+	)
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			os.Exit(status.ExitStatus())
+		}
+		os.Exit(1)
+	} else if err != nil {
+		fatalErr(err, fmt.Sprintf("Unable to run '%s'", path))
+	}
+}
+
+// listPlugins displays the plugins found on $PATH and
+// whether they are enabled
+func listPlugins(config clientConfig) {
+	plugins := findPlugins()
+	enabled := map[string]bool{}
+	for _, name := range config.Plugins {
+		enabled[name] = true
+		if _, ok := plugins[name]; !ok {
+			plugins[name] = "(not found)"
+		}
+	}
+	names := []string{}
+	for name, _ := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		status := "disabled"
+		if enabled[name] {
+			status = "enabled"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\n", name, status, plugins[name])
+	}
+	out.Flush()
+}
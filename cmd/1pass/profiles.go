@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func setProfileHelp() string {
+	return `A vault's keys and items are stored in a profile within the vault.
+Vaults normally have a single profile named 'default', but vaults
+used by several people or created by other apps may have more.
+
+Sets the profile which is used when the vault is opened. If no
+profile is given, the 'default' profile is used if the vault has one,
+otherwise the first profile listed by 'list-profiles'. The profile
+can also be chosen for a single command with the '-profile' flag.`
+}
+
+// openVaultProfile opens the vault at vaultPath, using the
+// given profile or the vault's default profile if it is empty
+func openVaultProfile(vaultPath string, profile string) (onepass.Vault, error) {
+	if profile == "" {
+		return onepass.OpenVault(vaultPath)
+	}
+	return onepass.OpenVaultProfile(vaultPath, profile)
+}
+
+// listProfiles displays the profiles in the vault,
+// marking the one which is in use
+func listProfiles(vault *onepass.Vault) {
+	profiles, err := onepass.VaultProfiles(vault.Path)
+	if err != nil {
+		fatalErr(err, "Unable to list profiles")
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, profile := range profiles {
+		status := ""
+		if profile == vault.Profile {
+			status = "(in use)"
+		}
+		fmt.Fprintf(out, "%s\t%s\n", profile, status)
+	}
+	out.Flush()
+}
+
+// setProfile saves the profile to use when opening vaults. If profile
+// is empty, the configured profile is cleared
+func setProfile(vaultPath string, profile string) {
+	if profile != "" {
+		_, err := onepass.OpenVaultProfile(vaultPath, profile)
+		if err != nil {
+			fatalErr(err, "")
+		}
+	}
+	config := readConfig()
+	config.Profile = profile
+	writeConfig(&config)
+	if profile == "" {
+		fmt.Printf("Using the vault's default profile\n")
+	} else {
+		fmt.Printf("Using the '%s' profile\n", profile)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+	"gopkg.in/yaml.v2"
+)
+
+// retagRule is an entry in a rules file for 'retag'. Items which
+// match all of the rule's conditions are given the rule's tags
+// and moved to its folder.
+type retagRule struct {
+	// glob pattern matched against the host of the item's website
+	Url string `yaml:"url"`
+	// item type, either a short alias or a full type name
+	Type string `yaml:"type"`
+	// regular expression matched against the item's title
+	Title string `yaml:"title"`
+
+	Tags   []string `yaml:"tags"`
+	Folder string   `yaml:"folder"`
+
+	typeName string
+	titleRe  *regexp.Regexp
+}
+
+type retagRules struct {
+	Rules []retagRule `yaml:"rules"`
+}
+
+func retagHelp() string {
+	return `Applies tags and folders to items according to a YAML rules file. Each
+rule has one or more conditions and the tags to add to and the folder to
+move matching items into:
+
+  rules:
+  - url: "*.amazonaws.com"
+    tags: [aws]
+    folder: Cloud
+  - type: card
+    title: "(?i)^work"
+    tags: [work, expenses]
+
+'url' is a glob pattern matched against the host of the item's website,
+'type' is an item type (see 'help add') and 'title' is a regular
+expression matched against the item's title. Items must match all of
+a rule's conditions. Folders are created if they do not exist.
+
+Tags are only ever added, so running 'retag' again with the same rules
+makes no further changes.`
+}
+
+func readRetagRules(path string) ([]retagRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRetagRules(data)
+}
+
+func parseRetagRules(data []byte) ([]retagRule, error) {
+	var rules retagRules
+	err := yaml.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if rule.Url == "" && rule.Type == "" && rule.Title == "" {
+			return nil, fmt.Errorf("Rule %d has no 'url', 'type' or 'title' condition", i+1)
+		}
+		if len(rule.Tags) == 0 && rule.Folder == "" {
+			return nil, fmt.Errorf("Rule %d has no 'tags' or 'folder'", i+1)
+		}
+		if _, err := path.Match(rule.Url, ""); err != nil {
+			return nil, fmt.Errorf("Rule %d has an invalid URL pattern: %v", i+1, err)
+		}
+		if rule.Type != "" {
+			rule.typeName = typeFromAlias(rule.Type)
+			if _, ok := onepass.ItemTypes[rule.Type]; ok {
+				rule.typeName = rule.Type
+			}
+			if rule.typeName == "" {
+				return nil, fmt.Errorf("Rule %d has unknown item type '%s'", i+1, rule.Type)
+			}
+		}
+		if rule.Title != "" {
+			rule.titleRe, err = regexp.Compile(rule.Title)
+			if err != nil {
+				return nil, fmt.Errorf("Rule %d has an invalid title pattern: %v", i+1, err)
+			}
+		}
+	}
+	return rules.Rules, nil
+}
+
+func (rule *retagRule) matches(item *onepass.Item) bool {
+	if rule.Url != "" {
+		host := urlHost(item.Location)
+		if matched, _ := path.Match(strings.ToLower(rule.Url), host); host == "" || !matched {
+			return false
+		}
+	}
+	if rule.typeName != "" && item.TypeName != rule.typeName {
+		return false
+	}
+	if rule.titleRe != nil && !rule.titleRe.MatchString(item.Title) {
+		return false
+	}
+	return true
+}
+
+// applyRetagRules returns the tags and folder path for an item after
+// applying rules to it. Where several matching rules specify a folder,
+// the last one wins. folder is empty if no matching rule has a folder.
+func applyRetagRules(rules []retagRule, item *onepass.Item, tags []string) (newTags []string, folder string) {
+	newTags = append([]string{}, tags...)
+	for i := range rules {
+		if !rules[i].matches(item) {
+			continue
+		}
+		for _, tag := range rules[i].Tags {
+			if !hasTag(newTags, tag) {
+				newTags = append(newTags, tag)
+			}
+		}
+		if rules[i].Folder != "" {
+			folder = rules[i].Folder
+		}
+	}
+	return newTags, folder
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, existing := range tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func retagItems(vault *onepass.Vault, rulesPath string, dryRun bool) {
+	rules, err := readRetagRules(rulesPath)
+	if err != nil {
+		fatalErr(err, "Unable to read rules")
+	}
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+
+	folders := newFolderCreator(vault, items)
+	paths := folderPaths(items)
+	private := readConfig().PrivateTags
+	updated := 0
+	vault.BeginBatch()
+	for _, item := range items {
+		if item.Trashed || item.TypeName == folderTypeName || item.TypeName == "system.Tombstone" {
+			continue
+		}
+		tags := itemTags(&item, private)
+		newTags, folder := applyRetagRules(rules, &item, tags)
+		moved := folder != "" && paths[item.FolderUuid] != strings.Trim(folder, "/")
+		if !moved && len(newTags) == len(tags) {
+			continue
+		}
+
+		updated++
+		if dryRun {
+			fmt.Printf("%s: tags [%s]", item.Title, strings.Join(newTags, ", "))
+			if moved {
+				fmt.Printf(", folder '%s'", folder)
+			}
+			fmt.Printf("\n")
+			continue
+		}
+		logItemAction("Updating item", item)
+		if moved {
+			item.FolderUuid, err = folders.folderUuid(folder)
+			if err != nil {
+				fatalErr(err, fmt.Sprintf("Unable to create folder '%s'", folder))
+			}
+		}
+		setItemTags(&item, newTags, private)
+	}
+	endBatch(vault)
+	if dryRun {
+		fmt.Printf("%d items would be updated\n", updated)
+	} else {
+		fmt.Printf("Updated %d items\n", updated)
+	}
+}
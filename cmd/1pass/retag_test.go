@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+const testRetagRules = `
+rules:
+- url: "*.amazonaws.com"
+  tags: [aws]
+  folder: Cloud
+- type: card
+  title: "(?i)^work"
+  tags: [work, expenses]
+- type: login
+  title: "(?i)aws"
+  tags: [aws, admin]
+  folder: Cloud/Admin
+`
+
+func TestApplyRetagRules(t *testing.T) {
+	rules, err := parseRetagRules([]byte(testRetagRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		item   onepass.Item
+		tags   []string
+		folder string
+	}{
+		{onepass.Item{Title: "S3", TypeName: "webforms.WebForm", Location: "https://s3.console.amazonaws.com/"},
+			[]string{"aws"}, "Cloud"},
+		{onepass.Item{Title: "AWS Root", TypeName: "webforms.WebForm", Location: "https://console.amazonaws.com"},
+			[]string{"aws", "admin"}, "Cloud/Admin"},
+		{onepass.Item{Title: "Work Visa", TypeName: "wallet.financial.CreditCard"},
+			[]string{"work", "expenses"}, ""},
+		{onepass.Item{Title: "Personal Visa", TypeName: "wallet.financial.CreditCard"},
+			[]string{}, ""},
+		{onepass.Item{Title: "Amazon", TypeName: "webforms.WebForm", Location: "https://amazon.com"},
+			[]string{}, ""},
+	}
+	for _, testCase := range cases {
+		tags, folder := applyRetagRules(rules, &testCase.item, []string{})
+		if strings.Join(tags, ",") != strings.Join(testCase.tags, ",") || folder != testCase.folder {
+			t.Errorf("Unexpected result for '%s': %v, '%s'", testCase.item.Title, tags, folder)
+		}
+	}
+
+	// applying rules again should not duplicate tags
+	item := onepass.Item{Title: "EC2", TypeName: "webforms.WebForm", Location: "ec2.amazonaws.com"}
+	tags, _ := applyRetagRules(rules, &item, []string{"existing", "aws"})
+	if strings.Join(tags, ",") != "existing,aws" {
+		t.Errorf("Unexpected tags after reapplying rules: %v", tags)
+	}
+}
+
+func TestInvalidRetagRules(t *testing.T) {
+	invalidRules := []string{
+		"rules:\n- tags: [no-condition]",
+		"rules:\n- type: login",
+		"rules:\n- type: unknown-type\n  tags: [x]",
+		"rules:\n- title: '(unclosed'\n  tags: [x]",
+		"rules:\n- url: '[a-'\n  tags: [x]",
+	}
+	for _, rules := range invalidRules {
+		_, err := parseRetagRules([]byte(rules))
+		if err == nil {
+			t.Errorf("Expected error parsing rules %q", rules)
+		}
+	}
+}
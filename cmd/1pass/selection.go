@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robertknight/1pass/cmdmodes"
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+// itemSelection is a set of items selected by one or more patterns,
+// excluding items which match any of a set of exclusion patterns.
+// Each pattern has one of the forms described by listHelp().
+type itemSelection struct {
+	patterns []string
+	exclude  []string
+}
+
+// selectPattern returns a selection of the items matching pattern
+func selectPattern(pattern string, flags cmdmodes.FlagValues) itemSelection {
+	return itemSelection{patterns: []string{pattern}, exclude: flags.Strings("exclude")}
+}
+
+// selectPatternArgs returns the selection for a command whose
+// positional arguments are all patterns. If required is false
+// and there are no arguments, all items are selected.
+func selectPatternArgs(cmdArgs []string, flags cmdmodes.FlagValues, required bool) itemSelection {
+	if required && len(cmdArgs) == 0 {
+		fatalErr(fmt.Errorf("Missing arguments: pattern"), "")
+	}
+	return itemSelection{patterns: cmdArgs, exclude: flags.Strings("exclude")}
+}
+
+// String returns a description of the selection for use in
+// messages and plugin hooks
+func (selection itemSelection) String() string {
+	description := strings.Join(selection.patterns, " ")
+	if len(selection.exclude) > 0 {
+		description += " --exclude " + strings.Join(selection.exclude, " --exclude ")
+	}
+	return description
+}
+
+// matchPattern returns the items which match a title,
+// ID or type pattern. See listHelp()
+func matchPattern(items []onepass.Item, pattern string) ([]onepass.Item, error) {
+	if pattern == lastItemPattern {
+		recent := readConfig().RecentItems
+		if len(recent) == 0 {
+			return nil, fmt.Errorf("No recently used items")
+		}
+		pattern = recent[0]
+	}
+
+	typeName := typeFromAlias(pattern)
+	if typeName != "" {
+		pattern = ""
+	}
+
+	if strings.Contains(pattern, ":") {
+		parts := strings.SplitN(pattern, ":", 2)
+		typeName = typeFromAlias(parts[0])
+		pattern = parts[1]
+
+		if typeName == "" {
+			fatalErr(nil, fmt.Sprintf("Unknown type name '%s'", parts[0]))
+		}
+	}
+	return client.MatchItems(items, pattern, typeName), nil
+}
+
+// selectItems returns the items which match any of the selection's
+// patterns, in the order they are listed in the vault, and do not
+// match any of its exclusion patterns. If the selection has no
+// patterns, all items which are not excluded are returned.
+func selectItems(vault *onepass.Vault, selection itemSelection) ([]onepass.Item, error) {
+	items, err := vault.ListItems()
+	if err != nil {
+		return items, err
+	}
+
+	selected := map[string]bool{}
+	for _, pattern := range selection.patterns {
+		matches, err := matchPattern(items, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range matches {
+			selected[item.Uuid] = true
+		}
+	}
+	excluded := map[string]bool{}
+	for _, pattern := range selection.exclude {
+		matches, err := matchPattern(items, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range matches {
+			excluded[item.Uuid] = true
+		}
+	}
+
+	result := []onepass.Item{}
+	for _, item := range items {
+		if (len(selection.patterns) == 0 || selected[item.Uuid]) && !excluded[item.Uuid] {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
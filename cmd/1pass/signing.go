@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func signItemsHelp() string {
+	return `When item signing is enabled, 1pass adds a signature to each item file,
+computed with a key derived from the vault's key. Items whose signature
+is missing or does not match were changed by something which does not
+know the master password, such as someone with access to the folder
+where the vault is synced, and are reported whenever the vault is used.
+
+Items saved by other 1Password apps are not signed and will also be
+reported. Turning signing on again re-signs all items in the vault, so
+check any reported items first.
+
+The setting is stored in the vault, where someone with access to its
+folder could change it, and also in ~/.1pass. Signatures are checked
+for as long as ~/.1pass says signing is on, and a warning is shown if
+the vault's setting does not match. Run 'sign-items off' on each
+computer where it was turned on to stop checking signatures.`
+}
+
+// warnAboutTamperedItems reports items whose signatures are
+// invalid, for vaults with item signing enabled
+func warnAboutTamperedItems(vault *onepass.Vault) {
+	options, err := vault.Options()
+	if err != nil || (!options.SignItems && !vault.RequireSignatures) {
+		return
+	}
+	if !options.SignItems {
+		fmt.Fprintf(os.Stderr, "Warning: Item signing was turned on for this vault with 'sign-items' "+
+			"but is turned off in the vault's options, which may have been changed without the master password.\n")
+	}
+	items, err := vault.ListItems()
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		if err := item.SignatureErr(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v. It may have been modified without the master password.\n", err)
+		}
+	}
+}
+
+func setSignItems(vault *onepass.Vault, setting string) {
+	if setting != "on" && setting != "off" {
+		fatalErr(fmt.Errorf("Expected 'on' or 'off'"), "")
+	}
+	err := vault.SetSignItems(setting == "on")
+	if err != nil {
+		fatalErr(err, "Unable to update item signatures")
+	}
+	config := readConfig()
+	if config.SignedVaults == nil {
+		config.SignedVaults = map[string]bool{}
+	}
+	if setting == "on" {
+		config.SignedVaults[vault.Path] = true
+	} else {
+		delete(config.SignedVaults, vault.Path)
+	}
+	writeConfig(&config)
+	if setting == "on" {
+		fmt.Printf("Items are now signed\n")
+	} else {
+		fmt.Printf("Item signatures have been removed\n")
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// time allowed for fetching a site's login page
+// for 'add login --from-url'
+const siteFetchTimeout = 10 * time.Second
+
+// maximum size of the page read when fetching site metadata
+const maxSitePageSize = 1024 * 1024
+
+var (
+	htmlTitleRegex    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlMetaRegex     = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	htmlInputRegex    = regexp.MustCompile(`(?is)<input\b[^>]*>`)
+	htmlAttrRegex     = regexp.MustCompile(`(?s)([a-zA-Z_:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	usernameNameRegex = regexp.MustCompile(`(?i)user|email|login|account|identifier`)
+)
+
+// siteMetadata is the information about a site's login page
+// used to fill in a new Login item
+type siteMetadata struct {
+	Url   string
+	Title string
+	// names and IDs of the username and password
+	// fields of the login form, if found
+	UsernameName string
+	UsernameId   string
+	PasswordName string
+	PasswordId   string
+}
+
+// normalizeSiteUrl adds a scheme to a URL entered
+// without one, eg. 'github.com'
+func normalizeSiteUrl(siteUrl string) (*url.URL, error) {
+	if !strings.Contains(siteUrl, "://") {
+		siteUrl = "https://" + siteUrl
+	}
+	parsed, err := url.Parse(siteUrl)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("'%s' is not a website URL", siteUrl)
+	}
+	return parsed, nil
+}
+
+// siteTitleFromUrl returns a title for a site based on its
+// host name, used when the site's page is not fetched
+func siteTitleFromUrl(siteUrl *url.URL) string {
+	return strings.TrimPrefix(siteUrl.Hostname(), "www.")
+}
+
+// fetchSiteMetadata fetches the page at siteUrl and
+// returns its title and login form fields
+func fetchSiteMetadata(siteUrl *url.URL) (siteMetadata, error) {
+	client := http.Client{Timeout: siteFetchTimeout}
+	req, err := http.NewRequest("GET", siteUrl.String(), nil)
+	if err != nil {
+		return siteMetadata{}, err
+	}
+	req.Header.Set("User-Agent", "1pass")
+	req.Header.Set("Accept", "text/html")
+	resp, err := client.Do(req)
+	if err != nil {
+		return siteMetadata{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return siteMetadata{}, fmt.Errorf("The site returned status %s", resp.Status)
+	}
+	page, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSitePageSize))
+	if err != nil {
+		return siteMetadata{}, err
+	}
+	return parseSiteMetadata(siteUrl, string(page)), nil
+}
+
+// parseSiteMetadata extracts the site's name and the fields of
+// its login form from the HTML of the page at siteUrl
+func parseSiteMetadata(siteUrl *url.URL, page string) siteMetadata {
+	metadata := siteMetadata{Url: siteUrl.String(), Title: siteTitleFromUrl(siteUrl)}
+	siteName := ""
+	for _, meta := range htmlMetaRegex.FindAllString(page, -1) {
+		attrs := htmlAttrs(meta)
+		if attrs["property"] == "og:site_name" || attrs["name"] == "application-name" {
+			siteName = strings.TrimSpace(attrs["content"])
+			break
+		}
+	}
+	if siteName != "" {
+		metadata.Title = siteName
+	} else if match := htmlTitleRegex.FindStringSubmatch(page); match != nil {
+		title := strings.Join(strings.Fields(html.UnescapeString(match[1])), " ")
+		if title != "" {
+			metadata.Title = title
+		}
+	}
+
+	// the password field is the first password input and the
+	// username field is the text input before it which looks most
+	// like a username, or the last text input before it otherwise
+	var username map[string]string
+	usernameScore := -1
+	for _, input := range htmlInputRegex.FindAllString(page, -1) {
+		attrs := htmlAttrs(input)
+		inputType := strings.ToLower(attrs["type"])
+		if inputType == "password" {
+			metadata.PasswordName = attrs["name"]
+			metadata.PasswordId = attrs["id"]
+			break
+		}
+		if inputType != "" && inputType != "text" && inputType != "email" && inputType != "tel" {
+			continue
+		}
+		score := 0
+		if attrs["autocomplete"] == "username" || inputType == "email" {
+			score = 2
+		} else if usernameNameRegex.MatchString(attrs["name"] + " " + attrs["id"]) {
+			score = 1
+		}
+		if score >= usernameScore {
+			username = attrs
+			usernameScore = score
+		}
+	}
+	if metadata.PasswordName != "" || metadata.PasswordId != "" {
+		if username != nil {
+			metadata.UsernameName = username["name"]
+			metadata.UsernameId = username["id"]
+		}
+	}
+	return metadata
+}
+
+// htmlAttrs returns the attributes of an HTML tag, with
+// lowercase names
+func htmlAttrs(tag string) map[string]string {
+	attrs := map[string]string{}
+	for _, match := range htmlAttrRegex.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(match[2] + match[3] + match[4])
+	}
+	return attrs
+}
+
+// newLoginSite returns the URL, title and form fields for a new
+// Login item for the site at siteUrl. If offline is set or the
+// site's page cannot be fetched, only the URL and a title based
+// on the host name are returned.
+func newLoginSite(siteUrl string, offline bool) *siteMetadata {
+	parsed, err := normalizeSiteUrl(siteUrl)
+	if err != nil {
+		fatalErr(err, "Invalid URL")
+	}
+	if !offline {
+		fmt.Printf("Fetching %s\n", parsed)
+		metadata, err := fetchSiteMetadata(parsed)
+		if err == nil {
+			return &metadata
+		}
+		fmt.Fprintf(os.Stderr, "Unable to fetch site details: %v\n", err)
+	}
+	return &siteMetadata{Url: parsed.String(), Title: siteTitleFromUrl(parsed)}
+}
+
+// loginFormFieldFromSite returns the name and ID of the field on
+// the site's login page with the given designation, if found
+func loginFormFieldFromSite(site *siteMetadata, designation string) (name string, id string, ok bool) {
+	switch designation {
+	case "username":
+		return site.UsernameName, site.UsernameId, site.UsernameName != "" || site.UsernameId != ""
+	case "password":
+		return site.PasswordName, site.PasswordId, site.PasswordName != "" || site.PasswordId != ""
+	}
+	return "", "", false
+}
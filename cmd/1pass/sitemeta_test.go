@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testLoginPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>
+    Sign in to Example &amp; Co
+  </title>
+</head>
+<body>
+  <form action="/search"><input type="search" name="q"></form>
+  <form action="/session" method="post">
+    <input type="hidden" name="token" value="abc">
+    <input type="text" name="login_field" id="login_field" autocomplete="username">
+    <input type="checkbox" name="remember">
+    <input type='password' name='pass' id='password'>
+    <input type="submit" value="Sign in">
+  </form>
+</body>
+</html>`
+
+func TestParseSiteMetadata(t *testing.T) {
+	siteUrl, _ := normalizeSiteUrl("www.example.com/login")
+	metadata := parseSiteMetadata(siteUrl, testLoginPage)
+	expected := siteMetadata{
+		Url:          "https://www.example.com/login",
+		Title:        "Sign in to Example & Co",
+		UsernameName: "login_field",
+		UsernameId:   "login_field",
+		PasswordName: "pass",
+		PasswordId:   "password",
+	}
+	if metadata != expected {
+		t.Errorf("Unexpected metadata %+v", metadata)
+	}
+
+	metadata = parseSiteMetadata(siteUrl, `<meta property="og:site_name" content="Example"><title>Log in</title>`)
+	if metadata.Title != "Example" || metadata.PasswordName != "" || metadata.UsernameName != "" {
+		t.Errorf("Unexpected metadata for page without a login form %+v", metadata)
+	}
+
+	metadata = parseSiteMetadata(siteUrl, "")
+	if metadata.Title != "example.com" {
+		t.Errorf("Expected title from host name, got '%s'", metadata.Title)
+	}
+}
+
+func TestFetchSiteMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, testLoginPage)
+	}))
+	defer server.Close()
+
+	siteUrl, _ := normalizeSiteUrl(server.URL + "/login")
+	metadata, err := fetchSiteMetadata(siteUrl)
+	if err != nil || metadata.PasswordName != "pass" {
+		t.Errorf("Unexpected metadata %+v: %v", metadata, err)
+	}
+	siteUrl, _ = normalizeSiteUrl(server.URL + "/missing")
+	if _, err := fetchSiteMetadata(siteUrl); err == nil {
+		t.Errorf("Expected error for missing page")
+	}
+}
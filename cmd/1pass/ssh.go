@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/securetmp"
+)
+
+// environment variable which is set when 1pass runs ssh, with the
+// path of a socket from which the server password can be read once.
+// When it is set, 1pass acts as an SSH_ASKPASS helper.
+const askPassSockEnv = "ONEPASS_ASKPASS_SOCK"
+
+// sshTarget is the destination of an ssh connection
+type sshTarget struct {
+	user string
+	host string
+	port string
+}
+
+// parseSshTarget parses the URL field of a server item, which
+// may be a plain host name, 'host:port', 'user@host' or a URL
+// such as 'ssh://user@host:port'
+func parseSshTarget(serverUrl string, username string) (sshTarget, error) {
+	target := sshTarget{user: username}
+	serverUrl = strings.TrimSpace(serverUrl)
+	if serverUrl == "" {
+		return target, fmt.Errorf("Server item has no URL")
+	}
+	if !strings.Contains(serverUrl, "://") {
+		serverUrl = "ssh://" + serverUrl
+	}
+	parsed, err := url.Parse(serverUrl)
+	if err != nil {
+		return target, fmt.Errorf("Unable to parse server URL: %v", err)
+	}
+	if parsed.User != nil && parsed.User.Username() != "" {
+		target.user = parsed.User.Username()
+	}
+	target.host = parsed.Hostname()
+	target.port = parsed.Port()
+	if target.host == "" {
+		return target, fmt.Errorf("Server URL '%s' has no host name", serverUrl)
+	}
+	return target, nil
+}
+
+func (target sshTarget) args() []string {
+	args := []string{}
+	if target.port != "" {
+		args = append(args, "-p", target.port)
+	}
+	if target.user != "" {
+		args = append(args, target.user+"@"+target.host)
+	} else {
+		args = append(args, target.host)
+	}
+	return args
+}
+
+// serveAskPass listens on a socket in a private temporary
+// directory (see securetmp.MkdirTemp()) and serves password to the first client which
+// connects. It returns the socket path and a function which
+// stops the server.
+func serveAskPass(password string) (string, func(), error) {
+	dir, err := securetmp.MkdirTemp("ssh")
+	if err != nil {
+		return "", nil, err
+	}
+	sockPath := filepath.Join(dir, "askpass.sock")
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		io.WriteString(conn, password)
+		conn.Close()
+		listener.Close()
+	}()
+	stop := func() {
+		listener.Close()
+		os.RemoveAll(dir)
+	}
+	return sockPath, stop, nil
+}
+
+// runAskPass implements the SSH_ASKPASS protocol. ssh invokes the
+// helper with a prompt as its argument and reads the response from
+// stdout. Password prompts are answered using the password served
+// by the parent 1pass process. Other prompts, such as host key
+// confirmations, are forwarded to the user's terminal.
+func runAskPass(sockPath string, prompt string) {
+	if strings.Contains(strings.ToLower(prompt), "password") {
+		conn, err := net.Dial("unix", sockPath)
+		if err == nil {
+			password, err := ioutil.ReadAll(conn)
+			conn.Close()
+			if err == nil {
+				fmt.Println(string(password))
+				return
+			}
+		}
+		// the stored password was rejected, fall back to asking
+		// the user
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		os.Exit(1)
+	}
+	defer tty.Close()
+	fmt.Fprint(tty, prompt)
+	response, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		os.Exit(1)
+	}
+	fmt.Println(strings.TrimRight(response, "\r\n"))
+}
+
+// sshToServer connects to the host described by a
+// Unix Server item using ssh, supplying the stored password
+// if the server asks for one
+func sshToServer(vault *onepass.Vault, pattern string, extraArgs []string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find server item")
+	}
+	if item.TypeName != "wallet.computer.UnixServer" {
+		fatalErr(fmt.Errorf("'%s' is not a Unix Server item", item.Title), "")
+	}
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+	target, err := parseSshTarget(content.PrimaryURL(), content.Username())
+	if err != nil {
+		fatalErr(err, "")
+	}
+
+	sshCmd := exec.Command("ssh", append(target.args(), extraArgs...)...)
+	sshCmd.Stdin = os.Stdin
+	sshCmd.Stdout = os.Stdout
+	sshCmd.Stderr = os.Stderr
+
+	// stopAskPass is called explicitly rather than deferred because
+	// the ssh exit status is passed on with os.Exit()
+	stopAskPass := func() {}
+	if password := content.Password(); password != "" {
+		sockPath, stop, err := serveAskPass(password)
+		if err != nil {
+			fatalErr(err, "Unable to pass password to ssh")
+		}
+		stopAskPass = stop
+
+		binPath, err := os.Executable()
+		if err != nil {
+			stopAskPass()
+			fatalErr(err, "Unable to find 1pass binary")
+		}
+		sshCmd.Env = append(os.Environ(),
+			"SSH_ASKPASS="+binPath,
+			"SSH_ASKPASS_REQUIRE=force",
+			askPassSockEnv+"="+sockPath,
+		)
+		if os.Getenv("DISPLAY") == "" {
+			// older versions of ssh only use SSH_ASKPASS
+			// if DISPLAY is set
+			sshCmd.Env = append(sshCmd.Env, "DISPLAY=:0")
+		}
+	}
+
+	err = recordServerConnection(&item, content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to record connection time: %v\n", err)
+	}
+	recordItemUse(vault, item)
+
+	err = sshCmd.Run()
+	stopAskPass()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	} else if err != nil {
+		fatalErr(err, "Unable to run ssh")
+	}
+}
+
+// recordServerConnection sets the 'last connected' field
+// of a server item to the current time
+func recordServerConnection(item *onepass.Item, content onepass.ItemContent) error {
+	now := time.Now().Unix()
+	found := false
+	for i, section := range content.Sections {
+		for j, field := range section.Fields {
+			if field.Name == "last_connected" {
+				content.Sections[i].Fields[j].Value = now
+				found = true
+			}
+		}
+	}
+	if !found {
+		content.AddField("", onepass.ItemField{
+			Kind:  "date",
+			Name:  "last_connected",
+			Title: "last connected",
+			Value: now,
+		})
+	}
+	err := item.SetContent(content)
+	if err != nil {
+		return err
+	}
+	return item.Save()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSshTarget(t *testing.T) {
+	cases := []struct {
+		url      string
+		username string
+		args     []string
+	}{
+		{"example.com", "bob", []string{"bob@example.com"}},
+		{"example.com:2222", "", []string{"-p", "2222", "example.com"}},
+		{"alice@example.com", "bob", []string{"alice@example.com"}},
+		{"ssh://bob@[::1]:22", "", []string{"-p", "22", "bob@::1"}},
+	}
+	for _, c := range cases {
+		target, err := parseSshTarget(c.url, c.username)
+		if err != nil {
+			t.Errorf("Unable to parse '%s': %v", c.url, err)
+			continue
+		}
+		if !reflect.DeepEqual(target.args(), c.args) {
+			t.Errorf("Unexpected args for '%s'. Actual: %v, Expected: %v", c.url, target.args(), c.args)
+		}
+	}
+
+	_, err := parseSshTarget("", "bob")
+	if err == nil {
+		t.Errorf("Expected error for empty server URL")
+	}
+}
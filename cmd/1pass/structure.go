@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/rangeutil"
+	"gopkg.in/yaml.v2"
+)
+
+const folderTypeName = "system.folder.Regular"
+
+// vaultStructure describes how the items in a vault are organized,
+// without any of their secret contents. It is written by
+// 'dump-structure' and applied to a vault by 'apply-structure'.
+//
+// Folders are identified by their path, eg. 'Work/Cloud'.
+type vaultStructure struct {
+	Folders []string        `yaml:"folders"`
+	Items   []structureItem `yaml:"items"`
+}
+
+type structureItem struct {
+	Uuid   string   `yaml:"uuid"`
+	Title  string   `yaml:"title"`
+	Type   string   `yaml:"type"`
+	Folder string   `yaml:"folder,omitempty"`
+	Tags   []string `yaml:"tags,omitempty"`
+}
+
+func dumpStructureHelp() string {
+	return `Writes the vault's folders and the folder and tags of each item to a YAML
+file. Passwords and other item contents are not included, so the file
+can be kept under version control.
+
+Use 'apply-structure' to reapply the layout to this or another vault.`
+}
+
+func applyStructureHelp() string {
+	return `Creates the folders listed in a file written by 'dump-structure' and moves
+items into their folders and sets their tags to match the file.
+
+Items are matched by UUID or, if no item has the same UUID, by title and
+type where there is exactly one such item. Items which are not listed in
+the file are left unchanged.`
+}
+
+// folderPaths returns a map of folder UUID -> path for
+// the folder items in a vault
+func folderPaths(items []onepass.Item) map[string]string {
+	folders := map[string]onepass.Item{}
+	for _, item := range items {
+		if item.TypeName == folderTypeName && !item.Trashed {
+			folders[item.Uuid] = item
+		}
+	}
+
+	paths := map[string]string{}
+	for uuid, folder := range folders {
+		path := folder.Title
+		visited := map[string]bool{uuid: true}
+		for parent, ok := folders[folder.FolderUuid]; ok && !visited[parent.Uuid]; parent, ok = folders[parent.FolderUuid] {
+			visited[parent.Uuid] = true
+			path = parent.Title + "/" + path
+		}
+		paths[uuid] = path
+	}
+	return paths
+}
+
+func readVaultStructure(vault *onepass.Vault) vaultStructure {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	paths := folderPaths(items)
+	private := readConfig().PrivateTags
+
+	structure := vaultStructure{Folders: []string{}, Items: []structureItem{}}
+	for _, path := range paths {
+		structure.Folders = append(structure.Folders, path)
+	}
+	sort.Strings(structure.Folders)
+
+	for _, item := range items {
+		if item.Trashed || item.TypeName == folderTypeName || item.TypeName == "system.Tombstone" {
+			continue
+		}
+		tags := itemTags(&item, private)
+		sort.Strings(tags)
+		structure.Items = append(structure.Items, structureItem{
+			Uuid:   item.Uuid,
+			Title:  item.Title,
+			Type:   item.TypeName,
+			Folder: paths[item.FolderUuid],
+			Tags:   tags,
+		})
+	}
+	rangeutil.Sort(0, len(structure.Items), func(i, j int) bool {
+		return strings.ToLower(structure.Items[i].Title) < strings.ToLower(structure.Items[j].Title)
+	}, func(i, j int) {
+		structure.Items[i], structure.Items[j] = structure.Items[j], structure.Items[i]
+	})
+	return structure
+}
+
+func dumpStructure(vault *onepass.Vault, path string) {
+	data, err := yaml.Marshal(readVaultStructure(vault))
+	if err != nil {
+		fatalErr(err, "Unable to serialize vault structure")
+	}
+	if path == "" || path == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	err = ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		fatalErr(err, "Unable to write vault structure")
+	}
+}
+
+// folderCreator looks up folders in a vault by path,
+// creating them if they do not exist
+type folderCreator struct {
+	vault   *onepass.Vault
+	uuids   map[string]string
+	created int
+}
+
+func newFolderCreator(vault *onepass.Vault, items []onepass.Item) *folderCreator {
+	creator := &folderCreator{vault: vault, uuids: map[string]string{}}
+	for uuid, path := range folderPaths(items) {
+		creator.uuids[path] = uuid
+	}
+	return creator
+}
+
+// folderUuid returns the UUID of the folder at path, creating it
+// and any missing parent folders if necessary
+func (creator *folderCreator) folderUuid(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", nil
+	}
+	if uuid, ok := creator.uuids[path]; ok {
+		return uuid, nil
+	}
+
+	parentUuid := ""
+	title := path
+	if sep := strings.LastIndex(path, "/"); sep != -1 {
+		var err error
+		parentUuid, err = creator.folderUuid(path[:sep])
+		if err != nil {
+			return "", err
+		}
+		title = path[sep+1:]
+	}
+	folder, err := creator.vault.AddItem(title, folderTypeName, onepass.ItemContent{})
+	if err != nil {
+		return "", err
+	}
+	if parentUuid != "" {
+		folder.FolderUuid = parentUuid
+		err = folder.Save()
+		if err != nil {
+			return "", err
+		}
+	}
+	fmt.Printf("Created folder '%s'\n", path)
+	creator.uuids[path] = folder.Uuid
+	creator.created++
+	return folder.Uuid, nil
+}
+
+// matchStructureItem returns the index of the item in items which
+// corresponds to an entry in a vault structure file, or -1
+func matchStructureItem(items []onepass.Item, entry structureItem) int {
+	match := -1
+	titleMatches := 0
+	for i, item := range items {
+		if item.Uuid == entry.Uuid {
+			return i
+		}
+		if item.Title == entry.Title && item.TypeName == entry.Type && !item.Trashed {
+			match = i
+			titleMatches++
+		}
+	}
+	if titleMatches != 1 {
+		return -1
+	}
+	return match
+}
+
+func sameTags(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func applyStructure(vault *onepass.Vault, path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalErr(err, "Unable to read vault structure")
+	}
+	var structure vaultStructure
+	err = yaml.Unmarshal(data, &structure)
+	if err != nil {
+		fatalErr(err, "Unable to parse vault structure")
+	}
+
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	folders := newFolderCreator(vault, items)
+	for _, folderPath := range structure.Folders {
+		_, err = folders.folderUuid(folderPath)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to create folder '%s'", folderPath))
+		}
+	}
+
+	private := readConfig().PrivateTags
+	updated := 0
+	missing := 0
+	vault.BeginBatch()
+	for _, entry := range structure.Items {
+		index := matchStructureItem(items, entry)
+		if index == -1 {
+			fmt.Fprintf(os.Stderr, "No item found for '%s' (%s)\n", entry.Title, entry.Uuid)
+			missing++
+			continue
+		}
+		item := items[index]
+		folderUuid, err := folders.folderUuid(entry.Folder)
+		if err != nil {
+			fatalErr(err, fmt.Sprintf("Unable to create folder '%s'", entry.Folder))
+		}
+		tags := itemTags(&item, private)
+		if item.FolderUuid == folderUuid && sameTags(tags, entry.Tags) {
+			continue
+		}
+		logItemAction("Updating item", item)
+		item.FolderUuid = folderUuid
+		setItemTags(&item, entry.Tags, private)
+		updated++
+	}
+	endBatch(vault)
+	fmt.Printf("Created %d folders and updated %d items", folders.created, updated)
+	if missing > 0 {
+		fmt.Printf(". %d items were not found", missing)
+	}
+	fmt.Printf("\n")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestFolderPaths(t *testing.T) {
+	items := []onepass.Item{
+		{Uuid: "A", Title: "Work", TypeName: folderTypeName},
+		{Uuid: "B", Title: "Cloud", TypeName: folderTypeName, FolderUuid: "A"},
+		{Uuid: "C", Title: "AWS", TypeName: "webforms.WebForm", FolderUuid: "B"},
+		// folders which contain each other should not loop forever
+		{Uuid: "D", Title: "Loop1", TypeName: folderTypeName, FolderUuid: "E"},
+		{Uuid: "E", Title: "Loop2", TypeName: folderTypeName, FolderUuid: "D"},
+	}
+	paths := folderPaths(items)
+	expected := map[string]string{
+		"A": "Work",
+		"B": "Work/Cloud",
+		"D": "Loop2/Loop1",
+		"E": "Loop1/Loop2",
+	}
+	if len(paths) != len(expected) {
+		t.Errorf("Expected %d folders, got %d", len(expected), len(paths))
+	}
+	for uuid, path := range expected {
+		if paths[uuid] != path {
+			t.Errorf("Expected path '%s' for %s, got '%s'", path, uuid, paths[uuid])
+		}
+	}
+}
+
+func TestMatchStructureItem(t *testing.T) {
+	items := []onepass.Item{
+		{Uuid: "A", Title: "GitHub", TypeName: "webforms.WebForm"},
+		{Uuid: "B", Title: "Bank", TypeName: "webforms.WebForm"},
+		{Uuid: "C", Title: "Bank", TypeName: "webforms.WebForm"},
+		{Uuid: "D", Title: "Bank", TypeName: "securenotes.SecureNote"},
+	}
+	cases := []struct {
+		entry structureItem
+		index int
+	}{
+		{structureItem{Uuid: "C", Title: "Renamed"}, 2},
+		{structureItem{Uuid: "X", Title: "GitHub", Type: "webforms.WebForm"}, 0},
+		{structureItem{Uuid: "X", Title: "Bank", Type: "securenotes.SecureNote"}, 3},
+		// ambiguous title
+		{structureItem{Uuid: "X", Title: "Bank", Type: "webforms.WebForm"}, -1},
+		{structureItem{Uuid: "X", Title: "GitHub", Type: "passwords.Password"}, -1},
+	}
+	for _, testCase := range cases {
+		index := matchStructureItem(items, testCase.entry)
+		if index != testCase.index {
+			t.Errorf("Expected match %d for %v, got %d", testCase.index, testCase.entry, index)
+		}
+	}
+}
+
+func TestSameTags(t *testing.T) {
+	if !sameTags([]string{"a", "b"}, []string{"b", "a"}) {
+		t.Errorf("Tags in different order should match")
+	}
+	if sameTags([]string{"a"}, []string{"a", "b"}) || sameTags([]string{"a", "c"}, []string{"a", "b"}) {
+		t.Errorf("Different tags should not match")
+	}
+	if !sameTags(nil, []string{}) {
+		t.Errorf("Empty tag lists should match")
+	}
+}
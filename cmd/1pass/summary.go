@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func setSummaryHelp() string {
+	return `Pins a field of the matching items so that its value is shown by
+'list --details', eg. the account number of bank accounts or the expiry
+date of software licenses. For concealed fields, such as account numbers
+and passwords, only the last four characters are shown.
+
+The name of the field is stored unencrypted with the item, but its value
+is only decrypted when listing items. Use 'none' to remove the field.`
+}
+
+// setItemSummaryField sets the field shown by 'list --details'
+// for the items matching pattern
+func setItemSummaryField(vault *onepass.Vault, pattern string, fieldName string) {
+	if fieldName == "none" {
+		fieldName = ""
+	}
+	items, err := lookupItems(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Unable to lookup items")
+	}
+	if len(items) == 0 {
+		fatalErr(fmt.Errorf("No matching items"), "")
+	}
+	for _, item := range items {
+		if fieldName != "" {
+			content, err := item.Content()
+			if err != nil {
+				fatalErr(err, fmt.Sprintf("Unable to decrypt item '%s'", item.Title))
+			}
+			if content.SummaryValue(fieldName) == "" {
+				fmt.Fprintf(os.Stderr, "Item '%s' has no field matching '%s'\n", item.Title, fieldName)
+			}
+		}
+		logItemAction("Setting summary field of item", item)
+		item.OpenContents.SummaryField = fieldName
+		err = item.Save()
+		if err != nil {
+			fatalErr(err, "Unable to save item")
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+func themeHelp() string {
+	return `The output of 'list', 'show', 'tree' and 'audit' is styled using the
+'Theme' setting in ~/.1pass, eg.
+
+  "Theme": {"Preset": "high-contrast", "DateFormat": "iso"}
+
+'Preset' is one of 'plain' (the default), 'color', 'monochrome' or
+'high-contrast'. The other settings override the preset's styles:
+
+  Label          - field labels and headings in 'show'
+  Title          - item titles
+  Folder         - folders in 'tree'
+  Detail         - the type and ID shown after an item's title
+  Trash          - the marker for items in the trash
+  Warning        - passwords reported by 'audit'
+  TrashIndicator - the text of the marker for items in the trash
+  DateFormat     - 'short', 'iso', 'us' or a Go time layout such as
+                   '2006-01-02 15:04'
+
+Styles are space-separated lists of 'bold', 'dim', 'italic',
+'underline', 'reverse' and a color: 'black', 'red', 'green', 'yellow',
+'blue', 'magenta', 'cyan' or 'white', optionally with a 'bright-'
+prefix. Styles are only used when the output is a terminal and
+$NO_COLOR is not set.`
+}
+
+// outputTheme holds the styles used for the output of 'list',
+// 'show', 'tree' and 'audit', from the 'Theme' setting. See
+// themeHelp()
+type outputTheme struct {
+	Preset string `json:",omitempty"`
+
+	Label   string `json:",omitempty"`
+	Title   string `json:",omitempty"`
+	Folder  string `json:",omitempty"`
+	Detail  string `json:",omitempty"`
+	Trash   string `json:",omitempty"`
+	Warning string `json:",omitempty"`
+
+	TrashIndicator string `json:",omitempty"`
+	DateFormat     string `json:",omitempty"`
+
+	// if set, styles are written as ANSI escape sequences
+	color bool
+}
+
+// themePresets are the themes which can be selected with
+// the 'Preset' key of the 'Theme' setting
+var themePresets = map[string]outputTheme{
+	"plain": {
+		TrashIndicator: " (in trash)",
+		DateFormat:     "short",
+	},
+	"color": {
+		Label:          "bold",
+		Folder:         "bold blue",
+		Detail:         "dim",
+		Trash:          "red",
+		Warning:        "yellow",
+		TrashIndicator: " (in trash)",
+		DateFormat:     "short",
+	},
+	"monochrome": {
+		Label:          "bold",
+		Folder:         "bold",
+		Detail:         "dim",
+		Trash:          "reverse",
+		Warning:        "bold",
+		TrashIndicator: " (in trash)",
+		DateFormat:     "short",
+	},
+	"high-contrast": {
+		Label:          "bold bright-white",
+		Title:          "bold",
+		Folder:         "bold bright-cyan",
+		Trash:          "bold bright-red",
+		Warning:        "bold bright-yellow",
+		TrashIndicator: " [TRASH]",
+		DateFormat:     "iso",
+	},
+}
+
+// named values for the 'DateFormat' key of the 'Theme' setting
+var themeDateFormats = map[string]string{
+	"short": "15:04 02/01/06",
+	"iso":   "2006-01-02 15:04",
+	"us":    "01/02/06 15:04",
+}
+
+var ansiStyleCodes = map[string]int{
+	"bold":      1,
+	"dim":       2,
+	"italic":    3,
+	"underline": 4,
+	"reverse":   7,
+}
+
+var ansiColorCodes = map[string]int{
+	"black":   30,
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+}
+
+// stdoutSupportsColor returns true if stdout is a
+// terminal and $NO_COLOR is not set
+func stdoutSupportsColor() bool {
+	return terminal.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+}
+
+// parseThemeStyle returns the ANSI escape sequence for
+// a style such as 'bold cyan'
+func parseThemeStyle(style string) (string, error) {
+	codes := []string{}
+	for _, word := range strings.Fields(strings.ToLower(style)) {
+		if code, ok := ansiStyleCodes[word]; ok {
+			codes = append(codes, fmt.Sprint(code))
+		} else if code, ok := ansiColorCodes[strings.TrimPrefix(word, "bright-")]; ok {
+			if strings.HasPrefix(word, "bright-") {
+				code += 60
+			}
+			codes = append(codes, fmt.Sprint(code))
+		} else {
+			return "", fmt.Errorf("Unknown style '%s'", word)
+		}
+	}
+	if len(codes) == 0 {
+		return "", nil
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m", nil
+}
+
+// newOutputTheme returns the theme from the 'Theme' setting,
+// with unset keys taken from its preset
+func newOutputTheme(config outputTheme) (outputTheme, error) {
+	presetName := config.Preset
+	if presetName == "" {
+		presetName = "plain"
+	}
+	theme, ok := themePresets[presetName]
+	if !ok {
+		return outputTheme{}, fmt.Errorf("Unknown theme preset '%s'", presetName)
+	}
+	theme.Preset = presetName
+	overrides := []struct {
+		value  string
+		target *string
+	}{
+		{config.Label, &theme.Label},
+		{config.Title, &theme.Title},
+		{config.Folder, &theme.Folder},
+		{config.Detail, &theme.Detail},
+		{config.Trash, &theme.Trash},
+		{config.Warning, &theme.Warning},
+		{config.TrashIndicator, &theme.TrashIndicator},
+		{config.DateFormat, &theme.DateFormat},
+	}
+	for _, override := range overrides {
+		if override.value != "" {
+			*override.target = override.value
+		}
+	}
+	for _, style := range []string{theme.Label, theme.Title, theme.Folder, theme.Detail, theme.Trash, theme.Warning} {
+		if _, err := parseThemeStyle(style); err != nil {
+			return outputTheme{}, err
+		}
+	}
+	return theme, nil
+}
+
+// currentTheme returns the theme from the 'Theme' setting,
+// or the 'plain' theme if the setting is invalid
+func currentTheme() outputTheme {
+	theme, err := newOutputTheme(readConfig().Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring 'Theme' setting: %v\n", err)
+		theme, _ = newOutputTheme(outputTheme{})
+	}
+	theme.color = stdoutSupportsColor()
+	return theme
+}
+
+// apply returns text formatted with style, which
+// is one of the theme's styles
+func (theme outputTheme) apply(style string, text string) string {
+	if !theme.color || text == "" {
+		return text
+	}
+	codes, _ := parseThemeStyle(style)
+	if codes == "" {
+		return text
+	}
+	return codes + text + ansiReset
+}
+
+func (theme outputTheme) label(text string) string  { return theme.apply(theme.Label, text) }
+func (theme outputTheme) title(text string) string  { return theme.apply(theme.Title, text) }
+func (theme outputTheme) folder(text string) string { return theme.apply(theme.Folder, text) }
+func (theme outputTheme) detail(text string) string { return theme.apply(theme.Detail, text) }
+
+func (theme outputTheme) warning(text string) string {
+	return theme.apply(theme.Warning, text)
+}
+
+// trashState returns the marker shown after the
+// title of item if it is in the trash
+func (theme outputTheme) trashState(item onepass.Item) string {
+	if !item.Trashed {
+		return ""
+	}
+	return theme.apply(theme.Trash, theme.TrashIndicator)
+}
+
+// itemSummary returns the title, type and short ID of item
+func (theme outputTheme) itemSummary(item onepass.Item) string {
+	return fmt.Sprintf("%s %s", theme.title(item.Title),
+		theme.detail(fmt.Sprintf("(%s, %s)", item.Type(), item.Uuid[0:4])))
+}
+
+func (theme outputTheme) formatDate(t time.Time) string {
+	if layout, ok := themeDateFormats[theme.DateFormat]; ok {
+		return t.Format(layout)
+	}
+	return t.Format(theme.DateFormat)
+}
+
+// styleLines applies style to each line of text after the first,
+// for use with output aligned by a tabwriter, which would be
+// misaligned by escape sequences in its cells. The first line is
+// treated as a heading and styled as a label.
+func (theme outputTheme) styleLines(text []byte, style string) string {
+	lines := bytes.SplitAfter(text, []byte("\n"))
+	var out strings.Builder
+	for i, line := range lines {
+		content := strings.TrimSuffix(string(line), "\n")
+		if i == 0 {
+			out.WriteString(theme.label(content))
+		} else {
+			out.WriteString(theme.apply(style, content))
+		}
+		if len(content) < len(line) {
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+// themedContent returns a copy of content with its field
+// labels styled for display by 'show'
+func themedContent(content onepass.ItemContent, theme outputTheme) onepass.ItemContent {
+	sections := []onepass.ItemSection{}
+	for _, section := range content.Sections {
+		fields := []onepass.ItemField{}
+		for _, field := range section.Fields {
+			field.Title = theme.label(field.Title)
+			fields = append(fields, field)
+		}
+		section.Title = theme.label(section.Title)
+		section.Fields = fields
+		sections = append(sections, section)
+	}
+	content.Sections = sections
+
+	urls := []onepass.ItemUrl{}
+	for _, url := range content.Urls {
+		url.Label = theme.label(url.Label)
+		urls = append(urls, url)
+	}
+	content.Urls = urls
+
+	formFields := []onepass.WebFormField{}
+	for _, field := range content.FormFields {
+		field.Name = theme.label(field.Name)
+		formFields = append(formFields, field)
+	}
+	content.FormFields = formFields
+	return content
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestParseThemeStyle(t *testing.T) {
+	tests := []struct {
+		style string
+		codes string
+		ok    bool
+	}{
+		{"", "", true},
+		{"bold", "\x1b[1m", true},
+		{"bold cyan", "\x1b[1;36m", true},
+		{"Bright-Red underline", "\x1b[91;4m", true},
+		{"sparkly", "", false},
+	}
+	for _, test := range tests {
+		codes, err := parseThemeStyle(test.style)
+		if codes != test.codes || (err == nil) != test.ok {
+			t.Errorf("Unexpected result for '%s': %q, %v", test.style, codes, err)
+		}
+	}
+}
+
+func TestOutputTheme(t *testing.T) {
+	theme, err := newOutputTheme(outputTheme{Preset: "high-contrast", Trash: "red", DateFormat: "2006"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Label != "bold bright-white" || theme.Trash != "red" || theme.TrashIndicator != " [TRASH]" {
+		t.Errorf("Settings not merged with preset: %+v", theme)
+	}
+	if date := theme.formatDate(time.Date(2015, 3, 1, 10, 0, 0, 0, time.UTC)); date != "2015" {
+		t.Errorf("Unexpected date '%s'", date)
+	}
+	if _, err := newOutputTheme(outputTheme{Preset: "neon"}); err == nil {
+		t.Errorf("Expected unknown preset to be rejected")
+	}
+	if _, err := newOutputTheme(outputTheme{Label: "blinking"}); err == nil {
+		t.Errorf("Expected unknown style to be rejected")
+	}
+
+	item := onepass.Item{Title: "GitHub", TypeName: "webforms.WebForm", Uuid: "ABCDEF", Trashed: true}
+	plain, _ := newOutputTheme(outputTheme{})
+	if summary := plain.itemSummary(item) + plain.trashState(item); summary != "GitHub (Login, ABCD) (in trash)" {
+		t.Errorf("Unexpected plain summary '%s'", summary)
+	}
+	theme.color = true
+	if trash := theme.trashState(item); trash != "\x1b[31m [TRASH]\x1b[0m" {
+		t.Errorf("Unexpected trash marker %q", trash)
+	}
+	table := theme.styleLines([]byte("A  B\n1  2\n"), "bold")
+	if table != "\x1b[1;97mA  B\x1b[0m\n\x1b[1m1  2\x1b[0m\n" {
+		t.Errorf("Unexpected styled table %q", table)
+	}
+}
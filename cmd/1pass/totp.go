@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func totpHelp() string {
+	return `Displays the current one-time password for an item with a one-time
+password field, and the number of seconds until it expires. The field
+holds either an 'otpauth://totp/' URI, as encoded in the QR codes which
+sites show when setting up two-factor authentication, or a bare base32
+secret.
+
+To add a one-time password field to an item, use 'edit' and add a new
+field titled 'one-time password'. 'copy <pattern> otp' copies the
+current one-time password.`
+}
+
+// itemOTPCode returns the current one-time password for an item
+// and the time until it expires
+func itemOTPCode(item onepass.Item, content onepass.ItemContent) (string, time.Duration) {
+	code, remaining, err := content.OTPCode(time.Now())
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Unable to generate a one-time password for '%s'", item.Title))
+	}
+	return code, remaining
+}
+
+// showOTPCode displays the current one-time password for an item
+func showOTPCode(vault *onepass.Vault, pattern string) {
+	item, err := lookupSingleItem(vault, pattern)
+	if err != nil {
+		fatalErr(err, "Failed to find item")
+	}
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, fmt.Sprintf("Failed to decrypt item '%s'", item.Title))
+	}
+	code, remaining := itemOTPCode(item, content)
+	fmt.Printf("%s (%ds remaining)\n", code, int(remaining.Seconds()))
+	recordItemUse(vault, item)
+}
+
+// isOTPFieldTitle returns true if a new field with the given
+// title should be created as a one-time password field
+func isOTPFieldTitle(title string) bool {
+	switch strings.ToLower(strings.TrimSpace(title)) {
+	case "one-time password", "otp", "totp":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// folderTree holds the folders and items in a vault,
+// grouped by the folder which contains them
+type folderTree struct {
+	// map of folder UUID -> folders and items in the folder,
+	// with "" for items which are not in a folder
+	children map[string][]onepass.Item
+}
+
+func newFolderTree(items []onepass.Item) folderTree {
+	folders := map[string]bool{}
+	for _, item := range items {
+		if item.TypeName == folderTypeName && !item.Trashed {
+			folders[item.Uuid] = true
+		}
+	}
+	tree := folderTree{children: map[string][]onepass.Item{}}
+	for _, item := range items {
+		if item.Trashed {
+			continue
+		}
+		// items in missing or trashed folders are
+		// shown at the top level
+		parent := item.FolderUuid
+		if !folders[parent] {
+			parent = ""
+		}
+		tree.children[parent] = append(tree.children[parent], item)
+	}
+	for parent, children := range tree.children {
+		sortItemsByTitle(children)
+		tree.children[parent] = append(tree.filter(children, true), tree.filter(children, false)...)
+	}
+	return tree
+}
+
+// filter returns the folders in items if folders is
+// true or the other items otherwise
+func (tree folderTree) filter(items []onepass.Item, folders bool) []onepass.Item {
+	result := []onepass.Item{}
+	for _, item := range items {
+		if (item.TypeName == folderTypeName) == folders {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// print writes the contents of a folder, indented by depth,
+// followed by the contents of each subfolder
+func (tree folderTree) print(theme outputTheme, folderUuid string, depth int, foldersOnly bool, visited map[string]bool) {
+	indent := strings.Repeat("  ", depth)
+	for _, item := range tree.children[folderUuid] {
+		if item.TypeName != folderTypeName {
+			if !foldersOnly {
+				fmt.Printf("%s%s\n", indent, theme.itemSummary(item))
+			}
+			continue
+		}
+		fmt.Printf("%s%s\n", indent, theme.folder(item.Title+"/"))
+		// guard against folders which contain themselves
+		if !visited[item.Uuid] {
+			visited[item.Uuid] = true
+			tree.print(theme, item.Uuid, depth+1, foldersOnly, visited)
+		}
+	}
+}
+
+// printFolderTree lists the folders in the vault hierarchically with
+// the items that they contain. If folderPattern is set, only the
+// matching folder is listed.
+func printFolderTree(vault *onepass.Vault, folderPattern string, foldersOnly bool) {
+	items, err := vault.ListItems()
+	if err != nil {
+		fatalErr(err, "Unable to list vault items")
+	}
+	tree := newFolderTree(items)
+	theme := currentTheme()
+	if folderPattern == "" {
+		tree.print(theme, "", 0, foldersOnly, map[string]bool{})
+		return
+	}
+	folder, err := lookupSingleItem(vault, "folder:"+folderPattern)
+	if err != nil {
+		fatalErr(err, "Failed to find folder")
+	}
+	fmt.Printf("%s\n", theme.folder(folder.Title+"/"))
+	tree.print(theme, folder.Uuid, 1, foldersOnly, map[string]bool{folder.Uuid: true})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestFolderTree(t *testing.T) {
+	items := []onepass.Item{
+		{Uuid: "F1", Title: "Work", TypeName: folderTypeName},
+		{Uuid: "F2", Title: "Cloud", TypeName: folderTypeName, FolderUuid: "F1"},
+		{Uuid: "I1", Title: "github", TypeName: "webforms.WebForm", FolderUuid: "F1"},
+		{Uuid: "I2", Title: "AWS", TypeName: "webforms.WebForm", FolderUuid: "F2"},
+		{Uuid: "I3", Title: "Bank", TypeName: "webforms.WebForm"},
+		{Uuid: "I4", Title: "Orphan", TypeName: "webforms.WebForm", FolderUuid: "MISSING"},
+		{Uuid: "I5", Title: "Old", TypeName: "webforms.WebForm", FolderUuid: "F1", Trashed: true},
+	}
+	tree := newFolderTree(items)
+	titles := func(parent string) []string {
+		result := []string{}
+		for _, item := range tree.children[parent] {
+			result = append(result, item.Title)
+		}
+		return result
+	}
+	expected := map[string][]string{
+		"":   {"Work", "Bank", "Orphan"},
+		"F1": {"Cloud", "github"},
+		"F2": {"AWS"},
+	}
+	for parent, want := range expected {
+		got := titles(parent)
+		if len(got) != len(want) {
+			t.Errorf("Expected %v in '%s', got %v", want, parent, got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Expected %v in '%s', got %v", want, parent, got)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+func lockHelp() string {
+	return `The agent locks a vault automatically if it has not been used for 2
+minutes. A different period can be set for each vault with the
+'UnlockDurations' setting in ~/.1pass, which maps vault paths or names
+(see 'set-vault') to durations, eg. {"work": "8h", "/path/to/Banking.agilekeychain": "5m"}.
+A path or name of '*' sets the period for other vaults.
+
+Commands which reveal or export secrets, such as 'export' and 'show --raw',
+shorten the time remaining before the vault is locked to the
+'SensitiveUnlockDuration' setting, eg. "30s", if it is set. The agent
+never extends the time remaining for these commands, so a vault with a
+shorter period is not affected.`
+}
+
+// sensitiveModes are the commands which write secrets to
+// files or the terminal in bulk or in plain form
+var sensitiveModes = map[string]bool{
+	"export":           true,
+	"export-bitwarden": true,
+	"export-pass":      true,
+	"mirror":           true,
+	"show-json":        true,
+}
+
+// isSensitiveCommand returns true if running 'mode' with the given
+// flags reveals or exports secrets. See 'SensitiveUnlockDuration'
+func isSensitiveCommand(mode string, raw bool) bool {
+	return sensitiveModes[mode] || (mode == "show" && raw)
+}
+
+// unlockDuration returns the period after which the agent locks the
+// vault at vaultPath if it is not used, from the 'UnlockDurations'
+// setting
+func unlockDuration(config clientConfig, vaultPath string) (time.Duration, error) {
+	for _, key := range vaultSettingKeys(config, vaultPath) {
+		if value, ok := config.UnlockDurations[key]; ok {
+			duration, err := parseUnlockDuration(value)
+			if err != nil {
+				return 0, fmt.Errorf("Invalid unlock duration for '%s': %v", key, err)
+			}
+			return duration, nil
+		}
+	}
+	return client.DefaultUnlockDelay, nil
+}
+
+// sensitiveUnlockDuration returns the maximum time for which the
+// vault remains unlocked after a sensitive command, or zero if the
+// 'SensitiveUnlockDuration' setting is not set
+func sensitiveUnlockDuration(config clientConfig) (time.Duration, error) {
+	if config.SensitiveUnlockDuration == "" {
+		return 0, nil
+	}
+	duration, err := parseUnlockDuration(config.SensitiveUnlockDuration)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid sensitive unlock duration: %v", err)
+	}
+	return duration, nil
+}
+
+func parseUnlockDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("'%s' is not a positive duration", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+func TestUnlockDuration(t *testing.T) {
+	config := clientConfig{
+		Vaults: map[string]string{"banking": "/vaults/Banking.agilekeychain"},
+		UnlockDurations: map[string]string{
+			"banking":                    "5m",
+			"/vaults/Work.agilekeychain": "8h",
+			"/vaults/Bad.agilekeychain":  "soon",
+		},
+	}
+	tests := []struct {
+		path     string
+		duration time.Duration
+	}{
+		{"/vaults/Banking.agilekeychain", 5 * time.Minute},
+		{"/vaults/Work.agilekeychain", 8 * time.Hour},
+		{"/vaults/Other.agilekeychain", client.DefaultUnlockDelay},
+	}
+	for _, test := range tests {
+		duration, err := unlockDuration(config, test.path)
+		if err != nil || duration != test.duration {
+			t.Errorf("Unexpected unlock duration for %s: %v, %v", test.path, duration, err)
+		}
+	}
+	if _, err := unlockDuration(config, "/vaults/Bad.agilekeychain"); err == nil {
+		t.Errorf("Expected invalid unlock duration to be rejected")
+	}
+
+	config.UnlockDurations["*"] = "1h"
+	if duration, _ := unlockDuration(config, "/vaults/Other.agilekeychain"); duration != time.Hour {
+		t.Errorf("Unexpected default unlock duration: %v", duration)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func checkVaultHelp() string {
+	return `Checks that the vault's index (contents.js) matches the item files
+in the vault and that each item's encrypted data is valid and can be
+decrypted.
+
+Problems with the index, such as item files which are not listed in it
+or entries for items whose files have been removed, can be fixed with
+--repair, which rebuilds the index from the item files. The previous
+index is saved to contents.js.bak. Other problems, such as corrupted
+item files, are reported but not repaired.
+
+If any problems remain, the exit status is 1.`
+}
+
+func rebuildIndexHelp() string {
+	return `Regenerates the list of items in the vault's contents.js file from the
+item files in the vault, for example if contents.js has been lost or
+corrupted after a sync conflict. Items which are already listed keep
+their order. With '-no-index', the existing contents.js is ignored and
+the list is regenerated from scratch. The previous contents.js, if any,
+is saved to contents.js.bak.
+
+Listing, searching and showing items never reads contents.js, since items
+are always listed from their item files, so these commands do not need
+'-no-index'. A damaged contents.js only prevents items from being saved
+or removed. Use '-no-index' with commands which add, change or remove
+items to make the changes without updating contents.js. A warning is
+printed if any items were changed, after which 'rebuild-index' brings
+contents.js up to date.
+
+The vault does not need to be unlocked. Use 'check' to find other
+problems with the vault.`
+}
+
+func printVaultProblems(problems []onepass.VaultProblem) {
+	for _, problem := range problems {
+		uuid := problem.Uuid
+		if uuid == "" {
+			uuid = "contents.js"
+		}
+		fmt.Printf("  %s: %s\n", uuid, problem.Description)
+	}
+}
+
+func checkVault(vault *onepass.Vault, repair bool) {
+	problems, err := vault.Verify()
+	if err != nil {
+		fatalErr(err, "Unable to check vault")
+	}
+	if len(problems) == 0 {
+		fmt.Printf("No problems found\n")
+		return
+	}
+	fmt.Printf("%d problems found:\n", len(problems))
+	printVaultProblems(problems)
+
+	repairable := 0
+	for _, problem := range problems {
+		if problem.Repairable {
+			repairable++
+		}
+	}
+	if repairable > 0 && !repair {
+		fmt.Printf("\n%d problems can be fixed by running 'check --repair'\n", repairable)
+	}
+	if repairable > 0 && repair {
+		err = vault.RebuildIndex()
+		if err != nil {
+			fatalErr(err, "Unable to repair vault index")
+		}
+		fmt.Printf("\nRebuilt the vault's index. The previous index was saved to contents.js.bak\n")
+		problems, err = vault.Verify()
+		if err != nil {
+			fatalErr(err, "Unable to check vault")
+		}
+		if len(problems) > 0 {
+			fmt.Printf("%d problems remain:\n", len(problems))
+			printVaultProblems(problems)
+		}
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+func resolveConflictHelp() string {
+	return `When an item is changed in two copies of a vault before they are
+synced, Dropbox and similar services keep both versions, saving one as
+a 'conflicted copy' of the item's file. 1pass and other 1Password apps
+only use the original file, so changes in the conflicted copy are not
+visible until the conflict is resolved.
+
+<conflict> is either the number of a conflicted copy as listed by
+'conflicts' or a pattern matching the item's title or ID. The versions
+which can be kept are:
+
+  current  Keep the current version and remove the conflicted copy
+  copy     Replace the current version with the conflicted copy
+  both     Keep the current version and add the conflicted copy as a
+           new item
+  merge    Compare the versions field by field and choose which
+           version of each changed field to keep
+
+When only one version is kept, passwords from the other version are
+added to its password history. See 'show --history'. If no version is
+given, both versions are shown and you are asked which to keep.`
+}
+
+// warnAboutConflictedCopies reports conflicted copies of
+// items created by file syncing services
+func warnAboutConflictedCopies(vault *onepass.Vault) {
+	conflicts, err := vault.ConflictedCopies()
+	if err != nil || len(conflicts) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Found %d conflicted copies of items, which may contain changes. Use 'conflicts' to list them.\n",
+		len(conflicts))
+}
+
+func formatUpdateTime(item onepass.Item) string {
+	return time.Unix(int64(item.UpdatedAt), 0).Format("15:04 02/01/06")
+}
+
+func listConflicts(vault *onepass.Vault) {
+	conflicts, err := vault.ConflictedCopies()
+	if err != nil {
+		fatalErr(err, "Unable to find conflicted copies")
+	}
+	if len(conflicts) == 0 {
+		fmt.Printf("No conflicted copies found\n")
+		return
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for i, conflict := range conflicts {
+		current := "item removed"
+		if item, err := vault.LoadItem(conflict.Item.Uuid); err == nil {
+			current = "current updated " + formatUpdateTime(item)
+		}
+		fmt.Fprintf(out, "%d\t%s (%s)\t%s\tcopy updated %s, %s\n", i+1, conflict.Item.Title,
+			conflict.Item.Uuid[0:4], conflict.Label, formatUpdateTime(conflict.Item), current)
+	}
+	out.Flush()
+}
+
+// lookupConflict returns the conflicted copy matching a number
+// from the 'conflicts' list or a title or ID pattern
+func lookupConflict(vault *onepass.Vault, selector string) onepass.ConflictedCopy {
+	conflicts, err := vault.ConflictedCopies()
+	if err != nil {
+		fatalErr(err, "Unable to find conflicted copies")
+	}
+	if number, err := strconv.Atoi(selector); err == nil {
+		if number < 1 || number > len(conflicts) {
+			fatalErr(fmt.Errorf("No conflicted copy %d", number), "")
+		}
+		return conflicts[number-1]
+	}
+
+	items := []onepass.Item{}
+	for _, conflict := range conflicts {
+		items = append(items, conflict.Item)
+	}
+	matches := client.MatchItems(items, selector, "")
+	if len(matches) == 0 {
+		fatalErr(fmt.Errorf("No conflicted copies match '%s'", selector), "")
+	}
+	if len(matches) > 1 {
+		fatalErr(fmt.Errorf("Multiple conflicted copies match '%s'. Use a number from 'conflicts' instead", selector), "")
+	}
+	for _, conflict := range conflicts {
+		if conflict.Item.Uuid == matches[0].Uuid {
+			return conflict
+		}
+	}
+	panic("matched conflicted copy not found")
+}
+
+func printConflictVersion(heading string, item onepass.Item) {
+	fmt.Printf("%s: %s (updated %s)\n", heading, item.Title, formatUpdateTime(item))
+	content, err := item.Content()
+	if err != nil {
+		fatalErr(err, "Unable to decrypt item")
+	}
+	fmt.Printf("%s\n", localizedContent(content).String())
+}
+
+func resolveConflict(vault *onepass.Vault, selector string, keep string) {
+	conflict := lookupConflict(vault, selector)
+	if keep == "" {
+		if current, err := vault.LoadItem(conflict.Item.Uuid); err == nil {
+			printConflictVersion("Current version", current)
+		}
+		printConflictVersion(fmt.Sprintf("Conflicted copy (%s)", conflict.Label), conflict.Item)
+		keep = readLinePrompt("Keep which version? (current/copy/both/merge)")
+	}
+	var err error
+	if keep == onepass.KeepMerged {
+		current, loadErr := vault.LoadItem(conflict.Item.Uuid)
+		if loadErr != nil {
+			fatalErr(loadErr, "Unable to load current version of item")
+		}
+		err = vault.MergeConflict(conflict, chooseMergedFields(current, conflict.Item, "current", "copy"))
+	} else {
+		err = vault.ResolveConflict(conflict, keep)
+	}
+	if err != nil {
+		fatalErr(err, "Unable to resolve conflict")
+	}
+	logItemAction("Resolved conflict for item", conflict.Item)
+}
+
+// chooseMergedFields asks which version of each field that differs
+// between two versions of an item to keep and returns the names of
+// the fields to take from other. See onepass.MergeItems()
+func chooseMergedFields(item onepass.Item, other onepass.Item, itemName string, otherName string) []string {
+	diffs, err := onepass.DiffItems(item, other)
+	if err != nil {
+		fatalErr(err, "Unable to compare versions of item")
+	}
+	fromOther := []string{}
+	for _, diff := range diffs {
+		if diff.Field == "type" {
+			fmt.Printf("The %s version has a different type. The type of the %s version will be kept.\n", otherName, itemName)
+			continue
+		}
+		if diff.Secret {
+			fmt.Printf("%s: changed (values hidden, see above)\n", diff.Field)
+		} else {
+			fmt.Printf("%s: '%s' (%s) or '%s' (%s)\n", diff.Field, diff.Old, itemName, diff.New, otherName)
+		}
+		for {
+			choice := readLinePrompt("  Keep which value? (%s/%s, default %s)", itemName, otherName, itemName)
+			if choice == otherName {
+				fromOther = append(fromOther, diff.Field)
+			} else if choice != itemName && choice != "" {
+				continue
+			}
+			break
+		}
+	}
+	return fromOther
+}
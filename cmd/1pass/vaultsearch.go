@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// maximum depth below each search root at which
+// vaults are found by scanning directories
+const vaultSearchDepth = 4
+
+// directories which are skipped when scanning for vaults
+// because they are large and never contain vaults
+var vaultSearchSkipDirs = map[string]bool{
+	"node_modules": true,
+	"Library":      true,
+	"go":           true,
+}
+
+func defaultVaultSearchRoots() []string {
+	home := os.Getenv("HOME")
+	return []string{
+		filepath.Join(home, "Dropbox"),
+		// location of Dropbox and other synced
+		// folders in recent versions of macOS
+		filepath.Join(home, "Library", "CloudStorage"),
+		home,
+	}
+}
+
+// vaultSearch collects the vaults found by the different
+// search methods, ignoring duplicates
+type vaultSearch struct {
+	seen     map[string]bool
+	vaults   []string
+	opVaults []string
+}
+
+func (search *vaultSearch) add(path string) {
+	path = filepath.Clean(strings.TrimSpace(path))
+	if path == "." || search.seen[path] {
+		return
+	}
+	search.seen[path] = true
+	switch filepath.Ext(path) {
+	case ".agilekeychain":
+		if onepass.CheckVault(path) == nil {
+			search.vaults = append(search.vaults, path)
+		}
+	case ".opvault":
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			search.opVaults = append(search.opVaults, path)
+		}
+	}
+}
+
+// addCommandOutput adds the paths listed, one per line,
+// in the output of a file search tool
+func (search *vaultSearch) addCommandOutput(name string, args ...string) {
+	if _, err := exec.LookPath(name); err != nil {
+		return
+	}
+	output, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return
+	}
+	for _, path := range strings.Split(string(output), "\n") {
+		search.add(path)
+	}
+}
+
+// scan adds vaults in dir or its subdirectories, up to depth
+// levels below dir. Hidden directories are skipped.
+func (search *vaultSearch) scan(dir string, depth int) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || vaultSearchSkipDirs[name] {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if ext := filepath.Ext(name); ext == ".agilekeychain" || ext == ".opvault" {
+			search.add(path)
+		} else if depth > 1 {
+			search.scan(path, depth-1)
+		}
+	}
+}
+
+// findKeyChainDirs attempts to locate vaults automatically, using the
+// 'locate' and macOS Spotlight ('mdfind') indexes where available and
+// then by scanning the directories in roots, which defaults to
+// defaultVaultSearchRoots().
+//
+// Returns the paths of the vaults found and the paths of any vaults in
+// the OPVault format, which is not supported.
+func findKeyChainDirs(roots []string) (vaults []string, opVaults []string) {
+	search := vaultSearch{seen: map[string]bool{}}
+	search.addCommandOutput("locate", "-b", "--existing", ".agilekeychain")
+	search.addCommandOutput("mdfind", "kMDItemFSName == '*.agilekeychain' || kMDItemFSName == '*.opvault'")
+
+	if len(roots) == 0 {
+		roots = defaultVaultSearchRoots()
+	}
+	for _, root := range roots {
+		search.scan(root, vaultSearchDepth)
+	}
+	return search.vaults, search.opVaults
+}
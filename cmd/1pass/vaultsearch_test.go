@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanForVaults(t *testing.T) {
+	root, err := ioutil.TempDir("", "vaultsearch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{
+		"Dropbox/1Password/1Password.agilekeychain/data/default",
+		"sync/a/b/c/deep.agilekeychain/data/default",
+		".hidden/hidden.agilekeychain/data/default",
+		"Dropbox/Work.opvault/default",
+		"Dropbox/invalid.agilekeychain",
+	} {
+		err = os.MkdirAll(filepath.Join(root, dir), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := vaultSearch{seen: map[string]bool{}}
+	search.scan(root, 3)
+	// repeated scans should not add duplicates
+	search.scan(filepath.Join(root, "Dropbox"), 3)
+
+	expected := []string{filepath.Join(root, "Dropbox/1Password/1Password.agilekeychain")}
+	if len(search.vaults) != len(expected) || search.vaults[0] != expected[0] {
+		t.Errorf("Expected vaults %v, got %v", expected, search.vaults)
+	}
+	if len(search.opVaults) != 1 || search.opVaults[0] != filepath.Join(root, "Dropbox/Work.opvault") {
+		t.Errorf("Expected OPVault to be found, got %v", search.opVaults)
+	}
+
+	search.scan(root, 5)
+	if len(search.vaults) != 2 {
+		t.Errorf("Expected deeper scan to find another vault, got %v", search.vaults)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/robertknight/1pass/locale"
+	"github.com/robertknight/1pass/onepass"
+)
+
+func syncVaultsHelp() string {
+	return `Merges the items in the current vault with those in another copy
+of the vault, such as a copy in Dropbox and a local copy which have
+diverged. Items are matched by ID. New items are copied to the vault
+which does not have them and, for items which have been changed,
+the most recently updated version replaces the older one. Removed
+items are removed from both vaults.
+
+Items which have been changed in both vaults since they were last
+synced are reported as conflicts and are not changed. Running 'sync'
+again replaces the older version of each conflicting item, so check
+the conflicting items in both vaults before doing so.
+
+With '--resolve', both versions of each conflicting item are shown and
+you are asked which to keep:
+
+  here   Keep the version in the current vault
+  other  Keep the version in the other vault
+  both   Keep the version in the current vault and add the version in
+         the other vault to both vaults as a new item
+  merge  Compare the versions field by field and choose which version
+         of each changed field to keep
+
+The chosen version is saved in both vaults. When only one version is
+kept, passwords from the other version are added to its password
+history. Leave the answer blank to skip the item.
+
+If the other vault is locked, you are prompted for its master
+password.`
+}
+
+// syncKey returns the key in clientConfig.LastSyncTimes
+// for a pair of vaults
+func syncKey(vaultPath string, otherPath string) string {
+	if otherPath < vaultPath {
+		vaultPath, otherPath = otherPath, vaultPath
+	}
+	return vaultPath + "|" + otherPath
+}
+
+func syncVaults(vault *onepass.Vault, otherPath string, dryRun bool, resolve bool) {
+	otherPath, err := filepath.Abs(otherPath)
+	if err != nil {
+		fatalErr(err, "Invalid vault path")
+	}
+	vaultPath, err := filepath.Abs(vault.Path)
+	if err != nil {
+		fatalErr(err, "Invalid vault path")
+	}
+	if otherPath == vaultPath {
+		fatalErr(fmt.Errorf("'%s' is the current vault", otherPath), "")
+	}
+	other, err := onepass.OpenVault(otherPath)
+	if err != nil {
+		fatalErr(err, "Unable to open vault to sync with")
+	}
+
+	config := readConfig()
+	watchVault(config, &other)
+	otherAgent := connectToAgent(config, otherPath)
+	otherAgent.Profile = other.Profile
+	locked, err := otherAgent.IsLocked()
+	if err != nil {
+		fatalErr(err, "Failed to check lock status")
+	}
+	if locked {
+		fmt.Printf("%s '%s': ", locale.T("Master password for"), otherPath)
+		masterPwd, err := readTerminalPassword()
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Println()
+		err = otherAgent.Unlock(masterPwd)
+		if _, ok := err.(onepass.DecryptError); ok {
+			hint, _ := other.PasswordHint()
+			fmt.Fprintf(os.Stderr, "Incorrect password (hint: %s)\n", hint)
+			os.Exit(1)
+		} else if err != nil {
+			fatalErr(err, "Unable to unlock vault to sync with")
+		}
+	}
+	err = otherAgent.AcquireLease()
+	if err != nil {
+		fatalErr(err, "Unable to acquire vault lease")
+	}
+	other.CryptoAgent = otherAgent
+
+	key := syncKey(vaultPath, otherPath)
+	var lastSync time.Time
+	if config.LastSyncTimes[key] != 0 {
+		lastSync = time.Unix(config.LastSyncTimes[key], 0)
+	}
+	syncTime := time.Now()
+	result, err := onepass.SyncVaults(vault, &other, lastSync, dryRun)
+	if err != nil {
+		fatalErr(err, "Failed to sync vaults")
+	}
+
+	for _, item := range result.Sent {
+		logItemAction("Sent item", item)
+	}
+	for _, item := range result.Received {
+		logItemAction("Received item", item)
+	}
+	for _, conflict := range result.Conflicts {
+		fmt.Printf("Conflict: '%s' (%s) was changed in both vaults (%s here, %s in '%s')\n",
+			conflict.Item.Title, conflict.Item.Uuid[0:4],
+			time.Unix(int64(conflict.Item.UpdatedAt), 0).Format(time.Stamp),
+			time.Unix(int64(conflict.Other.UpdatedAt), 0).Format(time.Stamp), otherPath)
+	}
+	fmt.Printf("%d items sent, %d items received, %d conflicts\n",
+		len(result.Sent), len(result.Received), len(result.Conflicts))
+
+	if dryRun {
+		return
+	}
+	if resolve {
+		for _, conflict := range result.Conflicts {
+			resolveSyncConflict(vault, &other, conflict)
+		}
+	}
+	// re-read the config in case it was changed while syncing
+	config = readConfig()
+	if config.LastSyncTimes == nil {
+		config.LastSyncTimes = map[string]int64{}
+	}
+	config.LastSyncTimes[key] = syncTime.Unix()
+	writeConfig(&config)
+}
+
+// resolveSyncConflict shows both versions of an item which was changed
+// in both vaults and asks the user which to keep
+func resolveSyncConflict(vault *onepass.Vault, other *onepass.Vault, conflict onepass.SyncConflict) {
+	fmt.Println()
+	printConflictVersion("Version in this vault", conflict.Item)
+	printConflictVersion(fmt.Sprintf("Version in '%s'", other.Path), conflict.Other)
+
+	var keep string
+	for keep == "" {
+		switch readLinePrompt("Keep which version? (here/other/both/merge, blank to skip)") {
+		case "":
+			return
+		case "here":
+			keep = onepass.KeepCurrent
+		case "other":
+			keep = onepass.KeepCopy
+		case "both":
+			keep = onepass.KeepBoth
+		case "merge":
+			keep = onepass.KeepMerged
+		}
+	}
+	var fromOther []string
+	if keep == onepass.KeepMerged {
+		fromOther = chooseMergedFields(conflict.Item, conflict.Other, "here", "other")
+	}
+	err := onepass.ResolveSyncConflict(vault, other, conflict, keep, fromOther)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to resolve conflict for '%s': %v\n", conflict.Item.Title, err)
+		return
+	}
+	logItemAction("Resolved conflict for item", conflict.Item)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func trashRetentionHelp() string {
+	return `Sets the number of days after which items in the trash are removed
+from the vault automatically, the next time the vault is unlocked.
+
+Removing an item leaves a record of the removal in the vault so that
+the item is also removed from other copies of the vault when they are
+synced. These records are deleted after the same number of days, so
+the period should be longer than the time between syncs.
+
+An item is assumed to have been moved to the trash when it was last
+updated. Use 'off' to keep items in the trash until 'empty-trash' is
+used.`
+}
+
+func listTrash(vault *onepass.Vault) {
+	items, err := vault.TrashedItems()
+	if err != nil {
+		fatalErr(err, "Unable to list items in trash")
+	}
+	if len(items) == 0 {
+		fmt.Printf("The trash is empty\n")
+		return
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, item := range items {
+		fmt.Fprintf(out, "%s (%s, %s)\tupdated %s\n", item.Title, item.Type(), item.Uuid[0:4],
+			formatUpdateTime(item))
+	}
+	out.Flush()
+}
+
+func emptyTrash(vault *onepass.Vault) {
+	items, err := vault.TrashedItems()
+	if err != nil {
+		fatalErr(err, "Unable to list items in trash")
+	}
+	if len(items) == 0 {
+		fmt.Printf("The trash is empty\n")
+		return
+	}
+	if !vault.DryRun {
+		fmt.Printf("Remove %d items in the trash from the vault? This cannot be undone. Y/N\n", len(items))
+		if !readConfirmation() {
+			return
+		}
+	}
+	removed, err := vault.EmptyTrash()
+	if !vault.DryRun {
+		for _, item := range removed {
+			logItemAction("Removed item", item)
+		}
+	}
+	if err != nil {
+		fatalErr(err, "Unable to empty trash")
+	}
+}
+
+func setTrashRetention(vault *onepass.Vault, setting string) {
+	days := 0
+	if setting != "off" {
+		var err error
+		days, err = strconv.Atoi(setting)
+		if err != nil || days <= 0 {
+			fatalErr(fmt.Errorf("Expected a number of days or 'off'"), "")
+		}
+	}
+	err := vault.SetTrashRetention(days)
+	if err != nil {
+		fatalErr(err, "Unable to update vault options")
+	}
+	if days == 0 {
+		fmt.Printf("Items will be kept in the trash until it is emptied\n")
+	} else {
+		fmt.Printf("Items will be removed after %d days in the trash\n", days)
+	}
+}
+
+// purgeExpiredTrash removes items which have been in the trash for
+// longer than the vault's retention period. See 'trash-retention'
+func purgeExpiredTrash(vault *onepass.Vault) {
+	purged, err := vault.PurgeExpiredTrash()
+	for _, item := range purged {
+		if item.TypeName != "system.Tombstone" {
+			logItemAction("Removed expired item from trash", item)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to remove expired items from trash: %v\n", err)
+	}
+}
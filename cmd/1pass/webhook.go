@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// time allowed for the webhook to respond to each event
+const webhookTimeout = 5 * time.Second
+
+// vaultEvent is the JSON body posted to the webhook set by
+// clientConfig.WebhookUrl when the vault is modified. Events
+// never include the titles or content of items.
+type vaultEvent struct {
+	Vault string `json:"vault"`
+	// 'add', 'update' or 'remove' for changes to items or
+	// the command, eg. 'set-password', for changes to the
+	// vault's keys
+	Action    string    `json:"action"`
+	Uuid      string    `json:"uuid,omitempty"`
+	TypeName  string    `json:"typeName,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// webhook posts events for changes to a vault to a local URL
+type webhook struct {
+	url       string
+	vaultPath string
+	client    http.Client
+	// set after a failed request, so that the failure
+	// is only reported once
+	failed bool
+}
+
+// newWebhook returns a webhook which posts events for the vault
+// at vaultPath to rawUrl. The URL must be an HTTP(S) URL on the
+// local machine, so that information about the vault is not
+// sent elsewhere.
+func newWebhook(rawUrl string, vaultPath string) (*webhook, error) {
+	hookUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	if hookUrl.Scheme != "http" && hookUrl.Scheme != "https" {
+		return nil, fmt.Errorf("'%s' is not an HTTP URL", rawUrl)
+	}
+	host := hookUrl.Hostname()
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("'%s' is not a local URL", rawUrl)
+	}
+	return &webhook{
+		url:       rawUrl,
+		vaultPath: vaultPath,
+		client:    http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// watch posts an event to the webhook for each change to
+// an item in vault
+func (hook *webhook) watch(vault *onepass.Vault) {
+	vault.OnItemChange = func(change onepass.ItemChange) {
+		hook.post(change.Action, change.Uuid, change.TypeName)
+	}
+}
+
+// post sends an event to the webhook. Failures are reported
+// but do not stop the command which changed the vault.
+func (hook *webhook) post(action string, uuid string, typeName string) {
+	body, err := json.Marshal(vaultEvent{
+		Vault:     hook.vaultPath,
+		Action:    action,
+		Uuid:      uuid,
+		TypeName:  typeName,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	resp, err := hook.client.Post(hook.url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("Server returned %s", resp.Status)
+		}
+	}
+	if err != nil && !hook.failed {
+		fmt.Fprintf(os.Stderr, "Unable to send event to webhook %s: %v\n", hook.url, err)
+		hook.failed = true
+	}
+}
+
+// watchVault sets up the webhook configured by config.WebhookUrl,
+// if any, to receive events for changes to vault
+func watchVault(config clientConfig, vault *onepass.Vault) *webhook {
+	if config.WebhookUrl == "" {
+		return nil
+	}
+	hook, err := newWebhook(config.WebhookUrl, vault.Path)
+	if err != nil {
+		fatalErr(err, "Invalid webhook URL")
+	}
+	hook.watch(vault)
+	return hook
+}
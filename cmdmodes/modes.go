@@ -12,7 +12,9 @@ The generic syntax is:
 package cmdmodes
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -39,6 +41,60 @@ type Mode struct {
 	// Indicates this is an internal command that should
 	// not be displayed in 'help' output
 	Internal bool
+	// Optional flags accepted by the command
+	Flags []Flag
+	// Worked examples of using the command, displayed
+	// by PrintExamples()
+	Examples []Example
+}
+
+// Example describes a sample invocation of a mode
+type Example struct {
+	// Arguments following the command name,
+	// eg. '--sort frequency github'
+	Args string `json:"args"`
+	// Description of what the example does
+	Description string `json:"description"`
+}
+
+// Flag describes an optional '--name' or '--name <value>'
+// argument accepted by a mode. Flags may appear anywhere
+// after the command name.
+type Flag struct {
+	// Name of the flag without leading dashes, eg. 'recent'
+	Name string `json:"name"`
+	// Name of the value taken by the flag, for use in help output.
+	// If empty, the flag is a boolean switch which does not
+	// take a value
+	ArgName string `json:"argName,omitempty"`
+	// One-line description of the flag
+	Description string `json:"description"`
+}
+
+// FlagValues maps flag names to the values supplied for them
+// on the command line. Boolean flags have a single empty value
+// when present.
+type FlagValues map[string][]string
+
+// Bool returns true if the flag 'name' was supplied
+func (f FlagValues) Bool(name string) bool {
+	_, ok := f[name]
+	return ok
+}
+
+// String returns the last value supplied for flag 'name' or
+// an empty string if the flag was not supplied
+func (f FlagValues) String(name string) string {
+	values := f[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// Strings returns all of the values supplied for flag 'name'
+func (f FlagValues) Strings(name string) []string {
+	return f[name]
 }
 
 // Parser provides functions to extract the arguments for
@@ -100,6 +156,9 @@ func (p *Parser) PrintHelp(banner string, cmd string) {
 				found = true
 
 				syntax := fmt.Sprintf("%s %s", os.Args[0], mode.Command)
+				if len(mode.Flags) > 0 {
+					syntax += " [flags]"
+				}
 				for _, arg := range mode.ArgNames {
 					if strings.HasPrefix(arg, "[") {
 						// optional arg
@@ -111,9 +170,26 @@ func (p *Parser) PrintHelp(banner string, cmd string) {
 				}
 				fmt.Printf("%s\n\n%s\n\n", syntax, mode.Description)
 
+				if len(mode.Flags) > 0 {
+					fmt.Printf("Flags:\n\n")
+					for _, flag := range mode.Flags {
+						flagSyntax := "--" + flag.Name
+						if flag.ArgName != "" {
+							flagSyntax += " <" + flag.ArgName + ">"
+						}
+						fmt.Printf("  %-20s %s\n", flagSyntax, flag.Description)
+					}
+					fmt.Println()
+				}
+
 				if mode.ExtraHelp != nil {
 					fmt.Printf("%s\n\n", mode.ExtraHelp())
 				}
+
+				if len(mode.Examples) > 0 {
+					fmt.Printf("Use '%s help --examples %s' to see examples of using this command.\n\n",
+						os.Args[0], mode.Command)
+				}
 			}
 		}
 		if !found {
@@ -122,6 +198,83 @@ func (p *Parser) PrintHelp(banner string, cmd string) {
 	}
 }
 
+// Machine-readable description of a mode
+// generated by WriteJson()
+type modeInfo struct {
+	Command     string    `json:"command"`
+	Description string    `json:"description"`
+	Args        []argInfo `json:"args"`
+	Flags       []Flag    `json:"flags"`
+	Examples    []Example `json:"examples"`
+	Help        string    `json:"help,omitempty"`
+	Internal    bool      `json:"internal,omitempty"`
+}
+
+type argInfo struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional"`
+}
+
+// WriteJson writes a JSON description of all supported modes,
+// including their arguments, flags, examples and extended help
+// text, for use by wrapper scripts and completion generators.
+func (p *Parser) WriteJson(w io.Writer) error {
+	modes := []modeInfo{}
+	for _, mode := range p.Modes {
+		info := modeInfo{
+			Command:     mode.Command,
+			Description: mode.Description,
+			Args:        []argInfo{},
+			Flags:       mode.Flags,
+			Examples:    mode.Examples,
+			Internal:    mode.Internal,
+		}
+		if info.Flags == nil {
+			info.Flags = []Flag{}
+		}
+		if info.Examples == nil {
+			info.Examples = []Example{}
+		}
+		for _, arg := range mode.ArgNames {
+			optional := strings.HasPrefix(arg, "[")
+			info.Args = append(info.Args, argInfo{
+				Name:     strings.Trim(arg, "[]"),
+				Optional: optional,
+			})
+		}
+		if mode.ExtraHelp != nil {
+			info.Help = mode.ExtraHelp()
+		}
+		modes = append(modes, info)
+	}
+	data, err := json.MarshalIndent(modes, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// PrintExamples prints the worked examples for a given command
+func (p *Parser) PrintExamples(cmd string) {
+	for _, mode := range p.Modes {
+		if mode.Command != cmd {
+			continue
+		}
+		if len(mode.Examples) == 0 {
+			fmt.Fprintf(os.Stderr, "No examples available for '%s'\n", cmd)
+			return
+		}
+		fmt.Printf("Examples:\n\n")
+		for _, example := range mode.Examples {
+			fmt.Printf("  %s %s %s\n", os.Args[0], mode.Command, example.Args)
+			fmt.Printf("    %s\n\n", example.Description)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "No such command: '%s'\n", cmd)
+}
+
 // ParseCmdArgs checks that the positional arguments supplied to
 // a command match the expected arguments for a given command and
 // saves them into the variables supplied via out.
@@ -154,3 +307,58 @@ func (p *Parser) ParseCmdArgs(cmdName string, cmdArgs []string, out ...*string)
 	}
 	return nil
 }
+
+// ParseCmdFlags extracts the flags declared for cmdName from
+// cmdArgs and returns their values along with the remaining
+// positional arguments, which can then be passed to ParseCmdArgs().
+//
+// Flags may be written as '--name', '-name', '--name value'
+// or '--name=value'. An argument of '--' ends flag parsing.
+// Returns an error if an unknown flag is encountered or a
+// flag is missing its value.
+func (p *Parser) ParseCmdFlags(cmdName string, cmdArgs []string) (FlagValues, []string, error) {
+	var flags []Flag
+	for _, mode := range p.Modes {
+		if mode.Command == cmdName {
+			flags = mode.Flags
+		}
+	}
+
+	values := FlagValues{}
+	args := []string{}
+	for i := 0; i < len(cmdArgs); i++ {
+		arg := cmdArgs[i]
+		if arg == "--" {
+			args = append(args, cmdArgs[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			args = append(args, arg)
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		hasValue := false
+		if sep := strings.Index(name, "="); sep != -1 {
+			name, value = name[:sep], name[sep+1:]
+			hasValue = true
+		}
+
+		flagIndex := rangeutil.IndexIn(0, len(flags), func(i int) bool {
+			return flags[i].Name == name
+		})
+		if flagIndex == -1 {
+			return nil, nil, fmt.Errorf("Unknown flag '%s' for '%s'", arg, cmdName)
+		}
+		if flags[flagIndex].ArgName != "" && !hasValue {
+			if i+1 >= len(cmdArgs) {
+				return nil, nil, fmt.Errorf("Missing value for flag '--%s'", name)
+			}
+			i++
+			value = cmdArgs[i]
+		}
+		values[name] = append(values[name], value)
+	}
+	return values, args, nil
+}
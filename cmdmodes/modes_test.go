@@ -0,0 +1,79 @@
+package cmdmodes
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var testModes = []Mode{
+	{
+		Command:  "list",
+		ArgNames: []string{"[pattern]"},
+		Flags: []Flag{
+			{Name: "recent", Description: "List recent items"},
+			{Name: "sort", ArgName: "order", Description: "Sort order"},
+		},
+	},
+}
+
+func TestParseCmdFlags(t *testing.T) {
+	parser := NewParser(testModes)
+
+	flags, args, err := parser.ParseCmdFlags("list", []string{"--recent", "git", "--sort", "frequency"})
+	if err != nil {
+		t.Fatalf("Unable to parse flags: %v", err)
+	}
+	if !flags.Bool("recent") || flags.String("sort") != "frequency" {
+		t.Errorf("Unexpected flag values: %v", flags)
+	}
+	if !reflect.DeepEqual(args, []string{"git"}) {
+		t.Errorf("Unexpected positional args: %v", args)
+	}
+
+	flags, args, err = parser.ParseCmdFlags("list", []string{"-sort=title", "--", "--recent"})
+	if err != nil {
+		t.Fatalf("Unable to parse flags: %v", err)
+	}
+	if flags.Bool("recent") || flags.String("sort") != "title" {
+		t.Errorf("Unexpected flag values: %v", flags)
+	}
+	if !reflect.DeepEqual(args, []string{"--recent"}) {
+		t.Errorf("Unexpected positional args: %v", args)
+	}
+
+	_, _, err = parser.ParseCmdFlags("list", []string{"--unknown"})
+	if err == nil {
+		t.Errorf("Expected error for unknown flag")
+	}
+	_, _, err = parser.ParseCmdFlags("list", []string{"--sort"})
+	if err == nil {
+		t.Errorf("Expected error for missing flag value")
+	}
+}
+
+func TestWriteJson(t *testing.T) {
+	parser := NewParser(testModes)
+	var buf bytes.Buffer
+	err := parser.WriteJson(&buf)
+	if err != nil {
+		t.Fatalf("Unable to write JSON: %v", err)
+	}
+
+	var modes []modeInfo
+	err = json.Unmarshal(buf.Bytes(), &modes)
+	if err != nil {
+		t.Fatalf("Unable to parse JSON: %v", err)
+	}
+	if len(modes) != 1 || modes[0].Command != "list" {
+		t.Fatalf("Unexpected modes: %v", modes)
+	}
+	expectedArgs := []argInfo{{Name: "pattern", Optional: true}}
+	if !reflect.DeepEqual(modes[0].Args, expectedArgs) {
+		t.Errorf("Unexpected args: %v", modes[0].Args)
+	}
+	if !reflect.DeepEqual(modes[0].Flags, testModes[0].Flags) {
+		t.Errorf("Unexpected flags: %v", modes[0].Flags)
+	}
+}
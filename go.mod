@@ -0,0 +1,3 @@
+module github.com/robertknight/1pass
+
+go 1.26.0
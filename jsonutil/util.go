@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+
+	"github.com/robertknight/1pass/securetmp"
 )
 
 type MarshalFunc func(interface{}) ([]byte, error)
@@ -19,6 +22,49 @@ func MarshalToFile(path string, in interface{}, marshal MarshalFunc) error {
 	return err
 }
 
+// MarshalToFileAtomic is like MarshalToFile but writes the data to
+// a temporary file in the same directory, which then replaces the
+// file at path, so that the file is never left partially written
+func MarshalToFileAtomic(path string, in interface{}, marshal MarshalFunc) error {
+	return marshalToFileAtomic(path, in, marshal, atomicFileMode(path))
+}
+
+func marshalToFileAtomic(path string, in interface{}, marshal MarshalFunc, perm os.FileMode) error {
+	data, err := marshal(in)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile.Name(), perm)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// atomicFileMode returns the mode for a file written by
+// MarshalToFileAtomic(). This is the mode of the file being replaced
+// if it exists, or the mode which MarshalToFile() would use otherwise.
+func atomicFileMode(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return securetmp.Perm(0644)
+}
+
 func ReadFile(path string, out interface{}) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -39,10 +85,22 @@ func WriteFile(path string, in interface{}) error {
 	return MarshalToFile(path, in, json.Marshal)
 }
 
+// WriteFileAtomic writes the JSON encoding of in to path,
+// replacing any existing file atomically. See MarshalToFileAtomic()
+func WriteFileAtomic(path string, in interface{}) error {
+	return MarshalToFileAtomic(path, in, json.Marshal)
+}
+
+// WritePrivateFileAtomic is like WriteFileAtomic but writes indented
+// JSON to a file which only the current user can read or write
+func WritePrivateFileAtomic(path string, in interface{}) error {
+	return marshalToFileAtomic(path, in, marshalPrettyJson, 0600)
+}
+
+func marshalPrettyJson(in interface{}) ([]byte, error) {
+	return json.MarshalIndent(in, "", "  ")
+}
+
 func WritePrettyFile(path string, in interface{}) error {
-	marshalPrettyJson := func(in interface{}) ([]byte, error) {
-		data, err := json.MarshalIndent(in, "", "  ")
-		return data, err
-	}
 	return MarshalToFile(path, in, marshalPrettyJson)
 }
@@ -0,0 +1,210 @@
+package locale
+
+// German translations
+var germanCatalog = Catalog{
+	// prompts
+	"Master password":                   "Master-Passwort",
+	"Enter master password":             "Master-Passwort eingeben",
+	"Re-enter master password":          "Master-Passwort wiederholen",
+	"Current master password":           "Aktuelles Master-Passwort",
+	"Re-enter new master password":      "Neues Master-Passwort wiederholen",
+	"New master password":               "Neues Master-Passwort",
+	"Passwords do not match":            "Die Passwörter stimmen nicht überein",
+	"%s (or '-' for a random new %s)":   "%s (oder '-' für ein(e) neue(s) zufällige(s) %s)",
+	"Re-enter %s":                       "%s wiederholen",
+	"(Random new password generated)":   "(Neues zufälliges Passwort erzeugt)",
+	"Street":                            "Straße",
+	"City":                              "Stadt",
+	"Zip":                               "Postleitzahl",
+	"State":                             "Bundesland",
+	"Country":                           "Land",
+	"Section (or title of new section)": "Abschnitt (oder Titel eines neuen Abschnitts)",
+	"Field (or title of new field)":     "Feld (oder Titel eines neuen Felds)",
+	"Field":                             "Feld",
+	"URL (or label of new URL)":         "URL (oder Bezeichnung einer neuen URL)",
+	"Added new item":                    "Neues Objekt hinzugefügt",
+	"Added note":                        "Notiz hinzugefügt",
+	"Editing item":                      "Bearbeite Objekt",
+	"Trashing item":                     "Verschiebe Objekt in den Papierkorb",
+	"Imported item":                     "Objekt importiert",
+	"Exporting item":                    "Exportiere Objekt",
+	"Password to verify":                "Zu prüfendes Passwort",
+	"Master password for":               "Master-Passwort für",
+	"Sent item":                         "Objekt gesendet",
+	"Received item":                     "Objekt empfangen",
+
+	// form fields
+	"username": "Benutzername",
+	"password": "Passwort",
+
+	// template sections and fields
+	"AOL/AIM":                      "AOL/AIM",
+	"Additional Details":           "Weitere Angaben",
+	"Address":                      "Adresse",
+	"Admin Console":                "Admin-Konsole",
+	"Branch Information":           "Filiale",
+	"Contact Information":          "Kontaktdaten",
+	"Custom Password Field":        "Benutzerdefiniertes Passwortfeld",
+	"Customer":                     "Kunde",
+	"Hosting Provider":             "Hosting-Anbieter",
+	"IBAN":                         "IBAN",
+	"ICQ":                          "ICQ",
+	"Identification":               "Identifikation",
+	"Internet Details":             "Internet",
+	"MSN":                          "MSN",
+	"More Information":             "Weitere Informationen",
+	"Order":                        "Bestellung",
+	"PIN":                          "PIN",
+	"Publisher":                    "Herausgeber",
+	"SID":                          "SID",
+	"SMTP":                         "SMTP",
+	"SMTP server":                  "SMTP-Server",
+	"SSL mode":                     "SSL-Modus",
+	"SWIFT":                        "SWIFT",
+	"URL":                          "URL",
+	"Yahoo":                        "Yahoo",
+	"account number":               "Kontonummer",
+	"address":                      "Adresse",
+	"admin console URL":            "URL der Admin-Konsole",
+	"admin console username":       "Benutzername der Admin-Konsole",
+	"alias":                        "Alias",
+	"approved wildlife":            "Zugelassene Wildarten",
+	"auth method":                  "Authentifizierungsmethode",
+	"bank name":                    "Name der Bank",
+	"birth date":                   "Geburtsdatum",
+	"business":                     "Geschäftlich",
+	"cardholder name":              "Karteninhaber",
+	"cash withdrawal limit":        "Bargeldlimit",
+	"cell":                         "Mobil",
+	"company":                      "Firma",
+	"company name":                 "Firmenname",
+	"conditions / restrictions":    "Auflagen / Einschränkungen",
+	"connection options":           "Verbindungsoptionen",
+	"console password":             "Konsolen-Passwort",
+	"country":                      "Land",
+	"credit limit":                 "Kreditlimit",
+	"customer service phone":       "Telefon Kundendienst",
+	"database":                     "Datenbank",
+	"date of birth":                "Geburtsdatum",
+	"default phone":                "Standardtelefon",
+	"department":                   "Abteilung",
+	"download page":                "Download-Seite",
+	"email":                        "E-Mail",
+	"endpoint":                     "Endpunkt",
+	"expires":                      "Läuft ab",
+	"expiry date":                  "Ablaufdatum",
+	"first name":                   "Vorname",
+	"forum signature":              "Forensignatur",
+	"full name":                    "Vollständiger Name",
+	"group":                        "Gruppe",
+	"height":                       "Größe",
+	"home":                         "Privat",
+	"initial":                      "Initiale",
+	"interest rate":                "Zinssatz",
+	"issue number":                 "Ausgabenummer",
+	"issued on":                    "Ausgestellt am",
+	"issuing authority":            "Ausstellende Behörde",
+	"issuing bank":                 "Ausgebende Bank",
+	"issuing country":              "Ausstellungsland",
+	"job title":                    "Berufsbezeichnung",
+	"key id":                       "Schlüssel-ID",
+	"last name":                    "Nachname",
+	"license class":                "Führerscheinklasse",
+	"license key":                  "Lizenzschlüssel",
+	"licensed to":                  "Lizenziert für",
+	"maximum quota":                "Maximales Kontingent",
+	"member ID":                    "Mitgliedsnummer",
+	"member ID (additional)":       "Mitgliedsnummer (zusätzlich)",
+	"member name":                  "Name des Mitglieds",
+	"member since":                 "Mitglied seit",
+	"name":                         "Name",
+	"name on account":              "Kontoinhaber",
+	"nationality":                  "Staatsangehörigkeit",
+	"number":                       "Nummer",
+	"occupation":                   "Beruf",
+	"order number":                 "Bestellnummer",
+	"order total":                  "Gesamtbetrag",
+	"phone":                        "Telefon",
+	"phone (intl)":                 "Telefon (international)",
+	"phone (local)":                "Telefon (national)",
+	"phone (toll free)":            "Telefon (gebührenfrei)",
+	"phone for reserva\u200btions": "Telefon für Reservierungen",
+	"place of birth":               "Geburtsort",
+	"port":                         "Port",
+	"port number":                  "Portnummer",
+	"provider":                     "Anbieter",
+	"provider's website":           "Website des Anbieters",
+	"publisher":                    "Herausgeber",
+	"purchase date":                "Kaufdatum",
+	"registered email":             "Registrierte E-Mail",
+	"reminder answer":              "Antwort auf Sicherheitsfrage",
+	"reminder question":            "Sicherheitsfrage",
+	"retail price":                 "Verkaufspreis",
+	"routing number":               "Bankleitzahl",
+	"scopes":                       "Berechtigungen",
+	"secret":                       "Geheimnis",
+	"security":                     "Sicherheit",
+	"server":                       "Server",
+	"sex":                          "Geschlecht",
+	"skype":                        "Skype",
+	"state":                        "Bundesland",
+	"support URL":                  "Support-URL",
+	"support email":                "Support-E-Mail",
+	"support phone":                "Support-Telefon",
+	"telephone":                    "Telefon",
+	"type":                         "Typ",
+	"valid from":                   "Gültig ab",
+	"verification number":          "Prüfnummer",
+	"version":                      "Version",
+	"website":                      "Website",
+	"website 2":                    "Website 2",
+	"website 3":                    "Website 3",
+
+	// item types
+	"Item Types:":            "Objekttypen:",
+	"Login":                  "Login",
+	"Credit Card":            "Kreditkarte",
+	"Secure Note":            "Sichere Notiz",
+	"Identity":               "Identität",
+	"Password":               "Passwort",
+	"Bank Account":           "Bankkonto",
+	"Driver's License":       "Führerschein",
+	"Email Account":          "E-Mail-Konto",
+	"Passport":               "Reisepass",
+	"Software License":       "Softwarelizenz",
+	"Folder":                 "Ordner",
+	"Wireless Router":        "WLAN-Router",
+	"Unix Server":            "Unix-Server",
+	"Database":               "Datenbank",
+	"Membership":             "Mitgliedschaft",
+	"Social Security Number": "Sozialversicherungsnummer",
+	"API Credential":         "API-Zugangsdaten",
+	"Outdoor License":        "Jagd- oder Angelschein",
+	"Reward Program":         "Bonusprogramm",
+	"Smart Folder":           "Intelligenter Ordner",
+
+	// commands
+	"Create a new vault":                                     "Einen neuen Tresor erstellen",
+	"Generate a new random password":                         "Ein neues zufälliges Passwort erzeugen",
+	"Set the path to the 1Password vault":                    "Den Pfad zum 1Password-Tresor festlegen",
+	"Display info about the current vault":                   "Informationen zum aktuellen Tresor anzeigen",
+	"List items in the vault":                                "Objekte im Tresor auflisten",
+	"List items in a folder":                                 "Objekte in einem Ordner auflisten",
+	"List items with a given tag":                            "Objekte mit einem bestimmten Tag auflisten",
+	"List all tags":                                          "Alle Tags auflisten",
+	"Show the raw decrypted JSON for the given item":         "Das entschlüsselte JSON des Objekts anzeigen",
+	"Display the details of the given item":                  "Die Details des Objekts anzeigen",
+	"Add a new item to the vault":                            "Ein neues Objekt zum Tresor hinzufügen",
+	"Create a Secure Note containing text read from stdin":   "Eine sichere Notiz mit Text von der Standardeingabe erstellen",
+	"Edit an existing item":                                  "Ein vorhandenes Objekt bearbeiten",
+	"Move items to a folder":                                 "Objekte in einen Ordner verschieben",
+	"Remove items from the vault matching the given pattern": "Objekte, die dem Muster entsprechen, aus dem Tresor entfernen",
+	"Move items to the trash":                                "Objekte in den Papierkorb verschieben",
+	"Restore items from the trash":                           "Objekte aus dem Papierkorb wiederherstellen",
+	"Renames an item in the vault":                           "Ein Objekt im Tresor umbenennen",
+	"Copy information from the given item to the clipboard":  "Informationen des Objekts in die Zwischenablage kopieren",
+	"Change the master password for the vault":               "Das Master-Passwort des Tresors ändern",
+	"Display usage information":                              "Hilfe zur Verwendung anzeigen",
+	"Add a tag to an item":                                   "Einem Objekt ein Tag hinzufügen",
+	"Remove tags from an item":                               "Tags von einem Objekt entfernen",
+}
@@ -0,0 +1,90 @@
+// Package locale provides translations of the user-visible text
+// used by 1pass, such as prompts, help text and the titles of the
+// sections and fields in item templates.
+//
+// Messages are looked up using their English text, so untranslated
+// messages are shown in English. Only text which is displayed is
+// translated. The names of fields and sections stored in items are
+// never changed.
+package locale
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Catalog is a map of English message -> translated message
+type Catalog map[string]string
+
+// map of language code -> message catalog
+var catalogs = map[string]Catalog{
+	"de": germanCatalog,
+}
+
+var current Catalog
+
+// Languages returns the codes of the available languages,
+// excluding English
+func Languages() []string {
+	languages := []string{}
+	for lang, _ := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Language returns the language to use for messages. This is
+// preferred if set, or otherwise the language from the LC_ALL,
+// LC_MESSAGES or LANG environment variables.
+func Language(preferred string) string {
+	if preferred != "" {
+		return normalize(preferred)
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return normalize(value)
+		}
+	}
+	return "en"
+}
+
+// converts a locale name such as 'de_DE.UTF-8' to
+// a language code
+func normalize(name string) string {
+	name = strings.ToLower(name)
+	if end := strings.IndexAny(name, "_-.@"); end != -1 {
+		name = name[0:end]
+	}
+	if name == "c" || name == "posix" || name == "" {
+		return "en"
+	}
+	return name
+}
+
+// SetLanguage selects the catalog used to translate messages.
+// English messages are used if lang is 'en' or if there is no
+// catalog for lang, in which case an error is returned.
+func SetLanguage(lang string) error {
+	current = nil
+	if lang == "en" {
+		return nil
+	}
+	catalog, ok := catalogs[lang]
+	if !ok {
+		return fmt.Errorf("No translations are available for language '%s'", lang)
+	}
+	current = catalog
+	return nil
+}
+
+// T returns the translation of msg in the current
+// language, or msg if there is no translation
+func T(msg string) string {
+	if translated, ok := current[msg]; ok {
+		return translated
+	}
+	return msg
+}
@@ -0,0 +1,48 @@
+package locale
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		os.Unsetenv(env)
+	}
+	if lang := Language(""); lang != "en" {
+		t.Errorf("Expected default language 'en', got '%s'", lang)
+	}
+	os.Setenv("LANG", "de_DE.UTF-8")
+	if lang := Language(""); lang != "de" {
+		t.Errorf("Expected language 'de' from $LANG, got '%s'", lang)
+	}
+	os.Setenv("LC_ALL", "C")
+	if lang := Language(""); lang != "en" {
+		t.Errorf("Expected language 'en' for C locale, got '%s'", lang)
+	}
+	if lang := Language("fr_FR"); lang != "fr" {
+		t.Errorf("Expected preferred language 'fr', got '%s'", lang)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage("en")
+
+	err := SetLanguage("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := T("first name"); msg != "Vorname" {
+		t.Errorf("Expected translated message, got '%s'", msg)
+	}
+	if msg := T("not in the catalog"); msg != "not in the catalog" {
+		t.Errorf("Expected untranslated message, got '%s'", msg)
+	}
+
+	if err = SetLanguage("xx"); err == nil {
+		t.Errorf("Expected error for unknown language")
+	}
+	if msg := T("first name"); msg != "first name" {
+		t.Errorf("Expected English message for unknown language, got '%s'", msg)
+	}
+}
@@ -0,0 +1,510 @@
+// Package agent implements the 1pass agent, a daemon which holds
+// the keys of unlocked vaults and encrypts and decrypts data for
+// clients, which connect to it using the onepass/client package.
+//
+// The agent is run by '1pass -agent' or by the separate '1pass-agent'
+// command, which can be installed on its own on servers where
+// programs use the client package but the 1pass CLI is not needed.
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+var agentBinaryVersion = client.BinaryVersion(os.Args[0])
+
+// time after the last lease on a vault is released before
+// the vault is locked, if its auto-lock timer expired while
+// the lease was held
+const leaseGracePeriod = 30 * time.Second
+
+type vaultData struct {
+	// keys from onepass.UnlockKeys(), which are
+	// wiped when the vault is locked
+	keys     onepass.KeyDict
+	autoLock *time.Timer
+	// time when autoLock fires
+	expiresAt time.Time
+
+	// IDs of the client connections holding leases on the
+	// vault, which prevent the vault from being auto-locked
+	leases map[int]bool
+	// set if the auto-lock timer expired while leases were held
+	lockDeferred bool
+}
+
+// OnePassAgent is an RPC service for temporarily
+// storing keys for unlocked vaults and providing
+// functions to encrypt and decrypt item data.
+type OnePassAgent struct {
+	rpcServer rpc.Server
+
+	mu     sync.Mutex // protects `vaults`, `state` and `lastConnId`
+	vaults map[string]vaultData
+
+	// ID of the most recent client connection
+	lastConnId int
+
+	// non-secret state which is saved to `statePath`, if set,
+	// so that it survives restarts of the agent
+	state     agentState
+	statePath string
+
+	log *Logger
+}
+
+func NewAgent() OnePassAgent {
+	return OnePassAgent{
+		vaults: map[string]vaultData{},
+		state:  newAgentState(),
+		log:    NewStderrLogger(LogInfo),
+	}
+}
+
+// LoadState restores the agent's persisted state from path
+// and saves changes to the state there in future
+func (agent *OnePassAgent) LoadState(path string) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	state, err := readAgentState(path)
+	agent.statePath = path
+	agent.state = state
+	return err
+}
+
+// saveState persists the agent's state, if a state path
+// has been set. agent.mu must be held by the caller.
+func (agent *OnePassAgent) saveState() {
+	if agent.statePath == "" {
+		return
+	}
+	err := writeAgentState(agent.statePath, agent.state)
+	if err != nil {
+		agent.log.Error("Saving agent state failed", "path", agent.statePath, "err", err)
+	}
+}
+
+// Encrypt encrypts data for storage in an item in a 1Password vault
+// The vault must previously have been unlocked using an Unlock() call
+func (agent *OnePassAgent) Encrypt(args client.CryptArgs, cipherText *[]byte) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, ok := agent.vaults[args.VaultPath]
+	if !ok {
+		return errors.New("No such vault")
+	}
+
+	itemKey, ok := vaultData.keys[args.KeyName]
+	if !ok {
+		return errors.New("No such key")
+	}
+	var err error
+	*cipherText, err = onepass.EncryptItemData(itemKey, args.Data)
+	return err
+}
+
+func (agent *OnePassAgent) Decrypt(args client.CryptArgs, plainText *[]byte) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, ok := agent.vaults[args.VaultPath]
+	if !ok {
+		return errors.New("No such vault")
+	}
+	itemKey, ok := vaultData.keys[args.KeyName]
+	if !ok {
+		return errors.New("No such key")
+	}
+	var err error
+	*plainText, err = onepass.DecryptItemData(itemKey, args.Data)
+	return err
+}
+
+// DecryptBatch decrypts the data for several items, avoiding
+// a round trip to the agent for each item
+func (agent *OnePassAgent) DecryptBatch(args client.BatchCryptArgs, plainTexts *[][]byte) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, ok := agent.vaults[args.VaultPath]
+	if !ok {
+		return errors.New("No such vault")
+	}
+	if len(args.KeyNames) != len(args.Data) {
+		return errors.New("Mismatched key names and data")
+	}
+	results := make([][]byte, len(args.Data))
+	for i, data := range args.Data {
+		itemKey, ok := vaultData.keys[args.KeyNames[i]]
+		if !ok {
+			return errors.New("No such key")
+		}
+		var err error
+		results[i], err = onepass.DecryptItemData(itemKey, data)
+		if err != nil {
+			return err
+		}
+	}
+	*plainTexts = results
+	return nil
+}
+
+// Sign computes the signature for an item file using a key
+// derived from one of the vault's keys
+func (agent *OnePassAgent) Sign(args client.CryptArgs, signature *[]byte) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, ok := agent.vaults[args.VaultPath]
+	if !ok {
+		return errors.New("No such vault")
+	}
+	itemKey, ok := vaultData.keys[args.KeyName]
+	if !ok {
+		return errors.New("No such key")
+	}
+	*signature = onepass.SignItemData(itemKey, args.Data)
+	return nil
+}
+
+// DerivePassword computes a password from a recipe using one of
+// the vault's keys, so that the key never leaves the agent
+func (agent *OnePassAgent) DerivePassword(args client.DeriveArgs, password *string) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, ok := agent.vaults[args.VaultPath]
+	if !ok {
+		return errors.New("No such vault")
+	}
+	itemKey, ok := vaultData.keys[args.KeyName]
+	if !ok {
+		return errors.New("No such key")
+	}
+	var err error
+	*password, err = onepass.DerivePassword(itemKey, args.Recipe)
+	return err
+}
+
+// GenPassword generates a random password. All passwords generated
+// by the client are created by the agent so that the source of
+// randomness can be changed in one place.
+func (agent *OnePassAgent) GenPassword(args client.GenPasswordArgs, password *string) error {
+	var err error
+	recipe := args.Recipe
+	if args.Custom != nil {
+		recipe = "custom"
+		*password, err = onepass.GenerateWithRecipe(*args.Custom, args.Length)
+	} else {
+		*password, err = onepass.GenerateFromRecipe(args.Recipe, args.Length)
+	}
+	if err != nil {
+		return err
+	}
+	agent.log.Info("Generated password", "recipe", recipe, "length", len(*password),
+		"purpose", args.Purpose)
+	return nil
+}
+
+// RandomBytes returns count bytes of random data
+func (agent *OnePassAgent) RandomBytes(count int, data *[]byte) error {
+	if count < 0 || count > 1024*1024 {
+		return fmt.Errorf("Invalid byte count %d", count)
+	}
+	*data = onepass.RandomBytes(count)
+	agent.log.Info("Generated random data", "bytes", count)
+	return nil
+}
+
+// GenRecipes returns the available recipes for GenPassword
+func (agent *OnePassAgent) GenRecipes(unused string, recipes *map[string]onepass.GenRecipe) error {
+	*recipes = onepass.GenRecipes
+	return nil
+}
+
+func (agent *OnePassAgent) Unlock(args client.UnlockArgs, ok *bool) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultState := agent.state.vault(args.VaultPath)
+	err := vaultState.checkUnlockAllowed(time.Now())
+	if err != nil {
+		agent.log.Warn("Unlock attempt refused", "vault", args.VaultPath,
+			"failedAttempts", vaultState.FailedUnlocks)
+		return err
+	}
+
+	var keys onepass.KeyDict
+	if args.Profile != "" {
+		keys, err = onepass.UnlockProfileKeys(args.VaultPath, args.Profile, args.MasterPwd)
+	} else {
+		keys, err = onepass.UnlockKeys(args.VaultPath, args.MasterPwd)
+	}
+	onepass.WipeBytes(args.MasterPwd)
+	if _, isDecryptErr := err.(onepass.DecryptError); isDecryptErr {
+		vaultState.recordUnlock(time.Now(), false)
+		agent.saveState()
+	}
+	if err != nil {
+		agent.log.Warn("Unlocking vault failed", "vault", args.VaultPath, "err", err,
+			"failedAttempts", vaultState.FailedUnlocks)
+		return err
+		*ok = false
+	}
+	vaultState.recordUnlock(time.Now(), true)
+	agent.saveState()
+	if previous, unlocked := agent.vaults[args.VaultPath]; unlocked {
+		previous.autoLock.Stop()
+		previous.keys.Wipe()
+	}
+	autoLock := time.AfterFunc(args.ExpireAfter, func() {
+		agent.autoLock(args.VaultPath)
+	})
+	agent.vaults[args.VaultPath] = vaultData{
+		keys:      keys,
+		autoLock:  autoLock,
+		expiresAt: time.Now().Add(args.ExpireAfter),
+		leases:    map[int]bool{},
+	}
+
+	agent.log.Info("Unlocked vault", "vault", args.VaultPath, "profile", args.Profile, "expireAfter", args.ExpireAfter)
+
+	*ok = true
+	return nil
+}
+
+func (agent *OnePassAgent) Lock(vaultPath string, ok *bool) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	if vaultData, unlocked := agent.vaults[vaultPath]; unlocked {
+		vaultData.autoLock.Stop()
+		vaultData.keys.Wipe()
+	}
+	delete(agent.vaults, vaultPath)
+	agent.log.Info("Locked vault", "vault", vaultPath)
+	*ok = true
+	return nil
+}
+
+// autoLock locks a vault when its unlock timeout expires, unless
+// clients hold leases on the vault, in which case the vault is
+// locked after the last lease is released
+func (agent *OnePassAgent) autoLock(vaultPath string) {
+	agent.mu.Lock()
+	vaultData, unlocked := agent.vaults[vaultPath]
+	if unlocked && len(vaultData.leases) > 0 {
+		agent.log.Info("Deferring auto-lock of leased vault", "vault", vaultPath,
+			"leases", len(vaultData.leases))
+		vaultData.lockDeferred = true
+		agent.vaults[vaultPath] = vaultData
+		agent.mu.Unlock()
+		return
+	}
+	agent.mu.Unlock()
+
+	agent.log.Info("Auto-locking vault", "vault", vaultPath)
+	ok := false
+	agent.Lock(vaultPath, &ok)
+}
+
+// releaseLease releases the lease held on a vault by a client
+// connection. agent.mu must be held by the caller.
+func (agent *OnePassAgent) releaseLease(vaultPath string, connId int) {
+	vaultData, unlocked := agent.vaults[vaultPath]
+	if !unlocked || !vaultData.leases[connId] {
+		return
+	}
+	delete(vaultData.leases, connId)
+	agent.log.Debug("Released vault lease", "vault", vaultPath, "conn", connId)
+	if len(vaultData.leases) == 0 && vaultData.lockDeferred {
+		vaultData.lockDeferred = false
+		vaultData.autoLock.Reset(leaseGracePeriod)
+		vaultData.expiresAt = time.Now().Add(leaseGracePeriod)
+		agent.log.Info("Vault will be locked after lease grace period", "vault", vaultPath,
+			"grace", leaseGracePeriod)
+	}
+	agent.vaults[vaultPath] = vaultData
+}
+
+func (agent *OnePassAgent) IsLocked(vaultPath string, locked *bool) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	_, unlocked := agent.vaults[vaultPath]
+	*locked = !unlocked
+	return nil
+}
+
+func (agent *OnePassAgent) RefreshAccess(args client.RefreshArgs, ok *bool) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, unlocked := agent.vaults[args.VaultPath]
+	if !unlocked {
+		return errors.New("Vault is not unlocked")
+	}
+	now := time.Now()
+	expiresAt := agent.state.vault(args.VaultPath).accessExpiry(now, args.ExpireAfter)
+	vaultData.autoLock.Reset(expiresAt.Sub(now))
+	vaultData.expiresAt = expiresAt
+	agent.vaults[args.VaultPath] = vaultData
+	agent.log.Debug("Refreshed vault access", "vault", args.VaultPath, "expireAfter", expiresAt.Sub(now))
+	return nil
+}
+
+// LimitAccess shortens the time before a vault is auto-locked to
+// args.ExpireAfter if more time than that remains. The time
+// remaining is never extended, and later RefreshAccess() calls
+// do not extend access beyond the limit until the vault is
+// next unlocked.
+func (agent *OnePassAgent) LimitAccess(args client.RefreshArgs, ok *bool) error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	vaultData, unlocked := agent.vaults[args.VaultPath]
+	if !unlocked {
+		return errors.New("Vault is not unlocked")
+	}
+	expiresAt := time.Now().Add(args.ExpireAfter)
+	agent.state.vault(args.VaultPath).limitAccess(expiresAt)
+	agent.saveState()
+	if expiresAt.Before(vaultData.expiresAt) {
+		vaultData.autoLock.Reset(args.ExpireAfter)
+		vaultData.expiresAt = expiresAt
+		agent.vaults[args.VaultPath] = vaultData
+		agent.log.Info("Limited vault access", "vault", args.VaultPath, "expireAfter", args.ExpireAfter)
+	}
+	*ok = true
+	return nil
+}
+
+func (agent *OnePassAgent) Info(unused string, info *client.AgentInfo) error {
+	*info = client.AgentInfo{
+		Pid:             os.Getpid(),
+		BinaryVersion:   agentBinaryVersion,
+		ProtocolVersion: client.ProtocolVersion,
+	}
+	return nil
+}
+
+// Run starts an agent which serves clients at the default socket,
+// restoring its state from the default state file. Log entries
+// at or above logLevel are written to logPath, or to stderr if
+// logPath is empty.
+func Run(logPath string, logLevel string) error {
+	level, err := ParseLogLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	agent := NewAgent()
+	if logPath != "" {
+		agent.log, err = OpenLog(logPath, level)
+		if err != nil {
+			return fmt.Errorf("Unable to open agent log file: %v", err)
+		}
+	} else {
+		agent.log = NewStderrLogger(level)
+	}
+	err = agent.LoadState(DefaultStatePath())
+	if err != nil {
+		agent.log.Error("Unable to restore agent state", "err", err)
+	}
+	return agent.Serve()
+}
+
+func (agent *OnePassAgent) Serve() error {
+	return agent.ServeAt(client.DefaultAgentSocket())
+}
+
+func (agent *OnePassAgent) ServeAt(addr string) error {
+	err := os.Remove(addr)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", addr)
+	if err != nil {
+		return err
+	}
+	// the socket is created with permissions from the umask, but
+	// connecting to it gives access to unlocked vaults
+	err = os.Chmod(addr, 0600)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	agent.log.Info("Agent started", "addr", addr, "pid", os.Getpid())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go agent.serveConn(conn)
+	}
+}
+
+// serveConn handles requests from a client connection. Any leases
+// held by the client are released when the connection is closed,
+// including when the client exits without releasing them.
+func (agent *OnePassAgent) serveConn(conn net.Conn) {
+	agent.mu.Lock()
+	agent.lastConnId++
+	service := &agentConn{OnePassAgent: agent, id: agent.lastConnId}
+	agent.mu.Unlock()
+
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterName("OnePassAgent", service)
+	rpcServer.ServeConn(conn)
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	for vaultPath, _ := range agent.vaults {
+		agent.releaseLease(vaultPath, service.id)
+	}
+}
+
+// agentConn is the RPC service for a single client connection.
+// It provides the agent's methods plus methods for leases, which
+// belong to the connection.
+type agentConn struct {
+	*OnePassAgent
+	id int
+}
+
+// AcquireLease prevents a vault from being auto-locked until the
+// lease is released with ReleaseLease() or the client disconnects,
+// so that long-running commands are not interrupted if they take
+// longer than the vault's unlock timeout
+func (conn *agentConn) AcquireLease(vaultPath string, ok *bool) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	vaultData, unlocked := conn.vaults[vaultPath]
+	if !unlocked {
+		return errors.New("Vault is not unlocked")
+	}
+	vaultData.leases[conn.id] = true
+	conn.log.Debug("Acquired vault lease", "vault", vaultPath, "conn", conn.id)
+	*ok = true
+	return nil
+}
+
+func (conn *agentConn) ReleaseLease(vaultPath string, ok *bool) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	conn.releaseLease(vaultPath, conn.id)
+	*ok = true
+	return nil
+}
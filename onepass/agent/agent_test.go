@@ -0,0 +1,309 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+const agentTestPwd = "test-pwd"
+
+func newTestVault(t *testing.T) *onepass.Vault {
+	path := os.TempDir() + "/agent-test.agilekeychain"
+	err := os.RemoveAll(path)
+	if err != nil {
+		t.Fatalf("Failed to create test dir")
+	}
+	security := onepass.VaultSecurity{
+		MasterPwd:  agentTestPwd,
+		Iterations: 100,
+	}
+	vault, err := onepass.NewVault(path, security)
+	if err != nil {
+		t.Fatalf("Unable to create test vault")
+	}
+	return &vault
+}
+
+func fatalTestErr(t *testing.T, msg string, err error) {
+	t.Fatalf("%s : %v", msg, err)
+}
+
+func waitForServer(addr string, d time.Duration) error {
+	timeout := time.After(d)
+	ticker := time.NewTimer(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			conn, err := net.Dial("unix", addr)
+			if err == nil {
+				conn.Close()
+				return nil
+			}
+		case <-timeout:
+			return errors.New("timeout expired")
+		}
+	}
+}
+
+func setupAgent(t *testing.T, vaultPath string) (OnePassAgent, *client.AgentClient) {
+	addr := "agent-test.sock"
+	agent := NewAgent()
+
+	go func() {
+		err := agent.ServeAt(addr)
+		if err != nil {
+			fatalTestErr(t, "Unable to setup agent", err)
+		}
+	}()
+	err := waitForServer(addr, 2*time.Second)
+	if err != nil {
+		fatalTestErr(t, "Unable to dial agent", err)
+	}
+
+	agentClient, err := client.DialAgentAt(vaultPath, addr)
+	if err != nil {
+		fatalTestErr(t, "Unable to dial agent", err)
+	}
+	return agent, agentClient
+}
+
+func TestLockUnlock(t *testing.T) {
+	vault := newTestVault(t)
+	_, client := setupAgent(t, vault.Path)
+
+	isLocked, err := client.IsLocked()
+	if err != nil {
+		fatalTestErr(t, "Unable to test if vault is locked", err)
+	}
+	if !isLocked {
+		t.Errorf("Expected vault to be locked")
+	}
+
+	err = client.Unlock([]byte(agentTestPwd))
+	if err != nil {
+		fatalTestErr(t, "Unable to unlock vault", err)
+	}
+
+	isLocked, err = client.IsLocked()
+	if err != nil {
+		fatalTestErr(t, "Unable to test if vault is locked", err)
+	}
+	if isLocked {
+		t.Errorf("Expected vault to be unlocked")
+	}
+
+	err = client.Lock()
+	if err != nil {
+		fatalTestErr(t, "Unable to lock vault", err)
+	}
+	isLocked, err = client.IsLocked()
+	if err != nil {
+		fatalTestErr(t, "Unable to test if vault is locked", err)
+	}
+	if !isLocked {
+		t.Errorf("Expected vault to be unlocked")
+	}
+}
+
+func TestProtocolVersion(t *testing.T) {
+	vault := newTestVault(t)
+	_, agentClient := setupAgent(t, vault.Path)
+	defer agentClient.Close()
+
+	if agentClient.Info.ProtocolVersion != client.ProtocolVersion {
+		t.Errorf("Expected protocol version %d, got %d", client.ProtocolVersion, agentClient.Info.ProtocolVersion)
+	}
+	if !agentClient.Supports(client.ProtocolVersion) || agentClient.Supports(client.ProtocolVersion+1) {
+		t.Errorf("Unexpected result from Supports()")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	vault := newTestVault(t)
+	_, client := setupAgent(t, vault.Path)
+	err := client.Unlock([]byte(agentTestPwd))
+	if err != nil {
+		fatalTestErr(t, "Unable to unlock vault", err)
+	}
+	data := "hello world"
+	encrypted, err := client.Encrypt("SL5", []byte(data))
+	decrypted, err := client.Decrypt("SL5", encrypted)
+	if string(decrypted) != data {
+		t.Errorf("Decrypted content does not match original. Actual: %s, Expected: %s", string(decrypted), data)
+	}
+}
+
+func TestDecryptBatch(t *testing.T) {
+	vault := newTestVault(t)
+	_, client := setupAgent(t, vault.Path)
+	err := client.Unlock([]byte(agentTestPwd))
+	if err != nil {
+		fatalTestErr(t, "Unable to unlock vault", err)
+	}
+	plainTexts := []string{"first item", "second item"}
+	encrypted := [][]byte{}
+	for _, plainText := range plainTexts {
+		data, err := client.Encrypt("SL5", []byte(plainText))
+		if err != nil {
+			fatalTestErr(t, "Unable to encrypt data", err)
+		}
+		encrypted = append(encrypted, data)
+	}
+	decrypted, err := client.DecryptBatch([]string{"SL5", "SL5"}, encrypted)
+	if err != nil {
+		fatalTestErr(t, "Unable to decrypt data", err)
+	}
+	if len(decrypted) != 2 || string(decrypted[0]) != plainTexts[0] || string(decrypted[1]) != plainTexts[1] {
+		t.Errorf("Decrypted content does not match original: %q", decrypted)
+	}
+}
+
+func TestGenPassword(t *testing.T) {
+	vault := newTestVault(t)
+	_, agentClient := setupAgent(t, vault.Path)
+	password, err := agentClient.GenPassword("pin", 8, "test")
+	if err != nil {
+		fatalTestErr(t, "Unable to generate password", err)
+	}
+	if len(password) != 8 {
+		t.Errorf("Unexpected generated password '%s'", password)
+	}
+	_, err = agentClient.GenPassword("no-such-recipe", 0, "test")
+	if err == nil {
+		t.Errorf("Expected error for unknown recipe")
+	}
+	data, err := agentClient.RandomBytes(32)
+	if err != nil || len(data) != 32 {
+		t.Errorf("Unexpected random data: %v, %v", data, err)
+	}
+
+	// generated passwords are recorded in the log, without the password
+	var logOutput bytes.Buffer
+	agent := NewAgent()
+	agent.log = &Logger{level: LogInfo, out: &logOutput}
+	err = agent.GenPassword(client.GenPasswordArgs{Recipe: "default", Purpose: "new login"}, &password)
+	if err != nil {
+		fatalTestErr(t, "Unable to generate password", err)
+	}
+	entry := logOutput.String()
+	if !strings.Contains(entry, `purpose="new login"`) || strings.Contains(entry, password) {
+		t.Errorf("Unexpected log entry for generated password: %s", entry)
+	}
+}
+
+func TestLeaseDefersAutoLock(t *testing.T) {
+	vault := newTestVault(t)
+	agent := NewAgent()
+	serverConn, clientConn := net.Pipe()
+	served := make(chan bool)
+	go func() {
+		agent.serveConn(serverConn)
+		served <- true
+	}()
+	agentClient, err := client.NewAgentClient(clientConn, vault.Path)
+	if err != nil {
+		fatalTestErr(t, "Unable to connect to agent", err)
+	}
+
+	var ok bool
+	err = agent.Unlock(client.UnlockArgs{
+		VaultPath:   vault.Path,
+		MasterPwd:   []byte(agentTestPwd),
+		ExpireAfter: 10 * time.Millisecond,
+	}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to unlock vault", err)
+	}
+	err = agentClient.AcquireLease()
+	if err != nil {
+		fatalTestErr(t, "Unable to acquire lease", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	isLocked, err := agentClient.IsLocked()
+	if err != nil {
+		fatalTestErr(t, "Unable to test if vault is locked", err)
+	}
+	if isLocked {
+		t.Errorf("Expected leased vault to stay unlocked")
+	}
+
+	// closing the connection releases the lease and the
+	// vault is locked after the grace period
+	agentClient.Close()
+	<-served
+	agent.mu.Lock()
+	vaultData := agent.vaults[vault.Path]
+	agent.mu.Unlock()
+	if len(vaultData.leases) != 0 || vaultData.lockDeferred {
+		t.Errorf("Expected lease to be released when the client disconnects")
+	}
+	agent.Lock(vault.Path, &ok)
+}
+
+func TestLimitAccess(t *testing.T) {
+	vault := newTestVault(t)
+	agent := NewAgent()
+	var ok bool
+	err := agent.Unlock(client.UnlockArgs{
+		VaultPath:   vault.Path,
+		MasterPwd:   []byte(agentTestPwd),
+		ExpireAfter: time.Hour,
+	}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to unlock vault", err)
+	}
+
+	// limiting access never extends the time remaining
+	err = agent.LimitAccess(client.RefreshArgs{VaultPath: vault.Path, ExpireAfter: 2 * time.Hour}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to limit vault access", err)
+	}
+	if remaining := time.Until(agent.vaults[vault.Path].expiresAt); remaining <= time.Minute*59 || remaining > time.Hour {
+		t.Errorf("Unexpected time remaining after limit: %v", remaining)
+	}
+
+	err = agent.LimitAccess(client.RefreshArgs{VaultPath: vault.Path, ExpireAfter: 10 * time.Millisecond}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to limit vault access", err)
+	}
+	// refreshing access does not extend it beyond the limit
+	err = agent.RefreshAccess(client.RefreshArgs{VaultPath: vault.Path, ExpireAfter: time.Hour}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to refresh vault access", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	var locked bool
+	agent.IsLocked(vault.Path, &locked)
+	if !locked {
+		t.Errorf("Expected vault to be locked once the limited period expired")
+	}
+
+	// the limit is removed when the vault is next unlocked
+	err = agent.Unlock(client.UnlockArgs{
+		VaultPath:   vault.Path,
+		MasterPwd:   []byte(agentTestPwd),
+		ExpireAfter: time.Hour,
+	}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to unlock vault", err)
+	}
+	err = agent.RefreshAccess(client.RefreshArgs{VaultPath: vault.Path, ExpireAfter: time.Hour}, &ok)
+	if err != nil {
+		fatalTestErr(t, "Unable to refresh vault access", err)
+	}
+	if remaining := time.Until(agent.vaults[vault.Path].expiresAt); remaining <= time.Minute*59 {
+		t.Errorf("Unexpected time remaining after unlocking again: %v", remaining)
+	}
+	agent.Lock(vault.Path, &ok)
+}
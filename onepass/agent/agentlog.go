@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// size at which the agent log file is rotated
+const maxAgentLogSize = 1024 * 1024
+
+// number of rotated log files which are kept
+const maxAgentLogBackups = 3
+
+// LogLevel is the minimum severity of
+// entries written to the agent's log
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+// ParseLogLevel returns the log level with the given
+// name, one of "debug", "info", "warn" or "error"
+func ParseLogLevel(name string) (LogLevel, error) {
+	if name == "" {
+		return LogInfo, nil
+	}
+	for level, levelName := range logLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return LogLevel(level), nil
+		}
+	}
+	return LogInfo, fmt.Errorf("Unknown log level '%s'", name)
+}
+
+// DefaultLogPath returns the path of the agent's log file,
+// following the XDG base directory conventions
+func DefaultLogPath() string {
+	return defaultAgentStateDir() + "/agent.log"
+}
+
+// Logger writes log entries in logfmt format
+// (key=value pairs) to a file which is rotated when
+// it exceeds maxAgentLogSize
+type Logger struct {
+	mu    sync.Mutex // protects all fields
+	level LogLevel
+	path  string
+	out   io.Writer
+	file  *os.File
+	size  int64
+}
+
+// NewStderrLogger returns a logger which writes to stderr
+func NewStderrLogger(level LogLevel) *Logger {
+	return &Logger{level: level, out: os.Stderr}
+}
+
+// OpenLog returns a logger which appends to the file at path
+func OpenLog(path string, level LogLevel) (*Logger, error) {
+	logger := &Logger{level: level, path: path}
+	err := logger.open()
+	if err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+func (logger *Logger) open() error {
+	err := os.MkdirAll(filepath.Dir(logger.path), 0700)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(logger.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	logger.file = file
+	logger.out = file
+	logger.size = info.Size()
+	return nil
+}
+
+// rotate renames the current log file to <path>.1, shifting
+// older backups along, and opens a new log file
+func (logger *Logger) rotate() error {
+	logger.file.Close()
+	for i := maxAgentLogBackups - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", logger.path, i), fmt.Sprintf("%s.%d", logger.path, i+1))
+	}
+	err := os.Rename(logger.path, logger.path+".1")
+	if err != nil {
+		return err
+	}
+	return logger.open()
+}
+
+func logfmtValue(value interface{}) string {
+	str := fmt.Sprintf("%v", value)
+	if str == "" || strings.ContainsAny(str, " =\"\t\n") {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+// log writes an entry with a message and a list of alternating
+// keys and values
+func (logger *Logger) log(level LogLevel, msg string, keyvals ...interface{}) {
+	if level < logger.level {
+		return
+	}
+	entry := fmt.Sprintf("time=%s level=%s msg=%s", time.Now().Format(time.RFC3339),
+		logLevelNames[level], logfmtValue(msg))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		entry += fmt.Sprintf(" %s=%s", keyvals[i], logfmtValue(keyvals[i+1]))
+	}
+	entry += "\n"
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+
+	if logger.file != nil && logger.size+int64(len(entry)) > maxAgentLogSize {
+		err := logger.rotate()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to rotate agent log: %v\n", err)
+			logger.out = os.Stderr
+			logger.file = nil
+		}
+	}
+	n, _ := io.WriteString(logger.out, entry)
+	logger.size += int64(n)
+}
+
+func (logger *Logger) Debug(msg string, keyvals ...interface{}) {
+	logger.log(LogDebug, msg, keyvals...)
+}
+
+func (logger *Logger) Info(msg string, keyvals ...interface{}) {
+	logger.log(LogInfo, msg, keyvals...)
+}
+
+func (logger *Logger) Warn(msg string, keyvals ...interface{}) {
+	logger.log(LogWarn, msg, keyvals...)
+}
+
+func (logger *Logger) Error(msg string, keyvals ...interface{}) {
+	logger.log(LogError, msg, keyvals...)
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAgentLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "1pass-agentlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "agent.log")
+	logger, err := OpenLog(path, LogInfo)
+	if err != nil {
+		t.Fatalf("Unable to open log: %v", err)
+	}
+	logger.Debug("hidden")
+	logger.Info("Unlocked vault", "vault", "/path/with space")
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if strings.Contains(content, "hidden") {
+		t.Errorf("Debug entry should not be logged at info level: %s", content)
+	}
+	if !strings.Contains(content, `level=info msg="Unlocked vault" vault="/path/with space"`) {
+		t.Errorf("Unexpected log entry: %s", content)
+	}
+
+	logger.size = maxAgentLogSize
+	logger.Warn("after rotation")
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("Expected log to be rotated: %v", err)
+	}
+	data, _ = ioutil.ReadFile(path)
+	if !strings.HasPrefix(string(data), "time=") || strings.Contains(string(data), "Unlocked vault") {
+		t.Errorf("Unexpected content after rotation: %s", data)
+	}
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/robertknight/1pass/jsonutil"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+// number of consecutive failed unlock attempts for a vault
+// after which further attempts are refused for a period
+const maxFailedUnlocks = 5
+
+// initial and maximum period for which unlock attempts are
+// refused once maxFailedUnlocks has been reached. The period
+// doubles with each further failed attempt.
+const unlockLockoutPeriod = 30 * time.Second
+const maxUnlockLockoutPeriod = 15 * time.Minute
+
+// agentVaultState holds the non-secret state which the agent
+// records about a vault. Keys are never persisted.
+type agentVaultState struct {
+	LastUnlocked   time.Time
+	FailedUnlocks  int
+	LockedOutUntil time.Time
+
+	// time after which access to the vault was limited by
+	// LimitAccess(). RefreshAccess() does not extend access
+	// beyond this until the vault is next unlocked.
+	LimitedUntil time.Time `json:",omitempty"`
+}
+
+// agentState is the agent state which is persisted across
+// restarts of the agent
+type agentState struct {
+	Vaults map[string]*agentVaultState
+}
+
+func defaultAgentStateDir() string {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		stateDir = os.Getenv("HOME") + "/.local/state"
+	}
+	return stateDir + "/1pass"
+}
+
+// DefaultStatePath returns the path of the file where
+// the agent's persistent state is saved
+func DefaultStatePath() string {
+	return defaultAgentStateDir() + "/agent-state.json"
+}
+
+func newAgentState() agentState {
+	return agentState{Vaults: map[string]*agentVaultState{}}
+}
+
+// readAgentState reads the agent state from path. If the
+// file does not exist, an empty state is returned.
+func readAgentState(path string) (agentState, error) {
+	state := newAgentState()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return newAgentState(), fmt.Errorf("Unable to parse agent state: %v", err)
+	}
+	if state.Vaults == nil {
+		state.Vaults = map[string]*agentVaultState{}
+	}
+	return state, nil
+}
+
+// writeAgentState saves the agent state to path. The state is
+// written to a temporary file which then replaces the existing
+// file so that a crash part-way through leaves the previous
+// state intact.
+func writeAgentState(path string, state agentState) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return jsonutil.WritePrivateFileAtomic(path, state)
+}
+
+func (state *agentState) vault(path string) *agentVaultState {
+	vaultState, ok := state.Vaults[path]
+	if !ok {
+		vaultState = &agentVaultState{}
+		state.Vaults[path] = vaultState
+	}
+	return vaultState
+}
+
+// checkUnlockAllowed returns an error if unlock attempts for
+// the vault are currently refused
+func (vaultState *agentVaultState) checkUnlockAllowed(now time.Time) error {
+	if now.Before(vaultState.LockedOutUntil) {
+		wait := vaultState.LockedOutUntil.Sub(now)
+		return fmt.Errorf("%s, try again in %v", client.UnlockLockoutErr, wait-wait%time.Second+time.Second)
+	}
+	return nil
+}
+
+// limitAccess records that access to the vault has been
+// limited until expiresAt, unless it is already limited
+// to an earlier time
+func (vaultState *agentVaultState) limitAccess(expiresAt time.Time) {
+	if vaultState.LimitedUntil.IsZero() || expiresAt.Before(vaultState.LimitedUntil) {
+		vaultState.LimitedUntil = expiresAt
+	}
+}
+
+// accessExpiry returns the time when access to the vault
+// should expire if it is refreshed at now for expireAfter,
+// taking into account any limit set by limitAccess()
+func (vaultState *agentVaultState) accessExpiry(now time.Time, expireAfter time.Duration) time.Time {
+	expiresAt := now.Add(expireAfter)
+	if !vaultState.LimitedUntil.IsZero() && vaultState.LimitedUntil.Before(expiresAt) {
+		return vaultState.LimitedUntil
+	}
+	return expiresAt
+}
+
+// recordUnlock updates the vault state after an unlock attempt
+func (vaultState *agentVaultState) recordUnlock(now time.Time, success bool) {
+	if success {
+		vaultState.LastUnlocked = now
+		vaultState.FailedUnlocks = 0
+		vaultState.LockedOutUntil = time.Time{}
+		vaultState.LimitedUntil = time.Time{}
+		return
+	}
+	vaultState.FailedUnlocks++
+	if vaultState.FailedUnlocks >= maxFailedUnlocks {
+		period := unlockLockoutPeriod
+		for i := maxFailedUnlocks; i < vaultState.FailedUnlocks && period < maxUnlockLockoutPeriod; i++ {
+			period *= 2
+		}
+		if period > maxUnlockLockoutPeriod {
+			period = maxUnlockLockoutPeriod
+		}
+		vaultState.LockedOutUntil = now.Add(period)
+	}
+}
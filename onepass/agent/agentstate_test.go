@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAgentStatePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "1pass-agentstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent-state.json")
+
+	state, err := readAgentState(path)
+	if err != nil || len(state.Vaults) != 0 {
+		t.Fatalf("Expected empty state for missing file, got %v, %v", state, err)
+	}
+
+	now := time.Now()
+	vaultState := state.vault("/tmp/test.agilekeychain")
+	for i := 0; i < maxFailedUnlocks; i++ {
+		vaultState.recordUnlock(now, false)
+	}
+	err = writeAgentState(path, state)
+	if err != nil {
+		t.Fatalf("Unable to save state: %v", err)
+	}
+
+	state, err = readAgentState(path)
+	if err != nil {
+		t.Fatalf("Unable to read state: %v", err)
+	}
+	vaultState = state.vault("/tmp/test.agilekeychain")
+	if vaultState.FailedUnlocks != maxFailedUnlocks {
+		t.Errorf("Expected %d failed unlocks, got %d", maxFailedUnlocks, vaultState.FailedUnlocks)
+	}
+	if vaultState.checkUnlockAllowed(now) == nil {
+		t.Errorf("Expected unlock to be refused after restoring state")
+	}
+	if vaultState.checkUnlockAllowed(now.Add(unlockLockoutPeriod)) != nil {
+		t.Errorf("Expected unlock to be allowed after lockout period")
+	}
+
+	vaultState.recordUnlock(now, false)
+	if vaultState.LockedOutUntil.Sub(now) != 2*unlockLockoutPeriod {
+		t.Errorf("Expected lockout period to double, got %v", vaultState.LockedOutUntil.Sub(now))
+	}
+	vaultState.recordUnlock(now, true)
+	if vaultState.FailedUnlocks != 0 || vaultState.checkUnlockAllowed(now) != nil {
+		t.Errorf("Expected successful unlock to reset failed attempts")
+	}
+}
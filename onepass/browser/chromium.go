@@ -0,0 +1,184 @@
+package browser
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/robertknight/1pass/jsonutil"
+	"github.com/robertknight/1pass/onepass"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Chromium-based browsers store saved logins in a SQLite database
+// named 'Login Data' in the profile directory. On Linux and macOS,
+// passwords are encrypted with AES-128-CBC using a key derived from a
+// 'Safe Storage' password kept in the platform keyring. On Windows,
+// passwords are encrypted with AES-256-GCM using a key from the
+// 'Local State' file, which is itself protected with DPAPI.
+
+// password used by Chromium on Linux when no keyring is available
+const chromiumFallbackPassword = "peanuts"
+
+// chromiumKeys holds the keys for decrypting passwords
+// with the 'v10' and 'v11' version prefixes
+type chromiumKeys struct {
+	v10 []byte
+	v11 []byte
+
+	// on Windows, the AES-256-GCM key for 'v10' values, which
+	// is used instead of v10
+	v10Gcm []byte
+	// on Windows, decrypts values without a version prefix,
+	// which older versions of Chromium protected with DPAPI
+	unprotect func(data []byte) ([]byte, error)
+}
+
+// ImportChromium reads the saved logins from a Chromium or Chrome
+// 'Login Data' file, decrypting passwords using the 'Safe Storage' password
+// from the platform keyring. This uses 'secret-tool' (libsecret) on Linux
+// and the 'security' tool (Keychain) on macOS. On Windows, the key is read
+// from the 'Local State' file in the browser's user data directory, the
+// parent of the profile directory containing loginDataPath, and decrypted
+// with DPAPI, which only works for the Windows user who owns the profile.
+func ImportChromium(loginDataPath string) ([]onepass.ExportedItem, error) {
+	browser := "chrome"
+	if strings.Contains(strings.ToLower(loginDataPath), "chromium") {
+		browser = "chromium"
+	}
+	keys, err := readChromiumKeys(loginDataPath, browser)
+	if err != nil {
+		return nil, err
+	}
+	return importChromiumLogins(loginDataPath, keys)
+}
+
+func chromiumKey(password string, iterations int) []byte {
+	return pbkdf2.Key([]byte(password), []byte("saltysalt"), iterations, 16, sha1.New)
+}
+
+// readChromiumLocalStateKey returns the encrypted key for 'v10' values
+// on Windows from the 'Local State' file for the profile containing
+// loginDataPath. The returned key must be decrypted with DPAPI.
+func readChromiumLocalStateKey(loginDataPath string) ([]byte, error) {
+	path := filepath.Join(filepath.Dir(filepath.Dir(loginDataPath)), "Local State")
+	var localState struct {
+		OsCrypt struct {
+			EncryptedKey []byte `json:"encrypted_key"`
+		} `json:"os_crypt"`
+	}
+	err := jsonutil.ReadFile(path, &localState)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read '%s': %v", path, err)
+	}
+	key := localState.OsCrypt.EncryptedKey
+	if !bytes.HasPrefix(key, []byte("DPAPI")) {
+		return nil, fmt.Errorf("'%s' does not contain a DPAPI-protected key", path)
+	}
+	return key[len("DPAPI"):], nil
+}
+
+func importChromiumLogins(loginDataPath string, keys chromiumKeys) ([]onepass.ExportedItem, error) {
+	db, err := sql.Open("sqlite3", "file:"+loginDataPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// from version 24 of the database, the decrypted value
+	// is prefixed with a SHA-256 hash of the login's domain
+	var versionStr string
+	_ = db.QueryRow("SELECT value FROM meta WHERE key = 'version'").Scan(&versionStr)
+	dbVersion, _ := strconv.Atoi(versionStr)
+
+	rows, err := db.Query(`SELECT origin_url, username_element, username_value,
+		password_element, password_value FROM logins WHERE blacklisted_by_user = 0`)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read logins: %v", err)
+	}
+	defer rows.Close()
+
+	items := []onepass.ExportedItem{}
+	for rows.Next() {
+		var originUrl, usernameElement, username, passwordElement string
+		var encryptedPassword []byte
+		err = rows.Scan(&originUrl, &usernameElement, &username, &passwordElement, &encryptedPassword)
+		if err != nil {
+			return nil, err
+		}
+		password, err := decryptChromiumValue(keys, encryptedPassword)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decrypt password for '%s': %v", originUrl, err)
+		}
+		if dbVersion >= 24 && len(password) >= 32 {
+			password = password[32:]
+		}
+
+		content := onepass.NewLoginContent(username, string(password), originUrl)
+		if usernameElement != "" {
+			content.FormFields[0].Name = usernameElement
+			content.FormFields[0].Id = usernameElement
+		}
+		if passwordElement != "" {
+			content.FormFields[1].Name = passwordElement
+			content.FormFields[1].Id = passwordElement
+		}
+
+		title := originUrl
+		if parsedUrl, err := url.Parse(originUrl); err == nil && parsedUrl.Host != "" {
+			title = parsedUrl.Host
+		}
+		items = append(items, onepass.NewImportedItem(title, content, nil))
+	}
+	return items, rows.Err()
+}
+
+func decryptChromiumValue(keys chromiumKeys, value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+	var key []byte
+	switch {
+	case bytes.HasPrefix(value, []byte("v10")) && keys.v10Gcm != nil:
+		return decryptChromiumGcm(keys.v10Gcm, value[3:])
+	case bytes.HasPrefix(value, []byte("v10")):
+		key = keys.v10
+	case bytes.HasPrefix(value, []byte("v11")):
+		key = keys.v11
+	case keys.unprotect != nil:
+		return keys.unprotect(value)
+	default:
+		return nil, errors.New("Unsupported encryption version")
+	}
+	if key == nil {
+		return nil, errors.New("Safe Storage password not found in keyring")
+	}
+	iv := bytes.Repeat([]byte{' '}, aes.BlockSize)
+	return decryptCbc(aes.NewCipher, key, iv, value[3:])
+}
+
+// decryptChromiumGcm decrypts a value encrypted with AES-256-GCM, which
+// consists of a 12-byte nonce followed by the ciphertext and tag
+func decryptChromiumGcm(key []byte, value []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) < gcm.NonceSize() {
+		return nil, errors.New("Encrypted value is too short")
+	}
+	return gcm.Open(nil, value[:gcm.NonceSize()], value[gcm.NonceSize():], nil)
+}
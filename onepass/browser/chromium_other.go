@@ -0,0 +1,39 @@
+//go:build !windows
+// +build !windows
+
+package browser
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// readChromiumKeys returns the keys for decrypting passwords in
+// a 'Login Data' file, using the 'Safe Storage' password for browser
+// from the platform keyring
+func readChromiumKeys(loginDataPath string, browser string) (chromiumKeys, error) {
+	var keys chromiumKeys
+	switch runtime.GOOS {
+	case "linux":
+		keys.v10 = chromiumKey(chromiumFallbackPassword, 1)
+		output, err := exec.Command("secret-tool", "lookup", "application", browser).Output()
+		if err == nil {
+			keys.v11 = chromiumKey(strings.TrimSpace(string(output)), 1)
+		}
+	case "darwin":
+		service := "Chrome Safe Storage"
+		if browser == "chromium" {
+			service = "Chromium Safe Storage"
+		}
+		output, err := exec.Command("security", "find-generic-password", "-w", "-s", service).Output()
+		if err != nil {
+			return keys, fmt.Errorf("Unable to read '%s' password from Keychain: %v", service, err)
+		}
+		keys.v10 = chromiumKey(strings.TrimSpace(string(output)), 1003)
+	default:
+		return keys, fmt.Errorf("Importing Chromium passwords is not supported on %s", runtime.GOOS)
+	}
+	return keys, nil
+}
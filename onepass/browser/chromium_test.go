@@ -0,0 +1,133 @@
+package browser
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestImportChromium(t *testing.T) {
+	requireSqlite(t)
+	tmpDir, err := ioutil.TempDir("", "1pass-chromium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	dbPath := tmpDir + "/Login Data"
+
+	keys := chromiumKeys{v10: chromiumKey(chromiumFallbackPassword, 1), v11: chromiumKey("keyring-pwd", 1)}
+	encrypt := func(version string, key []byte, value string) []byte {
+		block, _ := aes.NewCipher(key)
+		iv := bytes.Repeat([]byte{' '}, aes.BlockSize)
+		return append([]byte(version), encryptCbc(block, iv, []byte(value))...)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)",
+		"INSERT INTO meta VALUES ('version', '23')",
+		`CREATE TABLE logins (origin_url VARCHAR NOT NULL, username_element VARCHAR,
+			username_value VARCHAR, password_element VARCHAR, password_value BLOB,
+			blacklisted_by_user INTEGER NOT NULL)`,
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = db.Exec("INSERT INTO logins VALUES (?, ?, ?, ?, ?, 0)", "https://github.com/login",
+		"login", "alice", "pass", encrypt("v11", keys.v11, "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO logins VALUES (?, ?, ?, ?, ?, 0)", "https://example.com/",
+		"", "bob", "", encrypt("v10", keys.v10, "pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	items, err := importChromiumLogins(dbPath, keys)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	github := items[0].SecureContents
+	if items[0].Title != "github.com" || github.FormFields[1].Value != "secret" {
+		t.Errorf("Unexpected item: %s %v", items[0].Title, github.FormFields)
+	}
+	if github.FormFields[0].Name != "login" || github.FormFields[0].Designation != "username" {
+		t.Errorf("Form field names not imported: %v", github.FormFields)
+	}
+	if items[1].SecureContents.FormFields[1].Value != "pwd" {
+		t.Errorf("v10 password not decrypted: %v", items[1].SecureContents.FormFields)
+	}
+}
+
+func TestDecryptChromiumWindowsValues(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "1pass-chromium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the key in 'Local State' is protected with DPAPI,
+	// which is replaced here by inverting the bits
+	unprotect := func(data []byte) ([]byte, error) {
+		result := make([]byte, len(data))
+		for i, b := range data {
+			result[i] = ^b
+		}
+		return result, nil
+	}
+	gcmKey := randomBytes(32)
+	protectedKey, _ := unprotect(gcmKey)
+	localState := fmt.Sprintf(`{"os_crypt":{"encrypted_key":"%s"}}`,
+		base64.StdEncoding.EncodeToString(append([]byte("DPAPI"), protectedKey...)))
+	err = os.MkdirAll(tmpDir+"/User Data/Default", 0700)
+	if err == nil {
+		err = ioutil.WriteFile(tmpDir+"/User Data/Local State", []byte(localState), 0600)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedKey, err := readChromiumLocalStateKey(tmpDir + "/User Data/Default/Login Data")
+	if err != nil {
+		t.Fatalf("Unable to read key from Local State: %v", err)
+	}
+	keys := chromiumKeys{unprotect: unprotect}
+	keys.v10Gcm, _ = unprotect(encryptedKey)
+	if !bytes.Equal(keys.v10Gcm, gcmKey) {
+		t.Fatalf("Unexpected key from Local State")
+	}
+
+	block, _ := aes.NewCipher(gcmKey)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := randomBytes(gcm.NonceSize())
+	value := append([]byte("v10"), gcm.Seal(nonce, nonce, []byte("secret"), nil)...)
+	legacyValue, _ := unprotect([]byte("legacy-secret"))
+
+	for _, test := range []struct {
+		value    []byte
+		password string
+	}{
+		{value, "secret"},
+		{legacyValue, "legacy-secret"},
+	} {
+		password, err := decryptChromiumValue(keys, test.value)
+		if err != nil || string(password) != test.password {
+			t.Errorf("Unexpected password %q, %v", password, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = kernel32.NewProc("LocalFree")
+)
+
+// DATA_BLOB structure used by the DPAPI functions
+type dataBlob struct {
+	size uint32
+	data *byte
+}
+
+// readChromiumKeys returns the keys for decrypting passwords in
+// a 'Login Data' file, using the key from the profile's 'Local State'
+// file, decrypted with DPAPI
+func readChromiumKeys(loginDataPath string, browser string) (chromiumKeys, error) {
+	keys := chromiumKeys{unprotect: dpapiUnprotect}
+	encryptedKey, err := readChromiumLocalStateKey(loginDataPath)
+	if err != nil {
+		return keys, err
+	}
+	keys.v10Gcm, err = dpapiUnprotect(encryptedKey)
+	if err != nil {
+		return keys, fmt.Errorf("Unable to decrypt the key from 'Local State': %v", err)
+	}
+	return keys, nil
+}
+
+// dpapiUnprotect decrypts data protected with DPAPI for
+// the current Windows user using CryptUnprotectData()
+func dpapiUnprotect(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("No data to decrypt")
+	}
+	in := dataBlob{size: uint32(len(data)), data: &data[0]}
+	var out dataBlob
+	result, _, err := procCryptUnprotectData.Call(uintptr(unsafe.Pointer(&in)), 0, 0, 0, 0, 0,
+		uintptr(unsafe.Pointer(&out)))
+	if result == 0 {
+		return nil, fmt.Errorf("CryptUnprotectData failed: %v", err)
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(out.data)))
+	return append([]byte{}, unsafe.Slice(out.data, out.size)...), nil
+}
@@ -0,0 +1,286 @@
+package browser
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/robertknight/1pass/onepass"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Firefox stores saved logins in logins.json in the profile
+// directory. The username and password of each login are encrypted
+// with a key stored in the NSS key database (key4.db), which is itself
+// encrypted with a key derived from the user's primary password.
+
+var (
+	oidPbeSha1TripleDes = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 5, 1, 3}
+	oidPbes2            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPbkdf2           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHmacSha256       = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidTripleDesCbc     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAes256Cbc        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// ErrIncorrectPassword is returned by ImportFirefox() if
+// the primary password is incorrect
+var ErrIncorrectPassword = errors.New("Incorrect primary password")
+
+// key ID of the key used to encrypt saved logins
+var firefoxLoginKeyId = []byte{0xf8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+
+// password-based encrypted data stored in key4.db
+type nssPbeData struct {
+	Algorithm  pkix.AlgorithmIdentifier
+	CipherText []byte
+}
+
+// parameters for pbeWithSha1AndTripleDES-CBC
+type nssPbeSha1Params struct {
+	Salt       []byte
+	Iterations int
+}
+
+// parameters for PBES2 with PBKDF2 and AES-256-CBC
+type nssPbes2Params struct {
+	Kdf    pkix.AlgorithmIdentifier
+	Cipher pkix.AlgorithmIdentifier
+}
+
+type nssPbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	Prf        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// encrypted username or password in logins.json
+type nssEncryptedValue struct {
+	KeyId      []byte
+	Algorithm  pkix.AlgorithmIdentifier
+	CipherText []byte
+}
+
+type firefoxLogins struct {
+	Logins []struct {
+		Hostname          string `json:"hostname"`
+		EncryptedUsername string `json:"encryptedUsername"`
+		EncryptedPassword string `json:"encryptedPassword"`
+	} `json:"logins"`
+}
+
+// ImportFirefox reads the saved logins from a Firefox profile
+// directory, decrypting them using the keys in the profile's key4.db
+// file and the primary password, which is empty if the user has
+// not set one. The result is returned as a list of Login items.
+func ImportFirefox(profileDir string, primaryPassword string) ([]onepass.ExportedItem, error) {
+	key, err := firefoxLoginKey(profileDir+"/key4.db", []byte(primaryPassword))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(profileDir + "/logins.json")
+	if err != nil {
+		return nil, err
+	}
+	var logins firefoxLogins
+	err = json.Unmarshal(data, &logins)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read Firefox export: %v", err)
+	}
+
+	items := []onepass.ExportedItem{}
+	for _, login := range logins.Logins {
+		username, err := decryptFirefoxValue(key, login.EncryptedUsername)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decrypt username for '%s': %v", login.Hostname, err)
+		}
+		password, err := decryptFirefoxValue(key, login.EncryptedPassword)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decrypt password for '%s': %v", login.Hostname, err)
+		}
+		title := login.Hostname
+		if parsedUrl, err := url.Parse(login.Hostname); err == nil && parsedUrl.Host != "" {
+			title = parsedUrl.Host
+		}
+		content := onepass.NewLoginContent(string(username), string(password), login.Hostname)
+		items = append(items, onepass.NewImportedItem(title, content, nil))
+	}
+	return items, nil
+}
+
+// firefoxLoginKey reads the key used to encrypt saved logins
+// from a key4.db file
+func firefoxLoginKey(keyDbPath string, primaryPassword []byte) ([]byte, error) {
+	db, err := sql.Open("sqlite3", "file:"+keyDbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var globalSalt, passwordCheck []byte
+	err = db.QueryRow("SELECT item1, item2 FROM metaData WHERE id = 'password'").Scan(&globalSalt, &passwordCheck)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read key database: %v", err)
+	}
+	check, err := decryptNssPbe(globalSalt, primaryPassword, passwordCheck)
+	if err != nil || !bytes.HasPrefix(check, []byte("password-check")) {
+		return nil, ErrIncorrectPassword
+	}
+
+	rows, err := db.Query("SELECT a11, a102 FROM nssPrivate")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read keys: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var encryptedKey, keyId []byte
+		err = rows.Scan(&encryptedKey, &keyId)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(keyId, firefoxLoginKeyId) {
+			continue
+		}
+		return decryptNssPbe(globalSalt, primaryPassword, encryptedKey)
+	}
+	return nil, errors.New("Login encryption key not found in key database")
+}
+
+// decryptNssPbe decrypts data encrypted with a key derived from the
+// primary password and global salt
+func decryptNssPbe(globalSalt []byte, primaryPassword []byte, data []byte) ([]byte, error) {
+	var pbeData nssPbeData
+	_, err := asn1.Unmarshal(data, &pbeData)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash := sha1.Sum(append(append([]byte{}, globalSalt...), primaryPassword...))
+	algorithm := pbeData.Algorithm.Algorithm
+	switch {
+	case algorithm.Equal(oidPbeSha1TripleDes):
+		var params nssPbeSha1Params
+		_, err = asn1.Unmarshal(pbeData.Algorithm.Parameters.FullBytes, &params)
+		if err != nil {
+			return nil, err
+		}
+		key, iv := nssSha1TripleDesKey(passwordHash[:], params.Salt)
+		return decryptCbc(des.NewTripleDESCipher, key, iv, pbeData.CipherText)
+	case algorithm.Equal(oidPbes2):
+		var params nssPbes2Params
+		_, err = asn1.Unmarshal(pbeData.Algorithm.Parameters.FullBytes, &params)
+		if err != nil {
+			return nil, err
+		}
+		var kdfParams nssPbkdf2Params
+		_, err = asn1.Unmarshal(params.Kdf.Parameters.FullBytes, &kdfParams)
+		if err != nil {
+			return nil, err
+		}
+		var ivSuffix []byte
+		_, err = asn1.Unmarshal(params.Cipher.Parameters.FullBytes, &ivSuffix)
+		if err != nil {
+			return nil, err
+		}
+		if !params.Kdf.Algorithm.Equal(oidPbkdf2) || !params.Cipher.Algorithm.Equal(oidAes256Cbc) {
+			return nil, fmt.Errorf("Unsupported PBES2 algorithms %v, %v", params.Kdf.Algorithm, params.Cipher.Algorithm)
+		}
+		key := pbkdf2.Key(passwordHash[:], kdfParams.Salt, kdfParams.Iterations, 32, sha256.New)
+		// NSS stores only the last 14 bytes of the IV, the DER
+		// header for an OCTET STRING of length 14 forms the first two
+		iv := append([]byte{0x04, 0x0e}, ivSuffix...)
+		return decryptCbc(aes.NewCipher, key, iv, pbeData.CipherText)
+	default:
+		return nil, fmt.Errorf("Unsupported key encryption algorithm %v", algorithm)
+	}
+}
+
+// nssSha1TripleDesKey derives the key and IV for
+// pbeWithSha1AndTripleDES-CBC as implemented by NSS
+func nssSha1TripleDesKey(passwordHash []byte, entrySalt []byte) (key []byte, iv []byte) {
+	paddedSalt := make([]byte, 20)
+	copy(paddedSalt, entrySalt)
+	combinedHash := sha1.Sum(append(append([]byte{}, passwordHash...), entrySalt...))
+
+	hmacSha1 := func(data ...[]byte) []byte {
+		mac := hmac.New(sha1.New, combinedHash[:])
+		for _, chunk := range data {
+			mac.Write(chunk)
+		}
+		return mac.Sum(nil)
+	}
+	k1 := hmacSha1(paddedSalt, entrySalt)
+	tk := hmacSha1(paddedSalt)
+	k2 := hmacSha1(tk, entrySalt)
+	k := append(k1, k2...)
+	return k[:24], k[len(k)-8:]
+}
+
+// decryptFirefoxValue decrypts a base64-encoded username or
+// password from logins.json
+func decryptFirefoxValue(key []byte, encoded string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var value nssEncryptedValue
+	_, err = asn1.Unmarshal(data, &value)
+	if err != nil {
+		return nil, err
+	}
+	var iv []byte
+	_, err = asn1.Unmarshal(value.Algorithm.Parameters.FullBytes, &iv)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case value.Algorithm.Algorithm.Equal(oidTripleDesCbc):
+		if len(key) < 24 {
+			return nil, errors.New("Login key too short")
+		}
+		return decryptCbc(des.NewTripleDESCipher, key[:24], iv, value.CipherText)
+	case value.Algorithm.Algorithm.Equal(oidAes256Cbc):
+		if len(key) < 32 {
+			return nil, errors.New("Login key too short")
+		}
+		return decryptCbc(aes.NewCipher, key[:32], iv, value.CipherText)
+	default:
+		return nil, fmt.Errorf("Unsupported login encryption algorithm %v", value.Algorithm.Algorithm)
+	}
+}
+
+// decryptCbc decrypts PKCS#7-padded data encrypted in CBC mode
+// using a block cipher created by newCipher
+func decryptCbc(newCipher func(key []byte) (cipher.Block, error), key []byte, iv []byte,
+	cipherText []byte) ([]byte, error) {
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(cipherText) == 0 || len(cipherText)%block.BlockSize() != 0 {
+		return nil, errors.New("Invalid encrypted data length")
+	}
+	plainText := make([]byte, len(cipherText))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plainText, cipherText)
+	paddingLen := int(plainText[len(plainText)-1])
+	if paddingLen == 0 || paddingLen > block.BlockSize() {
+		return nil, errors.New("Invalid padding")
+	}
+	return plainText[:len(plainText)-paddingLen], nil
+}
@@ -0,0 +1,143 @@
+package browser
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func encryptCbc(block cipher.Block, iv []byte, plainText []byte) []byte {
+	paddingLen := block.BlockSize() - len(plainText)%block.BlockSize()
+	for i := 0; i < paddingLen; i++ {
+		plainText = append(plainText, byte(paddingLen))
+	}
+	cipherText := make([]byte, len(plainText))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText, plainText)
+	return cipherText
+}
+
+func rawParams(t *testing.T, v interface{}) asn1.RawValue {
+	data, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return asn1.RawValue{FullBytes: data}
+}
+
+// encrypts data in the PBES2 format used by
+// recent versions of NSS
+func encryptNssPbes2(t *testing.T, globalSalt []byte, password []byte, data []byte) []byte {
+	salt := randomBytes(32)
+	ivSuffix := randomBytes(14)
+	passwordHash := sha1.Sum(append(append([]byte{}, globalSalt...), password...))
+	key := pbkdf2.Key(passwordHash[:], salt, 10, 32, sha256.New)
+	block, _ := aes.NewCipher(key)
+	cipherText := encryptCbc(block, append([]byte{0x04, 0x0e}, ivSuffix...), data)
+
+	params := nssPbes2Params{
+		Kdf: pkix.AlgorithmIdentifier{
+			Algorithm: oidPbkdf2,
+			Parameters: rawParams(t, nssPbkdf2Params{
+				Salt: salt, Iterations: 10, KeyLength: 32,
+				Prf: pkix.AlgorithmIdentifier{Algorithm: oidHmacSha256},
+			}),
+		},
+		Cipher: pkix.AlgorithmIdentifier{Algorithm: oidAes256Cbc, Parameters: rawParams(t, ivSuffix)},
+	}
+	der, err := asn1.Marshal(nssPbeData{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidPbes2, Parameters: rawParams(t, params)},
+		CipherText: cipherText,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func encryptFirefoxValue(t *testing.T, key []byte, value string) string {
+	iv := randomBytes(8)
+	block, _ := des.NewTripleDESCipher(key[:24])
+	der, err := asn1.Marshal(nssEncryptedValue{
+		KeyId:      firefoxLoginKeyId,
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidTripleDesCbc, Parameters: rawParams(t, iv)},
+		CipherText: encryptCbc(block, iv, []byte(value)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func TestImportFirefox(t *testing.T) {
+	requireSqlite(t)
+	profileDir, err := ioutil.TempDir("", "1pass-firefox-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(profileDir)
+
+	primaryPwd := []byte("primary-pwd")
+	globalSalt := randomBytes(20)
+	loginKey := randomBytes(24)
+
+	db, err := sql.Open("sqlite3", profileDir+"/key4.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE metaData (id PRIMARY KEY UNIQUE ON CONFLICT REPLACE, item1, item2)",
+		"CREATE TABLE nssPrivate (id PRIMARY KEY UNIQUE ON CONFLICT ABORT, a11, a102)",
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = db.Exec("INSERT INTO metaData VALUES ('password', ?, ?)", globalSalt,
+		encryptNssPbes2(t, globalSalt, primaryPwd, []byte("password-check\x02\x02")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO nssPrivate VALUES (1, ?, ?)",
+		encryptNssPbes2(t, globalSalt, primaryPwd, loginKey), firefoxLoginKeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	logins := fmt.Sprintf(`{"logins": [{"hostname": "https://github.com",
+		"encryptedUsername": "%s", "encryptedPassword": "%s"}]}`,
+		encryptFirefoxValue(t, loginKey, "alice"), encryptFirefoxValue(t, loginKey, "secret"))
+	err = ioutil.WriteFile(profileDir+"/logins.json", []byte(logins), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ImportFirefox(profileDir, "wrong-pwd")
+	if err != ErrIncorrectPassword {
+		t.Errorf("Expected incorrect password error with wrong password, got %v", err)
+	}
+
+	items, err := ImportFirefox(profileDir, string(primaryPwd))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "github.com" {
+		t.Fatalf("Unexpected items: %v", items)
+	}
+	content := items[0].SecureContents
+	if content.FormFields[0].Value != "alice" || content.FormFields[1].Value != "secret" {
+		t.Errorf("Credentials not decrypted correctly: %v", content.FormFields)
+	}
+}
@@ -0,0 +1,67 @@
+// Package browser imports saved logins and reads browsing history
+// from the profiles of web browsers. Browsers keep these in SQLite
+// databases, so this is separate from the onepass package in order
+// that programs which do not need it, such as the agent, do not link
+// the cgo SQLite driver.
+package browser
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// ReadHistory reads the browsing history from a Firefox
+// 'places.sqlite' file, a Chrome or Chromium 'History' file or a CSV
+// file read with onepass.ReadCsvHistory().
+//
+// SQLite databases are opened read-only without locking, so the
+// history can be read while the browser is running.
+func ReadHistory(path string) ([]onepass.HistoryEntry, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return onepass.ReadCsvHistory(path)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&immutable=1")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Firefox stores history in 'moz_places' and
+	// Chromium-based browsers in 'urls'
+	var query string
+	for _, table := range []string{"moz_places", "urls"} {
+		var name string
+		err = db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&name)
+		if err == nil {
+			query = fmt.Sprintf("SELECT url, visit_count FROM %s WHERE visit_count > 0", table)
+			break
+		} else if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("Unable to read history database: %v", err)
+		}
+	}
+	if query == "" {
+		return nil, fmt.Errorf("'%s' is not a Firefox or Chromium history database", path)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read history database: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []onepass.HistoryEntry{}
+	for rows.Next() {
+		var entry onepass.HistoryEntry
+		err = rows.Scan(&entry.Url, &entry.Visits)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,57 @@
+package browser
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"os"
+	"testing"
+)
+
+func randomBytes(count int) []byte {
+	data := make([]byte, count)
+	rand.Read(data)
+	return data
+}
+
+// requireSqlite skips tests which use SQLite databases if the
+// driver is unavailable, as it is in builds without cgo
+func requireSqlite(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err == nil {
+		err = db.Ping()
+		db.Close()
+	}
+	if err != nil {
+		t.Skipf("SQLite is not available: %v", err)
+	}
+}
+
+func TestReadHistory(t *testing.T) {
+	requireSqlite(t)
+	dbPath := os.TempDir() + "/places.sqlite"
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, visit_count INTEGER DEFAULT 0)",
+		"INSERT INTO moz_places (url, visit_count) VALUES ('https://github.com/login', 4)",
+		"INSERT INTO moz_places (url, visit_count) VALUES ('https://example.com/', 0)",
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	entries, err := ReadHistory(dbPath)
+	if err != nil {
+		t.Fatalf("Unable to read history: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Url != "https://github.com/login" || entries[0].Visits != 4 {
+		t.Errorf("Unexpected history entries: %v", entries)
+	}
+}
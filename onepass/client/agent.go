@@ -0,0 +1,419 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// period after which the agent locks a vault if
+// access to it is not refreshed
+const DefaultUnlockDelay = 2 * time.Minute
+
+// version of the RPC protocol spoken by this client and by agents
+// built from the same source. Version 1 added the ProtocolVersion
+// field of AgentInfo and LimitAccess(). Version 2 added custom
+// recipes to GenPasswordArgs. Version 3 added passphrase recipes.
+// Agents which do not report a version use version 0.
+const ProtocolVersion = 3
+
+// oldest version of the protocol which this client can use.
+// Methods added in later versions are not called on older agents.
+const MinProtocolVersion = 0
+
+// prefix of the error returned by the agent when an unlock
+// attempt is refused due to too many failed attempts
+const UnlockLockoutErr = "Too many failed unlock attempts"
+
+// AgentClient is a client for the 1pass agent's RPC service, which
+// holds the keys for unlocked vaults. AgentClient implements
+// onepass.CryptoAgent so that it can be used as a vault's CryptoAgent.
+type AgentClient struct {
+	rpcClient *rpc.Client
+	VaultPath string
+	// profile of the vault whose keys are unlocked by Unlock().
+	// If empty, the profile chosen by onepass.OpenVault() is used.
+	// The agent holds the keys for one profile of a vault at a time.
+	Profile string
+	Info    AgentInfo
+
+	// period after which the agent locks the vault if access to
+	// it is not refreshed. Defaults to DefaultUnlockDelay
+	ExpireAfter time.Duration
+}
+
+type CryptArgs struct {
+	VaultPath string
+	KeyName   string
+	Data      []byte
+}
+
+type BatchCryptArgs struct {
+	VaultPath string
+	KeyNames  []string
+	Data      [][]byte
+}
+
+type DeriveArgs struct {
+	VaultPath string
+	KeyName   string
+	Recipe    onepass.PasswordRecipe
+}
+
+type GenPasswordArgs struct {
+	// name of a recipe from onepass.GenRecipes
+	Recipe string
+	// if set, used instead of Recipe
+	Custom *onepass.GenRecipe
+	// length of the password, or zero for the recipe's default
+	Length int
+	// description of what the password is for, which is
+	// recorded in the agent's log
+	Purpose string
+}
+
+type UnlockArgs struct {
+	VaultPath string
+	Profile   string
+	// the master password, which is zeroed by the
+	// agent once the vault has been unlocked
+	MasterPwd   []byte
+	ExpireAfter time.Duration
+}
+
+type RefreshArgs struct {
+	VaultPath   string
+	ExpireAfter time.Duration
+}
+
+type AgentInfo struct {
+	BinaryVersion   time.Time
+	Pid             int
+	ProtocolVersion int
+}
+
+// AgentConfig specifies how to connect to the 1pass agent
+// and how to start it if it is not already running
+type AgentConfig struct {
+	// path of the agent's socket. Defaults to DefaultAgentSocket()
+	Socket string
+
+	// command which starts the agent, eg. ["1pass", "-agent"].
+	// If empty, ConnectAgent() fails if the agent is not running.
+	// If set, a running agent whose binary version does not match
+	// the command's binary is shut down and restarted, unless
+	// ReuseCompatible is set.
+	Command []string
+
+	// if set, a running agent started by a different binary is
+	// used as long as it supports MinProtocolVersion, rather than
+	// being restarted. This allows the agent to be installed and
+	// upgraded separately from programs which use it.
+	ReuseCompatible bool
+
+	// if set, messages about restarting the agent are written to Log
+	Log io.Writer
+}
+
+// DefaultAgentSocket returns the path of the socket
+// which the agent listens on by default
+func DefaultAgentSocket() string {
+	return os.ExpandEnv("$HOME/.1pass.sock")
+}
+
+// BinaryVersion returns the version of the binary at path,
+// which is used to detect agents started by older binaries
+func BinaryVersion(path string) time.Time {
+	binInfo, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return binInfo.ModTime()
+}
+
+func (client *AgentClient) Encrypt(keyName string, in []byte) ([]byte, error) {
+	var cipherText []byte
+	err := client.rpcClient.Call("OnePassAgent.Encrypt", CryptArgs{
+		VaultPath: client.VaultPath,
+		KeyName:   keyName,
+		Data:      in,
+	}, &cipherText)
+	return cipherText, err
+}
+
+func (client *AgentClient) Decrypt(keyName string, in []byte) ([]byte, error) {
+	var plainText []byte
+	err := client.rpcClient.Call("OnePassAgent.Decrypt", CryptArgs{
+		VaultPath: client.VaultPath,
+		KeyName:   keyName,
+		Data:      in,
+	}, &plainText)
+	return plainText, err
+}
+
+func (client *AgentClient) DecryptBatch(keyNames []string, in [][]byte) ([][]byte, error) {
+	var plainTexts [][]byte
+	err := client.rpcClient.Call("OnePassAgent.DecryptBatch", BatchCryptArgs{
+		VaultPath: client.VaultPath,
+		KeyNames:  keyNames,
+		Data:      in,
+	}, &plainTexts)
+	return plainTexts, err
+}
+
+func (client *AgentClient) Sign(keyName string, data []byte) ([]byte, error) {
+	var signature []byte
+	err := client.rpcClient.Call("OnePassAgent.Sign", CryptArgs{
+		VaultPath: client.VaultPath,
+		KeyName:   keyName,
+		Data:      data,
+	}, &signature)
+	return signature, err
+}
+
+func (client *AgentClient) DerivePassword(keyName string, recipe onepass.PasswordRecipe) (string, error) {
+	var password string
+	err := client.rpcClient.Call("OnePassAgent.DerivePassword", DeriveArgs{
+		VaultPath: client.VaultPath,
+		KeyName:   keyName,
+		Recipe:    recipe,
+	}, &password)
+	return password, err
+}
+
+func (client *AgentClient) GenPassword(recipe string, length int, purpose string) (string, error) {
+	var password string
+	err := client.rpcClient.Call("OnePassAgent.GenPassword", GenPasswordArgs{
+		Recipe:  recipe,
+		Length:  length,
+		Purpose: purpose,
+	}, &password)
+	return password, err
+}
+
+// GenPasswordWithRecipe generates a password using a recipe which
+// is not one of the named recipes in onepass.GenRecipes
+func (client *AgentClient) GenPasswordWithRecipe(recipe onepass.GenRecipe, length int, purpose string) (string, error) {
+	if !client.Supports(2) {
+		return "", fmt.Errorf("The agent is too old to generate passwords with custom recipes. Run 'lock' to restart it")
+	}
+	if recipe.Words > 0 && !client.Supports(3) {
+		return "", fmt.Errorf("The agent is too old to generate passphrases. Run 'lock' to restart it")
+	}
+	var password string
+	err := client.rpcClient.Call("OnePassAgent.GenPassword", GenPasswordArgs{
+		Custom:  &recipe,
+		Length:  length,
+		Purpose: purpose,
+	}, &password)
+	return password, err
+}
+
+func (client *AgentClient) RandomBytes(count int) ([]byte, error) {
+	var data []byte
+	err := client.rpcClient.Call("OnePassAgent.RandomBytes", count, &data)
+	return data, err
+}
+
+func (client *AgentClient) GenRecipes() (map[string]onepass.GenRecipe, error) {
+	var recipes map[string]onepass.GenRecipe
+	err := client.rpcClient.Call("OnePassAgent.GenRecipes", "" /* unused */, &recipes)
+	return recipes, err
+}
+
+func (client *AgentClient) Unlock(masterPwd []byte) error {
+	var ok bool
+	err := client.rpcClient.Call("OnePassAgent.Unlock", UnlockArgs{
+		VaultPath:   client.VaultPath,
+		Profile:     client.Profile,
+		MasterPwd:   masterPwd,
+		ExpireAfter: client.expireAfter(),
+	}, &ok)
+	if err != nil && !ok {
+		if strings.HasPrefix(err.Error(), UnlockLockoutErr) {
+			return err
+		}
+		return onepass.DecryptError{}
+	}
+	return err
+}
+
+func (client *AgentClient) Lock() error {
+	var unused bool
+	err := client.rpcClient.Call("OnePassAgent.Lock", client.VaultPath, &unused)
+	return err
+}
+
+func (client *AgentClient) IsLocked() (bool, error) {
+	var locked bool
+	err := client.rpcClient.Call("OnePassAgent.IsLocked", client.VaultPath, &locked)
+	if err != nil {
+		return true, err
+	}
+	return locked, nil
+}
+
+func (client *AgentClient) RefreshAccess() error {
+	var ok bool
+	err := client.rpcClient.Call("OnePassAgent.RefreshAccess", RefreshArgs{
+		VaultPath:   client.VaultPath,
+		ExpireAfter: client.expireAfter(),
+	}, &ok)
+	return err
+}
+
+// LimitAccess shortens the time before the agent locks the vault
+// to at most maxRemaining. Unlike RefreshAccess(), it never extends
+// the time remaining. It is used before commands which reveal or
+// export secrets, so that the vault does not stay unlocked for long
+// afterwards.
+func (client *AgentClient) LimitAccess(maxRemaining time.Duration) error {
+	if !client.Supports(1) {
+		return fmt.Errorf("The agent is too old to limit access to the vault. Run 'lock' to restart it")
+	}
+	var ok bool
+	return client.rpcClient.Call("OnePassAgent.LimitAccess", RefreshArgs{
+		VaultPath:   client.VaultPath,
+		ExpireAfter: maxRemaining,
+	}, &ok)
+}
+
+func (client *AgentClient) expireAfter() time.Duration {
+	if client.ExpireAfter <= 0 {
+		return DefaultUnlockDelay
+	}
+	return client.ExpireAfter
+}
+
+// AcquireLease prevents the vault from being auto-locked while
+// a long-running operation is in progress. The lease lasts until
+// it is released with ReleaseLease() or the connection to the
+// agent is closed, including when the client exits.
+func (client *AgentClient) AcquireLease() error {
+	var ok bool
+	return client.rpcClient.Call("OnePassAgent.AcquireLease", client.VaultPath, &ok)
+}
+
+// ReleaseLease releases a lease acquired with AcquireLease()
+func (client *AgentClient) ReleaseLease() error {
+	var ok bool
+	return client.rpcClient.Call("OnePassAgent.ReleaseLease", client.VaultPath, &ok)
+}
+
+// Supports returns true if the agent speaks version 'version'
+// of the RPC protocol or later
+func (client *AgentClient) Supports(version int) bool {
+	return client.Info.ProtocolVersion >= version
+}
+
+func (client *AgentClient) AgentInfo() (AgentInfo, error) {
+	var info AgentInfo
+	err := client.rpcClient.Call("OnePassAgent.Info", "" /* unused */, &info)
+	if err != nil {
+		return AgentInfo{}, err
+	}
+	return info, nil
+}
+
+// Close closes the connection to the agent
+func (client *AgentClient) Close() error {
+	return client.rpcClient.Close()
+}
+
+func DialAgent(vaultPath string) (*AgentClient, error) {
+	return DialAgentAt(vaultPath, DefaultAgentSocket())
+}
+
+func DialAgentAt(vaultPath string, sock string) (*AgentClient, error) {
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+	return NewAgentClient(conn, vaultPath)
+}
+
+// NewAgentClient returns a client which talks to an agent over
+// an existing connection
+func NewAgentClient(conn io.ReadWriteCloser, vaultPath string) (*AgentClient, error) {
+	rpcClient := rpc.NewClient(conn)
+	client := &AgentClient{
+		rpcClient: rpcClient,
+		VaultPath: vaultPath,
+	}
+	agentInfo, err := client.AgentInfo()
+	if err != nil {
+		rpcClient.Close()
+		return nil, err
+	}
+	if agentInfo.ProtocolVersion < MinProtocolVersion {
+		rpcClient.Close()
+		return nil, fmt.Errorf("The agent uses protocol version %d but at least version %d is required",
+			agentInfo.ProtocolVersion, MinProtocolVersion)
+	}
+	client.Info = agentInfo
+	return client, nil
+}
+
+// ConnectAgent connects to the 1pass agent for the vault at vaultPath.
+// If config.Command is set, the agent is started if it is not already
+// running and restarted if it was started by a different version of
+// the binary, unless config.ReuseCompatible is set.
+func ConnectAgent(vaultPath string, config AgentConfig) (*AgentClient, error) {
+	sock := config.Socket
+	if sock == "" {
+		sock = DefaultAgentSocket()
+	}
+	if len(config.Command) == 0 {
+		return DialAgentAt(vaultPath, sock)
+	}
+
+	binaryPath := config.Command[0]
+	if lookedUp, err := exec.LookPath(binaryPath); err == nil {
+		binaryPath = lookedUp
+	}
+	agentClient, err := DialAgentAt(vaultPath, sock)
+	if err == nil && agentClient.Info.BinaryVersion != BinaryVersion(binaryPath) && !config.ReuseCompatible {
+		if agentClient.Info.Pid != 0 {
+			if config.Log != nil {
+				fmt.Fprintf(config.Log, "Agent/client version mismatch. Restarting agent.\n")
+			}
+			agentClient.Close()
+			// kill the existing agent
+			err = syscall.Kill(agentClient.Info.Pid, syscall.SIGINT)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to shut down existing agent: %v", err)
+			}
+			agentClient = nil
+		}
+	}
+	if agentClient == nil {
+		agentCmd := exec.Command(binaryPath, config.Command[1:]...)
+		// run the agent in its own session so that it is not
+		// killed by SIGHUP when the client's terminal closes
+		agentCmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		err = agentCmd.Start()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to start 1pass keychain agent: %v", err)
+		}
+		maxWait := time.Now().Add(1 * time.Second)
+		for time.Now().Before(maxWait) {
+			agentClient, err = DialAgentAt(vaultPath, sock)
+			if err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return agentClient, nil
+}
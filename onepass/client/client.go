@@ -0,0 +1,194 @@
+// Package client provides a high-level API for reading and
+// updating items in a 1Password vault, using the 1pass agent
+// to hold the vault's keys while it is unlocked.
+//
+// A typical program opens a vault, unlocks it and then
+// looks up items:
+//
+//	c, err := client.Open(vaultPath, client.AgentConfig{Command: []string{"1pass", "-agent"}})
+//	...
+//	err = c.Unlock(masterPwd)
+//	...
+//	item, content, err := c.Get("github")
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// Client provides access to the items in a vault
+type Client struct {
+	Vault *onepass.Vault
+	Agent *AgentClient
+}
+
+// Open opens the vault at vaultPath and connects to the agent,
+// starting it if necessary. See ConnectAgent()
+func Open(vaultPath string, config AgentConfig) (*Client, error) {
+	vault, err := onepass.OpenVault(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	return connect(vault, config)
+}
+
+// OpenProfile is like Open() but uses the named profile
+// of the vault. See onepass.VaultProfiles()
+func OpenProfile(vaultPath string, profile string, config AgentConfig) (*Client, error) {
+	vault, err := onepass.OpenVaultProfile(vaultPath, profile)
+	if err != nil {
+		return nil, err
+	}
+	return connect(vault, config)
+}
+
+func connect(vault onepass.Vault, config AgentConfig) (*Client, error) {
+	agent, err := ConnectAgent(vault.Path, config)
+	if err != nil {
+		return nil, err
+	}
+	agent.Profile = vault.Profile
+	vault.CryptoAgent = agent
+	return &Client{Vault: &vault, Agent: agent}, nil
+}
+
+// IsLocked returns true if the agent does not currently
+// hold the keys for the vault
+func (client *Client) IsLocked() (bool, error) {
+	return client.Agent.IsLocked()
+}
+
+// Unlock unlocks the vault using the master password, if it is not
+// already unlocked, and resets the agent's auto-lock timer. If the
+// password is incorrect, a onepass.DecryptError is returned.
+func (client *Client) Unlock(masterPwd []byte) error {
+	locked, err := client.Agent.IsLocked()
+	if err != nil {
+		return err
+	}
+	if locked {
+		err = client.Agent.Unlock(masterPwd)
+		if err != nil {
+			return err
+		}
+	}
+	return client.Agent.RefreshAccess()
+}
+
+// Lock removes the vault's keys from the agent
+func (client *Client) Lock() error {
+	return client.Agent.Lock()
+}
+
+// Close closes the connection to the agent. The vault
+// remains unlocked until the agent's auto-lock timer expires.
+func (client *Client) Close() error {
+	return client.Agent.Close()
+}
+
+// MatchItems returns the items whose title contains pattern or whose
+// UUID starts with pattern, ignoring case. If pattern contains '*' or
+// '?' wildcards, it must match the whole title instead, using the
+// syntax of path.Match(). If typeName is not empty, only items of that
+// type are returned.
+//
+// If pattern is the alias of an item, only that item is returned.
+func MatchItems(items []onepass.Item, pattern string, typeName string) []onepass.Item {
+	if pattern != "" {
+		for _, item := range items {
+			if strings.EqualFold(item.OpenContents.Alias, pattern) &&
+				(typeName == "" || item.TypeName == typeName) {
+				return []onepass.Item{item}
+			}
+		}
+	}
+	patternLower := strings.ToLower(pattern)
+	isWildcard := strings.ContainsAny(pattern, "*?")
+	matches := []onepass.Item{}
+	for _, item := range items {
+		patternMatch := pattern == ""
+		typeMatch := typeName == "" || item.TypeName == typeName
+
+		if isWildcard {
+			if match, _ := path.Match(patternLower, strings.ToLower(item.Title)); match {
+				patternMatch = true
+			}
+		} else if strings.Contains(strings.ToLower(item.Title), patternLower) ||
+			strings.HasPrefix(strings.ToLower(item.Uuid), patternLower) {
+			patternMatch = true
+		}
+
+		if patternMatch && typeMatch {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
+
+// Search returns the items in the vault which match pattern.
+// Removed items are not included. See MatchItems()
+func (client *Client) Search(pattern string) ([]onepass.Item, error) {
+	items, err := client.Vault.ListItems()
+	if err != nil {
+		return nil, err
+	}
+	matches := []onepass.Item{}
+	for _, item := range MatchItems(items, pattern, "") {
+		if item.TypeName != "system.Tombstone" {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// Get returns the item matching pattern and its decrypted
+// content. An error is returned unless exactly one item matches.
+func (client *Client) Get(pattern string) (onepass.Item, onepass.ItemContent, error) {
+	items, err := client.Search(pattern)
+	if err != nil {
+		return onepass.Item{}, onepass.ItemContent{}, err
+	}
+	if len(items) == 0 {
+		return onepass.Item{}, onepass.ItemContent{}, fmt.Errorf("No items match '%s'", pattern)
+	}
+	if len(items) > 1 {
+		return onepass.Item{}, onepass.ItemContent{}, fmt.Errorf("Multiple items match '%s'", pattern)
+	}
+	content, err := items[0].Content()
+	if err != nil {
+		return onepass.Item{}, onepass.ItemContent{}, err
+	}
+	return items[0], content, nil
+}
+
+// Put saves an item with the given content. If item.Uuid is empty,
+// a new item is added to the vault and item is updated with its UUID.
+// Content is checked with onepass.ValidateContent() before saving.
+func (client *Client) Put(item *onepass.Item, content onepass.ItemContent) error {
+	err := onepass.ValidateContent(item.TypeName, content)
+	if err != nil {
+		return err
+	}
+	if item.Uuid == "" {
+		newItem, err := client.Vault.AddItem(item.Title, item.TypeName, content)
+		if err != nil {
+			return err
+		}
+		*item = newItem
+		return nil
+	}
+	err = item.SetContent(content)
+	if err != nil {
+		return err
+	}
+	return item.Save()
+}
+
+// Delete permanently removes an item from the vault
+func (client *Client) Delete(item *onepass.Item) error {
+	return item.Remove()
+}
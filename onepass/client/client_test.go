@@ -0,0 +1,107 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func newTestClient(t *testing.T) *Client {
+	path := os.TempDir() + "/client-test.agilekeychain"
+	err := os.RemoveAll(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	security := onepass.VaultSecurity{MasterPwd: "test-pwd", Iterations: 100}
+	vault, err := onepass.NewVault(path, security)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vault.Unlock([]byte(security.MasterPwd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{Vault: &vault}
+}
+
+func TestMatchItems(t *testing.T) {
+	items := []onepass.Item{
+		{Title: "GitHub", Uuid: "AB12", TypeName: "webforms.WebForm"},
+		{Title: "GitLab", Uuid: "CD34", TypeName: "webforms.WebForm"},
+		{Title: "Git notes", Uuid: "EF56", TypeName: "securenotes.SecureNote"},
+		{Title: "Work GitHub", Uuid: "AB78", TypeName: "webforms.WebForm",
+			OpenContents: onepass.ItemOpenContents{Alias: "gh"}},
+	}
+	testCases := []struct {
+		pattern  string
+		typeName string
+		matches  int
+	}{
+		{"git", "", 4},
+		{"GITHUB", "", 2},
+		{"cd", "", 1},
+		{"ab", "", 3},
+		{"git", "securenotes.SecureNote", 1},
+		{"", "webforms.WebForm", 3},
+		{"bitbucket", "", 0},
+		{"git*", "", 3},
+		{"git?ab", "", 1},
+		{"*notes", "", 1},
+		{"hub*", "", 0},
+		{"GH", "", 1},
+		{"gh", "securenotes.SecureNote", 0},
+	}
+	for _, testCase := range testCases {
+		matches := MatchItems(items, testCase.pattern, testCase.typeName)
+		if len(matches) != testCase.matches {
+			t.Errorf("Expected %d matches for '%s', got %d", testCase.matches, testCase.pattern, len(matches))
+		}
+	}
+}
+
+func TestPutGetDelete(t *testing.T) {
+	client := newTestClient(t)
+
+	item := onepass.Item{Title: "Example", TypeName: "webforms.WebForm"}
+	err := client.Put(&item, onepass.NewLoginContent("alice", "secret", "https://example.com"))
+	if err != nil {
+		t.Fatalf("Unable to add item: %v", err)
+	}
+	if item.Uuid == "" {
+		t.Errorf("New item was not given a UUID")
+	}
+
+	found, content, err := client.Get("exam")
+	if err != nil {
+		t.Fatalf("Unable to get item: %v", err)
+	}
+	if found.Uuid != item.Uuid || content.Password() != "secret" {
+		t.Errorf("Unexpected item %v with password '%s'", found, content.Password())
+	}
+
+	content.SetPassword("new-secret")
+	err = client.Put(&found, content)
+	if err != nil {
+		t.Fatalf("Unable to update item: %v", err)
+	}
+	_, content, _ = client.Get(item.Uuid)
+	if content.Password() != "new-secret" {
+		t.Errorf("Password was not updated")
+	}
+
+	invalid := onepass.Item{Title: "Invalid", TypeName: "no.such.Type"}
+	err = client.Put(&invalid, onepass.ItemContent{})
+	if err == nil {
+		t.Errorf("Expected an error adding an item with an unknown type")
+	}
+
+	err = client.Delete(&found)
+	if err != nil {
+		t.Fatalf("Unable to delete item: %v", err)
+	}
+	items, err := client.Search("")
+	if err != nil || len(items) != 0 {
+		t.Errorf("Expected no items after deleting, got %v (%v)", items, err)
+	}
+}
@@ -0,0 +1,143 @@
+package onepass
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"time"
+)
+
+// matches the names of item files which were changed on two computers
+// at once and saved as separate copies by a file syncing service, eg.
+// '<UUID> (Alice's conflicted copy 2014-06-01).1password' for Dropbox
+var conflictedCopyRegex = regexp.MustCompile(`^([0-9A-Fa-f]{32}) \((.*conflicted copy.*)\)\.1password$`)
+
+// ConflictedCopy is a copy of an item file created by a file
+// syncing service such as Dropbox when the item was changed in
+// two copies of the vault before they were synced
+type ConflictedCopy struct {
+	// path of the conflicted copy
+	Path string
+	// the reason for the copy added by the syncing service,
+	// eg. "Alice's conflicted copy 2014-06-01"
+	Label string
+	// the version of the item in the conflicted copy
+	Item Item
+}
+
+// Ways of resolving a conflicted copy. See ResolveConflict()
+const (
+	// keep the current version of the item
+	KeepCurrent = "current"
+	// replace the item with the version in the conflicted copy
+	KeepCopy = "copy"
+	// keep the current version and add the conflicted copy as a new item
+	KeepBoth = "both"
+)
+
+// ConflictedCopies returns the conflicted copies of item files in
+// the vault, sorted by file name. Conflicted copies are not listed
+// in contents.js, so they are not included by ListItems().
+func (vault *Vault) ConflictedCopies() ([]ConflictedCopy, error) {
+	names, err := vault.conflictedCopyNames()
+	if err != nil {
+		return nil, err
+	}
+	conflicts := []ConflictedCopy{}
+	for _, name := range names {
+		conflict := ConflictedCopy{
+			Path:  vault.DataDir() + "/" + name,
+			Label: conflictedCopyRegex.FindStringSubmatch(name)[2],
+		}
+		conflict.Item, err = vault.loadItemFile(conflict.Path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read conflicted copy '%s': %v", name, err)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, nil
+}
+
+// conflictedCopyNames returns the file names of conflicted
+// copies of items in the vault, in sorted order
+func (vault *Vault) conflictedCopyNames() ([]string, error) {
+	dirEntries, err := ioutil.ReadDir(vault.DataDir())
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range dirEntries {
+		if conflictedCopyRegex.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// ResolveConflict resolves a conflicted copy of an item, using one of
+// KeepCurrent, KeepCopy or KeepBoth, and removes the copy. When only
+// one version is kept, any passwords from the other version which
+// differ are added to the kept version's password history so that
+// they are not lost. The vault must be unlocked.
+func (vault *Vault) ResolveConflict(conflict ConflictedCopy, keep string) error {
+	copied := conflict.Item
+	current, err := vault.LoadItem(copied.Uuid)
+	if os.IsNotExist(err) {
+		// the item has been removed from the index
+		// but the conflicted copy remains
+		current = Item{}
+	} else if err != nil {
+		return err
+	}
+
+	switch keep {
+	case KeepCurrent:
+		if current.Uuid == "" {
+			break
+		}
+		var changed bool
+		changed, err = mergePasswordHistory(&current, copied)
+		if err == nil && changed {
+			err = current.Save()
+		}
+	case KeepCopy:
+		if current.Uuid != "" {
+			copied.CreatedAt = current.CreatedAt
+			_, err = mergePasswordHistory(&copied, current)
+		}
+		if err == nil {
+			err = copied.Save()
+		}
+	case KeepBoth:
+		copied.Uuid = newItemId()
+		copied.Title = fmt.Sprintf("%s (%s)", copied.Title, conflict.Label)
+		err = copied.Save()
+	default:
+		return fmt.Errorf("Unknown conflict resolution '%s'", keep)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(conflict.Path)
+}
+
+// mergePasswordHistory adds the passwords in other which differ from
+// those in item to item's password history. Returns true if any
+// passwords were added.
+func mergePasswordHistory(item *Item, other Item) (bool, error) {
+	content, err := item.Content()
+	if err != nil {
+		return false, err
+	}
+	otherContent, err := other.Content()
+	if err != nil {
+		return false, err
+	}
+	historyLen := len(content.PasswordHistory)
+	content.RecordPasswordChanges(otherContent, time.Unix(int64(other.UpdatedAt), 0))
+	if len(content.PasswordHistory) == historyLen {
+		return false, nil
+	}
+	return true, item.SetContent(content)
+}
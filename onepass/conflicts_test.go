@@ -0,0 +1,87 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// writeConflictedCopy saves a copy of item with a different
+// password as a Dropbox conflicted copy
+func writeConflictedCopy(t *testing.T, item Item, password string) {
+	content := newTestContent("example.com")
+	content.FormFields = []WebFormField{{Name: "password", Type: "P", Value: password}}
+	err := item.SetContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.UpdatedAt++
+	path := item.vault.DataDir() + "/" + item.Uuid + " (Alice's conflicted copy 2014-06-01).1password"
+	err = jsonutil.WriteFile(path, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := newTestContent("example.com")
+	content.FormFields = []WebFormField{{Name: "password", Type: "P", Value: "current-pwd"}}
+	item, err := vault.AddItem("Example", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeConflictedCopy(t, item, "copy-pwd")
+	if items, _ := vault.ListItems(); len(items) != 1 {
+		t.Errorf("Expected conflicted copy to be left out of ListItems(), found %d items", len(items))
+	}
+
+	conflicts, err := vault.ConflictedCopies()
+	if err != nil {
+		t.Fatalf("Unable to list conflicted copies: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Item.Uuid != item.Uuid ||
+		conflicts[0].Label != "Alice's conflicted copy 2014-06-01" {
+		t.Fatalf("Unexpected conflicted copies: %+v", conflicts)
+	}
+	problems, err := vault.Verify()
+	if err != nil || len(problems) != 1 || problems[0].Uuid != item.Uuid {
+		t.Errorf("Expected conflicted copy to be reported, got %+v, %v", problems, err)
+	}
+
+	// keeping the copy replaces the item and adds the
+	// current password to the password history
+	err = vault.ResolveConflict(conflicts[0], KeepCopy)
+	if err != nil {
+		t.Fatalf("Unable to resolve conflict: %v", err)
+	}
+	if _, err = os.Stat(conflicts[0].Path); !os.IsNotExist(err) {
+		t.Errorf("Conflicted copy was not removed")
+	}
+	item, _ = vault.LoadItem(item.Uuid)
+	content, _ = item.Content()
+	if content.FormFields[0].Value != "copy-pwd" || len(content.PasswordHistory) != 1 ||
+		content.PasswordHistory[0].Value != "current-pwd" {
+		t.Errorf("Unexpected content after keeping copy: %+v", content)
+	}
+
+	// keeping both versions adds the copy as a new item
+	writeConflictedCopy(t, item, "other-pwd")
+	conflicts, _ = vault.ConflictedCopies()
+	err = vault.ResolveConflict(conflicts[0], KeepBoth)
+	if err != nil {
+		t.Fatalf("Unable to resolve conflict: %v", err)
+	}
+	items, _ := vault.ListItems()
+	if len(items) != 2 {
+		t.Errorf("Expected conflicted copy to be added as a new item, found %d items", len(items))
+	}
+	problems, _ = vault.Verify()
+	if len(problems) != 0 {
+		t.Errorf("Unexpected problems after resolving conflicts: %+v", problems)
+	}
+}
@@ -0,0 +1,150 @@
+package onepass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const derivedPasswordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+const derivedPasswordSymbols = "!#$%&*+-.:=?@^_~"
+
+// PasswordRecipe describes how to derive an item's password from
+// the vault's key instead of storing the password itself.
+// The same recipe always produces the same password for a given
+// vault, so incrementing Counter is used to change the password.
+type PasswordRecipe struct {
+	Site    string `json:"site"`
+	Counter int    `json:"counter"`
+	Length  int    `json:"length"`
+	Symbols bool   `json:"symbols,omitempty"`
+}
+
+// DerivingCryptoAgent is an optional interface for CryptoAgents
+// which can derive passwords from PasswordRecipes, used by
+// Item.DerivedPassword()
+type DerivingCryptoAgent interface {
+	DerivePassword(keyName string, recipe PasswordRecipe) (string, error)
+}
+
+// DerivePassword computes the password for a recipe from a vault key.
+//
+// The password is generated from a stream of HMAC-SHA256 blocks keyed
+// with the vault key, using rejection sampling to pick characters
+// without bias. Candidates which lack a lowercase letter, uppercase
+// letter or digit are discarded, as with GenPassword().
+func DerivePassword(key []byte, recipe PasswordRecipe) (string, error) {
+	if recipe.Length < 4 {
+		return "", fmt.Errorf("Minimum password length is 4 chars")
+	}
+	if recipe.Site == "" {
+		return "", fmt.Errorf("Password recipe has no site")
+	}
+	chars := derivedPasswordChars
+	if recipe.Symbols {
+		chars += derivedPasswordSymbols
+	}
+	// largest multiple of len(chars) which fits in a byte,
+	// bytes at or above this are rejected
+	limit := 256 - 256%len(chars)
+
+	block := uint32(0)
+	stream := []byte{}
+	nextByte := func() byte {
+		if len(stream) == 0 {
+			mac := hmac.New(sha256.New, key)
+			fmt.Fprintf(mac, "1pass-derived-password-v1\x00%s\x00%d\x00", strings.ToLower(recipe.Site), recipe.Counter)
+			binary.Write(mac, binary.BigEndian, block)
+			stream = mac.Sum(nil)
+			block++
+		}
+		b := stream[0]
+		stream = stream[1:]
+		return b
+	}
+
+	for {
+		password := make([]byte, 0, recipe.Length)
+		for len(password) < recipe.Length {
+			b := nextByte()
+			if int(b) < limit {
+				password = append(password, chars[int(b)%len(chars)])
+			}
+		}
+		if hasPasswordCharClasses(string(password)) {
+			return string(password), nil
+		}
+	}
+}
+
+// storeDerivedPassword replaces the password recipe in an item's JSON
+// content with the password computed from it by derive, for copies of
+// the item which are encrypted with different keys. The content is
+// returned unchanged if it has no recipe.
+func storeDerivedPassword(contentJson string, derive func(PasswordRecipe) (string, error)) (string, bool, error) {
+	if !strings.Contains(contentJson, `"passwordRecipe"`) {
+		return contentJson, false, nil
+	}
+	var content ItemContent
+	err := json.Unmarshal([]byte(contentJson), &content)
+	if err != nil || content.PasswordRecipe == nil {
+		return contentJson, false, err
+	}
+	password, err := derive(*content.PasswordRecipe)
+	if err != nil {
+		return "", false, err
+	}
+	if !content.SetPassword(password) {
+		content.FormFields = append(content.FormFields, WebFormField{
+			Name: "password", Type: "P", Designation: "password", Value: password,
+		})
+	}
+	content.PasswordRecipe = nil
+	stored, err := json.Marshal(content)
+	if err != nil {
+		return "", false, err
+	}
+	return string(stored), true, nil
+}
+
+func hasPasswordCharClasses(password string) bool {
+	hasLower := false
+	hasUpper := false
+	hasDigit := false
+	for _, ch := range password {
+		hasLower = hasLower || unicode.IsLower(ch)
+		hasUpper = hasUpper || unicode.IsUpper(ch)
+		hasDigit = hasDigit || unicode.IsDigit(ch)
+	}
+	return hasLower && hasUpper && hasDigit
+}
+
+func (agent *simpleCryptoAgent) DerivePassword(keyName string, recipe PasswordRecipe) (string, error) {
+	key, ok := agent.keys[keyName]
+	if !ok {
+		return "", fmt.Errorf("No such key")
+	}
+	return DerivePassword(key, recipe)
+}
+
+// DerivedPassword computes the item's password from the recipe
+// stored in its content. The vault must be unlocked and its
+// CryptoAgent must implement DerivingCryptoAgent.
+func (item *Item) DerivedPassword() (string, error) {
+	content, err := item.Content()
+	if err != nil {
+		return "", err
+	}
+	if content.PasswordRecipe == nil {
+		return "", fmt.Errorf("Item does not have a derived password")
+	}
+	agent, ok := item.vault.CryptoAgent.(DerivingCryptoAgent)
+	if !ok {
+		return "", fmt.Errorf("Deriving passwords is not supported")
+	}
+	return agent.DerivePassword(item.SecurityLevel, *content.PasswordRecipe)
+}
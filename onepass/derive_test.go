@@ -0,0 +1,97 @@
+package onepass
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDerivePassword(t *testing.T) {
+	key := []byte(strings.Repeat("k", 1024))
+	recipe := PasswordRecipe{Site: "example.com", Counter: 1, Length: 16}
+	password, err := DerivePassword(key, recipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) != 16 || !hasPasswordCharClasses(password) {
+		t.Errorf("Unexpected derived password '%s'", password)
+	}
+
+	// passwords are deterministic, case-insensitive for the site
+	// and change with the counter, site and key
+	if again, _ := DerivePassword(key, PasswordRecipe{Site: "Example.com", Counter: 1, Length: 16}); again != password {
+		t.Errorf("Derived password changed: '%s' != '%s'", again, password)
+	}
+	variants := []PasswordRecipe{
+		{Site: "example.com", Counter: 2, Length: 16},
+		{Site: "example.org", Counter: 1, Length: 16},
+	}
+	for _, variant := range variants {
+		if other, _ := DerivePassword(key, variant); other == password {
+			t.Errorf("Expected different password for %v", variant)
+		}
+	}
+	if other, _ := DerivePassword([]byte(strings.Repeat("x", 1024)), recipe); other == password {
+		t.Errorf("Expected different password for a different key")
+	}
+
+	withSymbols, _ := DerivePassword(key, PasswordRecipe{Site: "example.com", Counter: 1, Length: 64, Symbols: true})
+	if !strings.ContainsAny(withSymbols, derivedPasswordSymbols) {
+		t.Errorf("Expected symbols in password '%s'", withSymbols)
+	}
+
+	if _, err := DerivePassword(key, PasswordRecipe{Site: "example.com", Length: 3}); err == nil {
+		t.Errorf("Expected error for short password")
+	}
+}
+
+func TestItemDerivedPassword(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := newTestContent("https://example.com")
+	content.PasswordRecipe = &PasswordRecipe{Site: "example.com", Counter: 1, Length: 20}
+	item, err := vault.AddItem("Derived", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := item.DerivedPassword()
+	if err != nil {
+		t.Fatalf("Unable to derive password: %v", err)
+	}
+	expected, _ := DerivePassword(vault.CryptoAgent.(*simpleCryptoAgent).keys[item.SecurityLevel], *content.PasswordRecipe)
+	if password != expected {
+		t.Errorf("Derived password '%s' does not match expected '%s'", password, expected)
+	}
+}
+
+func TestRotateKeysStoresDerivedPassword(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := newTestContent("https://example.com")
+	content.PasswordRecipe = &PasswordRecipe{Site: "example.com", Counter: 1, Length: 20}
+	item, err := vault.AddItem("Derived", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := item.DerivedPassword()
+
+	err = vault.RotateKeys([]byte("test-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to rotate keys: %v", err)
+	}
+	vault.Unlock([]byte("test-pwd"))
+	loaded, _ := vault.LoadItem(item.Uuid)
+	loadedContent, err := loaded.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedContent.PasswordRecipe != nil {
+		t.Errorf("Password recipe was not removed after rotating keys")
+	}
+	if loadedContent.Password() != password {
+		t.Errorf("Password changed after rotating keys: '%s' != '%s'", loadedContent.Password(), password)
+	}
+}
@@ -0,0 +1,114 @@
+package onepass
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ItemDiff describes a change to a property or field of an item
+type ItemDiff struct {
+	// name of the property or field, eg. 'title' or 'folder'.
+	// Fields in sections are named '<section title>.<field title>'
+	Field string
+	Old   string
+	New   string
+	// set if the field's values are secret, such as passwords
+	// and notes, in which case Old and New are empty
+	Secret bool
+}
+
+func (diff ItemDiff) String() string {
+	if diff.Secret {
+		return fmt.Sprintf("%s: changed", diff.Field)
+	}
+	return fmt.Sprintf("%s: '%s' -> '%s'", diff.Field, diff.Old, diff.New)
+}
+
+// DiffItems returns the differences between two versions of an
+// item. If the encrypted content differs, the vault must be unlocked
+// to compare the items' fields.
+func DiffItems(old Item, new Item) ([]ItemDiff, error) {
+	diffs := []ItemDiff{}
+	addDiff := func(field string, oldValue string, newValue string, secret bool) {
+		if oldValue == newValue {
+			return
+		}
+		if secret {
+			oldValue, newValue = "", ""
+		}
+		diffs = append(diffs, ItemDiff{Field: field, Old: oldValue, New: newValue, Secret: secret})
+	}
+
+	addDiff("title", old.Title, new.Title, false)
+	addDiff("type", old.TypeName, new.TypeName, false)
+	addDiff("location", old.Location, new.Location, false)
+	addDiff("folder", old.FolderUuid, new.FolderUuid, false)
+	addDiff("trashed", fmt.Sprint(old.Trashed), fmt.Sprint(new.Trashed), false)
+	addDiff("faveIndex", fmt.Sprint(old.FaveIndex), fmt.Sprint(new.FaveIndex), false)
+	addDiff("tags", strings.Join(old.OpenContents.Tags, ", "), strings.Join(new.OpenContents.Tags, ", "), false)
+	addDiff("summaryField", old.OpenContents.SummaryField, new.OpenContents.SummaryField, false)
+	addDiff("alias", old.OpenContents.Alias, new.OpenContents.Alias, false)
+	if string(old.Encrypted) == string(new.Encrypted) {
+		return diffs, nil
+	}
+
+	oldContent, err := old.Content()
+	if err != nil {
+		return nil, err
+	}
+	newContent, err := new.Content()
+	if err != nil {
+		return nil, err
+	}
+	oldFields := contentFields(oldContent)
+	newFields := contentFields(newContent)
+	for _, field := range oldFields {
+		newField, _ := findContentField(newFields, field.name)
+		addDiff(field.name, field.value, newField.value, field.secret || newField.secret)
+	}
+	for _, field := range newFields {
+		if _, ok := findContentField(oldFields, field.name); !ok {
+			addDiff(field.name, "", field.value, field.secret)
+		}
+	}
+	addDiff("notes", oldContent.Notes, newContent.Notes, true)
+	addDiff("privateTags", strings.Join(oldContent.PrivateTags, ", "), strings.Join(newContent.PrivateTags, ", "), false)
+	return diffs, nil
+}
+
+type contentField struct {
+	name   string
+	value  string
+	secret bool
+}
+
+// contentFields returns the named values in an item's content
+// which are compared by DiffItems()
+func contentFields(content ItemContent) []contentField {
+	fields := []contentField{}
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			name := field.Title
+			if section.Title != "" {
+				name = section.Title + "." + field.Title
+			}
+			fields = append(fields, contentField{name, field.ValueString(), field.Kind == "concealed"})
+		}
+	}
+	for _, url := range content.Urls {
+		fields = append(fields, contentField{"URLs." + url.Label, url.Url, false})
+	}
+	for _, field := range content.FormFields {
+		fields = append(fields, contentField{"fields." + field.Name, field.Value, field.Type == "P"})
+	}
+	return fields
+}
+
+func findContentField(fields []contentField, name string) (contentField, bool) {
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return contentField{}, false
+}
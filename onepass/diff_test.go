@@ -0,0 +1,67 @@
+package onepass
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := newTestContent("example.com")
+	content.FormFields = []WebFormField{{Name: "password", Type: "P", Value: "secret"}}
+	item, err := vault.AddItem("Example", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemData, _ := ioutil.ReadFile(item.Path())
+	indexData, _ := ioutil.ReadFile(vault.DataDir() + "/contents.js")
+
+	changes := []ItemChange{}
+	vault.DryRun = true
+	vault.OnItemChange = func(change ItemChange) {
+		changes = append(changes, change)
+	}
+	item.Title = "Renamed"
+	content.FormFields[0].Value = "new-secret"
+	item.SetContent(content)
+	err = item.Save()
+	if err != nil {
+		t.Fatalf("Dry run save failed: %v", err)
+	}
+	added, err := vault.AddItem("Added", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newData, _ := ioutil.ReadFile(item.Path()); string(newData) != string(itemData) {
+		t.Errorf("Item file was changed during dry run")
+	}
+	if newData, _ := ioutil.ReadFile(vault.DataDir() + "/contents.js"); string(newData) != string(indexData) {
+		t.Errorf("Index was changed during dry run")
+	}
+	if _, err = vault.LoadItem(added.Uuid); err == nil {
+		t.Errorf("Item was added during dry run")
+	}
+
+	if len(changes) != 2 || changes[0].Action != ItemUpdated || changes[1].Action != ItemAdded {
+		t.Fatalf("Unexpected changes: %+v", changes)
+	}
+	if changes[0].Title != "Example" {
+		t.Errorf("Expected change to report the saved title, got %s", changes[0].Title)
+	}
+	expected := []ItemDiff{
+		{Field: "title", Old: "Example", New: "Renamed"},
+		{Field: "fields.password", Secret: true},
+	}
+	if len(changes[0].Changes) != len(expected) {
+		t.Fatalf("Unexpected differences: %+v", changes[0].Changes)
+	}
+	for i, diff := range changes[0].Changes {
+		if diff != expected[i] {
+			t.Errorf("Expected difference %+v, got %+v", expected[i], diff)
+		}
+	}
+}
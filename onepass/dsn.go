@@ -0,0 +1,118 @@
+package onepass
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// map of names accepted for each supported database driver
+var dsnDrivers = map[string]string{
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"mysql":      "mysql",
+	"mariadb":    "mysql",
+}
+
+// map of PostgreSQL 'sslmode' values to the equivalent
+// 'tls' option for MySQL
+var mysqlTlsModes = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
+// FieldValue returns the string value of the first field in
+// any section with the given name
+func (content *ItemContent) FieldValue(name string) string {
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			if field.Name == name {
+				return field.ValueString()
+			}
+		}
+	}
+	return ""
+}
+
+// ConnectionString returns a DSN for connecting to the database
+// described by a Database ('wallet.computer.Database') item.
+//
+// driver is one of 'postgres' or 'mysql'. If empty, the driver is
+// determined from the item's database type. PostgreSQL DSNs use the
+// 'postgres://' URL form and MySQL DSNs use the format of the
+// github.com/go-sql-driver/mysql package. Connection options in the
+// form 'key=value&key2=value2' are appended as query parameters.
+func (content *ItemContent) ConnectionString(driver string) (string, error) {
+	if driver == "" {
+		driver = content.FieldValue("database_type")
+		if driver == "" {
+			return "", fmt.Errorf("Database type is not set, a driver must be specified")
+		}
+	}
+	driverName, ok := dsnDrivers[strings.ToLower(driver)]
+	if !ok {
+		return "", fmt.Errorf("Unsupported database driver '%s'", driver)
+	}
+
+	host := content.FieldValue("hostname")
+	if host == "" {
+		host = "localhost"
+	}
+	port := content.FieldValue("port")
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	user := content.FieldValue("username")
+	password := content.FieldValue("password")
+	database := content.FieldValue("database")
+	sslMode := content.FieldValue("sslmode")
+
+	params, err := url.ParseQuery(content.FieldValue("options"))
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse connection options: %v", err)
+	}
+
+	switch driverName {
+	case "mysql":
+		if sslMode != "" && params.Get("tls") == "" {
+			tlsMode, ok := mysqlTlsModes[sslMode]
+			if !ok {
+				return "", fmt.Errorf("Unknown SSL mode '%s'", sslMode)
+			}
+			params.Set("tls", tlsMode)
+		}
+		dsn := user
+		if password != "" {
+			dsn += ":" + password
+		}
+		if dsn != "" {
+			dsn += "@"
+		}
+		dsn += fmt.Sprintf("tcp(%s)/%s", host, database)
+		if len(params) > 0 {
+			dsn += "?" + params.Encode()
+		}
+		return dsn, nil
+	default:
+		if sslMode != "" && params.Get("sslmode") == "" {
+			params.Set("sslmode", sslMode)
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			Host:     host,
+			Path:     "/" + database,
+			RawQuery: params.Encode(),
+		}
+		if password != "" {
+			dsn.User = url.UserPassword(user, password)
+		} else if user != "" {
+			dsn.User = url.User(user)
+		}
+		return dsn.String(), nil
+	}
+}
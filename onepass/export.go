@@ -53,7 +53,7 @@ func ExportItems(items []Item, path string) error {
 		}
 		exportData += fmt.Sprintf("%s\n***%s***", string(exportedJson), exportUuid.String())
 	}
-	err = ioutil.WriteFile(path+"/data.1pif", []byte(exportData), 0644)
+	err = ioutil.WriteFile(path+"/data.1pif", []byte(exportData), 0600)
 	if err != nil {
 		return err
 	}
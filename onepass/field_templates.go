@@ -0,0 +1,65 @@
+package onepass
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FieldTemplate specifies how a field is filled in automatically
+// when adding a new item, instead of prompting for its value
+type FieldTemplate struct {
+	// Value for the field. $VAR or ${VAR} references are
+	// replaced with the values of environment variables.
+	Default string `json:"default,omitempty"`
+
+	// Generator for the field's value, in the form
+	// 'recipe[:length]', eg. 'password:20' or 'pin'.
+	// 'password' is an alias for the 'default' recipe.
+	// See GenRecipes
+	Generate string `json:"generate,omitempty"`
+}
+
+// ParseGenerator returns the password recipe and length for a
+// FieldTemplate.Generate spec. A zero length means the recipe's
+// default length
+func ParseGenerator(spec string) (recipe string, length int, err error) {
+	recipe = spec
+	if sep := strings.Index(spec, ":"); sep != -1 {
+		recipe = spec[0:sep]
+		length, err = strconv.Atoi(spec[sep+1:])
+		if err != nil || length < 1 {
+			return "", 0, fmt.Errorf("Invalid length in generator '%s'", spec)
+		}
+	}
+	if recipe == "password" {
+		recipe = "default"
+	}
+	if _, ok := GenRecipes[recipe]; !ok {
+		return "", 0, fmt.Errorf("Unknown password recipe '%s' in generator '%s'", recipe, spec)
+	}
+	return recipe, length, nil
+}
+
+// DefaultValue returns the template's default value with
+// environment variable references expanded
+func (template FieldTemplate) DefaultValue() string {
+	return os.ExpandEnv(template.Default)
+}
+
+// FindFieldTemplate returns the template from templates whose key
+// matches one of names, ignoring case. Empty names are skipped.
+func FindFieldTemplate(templates map[string]FieldTemplate, names ...string) (FieldTemplate, bool) {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		for key, template := range templates {
+			if strings.EqualFold(key, name) {
+				return template, true
+			}
+		}
+	}
+	return FieldTemplate{}, false
+}
@@ -0,0 +1,53 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseGenerator(t *testing.T) {
+	cases := []struct {
+		spec   string
+		recipe string
+		length int
+		valid  bool
+	}{
+		{"password:20", "default", 20, true},
+		{"password", "default", 0, true},
+		{"pin:4", "pin", 4, true},
+		{"symbols", "symbols", 0, true},
+		{"pin:", "", 0, false},
+		{"pin:-2", "", 0, false},
+		{"words:5", "", 0, false},
+	}
+	for _, testCase := range cases {
+		recipe, length, err := ParseGenerator(testCase.spec)
+		if (err == nil) != testCase.valid {
+			t.Errorf("Unexpected result for '%s': %v", testCase.spec, err)
+			continue
+		}
+		if recipe != testCase.recipe || length != testCase.length {
+			t.Errorf("Expected %s:%d for '%s', got %s:%d", testCase.recipe, testCase.length,
+				testCase.spec, recipe, length)
+		}
+	}
+}
+
+func TestFieldTemplates(t *testing.T) {
+	os.Setenv("ONEPASS_TEST_USER", "jim")
+	templates := map[string]FieldTemplate{
+		"Username": {Default: "$ONEPASS_TEST_USER@example.com"},
+		"password": {Generate: "password:20"},
+	}
+	template, ok := FindFieldTemplate(templates, "", "username")
+	if !ok || template.DefaultValue() != "jim@example.com" {
+		t.Errorf("Expected default username, got %v", template)
+	}
+	template, ok = FindFieldTemplate(templates, "pwd", "Password")
+	if !ok || template.Generate != "password:20" {
+		t.Errorf("Expected password generator, got %v", template)
+	}
+	if _, ok = FindFieldTemplate(templates, "website"); ok {
+		t.Errorf("Unexpected template for website")
+	}
+}
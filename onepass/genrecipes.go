@@ -0,0 +1,193 @@
+package onepass
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// GenRecipe describes the form of passwords produced
+// by GenerateFromRecipe() and GenerateWithRecipe()
+type GenRecipe struct {
+	Description string `json:",omitempty"`
+	// default length of generated passwords
+	Length int `json:",omitempty"`
+	// characters to choose from. If empty, passwords are
+	// generated using GenPassword(), unless any of the options
+	// below are set, in which case letters are used together
+	// with digits and symbols as specified
+	Chars string `json:",omitempty"`
+	// require at least one lowercase letter, uppercase
+	// letter and digit
+	Mixed bool `json:",omitempty"`
+
+	// include symbols or exclude digits when Chars is empty
+	Symbols  bool `json:",omitempty"`
+	NoDigits bool `json:",omitempty"`
+	// leave out characters which are easily confused
+	// with each other, see AmbiguousChars
+	ExcludeAmbiguous bool `json:",omitempty"`
+	// classes of character of which passwords must contain
+	// at least one, from CharClasses
+	Require []string `json:",omitempty"`
+
+	// if set, passphrases of this many words from Wordlist are
+	// generated instead and the options above are ignored
+	Words int `json:",omitempty"`
+	// words to choose from, read with ReadWordlist(). The list is
+	// not saved with recipes in settings
+	Wordlist []string `json:"-"`
+	// text placed between the words of passphrases.
+	// Defaults to '-'
+	Separator string `json:",omitempty"`
+}
+
+// CharClasses maps the names of classes of character which
+// GenRecipe.Require can list to the characters in each class
+var CharClasses = map[string]string{
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digit":  "0123456789",
+	"symbol": derivedPasswordSymbols,
+}
+
+// AmbiguousChars are the characters left out of passwords
+// by recipes with ExcludeAmbiguous set
+const AmbiguousChars = "Il1|O0o`'\""
+
+// charset returns the characters to choose from, or an
+// empty string if GenPassword() should be used
+func (recipe GenRecipe) charset() string {
+	chars := recipe.Chars
+	if chars == "" {
+		if !recipe.Symbols && !recipe.NoDigits && !recipe.ExcludeAmbiguous && len(recipe.Require) == 0 {
+			return ""
+		}
+		chars = CharClasses["lower"] + CharClasses["upper"]
+		if !recipe.NoDigits {
+			chars += CharClasses["digit"]
+		}
+		if recipe.Symbols {
+			chars += CharClasses["symbol"]
+		}
+	}
+	if recipe.ExcludeAmbiguous {
+		chars = strings.Map(func(ch rune) rune {
+			if strings.ContainsRune(AmbiguousChars, ch) {
+				return -1
+			}
+			return ch
+		}, chars)
+	}
+	return chars
+}
+
+// requiredClasses returns the character classes which
+// passwords from the recipe must contain
+func (recipe GenRecipe) requiredClasses() []string {
+	if recipe.Mixed {
+		return append([]string{"lower", "upper", "digit"}, recipe.Require...)
+	}
+	return recipe.Require
+}
+
+// hasCharClasses returns true if password contains a
+// character from each of the given classes
+func hasCharClasses(password string, classes []string) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(password, CharClasses[class]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GenRecipes is the set of named recipes for generating passwords
+var GenRecipes = map[string]GenRecipe{
+	"default": {Description: "12 letters and digits in groups of three", Length: 12},
+	"long":    {Description: "24 letters and digits in groups of three", Length: 24},
+	"symbols": {Description: "16 letters, digits and symbols", Length: 16,
+		Chars: derivedPasswordChars + derivedPasswordSymbols, Mixed: true},
+	"pin": {Description: "6 digits", Length: 6, Chars: "0123456789"},
+}
+
+// RandomBytes returns count bytes from a cryptographically
+// secure random number generator
+func RandomBytes(count int) []byte {
+	return randomBytes(count)
+}
+
+// GenerateFromRecipe generates a random password using the named
+// recipe. If length is zero, the recipe's default length is used.
+func GenerateFromRecipe(name string, length int) (string, error) {
+	recipe, ok := GenRecipes[name]
+	if !ok {
+		return "", fmt.Errorf("Unknown password recipe '%s'", name)
+	}
+	return GenerateWithRecipe(recipe, length)
+}
+
+// GenerateWithRecipe generates a random password using recipe. If
+// length is zero, the recipe's length is used, or 12 if that is
+// also zero. length is ignored for passphrase recipes.
+func GenerateWithRecipe(recipe GenRecipe, length int) (string, error) {
+	if recipe.Words > 0 {
+		separator := recipe.Separator
+		if separator == "" {
+			separator = "-"
+		}
+		return GeneratePassphrase(recipe.Wordlist, recipe.Words, separator)
+	}
+	if length == 0 {
+		length = recipe.Length
+	}
+	if length == 0 {
+		length = GenRecipes["default"].Length
+	}
+	chars := recipe.charset()
+	for _, ch := range chars {
+		if ch > unicode.MaxASCII {
+			return "", fmt.Errorf("Passwords can only be generated from ASCII characters")
+		}
+	}
+	required := recipe.requiredClasses()
+	for _, class := range required {
+		classChars, ok := CharClasses[class]
+		if !ok {
+			return "", fmt.Errorf("Unknown character class '%s'", class)
+		}
+		if chars != "" && !strings.ContainsAny(chars, classChars) {
+			return "", fmt.Errorf("The password cannot contain a '%s' character with this recipe", class)
+		}
+	}
+	if chars == "" || recipe.Mixed {
+		if length < 4 {
+			return "", fmt.Errorf("Minimum password length is 4 chars")
+		}
+	} else if length < 1 {
+		return "", fmt.Errorf("Invalid password length %d", length)
+	}
+	if length < len(required) {
+		return "", fmt.Errorf("Passwords must be at least %d chars to contain every required class of character", len(required))
+	}
+	if chars == "" {
+		return GenPassword(length), nil
+	}
+
+	// largest multiple of len(chars) which fits in a byte,
+	// bytes at or above this are rejected to avoid bias
+	limit := 256 - 256%len(chars)
+	for {
+		password := make([]byte, 0, length)
+		for len(password) < length {
+			for _, b := range randomBytes(length) {
+				if int(b) < limit && len(password) < length {
+					password = append(password, chars[int(b)%len(chars)])
+				}
+			}
+		}
+		if hasCharClasses(string(password), required) {
+			return string(password), nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package onepass
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateFromRecipe(t *testing.T) {
+	for name, recipe := range GenRecipes {
+		password, err := GenerateFromRecipe(name, 0)
+		if err != nil {
+			t.Fatalf("Unable to generate password with recipe '%s': %v", name, err)
+		}
+		if len(password) != recipe.Length {
+			t.Errorf("Expected %d chars from recipe '%s', got '%s'", recipe.Length, name, password)
+		}
+		if recipe.Chars != "" {
+			for _, ch := range password {
+				if !strings.ContainsRune(recipe.Chars, ch) {
+					t.Errorf("Unexpected char '%c' from recipe '%s'", ch, name)
+				}
+			}
+		}
+	}
+
+	pin, err := GenerateFromRecipe("pin", 4)
+	if err != nil || len(pin) != 4 {
+		t.Errorf("Unexpected 4-digit pin '%s': %v", pin, err)
+	}
+	if _, err := GenerateFromRecipe("unknown", 0); err == nil {
+		t.Errorf("Expected error for unknown recipe")
+	}
+	if _, err := GenerateFromRecipe("default", 3); err == nil {
+		t.Errorf("Expected error for short password")
+	}
+}
+
+func TestGenerateWithRecipe(t *testing.T) {
+	recipe := GenRecipe{Symbols: true, ExcludeAmbiguous: true, Require: []string{"symbol", "digit"}}
+	for i := 0; i < 20; i++ {
+		password, err := GenerateWithRecipe(recipe, 8)
+		if err != nil {
+			t.Fatalf("Unable to generate password: %v", err)
+		}
+		if len(password) != 8 {
+			t.Errorf("Expected 8 chars, got '%s'", password)
+		}
+		if strings.ContainsAny(password, AmbiguousChars) {
+			t.Errorf("Unexpected ambiguous char in '%s'", password)
+		}
+		if !strings.ContainsAny(password, CharClasses["symbol"]) || !strings.ContainsAny(password, CharClasses["digit"]) {
+			t.Errorf("Expected a symbol and digit in '%s'", password)
+		}
+	}
+
+	password, err := GenerateWithRecipe(GenRecipe{NoDigits: true}, 0)
+	if err != nil || len(password) != 12 || strings.ContainsAny(password, CharClasses["digit"]) {
+		t.Errorf("Unexpected password without digits '%s': %v", password, err)
+	}
+
+	for _, invalid := range []GenRecipe{
+		{NoDigits: true, Require: []string{"digit"}},
+		{Require: []string{"emoji"}},
+		{Chars: "abc", Require: []string{"lower", "upper"}},
+		{Chars: "äöü"},
+	} {
+		if _, err := GenerateWithRecipe(invalid, 8); err == nil {
+			t.Errorf("Expected error for recipe %+v", invalid)
+		}
+	}
+	if _, err := GenerateWithRecipe(GenRecipe{Chars: "ab", Require: []string{"lower"}}, 0); err != nil {
+		t.Errorf("Unexpected error for recipe with custom chars: %v", err)
+	}
+}
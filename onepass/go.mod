@@ -0,0 +1,12 @@
+module github.com/robertknight/1pass/onepass
+
+go 1.26.0
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.52
+	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d
+	github.com/robertknight/1pass v0.1.0
+	golang.org/x/crypto v0.57.0
+)
+
+require golang.org/x/sys v0.48.0 // indirect
@@ -0,0 +1,38 @@
+package onepass
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// HistoryEntry is a URL from a web browser's history
+// and the number of times it was visited
+type HistoryEntry struct {
+	Url    string
+	Visits int
+}
+
+// ReadCsvHistory reads browsing history from a CSV file with a 'url'
+// column and an optional visit count column. Browsers' own history
+// databases are read by the browser package.
+func ReadCsvHistory(path string) ([]HistoryEntry, error) {
+	table, err := readCsvTable(path)
+	if err != nil {
+		return nil, err
+	}
+	urlCol := table.column("url")
+	if urlCol == -1 {
+		return nil, fmt.Errorf("History CSV file has no 'url' column")
+	}
+	visitsCol := table.column("visit_count", "visits", "visitCount", "count")
+
+	entries := []HistoryEntry{}
+	for _, row := range table.rows {
+		visits := 1
+		if count, err := strconv.Atoi(table.value(row, visitsCol)); err == nil {
+			visits = count
+		}
+		entries = append(entries, HistoryEntry{Url: table.value(row, urlCol), Visits: visits})
+	}
+	return entries, nil
+}
@@ -0,0 +1,18 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadCsvHistory(t *testing.T) {
+	csvPath := writeTestFile(t, "history.csv", "url,title\nhttps://github.com/,GitHub\n")
+	defer os.Remove(csvPath)
+	entries, err := ReadCsvHistory(csvPath)
+	if err != nil {
+		t.Fatalf("Unable to read CSV history: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Url != "https://github.com/" || entries[0].Visits != 1 {
+		t.Errorf("Unexpected CSV history entries: %v", entries)
+	}
+}
@@ -0,0 +1,212 @@
+package onepass
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// This file contains helpers shared by the importers
+// for other password managers' export formats
+
+// NewLoginContent returns the content for a new Login
+// ('webforms.WebForm') item with the given credentials
+// and website. The website is omitted if url is empty.
+func NewLoginContent(username string, password string, url string) ItemContent {
+	content := ItemContent{
+		Sections: []ItemSection{},
+		FormFields: []WebFormField{
+			{Name: "username", Type: "T", Designation: "username", Value: username},
+			{Name: "password", Type: "P", Designation: "password", Value: password},
+		},
+		Urls: []ItemUrl{},
+	}
+	if url != "" {
+		content.Urls = append(content.Urls, ItemUrl{Label: "website", Url: url})
+	}
+	return content
+}
+
+// NewOtpField returns a field storing an 'otpauth://' URI
+// for generating one-time passwords, using the same naming
+// convention as the official 1Password apps
+func NewOtpField(uri string) ItemField {
+	return ItemField{
+		Kind:  "concealed",
+		Name:  "TOTP_" + newItemId(),
+		Title: "one-time password",
+		Value: uri,
+	}
+}
+
+// otpUri returns an 'otpauth://' URI for a TOTP secret, or
+// secret itself if it is already a URI
+func otpUri(label string, secret string) string {
+	if strings.HasPrefix(secret, "otpauth://") {
+		return secret
+	}
+	return "otpauth://totp/" + url.QueryEscape(label) + "?secret=" + url.QueryEscape(secret)
+}
+
+// AddField appends a field to the section with the given title,
+// creating the section if it does not already exist
+func (content *ItemContent) AddField(sectionTitle string, field ItemField) {
+	for i, section := range content.Sections {
+		if section.Title == sectionTitle {
+			content.Sections[i].Fields = append(content.Sections[i].Fields, field)
+			return
+		}
+	}
+	content.Sections = append(content.Sections, ItemSection{
+		Name:   strings.ToLower(strings.Replace(sectionTitle, " ", "_", -1)),
+		Title:  sectionTitle,
+		Fields: []ItemField{field},
+	})
+}
+
+// splitTags splits a tag list separated by commas or semicolons
+func splitTags(tags string) []string {
+	result := []string{}
+	for _, tag := range strings.FieldsFunc(tags, func(ch rune) bool {
+		return ch == ',' || ch == ';'
+	}) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// NewImportedItem returns an item for use with the importers,
+// choosing a Secure Note for entries which have no credentials or
+// website and a Login otherwise
+func NewImportedItem(title string, content ItemContent, tags []string) ExportedItem {
+	typeName := "webforms.WebForm"
+	hasCredentials := len(content.Urls) > 0
+	for _, field := range content.FormFields {
+		hasCredentials = hasCredentials || field.Value != ""
+	}
+	if !hasCredentials {
+		typeName = "securenotes.SecureNote"
+		content.FormFields = []WebFormField{}
+	}
+	if title == "" {
+		title = "Untitled"
+	}
+	return ExportedItem{
+		Item: Item{
+			Title:        title,
+			TypeName:     typeName,
+			OpenContents: ItemOpenContents{Tags: tags},
+		},
+		SecureContents: content,
+	}
+}
+
+// newTemplateContent returns a copy of the standard template for
+// typeName with the fields named in values filled in. Values are
+// converted to the representation used by the field's kind where
+// necessary.
+func newTemplateContent(typeName string, values map[string]string) ItemContent {
+	var content ItemContent
+	template, ok := StandardTemplate(typeName)
+	if ok {
+		// copy the template so that the shared standard
+		// template is not modified
+		data, _ := json.Marshal(template)
+		_ = json.Unmarshal(data, &content)
+	}
+	if content.Sections == nil {
+		content.Sections = []ItemSection{}
+	}
+	if content.FormFields == nil {
+		content.FormFields = []WebFormField{}
+	}
+	if content.Urls == nil {
+		content.Urls = []ItemUrl{}
+	}
+
+	for sectionId, section := range content.Sections {
+		for fieldId, field := range section.Fields {
+			value, ok := values[field.Name]
+			if !ok || value == "" {
+				continue
+			}
+			content.Sections[sectionId].Fields[fieldId].Value = importedFieldValue(field.Kind, value)
+		}
+	}
+	return content
+}
+
+// importedFieldValue converts a field value from an imported
+// file to the representation used for a given field kind
+func importedFieldValue(kind string, value string) interface{} {
+	if kind == "monthYear" {
+		if monthYear, ok := parseMonthYear(value); ok {
+			return monthYear
+		}
+	}
+	return value
+}
+
+// parseMonthYear converts a date in the form 'MM/YY', 'MM/YYYY' or
+// 'YYYY-MM' to the YYYYMM integer used by 'monthYear' fields
+func parseMonthYear(value string) (int, bool) {
+	var monthStr, yearStr string
+	if parts := strings.Split(value, "/"); len(parts) == 2 {
+		monthStr, yearStr = parts[0], parts[1]
+	} else if parts := strings.Split(value, "-"); len(parts) == 2 {
+		yearStr, monthStr = parts[0], parts[1]
+	} else {
+		return 0, false
+	}
+	month, err := strconv.Atoi(strings.TrimSpace(monthStr))
+	if err != nil || month < 1 || month > 12 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(yearStr))
+	if err != nil {
+		return 0, false
+	}
+	if year < 100 {
+		year += 2000
+	}
+	return year*100 + month, true
+}
+
+// addCustomField adds a string or concealed field to the
+// 'Custom Fields' section of an imported item
+func (content *ItemContent) addCustomField(title string, value string, concealed bool) {
+	kind := "string"
+	if concealed {
+		kind = "concealed"
+	}
+	content.AddField("Custom Fields", ItemField{
+		Kind:  kind,
+		Name:  title,
+		Title: title,
+		Value: value,
+	})
+}
+
+func importError(format string, err error) error {
+	return fmt.Errorf("Unable to read %s export: %v", format, err)
+}
+
+// newTypedImportedItem returns an imported item
+// of a given type
+func newTypedImportedItem(title string, typeName string, content ItemContent) ExportedItem {
+	if title == "" {
+		title = "Untitled"
+	}
+	return ExportedItem{
+		Item: Item{
+			Title:    title,
+			TypeName: typeName,
+		},
+		SecureContents: content,
+	}
+}
@@ -0,0 +1,394 @@
+package onepass
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Bitwarden's unencrypted JSON export format
+type bitwardenExport struct {
+	Encrypted bool              `json:"encrypted"`
+	Folders   []bitwardenFolder `json:"folders"`
+	Items     []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	Id string `json:"id"`
+	// folder path, with parent folders separated by '/'
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	Id       string           `json:"id"`
+	FolderId *string          `json:"folderId"`
+	Type     int              `json:"type"`
+	Name     string           `json:"name"`
+	Notes    string           `json:"notes"`
+	Favorite bool             `json:"favorite"`
+	Fields   []bitwardenField `json:"fields,omitempty"`
+
+	Login      *bitwardenLogin      `json:"login,omitempty"`
+	SecureNote *bitwardenSecureNote `json:"secureNote,omitempty"`
+	Card       *bitwardenCard       `json:"card,omitempty"`
+	Identity   *bitwardenIdentity   `json:"identity,omitempty"`
+}
+
+// Bitwarden item types
+const (
+	bitwardenLoginType      = 1
+	bitwardenSecureNoteType = 2
+	bitwardenCardType       = 3
+	bitwardenIdentityType   = 4
+)
+
+// Bitwarden custom field types
+const (
+	bitwardenTextField    = 0
+	bitwardenHiddenField  = 1
+	bitwardenBooleanField = 2
+)
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenLogin struct {
+	Uris     []bitwardenUri `json:"uris"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	Totp     string         `json:"totp"`
+}
+
+type bitwardenUri struct {
+	Uri string `json:"uri"`
+}
+
+type bitwardenSecureNote struct {
+	Type int `json:"type"`
+}
+
+type bitwardenCard struct {
+	CardholderName string `json:"cardholderName"`
+	Brand          string `json:"brand"`
+	Number         string `json:"number"`
+	ExpMonth       string `json:"expMonth"`
+	ExpYear        string `json:"expYear"`
+	Code           string `json:"code"`
+}
+
+type bitwardenIdentity struct {
+	Title          string `json:"title"`
+	FirstName      string `json:"firstName"`
+	MiddleName     string `json:"middleName"`
+	LastName       string `json:"lastName"`
+	Address1       string `json:"address1"`
+	Address2       string `json:"address2"`
+	Address3       string `json:"address3"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	PostalCode     string `json:"postalCode"`
+	Country        string `json:"country"`
+	Company        string `json:"company"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	Ssn            string `json:"ssn"`
+	Username       string `json:"username"`
+	PassportNumber string `json:"passportNumber"`
+	LicenseNumber  string `json:"licenseNumber"`
+}
+
+// template field names of Credit Card and Identity items
+// which have an equivalent in Bitwarden cards and identities
+var bitwardenCardFields = []string{"cardholder", "type", "ccnum", "cvv", "expiry"}
+var bitwardenIdentityFields = []string{"firstname", "initial", "lastname", "company",
+	"email", "username", "defphone", "address"}
+
+// ImportBitwarden reads items from an unencrypted JSON export created
+// by Bitwarden. Logins, secure notes, cards and identities are mapped to
+// Login, Secure Note, Credit Card and Identity items and custom fields
+// are imported into a 'Custom Fields' section.
+//
+// Bitwarden folders are returned as folder ('system.folder.Regular')
+// items, with the FolderUuid of imported items set to the UUID of
+// their folder. Folder titles are the Bitwarden folder paths, with
+// parent folders separated by '/'.
+func ImportBitwarden(path string) ([]ExportedItem, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export bitwardenExport
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		return nil, importError("Bitwarden", err)
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("Encrypted Bitwarden exports are not supported. Export the vault in the unencrypted '.json' format")
+	}
+
+	items := []ExportedItem{}
+	folderIds := map[string]bool{}
+	for _, folder := range export.Folders {
+		items = append(items, ExportedItem{
+			Item: Item{
+				Uuid:     folder.Id,
+				Title:    folder.Name,
+				TypeName: "system.folder.Regular",
+			},
+		})
+		folderIds[folder.Id] = true
+	}
+
+	for _, entry := range export.Items {
+		var item ExportedItem
+		switch {
+		case entry.Type == bitwardenLoginType && entry.Login != nil:
+			item = newTypedImportedItem(entry.Name, "webforms.WebForm", bitwardenLoginContent(entry))
+		case entry.Type == bitwardenCardType && entry.Card != nil:
+			card := entry.Card
+			values := map[string]string{
+				"cardholder": card.CardholderName,
+				"type":       card.Brand,
+				"ccnum":      card.Number,
+				"cvv":        card.Code,
+			}
+			if card.ExpMonth != "" && card.ExpYear != "" {
+				values["expiry"] = card.ExpMonth + "/" + card.ExpYear
+			}
+			item = newTypedImportedItem(entry.Name, "wallet.financial.CreditCard",
+				newTemplateContent("wallet.financial.CreditCard", values))
+		case entry.Type == bitwardenIdentityType && entry.Identity != nil:
+			item = newTypedImportedItem(entry.Name, "identities.Identity", bitwardenIdentityContent(*entry.Identity))
+		default:
+			item = newTypedImportedItem(entry.Name, "securenotes.SecureNote",
+				newTemplateContent("securenotes.SecureNote", nil))
+		}
+
+		content := &item.SecureContents
+		content.Notes = entry.Notes
+		for _, field := range entry.Fields {
+			switch field.Type {
+			case bitwardenTextField, bitwardenBooleanField:
+				content.addCustomField(field.Name, field.Value, false)
+			case bitwardenHiddenField:
+				content.addCustomField(field.Name, field.Value, true)
+			}
+			// linked fields refer to other fields of the
+			// item and have no value of their own
+		}
+		if entry.FolderId != nil && folderIds[*entry.FolderId] {
+			item.FolderUuid = *entry.FolderId
+		}
+		if entry.Favorite {
+			item.FaveIndex = 1
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func bitwardenLoginContent(entry bitwardenItem) ItemContent {
+	login := entry.Login
+	content := NewLoginContent(login.Username, login.Password, "")
+	for i, uri := range login.Uris {
+		label := ""
+		if i == 0 {
+			label = "website"
+		}
+		content.Urls = append(content.Urls, ItemUrl{Label: label, Url: uri.Uri})
+	}
+	if login.Totp != "" {
+		content.AddField("", NewOtpField(otpUri(entry.Name, login.Totp)))
+	}
+	return content
+}
+
+func bitwardenIdentityContent(identity bitwardenIdentity) ItemContent {
+	content := newTemplateContent("identities.Identity", map[string]string{
+		"firstname": identity.FirstName,
+		"initial":   identity.MiddleName,
+		"lastname":  identity.LastName,
+		"company":   identity.Company,
+		"email":     identity.Email,
+		"username":  identity.Username,
+		"defphone":  identity.Phone,
+	})
+	street := []string{}
+	for _, line := range []string{identity.Address1, identity.Address2, identity.Address3} {
+		if line != "" {
+			street = append(street, line)
+		}
+	}
+	address := ItemAddress{
+		Street:  strings.Join(street, ", "),
+		City:    identity.City,
+		Zip:     identity.PostalCode,
+		State:   identity.State,
+		Country: identity.Country,
+	}
+	if address != (ItemAddress{}) {
+		for sectionId, section := range content.Sections {
+			for fieldId, field := range section.Fields {
+				if field.Kind == "address" {
+					content.Sections[sectionId].Fields[fieldId].Value = address
+				}
+			}
+		}
+	}
+	for _, extra := range []struct {
+		title     string
+		value     string
+		concealed bool
+	}{
+		{"title", identity.Title, false},
+		{"social security number", identity.Ssn, true},
+		{"passport number", identity.PassportNumber, false},
+		{"license number", identity.LicenseNumber, false},
+	} {
+		if extra.value != "" {
+			content.addCustomField(extra.title, extra.value, extra.concealed)
+		}
+	}
+	return content
+}
+
+// ExportBitwarden writes items to a file in Bitwarden's unencrypted
+// JSON export format. folders is a map of folder UUID -> folder path,
+// eg. 'Work/Cloud', used to create the Bitwarden folders for items.
+//
+// Logins, Secure Notes, Credit Cards and Identities are exported as the
+// equivalent Bitwarden item types and other items are exported as
+// secure notes. Fields with no Bitwarden equivalent are exported as
+// custom fields. Folders, tombstones and items in the Trash are
+// skipped.
+func ExportBitwarden(items []Item, folders map[string]string, path string) error {
+	export := bitwardenExport{
+		Folders: []bitwardenFolder{},
+		Items:   []bitwardenItem{},
+	}
+	exportedFolders := map[string]bool{}
+	for _, item := range items {
+		if item.Trashed || strings.HasPrefix(item.TypeName, "system.") {
+			continue
+		}
+		content, err := item.Content()
+		if err != nil {
+			return err
+		}
+		entry := bitwardenExportItem(item, content)
+		if folderPath, ok := folders[item.FolderUuid]; ok {
+			entry.FolderId = &item.FolderUuid
+			if !exportedFolders[item.FolderUuid] {
+				export.Folders = append(export.Folders, bitwardenFolder{Id: item.FolderUuid, Name: folderPath})
+				exportedFolders[item.FolderUuid] = true
+			}
+		}
+		export.Items = append(export.Items, entry)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func bitwardenExportItem(item Item, content ItemContent) bitwardenItem {
+	entry := bitwardenItem{
+		Id:       item.Uuid,
+		Name:     item.Title,
+		Notes:    content.Notes,
+		Favorite: item.FaveIndex > 0,
+	}
+
+	// names of fields which are exported as part
+	// of the Bitwarden item rather than as custom fields
+	exported := map[string]bool{}
+	switch item.TypeName {
+	case "webforms.WebForm", "passwords.Password":
+		entry.Type = bitwardenLoginType
+		entry.Login = &bitwardenLogin{
+			Uris:     []bitwardenUri{},
+			Username: content.Username(),
+			Password: content.Password(),
+			Totp:     content.OTPSecret(),
+		}
+		for _, url := range content.Urls {
+			entry.Login.Uris = append(entry.Login.Uris, bitwardenUri{Uri: url.Url})
+		}
+		exported["username"] = true
+		exported["password"] = true
+	case "wallet.financial.CreditCard":
+		entry.Type = bitwardenCardType
+		entry.Card = &bitwardenCard{
+			CardholderName: content.FieldValue("cardholder"),
+			Brand:          content.FieldValue("type"),
+			Number:         content.FieldValue("ccnum"),
+			Code:           content.FieldValue("cvv"),
+		}
+		expiry := content.FieldValue("expiry")
+		if sep := strings.Index(expiry, "/"); sep != -1 {
+			entry.Card.ExpMonth = strings.TrimLeft(expiry[0:sep], "0")
+			entry.Card.ExpYear = expiry[sep+1:]
+		}
+		for _, name := range bitwardenCardFields {
+			exported[name] = true
+		}
+	case "identities.Identity":
+		entry.Type = bitwardenIdentityType
+		entry.Identity = &bitwardenIdentity{
+			FirstName:  content.FieldValue("firstname"),
+			MiddleName: content.FieldValue("initial"),
+			LastName:   content.FieldValue("lastname"),
+			Company:    content.FieldValue("company"),
+			Email:      content.FieldValue("email"),
+			Username:   content.FieldValue("username"),
+			Phone:      content.FieldValue("defphone"),
+		}
+		for _, section := range content.Sections {
+			for _, field := range section.Fields {
+				if valueMap, ok := field.Value.(map[string]interface{}); ok && field.Kind == "address" {
+					address := AddressFromMap(valueMap)
+					entry.Identity.Address1 = address.Street
+					entry.Identity.City = address.City
+					entry.Identity.PostalCode = address.Zip
+					entry.Identity.State = address.State
+					entry.Identity.Country = address.Country
+				}
+			}
+		}
+		for _, name := range bitwardenIdentityFields {
+			exported[name] = true
+		}
+	default:
+		entry.Type = bitwardenSecureNoteType
+		entry.SecureNote = &bitwardenSecureNote{}
+		for _, url := range content.Urls {
+			entry.Fields = append(entry.Fields, bitwardenField{Name: url.Label, Value: url.Url})
+		}
+	}
+
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			value := field.ValueString()
+			if exported[field.Name] || value == "" ||
+				(entry.Login != nil && value == entry.Login.Totp) {
+				continue
+			}
+			fieldType := bitwardenTextField
+			if field.Kind == "concealed" {
+				fieldType = bitwardenHiddenField
+			}
+			title := field.Title
+			if title == "" {
+				title = field.Name
+			}
+			entry.Fields = append(entry.Fields, bitwardenField{Name: title, Value: value, Type: fieldType})
+		}
+	}
+	return entry
+}
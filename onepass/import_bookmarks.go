@@ -0,0 +1,59 @@
+package onepass
+
+import (
+	"errors"
+	"html"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// tag added to Logins created from bookmarks, whose
+// credentials need to be filled in
+const NeedsPasswordTag = "needs-password"
+
+// matches the bookmark links in a Netscape bookmark file,
+// the format used by all major browsers to export bookmarks
+var bookmarkLinkRegex = regexp.MustCompile(`(?is)<a\s([^>]*)>(.*?)</a>`)
+var bookmarkAttrRegex = regexp.MustCompile(`(?is)([a-z_]+)\s*=\s*"([^"]*)"`)
+
+// ImportBookmarks reads a bookmarks HTML file exported from
+// a web browser and returns a Login item with empty credentials
+// for each bookmarked website. Items are tagged with NeedsPasswordTag
+// and any tags associated with the bookmark. Links which are not
+// websites and duplicate links are skipped.
+func ImportBookmarks(path string) ([]ExportedItem, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []ExportedItem{}
+	seenUrls := map[string]bool{}
+	for _, link := range bookmarkLinkRegex.FindAllStringSubmatch(string(data), -1) {
+		attrs := map[string]string{}
+		for _, attr := range bookmarkAttrRegex.FindAllStringSubmatch(link[1], -1) {
+			attrs[strings.ToLower(attr[1])] = html.UnescapeString(attr[2])
+		}
+		url := strings.TrimSpace(attrs["href"])
+		lowerUrl := strings.ToLower(url)
+		if !strings.HasPrefix(lowerUrl, "http://") && !strings.HasPrefix(lowerUrl, "https://") {
+			continue
+		}
+		if seenUrls[url] {
+			continue
+		}
+		seenUrls[url] = true
+
+		title := strings.TrimSpace(html.UnescapeString(link[2]))
+		if title == "" {
+			title = url
+		}
+		tags := append([]string{NeedsPasswordTag}, splitTags(attrs["tags"])...)
+		items = append(items, NewImportedItem(title, NewLoginContent("", "", url), tags))
+	}
+	if len(items) == 0 && !strings.Contains(strings.ToUpper(string(data)), "NETSCAPE-BOOKMARK-FILE") {
+		return nil, importError("bookmarks", errors.New("File is not a bookmarks HTML file"))
+	}
+	return items, nil
+}
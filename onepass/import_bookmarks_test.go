@@ -0,0 +1,54 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+)
+
+func TestImportBookmarks(t *testing.T) {
+	path := writeTestFile(t, "bookmarks.html", `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1600000000">Work</H3>
+    <DL><p>
+        <DT><A HREF="https://github.com/" ADD_DATE="1600000000" TAGS="dev,code">GitHub &amp; Co</A>
+        <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+    </DL><p>
+    <DT><A HREF="https://github.com/">GitHub again</A>
+    <DT><A HREF="http://example.com/login"></A>
+    <DT><A HREF="place:sort=8">Recent Tags</A>
+</DL><p>
+`)
+	defer os.Remove(path)
+
+	items, err := ImportBookmarks(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	github := items[0]
+	if github.Title != "GitHub & Co" || github.TypeName != "webforms.WebForm" {
+		t.Errorf("Unexpected item: %s (%s)", github.Title, github.TypeName)
+	}
+	if url := github.SecureContents.Urls[0].Url; url != "https://github.com/" {
+		t.Errorf("Unexpected URL: %s", url)
+	}
+	tags := github.OpenContents.Tags
+	if len(tags) != 3 || tags[0] != NeedsPasswordTag || tags[1] != "dev" || tags[2] != "code" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+	if items[1].Title != "http://example.com/login" {
+		t.Errorf("Expected URL to be used as title for untitled bookmark, got %s", items[1].Title)
+	}
+
+	notBookmarks := writeTestFile(t, "not-bookmarks.html", "<html><body>Hello</body></html>")
+	defer os.Remove(notBookmarks)
+	_, err = ImportBookmarks(notBookmarks)
+	if err == nil {
+		t.Errorf("Expected error when importing a file which is not a bookmarks file")
+	}
+}
@@ -0,0 +1,270 @@
+package onepass
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// csvTable holds the rows of a CSV file with a header line
+type csvTable struct {
+	header []string
+	rows   [][]string
+}
+
+func readCsvTable(path string) (csvTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return csvTable{}, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return csvTable{}, fmt.Errorf("Unable to read CSV file: %v", err)
+	}
+	if len(records) == 0 {
+		return csvTable{}, fmt.Errorf("CSV file is empty")
+	}
+
+	header := records[0]
+	for i, name := range header {
+		// strip the UTF-8 byte order mark which some
+		// exporters prepend to the file
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	return csvTable{header: header, rows: records[1:]}, nil
+}
+
+// column returns the index of the first column whose name
+// matches one of names (case-insensitive) or -1 if there is
+// no such column
+func (table *csvTable) column(names ...string) int {
+	for _, name := range names {
+		for i, colName := range table.header {
+			if strings.EqualFold(colName, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// value returns the trimmed value of column col in row or an
+// empty string if col is -1 or the row is too short
+func (table *csvTable) value(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+// Import1PasswordCSV reads items from a CSV file exported by
+// 1Password 8 or later, which has 'Title', 'Url', 'Username', 'Password',
+// 'OTPAuth', 'Tags' and 'Notes' columns.
+//
+// 'otpauth://' URIs are stored in one-time password fields.
+// Any additional columns are imported as custom fields. Columns
+// named '<section>.<field>' are grouped into sections.
+func Import1PasswordCSV(path string) ([]ExportedItem, error) {
+	table, err := readCsvTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	titleCol := table.column("Title")
+	if titleCol == -1 {
+		return nil, fmt.Errorf("CSV file does not have a 'Title' column")
+	}
+	urlCol := table.column("Url", "URL", "Website")
+	usernameCol := table.column("Username")
+	passwordCol := table.column("Password")
+	otpCol := table.column("OTPAuth", "One-time password")
+	tagsCol := table.column("Tags")
+	notesCol := table.column("Notes", "notesPlain")
+
+	knownCols := map[int]bool{}
+	for _, col := range []int{titleCol, urlCol, usernameCol, passwordCol, otpCol, tagsCol, notesCol,
+		table.column("Favorite"), table.column("Archived")} {
+		knownCols[col] = true
+	}
+
+	items := []ExportedItem{}
+	for _, row := range table.rows {
+		content := NewLoginContent(table.value(row, usernameCol), table.value(row, passwordCol),
+			table.value(row, urlCol))
+		content.Notes = table.value(row, notesCol)
+
+		if otp := table.value(row, otpCol); otp != "" {
+			content.AddField("", NewOtpField(otp))
+		}
+
+		for col, colName := range table.header {
+			value := table.value(row, col)
+			if knownCols[col] || value == "" {
+				continue
+			}
+			sectionTitle := "Custom Fields"
+			fieldTitle := colName
+			if sep := strings.Index(colName, "."); sep != -1 {
+				sectionTitle, fieldTitle = colName[:sep], colName[sep+1:]
+			}
+			content.AddField(sectionTitle, ItemField{
+				Kind:  "string",
+				Name:  fieldTitle,
+				Title: fieldTitle,
+				Value: value,
+			})
+		}
+
+		items = append(items, NewImportedItem(table.value(row, titleCol), content,
+			splitTags(table.value(row, tagsCol))))
+	}
+	return items, nil
+}
+
+// ImportApplePasswordsCSV reads items from the Passwords CSV file
+// exported by Safari or the macOS Passwords app, which has 'Title', 'URL',
+// 'Username', 'Password', 'Notes' and 'OTPAuth' columns.
+func ImportApplePasswordsCSV(path string) ([]ExportedItem, error) {
+	table, err := readCsvTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	titleCol := table.column("Title")
+	urlCol := table.column("URL")
+	usernameCol := table.column("Username")
+	passwordCol := table.column("Password")
+	if urlCol == -1 || passwordCol == -1 {
+		return nil, fmt.Errorf("CSV file does not have 'URL' and 'Password' columns")
+	}
+	notesCol := table.column("Notes")
+	otpCol := table.column("OTPAuth")
+
+	items := []ExportedItem{}
+	for _, row := range table.rows {
+		content := NewLoginContent(table.value(row, usernameCol), table.value(row, passwordCol),
+			table.value(row, urlCol))
+		content.Notes = table.value(row, notesCol)
+
+		title := table.value(row, titleCol)
+		if otp := table.value(row, otpCol); otp != "" {
+			content.AddField("", NewOtpField(otpUri(title, otp)))
+		}
+		if title == "" {
+			title = table.value(row, urlCol)
+		}
+		items = append(items, NewImportedItem(title, content, nil))
+	}
+	return items, nil
+}
+
+// CSVMapping specifies the columns of a CSV file which hold each
+// part of a login, for ImportCSV(). Columns are given either by name,
+// which is matched against the header line ignoring case, or by
+// number, starting from 1.
+type CSVMapping struct {
+	Title    string `yaml:"title"`
+	Url      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Notes    string `yaml:"notes"`
+	Tags     string `yaml:"tags"`
+	Otp      string `yaml:"otp"`
+}
+
+// column names used by common browsers and password managers,
+// for parts of a login which are not specified in a CSVMapping
+var defaultCsvColumns = CSVMapping{
+	Title:    "Title,Name",
+	Url:      "URL,Website,Login URI,Web Site,Address",
+	Username: "Username,User Name,Login,Login Username,Email",
+	Password: "Password,Login Password",
+	Notes:    "Notes,Note,Extra,Comments",
+	Tags:     "Tags,Folder,Grouping,Category",
+	Otp:      "OTPAuth,TOTP,Login TOTP,OTP",
+}
+
+// mappedColumn returns the index of the column specified by spec or,
+// if spec is empty, of the first column matching one of the
+// comma-separated names in defaults. Returns -1 if spec is
+// empty and no default column exists.
+func (table *csvTable) mappedColumn(spec string, defaults string) (int, error) {
+	if spec == "" {
+		return table.column(strings.Split(defaults, ",")...), nil
+	}
+	if number, err := strconv.Atoi(spec); err == nil {
+		if number < 1 || number > len(table.header) {
+			return -1, fmt.Errorf("Column %d does not exist, the file has %d columns", number, len(table.header))
+		}
+		return number - 1, nil
+	}
+	col := table.column(spec)
+	if col == -1 {
+		return -1, fmt.Errorf("CSV file does not have a '%s' column", spec)
+	}
+	return col, nil
+}
+
+// ImportCSV reads logins from a CSV file with a header line, such as
+// the password exports from web browsers and other password managers.
+// mapping specifies which columns hold the title, website, username,
+// password, notes, tags and one-time password of each login. Columns
+// which are not specified are found by looking for common column names.
+// Other columns are ignored.
+//
+// Rows which have no username, password or website are
+// imported as Secure Notes.
+func ImportCSV(path string, mapping CSVMapping) ([]ExportedItem, error) {
+	table, err := readCsvTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var titleCol, urlCol, usernameCol, passwordCol, notesCol, tagsCol, otpCol int
+	for _, field := range []struct {
+		spec     string
+		defaults string
+		col      *int
+	}{
+		{mapping.Title, defaultCsvColumns.Title, &titleCol},
+		{mapping.Url, defaultCsvColumns.Url, &urlCol},
+		{mapping.Username, defaultCsvColumns.Username, &usernameCol},
+		{mapping.Password, defaultCsvColumns.Password, &passwordCol},
+		{mapping.Notes, defaultCsvColumns.Notes, &notesCol},
+		{mapping.Tags, defaultCsvColumns.Tags, &tagsCol},
+		{mapping.Otp, defaultCsvColumns.Otp, &otpCol},
+	} {
+		*field.col, err = table.mappedColumn(field.spec, field.defaults)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if passwordCol == -1 && urlCol == -1 {
+		return nil, fmt.Errorf("Unable to find the password or website column, specify the columns to import")
+	}
+
+	items := []ExportedItem{}
+	for _, row := range table.rows {
+		website := table.value(row, urlCol)
+		content := NewLoginContent(table.value(row, usernameCol), table.value(row, passwordCol), website)
+		content.Notes = table.value(row, notesCol)
+
+		title := table.value(row, titleCol)
+		if parsedUrl, err := url.Parse(website); title == "" && err == nil && parsedUrl.Host != "" {
+			title = parsedUrl.Host
+		}
+		if otp := table.value(row, otpCol); otp != "" {
+			content.AddField("", NewOtpField(otpUri(title, otp)))
+		}
+		items = append(items, NewImportedItem(title, content, splitTags(table.value(row, tagsCol))))
+	}
+	return items, nil
+}
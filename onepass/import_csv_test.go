@@ -0,0 +1,191 @@
+package onepass
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	path := os.TempDir() + "/" + name
+	err := ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("Unable to write test file: %v", err)
+	}
+	return path
+}
+
+func TestImport1PasswordCSV(t *testing.T) {
+	path := writeTestFile(t, "1password-export.csv",
+		"Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes,Server.port\n"+
+			"GitHub,https://github.com,alice,secret,otpauth://totp/GitHub?secret=ABC,false,false,\"dev,work\",,\n"+
+			"Alarm code,,,,,false,false,,1234,\n"+
+			"Mail,https://mail.example.com,bob,pwd,,false,false,,,993\n")
+	defer os.Remove(path)
+
+	items, err := Import1PasswordCSV(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+
+	github := items[0]
+	if github.TypeName != "webforms.WebForm" || github.Title != "GitHub" {
+		t.Errorf("Unexpected item: %s (%s)", github.Title, github.TypeName)
+	}
+	if len(github.OpenContents.Tags) != 2 {
+		t.Errorf("Unexpected tags: %v", github.OpenContents.Tags)
+	}
+	if field := github.SecureContents.FieldByPattern("one-time"); field == nil ||
+		field.ValueString() != "otpauth://totp/GitHub?secret=ABC" {
+		t.Errorf("One-time password field not imported")
+	}
+	if field := github.SecureContents.FormFieldByPattern("password"); field == nil || field.Value != "secret" {
+		t.Errorf("Password not imported")
+	}
+
+	note := items[1]
+	if note.TypeName != "securenotes.SecureNote" || note.SecureContents.Notes != "1234" {
+		t.Errorf("Expected secure note, got %s: %v", note.TypeName, note.SecureContents)
+	}
+
+	mail := items[2]
+	if len(mail.SecureContents.Sections) != 1 || mail.SecureContents.Sections[0].Title != "Server" {
+		t.Fatalf("Custom field section not created: %v", mail.SecureContents.Sections)
+	}
+	if mail.SecureContents.Sections[0].Fields[0].ValueString() != "993" {
+		t.Errorf("Custom field not imported")
+	}
+}
+
+func TestImportApplePasswordsCSV(t *testing.T) {
+	path := writeTestFile(t, "apple-passwords.csv",
+		"Title,URL,Username,Password,Notes,OTPAuth\n"+
+			"example.com (alice),https://example.com/,alice,secret,,otpauth://totp/example?secret=ABC\n"+
+			",https://other.com/,bob,pwd,note,\n")
+	defer os.Remove(path)
+
+	items, err := ImportApplePasswordsCSV(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if field := items[0].SecureContents.FieldByPattern("one-time"); field == nil {
+		t.Errorf("One-time password field not imported")
+	}
+	if items[1].Title != "https://other.com/" || items[1].SecureContents.Notes != "note" {
+		t.Errorf("Unexpected item: %s %v", items[1].Title, items[1].SecureContents)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	// Chrome's password export, with default column names
+	path := writeTestFile(t, "chrome-passwords.csv",
+		"name,url,username,password,note\n"+
+			"github.com,https://github.com/login,alice,secret,\n"+
+			",https://example.com/,bob,pwd,old account\n")
+	defer os.Remove(path)
+
+	items, err := ImportCSV(path, CSVMapping{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].Title != "github.com" || items[0].SecureContents.Username() != "alice" ||
+		items[0].SecureContents.Password() != "secret" {
+		t.Errorf("Unexpected item: %s %v", items[0].Title, items[0].SecureContents)
+	}
+	if items[1].Title != "example.com" || items[1].SecureContents.Notes != "old account" {
+		t.Errorf("Expected title from website and notes, got %s %v", items[1].Title, items[1].SecureContents)
+	}
+
+	// custom columns mapped by name and number
+	path = writeTestFile(t, "custom-passwords.csv",
+		"Account,Login Name,Secret,Web Site,Group\n"+
+			"Bank,alice,pin1234,https://bank.example.com,Finance\n")
+	defer os.Remove(path)
+	items, err = ImportCSV(path, CSVMapping{Title: "account", Username: "Login Name", Password: "3",
+		Url: "Web Site", Tags: "Group"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	content := items[0].SecureContents
+	if items[0].Title != "Bank" || content.Username() != "alice" || content.Password() != "pin1234" ||
+		content.PrimaryURL() != "https://bank.example.com" || len(items[0].OpenContents.Tags) != 1 {
+		t.Errorf("Unexpected item: %s %v", items[0].Title, content)
+	}
+
+	for _, mapping := range []CSVMapping{{Password: "Missing"}, {Password: "9"}} {
+		_, err = ImportCSV(path, mapping)
+		if err == nil {
+			t.Errorf("Expected error for mapping %v", mapping)
+		}
+	}
+}
+
+func TestImportLastPassCSV(t *testing.T) {
+	path := writeTestFile(t, "lastpass-export.csv",
+		"url,username,password,totp,extra,name,grouping,fav\n"+
+			"https://github.com/login,alice,secret,JBSWY3DPEHPK3PXP,recovery codes in safe,GitHub,Work\\Dev,1\n"+
+			"http://sn,,,,alarm code 1234,Alarm,,0\n"+
+			"http://sn,,,,\"NoteType:Credit Card\nLanguage:en-US\nName on Card:Alice\nType:Visa\n"+
+			"Number:4111111111111111\nSecurity Code:123\nStart Date:,\nExpiration Date:March,2027\n"+
+			"Notes:Travel card\nno foreign fees\",Visa,Work\\Dev,0\n"+
+			"http://sn,,,,\"NoteType:Wi-Fi Password\nSSID:home\nPassword:wifi-pwd\nNotes:\",Wifi,,0\n")
+	defer os.Remove(path)
+
+	items, err := ImportLastPassCSV(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 5 {
+		t.Fatalf("Expected 5 items, got %d", len(items))
+	}
+
+	folder := items[0]
+	if folder.TypeName != "system.folder.Regular" || folder.Title != "Work/Dev" {
+		t.Errorf("Folder not imported correctly: %v", folder.Item)
+	}
+
+	login := items[1]
+	if login.TypeName != "webforms.WebForm" || login.FolderUuid != folder.Uuid || login.FaveIndex != 1 ||
+		login.SecureContents.Username() != "alice" || login.SecureContents.Notes != "recovery codes in safe" ||
+		login.SecureContents.OTPSecret() == "" {
+		t.Errorf("Login not imported correctly: %v", login)
+	}
+
+	if items[2].TypeName != "securenotes.SecureNote" || items[2].SecureContents.Notes != "alarm code 1234" {
+		t.Errorf("Note not imported correctly: %v", items[2])
+	}
+
+	card := items[3]
+	content := card.SecureContents
+	if card.TypeName != "wallet.financial.CreditCard" || card.FolderUuid != folder.Uuid {
+		t.Errorf("Card not imported correctly: %v", card.Item)
+	}
+	if content.FieldValue("ccnum") != "4111111111111111" || content.FieldValue("cvv") != "123" ||
+		content.FieldByPattern("expiry").Value != 202703 || content.Notes != "Travel card\nno foreign fees" {
+		t.Errorf("Card fields not imported correctly: %v", content)
+	}
+	if content.FieldValue("Language") != "en-US" {
+		t.Errorf("Expected unknown field to be imported as a custom field: %v", content.Sections)
+	}
+
+	wifi := items[4]
+	fields := wifi.SecureContents.Sections[0].Fields
+	if wifi.TypeName != "securenotes.SecureNote" || len(fields) != 2 || fields[0].Value != "home" ||
+		fields[1].Kind != "concealed" {
+		t.Errorf("Wi-Fi note not imported correctly: %v", wifi)
+	}
+	for _, item := range items[1:] {
+		if err := ValidateContent(item.TypeName, item.SecureContents); err != nil {
+			t.Errorf("Imported item '%s' is not valid: %v", item.Title, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package onepass
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// entries in Dashlane's JSON export, grouped by
+// category
+type dashlaneExport struct {
+	Credentials []map[string]string `json:"AUTHENTIFIANT"`
+	Notes       []map[string]string `json:"SECURENOTE"`
+	CreditCards []map[string]string `json:"PAYMENTMEANS_CREDITCARD"`
+	BankAccount []map[string]string `json:"BANKSTATEMENT"`
+}
+
+// ImportDashlaneJSON reads items from a JSON export created
+// by Dashlane. Credentials are imported as Logins, secure notes as
+// Secure Notes and payment cards and bank accounts as Credit Card
+// and Bank Account items.
+func ImportDashlaneJSON(path string) ([]ExportedItem, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export dashlaneExport
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		return nil, importError("Dashlane", err)
+	}
+
+	items := []ExportedItem{}
+	for _, entry := range export.Credentials {
+		username := entry["login"]
+		if username == "" {
+			username = entry["email"]
+		}
+		content := NewLoginContent(username, entry["password"], entry["domain"])
+		content.Notes = entry["note"]
+		if entry["secondaryLogin"] != "" {
+			content.addCustomField("secondary login", entry["secondaryLogin"], false)
+		}
+		if entry["email"] != "" && entry["email"] != username {
+			content.addCustomField("email", entry["email"], false)
+		}
+		items = append(items, NewImportedItem(entry["title"], content, nil))
+	}
+	for _, entry := range export.Notes {
+		items = append(items, NewImportedItem(entry["title"], ItemContent{Notes: entry["content"]}, nil))
+	}
+	for _, entry := range export.CreditCards {
+		content := newTemplateContent("wallet.financial.CreditCard", map[string]string{
+			"cardholder": entry["owner"],
+			"ccnum":      entry["cardNumber"],
+			"cvv":        entry["securityCode"],
+			"expiry":     entry["expireMonth"] + "/" + entry["expireYear"],
+			"bank":       entry["bank"],
+		})
+		items = append(items, newTypedImportedItem(entry["name"], "wallet.financial.CreditCard", content))
+	}
+	for _, entry := range export.BankAccount {
+		content := newTemplateContent("wallet.financial.BankAccountUS", map[string]string{
+			"bankName": entry["bank"],
+			"owner":    entry["owner"],
+			"swift":    entry["BIC"],
+			"iban":     entry["IBAN"],
+		})
+		items = append(items, newTypedImportedItem(entry["name"], "wallet.financial.BankAccountUS", content))
+	}
+	return items, nil
+}
+
+// ImportDashlaneCSV reads items from one of the CSV files
+// in a Dashlane export archive. 'credentials.csv', 'securenotes.csv'
+// and 'payments.csv' are supported and are identified by their
+// columns.
+func ImportDashlaneCSV(path string) ([]ExportedItem, error) {
+	table, err := readCsvTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	titleCol := table.column("title")
+	noteCol := table.column("note")
+	items := []ExportedItem{}
+
+	switch {
+	case table.column("password") != -1:
+		usernameCols := []int{table.column("username"), table.column("username2"), table.column("username3")}
+		passwordCol := table.column("password")
+		urlCol := table.column("url")
+		categoryCol := table.column("category")
+		otpCol := table.column("otpSecret", "otpUrl")
+		for _, row := range table.rows {
+			content := NewLoginContent(table.value(row, usernameCols[0]), table.value(row, passwordCol),
+				table.value(row, urlCol))
+			content.Notes = table.value(row, noteCol)
+			for i, col := range usernameCols[1:] {
+				if value := table.value(row, col); value != "" {
+					content.addCustomField(table.header[usernameCols[i+1]], value, false)
+				}
+			}
+			if otp := table.value(row, otpCol); otp != "" {
+				content.AddField("", NewOtpField(otpUri(table.value(row, titleCol), otp)))
+			}
+			var tags []string
+			if category := table.value(row, categoryCol); category != "" {
+				tags = []string{category}
+			}
+			items = append(items, NewImportedItem(table.value(row, titleCol), content, tags))
+		}
+	case table.column("cc_number") != -1:
+		for _, row := range table.rows {
+			content := newTemplateContent("wallet.financial.CreditCard", map[string]string{
+				"cardholder": table.value(row, table.column("account_holder")),
+				"ccnum":      table.value(row, table.column("cc_number")),
+				"cvv":        table.value(row, table.column("code")),
+				"expiry": table.value(row, table.column("expiration_month")) + "/" +
+					table.value(row, table.column("expiration_year")),
+				"bank": table.value(row, table.column("issuing_bank")),
+			})
+			content.Notes = table.value(row, noteCol)
+			items = append(items, newTypedImportedItem(table.value(row, table.column("account_name")),
+				"wallet.financial.CreditCard", content))
+		}
+	default:
+		for _, row := range table.rows {
+			content := ItemContent{Notes: table.value(row, noteCol)}
+			items = append(items, NewImportedItem(table.value(row, titleCol), content, nil))
+		}
+	}
+	return items, nil
+}
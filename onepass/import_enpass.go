@@ -0,0 +1,152 @@
+package onepass
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// Enpass JSON export format
+type enpassExport struct {
+	Folders []struct {
+		Uuid  string `json:"uuid"`
+		Title string `json:"title"`
+	} `json:"folders"`
+	Items []enpassItem `json:"items"`
+}
+
+type enpassItem struct {
+	Title    string        `json:"title"`
+	Category string        `json:"category"`
+	Note     string        `json:"note"`
+	Fields   []enpassField `json:"fields"`
+	Folders  []string      `json:"folders"`
+	Tags     []string      `json:"tags"`
+	Trashed  int           `json:"trashed"`
+}
+
+type enpassField struct {
+	Label     string `json:"label"`
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Sensitive int    `json:"sensitive"`
+}
+
+// map of Enpass category -> 1Password item type
+var enpassCategories = map[string]string{
+	"login":      "webforms.WebForm",
+	"password":   "passwords.Password",
+	"note":       "securenotes.SecureNote",
+	"creditcard": "wallet.financial.CreditCard",
+	"finance":    "wallet.financial.BankAccountUS",
+	"license":    "wallet.computer.License",
+	"identity":   "identities.Identity",
+	"computer":   "wallet.computer.UnixServer",
+}
+
+// map of Enpass field type -> template field names for
+// non-login item types
+var enpassFieldNames = map[string]string{
+	"ccName":     "cardholder",
+	"ccNumber":   "ccnum",
+	"ccCvc":      "cvv",
+	"ccExpiry":   "expiry",
+	"ccType":     "type",
+	"ccBankname": "bank",
+}
+
+// ImportEnpassJSON reads items from a JSON export created by Enpass.
+// Enpass categories are mapped to the closest 1Password item type
+// and Enpass folders are added as tags. Fields which do not have an
+// equivalent in the item type's template are imported as custom fields.
+func ImportEnpassJSON(path string) ([]ExportedItem, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export enpassExport
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		return nil, importError("Enpass", err)
+	}
+
+	folderNames := map[string]string{}
+	for _, folder := range export.Folders {
+		folderNames[folder.Uuid] = folder.Title
+	}
+
+	items := []ExportedItem{}
+	for _, entry := range export.Items {
+		tags := append([]string{}, entry.Tags...)
+		for _, folderId := range entry.Folders {
+			if name, ok := folderNames[folderId]; ok {
+				tags = append(tags, name)
+			}
+		}
+
+		var item ExportedItem
+		typeName, ok := enpassCategories[entry.Category]
+		if !ok || typeName == "webforms.WebForm" || typeName == "passwords.Password" {
+			item = NewImportedItem(entry.Title, enpassLoginContent(entry), tags)
+		} else {
+			values := map[string]string{}
+			for _, field := range entry.Fields {
+				if name, ok := enpassFieldNames[field.Type]; ok {
+					values[name] = field.Value
+				}
+			}
+			content := newTemplateContent(typeName, values)
+			for _, field := range entry.Fields {
+				if _, ok := enpassFieldNames[field.Type]; !ok && field.Value != "" {
+					content.addCustomField(field.Label, field.Value, field.Sensitive != 0)
+				}
+			}
+			content.Notes = entry.Note
+			item = newTypedImportedItem(entry.Title, typeName, content)
+			item.OpenContents.Tags = tags
+		}
+		item.Trashed = entry.Trashed != 0
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// converts the fields of an Enpass login or password
+// item to Login item content
+func enpassLoginContent(entry enpassItem) ItemContent {
+	var username, email, password, url string
+	var extraFields []enpassField
+	for _, field := range entry.Fields {
+		switch {
+		case field.Type == "username" && username == "":
+			username = field.Value
+		case field.Type == "email" && email == "":
+			email = field.Value
+		case field.Type == "password" && password == "":
+			password = field.Value
+		case field.Type == "url" && url == "":
+			url = field.Value
+		default:
+			extraFields = append(extraFields, field)
+		}
+	}
+	if username == "" {
+		username, email = email, ""
+	}
+
+	content := NewLoginContent(username, password, url)
+	content.Notes = entry.Note
+	if email != "" {
+		content.addCustomField("email", email, false)
+	}
+	for _, field := range extraFields {
+		if field.Value == "" {
+			continue
+		}
+		if field.Type == "totp" {
+			content.AddField("", NewOtpField(otpUri(entry.Title, field.Value)))
+		} else {
+			content.addCustomField(field.Label, field.Value, field.Sensitive != 0)
+		}
+	}
+	return content
+}
@@ -0,0 +1,278 @@
+package onepass
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// URL which LastPass uses for secure notes in CSV exports
+const lastpassNoteUrl = "http://sn"
+
+// lastpassNoteType describes how a type of LastPass secure
+// note is converted to a 1Password item
+type lastpassNoteType struct {
+	typeName string
+	// map of LastPass field label -> template field name
+	fields map[string]string
+}
+
+// map of LastPass 'NoteType' -> item type for secure notes
+// with structured fields. Fields which are not listed are
+// imported as custom fields.
+var lastpassNoteTypes = map[string]lastpassNoteType{
+	"Credit Card": {"wallet.financial.CreditCard", map[string]string{
+		"Name on Card":    "cardholder",
+		"Type":            "type",
+		"Number":          "ccnum",
+		"Security Code":   "cvv",
+		"Start Date":      "validFrom",
+		"Expiration Date": "expiry",
+	}},
+	"Bank Account": {"wallet.financial.BankAccountUS", map[string]string{
+		"Bank Name":      "bankName",
+		"Account Type":   "accountType",
+		"Routing Number": "routingNo",
+		"Account Number": "accountNo",
+		"SWIFT Code":     "swift",
+		"IBAN Number":    "iban",
+		"Pin":            "telephonePin",
+		"Branch Address": "branchAddress",
+		"Branch Phone":   "branchPhone",
+	}},
+	"Database": {"wallet.computer.Database", map[string]string{
+		"Type":     "database_type",
+		"Hostname": "hostname",
+		"Port":     "port",
+		"Database": "database",
+		"Username": "username",
+		"Password": "password",
+		"SID":      "sid",
+		"Alias":    "alias",
+	}},
+	"Server": {"wallet.computer.UnixServer", map[string]string{
+		"Hostname": "url",
+		"Username": "username",
+		"Password": "password",
+	}},
+	"Email Account": {"wallet.onlineservices.Email.v2", map[string]string{
+		"Username":    "pop_username",
+		"Password":    "pop_password",
+		"Server":      "pop_server",
+		"Port":        "pop_port",
+		"Type":        "pop_type",
+		"SMTP Server": "smtp_server",
+		"SMTP Port":   "smtp_port",
+	}},
+	"Software License": {"wallet.computer.License", map[string]string{
+		"License Key":   "reg_code",
+		"Licensee":      "reg_name",
+		"Version":       "product_version",
+		"Publisher":     "publisher_name",
+		"Support Email": "support_email",
+		"Website":       "publisher_website",
+		"Price":         "retail_price",
+		"Order Number":  "order_number",
+		"Order Total":   "order_total",
+	}},
+	"Membership": {"wallet.membership.Membership", map[string]string{
+		"Organization":      "org_name",
+		"Membership Number": "membership_no",
+		"Member Name":       "member_name",
+		"Start Date":        "member_since",
+		"Expiration Date":   "expiry_date",
+		"Website":           "website",
+		"Telephone":         "phone",
+		"Password":          "pin",
+	}},
+	"Passport": {"wallet.government.Passport", map[string]string{
+		"Type":        "type",
+		"Name":        "fullname",
+		"Country":     "issuing_country",
+		"Number":      "number",
+		"Sex":         "sex",
+		"Nationality": "nationality",
+	}},
+	"Driver's License": {"wallet.government.DriversLicense", map[string]string{
+		"Number":          "number",
+		"Name":            "fullname",
+		"Address":         "address",
+		"State":           "state",
+		"Country":         "country",
+		"Sex":             "sex",
+		"Height":          "height",
+		"Class":           "class",
+		"Restrictions":    "conditions",
+		"Expiration Date": "expiry_date",
+	}},
+	"Social Security": {"wallet.government.SsnUS", map[string]string{
+		"Name":   "name",
+		"Number": "number",
+	}},
+}
+
+// lastpassField is a 'Label:Value' line from the 'extra'
+// column of a LastPass secure note
+type lastpassField struct {
+	label string
+	value string
+}
+
+// ImportLastPassCSV reads items from a CSV file exported by LastPass,
+// which has 'url', 'username', 'password', 'totp', 'extra', 'name',
+// 'grouping' and 'fav' columns.
+//
+// Entries with the URL 'http://sn' are secure notes. Notes whose 'extra'
+// column starts with a 'NoteType:' line, such as credit cards and bank
+// accounts, are converted to the equivalent item type where there is
+// one, with fields that have no equivalent imported as custom fields.
+// Other secure notes are imported as Secure Notes and the remaining
+// entries as Logins.
+//
+// The 'grouping' column is the entry's folder, with nested folders
+// separated by '\'. Folders are returned as folder
+// ('system.folder.Regular') items with titles containing the folder
+// path separated by '/', and the FolderUuid of each item is set to
+// the UUID of its folder.
+func ImportLastPassCSV(path string) ([]ExportedItem, error) {
+	table, err := readCsvTable(path)
+	if err != nil {
+		return nil, err
+	}
+	nameCol := table.column("name")
+	urlCol := table.column("url")
+	if nameCol == -1 || urlCol == -1 {
+		return nil, fmt.Errorf("CSV file does not have LastPass's 'name' and 'url' columns")
+	}
+	usernameCol := table.column("username")
+	passwordCol := table.column("password")
+	totpCol := table.column("totp")
+	extraCol := table.column("extra")
+	groupingCol := table.column("grouping")
+	favCol := table.column("fav")
+
+	folders := []ExportedItem{}
+	folderUuids := map[string]string{}
+	items := []ExportedItem{}
+	for _, row := range table.rows {
+		title := table.value(row, nameCol)
+		url := table.value(row, urlCol)
+		extra := table.value(row, extraCol)
+
+		var item ExportedItem
+		if url == lastpassNoteUrl {
+			item = lastpassNoteItem(title, extra)
+		} else {
+			content := NewLoginContent(table.value(row, usernameCol), table.value(row, passwordCol), url)
+			content.Notes = extra
+			if totp := table.value(row, totpCol); totp != "" {
+				content.AddField("", NewOtpField(otpUri(title, totp)))
+			}
+			item = newTypedImportedItem(title, "webforms.WebForm", content)
+		}
+
+		folder := strings.Trim(strings.Replace(table.value(row, groupingCol), "\\", "/", -1), "/")
+		if folder != "" {
+			if _, ok := folderUuids[folder]; !ok {
+				folderUuids[folder] = newItemId()
+				folders = append(folders, ExportedItem{
+					Item: Item{
+						Uuid:     folderUuids[folder],
+						Title:    folder,
+						TypeName: "system.folder.Regular",
+					},
+				})
+			}
+			item.FolderUuid = folderUuids[folder]
+		}
+		if table.value(row, favCol) == "1" {
+			item.FaveIndex = 1
+		}
+		items = append(items, item)
+	}
+	return append(folders, items...), nil
+}
+
+// lastpassNoteItem converts a LastPass secure note to an item
+func lastpassNoteItem(title string, extra string) ExportedItem {
+	if !strings.HasPrefix(extra, "NoteType:") {
+		content := newTemplateContent("securenotes.SecureNote", nil)
+		content.Notes = extra
+		return newTypedImportedItem(title, "securenotes.SecureNote", content)
+	}
+
+	fields, notes := parseLastpassNote(extra)
+	noteType, ok := lastpassNoteTypes[fields[0].value]
+	if !ok {
+		noteType = lastpassNoteType{typeName: "securenotes.SecureNote"}
+	}
+
+	template, _ := StandardTemplate(noteType.typeName)
+	kinds := map[string]string{}
+	for _, section := range template.Sections {
+		for _, field := range section.Fields {
+			kinds[field.Name] = field.Kind
+		}
+	}
+
+	values := map[string]string{}
+	extraFields := []lastpassField{}
+	for _, field := range fields[1:] {
+		name, ok := noteType.fields[field.label]
+		value := field.value
+		if ok && kinds[name] == "monthYear" {
+			value, ok = lastpassMonthYear(value)
+		}
+		if ok && values[name] == "" {
+			values[name] = value
+		} else if field.value != "" {
+			extraFields = append(extraFields, field)
+		}
+	}
+
+	content := newTemplateContent(noteType.typeName, values)
+	for _, field := range extraFields {
+		label := strings.ToLower(field.label)
+		concealed := strings.Contains(label, "password") || strings.Contains(label, "pin") ||
+			strings.Contains(label, "code")
+		content.addCustomField(field.label, field.value, concealed)
+	}
+	content.Notes = notes
+	return newTypedImportedItem(title, noteType.typeName, content)
+}
+
+// parseLastpassNote splits the 'extra' column of a structured LastPass
+// secure note into its fields, starting with the 'NoteType' field, and
+// the free-form notes which follow the 'Notes:' label
+func parseLastpassNote(extra string) (fields []lastpassField, notes string) {
+	lines := strings.Split(strings.Replace(extra, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "Notes:") {
+			notes = strings.TrimSpace(strings.Join(append([]string{line[len("Notes:"):]}, lines[i+1:]...), "\n"))
+			break
+		}
+		sep := strings.Index(line, ":")
+		if sep == -1 {
+			// continuation of a multi-line value
+			if len(fields) > 0 {
+				fields[len(fields)-1].value += "\n" + line
+			}
+			continue
+		}
+		fields = append(fields, lastpassField{
+			label: strings.TrimSpace(line[0:sep]),
+			value: strings.TrimSpace(line[sep+1:]),
+		})
+	}
+	return fields, notes
+}
+
+// lastpassMonthYear converts a LastPass date in the form
+// 'January,2027' to the 'MM/YYYY' form
+func lastpassMonthYear(value string) (string, bool) {
+	date, err := time.Parse("January,2006", strings.Replace(value, " ", "", -1))
+	if err != nil {
+		return "", false
+	}
+	return date.Format("01/2006"), true
+}
@@ -0,0 +1,197 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+)
+
+func TestImportDashlaneJSON(t *testing.T) {
+	path := writeTestFile(t, "dashlane-export.json", `{
+		"AUTHENTIFIANT": [{"title": "GitHub", "domain": "github.com", "login": "alice",
+			"email": "alice@example.com", "password": "secret", "note": "", "secondaryLogin": ""}],
+		"SECURENOTE": [{"title": "Alarm", "content": "1234"}],
+		"PAYMENTMEANS_CREDITCARD": [{"name": "Visa", "owner": "Alice", "cardNumber": "4111111111111111",
+			"expireMonth": "03", "expireYear": "2027", "securityCode": "123"}]
+	}`)
+	defer os.Remove(path)
+
+	items, err := ImportDashlaneJSON(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	if items[0].TypeName != "webforms.WebForm" || items[0].SecureContents.FormFields[0].Value != "alice" {
+		t.Errorf("Login not imported correctly: %v", items[0])
+	}
+	if items[1].TypeName != "securenotes.SecureNote" || items[1].SecureContents.Notes != "1234" {
+		t.Errorf("Note not imported correctly: %v", items[1])
+	}
+	card := items[2].SecureContents
+	if items[2].TypeName != "wallet.financial.CreditCard" {
+		t.Fatalf("Card not imported as credit card: %s", items[2].TypeName)
+	}
+	if field := card.FieldByPattern("expiry"); field == nil || field.Value != 202703 {
+		t.Errorf("Card expiry not imported correctly: %v", field)
+	}
+
+	// check that the standard template was not modified
+	template, _ := StandardTemplate("wallet.financial.CreditCard")
+	if template.FieldByPattern("ccnum").Value != nil {
+		t.Errorf("Standard template modified by import")
+	}
+}
+
+func TestImportEnpassJSON(t *testing.T) {
+	path := writeTestFile(t, "enpass-export.json", `{
+		"folders": [{"uuid": "f1", "title": "Work"}],
+		"items": [{
+			"title": "GitLab", "category": "login", "note": "", "folders": ["f1"], "trashed": 0,
+			"fields": [
+				{"label": "Username", "type": "username", "value": "bob", "sensitive": 0},
+				{"label": "Password", "type": "password", "value": "pwd", "sensitive": 1},
+				{"label": "Website", "type": "url", "value": "https://gitlab.com", "sensitive": 0},
+				{"label": "TOTP", "type": "totp", "value": "JBSWY3DP", "sensitive": 1},
+				{"label": "Security answer", "type": "text", "value": "blue", "sensitive": 1}
+			]
+		}]
+	}`)
+	defer os.Remove(path)
+
+	items, err := ImportEnpassJSON(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	item := items[0]
+	if len(item.OpenContents.Tags) != 1 || item.OpenContents.Tags[0] != "Work" {
+		t.Errorf("Folder not imported as tag: %v", item.OpenContents.Tags)
+	}
+	if field := item.SecureContents.FieldByPattern("one-time"); field == nil ||
+		field.ValueString() != "otpauth://totp/GitLab?secret=JBSWY3DP" {
+		t.Errorf("TOTP secret not imported: %v", field)
+	}
+	if field := item.SecureContents.FieldByPattern("answer"); field == nil || field.Kind != "concealed" {
+		t.Errorf("Custom field not imported: %v", field)
+	}
+}
+
+func TestImportBitwarden(t *testing.T) {
+	path := writeTestFile(t, "bitwarden-export.json", `{
+		"encrypted": false,
+		"folders": [{"id": "f1", "name": "Work/Cloud"}],
+		"items": [
+			{"id": "1", "folderId": "f1", "type": 1, "name": "AWS", "notes": "root account", "favorite": true,
+			 "fields": [{"name": "account id", "value": "1234", "type": 0},
+			            {"name": "recovery", "value": "abcd", "type": 1},
+			            {"name": "linked", "value": null, "type": 3, "linkedId": 100}],
+			 "login": {"uris": [{"match": null, "uri": "https://aws.amazon.com"}, {"uri": "https://signin.aws"}],
+			           "username": "alice", "password": "secret", "totp": "JBSWY3DPEHPK3PXP"}},
+			{"id": "2", "folderId": null, "type": 2, "name": "Alarm", "notes": "1234", "secureNote": {"type": 0}},
+			{"id": "3", "folderId": "missing", "type": 3, "name": "Visa", "notes": null,
+			 "card": {"cardholderName": "Alice", "brand": "Visa", "number": "4111111111111111",
+			          "expMonth": "3", "expYear": "2027", "code": "123"}}
+		]
+	}`)
+	defer os.Remove(path)
+
+	items, err := ImportBitwarden(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 items, got %d", len(items))
+	}
+	if items[0].TypeName != "system.folder.Regular" || items[0].Title != "Work/Cloud" {
+		t.Errorf("Folder not imported correctly: %v", items[0])
+	}
+
+	login := items[1]
+	content := login.SecureContents
+	if login.TypeName != "webforms.WebForm" || login.FolderUuid != "f1" || login.FaveIndex == 0 {
+		t.Errorf("Login not imported correctly: %v", login.Item)
+	}
+	if content.Username() != "alice" || content.Password() != "secret" ||
+		content.PrimaryURL() != "https://aws.amazon.com" || len(content.Urls) != 2 ||
+		content.Notes != "root account" {
+		t.Errorf("Login content not imported correctly: %v", content)
+	}
+	if content.OTPSecret() != "otpauth://totp/AWS?secret=JBSWY3DPEHPK3PXP" {
+		t.Errorf("Unexpected OTP secret '%s'", content.OTPSecret())
+	}
+	if content.FieldValue("account id") != "1234" || content.FieldValue("recovery") != "abcd" ||
+		content.FieldByPattern("linked") != nil {
+		t.Errorf("Custom fields not imported correctly: %v", content.Sections)
+	}
+
+	if items[2].TypeName != "securenotes.SecureNote" || items[2].SecureContents.Notes != "1234" {
+		t.Errorf("Note not imported correctly: %v", items[2])
+	}
+	card := items[3]
+	if card.TypeName != "wallet.financial.CreditCard" || card.FolderUuid != "" ||
+		card.SecureContents.FieldValue("ccnum") != "4111111111111111" {
+		t.Errorf("Card not imported correctly: %v", card)
+	}
+	for _, item := range items[1:] {
+		if err := ValidateContent(item.TypeName, item.SecureContents); err != nil {
+			t.Errorf("Imported item '%s' is not valid: %v", item.Title, err)
+		}
+	}
+}
+
+func TestExportBitwarden(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder, _ := vault.AddItem("Work", "system.folder.Regular", ItemContent{})
+	login := NewLoginContent("alice", "secret", "https://github.com")
+	login.addCustomField("pin", "1234", true)
+	item, _ := vault.AddItem("GitHub", "webforms.WebForm", login)
+	item.FolderUuid = folder.Uuid
+	item.Save()
+	card := newTemplateContent("wallet.financial.CreditCard", map[string]string{
+		"ccnum":  "4111111111111111",
+		"expiry": "03/2027",
+	})
+	vault.AddItem("Visa", "wallet.financial.CreditCard", card)
+	trashed, _ := vault.AddItem("Old", "webforms.WebForm", newTestContent("https://old.com"))
+	trashed.Trashed = true
+	trashed.Save()
+
+	items, err := vault.ListItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := os.TempDir() + "/bitwarden-export.json"
+	defer os.Remove(path)
+	err = ExportBitwarden(items, map[string]string{folder.Uuid: "Work"}, path)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// importing the exported file should produce the same items
+	imported, err := ImportBitwarden(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	byTitle := map[string]ExportedItem{}
+	for _, item := range imported {
+		byTitle[item.Title] = item
+	}
+	if len(imported) != 3 || byTitle["Work"].TypeName != "system.folder.Regular" {
+		t.Fatalf("Unexpected items exported: %v", imported)
+	}
+	github := byTitle["GitHub"]
+	if github.FolderUuid != folder.Uuid || github.SecureContents.Username() != "alice" ||
+		github.SecureContents.Password() != "secret" || github.SecureContents.FieldValue("pin") != "1234" {
+		t.Errorf("Login not exported correctly: %v", github)
+	}
+	visa := byTitle["Visa"].SecureContents
+	if visa.FieldValue("ccnum") != "4111111111111111" || visa.FieldByPattern("expiry").Value != 202703 {
+		t.Errorf("Card not exported correctly: %v", visa)
+	}
+}
@@ -0,0 +1,337 @@
+package onepass
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// RebuildIndex regenerates the vault's contents.js index from the
+// metadata in the vault's item files, eg. if contents.js has been lost
+// or corrupted. Entries for items without an item file are removed and
+// entries are added for item files which are not in the index. Items
+// which are already in the index keep their order, unless the vault's
+// NoIndex option is set, in which case the index is regenerated from
+// scratch. The previous index, if any, is saved to contents.js.bak.
+//
+// The vault does not need to be unlocked.
+func (vault *Vault) RebuildIndex() error {
+	contentsIndex := vault.contentsIndex()
+	contentsIndex.mu.Lock()
+	defer contentsIndex.mu.Unlock()
+	// queued updates are also in the item files
+	contentsIndex.queued = nil
+	contentsIndex.outdated = false
+
+	contentsPath := vault.DataDir() + "/contents.js"
+	data, err := ioutil.ReadFile(contentsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		err = ioutil.WriteFile(contentsPath+".bak", data, 0644)
+		if err != nil {
+			return fmt.Errorf("Failed to back up contents.js: %v", err)
+		}
+	}
+
+	entries, err := vault.readContentsIndex()
+	if err != nil || vault.NoIndex {
+		entries = nil
+	}
+	items, unreadable, err := vault.readItemFiles()
+	if err != nil {
+		return err
+	}
+	itemFiles := map[string]Item{}
+	for _, item := range items {
+		itemFiles[item.Uuid] = item
+	}
+	for _, uuid := range unreadable {
+		itemFiles[uuid] = Item{}
+	}
+
+	// keep the order of items which are already listed
+	// and the entries of items whose files cannot be read
+	index := [][]interface{}{}
+	listed := map[string]bool{}
+	for _, entry := range entries {
+		entryItem, err := parseContentsEntry(entry)
+		if err != nil || listed[entryItem.Uuid] {
+			continue
+		}
+		item, ok := itemFiles[entryItem.Uuid]
+		if !ok {
+			continue
+		}
+		if item.Uuid == "" {
+			index = append(index, entry)
+		} else {
+			index = append(index, item.contentsEntry())
+		}
+		listed[entryItem.Uuid] = true
+	}
+	for _, item := range items {
+		if !listed[item.Uuid] {
+			index = append(index, item.contentsEntry())
+		}
+	}
+	return writeContentsIndex(contentsPath, index)
+}
+
+func (vault *Vault) readContentsIndex() ([][]interface{}, error) {
+	var entries [][]interface{}
+	err := jsonutil.ReadFile(vault.DataDir()+"/contents.js", &entries)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read contents.js: %v", err)
+	}
+	return entries, nil
+}
+
+// readItemFiles reads the item files in the vault's data directory
+// as they are stored, without revealing private titles. Returns the
+// items and the UUIDs of any item files which could not be read.
+// Conflicted copies of item files are skipped.
+func (vault *Vault) readItemFiles() (items []Item, unreadable []string, err error) {
+	dirEntries, err := ioutil.ReadDir(vault.DataDir())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range dirEntries {
+		if path.Ext(entry.Name()) != ".1password" || conflictedCopyRegex.MatchString(entry.Name()) {
+			continue
+		}
+		uuid := strings.TrimSuffix(entry.Name(), ".1password")
+		item := Item{vault: vault}
+		err := jsonutil.ReadFile(vault.DataDir()+"/"+entry.Name(), &item)
+		if err != nil || item.Uuid != uuid {
+			unreadable = append(unreadable, uuid)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, unreadable, nil
+}
+
+// parseContentsEntry is a version of readContentsEntry()
+// which checks the types of the values in the entry
+func parseContentsEntry(entry []interface{}) (Item, error) {
+	if len(entry) < 8 {
+		return Item{}, fmt.Errorf("expected 8 values, found %d", len(entry))
+	}
+	stringValues := map[int]bool{0: true, 1: true, 2: true, 3: true, 5: true, 7: true}
+	for i, value := range entry[0:8] {
+		_, isString := value.(string)
+		_, isNumber := value.(float64)
+		if (stringValues[i] && !isString) || (i == 4 && !isNumber) {
+			return Item{}, fmt.Errorf("value %d has the wrong type", i)
+		}
+	}
+	return readContentsEntry(entry), nil
+}
+
+// maximum number of updates which are queued during a
+// batch before they are written to contents.js
+const maxQueuedIndexUpdates = 100
+
+// indexUpdate is a change to an entry in contents.js
+type indexUpdate struct {
+	uuid string
+	// new entry for the item, or nil to remove the entry
+	entry []interface{}
+}
+
+// contentsIndex serializes updates to a vault's contents.js
+// file within the process and queues them while a batch of
+// changes is being made. See Vault.BeginBatch()
+type contentsIndex struct {
+	mu sync.Mutex
+	// number of BeginBatch() calls without a matching EndBatch()
+	batchDepth int
+	// number of batches in progress which queue all changes until
+	// they end, rather than at most maxQueuedIndexUpdates changes.
+	// See Vault.SaveItems()
+	unbounded int
+	queued    []indexUpdate
+	// set if items were changed without updating the index
+	// because the vault's NoIndex option was set
+	outdated bool
+}
+
+// updateIndex sets or removes the contents.js entry for an
+// item, unless the vault's NoIndex option is set
+func (vault *Vault) updateIndex(uuid string, entry []interface{}) error {
+	index := vault.contentsIndex()
+	if vault.NoIndex {
+		index.mu.Lock()
+		index.outdated = true
+		index.mu.Unlock()
+		return nil
+	}
+	return index.update(vault.DataDir(), uuid, entry)
+}
+
+// IndexOutdated returns true if items have been changed without
+// updating the contents.js index because the vault's NoIndex option
+// is set. Use RebuildIndex() to bring the index up to date.
+func (vault *Vault) IndexOutdated() bool {
+	index := vault.contentsIndex()
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	return index.outdated
+}
+
+// map of vault data dir -> index
+var contentsIndexes = struct {
+	sync.Mutex
+	indexes map[string]*contentsIndex
+}{indexes: map[string]*contentsIndex{}}
+
+func (vault *Vault) contentsIndex() *contentsIndex {
+	dataDir := filepath.Clean(vault.DataDir())
+	contentsIndexes.Lock()
+	defer contentsIndexes.Unlock()
+	index, ok := contentsIndexes.indexes[dataDir]
+	if !ok {
+		index = &contentsIndex{}
+		contentsIndexes.indexes[dataDir] = index
+	}
+	return index
+}
+
+// BeginBatch starts a batch of changes to the vault's items, such as an
+// import. Until the matching EndBatch() call, changes to the contents.js
+// index are queued and written together, instead of the index being
+// rewritten for every change. Batches may be nested.
+//
+// If the process exits before EndBatch() is called, the index may be
+// missing up to 100 of the changed items. Use RebuildIndex() to fix it.
+func (vault *Vault) BeginBatch() {
+	index := vault.contentsIndex()
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.batchDepth++
+}
+
+// EndBatch ends a batch of changes started with BeginBatch()
+// and writes any queued changes to the contents.js index
+func (vault *Vault) EndBatch() error {
+	index := vault.contentsIndex()
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if index.batchDepth > 0 {
+		index.batchDepth--
+	}
+	if index.batchDepth > 0 {
+		return nil
+	}
+	return index.flush(vault.DataDir())
+}
+
+// SaveItems saves a set of items, which may be new or existing items
+// in the vault, and then updates the contents.js index once with the
+// changes to all of them. This is much faster than calling Save() for
+// each item in large vaults.
+//
+// If saving an item fails, the remaining items are not saved and the
+// index is updated for the items which were saved.
+func (vault *Vault) SaveItems(items []Item) error {
+	index := vault.contentsIndex()
+	vault.BeginBatch()
+	index.mu.Lock()
+	index.unbounded++
+	index.mu.Unlock()
+
+	var err error
+	for i := range items {
+		err = items[i].Save()
+		if err != nil {
+			break
+		}
+	}
+
+	index.mu.Lock()
+	index.unbounded--
+	index.mu.Unlock()
+	endErr := vault.EndBatch()
+	if err == nil {
+		err = endErr
+	}
+	return err
+}
+
+// update sets the contents.js entry for an item, or removes the entry
+// if entry is nil. The change is written immediately unless a batch is
+// in progress.
+func (index *contentsIndex) update(dataDir string, uuid string, entry []interface{}) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.queued = append(index.queued, indexUpdate{uuid: uuid, entry: entry})
+	if index.batchDepth > 0 && (index.unbounded > 0 || len(index.queued) < maxQueuedIndexUpdates) {
+		return nil
+	}
+	return index.flush(dataDir)
+}
+
+// flush merges the queued updates with the entries in contents.js
+// by UUID and writes the result. index.mu must be held by the caller.
+func (index *contentsIndex) flush(dataDir string) error {
+	if len(index.queued) == 0 {
+		return nil
+	}
+	updates := index.queued
+	index.queued = nil
+
+	contentsPath := dataDir + "/contents.js"
+	var entries [][]interface{}
+	err := jsonutil.ReadFile(contentsPath, &entries)
+	if err != nil {
+		return fmt.Errorf("Failed to read contents.js: %v", err)
+	}
+	positions := map[string]int{}
+	for i, entry := range entries {
+		if item, err := parseContentsEntry(entry); err == nil {
+			positions[item.Uuid] = i
+		}
+	}
+	removed := map[int]bool{}
+	for _, update := range updates {
+		i, ok := positions[update.uuid]
+		switch {
+		case update.entry == nil && ok:
+			removed[i] = true
+			delete(positions, update.uuid)
+		case update.entry == nil:
+			// already removed
+		case ok:
+			entries[i] = update.entry
+		default:
+			positions[update.uuid] = len(entries)
+			entries = append(entries, update.entry)
+		}
+	}
+	newEntries := [][]interface{}{}
+	for i, entry := range entries {
+		if !removed[i] {
+			newEntries = append(newEntries, entry)
+		}
+	}
+	return writeContentsIndex(contentsPath, newEntries)
+}
+
+// writeContentsIndex replaces contents.js with entries. The new index
+// is written to a temporary file first so that readers never see a
+// partially written index. See jsonutil.WriteFileAtomic()
+func writeContentsIndex(contentsPath string, entries [][]interface{}) error {
+	err := jsonutil.WriteFileAtomic(contentsPath, entries)
+	if err != nil {
+		return fmt.Errorf("Failed to update contents.js: %v", err)
+	}
+	return nil
+}
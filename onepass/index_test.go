@@ -0,0 +1,199 @@
+package onepass
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestRebuildLostIndex(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+	for _, title := range []string{"First", "Second"} {
+		_, err = vault.AddItem(title, "securenotes.SecureNote", newTestContent(title+".com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Remove(vault.DataDir() + "/contents.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := vault.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(problems) != 1 || !problems[0].Repairable {
+		t.Errorf("Unexpected problems with missing index: %+v", problems)
+	}
+
+	err = vault.RebuildIndex()
+	if err != nil {
+		t.Fatalf("Rebuilding index failed: %v", err)
+	}
+	entries, err := vault.readContentsIndex()
+	if err != nil {
+		t.Fatalf("Failed to read rebuilt index: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 entries in rebuilt index, found %d", len(entries))
+	}
+	problems, err = vault.Verify()
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Unexpected problems after rebuilding index: %+v, %v", problems, err)
+	}
+}
+
+func TestNoIndex(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+	first, err := vault.AddItem("First", "securenotes.SecureNote", newTestContent("first.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contentsPath := vault.DataDir() + "/contents.js"
+	err = ioutil.WriteFile(contentsPath, []byte("[[\"truncated"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// saving items fails with a damaged index unless NoIndex is set
+	_, err = vault.AddItem("Second", "securenotes.SecureNote", newTestContent("second.com"))
+	if err == nil {
+		t.Errorf("Expected save to fail with damaged index")
+	}
+	vault.NoIndex = true
+	_, err = vault.AddItem("Third", "securenotes.SecureNote", newTestContent("third.com"))
+	if err != nil {
+		t.Fatalf("Save with NoIndex failed: %v", err)
+	}
+	err = first.Remove()
+	if err != nil {
+		t.Fatalf("Remove with NoIndex failed: %v", err)
+	}
+	if data, _ := ioutil.ReadFile(contentsPath); string(data) != "[[\"truncated" {
+		t.Errorf("Index was changed with NoIndex set: %s", data)
+	}
+	if !vault.IndexOutdated() {
+		t.Errorf("Index not reported as outdated after changes with NoIndex set")
+	}
+	items, err := vault.ListItems()
+	if err != nil || len(items) != 2 {
+		t.Errorf("Expected 2 items listed from item files, got %d, %v", len(items), err)
+	}
+
+	err = vault.RebuildIndex()
+	if err != nil {
+		t.Fatalf("Rebuilding index failed: %v", err)
+	}
+	vault.NoIndex = false
+	if vault.IndexOutdated() {
+		t.Errorf("Index reported as outdated after rebuilding it")
+	}
+	problems, err := vault.Verify()
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Unexpected problems after rebuilding index: %+v, %v", problems, err)
+	}
+}
+
+func TestConcurrentAndBatchedSaves(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+
+	// concurrent saves must not overwrite each other's
+	// contents.js entries
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := vault.AddItem(fmt.Sprintf("Item %d", i), "securenotes.SecureNote", newTestContent("site.com"))
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	entries, err := vault.readContentsIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 20 {
+		t.Errorf("Expected 20 entries after concurrent saves, found %d", len(entries))
+	}
+
+	// changes during a batch are written when it ends
+	vault.BeginBatch()
+	item, err := vault.AddItem("Batched", "securenotes.SecureNote", newTestContent("site.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = vault.readContentsIndex()
+	if len(entries) != 20 {
+		t.Errorf("Index was updated during batch")
+	}
+	err = item.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vault.EndBatch()
+	if err != nil {
+		t.Fatalf("Ending batch failed: %v", err)
+	}
+	problems, err := vault.Verify()
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Unexpected problems after batch: %+v, %v", problems, err)
+	}
+}
+
+func TestSaveItems(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []Item{}
+	for i := 0; i < maxQueuedIndexUpdates+20; i++ {
+		item := Item{
+			Title:         fmt.Sprintf("Item %d", i),
+			SecurityLevel: "SL5",
+			TypeName:      "securenotes.SecureNote",
+			Uuid:          newItemId(),
+			vault:         &vault,
+		}
+		err = item.SetContent(newTestContent("site.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+
+	// the index is only written once all items are saved
+	saved := 0
+	vault.OnItemChange = func(change ItemChange) {
+		saved++
+		entries, _ := vault.readContentsIndex()
+		if len(entries) != 0 {
+			t.Fatalf("Index was updated after %d of %d items were saved", saved, len(items))
+		}
+	}
+	err = vault.SaveItems(items)
+	if err != nil {
+		t.Fatalf("Saving items failed: %v", err)
+	}
+	vault.OnItemChange = nil
+	if saved != len(items) || items[0].UpdatedAt == 0 {
+		t.Errorf("Items were not saved")
+	}
+	entries, err := vault.readContentsIndex()
+	if err != nil || len(entries) != len(items) {
+		t.Errorf("Expected %d index entries, found %d (%v)", len(items), len(entries), err)
+	}
+}
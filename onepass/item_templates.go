@@ -195,6 +195,54 @@ const itemTemplateData = `{
       }
     ]
   },
+  "wallet.computer.APICredential": {
+    "sections": [
+      {
+        "name": "",
+        "title": "",
+        "fields": [
+          {
+            "k": "string",
+            "n": "provider",
+            "t": "provider",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "username",
+            "t": "key id",
+            "v": null
+          },
+          {
+            "k": "concealed",
+            "n": "credential",
+            "t": "secret",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "scopes",
+            "t": "scopes",
+            "v": null
+          },
+          {
+            "k": "date",
+            "n": "expires",
+            "t": "expiry date",
+            "v": null
+          },
+          {
+            "k": "URL",
+            "n": "hostname",
+            "t": "endpoint",
+            "v": null
+          }
+        ]
+      }
+    ],
+    "fields": [],
+    "URLs": null
+  },
   "wallet.computer.Database": {
     "sections": [
       {
@@ -237,6 +285,12 @@ const itemTemplateData = `{
             "t": "password",
             "v": null
           },
+          {
+            "k": "string",
+            "n": "sslmode",
+            "t": "SSL mode",
+            "v": null
+          },
           {
             "k": "string",
             "n": "sid",
@@ -278,6 +332,12 @@ const itemTemplateData = `{
             "n": "reg_code",
             "t": "license key",
             "v": null
+          },
+          {
+            "k": "date",
+            "n": "expiry_date",
+            "t": "expiry date",
+            "v": null
           }
         ]
       },
@@ -1023,6 +1083,132 @@ const itemTemplateData = `{
     "fields": [],
     "URLs": null
   },
+  "wallet.onlineservices.Email.v2": {
+    "sections": [
+      {
+        "name": "",
+        "title": "",
+        "fields": [
+          {
+            "k": "menu",
+            "n": "pop_type",
+            "t": "type",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "pop_username",
+            "t": "username",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "pop_server",
+            "t": "server",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "pop_port",
+            "t": "port number",
+            "v": null
+          },
+          {
+            "k": "concealed",
+            "n": "pop_password",
+            "t": "password",
+            "v": null
+          },
+          {
+            "k": "menu",
+            "n": "pop_security",
+            "t": "security",
+            "v": null
+          },
+          {
+            "k": "menu",
+            "n": "pop_authentication",
+            "t": "auth method",
+            "v": null
+          }
+        ]
+      },
+      {
+        "name": "SMTP",
+        "title": "SMTP",
+        "fields": [
+          {
+            "k": "string",
+            "n": "smtp_server",
+            "t": "SMTP server",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "smtp_port",
+            "t": "port number",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "smtp_username",
+            "t": "username",
+            "v": null
+          },
+          {
+            "k": "concealed",
+            "n": "smtp_password",
+            "t": "password",
+            "v": null
+          },
+          {
+            "k": "menu",
+            "n": "smtp_security",
+            "t": "security",
+            "v": null
+          },
+          {
+            "k": "menu",
+            "n": "smtp_authentication",
+            "t": "auth method",
+            "v": null
+          }
+        ]
+      },
+      {
+        "name": "Contact Information",
+        "title": "Contact Information",
+        "fields": [
+          {
+            "k": "string",
+            "n": "provider",
+            "t": "provider",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "provider_website",
+            "t": "provider's website",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "phone_local",
+            "t": "phone (local)",
+            "v": null
+          },
+          {
+            "k": "string",
+            "n": "phone_tollfree",
+            "t": "phone (toll free)",
+            "v": null
+          }
+        ]
+      }
+    ],
+    "fields": [],
+    "URLs": null
+  },
   "webforms.WebForm": {
     "sections": [],
     "fields": [
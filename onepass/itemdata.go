@@ -6,6 +6,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/robertknight/1pass/onepass/totp"
 )
 
 type ItemType struct {
@@ -13,6 +15,8 @@ type ItemType struct {
 	Name string
 	// a short alias for this item type
 	ShortAlias string
+	// other names which are accepted in place of ShortAlias
+	Aliases []string
 }
 
 // Decrypted contents of an item, consisting primarily
@@ -22,6 +26,28 @@ type ItemContent struct {
 	Urls     []ItemUrl     `json:"URLs"`
 	Notes    string        `json:"notesPlain"`
 
+	// tags stored in the encrypted content rather than in the
+	// item's plaintext OpenContents. See Item.SetTags()
+	PrivateTags []string `json:"privateTags,omitempty"`
+
+	// title and location of the item in vaults with private
+	// titles. See VaultOptions
+	PrivateTitle    string `json:"privateTitle,omitempty"`
+	PrivateLocation string `json:"privateLocation,omitempty"`
+
+	// recipe for deriving the item's password, which is used
+	// instead of storing it. See DerivePassword()
+	PasswordRecipe *PasswordRecipe `json:"passwordRecipe,omitempty"`
+
+	// rules which the item's website applies to passwords, used
+	// when generating new passwords for the item.
+	// See ParsePasswordPolicy()
+	PasswordPolicy string `json:"passwordPolicy,omitempty"`
+
+	// previous passwords of the item, oldest first.
+	// See RecordPasswordChanges()
+	PasswordHistory []PasswordHistoryEntry `json:"passwordHistory,omitempty"`
+
 	// additional fields used only for
 	// web forms
 	FormFields []WebFormField `json:"fields"`
@@ -39,6 +65,16 @@ type ItemOpenContents struct {
 	// Supported values are 'Always' (show everywhere)
 	// and 'Never' (never show in browser)
 	Scope string `json:"scope"`
+
+	// Name of a field whose value is shown when listing items
+	// with their details, eg. 'account number'. This is
+	// specific to 1pass and is ignored by other 1Password apps.
+	// See ItemContent.SummaryValue()
+	SummaryField string `json:"summaryField,omitempty"`
+
+	// Short name which refers to this item in patterns, eg. 'gh'.
+	// This is specific to 1pass. See client.MatchItems()
+	Alias string `json:"alias,omitempty"`
 }
 
 // Section of an item's contents
@@ -115,6 +151,11 @@ func FieldValueFromString(kind string, str string) (interface{}, error) {
 		}
 		// convert to int with digits YYYYMM
 		return date.Year()*100 + int(date.Month()), nil
+	case "otp":
+		if _, err := totp.Parse(str); err != nil {
+			return nil, err
+		}
+		return strings.TrimSpace(str), nil
 	default:
 		return str, nil
 	}
@@ -211,6 +252,10 @@ var ItemTypes = map[string]ItemType{
 		Name:       "Bank Account",
 		ShortAlias: "bank",
 	},
+	"wallet.computer.APICredential": ItemType{
+		Name:       "API Credential",
+		ShortAlias: "api",
+	},
 	"wallet.computer.Database": ItemType{
 		Name:       "Database",
 		ShortAlias: "db",
@@ -246,6 +291,7 @@ var ItemTypes = map[string]ItemType{
 	"wallet.computer.License": ItemType{
 		Name:       "Software License",
 		ShortAlias: "software",
+		Aliases:    []string{"license"},
 	},
 	"identities.Identity": ItemType{
 		Name:       "Identity",
@@ -271,6 +317,7 @@ const (
 	GenderField
 	MenuField
 	ConcealedField
+	OTPField
 )
 
 type FieldType int
@@ -287,6 +334,9 @@ var FieldKindMap = map[string]FieldType{
 	"gender":    GenderField,
 	"menu":      MenuField,
 	"concealed": ConcealedField,
+	// one-time password secrets, which the official
+	// 1Password apps store as 'concealed' fields
+	"otp": OTPField,
 }
 
 func (item ItemContent) String() string {
@@ -323,6 +373,13 @@ func (item ItemContent) String() string {
 			result += fmt.Sprintf("  %s (%s): %s\n", field.Name, field.Type, field.Value)
 		}
 	}
+	if item.PasswordRecipe != nil {
+		if len(result) > 0 {
+			result += "\n"
+		}
+		recipe := item.PasswordRecipe
+		result += fmt.Sprintf("Derived Password:\n  site: %s, counter: %d, length: %d\n", recipe.Site, recipe.Counter, recipe.Length)
+	}
 	if len(item.HtmlAction) > 0 {
 		if len(result) > 0 {
 			result += "\n"
@@ -356,6 +413,93 @@ func (item *ItemContent) FormFieldByPattern(pattern string) *WebFormField {
 	return nil
 }
 
+// Username returns the item's username. For logins this is the value
+// of the form field designated as the username, for other items it is
+// the value of a 'username' field.
+func (item *ItemContent) Username() string {
+	for _, field := range item.FormFields {
+		if field.Designation == "username" {
+			return field.Value
+		}
+	}
+	return item.FieldValue("username")
+}
+
+// Password returns the item's password. For logins this is the value
+// of the form field designated as the password, for other items it is
+// the value of a 'password' field.
+func (item *ItemContent) Password() string {
+	for _, field := range item.FormFields {
+		if field.Designation == "password" {
+			return field.Value
+		}
+	}
+	return item.FieldValue("password")
+}
+
+// SetPassword replaces the item's password, in the same field that
+// Password() reads it from. Returns false if the item has no
+// password field.
+func (item *ItemContent) SetPassword(password string) bool {
+	for i, field := range item.FormFields {
+		if field.Designation == "password" {
+			item.FormFields[i].Value = password
+			return true
+		}
+	}
+	for i, section := range item.Sections {
+		for k, field := range section.Fields {
+			if field.Name == "password" {
+				item.Sections[i].Fields[k].Value = password
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrimaryURL returns the item's main website. For logins this is the
+// URL labelled 'website' or failing that, the first URL. For other
+// items it is the value of a 'website' or 'url' field, or of the first
+// field with the 'URL' kind.
+func (item *ItemContent) PrimaryURL() string {
+	for _, url := range item.Urls {
+		if url.Label == "website" {
+			return url.Url
+		}
+	}
+	if len(item.Urls) > 0 {
+		return item.Urls[0].Url
+	}
+	for _, name := range []string{"website", "url"} {
+		if value := item.FieldValue(name); value != "" {
+			return value
+		}
+	}
+	for _, section := range item.Sections {
+		for _, field := range section.Fields {
+			if field.Kind == "URL" && field.ValueString() != "" {
+				return field.ValueString()
+			}
+		}
+	}
+	return ""
+}
+
+// OTPSecret returns the value of the item's one-time password field.
+// This is usually an 'otpauth://' URI but may be a bare TOTP secret.
+// See ItemField.IsOTP()
+func (item *ItemContent) OTPSecret() string {
+	for _, section := range item.Sections {
+		for _, field := range section.Fields {
+			if value := field.ValueString(); value != "" && field.IsOTP() {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
 func (item *ItemContent) UrlByPattern(pattern string) *ItemUrl {
 	patternLower := strings.ToLower(pattern)
 	for urlId, url := range item.Urls {
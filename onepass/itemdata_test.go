@@ -0,0 +1,89 @@
+package onepass
+
+import (
+	"testing"
+)
+
+func TestContentAccessors(t *testing.T) {
+	login := NewLoginContent("alice", "secret", "https://example.com")
+	login.Urls = append([]ItemUrl{{Label: "other", Url: "https://other.com"}}, login.Urls...)
+	login.AddField("", NewOtpField("otpauth://totp/example?secret=ABC"))
+
+	server := ItemContent{Sections: []ItemSection{{
+		Fields: []ItemField{
+			{Kind: "string", Name: "url", Value: "ssh://host.example.com"},
+			{Kind: "string", Name: "username", Value: "root"},
+			{Kind: "concealed", Name: "password", Value: "hunter2"},
+		},
+	}}}
+
+	router := ItemContent{Sections: []ItemSection{{
+		Fields: []ItemField{
+			{Kind: "URL", Name: "hostname", Value: "192.168.0.1"},
+			{Kind: "concealed", Name: "2fa", Value: "otpauth://totp/router?secret=DEF"},
+		},
+	}}}
+
+	membership := ItemContent{Sections: []ItemSection{{
+		Fields: []ItemField{
+			{Kind: "otp", Name: "code", Value: "JBSWY3DPEHPK3PXP"},
+		},
+	}}}
+
+	testCases := []struct {
+		content  ItemContent
+		username string
+		password string
+		url      string
+		otp      string
+	}{
+		{login, "alice", "secret", "https://example.com", "otpauth://totp/example?secret=ABC"},
+		{server, "root", "hunter2", "ssh://host.example.com", ""},
+		{router, "", "", "192.168.0.1", "otpauth://totp/router?secret=DEF"},
+		{membership, "", "", "", "JBSWY3DPEHPK3PXP"},
+		{ItemContent{}, "", "", "", ""},
+	}
+
+	for i, testCase := range testCases {
+		content := testCase.content
+		if content.Username() != testCase.username {
+			t.Errorf("%d: Expected username '%s', got '%s'", i, testCase.username, content.Username())
+		}
+		if content.Password() != testCase.password {
+			t.Errorf("%d: Expected password '%s', got '%s'", i, testCase.password, content.Password())
+		}
+		if content.PrimaryURL() != testCase.url {
+			t.Errorf("%d: Expected URL '%s', got '%s'", i, testCase.url, content.PrimaryURL())
+		}
+		if content.OTPSecret() != testCase.otp {
+			t.Errorf("%d: Expected OTP secret '%s', got '%s'", i, testCase.otp, content.OTPSecret())
+		}
+	}
+}
+
+func TestSummaryValue(t *testing.T) {
+	content := ItemContent{
+		Sections: []ItemSection{{Fields: []ItemField{
+			{Kind: "concealed", Name: "accountNo", Title: "account number", Value: "12345678"},
+			{Kind: "string", Name: "branch", Title: "branch", Value: "Cambridge"},
+			{Kind: "concealed", Name: "pin", Title: "pin", Value: "123"},
+		}}},
+		FormFields: []WebFormField{{Name: "password", Designation: "password", Type: "P", Value: "hunter22"}},
+	}
+	tests := []struct {
+		field string
+		value string
+	}{
+		{"account number", "••••5678"},
+		{"branch", "Cambridge"},
+		{"pin", "•••"},
+		{"password", "••••er22"},
+		{"missing", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if value := content.SummaryValue(test.field); value != test.value {
+			t.Errorf("Unexpected summary value for '%s': '%s'", test.field, value)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package onepass
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// MinPbkdfIterations is the number of PBKDF2 iterations below which
+// the encryption of a vault's keys is considered weak
+const MinPbkdfIterations = 10000
+
+// RecommendedPbkdfIterations is the default number of PBKDF2
+// iterations used by UpgradeKdf()
+const RecommendedPbkdfIterations = 100000
+
+// KeyIterations returns the lowest number of PBKDF2 iterations
+// used to encrypt any of the vault's keys with the master password
+func (vault *Vault) KeyIterations() (int, error) {
+	var keyList encryptionKeys
+	err := jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+	if err != nil {
+		return 0, errors.New("Failed to read encryption key file")
+	}
+	if len(keyList.List) == 0 {
+		return 0, errors.New("Vault has no encryption keys")
+	}
+	iterations := keyList.List[0].Iterations
+	for _, entry := range keyList.List[1:] {
+		if entry.Iterations < iterations {
+			iterations = entry.Iterations
+		}
+	}
+	return iterations, nil
+}
+
+// UpgradeKdf re-encrypts the vault's keys using the master password
+// with a new number of PBKDF2 iterations. The keys themselves and
+// therefore the encrypted items are unchanged.
+func (vault *Vault) UpgradeKdf(pwd []byte, iterations int) error {
+	if iterations < MinPbkdfIterations {
+		return fmt.Errorf("At least %d iterations are required", MinPbkdfIterations)
+	}
+	kdf, _, err := vault.KeyKdf()
+	if err != nil {
+		return err
+	}
+	if kdf != KdfPbkdf2 {
+		return fmt.Errorf("The vault's keys are derived using %s, not PBKDF2", kdf)
+	}
+	return vault.reencryptKeys(pwd, pwd, iterations)
+}
+
+// Schemes used to encrypt a vault's keys with the master password.
+// See VaultSecurity.KeyScheme
+const (
+	// AES-128 with a key and IV derived using PBKDF2-SHA1.
+	// This is the scheme used by 1Password
+	KeySchemeAes128Sha1 = "aes128-pbkdf2-sha1"
+
+	// AES-256 with a key and IV derived using PBKDF2-SHA256.
+	// Vaults using this scheme can only be unlocked by 1pass
+	KeySchemeAes256Sha256 = "aes256-pbkdf2-sha256"
+)
+
+func isKnownKeyScheme(scheme string) bool {
+	return scheme == "" || scheme == KeySchemeAes128Sha1 || scheme == KeySchemeAes256Sha256
+}
+
+// KeyScheme returns the scheme used to encrypt the vault's
+// keys with the master password
+func (vault *Vault) KeyScheme() (string, error) {
+	var keyList encryptionKeys
+	err := jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+	if err != nil {
+		return "", errors.New("Failed to read encryption key file")
+	}
+	if len(keyList.List) == 0 {
+		return "", errors.New("Vault has no encryption keys")
+	}
+	scheme := keyList.List[0].Scheme
+	if scheme == "" {
+		scheme = KeySchemeAes128Sha1
+	}
+	return scheme, nil
+}
+
+// Functions used to derive the key which encrypts a vault's keys
+// from the master password. See VaultSecurity.Kdf
+const (
+	KdfPbkdf2   = "pbkdf2"
+	KdfScrypt   = "scrypt"
+	KdfArgon2id = "argon2id"
+)
+
+// KdfParams holds the parameters for the scrypt and argon2id
+// key derivation functions
+type KdfParams struct {
+	// scrypt CPU/memory cost (N), block size (r)
+	// and parallelization (p) parameters
+	N int `json:"N,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+
+	// argon2id number of passes over the memory,
+	// memory size in KiB and number of threads
+	Time    int `json:"time,omitempty"`
+	Memory  int `json:"memory,omitempty"`
+	Threads int `json:"threads,omitempty"`
+}
+
+// DefaultKdfParams returns the parameters used for new vaults which
+// use the scrypt or argon2id KDFs, if no parameters are specified
+func DefaultKdfParams(kdf string) (KdfParams, error) {
+	switch kdf {
+	case KdfScrypt:
+		return KdfParams{N: 1 << 15, R: 8, P: 1}, nil
+	case KdfArgon2id:
+		return KdfParams{Time: 3, Memory: 64 * 1024, Threads: 4}, nil
+	default:
+		return KdfParams{}, fmt.Errorf("Unsupported key derivation function '%s'", kdf)
+	}
+}
+
+// validate checks that params are valid for kdf. params may be nil
+// for keys derived with PBKDF2
+func (params *KdfParams) validate(kdf string) error {
+	switch kdf {
+	case "", KdfPbkdf2:
+		return nil
+	case KdfScrypt:
+		if params == nil || params.N <= 1 || params.N&(params.N-1) != 0 || params.R <= 0 || params.P <= 0 {
+			return errors.New("Invalid scrypt parameters. N must be a power of 2 greater than 1 and r and p must be positive")
+		}
+	case KdfArgon2id:
+		if params == nil || params.Time <= 0 || params.Memory <= 0 || params.Threads <= 0 || params.Threads > 255 {
+			return errors.New("Invalid argon2id parameters. The time, memory and threads must be positive and threads at most 255")
+		}
+	default:
+		return fmt.Errorf("Unsupported key derivation function '%s'", kdf)
+	}
+	return nil
+}
+
+// KeyKdf returns the function used to derive the key which encrypts
+// the vault's keys from the master password and its parameters, which
+// are nil for PBKDF2. See KeyIterations()
+func (vault *Vault) KeyKdf() (string, *KdfParams, error) {
+	var keyList encryptionKeys
+	err := jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+	if err != nil {
+		return "", nil, errors.New("Failed to read encryption key file")
+	}
+	if len(keyList.List) == 0 {
+		return "", nil, errors.New("Vault has no encryption keys")
+	}
+	entry := keyList.List[0]
+	if entry.Kdf == "" {
+		return KdfPbkdf2, nil, nil
+	}
+	return entry.Kdf, entry.KdfParams, nil
+}
+
+// deriveKeyEncryptionKey derives the AES key and IV used to encrypt
+// one of the vault's keys from the master password, using the KDF and
+// the scheme specified by entry. For KDFs other than PBKDF2, only the
+// cipher of the scheme is used.
+func deriveKeyEncryptionKey(masterPwd []byte, salt []byte, entry encKeyEntry) (key []byte, iv []byte, err error) {
+	var keyLen int
+	var hashFunc func() hash.Hash
+	switch entry.Scheme {
+	case "", KeySchemeAes128Sha1:
+		keyLen, hashFunc = Aes128KeyLen, sha1.New
+	case KeySchemeAes256Sha256:
+		keyLen, hashFunc = Aes256KeyLen, sha256.New
+	default:
+		return nil, nil, fmt.Errorf("Unsupported key encryption scheme '%s'", entry.Scheme)
+	}
+	err = entry.KdfParams.validate(entry.Kdf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var derivedKey []byte
+	params := entry.KdfParams
+	switch entry.Kdf {
+	case "", KdfPbkdf2:
+		derivedKey = pbkdf2.Key(masterPwd, salt, entry.Iterations, keyLen+AesBlockLen, hashFunc)
+	case KdfScrypt:
+		derivedKey, err = scrypt.Key(masterPwd, salt, params.N, params.R, params.P, keyLen+AesBlockLen)
+		if err != nil {
+			return nil, nil, err
+		}
+	case KdfArgon2id:
+		derivedKey = argon2.IDKey(masterPwd, salt, uint32(params.Time), uint32(params.Memory),
+			uint8(params.Threads), uint32(keyLen+AesBlockLen))
+	}
+	return derivedKey[:keyLen], derivedKey[keyLen:], nil
+}
@@ -0,0 +1,48 @@
+package onepass
+
+import (
+	"testing"
+)
+
+func TestUpgradeKdf(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	iterations, err := vault.KeyIterations()
+	if err != nil || iterations != 100 {
+		t.Fatalf("Unexpected key iterations %d: %v", iterations, err)
+	}
+	item, err := vault.AddItem("Test", "webforms.WebForm", newTestContent("https://example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = vault.UpgradeKdf([]byte("test-pwd"), 50); err == nil {
+		t.Errorf("Expected error for too few iterations")
+	}
+	if err = vault.UpgradeKdf([]byte("wrong-pwd"), MinPbkdfIterations); err == nil {
+		t.Errorf("Expected error for wrong password")
+	}
+	err = vault.UpgradeKdf([]byte("test-pwd"), MinPbkdfIterations)
+	if err != nil {
+		t.Fatalf("Unable to upgrade KDF: %v", err)
+	}
+	iterations, _ = vault.KeyIterations()
+	if iterations != MinPbkdfIterations {
+		t.Errorf("Expected %d iterations after upgrade, got %d", MinPbkdfIterations, iterations)
+	}
+
+	vault.Lock()
+	err = vault.Unlock([]byte("test-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to unlock vault after upgrade: %v", err)
+	}
+	loaded, err := vault.LoadItem(item.Uuid)
+	if err == nil {
+		_, err = loaded.Content()
+	}
+	if err != nil {
+		t.Errorf("Unable to read item after upgrade: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package onepass
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// separates the master password from the digest of the key
+// file in passwords returned by KeyFilePassword()
+const keyFileSeparator = "\x00keyfile:"
+
+// KeyFilePassword combines a master password with the contents of a
+// key file. The result is used in place of the master password to
+// create, unlock or change the password of a vault which requires a
+// key file, so that both the password and the file are needed to
+// decrypt the vault's keys. Key files can contain any data but should
+// include at least 32 random bytes.
+func KeyFilePassword(pwd []byte, keyFilePath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(keyFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read key file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Key file '%s' is empty", keyFilePath)
+	}
+	digest := sha256.Sum256(data)
+	WipeBytes(data)
+	combined := make([]byte, 0, len(pwd)+len(keyFileSeparator)+hex.EncodedLen(len(digest)))
+	combined = append(combined, pwd...)
+	combined = append(combined, keyFileSeparator...)
+	return append(combined, hex.EncodeToString(digest[:])...), nil
+}
+
+// usesKeyFile returns true if pwd was returned by KeyFilePassword()
+func usesKeyFile(pwd []byte) bool {
+	return bytes.Contains(pwd, []byte(keyFileSeparator))
+}
+
+// RequiresKeyFile returns true if a key file is needed in addition
+// to the master password to unlock the vault. See KeyFilePassword()
+func (vault *Vault) RequiresKeyFile() (bool, error) {
+	var keyList encryptionKeys
+	err := jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+	if err != nil {
+		return false, errors.New("Failed to read encryption key file")
+	}
+	for _, entry := range keyList.List {
+		if entry.KeyFile {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UnlockWithKeyFile unlocks a vault which requires a key file
+// using the master password and the key file
+func (vault *Vault) UnlockWithKeyFile(pwd []byte, keyFilePath string) error {
+	combinedPwd, err := KeyFilePassword(pwd, keyFilePath)
+	if err != nil {
+		return err
+	}
+	defer WipeBytes(combinedPwd)
+	return vault.Unlock(combinedPwd)
+}
@@ -0,0 +1,417 @@
+package onepass
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeepMerged resolves a conflict by combining the two versions of
+// an item field by field. See MergeConflict() and ResolveSyncConflict()
+const KeepMerged = "merge"
+
+// MergeItems returns a copy of item in which the properties and
+// fields named in fromOther are replaced with those from other.
+// Fields are named as in the ItemDiff list returned by
+// DiffItems(item, other). Fields which only exist in one of the
+// versions are added or removed accordingly. The vault must be
+// unlocked if any fields from the items' content are named.
+func MergeItems(item Item, other Item, fromOther []string) (Item, error) {
+	merged := item
+	merged.OpenContents.Tags = append([]string{}, item.OpenContents.Tags...)
+	content, err := item.Content()
+	if err != nil {
+		return Item{}, err
+	}
+	otherContent, err := other.Content()
+	if err != nil {
+		return Item{}, err
+	}
+	for _, field := range fromOther {
+		switch field {
+		case "title":
+			merged.Title = other.Title
+			content.PrivateTitle = otherContent.PrivateTitle
+		case "type":
+			return Item{}, fmt.Errorf("The type of an item cannot be merged")
+		case "location":
+			merged.Location = other.Location
+			content.PrivateLocation = otherContent.PrivateLocation
+		case "folder":
+			merged.FolderUuid = other.FolderUuid
+		case "trashed":
+			merged.Trashed = other.Trashed
+		case "faveIndex":
+			merged.FaveIndex = other.FaveIndex
+		case "tags":
+			merged.OpenContents.Tags = append([]string{}, other.OpenContents.Tags...)
+		case "summaryField":
+			merged.OpenContents.SummaryField = other.OpenContents.SummaryField
+		case "alias":
+			merged.OpenContents.Alias = other.OpenContents.Alias
+		case "notes":
+			content.Notes = otherContent.Notes
+		case "privateTags":
+			content.PrivateTags = append([]string{}, otherContent.PrivateTags...)
+		default:
+			if !mergeContentField(&content, otherContent, field) {
+				return Item{}, fmt.Errorf("Unknown field '%s'", field)
+			}
+		}
+	}
+	return merged, merged.SetContent(content)
+}
+
+// mergeContentField replaces the field in content which has the name
+// 'name' in the list returned by contentFields() with the field of
+// the same name in other. Returns false if neither content has a
+// field with that name.
+func mergeContentField(content *ItemContent, other ItemContent, name string) bool {
+	if !hasContentField(*content, name) && !hasContentField(other, name) {
+		return false
+	}
+	if strings.HasPrefix(name, "URLs.") {
+		label := strings.TrimPrefix(name, "URLs.")
+		urls := []ItemUrl{}
+		for _, url := range content.Urls {
+			if url.Label != label {
+				urls = append(urls, url)
+			}
+		}
+		for _, url := range other.Urls {
+			if url.Label == label {
+				urls = append(urls, url)
+			}
+		}
+		content.Urls = urls
+		return true
+	}
+	if strings.HasPrefix(name, "fields.") {
+		fieldName := strings.TrimPrefix(name, "fields.")
+		fields := []WebFormField{}
+		for _, field := range content.FormFields {
+			if field.Name != fieldName {
+				fields = append(fields, field)
+			}
+		}
+		for _, field := range other.FormFields {
+			if field.Name == fieldName {
+				fields = append(fields, field)
+			}
+		}
+		content.FormFields = fields
+		return true
+	}
+
+	// remove the field from the section that contains it,
+	// then add the other version's field to the section with
+	// the same title, adding the section if necessary
+	for i, section := range content.Sections {
+		fields := []ItemField{}
+		for _, field := range section.Fields {
+			if sectionFieldName(section, field) != name {
+				fields = append(fields, field)
+			}
+		}
+		content.Sections[i].Fields = fields
+	}
+	for _, otherSection := range other.Sections {
+		for _, field := range otherSection.Fields {
+			if sectionFieldName(otherSection, field) != name {
+				continue
+			}
+			added := false
+			for i, section := range content.Sections {
+				if section.Title == otherSection.Title {
+					content.Sections[i].Fields = append(content.Sections[i].Fields, field)
+					added = true
+					break
+				}
+			}
+			if !added {
+				content.Sections = append(content.Sections, ItemSection{
+					Name:   otherSection.Name,
+					Title:  otherSection.Title,
+					Fields: []ItemField{field},
+				})
+			}
+		}
+	}
+	return true
+}
+
+func hasContentField(content ItemContent, name string) bool {
+	_, ok := findContentField(contentFields(content), name)
+	return ok
+}
+
+// sectionFieldName returns the name of a field in a section
+// in the list returned by contentFields()
+func sectionFieldName(section ItemSection, field ItemField) string {
+	if section.Title != "" {
+		return section.Title + "." + field.Title
+	}
+	return field.Title
+}
+
+// MergeConflict resolves a conflicted copy of an item by replacing
+// the fields of the current version named in fromCopy with those
+// from the conflicted copy. See MergeItems(). Passwords from the
+// conflicted copy which are not kept are added to the item's
+// password history and the conflicted copy is removed.
+func (vault *Vault) MergeConflict(conflict ConflictedCopy, fromCopy []string) error {
+	current, err := vault.LoadItem(conflict.Item.Uuid)
+	if err != nil {
+		return err
+	}
+	merged, err := MergeItems(current, conflict.Item, fromCopy)
+	if err != nil {
+		return err
+	}
+	_, err = mergePasswordHistory(&merged, conflict.Item)
+	if err != nil {
+		return err
+	}
+	err = merged.Save()
+	if err != nil {
+		return err
+	}
+	return os.Remove(conflict.Path)
+}
+
+// ResolveSyncConflict resolves a conflict reported by SyncVaults() by
+// saving the same version of the item in both vaults, which must be
+// unlocked. keep is KeepCurrent to keep the version in vault, KeepCopy
+// to keep the version in other, KeepMerged to keep the version in
+// vault with the fields named in fromOther replaced by those from
+// other, or KeepBoth to keep the version in vault and add the version
+// in other to both vaults as a new item. When only one version is
+// kept, passwords from the other version are added to its password
+// history.
+func ResolveSyncConflict(vault *Vault, other *Vault, conflict SyncConflict, keep string, fromOther []string) error {
+	var resolved Item
+	var err error
+	switch keep {
+	case KeepCurrent:
+		resolved = conflict.Item
+		_, err = mergePasswordHistory(&resolved, conflict.Other)
+	case KeepCopy:
+		resolved, err = copyToVault(conflict.Other, vault)
+		if err == nil {
+			resolved.CreatedAt = conflict.Item.CreatedAt
+			_, err = mergePasswordHistory(&resolved, conflict.Item)
+		}
+	case KeepMerged:
+		resolved, err = MergeItems(conflict.Item, conflict.Other, fromOther)
+		if err == nil {
+			_, err = mergePasswordHistory(&resolved, conflict.Other)
+		}
+	case KeepBoth:
+		var added Item
+		added, err = copyToVault(conflict.Other, vault)
+		if err != nil {
+			return err
+		}
+		added.Uuid = newItemId()
+		added.Title = fmt.Sprintf("%s (from %s)", added.Title, filepath.Base(other.Path))
+		err = added.Save()
+		if err == nil {
+			err = copyItem(added, other)
+		}
+		if err == nil {
+			err = copyItem(conflict.Item, other)
+		}
+		return err
+	default:
+		return fmt.Errorf("Unknown conflict resolution '%s'", keep)
+	}
+	if err != nil {
+		return err
+	}
+	err = resolved.Save()
+	if err != nil {
+		return err
+	}
+	return copyItem(resolved, other)
+}
+
+// copyToVault returns a copy of item which belongs to dest, with its
+// content re-encrypted using dest's key. The copy is not saved.
+func copyToVault(item Item, dest *Vault) (Item, error) {
+	content, err := item.Content()
+	if err != nil {
+		return Item{}, err
+	}
+	copied := item
+	copied.vault = dest
+	copied.signatureErr = nil
+	return copied, copied.SetContent(content)
+}
+
+// MergeStrategy determines which value MergeContent() keeps for
+// fields which have different values in the two versions of an item
+type MergeStrategy int
+
+const (
+	// keep the values from the first version
+	PreferFirst MergeStrategy = iota
+	// keep the values from the second version
+	PreferSecond
+)
+
+// NewerWins returns the strategy which keeps the values from the
+// version of an item which was updated most recently, given the
+// UpdatedAt times of the two versions
+func NewerWins(firstUpdatedAt uint64, secondUpdatedAt uint64) MergeStrategy {
+	if secondUpdatedAt > firstUpdatedAt {
+		return PreferSecond
+	}
+	return PreferFirst
+}
+
+// MergeContent combines two versions of an item's content. The
+// merged content has the union of the websites, private tags and
+// sections of both versions. Sections are matched by their internal
+// name, so that renamed sections are merged, and fields within them
+// are matched in the same way. For fields and other values which
+// differ, the value chosen by strategy is kept, unless it is empty.
+// Passwords which are replaced are added to the password history.
+func MergeContent(first ItemContent, second ItemContent, strategy MergeStrategy) ItemContent {
+	pick := func(firstValue string, secondValue string) string {
+		if firstValue == "" || (secondValue != "" && strategy == PreferSecond) {
+			return secondValue
+		}
+		return firstValue
+	}
+
+	merged := first
+	merged.Notes = pick(first.Notes, second.Notes)
+	merged.PrivateTitle = pick(first.PrivateTitle, second.PrivateTitle)
+	merged.PrivateLocation = pick(first.PrivateLocation, second.PrivateLocation)
+	merged.HtmlMethod = pick(first.HtmlMethod, second.HtmlMethod)
+	merged.HtmlAction = pick(first.HtmlAction, second.HtmlAction)
+	merged.HtmlId = pick(first.HtmlId, second.HtmlId)
+	if first.PasswordRecipe == nil || (second.PasswordRecipe != nil && strategy == PreferSecond) {
+		merged.PasswordRecipe = second.PasswordRecipe
+	}
+
+	merged.Urls = append([]ItemUrl{}, first.Urls...)
+	for _, url := range second.Urls {
+		found := false
+		for _, existing := range merged.Urls {
+			found = found || existing.Url == url.Url
+		}
+		if !found {
+			merged.Urls = append(merged.Urls, url)
+		}
+	}
+	merged.PrivateTags = append([]string{}, first.PrivateTags...)
+	for _, tag := range second.PrivateTags {
+		if !containsString(merged.PrivateTags, tag) {
+			merged.PrivateTags = append(merged.PrivateTags, tag)
+		}
+	}
+
+	merged.FormFields = append([]WebFormField{}, first.FormFields...)
+	for _, field := range second.FormFields {
+		i := findFormField(merged.FormFields, field)
+		if i == -1 {
+			merged.FormFields = append(merged.FormFields, field)
+		} else if pick(merged.FormFields[i].Value, field.Value) != merged.FormFields[i].Value {
+			merged.FormFields[i] = field
+		}
+	}
+
+	merged.Sections = []ItemSection{}
+	for _, section := range first.Sections {
+		section.Fields = append([]ItemField{}, section.Fields...)
+		merged.Sections = append(merged.Sections, section)
+	}
+	for _, section := range second.Sections {
+		i := findSection(merged.Sections, section)
+		if i == -1 {
+			merged.Sections = append(merged.Sections, section)
+			continue
+		}
+		mergedSection := &merged.Sections[i]
+		mergedSection.Title = pick(mergedSection.Title, section.Title)
+		for _, field := range section.Fields {
+			k := findSectionField(mergedSection.Fields, field)
+			if k == -1 {
+				mergedSection.Fields = append(mergedSection.Fields, field)
+			} else if pick(mergedSection.Fields[k].ValueString(), field.ValueString()) != mergedSection.Fields[k].ValueString() {
+				mergedSection.Fields[k] = field
+			}
+		}
+	}
+
+	merged.PasswordHistory = nil
+	for _, entry := range append(append([]PasswordHistoryEntry{}, first.PasswordHistory...), second.PasswordHistory...) {
+		if !containsHistoryEntry(merged.PasswordHistory, entry) {
+			merged.PasswordHistory = append(merged.PasswordHistory, entry)
+		}
+	}
+	now := time.Now()
+	merged.RecordPasswordChanges(first, now)
+	merged.RecordPasswordChanges(second, now)
+	sort.SliceStable(merged.PasswordHistory, func(i, k int) bool {
+		return merged.PasswordHistory[i].Time < merged.PasswordHistory[k].Time
+	})
+	return merged
+}
+
+// findFormField returns the index of the field in fields with the
+// same name as field, or the same designation if it has no name
+func findFormField(fields []WebFormField, field WebFormField) int {
+	for i, other := range fields {
+		if (field.Name != "" && other.Name == field.Name) ||
+			(field.Name == "" && other.Name == "" && other.Designation == field.Designation) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findSection returns the index of the section in sections with
+// the same internal name as section, or the same title if either
+// section has no internal name
+func findSection(sections []ItemSection, section ItemSection) int {
+	for i, other := range sections {
+		if section.Name != "" && other.Name != "" {
+			if other.Name == section.Name {
+				return i
+			}
+		} else if other.Title == section.Title {
+			return i
+		}
+	}
+	return -1
+}
+
+// findSectionField returns the index of the field in fields with the
+// same internal name as field, or the same title if either field
+// has no internal name
+func findSectionField(fields []ItemField, field ItemField) int {
+	for i, other := range fields {
+		if field.Name != "" && other.Name != "" {
+			if other.Name == field.Name {
+				return i
+			}
+		} else if other.Title == field.Title {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsHistoryEntry(entries []PasswordHistoryEntry, entry PasswordHistoryEntry) bool {
+	for _, other := range entries {
+		if other == entry {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package onepass
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeItems(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := newTestContent("first.com")
+	content.Notes = "first notes"
+	content.Sections = []ItemSection{{Name: "s1", Title: "Details", Fields: []ItemField{
+		{Kind: "string", Name: "f1", Title: "PIN", Value: "1234"},
+		{Kind: "string", Name: "f2", Title: "Color", Value: "red"},
+	}}}
+	item, err := vault.AddItem("First", "securenotes.SecureNote", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := item
+	otherContent := newTestContent("second.com")
+	otherContent.Notes = "second notes"
+	otherContent.Sections = []ItemSection{{Name: "s1", Title: "Details", Fields: []ItemField{
+		{Kind: "string", Name: "f1", Title: "PIN", Value: "5678"},
+	}}, {Name: "s2", Title: "Extra", Fields: []ItemField{
+		{Kind: "string", Name: "f3", Title: "Size", Value: "large"},
+	}}}
+	other.Title = "Second"
+	other.SetContent(otherContent)
+
+	merged, err := MergeItems(item, other, []string{"title", "Details.PIN", "Details.Color", "Extra.Size"})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	mergedContent, err := merged.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Title != "Second" || mergedContent.Notes != "first notes" || mergedContent.PrimaryURL() != "first.com" {
+		t.Errorf("Unexpected merged item: %s %+v", merged.Title, mergedContent)
+	}
+	fields := contentFields(mergedContent)
+	expected := map[string]string{"URLs.website": "first.com", "Details.PIN": "5678", "Extra.Size": "large"}
+	if len(fields) != len(expected) {
+		t.Errorf("Unexpected merged fields: %+v", fields)
+	}
+	for _, field := range fields {
+		if expected[field.name] != field.value {
+			t.Errorf("Unexpected value '%s' for %s", field.value, field.name)
+		}
+	}
+
+	_, err = MergeItems(item, other, []string{"no-such-field"})
+	if err == nil {
+		t.Errorf("Merging unknown field should fail")
+	}
+}
+
+func TestResolveSyncConflict(t *testing.T) {
+	vault, other, removeOther := newSyncTestVaults(t)
+	defer removeOther()
+	item, err := vault.AddItem("Synced", "securenotes.SecureNote", newTestContent("first.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SyncVaults(&vault, &other, time.Time{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lastSync := time.Now()
+	updateSyncTestItem(t, item, "here.com", lastSync.Add(time.Hour))
+	copied, _ := other.LoadItem(item.Uuid)
+	copied.Title = "Synced elsewhere"
+	updateSyncTestItem(t, copied, "other.com", lastSync.Add(2*time.Hour))
+	result, err := SyncVaults(&vault, &other, lastSync, false)
+	if err != nil || len(result.Conflicts) != 1 {
+		t.Fatalf("Expected a conflict: %+v, %v", result, err)
+	}
+
+	err = ResolveSyncConflict(&vault, &other, result.Conflicts[0], KeepMerged, []string{"title"})
+	if err != nil {
+		t.Fatalf("Resolving conflict failed: %v", err)
+	}
+	for _, v := range []*Vault{&vault, &other} {
+		resolved, err := v.LoadItem(item.Uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := resolved.Content()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved.Title != "Synced elsewhere" || content.PrimaryURL() != "here.com" {
+			t.Errorf("Unexpected resolved item in %s: %s, %s", v.Path, resolved.Title, content.PrimaryURL())
+		}
+	}
+
+	// both vaults now have the same version
+	result, err = SyncVaults(&vault, &other, lastSync, false)
+	if err != nil || len(result.Conflicts) != 0 || len(result.Sent) != 0 || len(result.Received) != 0 {
+		t.Errorf("Unexpected sync result after resolving conflict: %+v, %v", result, err)
+	}
+}
+
+func TestMergeContent(t *testing.T) {
+	first := ItemContent{
+		Notes: "first notes",
+		Urls:  []ItemUrl{{Label: "website", Url: "https://a.com"}},
+		FormFields: []WebFormField{
+			{Name: "user", Designation: "username", Type: "T", Value: "alice"},
+			{Name: "pass", Designation: "password", Type: "P", Value: "old-pwd"},
+		},
+		Sections: []ItemSection{{Name: "s1", Title: "Details", Fields: []ItemField{
+			{Kind: "string", Name: "pin", Title: "PIN", Value: "1234"},
+			{Kind: "string", Name: "color", Title: "Color", Value: ""},
+		}}},
+		PrivateTags: []string{"work"},
+	}
+	second := ItemContent{
+		Urls: []ItemUrl{
+			{Label: "website", Url: "https://a.com"},
+			{Label: "login", Url: "https://login.a.com"},
+		},
+		FormFields: []WebFormField{
+			{Name: "pass", Designation: "password", Type: "P", Value: "new-pwd"},
+		},
+		// renamed section with the same internal name
+		Sections: []ItemSection{{Name: "s1", Title: "Account", Fields: []ItemField{
+			{Kind: "string", Name: "pin", Title: "PIN code", Value: "5678"},
+			{Kind: "string", Name: "color", Title: "Color", Value: "red"},
+		}}, {Name: "s2", Title: "Extra", Fields: []ItemField{
+			{Kind: "string", Name: "size", Title: "Size", Value: "large"},
+		}}},
+		PrivateTags: []string{"work", "shared"},
+	}
+
+	merged := MergeContent(first, second, PreferSecond)
+	if merged.Notes != "first notes" {
+		t.Errorf("Empty value should not replace notes, got '%s'", merged.Notes)
+	}
+	if len(merged.Urls) != 2 || len(merged.PrivateTags) != 2 || len(merged.FormFields) != 2 {
+		t.Errorf("Expected union of URLs, tags and fields: %+v", merged)
+	}
+	if merged.Password() != "new-pwd" {
+		t.Errorf("Expected password from second version, got '%s'", merged.Password())
+	}
+	if len(merged.PasswordHistory) != 1 || merged.PasswordHistory[0].Value != "old-pwd" {
+		t.Errorf("Replaced password was not added to history: %+v", merged.PasswordHistory)
+	}
+	if len(merged.Sections) != 2 || merged.Sections[0].Title != "Account" || len(merged.Sections[0].Fields) != 2 {
+		t.Fatalf("Renamed section was not merged: %+v", merged.Sections)
+	}
+	fields := merged.Sections[0].Fields
+	if fields[0].ValueString() != "5678" || fields[0].Title != "PIN code" || fields[1].ValueString() != "red" {
+		t.Errorf("Unexpected merged section fields: %+v", fields)
+	}
+
+	merged = MergeContent(first, second, PreferFirst)
+	if merged.Password() != "old-pwd" || merged.Sections[0].Title != "Details" {
+		t.Errorf("Expected values from first version: %+v", merged)
+	}
+	fields = merged.Sections[0].Fields
+	if fields[0].ValueString() != "1234" || fields[1].ValueString() != "red" {
+		t.Errorf("Empty fields should be filled from the second version: %+v", fields)
+	}
+	if len(merged.PasswordHistory) != 1 || merged.PasswordHistory[0].Value != "new-pwd" {
+		t.Errorf("Unexpected password history: %+v", merged.PasswordHistory)
+	}
+
+	// merging must not modify the inputs
+	if first.Sections[0].Fields[1].ValueString() != "" || len(first.Urls) != 1 {
+		t.Errorf("MergeContent modified its input")
+	}
+
+	if NewerWins(10, 20) != PreferSecond || NewerWins(20, 10) != PreferFirst {
+		t.Errorf("Unexpected strategy from NewerWins()")
+	}
+}
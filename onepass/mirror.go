@@ -0,0 +1,140 @@
+package onepass
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// MirrorSource records the vault from which a read-only mirror
+// vault is created by UpdateMirror() and the filters which select
+// the items copied to it
+type MirrorSource struct {
+	Path    string   `json:"path"`
+	Filters []string `json:"filters"`
+}
+
+// ReadOnlyError is returned when saving an item
+// in a read-only mirror vault
+type ReadOnlyError struct {
+	Source string
+}
+
+func (err ReadOnlyError) Error() string {
+	return fmt.Sprintf("The vault is a read-only mirror of '%s'. Change the item there and refresh the mirror instead", err.Source)
+}
+
+// MirrorResult lists the changes made by UpdateMirror()
+type MirrorResult struct {
+	// items which were added to or updated in the mirror
+	Copied []Item
+	// items which were removed from the mirror
+	Removed []Item
+}
+
+// mirrorItem saves a copy of an item to a mirror vault. Derived
+// passwords depend on the keys of the vault which holds the recipe,
+// so the copy stores the password computed in the source vault
+// instead of the recipe.
+func mirrorItem(item Item, mirror *Vault) error {
+	content, err := item.vault.CryptoAgent.Decrypt(item.SecurityLevel, item.Encrypted)
+	if err != nil {
+		return fmt.Errorf("Failed to decrypt item %s: %v", item.Title, err)
+	}
+	stored, _, err := storeDerivedPassword(string(content), func(recipe PasswordRecipe) (string, error) {
+		agent, ok := item.vault.CryptoAgent.(DerivingCryptoAgent)
+		if !ok {
+			return "", fmt.Errorf("Deriving passwords is not supported")
+		}
+		return agent.DerivePassword(item.SecurityLevel, recipe)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to derive password for item %s: %v", item.Title, err)
+	}
+	return copyItemContent(item, []byte(stored), mirror)
+}
+
+// UpdateMirror makes the mirror vault contain copies of items,
+// together with the folders which contain them, and nothing else.
+// Both vaults must be unlocked. Items are re-encrypted with the
+// mirror's key and keep their UUIDs and timestamps, so items whose
+// copy in the mirror has the same UpdatedAt time are left unchanged.
+// Copies of items with derived passwords store the password instead
+// of the recipe.
+// Other items are removed from the mirror entirely, without leaving
+// a record of their removal.
+//
+// source is the vault which items belong to. Its absolute path is
+// recorded in the mirror's VaultOptions along with filters, after
+// which the mirror is read-only except for further calls to
+// UpdateMirror().
+func UpdateMirror(source *Vault, items []Item, filters []string, mirror *Vault) (MirrorResult, error) {
+	result := MirrorResult{}
+	sourceItems, err := source.ListItems()
+	if err != nil {
+		return result, err
+	}
+	byUuid := map[string]Item{}
+	for _, item := range sourceItems {
+		byUuid[item.Uuid] = item
+	}
+
+	// include the folders which contain the selected items
+	wanted := map[string]Item{}
+	for _, item := range items {
+		wanted[item.Uuid] = item
+		for folderUuid := item.FolderUuid; folderUuid != ""; {
+			folder, ok := byUuid[folderUuid]
+			if !ok || wanted[folderUuid].Uuid != "" {
+				break
+			}
+			wanted[folderUuid] = folder
+			folderUuid = folder.FolderUuid
+		}
+	}
+
+	mirrorItems, err := mirror.listItems(true)
+	if err != nil {
+		return result, err
+	}
+	mirror.BeginBatch()
+	for _, item := range mirrorItems {
+		if wantedItem, ok := wanted[item.Uuid]; ok && wantedItem.UpdatedAt == item.UpdatedAt {
+			delete(wanted, item.Uuid)
+		} else if !ok {
+			err = item.removeDataFiles()
+			if err != nil {
+				break
+			}
+			result.Removed = append(result.Removed, item)
+		}
+	}
+	if err == nil {
+		for _, item := range sourceItems {
+			if _, ok := wanted[item.Uuid]; !ok {
+				continue
+			}
+			err = mirrorItem(item, mirror)
+			if err != nil {
+				break
+			}
+			result.Copied = append(result.Copied, item)
+		}
+	}
+	if endErr := mirror.EndBatch(); err == nil {
+		err = endErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	options, err := mirror.Options()
+	if err != nil {
+		return result, err
+	}
+	sourcePath, err := filepath.Abs(source.Path)
+	if err != nil {
+		return result, err
+	}
+	options.Mirror = &MirrorSource{Path: sourcePath, Filters: filters}
+	return result, mirror.SetOptions(options)
+}
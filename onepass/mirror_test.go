@@ -0,0 +1,100 @@
+package onepass
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateMirror(t *testing.T) {
+	vault, mirror, removeMirror := newSyncTestVaults(t)
+	defer removeMirror()
+	folder, err := vault.AddItem("CI", "system.folder.Regular", ItemContent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployKey, err := vault.AddItem("Deploy key", "securenotes.SecureNote", newTestContent("deploy.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployKey.FolderUuid = folder.Uuid
+	deployKey.Save()
+	token, err := vault.AddItem("Registry token", "securenotes.SecureNote", newTestContent("registry.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vault.AddItem("Bank", "securenotes.SecureNote", newTestContent("bank.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := UpdateMirror(&vault, []Item{deployKey, token}, []string{"tag:ci"}, &mirror)
+	if err != nil {
+		t.Fatalf("Creating mirror failed: %v", err)
+	}
+	if len(result.Copied) != 3 || len(result.Removed) != 0 {
+		t.Errorf("Unexpected mirror result: %+v", result)
+	}
+	copied, err := mirror.LoadItem(deployKey.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := copied.Content(); err != nil || content.PrimaryURL() != "deploy.com" {
+		t.Errorf("Unexpected mirrored content %+v (%v)", content, err)
+	}
+	if _, err := mirror.LoadItem(folder.Uuid); err != nil {
+		t.Errorf("Expected folder of mirrored item to be copied: %v", err)
+	}
+
+	// items cannot be changed in the mirror
+	if err = copied.Save(); err == nil {
+		t.Errorf("Expected saving item in mirror to fail")
+	} else if _, ok := err.(ReadOnlyError); !ok {
+		t.Errorf("Unexpected error saving item in mirror: %v", err)
+	}
+	options, _ := mirror.Options()
+	if options.Mirror == nil || !strings.HasSuffix(options.Mirror.Path, "/vault.agilekeychain") || options.Mirror.Filters[0] != "tag:ci" {
+		t.Errorf("Unexpected mirror options %+v", options.Mirror)
+	}
+
+	// refreshing copies changed items and removes unselected items
+	updateSyncTestItem(t, deployKey, "deploy.org", time.Now().Add(time.Minute))
+	deployKey, _ = vault.LoadItem(deployKey.Uuid)
+	result, err = UpdateMirror(&vault, []Item{deployKey}, []string{"tag:ci"}, &mirror)
+	if err != nil {
+		t.Fatalf("Refreshing mirror failed: %v", err)
+	}
+	if len(result.Copied) != 1 || len(result.Removed) != 1 || result.Removed[0].Uuid != token.Uuid {
+		t.Errorf("Unexpected refresh result: %+v", result)
+	}
+	items, _ := mirror.ListItems()
+	if len(items) != 2 {
+		t.Errorf("Expected 2 items in mirror, found %d", len(items))
+	}
+}
+
+func TestMirrorDerivedPassword(t *testing.T) {
+	vault, mirror, removeMirror := newSyncTestVaults(t)
+	defer removeMirror()
+	content := newTestContent("https://example.com")
+	content.PasswordRecipe = &PasswordRecipe{Site: "example.com", Counter: 1, Length: 20}
+	item, err := vault.AddItem("Derived", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := item.DerivedPassword()
+
+	_, err = UpdateMirror(&vault, []Item{item}, nil, &mirror)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, _ := mirror.LoadItem(item.Uuid)
+	copiedContent, err := copied.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copiedContent.PasswordRecipe != nil || copiedContent.Password() != password {
+		t.Errorf("Expected mirror to store derived password '%s', got '%s' (recipe %v)",
+			password, copiedContent.Password(), copiedContent.PasswordRecipe)
+	}
+}
@@ -0,0 +1,234 @@
+package onepasstest
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/robertknight/1pass/onepass"
+	"github.com/robertknight/1pass/onepass/client"
+)
+
+// MasterPwd is the master password accepted by
+// fake agents and used by NewVault()
+const MasterPwd = "onepasstest-pwd"
+
+// Agent is a fake 1pass agent which serves the same RPC interface
+// as the real agent, for testing code which uses client.AgentClient.
+//
+// Unlike the real agent, vaults are unlocked without reading any
+// files. Unlock() succeeds for any vault path if the password is
+// MasterPwd and vaults stay unlocked until Lock() is called.
+type Agent struct {
+	service *agentService
+	server  *rpc.Server
+}
+
+// agentService holds the RPC methods of Agent. It is kept separate
+// so that Agent's own methods are not registered with the RPC server.
+type agentService struct {
+	mu     sync.Mutex
+	vaults map[string]*CryptoAgent
+
+	// passwords returned by GenPassword, in order
+	passwords []string
+}
+
+// NewAgent returns a fake agent with no unlocked vaults
+func NewAgent() *Agent {
+	service := &agentService{vaults: map[string]*CryptoAgent{}}
+	server := rpc.NewServer()
+	err := server.RegisterName("OnePassAgent", service)
+	if err != nil {
+		panic(err)
+	}
+	return &Agent{service: service, server: server}
+}
+
+// Dial returns a client connected to the agent for the vault at
+// vaultPath, using an in-memory connection
+func (agent *Agent) Dial(vaultPath string) (*client.AgentClient, error) {
+	clientConn, serverConn := net.Pipe()
+	go agent.server.ServeConn(serverConn)
+	return client.NewAgentClient(clientConn, vaultPath)
+}
+
+// Serve accepts connections from clients on listener, for
+// code under test which dials the agent's socket itself
+func (agent *Agent) Serve(listener net.Listener) {
+	agent.server.Accept(listener)
+}
+
+// SetPasswords sets the passwords returned by future GenPassword
+// calls, so that tests can check where generated passwords are used.
+// Once they are used up, passwords are generated as normal.
+func (agent *Agent) SetPasswords(passwords ...string) {
+	agent.service.mu.Lock()
+	defer agent.service.mu.Unlock()
+	agent.service.passwords = passwords
+}
+
+// CryptoAgent returns the CryptoAgent which holds the 'keys' for
+// an unlocked vault, or nil if the vault is locked
+func (agent *Agent) CryptoAgent(vaultPath string) *CryptoAgent {
+	agent.service.mu.Lock()
+	defer agent.service.mu.Unlock()
+	return agent.service.vaults[vaultPath]
+}
+
+func (service *agentService) vault(vaultPath string) (*CryptoAgent, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	crypto, ok := service.vaults[vaultPath]
+	if !ok {
+		return nil, errors.New("No such vault")
+	}
+	return crypto, nil
+}
+
+func (service *agentService) Encrypt(args client.CryptArgs, cipherText *[]byte) error {
+	crypto, err := service.vault(args.VaultPath)
+	if err != nil {
+		return err
+	}
+	*cipherText, err = crypto.Encrypt(args.KeyName, args.Data)
+	return err
+}
+
+func (service *agentService) Decrypt(args client.CryptArgs, plainText *[]byte) error {
+	crypto, err := service.vault(args.VaultPath)
+	if err != nil {
+		return err
+	}
+	*plainText, err = crypto.Decrypt(args.KeyName, args.Data)
+	return err
+}
+
+func (service *agentService) DecryptBatch(args client.BatchCryptArgs, plainTexts *[][]byte) error {
+	crypto, err := service.vault(args.VaultPath)
+	if err != nil {
+		return err
+	}
+	*plainTexts, err = crypto.DecryptBatch(args.KeyNames, args.Data)
+	return err
+}
+
+func (service *agentService) Sign(args client.CryptArgs, signature *[]byte) error {
+	crypto, err := service.vault(args.VaultPath)
+	if err != nil {
+		return err
+	}
+	*signature, err = crypto.Sign(args.KeyName, args.Data)
+	return err
+}
+
+func (service *agentService) DerivePassword(args client.DeriveArgs, password *string) error {
+	crypto, err := service.vault(args.VaultPath)
+	if err != nil {
+		return err
+	}
+	*password, err = crypto.DerivePassword(args.KeyName, args.Recipe)
+	return err
+}
+
+func (service *agentService) GenPassword(args client.GenPasswordArgs, password *string) error {
+	service.mu.Lock()
+	if len(service.passwords) > 0 {
+		*password = service.passwords[0]
+		service.passwords = service.passwords[1:]
+		service.mu.Unlock()
+		return nil
+	}
+	service.mu.Unlock()
+
+	var err error
+	if args.Custom != nil {
+		*password, err = onepass.GenerateWithRecipe(*args.Custom, args.Length)
+	} else {
+		*password, err = onepass.GenerateFromRecipe(args.Recipe, args.Length)
+	}
+	return err
+}
+
+// RandomBytes returns zeroed data, so that results are repeatable
+func (service *agentService) RandomBytes(count int, data *[]byte) error {
+	*data = make([]byte, count)
+	return nil
+}
+
+func (service *agentService) GenRecipes(unused string, recipes *map[string]onepass.GenRecipe) error {
+	*recipes = onepass.GenRecipes
+	return nil
+}
+
+func (service *agentService) Unlock(args client.UnlockArgs, ok *bool) error {
+	if string(args.MasterPwd) != MasterPwd {
+		*ok = false
+		return errors.New("Incorrect password")
+	}
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if _, unlocked := service.vaults[args.VaultPath]; !unlocked {
+		service.vaults[args.VaultPath] = NewCryptoAgent()
+	}
+	*ok = true
+	return nil
+}
+
+func (service *agentService) Lock(vaultPath string, ok *bool) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if crypto, unlocked := service.vaults[vaultPath]; unlocked {
+		crypto.Lock()
+		delete(service.vaults, vaultPath)
+	}
+	*ok = true
+	return nil
+}
+
+func (service *agentService) IsLocked(vaultPath string, locked *bool) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	_, unlocked := service.vaults[vaultPath]
+	*locked = !unlocked
+	return nil
+}
+
+func (service *agentService) RefreshAccess(args client.RefreshArgs, ok *bool) error {
+	_, err := service.vault(args.VaultPath)
+	if err != nil {
+		return errors.New("Vault is not unlocked")
+	}
+	*ok = true
+	return nil
+}
+
+// LimitAccess succeeds for unlocked vaults. As with leases, it
+// has no effect since the fake agent never auto-locks vaults.
+func (service *agentService) LimitAccess(args client.RefreshArgs, ok *bool) error {
+	return service.RefreshAccess(args, ok)
+}
+
+// AcquireLease succeeds for unlocked vaults. The fake agent
+// never auto-locks vaults, so leases have no effect.
+func (service *agentService) AcquireLease(vaultPath string, ok *bool) error {
+	_, err := service.vault(vaultPath)
+	if err != nil {
+		return errors.New("Vault is not unlocked")
+	}
+	*ok = true
+	return nil
+}
+
+func (service *agentService) ReleaseLease(vaultPath string, ok *bool) error {
+	*ok = true
+	return nil
+}
+
+// Info reports a zero PID so that client.ConnectAgent() never
+// tries to shut down the process which is running the fake agent
+func (service *agentService) Info(unused string, info *client.AgentInfo) error {
+	*info = client.AgentInfo{ProtocolVersion: client.ProtocolVersion}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package onepasstest provides test doubles for programs which use
+// the onepass library: an in-memory CryptoAgent, a fake 1pass agent
+// and a set of fixture items.
+package onepasstest
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// prefix added to data 'encrypted' by CryptoAgent
+const fakeCipherPrefix = "onepasstest-encrypted:"
+
+// CryptoAgent is an in-memory onepass.CryptoAgent which does not
+// perform any real encryption. Data is stored in plain text with
+// a marker prefix so that items can be inspected in tests.
+//
+// A CryptoAgent can be shared by several vaults and goroutines.
+type CryptoAgent struct {
+	mu     sync.Mutex
+	locked bool
+	// number of Encrypt/Decrypt calls, for each key name
+	calls map[string]int
+}
+
+// NewCryptoAgent returns an unlocked CryptoAgent
+func NewCryptoAgent() *CryptoAgent {
+	return &CryptoAgent{calls: map[string]int{}}
+}
+
+// Calls returns the number of items which have been encrypted
+// or decrypted using keyName
+func (agent *CryptoAgent) Calls(keyName string) int {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	return agent.calls[keyName]
+}
+
+// Unlock reverses a previous call to Lock()
+func (agent *CryptoAgent) Unlock() {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	agent.locked = false
+}
+
+func (agent *CryptoAgent) checkUnlocked(keyName string) error {
+	if agent.locked {
+		return errors.New("Vault is locked")
+	}
+	if keyName == "" {
+		return errors.New("No such key")
+	}
+	agent.calls[keyName]++
+	return nil
+}
+
+func (agent *CryptoAgent) Encrypt(keyName string, in []byte) ([]byte, error) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if err := agent.checkUnlocked(keyName); err != nil {
+		return nil, err
+	}
+	return append([]byte(fakeCipherPrefix+keyName+":"), in...), nil
+}
+
+func (agent *CryptoAgent) Decrypt(keyName string, in []byte) ([]byte, error) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if err := agent.checkUnlocked(keyName); err != nil {
+		return nil, err
+	}
+	prefix := []byte(fakeCipherPrefix + keyName + ":")
+	if !bytes.HasPrefix(in, prefix) {
+		return nil, errors.New("Data was not encrypted with this key")
+	}
+	return append([]byte{}, in[len(prefix):]...), nil
+}
+
+func (agent *CryptoAgent) DecryptBatch(keyNames []string, in [][]byte) ([][]byte, error) {
+	if len(keyNames) != len(in) {
+		return nil, errors.New("Mismatched key names and data")
+	}
+	results := make([][]byte, len(in))
+	for i, data := range in {
+		var err error
+		results[i], err = agent.Decrypt(keyNames[i], data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// DerivePassword derives a password using the key name in place
+// of the vault key, so results are the same for every vault
+func (agent *CryptoAgent) DerivePassword(keyName string, recipe onepass.PasswordRecipe) (string, error) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if err := agent.checkUnlocked(keyName); err != nil {
+		return "", err
+	}
+	return onepass.DerivePassword([]byte(keyName), recipe)
+}
+
+// Sign signs data using the key name in place of the vault key
+func (agent *CryptoAgent) Sign(keyName string, data []byte) ([]byte, error) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if err := agent.checkUnlocked(keyName); err != nil {
+		return nil, err
+	}
+	return onepass.SignItemData([]byte(keyName), data), nil
+}
+
+func (agent *CryptoAgent) Lock() error {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	agent.locked = true
+	return nil
+}
+
+func (agent *CryptoAgent) IsLocked() (bool, error) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	return agent.locked, nil
+}
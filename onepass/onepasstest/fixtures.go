@@ -0,0 +1,81 @@
+package onepasstest
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+// Fixture describes an item which is added to
+// test vaults by AddFixtures()
+type Fixture struct {
+	Title    string
+	TypeName string
+	Content  onepass.ItemContent
+}
+
+// Fixtures returns a set of items covering common item types:
+// logins with and without one-time passwords, a secure note,
+// a credit card and a server
+func Fixtures() []Fixture {
+	github := onepass.NewLoginContent("octocat", "gh-password", "https://github.com")
+	github.AddField("", onepass.NewOtpField("otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP"))
+
+	return []Fixture{
+		{"GitHub", "webforms.WebForm", github},
+		{"Example", "webforms.WebForm", onepass.NewLoginContent("alice@example.com", "example-password", "https://www.example.com")},
+		{"Wifi Notes", "securenotes.SecureNote", onepass.ItemContent{Notes: "The router is in the cupboard"}},
+		{"Visa", "wallet.financial.CreditCard", onepass.ItemContent{Sections: []onepass.ItemSection{{
+			Fields: []onepass.ItemField{
+				{Kind: "string", Name: "cardholder", Title: "cardholder name", Value: "Alice Smith"},
+				{Kind: "string", Name: "ccnum", Title: "number", Value: "4111111111111111"},
+				{Kind: "monthYear", Name: "expiry", Title: "expiry date", Value: 203012},
+			},
+		}}}},
+		{"Web Server", "wallet.computer.UnixServer", onepass.ItemContent{Sections: []onepass.ItemSection{{
+			Fields: []onepass.ItemField{
+				{Kind: "string", Name: "url", Title: "URL", Value: "ssh://web.example.com"},
+				{Kind: "string", Name: "username", Title: "username", Value: "deploy"},
+				{Kind: "concealed", Name: "password", Title: "password", Value: "server-password"},
+			},
+		}}}},
+	}
+}
+
+// AddFixtures adds the items returned by Fixtures() to a vault
+func AddFixtures(vault *onepass.Vault) ([]onepass.Item, error) {
+	items := []onepass.Item{}
+	for _, fixture := range Fixtures() {
+		item, err := vault.AddItem(fixture.Title, fixture.TypeName, fixture.Content)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// NewVault creates an empty vault in dir whose items are
+// 'encrypted' by a CryptoAgent.
+//
+// The vault's keys are created using a low number of key derivation
+// iterations and master password MasterPwd. Items added through
+// the returned vault cannot be read after unlocking it with
+// Vault.Unlock(), which uses the real keys.
+func NewVault(dir string) (*onepass.Vault, *CryptoAgent, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, nil, err
+	}
+	vault, err := onepass.NewVault(filepath.Join(dir, "test.agilekeychain"), onepass.VaultSecurity{
+		MasterPwd:  MasterPwd,
+		Iterations: 10,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	crypto := NewCryptoAgent()
+	vault.CryptoAgent = crypto
+	return &vault, crypto, nil
+}
@@ -0,0 +1,101 @@
+package onepasstest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/robertknight/1pass/onepass"
+)
+
+func TestFixturesAreValid(t *testing.T) {
+	for _, fixture := range Fixtures() {
+		err := onepass.ValidateContent(fixture.TypeName, fixture.Content)
+		if err != nil {
+			t.Errorf("Invalid fixture '%s': %v", fixture.Title, err)
+		}
+	}
+}
+
+func TestVaultWithFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "1pass-onepasstest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	vault, crypto, err := NewVault(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := AddFixtures(vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := vault.ItemContents(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contents[0].Username() != "octocat" || contents[0].OTPSecret() == "" {
+		t.Errorf("Unexpected content for first fixture: %v", contents[0])
+	}
+	if crypto.Calls("SL5") != 2*len(items) {
+		t.Errorf("Expected %d crypto calls, got %d", 2*len(items), crypto.Calls("SL5"))
+	}
+
+	crypto.Lock()
+	_, err = items[0].Content()
+	if err == nil {
+		t.Errorf("Expected reading content to fail when locked")
+	}
+}
+
+func TestAgent(t *testing.T) {
+	agent := NewAgent()
+	client, err := agent.Dial("/vaults/test.agilekeychain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	locked, err := client.IsLocked()
+	if err != nil || !locked {
+		t.Errorf("Expected vault to be locked initially")
+	}
+	err = client.Unlock([]byte("wrong-pwd"))
+	if _, isDecryptErr := err.(onepass.DecryptError); !isDecryptErr {
+		t.Errorf("Expected incorrect password error, got %v", err)
+	}
+	err = client.Unlock([]byte(MasterPwd))
+	if err != nil {
+		t.Fatalf("Unable to unlock: %v", err)
+	}
+
+	encrypted, err := client.Encrypt("SL5", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := client.Decrypt("SL5", encrypted)
+	if err != nil || string(decrypted) != "secret" {
+		t.Errorf("Unexpected decrypted data '%s' (%v)", decrypted, err)
+	}
+	if agent.CryptoAgent(client.VaultPath).Calls("SL5") != 2 {
+		t.Errorf("Expected calls to be recorded")
+	}
+
+	agent.SetPasswords("first", "second")
+	for _, expected := range []string{"first", "second"} {
+		password, err := client.GenPassword("default", 0, "test")
+		if err != nil || password != expected {
+			t.Errorf("Expected password '%s', got '%s' (%v)", expected, password, err)
+		}
+	}
+
+	err = client.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Decrypt("SL5", encrypted)
+	if err == nil {
+		t.Errorf("Expected decrypting to fail after locking")
+	}
+}
@@ -0,0 +1,37 @@
+package onepass
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/onepass/totp"
+)
+
+// IsOTP returns true if the field holds a one-time password secret.
+// These are fields with the 'otp' kind, the 'TOTP_' name prefix used
+// by the official 1Password apps or an 'otpauth://' value.
+func (field ItemField) IsOTP() bool {
+	return field.Kind == "otp" || strings.HasPrefix(field.Name, "TOTP_") ||
+		strings.HasPrefix(field.ValueString(), "otpauth://")
+}
+
+// OTPKey returns the key from the item's one-time password field,
+// see OTPSecret()
+func (item *ItemContent) OTPKey() (totp.Key, error) {
+	secret := item.OTPSecret()
+	if secret == "" {
+		return totp.Key{}, fmt.Errorf("The item has no one-time password field")
+	}
+	return totp.Parse(secret)
+}
+
+// OTPCode returns the item's one-time password which is valid at
+// time t and the time remaining until it expires
+func (item *ItemContent) OTPCode(t time.Time) (code string, remaining time.Duration, err error) {
+	key, err := item.OTPKey()
+	if err != nil {
+		return "", 0, err
+	}
+	return key.Code(t), key.Remaining(t), nil
+}
@@ -0,0 +1,106 @@
+package onepass
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// MinWordlistSize is the smallest number of distinct words which
+// passphrases are generated from, so that each word adds at least
+// 10 bits of entropy
+const MinWordlistSize = 1024
+
+// DefaultPassphraseWords is the number of words in passphrases
+// from recipes which use a wordlist but do not set Words
+const DefaultPassphraseWords = 6
+
+// ReadWordlist reads a list of words for passphrases from the file
+// at path. See ParseWordlist()
+func ReadWordlist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	words, err := ParseWordlist(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return words, nil
+}
+
+// ParseWordlist reads a list of words with one word per line.
+// Lines in the Diceware format, where the word follows a number
+// made of the dice rolls which select it, are also accepted.
+// Empty lines and lines starting with '#' are skipped and repeated
+// words are removed. An error is returned if the list contains
+// fewer than MinWordlistSize words.
+func ParseWordlist(reader io.Reader) ([]string, error) {
+	words := []string{}
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) == 2 && strings.IndexFunc(fields[0], func(ch rune) bool { return !unicode.IsDigit(ch) }) == -1 {
+			fields = fields[1:]
+		}
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("line %d: expected a single word", lineNumber)
+		}
+		if !seen[fields[0]] {
+			seen[fields[0]] = true
+			words = append(words, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) < MinWordlistSize {
+		return nil, fmt.Errorf("The wordlist has %d distinct words but at least %d are needed", len(words), MinWordlistSize)
+	}
+	return words, nil
+}
+
+// randomIndex returns a random number in [0, n)
+func randomIndex(n int) int {
+	// largest multiple of n which fits in 32 bits,
+	// values at or above this are rejected to avoid bias
+	limit := math.MaxUint32 - math.MaxUint32%uint32(n)
+	for {
+		value := binary.BigEndian.Uint32(randomBytes(4))
+		if value < limit {
+			return int(value % uint32(n))
+		}
+	}
+}
+
+// GeneratePassphrase returns count words chosen at random from
+// words, joined by separator
+func GeneratePassphrase(words []string, count int, separator string) (string, error) {
+	distinct := map[string]bool{}
+	for _, word := range words {
+		distinct[word] = true
+	}
+	if len(distinct) < MinWordlistSize {
+		return "", fmt.Errorf("The wordlist has %d distinct words but at least %d are needed", len(distinct), MinWordlistSize)
+	}
+	if count < 1 {
+		return "", fmt.Errorf("Invalid number of words %d", count)
+	}
+	chosen := make([]string, count)
+	for i := range chosen {
+		chosen[i] = words[randomIndex(len(words))]
+	}
+	return strings.Join(chosen, separator), nil
+}
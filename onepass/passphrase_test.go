@@ -0,0 +1,59 @@
+package onepass
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testWordlist(count int) string {
+	lines := []string{"# test wordlist", ""}
+	for i := 0; i < count; i++ {
+		lines = append(lines, fmt.Sprintf("%05d\tword%d", i+11111, i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestParseWordlist(t *testing.T) {
+	words, err := ParseWordlist(strings.NewReader(testWordlist(MinWordlistSize) + "\nword0\nword1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != MinWordlistSize || words[0] != "word0" {
+		t.Errorf("Unexpected words: %d, %v", len(words), words[0:2])
+	}
+
+	_, err = ParseWordlist(strings.NewReader(testWordlist(MinWordlistSize - 1)))
+	if err == nil || !strings.Contains(err.Error(), "at least") {
+		t.Errorf("Expected short wordlist to be rejected, got %v", err)
+	}
+	_, err = ParseWordlist(strings.NewReader("two words\n" + testWordlist(MinWordlistSize)))
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected invalid line to be rejected, got %v", err)
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	words, err := ParseWordlist(strings.NewReader(testWordlist(2000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase, err := GenerateWithRecipe(GenRecipe{Words: 5, Wordlist: words}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(passphrase, "-")
+	if len(parts) != 5 {
+		t.Fatalf("Expected 5 words, got '%s'", passphrase)
+	}
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "word") {
+			t.Errorf("Unexpected word '%s'", part)
+		}
+	}
+
+	_, err = GeneratePassphrase(words[0:100], 5, " ")
+	if err == nil {
+		t.Errorf("Expected short wordlist to be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+package onepass
+
+import (
+	"time"
+)
+
+// PasswordHistoryEntry is a password which was previously stored
+// in an item, in the format used by the official 1Password apps
+type PasswordHistoryEntry struct {
+	Value string `json:"value"`
+	// UNIX timestamp of the time when the password was replaced
+	Time int64 `json:"time"`
+}
+
+// passwords returns the values of the password fields in an item,
+// keyed by the section and field name. Password fields are concealed
+// fields and web form fields of type 'P'.
+func (content *ItemContent) passwords() map[string]string {
+	passwords := map[string]string{}
+	for _, section := range content.Sections {
+		for _, field := range section.Fields {
+			if field.Kind == "concealed" {
+				passwords[section.Name+"."+field.Name] = field.ValueString()
+			}
+		}
+	}
+	for _, field := range content.FormFields {
+		if field.Type == "P" {
+			passwords["fields."+field.Name] = field.Value
+		}
+	}
+	return passwords
+}
+
+// RecordPasswordChanges adds each password in previous which was
+// changed or removed in content to the password history in content,
+// with the time of the change set to changedAt
+func (content *ItemContent) RecordPasswordChanges(previous ItemContent, changedAt time.Time) {
+	current := content.passwords()
+	for _, section := range previous.Sections {
+		for _, field := range section.Fields {
+			if field.Kind == "concealed" {
+				content.recordPasswordChange(field.ValueString(), current[section.Name+"."+field.Name], changedAt)
+			}
+		}
+	}
+	for _, field := range previous.FormFields {
+		if field.Type == "P" {
+			content.recordPasswordChange(field.Value, current["fields."+field.Name], changedAt)
+		}
+	}
+}
+
+func (content *ItemContent) recordPasswordChange(oldValue string, newValue string, changedAt time.Time) {
+	if oldValue == "" || oldValue == newValue {
+		return
+	}
+	content.PasswordHistory = append(content.PasswordHistory, PasswordHistoryEntry{
+		Value: oldValue,
+		Time:  changedAt.Unix(),
+	})
+}
@@ -0,0 +1,59 @@
+package onepass
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordPasswordChanges(t *testing.T) {
+	previous := ItemContent{
+		Sections: []ItemSection{{
+			Name: "",
+			Fields: []ItemField{
+				{Kind: "concealed", Name: "password", Value: "old-secret"},
+				{Kind: "string", Name: "username", Value: "alice"},
+				{Kind: "concealed", Name: "pin", Value: "1234"},
+			},
+		}},
+		FormFields: []WebFormField{
+			{Name: "user", Type: "T", Value: "alice"},
+			{Name: "pass", Type: "P", Value: "old-form-pass"},
+		},
+	}
+	content := ItemContent{
+		Sections: []ItemSection{{
+			Name: "",
+			Fields: []ItemField{
+				{Kind: "concealed", Name: "password", Value: "new-secret"},
+				{Kind: "string", Name: "username", Value: "bob"},
+				{Kind: "concealed", Name: "pin", Value: "1234"},
+			},
+		}},
+		FormFields: []WebFormField{
+			{Name: "user", Type: "T", Value: "bob"},
+			{Name: "pass", Type: "P", Value: "new-form-pass"},
+		},
+		PasswordHistory: []PasswordHistoryEntry{{Value: "oldest", Time: 1}},
+	}
+
+	changedAt := time.Unix(1400000000, 0)
+	content.RecordPasswordChanges(previous, changedAt)
+	expected := []string{"oldest", "old-secret", "old-form-pass"}
+	if len(content.PasswordHistory) != len(expected) {
+		t.Fatalf("Unexpected password history: %+v", content.PasswordHistory)
+	}
+	for i, entry := range content.PasswordHistory {
+		if entry.Value != expected[i] {
+			t.Errorf("Expected history entry %d to be %s, got %s", i, expected[i], entry.Value)
+		}
+		if i > 0 && entry.Time != changedAt.Unix() {
+			t.Errorf("Unexpected change time %d", entry.Time)
+		}
+	}
+
+	// unchanged passwords are not recorded
+	content.RecordPasswordChanges(content, changedAt)
+	if len(content.PasswordHistory) != len(expected) {
+		t.Errorf("Unchanged passwords were added to history: %+v", content.PasswordHistory)
+	}
+}
@@ -0,0 +1,325 @@
+package onepass
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy is the set of rules which a site applies to
+// passwords, parsed from a description such as "min 8, max 16, at
+// least one symbol, no spaces" by ParsePasswordPolicy()
+type PasswordPolicy struct {
+	// minimum and maximum length in characters, or zero
+	// if not limited
+	MinLength int
+	MaxLength int
+	// classes of character from CharClasses of which the
+	// password must contain at least one
+	Require []string
+	// classes of character from CharClasses, or "space",
+	// which the password must not contain
+	Forbid []string
+	// the only symbols which the password may contain,
+	// or empty if not restricted
+	Symbols string
+}
+
+// names used for classes of character in policy descriptions
+var policyClassNames = map[string]string{
+	"lowercase":           "lower",
+	"lowercase letter":    "lower",
+	"lower":               "lower",
+	"lower case letter":   "lower",
+	"uppercase":           "upper",
+	"uppercase letter":    "upper",
+	"upper":               "upper",
+	"upper case letter":   "upper",
+	"capital":             "upper",
+	"capital letter":      "upper",
+	"digit":               "digit",
+	"number":              "digit",
+	"numeral":             "digit",
+	"symbol":              "symbol",
+	"special":             "symbol",
+	"special character":   "symbol",
+	"punctuation":         "symbol",
+	"punctuation mark":    "symbol",
+	"non-alphanumeric":    "symbol",
+	"space":               "space",
+	"whitespace":          "space",
+	"non-ascii character": "non-ascii",
+}
+
+const policyClassPattern = `([a-z -]+?)`
+
+// policyClass returns the class of character named
+// by name, which may be plural
+func policyClass(name string) (string, bool) {
+	for _, singular := range []string{name, strings.TrimSuffix(name, "s"), strings.TrimSuffix(name, "es")} {
+		if class, ok := policyClassNames[singular]; ok {
+			return class, true
+		}
+	}
+	return "", false
+}
+
+var (
+	policyMinRegex     = regexp.MustCompile(`^(?:(?:min|minimum|at least|no fewer than)(?: length)?:? (\d+)|(\d+) ?\+|(\d+) or more)(?: characters| chars)?(?: long)?$`)
+	policyMaxRegex     = regexp.MustCompile(`^(?:max|maximum|at most|up to|no more than)(?: length)?:? (\d+)(?: characters| chars)?(?: long)?$`)
+	policyRangeRegex   = regexp.MustCompile(`^(?:between )?(\d+) ?(?:-|to|and) ?(\d+)(?: characters| chars)?(?: long)?$`)
+	policyExactRegex   = regexp.MustCompile(`^(?:exactly|length:?) (\d+)(?: characters| chars)?$`)
+	policyRequireRegex = regexp.MustCompile(`^(?:at least (?:one|1)|one or more|(?:must )?(?:contains?|includes?|have|has|requires?) (?:a|an|one|at least one)) ` + policyClassPattern + `$`)
+	policyForbidRegex  = regexp.MustCompile(`^(?:no|without) ` + policyClassPattern + `(?: allowed)?$|^` + policyClassPattern + ` (?:are )?not allowed$`)
+	policySymbolsRegex = regexp.MustCompile(`^(?:allowed )?(?:symbols|special characters): ?\S+$`)
+)
+
+// ParsePasswordPolicy parses a description of the rules which a site
+// applies to passwords. The description is a list of rules separated
+// by commas or semicolons, such as:
+//
+//	min 8, max 16, at least one symbol, no spaces, symbols: !@#$%
+//
+// Lengths can also be given as '8-16 characters', '8+ characters' or
+// 'exactly 6'. The kinds of character are lowercase, uppercase, digit,
+// symbol and space. 'symbols:' lists the only symbols which the site
+// allows, which cannot include commas or semicolons.
+func ParsePasswordPolicy(description string) (PasswordPolicy, error) {
+	var policy PasswordPolicy
+	for _, rule := range strings.FieldsFunc(description, func(ch rune) bool {
+		return ch == ',' || ch == ';' || ch == '\n'
+	}) {
+		original := strings.TrimSpace(rule)
+		rule = strings.Join(strings.Fields(strings.ToLower(original)), " ")
+		if rule == "" {
+			continue
+		}
+		var err error
+		if match := policyMinRegex.FindStringSubmatch(rule); match != nil {
+			policy.MinLength, err = strconv.Atoi(match[1] + match[2] + match[3])
+		} else if match := policyMaxRegex.FindStringSubmatch(rule); match != nil {
+			policy.MaxLength, err = strconv.Atoi(match[1])
+		} else if match := policyRangeRegex.FindStringSubmatch(rule); match != nil {
+			policy.MinLength, _ = strconv.Atoi(match[1])
+			policy.MaxLength, err = strconv.Atoi(match[2])
+		} else if match := policyExactRegex.FindStringSubmatch(rule); match != nil {
+			policy.MinLength, err = strconv.Atoi(match[1])
+			policy.MaxLength = policy.MinLength
+		} else if policySymbolsRegex.MatchString(rule) {
+			policy.Symbols = strings.TrimSpace(original[strings.Index(original, ":")+1:])
+		} else if match := policyRequireRegex.FindStringSubmatch(rule); match != nil {
+			class, ok := policyClass(match[1])
+			if !ok || class == "space" || class == "non-ascii" {
+				return policy, fmt.Errorf("Unknown kind of character in password rule '%s'", original)
+			}
+			policy.Require = appendUnique(policy.Require, class)
+		} else if match := policyForbidRegex.FindStringSubmatch(rule); match != nil {
+			class, ok := policyClass(match[1] + match[2])
+			if !ok {
+				return policy, fmt.Errorf("Unknown kind of character in password rule '%s'", original)
+			}
+			policy.Forbid = appendUnique(policy.Forbid, class)
+		} else {
+			return policy, fmt.Errorf("Unrecognized password rule '%s'", original)
+		}
+		if err != nil {
+			return policy, fmt.Errorf("Invalid length in password rule '%s'", original)
+		}
+	}
+	if policy.MaxLength > 0 && policy.MinLength > policy.MaxLength {
+		return policy, fmt.Errorf("The minimum password length %d is greater than the maximum %d",
+			policy.MinLength, policy.MaxLength)
+	}
+	for _, class := range policy.Require {
+		if policy.forbids(class) {
+			return policy, fmt.Errorf("The password rules both require and forbid a '%s' character", class)
+		}
+	}
+	return policy, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func (policy PasswordPolicy) forbids(class string) bool {
+	for _, forbidden := range policy.Forbid {
+		if forbidden == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error describing the first rule of the
+// policy which password does not meet
+func (policy PasswordPolicy) Check(password string) error {
+	length := len([]rune(password))
+	if length < policy.MinLength {
+		return fmt.Errorf("The password must be at least %d characters", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		return fmt.Errorf("The password must be at most %d characters", policy.MaxLength)
+	}
+	for _, class := range policy.Require {
+		if !strings.ContainsAny(password, policy.classChars(class)) {
+			return fmt.Errorf("The password must contain a '%s' character", class)
+		}
+	}
+	for _, ch := range password {
+		class := charClass(ch)
+		if policy.forbids(class) || (ch > unicode.MaxASCII && policy.forbids("non-ascii")) {
+			return fmt.Errorf("The password must not contain '%c'", ch)
+		}
+		if class == "symbol" && policy.Symbols != "" && !strings.ContainsRune(policy.Symbols, ch) {
+			return fmt.Errorf("The password must not contain '%c'. The allowed symbols are '%s'", ch, policy.Symbols)
+		}
+	}
+	return nil
+}
+
+// charClass returns the class of character, from
+// CharClasses or "space", which ch belongs to
+func charClass(ch rune) string {
+	switch {
+	case unicode.IsSpace(ch):
+		return "space"
+	case unicode.IsLower(ch):
+		return "lower"
+	case unicode.IsUpper(ch):
+		return "upper"
+	case unicode.IsDigit(ch):
+		return "digit"
+	default:
+		return "symbol"
+	}
+}
+
+// classChars returns the characters of a class which
+// passwords generated for the policy use
+func (policy PasswordPolicy) classChars(class string) string {
+	if class == "symbol" && policy.Symbols != "" {
+		return policy.Symbols
+	}
+	return CharClasses[class]
+}
+
+// Recipe adapts recipe so that the passwords which it generates
+// meet the policy, and returns the adapted recipe together with
+// length adjusted to within the policy's limits. If length is zero,
+// the recipe's length is used.
+func (policy PasswordPolicy) Recipe(recipe GenRecipe, length int) (GenRecipe, int) {
+	if length == 0 {
+		length = recipe.Length
+	}
+	if length == 0 {
+		length = GenRecipes["default"].Length
+	}
+	if length < policy.MinLength {
+		length = policy.MinLength
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		length = policy.MaxLength
+	}
+
+	required := []string{}
+	for _, class := range recipe.requiredClasses() {
+		if !policy.forbids(class) {
+			required = appendUnique(required, class)
+		}
+	}
+	for _, class := range policy.Require {
+		required = appendUnique(required, class)
+	}
+
+	// the characters of generated passwords are always listed
+	// explicitly, since the groups of GenPassword() are
+	// separated by symbols
+	chars := recipe.Chars
+	if chars == "" {
+		chars = CharClasses["lower"] + CharClasses["upper"]
+		if !recipe.NoDigits {
+			chars += CharClasses["digit"]
+		}
+		if recipe.Symbols {
+			chars += CharClasses["symbol"]
+		}
+	}
+	for _, class := range required {
+		if !strings.ContainsAny(chars, policy.classChars(class)) {
+			chars += policy.classChars(class)
+		}
+	}
+	chars = strings.Map(func(ch rune) rune {
+		class := charClass(ch)
+		if policy.forbids(class) {
+			return -1
+		}
+		if class == "symbol" && policy.Symbols != "" && !strings.ContainsRune(policy.Symbols, ch) {
+			return -1
+		}
+		return ch
+	}, chars)
+
+	// the rules are about characters, so passwords are
+	// generated from chars even if recipe is for passphrases
+	recipe.Words = 0
+	recipe.Wordlist = nil
+	recipe.Chars = chars
+	recipe.Mixed = false
+	recipe.Require = nil
+	for _, class := range required {
+		// symbols outside of CharClasses are checked
+		// by Check() after generating the password
+		if class != "symbol" || strings.ContainsAny(chars, CharClasses["symbol"]) {
+			recipe.Require = append(recipe.Require, class)
+		}
+	}
+	recipe.Length = length
+	return recipe, length
+}
+
+// maximum number of passwords generated by
+// GenerateForPolicy() before giving up
+const maxPolicyAttempts = 100
+
+// GenerateForPolicy generates a password using recipe adapted
+// by PasswordPolicy.Recipe() which meets the policy
+func GenerateForPolicy(policy PasswordPolicy, recipe GenRecipe, length int) (string, error) {
+	return GenerateForPolicyWith(policy, recipe, length, GenerateWithRecipe)
+}
+
+// GenerateForPolicyWith is like GenerateForPolicy() but uses
+// generate to generate passwords, eg. using the agent
+func GenerateForPolicyWith(policy PasswordPolicy, recipe GenRecipe, length int,
+	generate func(recipe GenRecipe, length int) (string, error)) (string, error) {
+	recipe, length = policy.Recipe(recipe, length)
+	var err error
+	for i := 0; i < maxPolicyAttempts; i++ {
+		var password string
+		password, err = generate(recipe, length)
+		if err != nil {
+			return "", err
+		}
+		if err = policy.Check(password); err == nil {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("Unable to generate a password which meets the password rules: %v", err)
+}
+
+// Policy returns the rules set for the item's passwords by
+// PasswordPolicy, or an empty policy if none are set
+func (content *ItemContent) Policy() (PasswordPolicy, error) {
+	if content.PasswordPolicy == "" {
+		return PasswordPolicy{}, nil
+	}
+	return ParsePasswordPolicy(content.PasswordPolicy)
+}
@@ -0,0 +1,98 @@
+package onepass
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePasswordPolicy(t *testing.T) {
+	cases := []struct {
+		description string
+		policy      PasswordPolicy
+	}{
+		{"min 8, max 16, at least one symbol, no spaces", PasswordPolicy{
+			MinLength: 8, MaxLength: 16, Require: []string{"symbol"}, Forbid: []string{"space"}}},
+		{"8-12 characters; must contain a number; at least one Uppercase letter", PasswordPolicy{
+			MinLength: 8, MaxLength: 12, Require: []string{"digit", "upper"}}},
+		{"between 6 and 20 chars, no special characters, no spaces allowed", PasswordPolicy{
+			MinLength: 6, MaxLength: 20, Forbid: []string{"symbol", "space"}}},
+		{"10+ characters, Symbols: !@#$", PasswordPolicy{MinLength: 10, Symbols: "!@#$"}},
+		{"exactly 6, no letters", PasswordPolicy{}},
+	}
+	for _, tc := range cases {
+		policy, err := ParsePasswordPolicy(tc.description)
+		if tc.description == "exactly 6, no letters" {
+			if err == nil {
+				t.Errorf("Expected error parsing '%s'", tc.description)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to parse '%s': %v", tc.description, err)
+			continue
+		}
+		if !reflect.DeepEqual(policy, tc.policy) {
+			t.Errorf("Parsing '%s': expected %+v, got %+v", tc.description, tc.policy, policy)
+		}
+	}
+
+	for _, invalid := range []string{"min 20, max 10", "must be memorable", "at least one symbol, no symbols"} {
+		if _, err := ParsePasswordPolicy(invalid); err == nil {
+			t.Errorf("Expected error parsing '%s'", invalid)
+		}
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy, err := ParsePasswordPolicy("min 8, max 12, at least one digit, no spaces, symbols: !#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for password, valid := range map[string]bool{
+		"abcdef12":      true,
+		"abc!def#12":    true,
+		"abcdef1":       false,
+		"abcdefgh":      false,
+		"abcdef1234567": false,
+		"abc def12":     false,
+		"abcdef12@":     false,
+	} {
+		if err := policy.Check(password); (err == nil) != valid {
+			t.Errorf("Checking '%s': expected valid=%v, got error %v", password, valid, err)
+		}
+	}
+}
+
+func TestGenerateForPolicy(t *testing.T) {
+	policy, err := ParsePasswordPolicy("max 10, at least one symbol, symbols: ()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, recipeName := range []string{"default", "long", "symbols"} {
+		for i := 0; i < 20; i++ {
+			password, err := GenerateForPolicy(policy, GenRecipes[recipeName], 0)
+			if err != nil {
+				t.Fatalf("Failed to generate password with recipe '%s': %v", recipeName, err)
+			}
+			if err := policy.Check(password); err != nil {
+				t.Errorf("Generated password '%s' does not meet policy: %v", password, err)
+			}
+			if len(password) != 10 {
+				t.Errorf("Expected password to be limited to 10 chars, got '%s'", password)
+			}
+			if !strings.ContainsAny(password, "()") {
+				t.Errorf("Expected password '%s' to contain an allowed symbol", password)
+			}
+		}
+	}
+
+	policy, err = ParsePasswordPolicy("exactly 6, no lowercase, no uppercase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := GenerateForPolicy(policy, GenRecipes["default"], 0)
+	if err != nil || len(password) != 6 || strings.Trim(password, "0123456789") != "" {
+		t.Errorf("Expected 6 digit password, got '%s' (%v)", password, err)
+	}
+}
@@ -0,0 +1,153 @@
+package onepass
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// placeholder title written to contents.js and item files
+// for vaults with private titles
+const PrivateTitlePlaceholder = "Private Item"
+
+// VaultOptions holds settings for a vault which are specific
+// to 1pass and are not understood by other 1Password apps
+type VaultOptions struct {
+	// If true, item titles and locations are stored only in the
+	// encrypted item content. The plaintext item files and
+	// contents.js index contain placeholders instead, so listing
+	// items requires the vault to be unlocked.
+	//
+	// Other 1Password apps will only show placeholder titles
+	// for items in such a vault.
+	PrivateTitles bool `json:"privateTitles"`
+
+	// If true, each item file is signed with a key derived from the
+	// vault's key, so that changes made without the master password
+	// are detected when items are loaded. See Item.SignatureErr()
+	// and Vault.RequireSignatures
+	SignItems bool `json:"signItems,omitempty"`
+
+	// If true, the previous version of an item is kept whenever
+	// its content is changed. See Item.History()
+	KeepHistory bool `json:"keepHistory,omitempty"`
+
+	// Number of days after which items in the trash and the
+	// records of removed items are purged, or zero to keep them
+	// indefinitely. See PurgeTrash()
+	TrashRetentionDays int `json:"trashRetentionDays,omitempty"`
+
+	// UNIX timestamps of the last change of the master password and
+	// of the creation of the vault's keys, or zero if unknown.
+	// See PasswordAge() and KeyAge()
+	PasswordChangedAt int64 `json:"passwordChangedAt,omitempty"`
+	KeysCreatedAt     int64 `json:"keysCreatedAt,omitempty"`
+
+	// If set, the vault is a read-only mirror of a subset of
+	// another vault's items and items cannot be saved except
+	// by UpdateMirror()
+	Mirror *MirrorSource `json:"mirror,omitempty"`
+}
+
+func (vault *Vault) optionsPath() string {
+	return vault.DataDir() + "/1pass-options.json"
+}
+
+// Options returns the 1pass-specific settings for the vault
+func (vault *Vault) Options() (VaultOptions, error) {
+	var options VaultOptions
+	err := jsonutil.ReadFile(vault.optionsPath(), &options)
+	if os.IsNotExist(err) {
+		return VaultOptions{}, nil
+	} else if err != nil {
+		return VaultOptions{}, fmt.Errorf("Failed to read vault options: %v", err)
+	}
+	return options, nil
+}
+
+// SetOptions changes the 1pass-specific settings for the vault.
+// When enabling or disabling private titles, existing items need to
+// be re-saved to update their plaintext titles. See SetPrivateTitles().
+func (vault *Vault) SetOptions(options VaultOptions) error {
+	return jsonutil.WritePrettyFile(vault.optionsPath(), options)
+}
+
+// SetPrivateTitles enables or disables private titles for the vault
+// and updates existing items accordingly. The vault must be unlocked.
+func (vault *Vault) SetPrivateTitles(enabled bool) error {
+	if vault.IsLocked() {
+		return errors.New("Vault is locked")
+	}
+	options, err := vault.Options()
+	if err != nil {
+		return err
+	}
+
+	// when enabling private titles, the real titles are read from
+	// the item files before the option is changed. When disabling,
+	// they are read from the encrypted content.
+	if enabled {
+		options.PrivateTitles = true
+		err = vault.SetOptions(options)
+		if err != nil {
+			return err
+		}
+	}
+	items, err := vault.ListItems()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		options.PrivateTitles = false
+		err = vault.SetOptions(options)
+		if err != nil {
+			return err
+		}
+	}
+
+	return vault.SaveItems(items)
+}
+
+// revealPrivateTitle restores the title and location of an item
+// in a vault with private titles from its encrypted content
+func (item *Item) revealPrivateTitle() error {
+	content, err := item.Content()
+	if err != nil {
+		return fmt.Errorf("Unable to read title of item %s: %v", item.Uuid, err)
+	}
+	if content.PrivateTitle != "" {
+		item.Title = content.PrivateTitle
+		item.Location = content.PrivateLocation
+	}
+	return nil
+}
+
+// storePrivateTitle copies the item's title and location into its
+// encrypted content and returns a copy of the item with
+// placeholders for the title and location, for saving to the
+// item's plaintext file and contents.js
+func (item *Item) storePrivateTitle() (Item, error) {
+	content, err := item.Content()
+	if err != nil {
+		return Item{}, err
+	}
+	if content.PrivateTitle != item.Title || content.PrivateLocation != item.Location {
+		content.PrivateTitle = item.Title
+		content.PrivateLocation = item.Location
+		location := item.Location
+		err = item.SetContent(content)
+		if err != nil {
+			return Item{}, err
+		}
+		// SetContent() updates the location from the
+		// content's URLs, restore the original value
+		item.Location = location
+	}
+
+	placeholder := *item
+	placeholder.Title = PrivateTitlePlaceholder
+	placeholder.Location = ""
+	return placeholder, nil
+}
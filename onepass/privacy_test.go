@@ -0,0 +1,57 @@
+package onepass
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestPrivateTitles(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := vault.AddItem("Secret Bank", "webforms.WebForm", newTestContent("https://bank.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vault.SetPrivateTitles(true)
+	if err != nil {
+		t.Fatalf("Unable to enable private titles: %v", err)
+	}
+	for _, path := range []string{item.Path(), vault.DataDir() + "/contents.js"} {
+		data, _ := ioutil.ReadFile(path)
+		if strings.Contains(string(data), "Secret Bank") || strings.Contains(string(data), "bank.example.com") {
+			t.Errorf("Title or location stored in plaintext in %s", path)
+		}
+	}
+
+	items, err := vault.ListItems()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Unable to list items: %v", err)
+	}
+	if items[0].Title != "Secret Bank" || items[0].Location != "https://bank.example.com" {
+		t.Errorf("Title not restored: %s, %s", items[0].Title, items[0].Location)
+	}
+	loadedItem, err := vault.LoadItem(item.Uuid)
+	if err != nil || loadedItem.Title != "Secret Bank" {
+		t.Errorf("Title not restored when loading item: %s, %v", loadedItem.Title, err)
+	}
+
+	vault.Lock()
+	_, err = vault.ListItems()
+	if err == nil {
+		t.Errorf("Expected error listing items in locked vault with private titles")
+	}
+	vault.Unlock([]byte("test-pwd"))
+
+	err = vault.SetPrivateTitles(false)
+	if err != nil {
+		t.Fatalf("Unable to disable private titles: %v", err)
+	}
+	data, _ := ioutil.ReadFile(vault.DataDir() + "/contents.js")
+	if !strings.Contains(string(data), "Secret Bank") {
+		t.Errorf("Title not restored to contents.js after disabling private titles")
+	}
+}
@@ -0,0 +1,70 @@
+package onepass
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// name of the profile which is created in new vaults
+// and used by default when opening a vault
+const DefaultProfile = "default"
+
+// profileDataDir returns the folder containing the keys
+// and items for a profile of the vault in vaultPath
+func profileDataDir(vaultPath string, profile string) string {
+	return vaultPath + "/data/" + profile
+}
+
+// VaultProfiles returns the names of the profiles in the vault in
+// vaultPath, in sorted order. Each profile is a folder in the vault's
+// 'data' folder with its own set of keys and items. Vaults created by
+// 1Password and 1pass normally have a single 'default' profile.
+func VaultProfiles(vaultPath string) ([]string, error) {
+	entries, err := ioutil.ReadDir(vaultPath + "/data")
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// defaultProfile returns the profile used when opening the vault in
+// vaultPath without naming a profile: 'default' if the vault has one
+// or the first of its profiles otherwise
+func defaultProfile(vaultPath string) (string, error) {
+	profiles, err := VaultProfiles(vaultPath)
+	if err != nil {
+		return "", err
+	}
+	if len(profiles) == 0 {
+		return "", fmt.Errorf("No profiles found in vault")
+	}
+	if containsString(profiles, DefaultProfile) {
+		return DefaultProfile, nil
+	}
+	return profiles[0], nil
+}
+
+// profileName returns the vault's profile, which defaults
+// to 'default' for vaults which were not opened with
+// OpenVault() or OpenVaultProfile()
+func (vault *Vault) profileName() string {
+	if vault.Profile == "" {
+		return DefaultProfile
+	}
+	return vault.Profile
+}
+
+// unlockKeys decrypts the item encryption keys for the
+// vault's profile. See UnlockProfileKeys()
+func (vault *Vault) unlockKeys(pwd []byte) (KeyDict, error) {
+	return UnlockProfileKeys(vault.Path, vault.profileName(), pwd)
+}
@@ -0,0 +1,61 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+)
+
+func TestVaultProfiles(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vault.AddItem("Example", "webforms.WebForm", newTestContent("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// move the vault's only profile so that there is
+	// no 'default' profile
+	err = os.Rename(vault.Path+"/data/default", vault.Path+"/data/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := OpenVault(vault.Path)
+	if err != nil {
+		t.Fatalf("Unable to open vault without a default profile: %v", err)
+	}
+	if opened.Profile != "work" {
+		t.Errorf("Expected 'work' profile to be used, got '%s'", opened.Profile)
+	}
+	err = opened.Unlock([]byte("test-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to unlock profile: %v", err)
+	}
+	items, err := opened.ListItems()
+	if err != nil || len(items) != 1 || items[0].Title != "Example" {
+		t.Errorf("Expected items from 'work' profile, got %v, %v", items, err)
+	}
+
+	// add a second, empty profile
+	err = os.MkdirAll(vault.Path+"/data/default", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := VaultProfiles(vault.Path)
+	if err != nil || len(profiles) != 2 || profiles[0] != "default" || profiles[1] != "work" {
+		t.Errorf("Unexpected profiles %v, %v", profiles, err)
+	}
+	opened, err = OpenVault(vault.Path)
+	if err != nil || opened.Profile != DefaultProfile {
+		t.Errorf("Expected default profile to be preferred, got '%s', %v", opened.Profile, err)
+	}
+	opened, err = OpenVaultProfile(vault.Path, "work")
+	if err != nil || opened.DataDir() != vault.Path+"/data/work" {
+		t.Errorf("Unable to open 'work' profile: %v", err)
+	}
+	_, err = OpenVaultProfile(vault.Path, "home")
+	if err == nil {
+		t.Errorf("Expected opening a missing profile to fail")
+	}
+}
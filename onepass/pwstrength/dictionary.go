@@ -0,0 +1,69 @@
+package pwstrength
+
+import (
+	"strings"
+)
+
+// frequently used passwords, most common first, from
+// published lists of leaked passwords
+var commonPasswords = strings.Fields(`
+123456 password 12345678 qwerty 123456789 12345 1234 111111 1234567 dragon
+123123 baseball abc123 football monkey letmein 696969 shadow master 666666
+qwertyuiop 123321 mustang 1234567890 michael 654321 superman 1qaz2wsx 7777777
+121212 000000 qazwsx 123qwe killer trustno1 jordan jennifer zxcvbnm asdfgh
+hunter buster soccer harley batman andrew tigger sunshine iloveyou 2000 charlie
+robert thomas hockey ranger daniel starwars klaster 112233 george computer
+michelle jessica pepper 1111 zxcvbn 555555 11111111 131313 freedom 777777 pass
+maggie 159753 aaaaaa ginger princess joshua cheese amanda summer love ashley
+nicole chelsea biteme matthew access yankees 987654321 dallas austin thunder
+taylor matrix mobilemail mom monitor monitoring montana moon moscow welcome
+secret admin login passw0rd password1 password123 qwerty123 abc123456 changeme
+default administrator root toor guest letmein1 welcome1 hello hello123 test
+test123 temp temp123 azerty solo loveme whatever donald flower hottie lovely
+`)
+
+// common English words, which are often combined to make passwords
+var commonWords = strings.Fields(`
+the be to of and a in that have it for not on with he as you do at this but his
+by from they we say her she or an will my one all would there their what so up
+out if about who get which go me when make can like time no just him know take
+people into year your good some could them see other than then now look only
+come its over think also back after use two how our work first well way even new
+want because any these give day most us is was are been has had were said did
+very world house home family life child school water money car dog cat horse
+love happy summer winter spring autumn monday friday sunday january december
+apple orange banana cherry lemon tiger lion eagle bear wolf dragon monkey
+football soccer baseball hockey golf tennis music guitar piano movie star
+red blue green yellow black white purple silver gold secret password letmein
+correct battery staple horse sun moon sky sea ocean river mountain forest
+king queen prince princess angel devil heaven hell god jesus christ
+`)
+
+// rows of keys on common keyboard layouts, which are used to find
+// passwords formed by runs of adjacent keys
+var keyboardRows = []string{
+	"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./",
+	"azertyuiop", "qsdfghjklm", "wxcvbn", "qwertzuiop", "yxcvbnm",
+	"1qaz", "2wsx", "3edc", "4rfv", "5tgb", "6yhn", "7ujm", "8ik,", "9ol.", "0p;/",
+}
+
+// common substitutions of symbols and digits for letters
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i',
+	'!': 'i', '|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// rankedDictionary maps each word in a list to its rank,
+// starting from 1 for the first word
+func rankedDictionary(words []string) map[string]int {
+	ranks := map[string]int{}
+	for i, word := range words {
+		if _, exists := ranks[word]; !exists {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}
+
+var passwordRanks = rankedDictionary(commonPasswords)
+var wordRanks = rankedDictionary(commonWords)
@@ -0,0 +1,464 @@
+// Package pwstrength estimates the strength of passwords in the
+// manner of Dropbox's zxcvbn. A password is split into the patterns
+// which an attacker would try first, such as common passwords and
+// words, keyboard rows, sequences, repeats and dates, and the
+// strength is the number of guesses needed to find the cheapest
+// combination of patterns and other characters.
+package pwstrength
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// number of guesses for each character which is not
+// part of a pattern
+const bruteforceCardinality = 10
+
+// minimum length of dictionary words, keyboard
+// runs, sequences and repeats
+const minPatternLength = 3
+
+// Kinds of pattern found by Estimate()
+const (
+	PatternDictionary = "dictionary"
+	PatternUserInput  = "user-input"
+	PatternKeyboard   = "keyboard"
+	PatternSequence   = "sequence"
+	PatternRepeat     = "repeat"
+	PatternDate       = "date"
+	PatternBruteforce = "bruteforce"
+)
+
+// Match is a part of a password which forms a pattern
+type Match struct {
+	Pattern string
+	Token   string
+	// estimated number of guesses needed to find Token
+	Guesses float64
+	// position of Token in the password, in characters
+	start, end int
+	// set for dictionary words with symbols or
+	// digits substituted for letters
+	leet bool
+	// set for dictionary matches against the
+	// list of common passwords
+	commonPassword bool
+}
+
+// Strength is the estimated strength of a password
+type Strength struct {
+	// estimated number of guesses needed to find the
+	// password and its base-2 logarithm
+	Guesses float64
+	Bits    float64
+	// score from 0 (too guessable) to 4 (very unguessable),
+	// using the same scale as zxcvbn
+	Score int
+	// describes the main weakness of the password, if any
+	Warning string
+	// the patterns which make up the password
+	Matches []Match
+}
+
+// guesses needed for scores 1-4
+var scoreThresholds = []float64{1e3, 1e6, 1e8, 1e10}
+
+// ScoreDescription returns a short description of a score
+// returned by Estimate()
+func ScoreDescription(score int) string {
+	return []string{"very weak", "weak", "fair", "strong", "very strong"}[score]
+}
+
+// Estimate returns the estimated strength of password. userInputs
+// are words which an attacker might associate with the password,
+// such as the name of the vault, and are treated as the most
+// common dictionary words.
+func Estimate(password string, userInputs ...string) Strength {
+	runes := []rune(password)
+	matches := findMatches(runes, userInputs)
+
+	// bits[k] is the smallest number of bits needed
+	// to guess the first k characters
+	bits := make([]float64, len(runes)+1)
+	last := make([]*Match, len(runes)+1)
+	for k := 1; k <= len(runes); k++ {
+		bits[k] = bits[k-1] + math.Log2(bruteforceCardinality)
+		last[k] = nil
+		for i := range matches {
+			match := &matches[i]
+			if match.end != k {
+				continue
+			}
+			matchBits := bits[match.start] + math.Log2(match.Guesses)
+			if matchBits < bits[k] {
+				bits[k] = matchBits
+				last[k] = match
+			}
+		}
+	}
+
+	sequence := []Match{}
+	for k := len(runes); k > 0; {
+		if last[k] != nil {
+			sequence = append([]Match{*last[k]}, sequence...)
+			k = last[k].start
+			continue
+		}
+		start := k - 1
+		for start > 0 && last[start] == nil {
+			start--
+		}
+		sequence = append([]Match{{
+			Pattern: PatternBruteforce,
+			Token:   string(runes[start:k]),
+			Guesses: math.Pow(bruteforceCardinality, float64(k-start)),
+			start:   start,
+			end:     k,
+		}}, sequence...)
+		k = start
+	}
+
+	strength := Strength{
+		Bits:    bits[len(runes)],
+		Matches: sequence,
+	}
+	strength.Guesses = math.Pow(2, strength.Bits)
+	for _, threshold := range scoreThresholds {
+		if strength.Guesses >= threshold {
+			strength.Score++
+		}
+	}
+	if strength.Score < 3 {
+		strength.Warning = warning(sequence)
+	}
+	return strength
+}
+
+// warning describes the longest pattern in a password
+func warning(sequence []Match) string {
+	var longest *Match
+	for i := range sequence {
+		match := &sequence[i]
+		if match.Pattern == PatternBruteforce {
+			continue
+		}
+		if longest == nil || match.end-match.start > longest.end-longest.start {
+			longest = match
+		}
+	}
+	if longest == nil {
+		return "Short passwords are easy to guess. Add more words or characters."
+	}
+
+	switch longest.Pattern {
+	case PatternDictionary:
+		switch {
+		case longest.commonPassword && len(sequence) == 1:
+			return "This is a commonly used password."
+		case longest.commonPassword:
+			return "This is similar to a commonly used password."
+		case longest.leet:
+			return "Predictable substitutions like '@' instead of 'a' do not help very much."
+		case len(sequence) == 1:
+			return "A word by itself is easy to guess."
+		default:
+			return "Common words and names are easy to guess."
+		}
+	case PatternUserInput:
+		return "Passwords based on names associated with them are easy to guess."
+	case PatternKeyboard:
+		return "Rows of keys on the keyboard are easy to guess."
+	case PatternSequence:
+		return "Sequences like 'abc' or '6543' are easy to guess."
+	case PatternRepeat:
+		return "Repeats like 'aaa' or 'abcabc' are easy to guess."
+	case PatternDate:
+		return "Dates and years are easy to guess."
+	}
+	return ""
+}
+
+func findMatches(password []rune, userInputs []string) []Match {
+	matches := dictionaryMatches(password, userInputs)
+	matches = append(matches, keyboardMatches(password)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, repeatMatches(password)...)
+	matches = append(matches, dateMatches(password)...)
+	return matches
+}
+
+// dictionaryMatches returns the substrings of password which are
+// common passwords, common words or user inputs, ignoring case
+// and common substitutions of symbols for letters
+func dictionaryMatches(password []rune, userInputs []string) []Match {
+	userInputRanks := map[string]int{}
+	for i, input := range userInputs {
+		userInputRanks[strings.ToLower(input)] = i + 1
+	}
+
+	lower := []rune(strings.ToLower(string(password)))
+	if len(lower) != len(password) {
+		return nil
+	}
+	unleet := make([]rune, len(lower))
+	for i, ch := range lower {
+		if letter, ok := leetSubstitutions[ch]; ok {
+			unleet[i] = letter
+		} else {
+			unleet[i] = ch
+		}
+	}
+
+	matches := []Match{}
+	for i := 0; i < len(password); i++ {
+		for j := i + minPatternLength; j <= len(password); j++ {
+			for _, candidate := range []struct {
+				word []rune
+				leet bool
+			}{{lower[i:j], false}, {unleet[i:j], true}} {
+				if candidate.leet && string(candidate.word) == string(lower[i:j]) {
+					continue
+				}
+				word := string(candidate.word)
+				pattern := PatternDictionary
+				rank, common := passwordRanks[word]
+				if wordRank, ok := wordRanks[word]; ok && (!common || wordRank < rank) {
+					rank, common = wordRank, false
+				}
+				if inputRank, ok := userInputRanks[word]; ok {
+					rank, common, pattern = inputRank, false, PatternUserInput
+				}
+				if rank == 0 {
+					continue
+				}
+				guesses := float64(rank) * caseVariations(password[i:j])
+				if candidate.leet {
+					guesses *= leetVariations(lower[i:j])
+				}
+				matches = append(matches, Match{
+					Pattern:        pattern,
+					Token:          string(password[i:j]),
+					Guesses:        guesses,
+					start:          i,
+					end:            j,
+					leet:           candidate.leet,
+					commonPassword: common,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// caseVariations returns the number of ways that an attacker
+// would try of capitalizing a word before reaching token
+func caseVariations(token []rune) float64 {
+	upper := 0
+	for _, ch := range token {
+		if unicode.IsUpper(ch) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 1
+	case upper == len(token) || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))):
+		// all uppercase, or only the first or last
+		// letter is capitalized
+		return 2
+	default:
+		return math.Pow(2, float64(upper))
+	}
+}
+
+// leetVariations returns the number of ways that an attacker
+// would try of substituting symbols for letters in a word
+func leetVariations(token []rune) float64 {
+	substituted := 0
+	for _, ch := range token {
+		if _, ok := leetSubstitutions[ch]; ok {
+			substituted++
+		}
+	}
+	return 2 * float64(substituted)
+}
+
+// keyboardMatches returns runs of adjacent keys in password, in
+// either direction, such as 'qwerty' or 'lkjh'
+func keyboardMatches(password []rune) []Match {
+	lower := strings.ToLower(string(password))
+	if len([]rune(lower)) != len(password) {
+		return nil
+	}
+	lowerRunes := []rune(lower)
+	matches := []Match{}
+	for i := 0; i < len(password); i++ {
+		for j := i + minPatternLength + 1; j <= len(password); j++ {
+			token := string(lowerRunes[i:j])
+			if !isKeyboardRun(token) {
+				break
+			}
+			matches = append(matches, Match{
+				Pattern: PatternKeyboard,
+				Token:   string(password[i:j]),
+				Guesses: float64(len(keyboardRows)*2*(j-i)) * caseVariations(password[i:j]),
+				start:   i,
+				end:     j,
+			})
+		}
+	}
+	return matches
+}
+
+func isKeyboardRun(token string) bool {
+	reversed := []rune(token)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	for _, row := range keyboardRows {
+		if strings.Contains(row, token) || strings.Contains(row, string(reversed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sequenceMatches returns runs of consecutive characters in
+// password, in either direction, such as 'abcd' or '97531'
+func sequenceMatches(password []rune) []Match {
+	matches := []Match{}
+	for i := 0; i < len(password)-1; {
+		delta := password[i+1] - password[i]
+		j := i + 1
+		for j < len(password) && password[j]-password[j-1] == delta {
+			j++
+		}
+		if j-i >= minPatternLength && delta != 0 && delta >= -2 && delta <= 2 {
+			first := password[i]
+			var base float64
+			switch {
+			case strings.ContainsRune("aAzZ019", first):
+				base = 4
+			case unicode.IsDigit(first):
+				base = 10
+			default:
+				base = 26
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			matches = append(matches, Match{
+				Pattern: PatternSequence,
+				Token:   string(password[i:j]),
+				Guesses: base * float64(j-i),
+				start:   i,
+				end:     j,
+			})
+		}
+		// the last character of a run may
+		// start the next one
+		i = j - 1
+	}
+	return matches
+}
+
+// repeatMatches returns runs of a repeated character or
+// group of characters in password, such as 'aaaa' or 'abcabc'
+func repeatMatches(password []rune) []Match {
+	matches := []Match{}
+	for i := 0; i < len(password); i++ {
+		for size := 1; i+size*2 <= len(password); size++ {
+			block := string(password[i : i+size])
+			j := i + size
+			for j+size <= len(password) && string(password[j:j+size]) == block {
+				j += size
+			}
+			count := (j - i) / size
+			if count < 2 || j-i < minPatternLength {
+				continue
+			}
+			var blockGuesses float64 = bruteforceCardinality
+			if size > 1 {
+				blockGuesses = Estimate(block).Guesses
+			}
+			matches = append(matches, Match{
+				Pattern: PatternRepeat,
+				Token:   string(password[i:j]),
+				Guesses: blockGuesses * float64(count),
+				start:   i,
+				end:     j,
+			})
+		}
+	}
+	return matches
+}
+
+var (
+	yearRegex      = regexp.MustCompile(`^(19|20)\d\d$`)
+	dateRegex      = regexp.MustCompile(`^(\d{1,2})([-/. _]?)(\d{1,2})([-/. _]?)(\d{2}|\d{4})$`)
+	yearFirstRegex = regexp.MustCompile(`^(\d{4})([-/. _]?)(\d{1,2})([-/. _]?)(\d{1,2})$`)
+)
+
+// number of years and days which an attacker
+// would try when guessing a date
+const (
+	dateYears = 120
+	dateDays  = 365
+)
+
+// dateMatches returns years and dates in password, such
+// as '1987', '25.12.99' or '2019-03-14'
+func dateMatches(password []rune) []Match {
+	matches := []Match{}
+	for i := 0; i < len(password); i++ {
+		for j := i + 4; j <= len(password) && j-i <= 10; j++ {
+			token := string(password[i:j])
+			var guesses float64
+			if yearRegex.MatchString(token) {
+				guesses = dateYears
+			} else if isDate(token) {
+				guesses = dateYears * dateDays
+			} else {
+				continue
+			}
+			matches = append(matches, Match{
+				Pattern: PatternDate,
+				Token:   token,
+				Guesses: guesses,
+				start:   i,
+				end:     j,
+			})
+		}
+	}
+	return matches
+}
+
+// isDate returns true if token is a day, month and year in any
+// order with the year first or last, optionally separated by
+// '-', '/', '.', '_' or spaces
+func isDate(token string) bool {
+	if match := dateRegex.FindStringSubmatch(token); match != nil && match[2] == match[4] {
+		a, b := atoi(match[1]), atoi(match[3])
+		return isDayMonth(a, b) || isDayMonth(b, a)
+	}
+	if match := yearFirstRegex.FindStringSubmatch(token); match != nil && match[2] == match[4] {
+		year := atoi(match[1])
+		return year >= 1900 && year < 2100 && isDayMonth(atoi(match[5]), atoi(match[3]))
+	}
+	return false
+}
+
+func isDayMonth(day int, month int) bool {
+	return day >= 1 && day <= 31 && month >= 1 && month <= 12
+}
+
+func atoi(digits string) int {
+	value := 0
+	for _, ch := range digits {
+		value = value*10 + int(ch-'0')
+	}
+	return value
+}
@@ -0,0 +1,68 @@
+package pwstrength
+
+import (
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	type testCase struct {
+		password   string
+		userInputs []string
+		minScore   int
+		maxScore   int
+		pattern    string
+	}
+	cases := []testCase{
+		{password: "password", maxScore: 0, pattern: PatternDictionary},
+		{password: "P@ssw0rd", maxScore: 1, pattern: PatternDictionary},
+		{password: "qwertyuiop", maxScore: 0, pattern: PatternDictionary},
+		{password: "zxcvfdsa", maxScore: 1},
+		{password: "abcdefghijk", maxScore: 1, pattern: PatternSequence},
+		{password: "aaaaaaaaaaaa", maxScore: 1, pattern: PatternRepeat},
+		{password: "xyzqxyzqxyzq", maxScore: 2, pattern: PatternRepeat},
+		{password: "14/03/1987", maxScore: 1, pattern: PatternDate},
+		{password: "banking2019", userInputs: []string{"banking"}, maxScore: 1, pattern: PatternUserInput},
+		{password: "Tr0ub4dour&3", minScore: 2, maxScore: 4},
+		{password: "correct horse battery staple", minScore: 3, maxScore: 4},
+		{password: "xK#9mQ!2vL@7pR", minScore: 4, maxScore: 4},
+	}
+	for _, tc := range cases {
+		strength := Estimate(tc.password, tc.userInputs...)
+		if strength.Score < tc.minScore || strength.Score > tc.maxScore {
+			t.Errorf("Expected score %d-%d for '%s', got %d (%.1f bits)", tc.minScore, tc.maxScore,
+				tc.password, strength.Score, strength.Bits)
+		}
+		if tc.pattern != "" {
+			found := false
+			for _, match := range strength.Matches {
+				found = found || match.Pattern == tc.pattern
+			}
+			if !found {
+				t.Errorf("Expected '%s' pattern in '%s', got %v", tc.pattern, tc.password, strength.Matches)
+			}
+		}
+		if strength.Score < 3 && strength.Warning == "" {
+			t.Errorf("Expected a warning for '%s'", tc.password)
+		}
+	}
+}
+
+func TestEstimateEmpty(t *testing.T) {
+	strength := Estimate("")
+	if strength.Score != 0 || strength.Guesses != 1 {
+		t.Errorf("Unexpected strength for empty password: %+v", strength)
+	}
+}
+
+func TestIsDate(t *testing.T) {
+	for _, date := range []string{"25.12.99", "2019-03-14", "3/14/2015", "010190"} {
+		if !isDate(date) {
+			t.Errorf("Expected '%s' to be a date", date)
+		}
+	}
+	for _, notDate := range []string{"13/13/2000", "25.12-99", "123456789"} {
+		if isDate(notDate) {
+			t.Errorf("Expected '%s' not to be a date", notDate)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package onepass
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// ItemRevision is a previous version of an item, kept when
+// the item was changed in a vault with history enabled.
+// See VaultOptions.KeepHistory
+type ItemRevision struct {
+	// revision number, starting from 1 for the oldest
+	// version of the item
+	Number int
+	Item
+}
+
+// historyDir returns the directory where previous
+// versions of an item are kept
+func (item *Item) historyDir() string {
+	return item.vault.DataDir() + "/1pass-history/" + item.Uuid
+}
+
+func (item *Item) revisionPath(number int) string {
+	return fmt.Sprintf("%s/%d.1password", item.historyDir(), number)
+}
+
+// revisionNumbers returns the numbers of the item's
+// revisions in ascending order
+func (item *Item) revisionNumbers() ([]int, error) {
+	entries, err := ioutil.ReadDir(item.historyDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read history of item %s: %v", item.Uuid, err)
+	}
+	numbers := []int{}
+	for _, entry := range entries {
+		if path.Ext(entry.Name()) != ".1password" {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".1password"))
+		if err == nil {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// saveRevision copies the item's current file to its history
+// before it is replaced by savedItem. Nothing is saved if the
+// item's encrypted content is unchanged.
+func (item *Item) saveRevision(savedItem Item) error {
+	var previous Item
+	err := jsonutil.ReadFile(item.Path(), &previous)
+	if err != nil {
+		return fmt.Errorf("Failed to read previous version of item %s: %v", item.Title, err)
+	}
+	if bytes.Equal(previous.Encrypted, savedItem.Encrypted) {
+		return nil
+	}
+	numbers, err := item.revisionNumbers()
+	if err != nil {
+		return err
+	}
+	number := 1
+	if len(numbers) > 0 {
+		number = numbers[len(numbers)-1] + 1
+	}
+	err = os.MkdirAll(item.historyDir(), 0700)
+	if err == nil {
+		err = jsonutil.WriteFile(item.revisionPath(number), previous)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to save previous version of item %s: %v", item.Title, err)
+	}
+	return nil
+}
+
+// removeHistory deletes the item's previous versions
+func (item *Item) removeHistory() error {
+	err := os.RemoveAll(item.historyDir())
+	if err != nil {
+		return fmt.Errorf("Failed to remove history of item %s: %v", item.Uuid, err)
+	}
+	return nil
+}
+
+// History returns the previous versions of the item, oldest
+// first. The item's current version is not included.
+func (item *Item) History() ([]ItemRevision, error) {
+	numbers, err := item.revisionNumbers()
+	if err != nil {
+		return nil, err
+	}
+	revisions := []ItemRevision{}
+	for _, number := range numbers {
+		revision, err := item.vault.loadItemFile(item.revisionPath(number))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read revision %d of item %s: %v", number, item.Uuid, err)
+		}
+		revisions = append(revisions, ItemRevision{Number: number, Item: revision})
+	}
+	return revisions, nil
+}
+
+// Revert replaces the item with a previous version from its
+// history. The version being replaced is added to the history,
+// so reverting can itself be undone.
+func (item *Item) Revert(number int) error {
+	revisionPath := item.revisionPath(number)
+	if _, err := os.Stat(revisionPath); os.IsNotExist(err) {
+		return fmt.Errorf("Item '%s' has no revision %d", item.Title, number)
+	}
+	revision, err := item.vault.loadItemFile(revisionPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read revision %d of item %s: %v", number, item.Uuid, err)
+	}
+	if revision.Uuid != item.Uuid {
+		return fmt.Errorf("Revision %d of item %s belongs to a different item", number, item.Uuid)
+	}
+	revision.CreatedAt = item.CreatedAt
+	err = revision.Save()
+	if err != nil {
+		return err
+	}
+	*item = revision
+	return nil
+}
+
+// SetKeepHistory enables or disables keeping previous versions of
+// items when they are changed. Disabling history does not remove
+// the versions which have already been kept.
+func (vault *Vault) SetKeepHistory(enabled bool) error {
+	options, err := vault.Options()
+	if err != nil {
+		return err
+	}
+	options.KeepHistory = enabled
+	return vault.SetOptions(options)
+}
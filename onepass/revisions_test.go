@@ -0,0 +1,125 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func revisionUrls(t *testing.T, item Item) []string {
+	history, err := item.History()
+	if err != nil {
+		t.Fatalf("Unable to read item history: %v", err)
+	}
+	urls := []string{}
+	for _, revision := range history {
+		content, err := revision.Content()
+		if err != nil {
+			t.Fatalf("Unable to decrypt revision %d: %v", revision.Number, err)
+		}
+		urls = append(urls, content.PrimaryURL())
+	}
+	return urls
+}
+
+func TestItemHistory(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := vault.AddItem("Example", "webforms.WebForm", newTestContent("first.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// history is not kept unless enabled
+	item.SetContent(newTestContent("second.com"))
+	item.Save()
+	if urls := revisionUrls(t, item); len(urls) != 0 {
+		t.Errorf("Expected no history, found %v", urls)
+	}
+
+	err = vault.SetKeepHistory(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.SetContent(newTestContent("third.com"))
+	item.Save()
+	// saving without changing the content does not add a revision
+	item.Save()
+	urls := revisionUrls(t, item)
+	if len(urls) != 1 || urls[0] != "second.com" {
+		t.Errorf("Unexpected history %v", urls)
+	}
+
+	err = item.Revert(1)
+	if err != nil {
+		t.Fatalf("Unable to revert item: %v", err)
+	}
+	item, _ = vault.LoadItem(item.Uuid)
+	content, _ := item.Content()
+	if content.PrimaryURL() != "second.com" {
+		t.Errorf("Item was not reverted, URL is %s", content.PrimaryURL())
+	}
+	urls = revisionUrls(t, item)
+	if len(urls) != 2 || urls[1] != "third.com" {
+		t.Errorf("Reverted version was not added to history: %v", urls)
+	}
+	if err = item.Revert(5); err == nil {
+		t.Errorf("Reverting to a missing revision should fail")
+	}
+
+	// previous versions are re-encrypted when the
+	// vault's keys are replaced
+	err = vault.RotateKeys([]byte("test-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to rotate keys: %v", err)
+	}
+	vault.Unlock([]byte("test-pwd"))
+	item, _ = vault.LoadItem(item.Uuid)
+	urls = revisionUrls(t, item)
+	if len(urls) != 2 || urls[0] != "second.com" {
+		t.Errorf("Unexpected history after rotating keys: %v", urls)
+	}
+	if info, err := os.Stat(vault.DataDir() + "/1pass-history/" + item.Uuid); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected history to be private, got %v", info.Mode())
+	}
+
+	// removing the item deletes its history
+	// without recording the removal in it
+	err = item.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(vault.DataDir() + "/1pass-history/" + item.Uuid); !os.IsNotExist(err) {
+		t.Errorf("History was not deleted when item was removed")
+	}
+	if urls := revisionUrls(t, item); len(urls) != 0 {
+		t.Errorf("Expected no history after removing item, found %v", urls)
+	}
+}
+
+func TestPurgeTrashRemovesHistory(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault.SetKeepHistory(true)
+	item, err := vault.AddItem("Example", "webforms.WebForm", newTestContent("first.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.SetContent(newTestContent("second.com"))
+	item.Trashed = true
+	item.Save()
+	if urls := revisionUrls(t, item); len(urls) != 1 {
+		t.Fatalf("Expected one revision, found %v", urls)
+	}
+	_, err = vault.PurgeTrash(time.Now().Add(time.Minute), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(vault.DataDir() + "/1pass-history/" + item.Uuid); !os.IsNotExist(err) {
+		t.Errorf("History was not deleted when item was purged from the trash")
+	}
+}
@@ -0,0 +1,335 @@
+package onepass
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+// recordRotation updates the times at which the master password
+// was changed and/or the vault's keys were created
+func (vault *Vault) recordRotation(password bool, keys bool) error {
+	options, err := vault.Options()
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	if password {
+		options.PasswordChangedAt = now
+	}
+	if keys {
+		options.KeysCreatedAt = now
+	}
+	return vault.SetOptions(options)
+}
+
+func ageSince(timestamp int64) (time.Duration, bool) {
+	if timestamp == 0 {
+		return 0, false
+	}
+	return time.Since(time.Unix(timestamp, 0)), true
+}
+
+// PasswordAge returns the time since the master password was set.
+// ok is false if this is not known, eg. for vaults created by
+// other 1Password apps.
+func (vault *Vault) PasswordAge() (age time.Duration, ok bool, err error) {
+	options, err := vault.Options()
+	if err != nil {
+		return 0, false, err
+	}
+	age, ok = ageSince(options.PasswordChangedAt)
+	return age, ok, nil
+}
+
+// KeyAge returns the time since the vault's keys were created,
+// either with the vault or by RotateKeys(). ok is false if this
+// is not known.
+func (vault *Vault) KeyAge() (age time.Duration, ok bool, err error) {
+	options, err := vault.Options()
+	if err != nil {
+		return 0, false, err
+	}
+	age, ok = ageSince(options.KeysCreatedAt)
+	return age, ok, nil
+}
+
+// suffix added to the names of files written while
+// rotating keys, until the rotation is committed
+const rotationSuffix = ".rotating"
+
+// keyRotation is the journal of a key rotation in progress
+type keyRotation struct {
+	// identifier of the new SL5 key. The rotation has been
+	// committed if encryptionKeys.js contains this key.
+	Keys string
+
+	// paths of the re-encrypted item and revision files,
+	// relative to the vault's data directory
+	Files []string
+}
+
+func rotationJournalPath(dataDir string) string {
+	return dataDir + "/1pass-rotation.json"
+}
+
+// completeKeyRotation finishes a key rotation which was interrupted
+// after the new keys were saved, by moving the remaining re-encrypted
+// files into place, or undoes one which was interrupted before then.
+// It does nothing if no key rotation is in progress.
+func completeKeyRotation(dataDir string) error {
+	var rotation keyRotation
+	journalPath := rotationJournalPath(dataDir)
+	err := jsonutil.ReadFile(journalPath, &rotation)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Failed to read key rotation journal: %v", err)
+	}
+	var keyList encryptionKeys
+	keyFilePath := dataDir + "/encryptionKeys.js"
+	err = jsonutil.ReadFile(keyFilePath, &keyList)
+	if err != nil {
+		return errors.New("Failed to read encryption key file")
+	}
+	committed := keyList.SL5 == rotation.Keys
+
+	files := append([]string{"encryptionKeys.js"}, rotation.Files...)
+	for _, file := range files {
+		path := filepath.Join(dataDir, file)
+		if committed {
+			err = os.Rename(path+rotationSuffix, path)
+		} else {
+			err = os.Remove(path + rotationSuffix)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed to complete key rotation: %v", err)
+		}
+	}
+	if committed {
+		err = writePlistFile(dataDir+"/1password.keys", keyList)
+		if err != nil {
+			return fmt.Errorf("Failed to save new keys: %v", err)
+		}
+	}
+	return os.Remove(journalPath)
+}
+
+// RotateKeys replaces the vault's keys with new random keys and
+// re-encrypts every item with them. The new keys are encrypted with
+// the same master password and number of PBKDF2 iterations.
+//
+// All items are decrypted before anything is written. The re-encrypted
+// items and the new keys are then written to staging files which are
+// listed in a journal, and replacing encryptionKeys.js with the new keys
+// commits the rotation. If rotation is interrupted, it is completed or
+// undone when the vault is next opened. See completeKeyRotation().
+//
+// Passwords derived from PasswordRecipes would change with the keys,
+// so they are stored in their items and the recipes are removed.
+//
+// Copies of the vault and any CryptoAgents holding its keys must be
+// updated afterwards. Conflicted copies of items must be resolved first.
+//
+// If the vault has DryRun set, each item is reported to OnItemChange
+// after it has been decrypted but nothing is changed.
+func (vault *Vault) RotateKeys(pwd []byte) error {
+	err := completeKeyRotation(vault.DataDir())
+	if err != nil {
+		return err
+	}
+	oldKeys, err := vault.unlockKeys(pwd)
+	if err != nil {
+		return err
+	}
+	defer oldKeys.Wipe()
+	conflicts, err := vault.conflictedCopyNames()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("The vault has %d conflicted copies of items which must be resolved first", len(conflicts))
+	}
+	var keyList encryptionKeys
+	err = jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+	if err != nil {
+		return errors.New("Failed to read encryption key file")
+	}
+
+	// load and decrypt every item, including removed items
+	oldVault := Vault{Path: vault.Path, Profile: vault.Profile, CryptoAgent: &simpleCryptoAgent{oldKeys}}
+	dirEntries, err := ioutil.ReadDir(vault.DataDir())
+	if err != nil {
+		return err
+	}
+	items := []Item{}
+	contents := []string{}
+	revisions := []ItemRevision{}
+	revisionContents := []string{}
+	// items whose derived passwords are stored in them below
+	derivedItems := map[string]bool{}
+	for _, entry := range dirEntries {
+		if path.Ext(entry.Name()) != ".1password" {
+			continue
+		}
+		item, err := oldVault.LoadItem(strings.TrimSuffix(entry.Name(), ".1password"))
+		if err != nil {
+			return fmt.Errorf("Failed to read item %s: %v", entry.Name(), err)
+		}
+		content, err := item.ContentJson()
+		if err != nil {
+			return fmt.Errorf("Failed to decrypt item %s: %v", item.Uuid, err)
+		}
+		content, stored, err := storeDerivedPassword(content, func(recipe PasswordRecipe) (string, error) {
+			return DerivePassword(oldKeys[item.SecurityLevel], recipe)
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to derive password for item %s: %v", item.Uuid, err)
+		}
+		if stored {
+			derivedItems[item.Uuid] = true
+		}
+		items = append(items, item)
+		contents = append(contents, content)
+
+		history, err := item.History()
+		if err != nil {
+			return err
+		}
+		for _, revision := range history {
+			content, err := revision.ContentJson()
+			if err != nil {
+				return fmt.Errorf("Failed to decrypt revision %d of item %s: %v", revision.Number, item.Uuid, err)
+			}
+			content, _, err = storeDerivedPassword(content, func(recipe PasswordRecipe) (string, error) {
+				return DerivePassword(oldKeys[revision.SecurityLevel], recipe)
+			})
+			if err != nil {
+				return fmt.Errorf("Failed to derive password for revision %d of item %s: %v", revision.Number, item.Uuid, err)
+			}
+			revisions = append(revisions, revision)
+			revisionContents = append(revisionContents, content)
+		}
+	}
+
+	if vault.DryRun {
+		for _, item := range items {
+			changes := []ItemDiff{{Field: "encrypted", Secret: true}}
+			if derivedItems[item.Uuid] {
+				changes = append(changes, ItemDiff{Field: "password", Secret: true})
+			}
+			if vault.OnItemChange != nil {
+				vault.OnItemChange(ItemChange{
+					Uuid:     item.Uuid,
+					Title:    item.Title,
+					TypeName: item.TypeName,
+					Action:   ItemUpdated,
+					Changes:  changes,
+				})
+			}
+		}
+		return nil
+	}
+
+	newKeys := KeyDict{}
+	defer newKeys.Wipe()
+	for i, entry := range keyList.List {
+		newKeys[entry.Level] = secureCopy(randomBytes(agileKeychainKeyLen))
+		salt := randomBytes(8)
+		encryptedKey, validation, err := encryptKey([]byte(pwd), newKeys[entry.Level], salt, entry)
+		if err != nil {
+			return fmt.Errorf("Failed to encrypt new key: %v", err)
+		}
+		entry.Data = []byte(fmt.Sprintf("Salted__%s%s", salt, encryptedKey))
+		entry.Identifier = newItemId()
+		entry.Validation = validation
+		keyList.List[i] = entry
+		if entry.Level == "SL5" {
+			keyList.SL5 = entry.Identifier
+		}
+	}
+
+	newAgent := &simpleCryptoAgent{newKeys}
+	oldVault.CryptoAgent = newAgent
+	options, err := vault.Options()
+	if err != nil {
+		return err
+	}
+	if options.Mirror != nil {
+		return ReadOnlyError{options.Mirror.Path}
+	}
+
+	// the re-encrypted items and revisions are written next to
+	// the originals and moved into place once the new keys are saved
+	staged := []Item{}
+	stagedPaths := []string{}
+	for i := range items {
+		items[i].Encrypted, err = newAgent.Encrypt(items[i].SecurityLevel, []byte(contents[i]))
+		if err != nil {
+			return fmt.Errorf("Failed to encrypt item %s: %v", items[i].Uuid, err)
+		}
+		staged = append(staged, items[i])
+		stagedPaths = append(stagedPaths, items[i].Path())
+	}
+	for i, revision := range revisions {
+		revision.Encrypted, err = newAgent.Encrypt(revision.SecurityLevel, []byte(revisionContents[i]))
+		if err != nil {
+			return fmt.Errorf("Failed to encrypt revision %d of item %s: %v", revision.Number, revision.Uuid, err)
+		}
+		staged = append(staged, revision.Item)
+		stagedPaths = append(stagedPaths, revision.revisionPath(revision.Number))
+	}
+
+	rotation := keyRotation{Keys: keyList.SL5}
+	for _, path := range stagedPaths {
+		relPath, err := filepath.Rel(vault.DataDir(), path)
+		if err != nil {
+			return err
+		}
+		rotation.Files = append(rotation.Files, relPath)
+	}
+	err = jsonutil.WriteFileAtomic(rotationJournalPath(vault.DataDir()), rotation)
+	if err != nil {
+		return fmt.Errorf("Failed to save key rotation journal: %v", err)
+	}
+	for i := range staged {
+		savedItem, err := staged[i].storedItem(options)
+		if err != nil {
+			return err
+		}
+		err = jsonutil.WriteFile(stagedPaths[i]+rotationSuffix, savedItem)
+		if err != nil {
+			return fmt.Errorf("Failed to save item %s: %v", staged[i].Uuid, err)
+		}
+	}
+	keyFilePath := vault.DataDir() + "/encryptionKeys.js"
+	err = jsonutil.WriteFile(keyFilePath+rotationSuffix, keyList)
+	if err != nil {
+		return fmt.Errorf("Failed to save new keys: %v", err)
+	}
+
+	// replacing the key file commits the rotation. If this
+	// process is interrupted after this point, the remaining
+	// files are moved into place when the vault is next opened.
+	err = os.Rename(keyFilePath+rotationSuffix, keyFilePath)
+	if err != nil {
+		return fmt.Errorf("Failed to save new keys: %v", err)
+	}
+	err = completeKeyRotation(vault.DataDir())
+	if err != nil {
+		return err
+	}
+	if vault.OnItemChange != nil {
+		for _, item := range items {
+			vault.OnItemChange(ItemChange{Uuid: item.Uuid, Title: item.Title, TypeName: item.TypeName, Action: ItemUpdated})
+		}
+	}
+	return vault.recordRotation(false, true)
+}
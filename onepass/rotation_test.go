@@ -0,0 +1,142 @@
+package onepass
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+func TestRotationTimes(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, age := range []func() (time.Duration, bool, error){vault.PasswordAge, vault.KeyAge} {
+		duration, ok, err := age()
+		if err != nil || !ok || duration > time.Minute {
+			t.Errorf("Expected age of new vault to be recorded, got %v %v %v", duration, ok, err)
+		}
+	}
+
+	options, _ := vault.Options()
+	options.PasswordChangedAt = time.Now().Add(-48 * time.Hour).Unix()
+	vault.SetOptions(options)
+	duration, _, _ := vault.PasswordAge()
+	if duration < 47*time.Hour {
+		t.Errorf("Unexpected password age %v", duration)
+	}
+	err = vault.SetMasterPassword([]byte("test-pwd"), []byte("new-pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	duration, _, _ = vault.PasswordAge()
+	if duration > time.Minute {
+		t.Errorf("Password change not recorded")
+	}
+}
+
+func TestRotateKeys(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := vault.AddItem("Example", "webforms.WebForm", newTestContent("https://example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, _ := vault.AddItem("Removed", "webforms.WebForm", newTestContent("https://removed.com"))
+	removed.Remove()
+	oldKeys, _ := UnlockKeys(vault.Path, []byte("test-pwd"))
+
+	err = vault.RotateKeys([]byte("wrong-pwd"))
+	if _, ok := err.(DecryptError); !ok {
+		t.Errorf("Expected incorrect password error, got %v", err)
+	}
+	err = vault.RotateKeys([]byte("test-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to rotate keys: %v", err)
+	}
+
+	newKeys, err := UnlockKeys(vault.Path, []byte("test-pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(newKeys["SL5"], oldKeys["SL5"]) {
+		t.Errorf("Key was not changed")
+	}
+	vault.Unlock([]byte("test-pwd"))
+	loaded, err := vault.LoadItem(item.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := loaded.Content()
+	if err != nil || content.PrimaryURL() != "https://example.com" {
+		t.Errorf("Unable to read item after rotating keys: %v", err)
+	}
+	if bytes.Equal(loaded.Encrypted, item.Encrypted) {
+		t.Errorf("Item was not re-encrypted")
+	}
+
+	// the key files are replaced without leaving temporary files
+	tmpFiles, _ := filepath.Glob(vault.DataDir() + "/.*.tmp*")
+	if len(tmpFiles) > 0 {
+		t.Errorf("Temporary files left after saving keys: %v", tmpFiles)
+	}
+	stagedFiles, _ := filepath.Glob(vault.DataDir() + "/*" + rotationSuffix)
+	if len(stagedFiles) > 0 {
+		t.Errorf("Staged files left after rotating keys: %v", stagedFiles)
+	}
+}
+
+func TestCompleteKeyRotation(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := vault.AddItem("Example", "webforms.WebForm", newTestContent("https://example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyList encryptionKeys
+	jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+
+	stage := func(keys string) {
+		staged := item
+		staged.Title = "Staged"
+		jsonutil.WriteFile(item.Path()+rotationSuffix, staged)
+		rotation := keyRotation{Keys: keys, Files: []string{item.Uuid + ".1password"}}
+		jsonutil.WriteFile(rotationJournalPath(vault.DataDir()), rotation)
+	}
+	checkTitle := func(expected string) {
+		vault, err := OpenVault(vault.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := vault.LoadItem(item.Uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Title != expected {
+			t.Errorf("Expected title '%s' after opening vault, got '%s'", expected, loaded.Title)
+		}
+		leftover, _ := filepath.Glob(vault.DataDir() + "/*" + rotationSuffix)
+		if len(leftover) > 0 {
+			t.Errorf("Staged files left after opening vault: %v", leftover)
+		}
+		if _, err := os.Stat(rotationJournalPath(vault.DataDir())); !os.IsNotExist(err) {
+			t.Errorf("Rotation journal left after opening vault")
+		}
+	}
+
+	// rotation interrupted before the new keys were saved
+	stage("not-saved")
+	checkTitle("Example")
+
+	// rotation interrupted after the new keys were saved
+	stage(keyList.SL5)
+	checkTitle("Staged")
+}
@@ -0,0 +1,180 @@
+package onepass
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maximum number of problems reported by ValidateContentJson
+const maxSchemaErrors = 10
+
+// SchemaError lists the problems found when validating
+// item content against the expected structure for its type
+type SchemaError struct {
+	TypeName string
+	Problems []string
+}
+
+func (err SchemaError) Error() string {
+	return fmt.Sprintf("Invalid content for %s item: %s", err.TypeName, strings.Join(err.Problems, "; "))
+}
+
+type schemaValidator struct {
+	problems []string
+}
+
+func (v *schemaValidator) addProblem(path string, format string, args ...interface{}) {
+	if len(v.problems) < maxSchemaErrors {
+		v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// expectArray checks that value is an array, or absent
+func (v *schemaValidator) expectArray(path string, value interface{}) []interface{} {
+	if value == nil {
+		return nil
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		v.addProblem(path, "expected an array")
+	}
+	return array
+}
+
+func (v *schemaValidator) expectObject(path string, value interface{}) map[string]interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.addProblem(path, "expected an object")
+	}
+	return object
+}
+
+// expectString checks that value is a string, or absent if
+// required is false
+func (v *schemaValidator) expectString(path string, value interface{}, required bool) string {
+	if value == nil {
+		if required {
+			v.addProblem(path, "missing")
+		}
+		return ""
+	}
+	str, ok := value.(string)
+	if !ok {
+		v.addProblem(path, "expected a string")
+	}
+	return str
+}
+
+// checkFieldValue checks that a section field's value has
+// the representation used for its kind
+func (v *schemaValidator) checkFieldValue(path string, kind string, value interface{}) {
+	if value == nil {
+		return
+	}
+	switch kind {
+	case "date", "monthYear":
+		if _, ok := value.(float64); !ok {
+			v.addProblem(path, "expected a number for '%s' field", kind)
+		}
+	case "address":
+		v.expectObject(path, value)
+	default:
+		if _, ok := value.(string); !ok {
+			v.addProblem(path, "expected a string for '%s' field", kind)
+		}
+	}
+}
+
+// templateFieldKinds returns a map of '<section name>.<field name>'
+// to field kind for the standard fields of an item type
+func templateFieldKinds(typeName string) map[string]string {
+	kinds := map[string]string{}
+	template, ok := StandardTemplate(typeName)
+	if !ok {
+		return kinds
+	}
+	for _, section := range template.Sections {
+		for _, field := range section.Fields {
+			kinds[section.Name+"."+field.Name] = field.Kind
+		}
+	}
+	return kinds
+}
+
+// ValidateContentJson checks that content is a JSON object with the
+// structure that the official 1Password apps expect for items of
+// type typeName. Sections must contain fields with known kinds
+// and values of the matching type, and standard fields for the
+// item type must have the standard kind.
+func ValidateContentJson(typeName string, content string) error {
+	if _, ok := ItemTypes[typeName]; !ok {
+		return fmt.Errorf("Unknown item type '%s'", typeName)
+	}
+	var data interface{}
+	err := json.Unmarshal([]byte(content), &data)
+	if err != nil {
+		return fmt.Errorf("Content is not valid JSON: %v", err)
+	}
+
+	v := schemaValidator{}
+	root := v.expectObject("content", data)
+	templateKinds := templateFieldKinds(typeName)
+
+	for i, sectionValue := range v.expectArray("sections", root["sections"]) {
+		sectionPath := fmt.Sprintf("sections[%d]", i)
+		section := v.expectObject(sectionPath, sectionValue)
+		sectionName := v.expectString(sectionPath+".name", section["name"], false)
+		v.expectString(sectionPath+".title", section["title"], false)
+
+		for k, fieldValue := range v.expectArray(sectionPath+".fields", section["fields"]) {
+			fieldPath := fmt.Sprintf("%s.fields[%d]", sectionPath, k)
+			field := v.expectObject(fieldPath, fieldValue)
+			kind := v.expectString(fieldPath+".k", field["k"], true)
+			name := v.expectString(fieldPath+".n", field["n"], true)
+			v.expectString(fieldPath+".t", field["t"], false)
+			if _, ok := FieldKindMap[kind]; !ok && kind != "" {
+				v.addProblem(fieldPath+".k", "unknown field kind '%s'", kind)
+				continue
+			}
+			if expected, ok := templateKinds[sectionName+"."+name]; ok && kind != expected {
+				v.addProblem(fieldPath+".k", "standard field '%s' must have kind '%s', not '%s'", name, expected, kind)
+			}
+			v.checkFieldValue(fieldPath+".v", kind, field["v"])
+		}
+	}
+
+	for i, urlValue := range v.expectArray("URLs", root["URLs"]) {
+		urlPath := fmt.Sprintf("URLs[%d]", i)
+		url := v.expectObject(urlPath, urlValue)
+		v.expectString(urlPath+".label", url["label"], false)
+		v.expectString(urlPath+".url", url["url"], true)
+	}
+
+	for i, formFieldValue := range v.expectArray("fields", root["fields"]) {
+		fieldPath := fmt.Sprintf("fields[%d]", i)
+		field := v.expectObject(fieldPath, formFieldValue)
+		for _, key := range []string{"value", "id", "name", "type", "designation"} {
+			v.expectString(fieldPath+"."+key, field[key], false)
+		}
+	}
+
+	for _, key := range []string{"notesPlain", "htmlMethod", "htmlAction", "htmlID"} {
+		v.expectString(key, root[key], false)
+	}
+
+	if len(v.problems) > 0 {
+		return SchemaError{TypeName: typeName, Problems: v.problems}
+	}
+	return nil
+}
+
+// ValidateContent checks that content has the structure expected
+// for items of type typeName. See ValidateContentJson()
+func ValidateContent(typeName string, content ItemContent) error {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return ValidateContentJson(typeName, string(data))
+}
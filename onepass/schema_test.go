@@ -0,0 +1,72 @@
+package onepass
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateContentJson(t *testing.T) {
+	valid := []struct {
+		typeName string
+		content  string
+	}{
+		{"securenotes.SecureNote", `{"notesPlain":"hello"}`},
+		{"webforms.WebForm", `{"fields":[{"designation":"username","name":"user","type":"T","value":"alice"}],
+			"URLs":[{"label":"website","url":"https://example.com"}]}`},
+		{"wallet.financial.CreditCard", `{"sections":[{"name":"","title":"","fields":[
+			{"k":"string","n":"cardholder","t":"cardholder name","v":"Alice"},
+			{"k":"monthYear","n":"expiry","t":"expiry date","v":202701}]}]}`},
+		// custom sections may use any known kind
+		{"wallet.financial.CreditCard", `{"sections":[{"name":"Custom","title":"Custom","fields":[
+			{"k":"date","n":"renewed","t":"renewed","v":1700000000}]}]}`},
+	}
+	for _, testCase := range valid {
+		err := ValidateContentJson(testCase.typeName, testCase.content)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", testCase.content, err)
+		}
+	}
+
+	invalid := []struct {
+		typeName string
+		content  string
+		problem  string
+	}{
+		{"securenotes.SecureNote", `{"notesPlain":`, "not valid JSON"},
+		{"unknown.Type", `{}`, "Unknown item type"},
+		{"securenotes.SecureNote", `[]`, "content: expected an object"},
+		{"securenotes.SecureNote", `{"notesPlain":42}`, "notesPlain: expected a string"},
+		{"securenotes.SecureNote", `{"sections":{}}`, "sections: expected an array"},
+		{"securenotes.SecureNote", `{"sections":[{"fields":[{"k":"colour","n":"x"}]}]}`,
+			"sections[0].fields[0].k: unknown field kind 'colour'"},
+		{"securenotes.SecureNote", `{"sections":[{"fields":[{"k":"string"}]}]}`,
+			"sections[0].fields[0].n: missing"},
+		{"securenotes.SecureNote", `{"sections":[{"fields":[{"k":"date","n":"d","v":"yesterday"}]}]}`,
+			"expected a number for 'date' field"},
+		{"wallet.financial.CreditCard", `{"sections":[{"name":"","fields":[{"k":"string","n":"expiry","v":"01/27"}]}]}`,
+			"standard field 'expiry' must have kind 'monthYear'"},
+		{"webforms.WebForm", `{"URLs":[{"label":"website"}]}`, "URLs[0].url: missing"},
+		{"webforms.WebForm", `{"fields":[{"value":true}]}`, "fields[0].value: expected a string"},
+	}
+	for _, testCase := range invalid {
+		err := ValidateContentJson(testCase.typeName, testCase.content)
+		if err == nil || !strings.Contains(err.Error(), testCase.problem) {
+			t.Errorf("Expected error containing %q for %s, got %v", testCase.problem, testCase.content, err)
+		}
+	}
+}
+
+func TestSetContentJsonValidates(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := newTestItem(&vault)
+	err = item.SetContentJson(`{"sections":[{"fields":[{"k":"bogus","n":"x"}]}]}`)
+	if _, isSchemaErr := err.(SchemaError); !isSchemaErr {
+		t.Errorf("Expected schema error, got %v", err)
+	}
+	if len(item.Encrypted) != 0 {
+		t.Errorf("Invalid content should not be stored")
+	}
+}
@@ -0,0 +1,69 @@
+package onepass
+
+import (
+	"sync"
+)
+
+// secure buffers allocated by newSecureBytes() which are
+// released by WipeBytes(), by address of the first byte
+var secureAllocs = struct {
+	sync.Mutex
+	buffers map[*byte][]byte
+}{buffers: map[*byte][]byte{}}
+
+// newSecureBytes returns a zeroed buffer for secret data, such as
+// decrypted keys. Where supported, the buffer is allocated outside
+// of the Go heap, so that the garbage collector does not leave
+// copies of it behind, and locked into memory so that it is not
+// written to swap. The buffer should be released with WipeBytes()
+// once it is no longer needed.
+func newSecureBytes(size int) []byte {
+	if size == 0 {
+		return []byte{}
+	}
+	data, err := lockedAlloc(size)
+	if err != nil {
+		return make([]byte, size)
+	}
+	secureAllocs.Lock()
+	secureAllocs.buffers[&data[0]] = data
+	secureAllocs.Unlock()
+	return data
+}
+
+// secureCopy returns a copy of data in a buffer from newSecureBytes()
+// and zeroes data
+func secureCopy(data []byte) []byte {
+	copied := newSecureBytes(len(data))
+	copy(copied, data)
+	WipeBytes(data)
+	return copied
+}
+
+// WipeBytes overwrites data with zeroes. If data was allocated by
+// newSecureBytes(), its memory is also released and data must not
+// be used afterwards.
+func WipeBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+	if len(data) == 0 {
+		return
+	}
+	secureAllocs.Lock()
+	buffer, ok := secureAllocs.buffers[&data[0]]
+	delete(secureAllocs.buffers, &data[0])
+	secureAllocs.Unlock()
+	if ok {
+		lockedFree(buffer)
+	}
+}
+
+// Wipe zeroes and releases the decrypted keys and removes
+// them from the dictionary. See WipeBytes()
+func (keys KeyDict) Wipe() {
+	for name, key := range keys {
+		WipeBytes(key)
+		delete(keys, name)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package onepass
+
+import (
+	"errors"
+)
+
+// lockedAlloc is not supported on this platform, so secret
+// data is stored in ordinary memory and only zeroed by
+// WipeBytes()
+func lockedAlloc(size int) ([]byte, error) {
+	return nil, errors.New("Locked memory is not supported")
+}
+
+func lockedFree(data []byte) {
+}
@@ -0,0 +1,34 @@
+package onepass
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWipeKeys(t *testing.T) {
+	secureBufferCount := func() int {
+		secureAllocs.Lock()
+		defer secureAllocs.Unlock()
+		return len(secureAllocs.buffers)
+	}
+	initialCount := secureBufferCount()
+
+	key := []byte("0123456789abcdef")
+	original := key
+	keys := KeyDict{"SL5": secureCopy(key)}
+	if !bytes.Equal(original, make([]byte, len(original))) {
+		t.Errorf("Expected source of secure copy to be zeroed")
+	}
+	if string(keys["SL5"]) != "0123456789abcdef" {
+		t.Errorf("Unexpected secure copy: %v", keys["SL5"])
+	}
+
+	agent := &simpleCryptoAgent{keys}
+	agent.Lock()
+	if len(keys) != 0 {
+		t.Errorf("Expected keys to be removed when locked")
+	}
+	if count := secureBufferCount(); count != initialCount {
+		t.Errorf("Expected secure buffers to be released, %d remain", count-initialCount)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package onepass
+
+import (
+	"syscall"
+)
+
+// lockedAlloc allocates memory for secret data using an anonymous
+// mapping and locks it so that it is not swapped to disk. Locking
+// fails if the process's RLIMIT_MEMLOCK limit is reached, in which
+// case the memory is still used, but unlocked.
+func lockedAlloc(size int) ([]byte, error) {
+	data, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	_ = syscall.Mlock(data)
+	return data, nil
+}
+
+// lockedFree releases memory allocated by lockedAlloc()
+func lockedFree(data []byte) {
+	_ = syscall.Munlock(data)
+	_ = syscall.Munmap(data)
+}
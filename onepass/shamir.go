@@ -0,0 +1,113 @@
+package onepass
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// This file implements Shamir's secret sharing scheme over
+// GF(2^8), which is used to split a vault's master keys into
+// shares which can be held by different people. Any 'threshold'
+// shares can be combined to recover the keys but fewer shares
+// reveal nothing about them.
+//
+// Each byte of the secret is shared separately using a random
+// polynomial of degree threshold-1 whose constant term is the
+// secret byte. A share consists of the x coordinate, in the
+// range 1-255, followed by the value of each polynomial at x.
+
+// multiplication and inverse in GF(2^8) using the
+// AES polynomial x^8 + x^4 + x^3 + x + 1
+func gfMul(a byte, b byte) byte {
+	var product byte
+	for b != 0 {
+		if b&1 != 0 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return product
+}
+
+func gfInverse(a byte) byte {
+	// a^254 = a^-1 for non-zero a
+	result := byte(1)
+	for i := 0; i < 254; i++ {
+		result = gfMul(result, a)
+	}
+	return result
+}
+
+// SplitSecret splits secret into shareCount shares, any
+// threshold of which can be passed to CombineShares() to
+// recover it
+func SplitSecret(secret []byte, shareCount int, threshold int) ([][]byte, error) {
+	if threshold < 2 || threshold > shareCount || shareCount > 255 {
+		return nil, fmt.Errorf("Invalid share count (%d) or threshold (%d). The threshold must be at least 2 and no more than the number of shares", shareCount, threshold)
+	}
+	shares := make([][]byte, shareCount)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+	coefficients := make([]byte, threshold)
+	for pos, secretByte := range secret {
+		coefficients[0] = secretByte
+		_, err := io.ReadFull(rand.Reader, coefficients[1:])
+		if err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			// evaluate the polynomial at x using Horner's method
+			x := share[0]
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = gfMul(y, x) ^ coefficients[k]
+			}
+			share[pos+1] = y
+		}
+	}
+	return shares, nil
+}
+
+// CombineShares recovers a secret from shares created by
+// SplitSecret(). If fewer shares are supplied than the threshold
+// used when splitting the secret, the result will be garbage.
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("At least two shares are needed")
+	}
+	length := len(shares[0])
+	for i, share := range shares {
+		if len(share) != length || length < 2 {
+			return nil, errors.New("Shares have different lengths")
+		}
+		for _, other := range shares[:i] {
+			if share[0] == other[0] {
+				return nil, errors.New("The same share was supplied more than once")
+			}
+		}
+	}
+
+	secret := make([]byte, length-1)
+	for i, share := range shares {
+		// Lagrange basis polynomial for this share, evaluated at 0
+		basis := byte(1)
+		for k, other := range shares {
+			if k != i {
+				basis = gfMul(basis, gfMul(other[0], gfInverse(other[0]^share[0])))
+			}
+		}
+		for pos := range secret {
+			secret[pos] ^= gfMul(basis, share[pos+1])
+		}
+	}
+	return secret, nil
+}
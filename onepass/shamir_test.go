@@ -0,0 +1,81 @@
+package onepass
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSplitCombineSecret(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+	shares, err := SplitSecret(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("Unable to split secret: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("Expected 5 shares, got %d", len(shares))
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		selected := [][]byte{}
+		for _, i := range subset {
+			selected = append(selected, shares[i])
+		}
+		combined, err := CombineShares(selected)
+		if err != nil {
+			t.Errorf("Unable to combine shares %v: %v", subset, err)
+		}
+		if !bytes.Equal(combined, secret) {
+			t.Errorf("Shares %v produced %q", subset, combined)
+		}
+	}
+
+	combined, _ := CombineShares(shares[:2])
+	if bytes.Equal(combined, secret) {
+		t.Errorf("Secret should not be recoverable from fewer than threshold shares")
+	}
+
+	_, err = CombineShares([][]byte{shares[0], shares[0]})
+	if err == nil {
+		t.Errorf("Expected error when combining duplicate shares")
+	}
+	_, err = SplitSecret(secret, 3, 4)
+	if err == nil {
+		t.Errorf("Expected error when threshold exceeds share count")
+	}
+}
+
+func TestResetMasterPassword(t *testing.T) {
+	vaultDir := "test/reset-pass.agilekeychain"
+	err := os.RemoveAll(vaultDir)
+	if err != nil {
+		t.Error(err)
+	}
+	vault, err := NewVault(vaultDir, VaultSecurity{MasterPwd: "lost-pwd", Iterations: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := UnlockKeys(vaultDir, []byte("lost-pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongKeys := KeyDict{}
+	for level, key := range keys {
+		wrongKeys[level] = append([]byte{}, key...)
+		wrongKeys[level][0] ^= 1
+	}
+	err = vault.ResetMasterPassword(wrongKeys, []byte("new-pwd"))
+	if err == nil {
+		t.Errorf("Expected error when resetting password with wrong keys")
+	}
+
+	err = vault.ResetMasterPassword(keys, []byte("new-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to reset master password: %v", err)
+	}
+	err = vault.Unlock([]byte("new-pwd"))
+	if err != nil {
+		t.Errorf("Unable to unlock vault with new password: %v", err)
+	}
+}
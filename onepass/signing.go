@@ -0,0 +1,136 @@
+package onepass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SigningCryptoAgent is an optional interface for CryptoAgents which
+// can sign item files, used when item signing is enabled for a vault.
+// See VaultOptions.SignItems
+type SigningCryptoAgent interface {
+	// Sign returns a MAC for data using a key derived from
+	// the named key
+	Sign(keyName string, data []byte) ([]byte, error)
+}
+
+// SignatureError reports an item whose file has been modified
+// by something which does not have the vault's master password
+type SignatureError struct {
+	Uuid  string
+	Title string
+	// true if the item has no signature at all, which is also
+	// the case for items saved by other 1Password apps
+	Unsigned bool
+}
+
+func (err SignatureError) Error() string {
+	if err.Unsigned {
+		return fmt.Sprintf("Item '%s' (%s) is not signed", err.Title, err.Uuid)
+	}
+	return fmt.Sprintf("Item '%s' (%s) has an invalid signature", err.Title, err.Uuid)
+}
+
+// SignItemData computes the signature for an item file using a
+// key derived from an item encryption key, so that the signing
+// key is never used directly for encryption
+func SignItemData(key []byte, data []byte) []byte {
+	keyMac := hmac.New(sha256.New, key)
+	keyMac.Write([]byte("1pass-item-signature-v1"))
+	mac := hmac.New(sha256.New, keyMac.Sum(nil))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func (agent *simpleCryptoAgent) Sign(keyName string, data []byte) ([]byte, error) {
+	key, ok := agent.keys[keyName]
+	if !ok {
+		return nil, errors.New("No such key")
+	}
+	return SignItemData(key, data), nil
+}
+
+// signature computes the signature for the item as it
+// is stored in the item's file
+func (item *Item) signature() (string, error) {
+	agent, ok := item.vault.CryptoAgent.(SigningCryptoAgent)
+	if !ok {
+		return "", errors.New("Signing items is not supported")
+	}
+	unsigned := *item
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	mac, err := agent.Sign(item.SecurityLevel, data)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(mac), nil
+}
+
+// canVerifySignatures returns true if items loaded from the vault
+// should have their signatures checked. Signatures are not checked
+// if the vault is locked or its CryptoAgent cannot sign items.
+// See Vault.RequireSignatures
+func (vault *Vault) canVerifySignatures(options VaultOptions) bool {
+	if !options.SignItems && !vault.RequireSignatures {
+		return false
+	}
+	_, canSign := vault.CryptoAgent.(SigningCryptoAgent)
+	return canSign && !vault.IsLocked()
+}
+
+// verifySignature checks the signature of an item as read from
+// the item's file
+func (item *Item) verifySignature() error {
+	if item.Signature == "" {
+		return SignatureError{Uuid: item.Uuid, Title: item.Title, Unsigned: true}
+	}
+	expected, err := item.signature()
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(item.Signature)) {
+		return SignatureError{Uuid: item.Uuid, Title: item.Title}
+	}
+	return nil
+}
+
+// SignatureErr returns the result of checking the item's signature
+// when it was loaded, for vaults with item signing enabled. It is
+// nil if the signature was valid or was not checked.
+func (item *Item) SignatureErr() error {
+	return item.signatureErr
+}
+
+// SetSignItems enables or disables signing of item files and
+// re-saves existing items to add or remove their signatures.
+// The vault must be unlocked.
+func (vault *Vault) SetSignItems(enabled bool) error {
+	if vault.IsLocked() {
+		return errors.New("Vault is locked")
+	}
+	if _, canSign := vault.CryptoAgent.(SigningCryptoAgent); enabled && !canSign {
+		return errors.New("Signing items is not supported")
+	}
+	options, err := vault.Options()
+	if err != nil {
+		return err
+	}
+	items, err := vault.ListItems()
+	if err != nil {
+		return err
+	}
+	options.SignItems = enabled
+	err = vault.SetOptions(options)
+	if err != nil {
+		return err
+	}
+	return vault.SaveItems(items)
+}
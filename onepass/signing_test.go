@@ -0,0 +1,82 @@
+package onepass
+
+import (
+	"testing"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+func TestSignItems(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := vault.AddItem("Bank", "webforms.WebForm", newTestContent("https://bank.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vault.SetSignItems(true)
+	if err != nil {
+		t.Fatalf("Unable to enable signing: %v", err)
+	}
+
+	loaded, err := vault.LoadItem(item.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Signature == "" || loaded.SignatureErr() != nil {
+		t.Errorf("Expected valid signature, got '%s' (%v)", loaded.Signature, loaded.SignatureErr())
+	}
+
+	// modify the item file directly, as a sync provider could
+	var itemData map[string]interface{}
+	err = jsonutil.ReadFile(item.Path(), &itemData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemData["location"] = "https://evil.example.com"
+	err = jsonutil.WriteFile(item.Path(), itemData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := vault.ListItems()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Unable to list items: %v", err)
+	}
+	if sigErr, ok := items[0].SignatureErr().(SignatureError); !ok || sigErr.Unsigned {
+		t.Errorf("Expected invalid signature, got %v", items[0].SignatureErr())
+	}
+
+	// removing the signature is also detected
+	delete(itemData, "signature")
+	jsonutil.WriteFile(item.Path(), itemData)
+	loaded, _ = vault.LoadItem(item.Uuid)
+	if sigErr, ok := loaded.SignatureErr().(SignatureError); !ok || !sigErr.Unsigned {
+		t.Errorf("Expected unsigned item, got %v", loaded.SignatureErr())
+	}
+
+	// signatures are not checked when the vault is locked
+	vault.Lock()
+	items, _ = vault.ListItems()
+	if items[0].SignatureErr() != nil {
+		t.Errorf("Unexpected signature check for locked vault")
+	}
+	vault.Unlock([]byte("test-pwd"))
+
+	err = vault.SetSignItems(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ = vault.LoadItem(item.Uuid)
+	if loaded.Signature != "" || loaded.SignatureErr() != nil {
+		t.Errorf("Expected signature to be removed")
+	}
+
+	// signatures are still checked if they are required by the
+	// client, eg. if signing was turned off in the options file
+	vault.RequireSignatures = true
+	loaded, _ = vault.LoadItem(item.Uuid)
+	if sigErr, ok := loaded.SignatureErr().(SignatureError); !ok || !sigErr.Unsigned {
+		t.Errorf("Expected unsigned item when signatures are required, got %v", loaded.SignatureErr())
+	}
+}
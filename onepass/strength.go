@@ -0,0 +1,50 @@
+package onepass
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/robertknight/1pass/onepass/pwstrength"
+)
+
+// EstimateEntropy returns the estimated strength of password, based
+// on the common passwords, words, keyboard rows, sequences, repeats
+// and dates which it contains. userInputs are words which an attacker
+// might associate with the password, such as the name of the site it
+// is used with. See the pwstrength package for details.
+func EstimateEntropy(password string, userInputs ...string) pwstrength.Strength {
+	return pwstrength.Estimate(password, userInputs...)
+}
+
+// EstimateItemEntropy returns the estimated strength of an item's
+// password, treating the words in its title, username and website
+// as user inputs for EstimateEntropy()
+func EstimateItemEntropy(item Item, content *ItemContent) pwstrength.Strength {
+	return EstimateEntropy(content.Password(), itemUserInputs(item, content)...)
+}
+
+// itemUserInputs returns the words in an item's title, username
+// and website host name
+func itemUserInputs(item Item, content *ItemContent) []string {
+	isSeparator := func(ch rune) bool {
+		return !unicode.IsLetter(ch) && !unicode.IsDigit(ch)
+	}
+	inputs := strings.FieldsFunc(item.Title, isSeparator)
+	if username := content.Username(); username != "" {
+		inputs = append(inputs, username)
+		inputs = append(inputs, strings.FieldsFunc(username, isSeparator)...)
+	}
+	location := content.PrimaryURL()
+	if location == "" {
+		location = item.Location
+	}
+	if parsed, err := url.Parse(location); err == nil {
+		for _, label := range strings.Split(parsed.Hostname(), ".") {
+			if label != "www" && len(label) > 2 {
+				inputs = append(inputs, label)
+			}
+		}
+	}
+	return inputs
+}
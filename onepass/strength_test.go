@@ -0,0 +1,33 @@
+package onepass
+
+import (
+	"testing"
+)
+
+func TestEstimateEntropy(t *testing.T) {
+	if strength := EstimateEntropy("password1"); strength.Score != 0 {
+		t.Errorf("Expected 'password1' to have score 0, got %d", strength.Score)
+	}
+	if strength := EstimateEntropy("tdf8-Jq2x-PZ7m-a4Lk"); strength.Score != 4 {
+		t.Errorf("Expected random password to have score 4, got %d", strength.Score)
+	}
+}
+
+func TestEstimateItemEntropy(t *testing.T) {
+	item := Item{Title: "Acme Widgets", Location: "https://shop.acmewidgets.example/login"}
+	content := ItemContent{
+		FormFields: []WebFormField{
+			{Designation: "username", Value: "jbloggs@example.com"},
+			{Designation: "password", Value: "Jbloggs-Acmewidgets"},
+		},
+	}
+	withInputs := EstimateItemEntropy(item, &content)
+	withoutInputs := EstimateEntropy(content.Password())
+	if withInputs.Bits >= withoutInputs.Bits {
+		t.Errorf("Expected password based on item details to be weaker. Got %.1f bits, without item details %.1f",
+			withInputs.Bits, withoutInputs.Bits)
+	}
+	if withInputs.Score > 1 {
+		t.Errorf("Expected password based on item details to be weak, got score %d", withInputs.Score)
+	}
+}
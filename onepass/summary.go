@@ -0,0 +1,42 @@
+package onepass
+
+import (
+	"strings"
+)
+
+// number of trailing characters of concealed
+// values shown by SummaryValue()
+const summaryVisibleChars = 4
+
+// SummaryValue returns the value of the field matching fieldName
+// for display in item lists. The field is looked up in the item's
+// sections and then its web form fields, as for FieldByPattern().
+// Only the last four characters of concealed fields, such as
+// account numbers or passwords, are included.
+func (item *ItemContent) SummaryValue(fieldName string) string {
+	if fieldName == "" {
+		return ""
+	}
+	if field := item.FieldByPattern(fieldName); field != nil {
+		value := field.ValueString()
+		if field.Kind == "concealed" {
+			return maskSummaryValue(value)
+		}
+		return value
+	}
+	if field := item.FormFieldByPattern(fieldName); field != nil {
+		if field.Type == "P" {
+			return maskSummaryValue(field.Value)
+		}
+		return field.Value
+	}
+	return ""
+}
+
+func maskSummaryValue(value string) string {
+	chars := []rune(value)
+	if len(chars) <= summaryVisibleChars {
+		return strings.Repeat("•", len(chars))
+	}
+	return "••••" + string(chars[len(chars)-summaryVisibleChars:])
+}
@@ -0,0 +1,130 @@
+package onepass
+
+import (
+	"fmt"
+	"time"
+)
+
+// SyncConflict describes an item which has been changed
+// in both vaults since they were last synced
+type SyncConflict struct {
+	// version of the item in the first vault
+	Item Item
+	// version of the item in the other vault
+	Other Item
+}
+
+// SyncResult lists the changes made by SyncVaults()
+type SyncResult struct {
+	// items copied from the first vault to the other vault
+	Sent []Item
+	// items copied from the other vault to the first vault
+	Received []Item
+	// items which were not synced because they have been
+	// changed in both vaults
+	Conflicts []SyncConflict
+}
+
+// SyncVaults merges the items of two vaults, which must both be
+// unlocked. Items are matched by UUID. Items which only exist in one
+// vault are copied to the other and where the versions of an item in
+// the two vaults have different UpdatedAt times, the newer version
+// replaces the older one. Copied items keep their timestamps. Removed
+// items are synced in the same way, using the tombstones which are
+// left in a vault when items are removed.
+//
+// If lastSync is non-zero, items which have been updated in both
+// vaults since lastSync are reported as conflicts and are not
+// changed in either vault.
+//
+// If dryRun is true, the changes which would be made are returned
+// but neither vault is modified.
+func SyncVaults(vault *Vault, other *Vault, lastSync time.Time, dryRun bool) (SyncResult, error) {
+	result := SyncResult{}
+	items, err := vault.listItems(true)
+	if err != nil {
+		return result, err
+	}
+	otherItems, err := other.listItems(true)
+	if err != nil {
+		return result, err
+	}
+
+	otherByUuid := map[string]Item{}
+	for _, item := range otherItems {
+		otherByUuid[item.Uuid] = item
+	}
+	for _, item := range items {
+		otherItem, ok := otherByUuid[item.Uuid]
+		delete(otherByUuid, item.Uuid)
+		switch {
+		case !ok:
+			result.Sent = append(result.Sent, item)
+		case item.UpdatedAt == otherItem.UpdatedAt:
+			// unchanged
+		case !lastSync.IsZero() && item.UpdatedAt > uint64(lastSync.Unix()) &&
+			otherItem.UpdatedAt > uint64(lastSync.Unix()):
+			result.Conflicts = append(result.Conflicts, SyncConflict{Item: item, Other: otherItem})
+		case item.UpdatedAt > otherItem.UpdatedAt:
+			result.Sent = append(result.Sent, item)
+		default:
+			result.Received = append(result.Received, otherItem)
+		}
+	}
+	for _, item := range otherItems {
+		if _, ok := otherByUuid[item.Uuid]; ok {
+			result.Received = append(result.Received, item)
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	vault.BeginBatch()
+	other.BeginBatch()
+	err = copyItems(result.Sent, other)
+	if err == nil {
+		err = copyItems(result.Received, vault)
+	}
+	for _, v := range []*Vault{vault, other} {
+		if endErr := v.EndBatch(); err == nil {
+			err = endErr
+		}
+	}
+	return result, err
+}
+
+func copyItems(items []Item, dest *Vault) error {
+	for _, item := range items {
+		err := copyItem(item, dest)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyItem saves a copy of an item to another vault, keeping
+// its UUID and timestamps. The content is re-encrypted using
+// the other vault's key.
+func copyItem(item Item, dest *Vault) error {
+	content, err := item.vault.CryptoAgent.Decrypt(item.SecurityLevel, item.Encrypted)
+	if err != nil {
+		return fmt.Errorf("Failed to decrypt item %s: %v", item.Title, err)
+	}
+	return copyItemContent(item, content, dest)
+}
+
+// copyItemContent saves a copy of an item with the given
+// decrypted content to another vault. See copyItem()
+func copyItemContent(item Item, content []byte, dest *Vault) error {
+	var err error
+	copied := item
+	copied.vault = dest
+	copied.signatureErr = nil
+	copied.Encrypted, err = dest.CryptoAgent.Encrypt(item.SecurityLevel, content)
+	if err != nil {
+		return fmt.Errorf("Failed to encrypt item %s: %v", item.Title, err)
+	}
+	return copied.write()
+}
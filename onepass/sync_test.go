@@ -0,0 +1,126 @@
+package onepass
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// newSyncTestVaults creates two test vaults. The returned
+// function removes the second vault's temporary directory.
+func newSyncTestVaults(t *testing.T) (Vault, Vault, func()) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+	otherDir, err := ioutil.TempDir("", "1pass-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removeOther := func() {
+		os.RemoveAll(otherDir)
+	}
+	security := VaultSecurity{MasterPwd: "other-pwd", Iterations: 100}
+	other, err := NewVault(otherDir+"/other-vault.agilekeychain", security)
+	if err != nil {
+		removeOther()
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+	err = other.Unlock([]byte(security.MasterPwd))
+	if err != nil {
+		removeOther()
+		t.Fatal(err)
+	}
+	return vault, other, removeOther
+}
+
+// updates an item's content and sets its UpdatedAt time
+func updateSyncTestItem(t *testing.T, item Item, url string, updatedAt time.Time) {
+	err := item.SetContent(newTestContent(url))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.UpdatedAt = uint64(updatedAt.Unix())
+	err = item.write()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncVaults(t *testing.T) {
+	vault, other, removeOther := newSyncTestVaults(t)
+	defer removeOther()
+	item, err := vault.AddItem("Synced", "securenotes.SecureNote", newTestContent("first.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// new items are copied to the other vault
+	result, err := SyncVaults(&vault, &other, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.Sent) != 1 || len(result.Received) != 0 || len(result.Conflicts) != 0 {
+		t.Fatalf("Unexpected sync result: %+v", result)
+	}
+	copied, err := other.LoadItem(item.Uuid)
+	if err != nil {
+		t.Fatalf("Item was not copied: %v", err)
+	}
+	content, err := copied.Content()
+	if err != nil {
+		t.Fatalf("Failed to decrypt copied item: %v", err)
+	}
+	if copied.UpdatedAt != item.UpdatedAt || content.PrimaryURL() != "first.com" {
+		t.Errorf("Copied item does not match original: %+v", copied)
+	}
+
+	// newer versions replace older ones
+	lastSync := time.Now()
+	updateSyncTestItem(t, copied, "second.com", lastSync.Add(time.Hour))
+	result, err = SyncVaults(&vault, &other, lastSync, false)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.Sent) != 0 || len(result.Received) != 1 {
+		t.Fatalf("Unexpected sync result: %+v", result)
+	}
+	item, _ = vault.LoadItem(item.Uuid)
+	content, _ = item.Content()
+	if content.PrimaryURL() != "second.com" {
+		t.Errorf("Newer version of item was not synced")
+	}
+
+	// items changed in both vaults since the last sync
+	// are reported as conflicts
+	updateSyncTestItem(t, item, "third.com", lastSync.Add(2*time.Hour))
+	copied, _ = other.LoadItem(item.Uuid)
+	updateSyncTestItem(t, copied, "fourth.com", lastSync.Add(3*time.Hour))
+	result, err = SyncVaults(&vault, &other, lastSync, false)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.Conflicts) != 1 || len(result.Sent) != 0 || len(result.Received) != 0 {
+		t.Fatalf("Unexpected sync result: %+v", result)
+	}
+
+	// removed items are removed from the other vault
+	err = item.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.UpdatedAt = uint64(lastSync.Add(4 * time.Hour).Unix())
+	item.write()
+	_, err = SyncVaults(&vault, &other, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	otherItems, err := other.ListItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(otherItems) != 0 {
+		t.Errorf("Removed item was not synced: %+v", otherItems)
+	}
+}
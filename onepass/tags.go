@@ -0,0 +1,59 @@
+package onepass
+
+// Tags returns the item's tags. If includePrivate is true, tags
+// stored in the item's encrypted content by SetTags() are also
+// returned, which requires the vault to be unlocked.
+func (item *Item) Tags(includePrivate bool) ([]string, error) {
+	tags := append([]string{}, item.OpenContents.Tags...)
+	if !includePrivate {
+		return tags, nil
+	}
+	content, err := item.Content()
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range content.PrivateTags {
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// SetTags replaces the item's tags. If private is true, the tags
+// are stored in the item's encrypted content instead of the
+// plaintext OpenContents so that tag names are not visible without
+// the master password. Other 1Password apps do not read private
+// tags and may discard them when editing the item.
+//
+// The item must be saved afterwards.
+func (item *Item) SetTags(tags []string, private bool) error {
+	if !private {
+		item.OpenContents.Tags = tags
+		if item.vault == nil || item.vault.IsLocked() {
+			return nil
+		}
+	}
+	content, err := item.Content()
+	if err != nil {
+		return err
+	}
+	if private {
+		item.OpenContents.Tags = nil
+		content.PrivateTags = tags
+	} else if len(content.PrivateTags) > 0 {
+		content.PrivateTags = nil
+	} else {
+		return nil
+	}
+	return item.SetContent(content)
+}
+
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
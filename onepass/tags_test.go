@@ -0,0 +1,48 @@
+package onepass
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrivateTags(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := newTestItem(&vault)
+	err = item.SetContent(newTestContent("https://example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.OpenContents.Tags = []string{"public"}
+
+	err = item.SetTags([]string{"public", "private"}, true)
+	if err != nil {
+		t.Fatalf("Unable to set private tags: %v", err)
+	}
+	if len(item.OpenContents.Tags) != 0 {
+		t.Errorf("Private tags should not be stored in open contents: %v", item.OpenContents.Tags)
+	}
+	tags, _ := item.Tags(false)
+	if len(tags) != 0 {
+		t.Errorf("Expected no public tags, got %v", tags)
+	}
+	tags, err = item.Tags(true)
+	if err != nil || strings.Join(tags, ",") != "public,private" {
+		t.Errorf("Unexpected private tags: %v, %v", tags, err)
+	}
+
+	err = item.SetTags([]string{"public"}, false)
+	if err != nil {
+		t.Fatalf("Unable to set public tags: %v", err)
+	}
+	content, _ := item.Content()
+	if len(content.PrivateTags) != 0 {
+		t.Errorf("Private tags should be removed when storing tags publicly")
+	}
+	tags, _ = item.Tags(true)
+	if strings.Join(tags, ",") != "public" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+}
@@ -0,0 +1,148 @@
+// Package totp generates time-based one-time passwords as described
+// in RFC 6238, from keys given as 'otpauth://' URIs or as bare base32
+// secrets.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaults used by Google Authenticator and most sites
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30
+)
+
+// Key holds the secret and settings used to generate codes
+type Key struct {
+	Secret []byte
+	// hash function used for the HMAC, one of
+	// SHA1, SHA256 or SHA512
+	Algorithm string
+	// number of digits in each code
+	Digits int
+	// number of seconds for which each code is valid
+	Period int
+
+	// name of the service and account which the key
+	// is for, from the URI's label and parameters
+	Issuer  string
+	Account string
+}
+
+var hashes = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// decodeSecret decodes a base32 secret, which may be in lower case
+// and contain spaces or be missing its padding
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.Join(strings.Fields(secret), ""))
+	secret = strings.TrimRight(secret, "=")
+	data, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("The one-time password secret is not valid base32")
+	}
+	return data, nil
+}
+
+// Parse reads a key from an 'otpauth://totp/' URI or
+// a bare base32 secret
+func Parse(value string) (Key, error) {
+	key := Key{Algorithm: "SHA1", Digits: DefaultDigits, Period: DefaultPeriod}
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "otpauth://") {
+		var err error
+		key.Secret, err = decodeSecret(value)
+		return key, err
+	}
+
+	uri, err := url.Parse(value)
+	if err != nil {
+		return key, fmt.Errorf("Invalid one-time password URI: %v", err)
+	}
+	if uri.Host != "totp" {
+		return key, fmt.Errorf("Unsupported one-time password type '%s'. Only 'totp' is supported", uri.Host)
+	}
+	label := strings.TrimPrefix(uri.Path, "/")
+	if sep := strings.Index(label, ":"); sep != -1 {
+		key.Issuer = strings.TrimSpace(label[:sep])
+		key.Account = strings.TrimSpace(label[sep+1:])
+	} else {
+		key.Account = label
+	}
+
+	params := uri.Query()
+	key.Secret, err = decodeSecret(params.Get("secret"))
+	if err != nil {
+		return key, err
+	}
+	if issuer := params.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+	if algorithm := params.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if _, ok := hashes[key.Algorithm]; !ok {
+			return key, fmt.Errorf("Unsupported one-time password algorithm '%s'", algorithm)
+		}
+	}
+	if digits := params.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < 6 || key.Digits > 8 {
+			return key, fmt.Errorf("Invalid number of one-time password digits '%s'", digits)
+		}
+	}
+	if period := params.Get("period"); period != "" {
+		key.Period, err = strconv.Atoi(period)
+		if err != nil || key.Period < 1 {
+			return key, fmt.Errorf("Invalid one-time password period '%s'", period)
+		}
+	}
+	return key, nil
+}
+
+// HOTP returns the HMAC-based one-time password for counter,
+// as described in RFC 4226
+func (key Key) HOTP(counter uint64) string {
+	newHash, ok := hashes[key.Algorithm]
+	if !ok {
+		newHash = sha1.New
+	}
+	mac := hmac.New(newHash, key.Secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulus := uint32(1)
+	for i := 0; i < key.Digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", key.Digits, value%modulus)
+}
+
+// Code returns the one-time password which is valid at time t
+func (key Key) Code(t time.Time) string {
+	return key.HOTP(uint64(t.Unix()) / uint64(key.Period))
+}
+
+// Remaining returns the time after t until the code
+// returned by Code(t) expires
+func (key Key) Remaining(t time.Time) time.Duration {
+	period := int64(key.Period)
+	next := (t.Unix()/period + 1) * period
+	return time.Unix(next, 0).Sub(t)
+}
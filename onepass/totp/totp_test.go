@@ -0,0 +1,84 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestCode(t *testing.T) {
+	// test vectors from RFC 6238, Appendix B
+	secrets := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		time      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111111, "SHA256", "67062674"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{20000000000, "SHA256", "77737706"},
+	}
+	for _, test := range tests {
+		key := Key{Secret: []byte(secrets[test.algorithm]), Algorithm: test.algorithm, Digits: 8, Period: 30}
+		if code := key.Code(time.Unix(test.time, 0)); code != test.code {
+			t.Errorf("Expected code %s at %d with %s, got %s", test.code, test.time, test.algorithm, code)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	key, err := Parse("otpauth://totp/Example:alice@example.com?secret=" + secret + "&issuer=Example&digits=8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key.Secret) != "12345678901234567890" || key.Digits != 8 || key.Period != DefaultPeriod ||
+		key.Algorithm != "SHA1" || key.Issuer != "Example" || key.Account != "alice@example.com" {
+		t.Errorf("Unexpected key %+v", key)
+	}
+	if code := key.Code(time.Unix(59, 0)); code != "94287082" {
+		t.Errorf("Unexpected code %s", code)
+	}
+
+	key, err = Parse("jbsw y3dp ehpk 3pxp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key.Secret) != "Hello!\xde\xad\xbe\xef" || key.Digits != DefaultDigits {
+		t.Errorf("Unexpected key from bare secret %+v", key)
+	}
+	if len(key.Code(time.Now())) != 6 {
+		t.Errorf("Expected 6 digit code")
+	}
+
+	for _, invalid := range []string{
+		"not base32!",
+		"otpauth://hotp/Example?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP&digits=4",
+		"otpauth://totp/Example",
+	} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Expected error parsing '%s'", invalid)
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	key := Key{Period: 30}
+	if remaining := key.Remaining(time.Unix(65, 0)); remaining != 25*time.Second {
+		t.Errorf("Expected 25s remaining, got %v", remaining)
+	}
+	if remaining := key.Remaining(time.Unix(60, 0)); remaining != 30*time.Second {
+		t.Errorf("Expected 30s remaining, got %v", remaining)
+	}
+}
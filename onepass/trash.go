@@ -0,0 +1,91 @@
+package onepass
+
+import (
+	"time"
+)
+
+// TrashedItems returns the items in the vault which have been
+// moved to the trash but not removed
+func (vault *Vault) TrashedItems() ([]Item, error) {
+	items, err := vault.ListItems()
+	if err != nil {
+		return nil, err
+	}
+	trashed := []Item{}
+	for _, item := range items {
+		if item.Trashed {
+			trashed = append(trashed, item)
+		}
+	}
+	return trashed, nil
+}
+
+// EmptyTrash removes every item in the trash from the vault
+// and returns the removed items. The vault must be unlocked.
+func (vault *Vault) EmptyTrash() ([]Item, error) {
+	return vault.PurgeTrash(time.Now(), false)
+}
+
+// PurgeTrash removes items which were moved to the trash before
+// cutoff and returns them. If removeTombstones is set, the files of
+// items which were removed from the vault before cutoff are also
+// deleted, instead of being kept to record the removal for other
+// copies of the vault. The vault must be unlocked.
+//
+// Items are assumed to have been trashed or removed when they
+// were last updated.
+func (vault *Vault) PurgeTrash(cutoff time.Time, removeTombstones bool) ([]Item, error) {
+	items, err := vault.listItems(removeTombstones)
+	if err != nil {
+		return nil, err
+	}
+	vault.BeginBatch()
+	purged := []Item{}
+	for _, item := range items {
+		if !item.Trashed || int64(item.UpdatedAt) >= cutoff.Unix() {
+			continue
+		}
+		original := item
+		if item.TypeName == "system.Tombstone" {
+			if vault.DryRun {
+				continue
+			}
+			err = item.removeDataFiles()
+		} else {
+			err = item.Remove()
+		}
+		if err != nil {
+			break
+		}
+		purged = append(purged, original)
+	}
+	endErr := vault.EndBatch()
+	if err == nil {
+		err = endErr
+	}
+	return purged, err
+}
+
+// SetTrashRetention sets the number of days after which items in
+// the trash are purged by PurgeExpiredTrash(), or disables purging
+// if days is zero
+func (vault *Vault) SetTrashRetention(days int) error {
+	options, err := vault.Options()
+	if err != nil {
+		return err
+	}
+	options.TrashRetentionDays = days
+	return vault.SetOptions(options)
+}
+
+// PurgeExpiredTrash purges items which have been in the trash or
+// removed for longer than the vault's trash retention period, if
+// one is set. See SetTrashRetention() and PurgeTrash()
+func (vault *Vault) PurgeExpiredTrash() ([]Item, error) {
+	options, err := vault.Options()
+	if err != nil || options.TrashRetentionDays <= 0 {
+		return nil, err
+	}
+	retention := time.Duration(options.TrashRetentionDays) * 24 * time.Hour
+	return vault.PurgeTrash(time.Now().Add(-retention), true)
+}
@@ -0,0 +1,85 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addItem := func(title string, trashed bool, daysAgo int) Item {
+		item, err := vault.AddItem(title, "webforms.WebForm", newTestContent("example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		item.Trashed = trashed
+		item.UpdatedAt = uint64(time.Now().Add(-time.Duration(daysAgo) * 24 * time.Hour).Unix())
+		err = item.write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return item
+	}
+	kept := addItem("Kept", false, 90)
+	recent := addItem("Recently trashed", true, 1)
+	old := addItem("Trashed long ago", true, 60)
+	removed := addItem("Removed long ago", false, 0)
+	err = removed.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed.UpdatedAt = uint64(time.Now().Add(-60 * 24 * time.Hour).Unix())
+	err = removed.write()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, err := vault.TrashedItems()
+	if err != nil || len(trashed) != 2 {
+		t.Fatalf("Expected 2 trashed items, got %v, %v", trashed, err)
+	}
+
+	// nothing is purged without a retention period
+	purged, err := vault.PurgeExpiredTrash()
+	if err != nil || len(purged) != 0 {
+		t.Fatalf("Expected nothing to be purged, got %v, %v", purged, err)
+	}
+
+	err = vault.SetTrashRetention(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purged, err = vault.PurgeExpiredTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 2 {
+		t.Fatalf("Expected 2 items to be purged, got %v", purged)
+	}
+	if _, err := os.Stat(removed.Path()); !os.IsNotExist(err) {
+		t.Errorf("Expected file of removed item to be deleted")
+	}
+	item, err := vault.LoadItem(old.Uuid)
+	if err != nil || item.TypeName != "system.Tombstone" {
+		t.Errorf("Expected old trashed item to be removed, got %v, %v", item, err)
+	}
+	for _, uuid := range []string{kept.Uuid, recent.Uuid} {
+		item, err := vault.LoadItem(uuid)
+		if err != nil || item.TypeName == "system.Tombstone" {
+			t.Errorf("Expected item %s to be kept", uuid)
+		}
+	}
+
+	removedItems, err := vault.EmptyTrash()
+	if err != nil || len(removedItems) != 1 || removedItems[0].Title != "Recently trashed" {
+		t.Errorf("Expected trash to be emptied, got %v, %v", removedItems, err)
+	}
+	trashed, err = vault.TrashedItems()
+	if err != nil || len(trashed) != 0 {
+		t.Errorf("Expected trash to be empty, got %v, %v", trashed, err)
+	}
+}
@@ -0,0 +1,141 @@
+package onepass
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// query parameters added to links by advertising and analytics
+// services, which are removed by NormalizeUrl(). Parameters
+// ending in '*' match any parameter with that prefix.
+var trackingParams = []string{"utm_*", "fbclid", "gclid", "dclid", "gbraid", "wbraid",
+	"msclkid", "mc_cid", "mc_eid", "yclid", "igshid", "_ga", "_gl"}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range trackingParams {
+		if strings.HasSuffix(param, "*") && strings.HasPrefix(name, strings.TrimSuffix(param, "*")) {
+			return true
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeUrl returns a canonical form of an 'http' or 'https' URL
+// with a lowercase scheme and host, without the default port and
+// without tracking parameters such as 'utm_source' in the query.
+// If upgradeToHttps is set, 'http' URLs are changed to 'https',
+// except for local addresses.
+//
+// Other URLs, and URLs which are already in canonical form,
+// are returned unchanged.
+func NormalizeUrl(rawUrl string, upgradeToHttps bool) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" || parsed.Host == "" {
+		return rawUrl
+	}
+	changed := scheme != parsed.Scheme
+	host := strings.ToLower(parsed.Host)
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+			host = hostname
+		}
+	}
+	changed = changed || host != parsed.Host
+	parsed.Host = host
+
+	if upgradeToHttps && scheme == "http" && !isLocalHost(parsed.Hostname()) && parsed.Port() == "" {
+		scheme = "https"
+		changed = true
+	}
+	parsed.Scheme = scheme
+
+	if parsed.RawQuery != "" {
+		params := []string{}
+		for _, param := range strings.Split(parsed.RawQuery, "&") {
+			name := strings.SplitN(param, "=", 2)[0]
+			if unescaped, err := url.QueryUnescape(name); err == nil && isTrackingParam(unescaped) {
+				changed = true
+				continue
+			}
+			params = append(params, param)
+		}
+		parsed.RawQuery = strings.Join(params, "&")
+	}
+
+	if !changed {
+		return rawUrl
+	}
+	return parsed.String()
+}
+
+// isLocalHost returns true for host names and addresses which
+// refer to the local computer or network, which often do not
+// support https
+func isLocalHost(hostname string) bool {
+	if hostname == "localhost" || strings.HasSuffix(hostname, ".local") || !strings.Contains(hostname, ".") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && (ip.IsLoopback() || isPrivateIP(ip))
+}
+
+func isPrivateIP(ip net.IP) bool {
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeUrls normalizes the item's URLs with NormalizeUrl() and
+// removes URLs which are equivalent to an earlier URL once normalized.
+// Returns true if any URLs were changed or removed.
+func (item *ItemContent) NormalizeUrls(upgradeToHttps bool) bool {
+	changed := false
+	seen := map[string]bool{}
+	urls := []ItemUrl{}
+	for _, itemUrl := range item.Urls {
+		normalized := NormalizeUrl(itemUrl.Url, upgradeToHttps)
+		key := strings.TrimSuffix(normalized, "/")
+		if seen[key] {
+			changed = true
+			continue
+		}
+		seen[key] = true
+		changed = changed || normalized != itemUrl.Url
+		itemUrl.Url = normalized
+		urls = append(urls, itemUrl)
+	}
+	item.Urls = urls
+	return changed
+}
+
+// NormalizeUrls normalizes the URLs in the item's content and its
+// location. See ItemContent.NormalizeUrls(). Returns true if any URLs
+// were changed. The vault must be unlocked.
+func (item *Item) NormalizeUrls(upgradeToHttps bool) (bool, error) {
+	if item.TypeName == "system.Tombstone" {
+		return false, nil
+	}
+	content, err := item.Content()
+	if err != nil {
+		return false, err
+	}
+	location := NormalizeUrl(item.Location, upgradeToHttps)
+	changed := location != item.Location
+	item.Location = location
+	if content.NormalizeUrls(upgradeToHttps) {
+		return true, item.SetContent(content)
+	}
+	return changed, nil
+}
@@ -0,0 +1,64 @@
+package onepass
+
+import (
+	"testing"
+)
+
+func TestNormalizeUrl(t *testing.T) {
+	cases := []struct {
+		url     string
+		upgrade bool
+		want    string
+	}{
+		{"https://example.com/login", false, "https://example.com/login"},
+		{"HTTPS://Example.COM/Login", false, "https://example.com/Login"},
+		{"https://example.com:443/", false, "https://example.com/"},
+		{"http://example.com:8080/", false, "http://example.com:8080/"},
+		{"https://example.com/?utm_source=mail&id=3&fbclid=abc", false, "https://example.com/?id=3"},
+		{"https://example.com/?utm_campaign=x", false, "https://example.com/"},
+		{"http://example.com/", true, "https://example.com/"},
+		{"http://example.com:80/", true, "https://example.com/"},
+		{"http://192.168.1.1/admin", true, "http://192.168.1.1/admin"},
+		{"http://nas.local/", true, "http://nas.local/"},
+		{"example.com", true, "example.com"},
+		{"ftp://Example.com/", true, "ftp://Example.com/"},
+	}
+	for _, c := range cases {
+		if got := NormalizeUrl(c.url, c.upgrade); got != c.want {
+			t.Errorf("NormalizeUrl(%q, %v) = %q, want %q", c.url, c.upgrade, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeItemUrls(t *testing.T) {
+	content := ItemContent{Urls: []ItemUrl{
+		{Label: "website", Url: "HTTPS://Example.com/?utm_source=x"},
+		{Label: "login", Url: "https://example.com"},
+		{Label: "other", Url: "https://other.example.com/"},
+	}}
+	if !content.NormalizeUrls(false) {
+		t.Fatalf("Expected URLs to be changed")
+	}
+	if len(content.Urls) != 2 || content.Urls[0].Url != "https://example.com/" ||
+		content.Urls[1].Url != "https://other.example.com/" {
+		t.Errorf("Unexpected normalized URLs: %v", content.Urls)
+	}
+	if content.NormalizeUrls(false) {
+		t.Errorf("Expected normalized URLs to be unchanged")
+	}
+
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault.NormalizeUrls = true
+	vault.UpgradeUrlsToHttps = true
+	content = newTestContent("http://Example.com/?gclid=1")
+	item, err := vault.AddItem("Example", "webforms.WebForm", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Location != "https://example.com/" {
+		t.Errorf("Expected location to be normalized on save, got %s", item.Location)
+	}
+}
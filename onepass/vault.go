@@ -22,7 +22,6 @@ import (
 	"crypto/cipher"
 	"crypto/md5"
 	"crypto/rand"
-	"crypto/sha1"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -33,9 +32,7 @@ import (
 	"path"
 	"strings"
 	"time"
-	"unicode"
 
-	"golang.org/x/crypto/pbkdf2"
 	uuid "github.com/nu7hatch/gouuid"
 
 	"github.com/robertknight/1pass/jsonutil"
@@ -43,6 +40,7 @@ import (
 )
 
 const Aes128KeyLen = 16
+const Aes256KeyLen = 32
 const AesBlockLen = 16
 
 var PbkdfIterations = 17094
@@ -68,8 +66,17 @@ type CryptoAgent interface {
 	IsLocked() (bool, error)
 }
 
-// default CryptoAgent implementation which just
-// stores decrypted keys in memory
+// BatchCryptoAgent is an optional interface for CryptoAgents
+// which can decrypt the data for many items in one call, used
+// by Vault.ItemContents()
+type BatchCryptoAgent interface {
+	// Decrypt the data for several items, using keyNames[i]
+	// to decrypt in[i]
+	DecryptBatch(keyNames []string, in [][]byte) ([][]byte, error)
+}
+
+// default CryptoAgent implementation which stores decrypted
+// keys in memory until Lock() is called. See UnlockKeys()
 type simpleCryptoAgent struct {
 	keys KeyDict
 }
@@ -85,6 +92,7 @@ func (agent *simpleCryptoAgent) Decrypt(keyName string, in []byte) ([]byte, erro
 }
 
 func (agent *simpleCryptoAgent) Lock() error {
+	agent.keys.Wipe()
 	agent.keys = nil
 	return nil
 }
@@ -97,6 +105,56 @@ func (agent *simpleCryptoAgent) IsLocked() (bool, error) {
 type Vault struct {
 	Path        string
 	CryptoAgent CryptoAgent
+
+	// name of the profile within the vault which contains
+	// its keys and items. See VaultProfiles()
+	Profile string
+
+	// if set, called after an item in the vault
+	// has been added, updated or removed
+	OnItemChange func(change ItemChange)
+
+	// if set, changes to items are reported to OnItemChange but
+	// are not written to the vault. See ItemChange.Changes
+	DryRun bool
+
+	// if set, the contents.js index is not updated when items are
+	// saved or removed, eg. because it is damaged, and RebuildIndex()
+	// ignores the existing index. Items are always listed from their
+	// item files, so the vault can be used as normal until the index
+	// is rebuilt.
+	NoIndex bool
+
+	// if set, the URLs of items are normalized when they are
+	// saved and 'http' URLs are optionally changed to 'https'.
+	// See Item.NormalizeUrls()
+	NormalizeUrls      bool
+	UpgradeUrlsToHttps bool
+
+	// if set, item signatures are checked even if signing is not
+	// enabled in the vault's options. The options are stored
+	// unsigned with the items, so someone with access to the
+	// vault's folder could otherwise turn signing off unnoticed.
+	RequireSignatures bool
+}
+
+// Actions reported in ItemChange.Action
+const (
+	ItemAdded   = "add"
+	ItemUpdated = "update"
+	ItemRemoved = "remove"
+)
+
+// ItemChange describes a change to an item in a vault
+type ItemChange struct {
+	Uuid     string
+	Title    string
+	TypeName string
+	// ItemAdded, ItemUpdated or ItemRemoved
+	Action string
+	// differences from the saved item for updates to
+	// items in vaults with DryRun set
+	Changes []ItemDiff
 }
 
 type DecryptError struct {
@@ -146,10 +204,22 @@ type Item struct {
 	// to the Trash
 	Trashed bool `json:"trashed"`
 
+	// UNIX timestamp after which the item is no longer needed,
+	// eg. for temporary credentials. This is specific to 1pass
+	// and is ignored by other 1Password apps.
+	ExpiresAt uint64 `json:"expiresAt,omitempty"`
+
 	// Unencrypted item content
 	OpenContents ItemOpenContents `json:"openContents"`
 
+	// signature of the item file, for vaults with item signing
+	// enabled. This is specific to 1pass. See VaultOptions.SignItems
+	Signature string `json:"signature,omitempty"`
+
 	vault *Vault
+
+	// result of checking the signature when the item was loaded
+	signatureErr error
 }
 
 // struct for items in encryptionKeys.js
@@ -172,6 +242,22 @@ type encKeyEntry struct {
 
 	// copy of decryption key encrypted with itself
 	Validation []byte `json:"validation"`
+
+	// scheme used to encrypt the key with the master password.
+	// This is specific to 1pass and is omitted for keys using
+	// KeySchemeAes128Sha1. See VaultSecurity.KeyScheme
+	Scheme string `json:"scheme,omitempty"`
+
+	// function used to derive the key which encrypts this key
+	// from the master password and its parameters. These are
+	// specific to 1pass and are omitted for keys using PBKDF2.
+	// See VaultSecurity.Kdf
+	Kdf       string     `json:"kdf,omitempty"`
+	KdfParams *KdfParams `json:"kdfParams,omitempty"`
+
+	// set if the key is encrypted using a key file in addition
+	// to the master password. See KeyFilePassword()
+	KeyFile bool `json:"keyFile,omitempty"`
 }
 
 // struct for encryptionKeys.js
@@ -202,9 +288,8 @@ func CheckVault(vaultPath string) error {
 		return errors.New("Unknown or unsupported 1Password vault format")
 	}
 
-	dataDir := vaultPath + "/data/default"
-	_, err = os.Stat(dataDir)
-	if err != nil {
+	profiles, err := VaultProfiles(vaultPath)
+	if err != nil || len(profiles) == 0 {
 		return errors.New("Unable to find data dir in vault")
 	}
 
@@ -222,6 +307,20 @@ type VaultSecurity struct {
 	// will slow down password cracking but also slow
 	// down unlocking the vault
 	Iterations int
+	// Scheme used to encrypt the main encryption keys
+	// with the master password. Defaults to KeySchemeAes128Sha1
+	KeyScheme string
+	// Function used to derive the key which encrypts the main
+	// encryption keys from the master password. Defaults to
+	// KdfPbkdf2, in which case Iterations is used. For other
+	// functions, KdfParams is used or the function's default
+	// parameters if it is not set. See DefaultKdfParams()
+	Kdf       string
+	KdfParams *KdfParams
+	// Path of an optional key file whose contents are needed in
+	// addition to the master password to unlock the vault.
+	// See KeyFilePassword()
+	KeyFilePath string
 }
 
 // Creates a new vault in 'vaultPath' and a random master key, encrypted
@@ -239,13 +338,43 @@ func NewVault(vaultPath string, security VaultSecurity) (Vault, error) {
 	if security.Iterations == 0 {
 		security.Iterations = defaultPbkdfIterations
 	}
+	if security.KeyScheme == KeySchemeAes128Sha1 {
+		// the default scheme is not recorded in encryptionKeys.js,
+		// for compatibility with 1Password
+		security.KeyScheme = ""
+	}
+	if !isKnownKeyScheme(security.KeyScheme) {
+		return Vault{}, fmt.Errorf("Unsupported key encryption scheme '%s'", security.KeyScheme)
+	}
+	if security.Kdf == "" || security.Kdf == KdfPbkdf2 {
+		// as for the key scheme, the default KDF is not recorded
+		security.Kdf = ""
+		security.KdfParams = nil
+	} else if security.KdfParams == nil {
+		params, err := DefaultKdfParams(security.Kdf)
+		if err != nil {
+			return Vault{}, err
+		}
+		security.KdfParams = &params
+	}
+	if err := security.KdfParams.validate(security.Kdf); err != nil {
+		return Vault{}, err
+	}
+	masterPwd := security.MasterPwd
+	if security.KeyFilePath != "" {
+		combinedPwd, err := KeyFilePassword([]byte(masterPwd), security.KeyFilePath)
+		if err != nil {
+			return Vault{}, err
+		}
+		masterPwd = string(combinedPwd)
+	}
 
 	_, err := os.Stat(vaultPath)
 	if !os.IsNotExist(err) {
 		return Vault{}, fmt.Errorf("Vault %s already exists", vaultPath)
 	}
 
-	dataDir := vaultDataDir(vaultPath)
+	dataDir := profileDataDir(vaultPath, DefaultProfile)
 	err = os.MkdirAll(dataDir, os.ModeDir|0755)
 	if err != nil {
 		return Vault{}, err
@@ -258,61 +387,120 @@ func NewVault(vaultPath string, security VaultSecurity) (Vault, error) {
 	}
 
 	// create encryptionKeys.js file
-	randomKey := randomBytes(1024)
-	salt := randomBytes(8)
-	encryptedKey, validation, err := encryptKey([]byte(security.MasterPwd), randomKey, salt, security.Iterations)
-	if err != nil {
-		return Vault{}, fmt.Errorf("Failed to generate encryption key")
-	}
-
 	mainKey := encKeyEntry{
-		Data:       []byte(fmt.Sprintf("Salted__%s%s", salt, encryptedKey)),
 		Identifier: newItemId(),
 		Iterations: security.Iterations,
 		Level:      "SL5",
-		Validation: validation,
+		Scheme:     security.KeyScheme,
+		Kdf:        security.Kdf,
+		KdfParams:  security.KdfParams,
+		KeyFile:    security.KeyFilePath != "",
 	}
+	randomKey := randomBytes(1024)
+	salt := randomBytes(8)
+	encryptedKey, validation, err := encryptKey([]byte(masterPwd), randomKey, salt, mainKey)
+	if err != nil {
+		return Vault{}, fmt.Errorf("Failed to generate encryption key")
+	}
+	mainKey.Data = []byte(fmt.Sprintf("Salted__%s%s", salt, encryptedKey))
+	mainKey.Validation = validation
 
 	keyList := encryptionKeys{
 		List: []encKeyEntry{mainKey},
 		SL5:  mainKey.Identifier,
 	}
 	err = saveEncryptionKeys(dataDir, keyList)
-	return Vault{
-		Path: vaultPath,
-	}, nil
+	if err != nil {
+		return Vault{}, fmt.Errorf("Failed to save encryption keys: %v", err)
+	}
+	vault := Vault{
+		Path:    vaultPath,
+		Profile: DefaultProfile,
+	}
+	err = vault.recordRotation(true, true)
+	if err != nil {
+		return Vault{}, err
+	}
+	return vault, nil
 }
 
 // Returns the vault in 'vaultPath'. The vault is initially
 // locked and must be unlocked with Unlock()
+//
+// The 'default' profile is used if the vault has one, otherwise
+// the first of the vault's profiles. See OpenVaultProfile()
 func OpenVault(vaultPath string) (Vault, error) {
 	err := CheckVault(vaultPath)
 	if err != nil {
 		return Vault{}, err
 	}
+	profile, err := defaultProfile(vaultPath)
+	if err != nil {
+		return Vault{}, err
+	}
+	return openProfile(vaultPath, profile)
+}
 
-	return Vault{
-		Path: vaultPath,
-	}, nil
+// openProfile returns the vault in 'vaultPath' using the named
+// profile, after completing any key rotation which was interrupted
+func openProfile(vaultPath string, profile string) (Vault, error) {
+	vault := Vault{
+		Path:    vaultPath,
+		Profile: profile,
+	}
+	err := completeKeyRotation(vault.DataDir())
+	if err != nil {
+		return Vault{}, err
+	}
+	return vault, nil
 }
 
-func vaultDataDir(vaultPath string) string {
-	return vaultPath + "/data/default"
+// OpenVaultProfile returns the vault in 'vaultPath', using the
+// keys and items from the named profile
+func OpenVaultProfile(vaultPath string, profile string) (Vault, error) {
+	err := CheckVault(vaultPath)
+	if err != nil {
+		return Vault{}, err
+	}
+	profiles, err := VaultProfiles(vaultPath)
+	if err != nil {
+		return Vault{}, err
+	}
+	if !containsString(profiles, profile) {
+		return Vault{}, fmt.Errorf("No profile named '%s' in vault. Available profiles: %s",
+			profile, strings.Join(profiles, ", "))
+	}
+	return openProfile(vaultPath, profile)
 }
 
 // DataDir returns the path to the folder containing
 // encrypted items in the vault
 func (vault *Vault) DataDir() string {
-	return vaultDataDir(vault.Path)
+	return profileDataDir(vault.Path, vault.profileName())
 }
 
 // UnlockKeys decrypts the item encryption keys for
 // a vault using the master password and returns a dictionary
 // mapping key name to key data or an instance of DecryptError
-// if the password is wrong
-func UnlockKeys(vaultPath string, pwd string) (KeyDict, error) {
+// if the password is wrong. The keys are read from the profile
+// which OpenVault() would use.
+//
+// The keys are held in locked memory where supported and
+// should be released with KeyDict.Wipe() once they are
+// no longer needed.
+func UnlockKeys(vaultPath string, pwd []byte) (KeyDict, error) {
+	profile, err := defaultProfile(vaultPath)
+	if err != nil {
+		return KeyDict{}, err
+	}
+	return UnlockProfileKeys(vaultPath, profile, pwd)
+}
+
+// UnlockProfileKeys decrypts the item encryption keys for the
+// named profile of a vault. See UnlockKeys()
+func UnlockProfileKeys(vaultPath string, profile string, pwd []byte) (KeyDict, error) {
 	var keyList encryptionKeys
-	err := jsonutil.ReadFile(vaultDataDir(vaultPath)+"/encryptionKeys.js", &keyList)
+	err := jsonutil.ReadFile(profileDataDir(vaultPath, profile)+"/encryptionKeys.js", &keyList)
 	if err != nil {
 		return KeyDict{}, errors.New("Failed to read encryption key file")
 	}
@@ -320,18 +508,25 @@ func UnlockKeys(vaultPath string, pwd string) (KeyDict, error) {
 	keys := KeyDict{}
 	for _, entry := range keyList.List {
 		if len(entry.Data) != 1056 {
+			keys.Wipe()
 			return KeyDict{}, fmt.Errorf("Unexpected encrypted key length: %d", len(entry.Data))
 		}
 
 		salt, encryptedKey, err := extractSaltAndCipherText(entry.Data)
 		if err != nil {
+			keys.Wipe()
 			return KeyDict{}, fmt.Errorf("Invalid encrypted data: %v", err)
 		}
-		decryptedKey, err := decryptKey([]byte(pwd), encryptedKey, salt, entry.Iterations, entry.Validation)
+		decryptedKey, err := decryptKey(pwd, encryptedKey, salt, entry)
 		if err != nil {
+			keys.Wipe()
+		}
+		if err != nil && entry.KeyFile && !usesKeyFile(pwd) {
+			return KeyDict{}, DecryptError{err: errors.New("Failed to decrypt main key: The vault requires a key file")}
+		} else if err != nil {
 			return KeyDict{}, DecryptError{err: fmt.Errorf("Failed to decrypt main key: %v", err)}
 		}
-		keys[entry.Level] = decryptedKey
+		keys[entry.Level] = secureCopy(decryptedKey)
 	}
 
 	return keys, nil
@@ -339,9 +534,10 @@ func UnlockKeys(vaultPath string, pwd string) (KeyDict, error) {
 
 // Decrypts the master encryption key for the vault using
 // the given master password. Item contents can then be decrypted
-// and items can be added or updated
-func (vault *Vault) Unlock(pwd string) error {
-	keys, err := UnlockKeys(vault.Path, pwd)
+// and items can be added or updated. pwd is not retained, so the
+// caller should wipe it once Unlock() returns
+func (vault *Vault) Unlock(pwd []byte) error {
+	keys, err := vault.unlockKeys(pwd)
 	vault.CryptoAgent = &simpleCryptoAgent{keys}
 	return err
 }
@@ -372,7 +568,7 @@ func (vault *Vault) Lock() {
 
 // Returns the user-provided password hint text
 func (vault *Vault) PasswordHint() (string, error) {
-	hintFile, err := os.Open(vaultDataDir(vault.Path) + "/.password.hint")
+	hintFile, err := os.Open(vault.DataDir() + "/.password.hint")
 	if err != nil {
 		return "", err
 	}
@@ -383,8 +579,13 @@ func (vault *Vault) PasswordHint() (string, error) {
 	return string(hintText), nil
 }
 
+// saveEncryptionKeys replaces the vault's key files. Each file is
+// replaced atomically but the two are not replaced together, so this
+// is only used when the old and new files hold the same keys, eg.
+// encrypted with a different master password. New keys are saved
+// by RotateKeys() instead.
 func saveEncryptionKeys(dataDir string, keyList encryptionKeys) (err error) {
-	err = jsonutil.WriteFile(dataDir+"/encryptionKeys.js", keyList)
+	err = jsonutil.WriteFileAtomic(dataDir+"/encryptionKeys.js", keyList)
 	if err != nil {
 		return
 	}
@@ -394,8 +595,20 @@ func saveEncryptionKeys(dataDir string, keyList encryptionKeys) (err error) {
 
 // Changes the master password for the vault. The main encryption key
 // is first decrypted using the current password, then re-encrypted
-// using the new password
-func (vault *Vault) SetMasterPassword(currentPwd string, newPwd string) error {
+// using the new password. A key file is added or removed by passing
+// a new password which does or does not come from KeyFilePassword()
+func (vault *Vault) SetMasterPassword(currentPwd []byte, newPwd []byte) error {
+	err := vault.reencryptKeys(currentPwd, newPwd, 0)
+	if err != nil {
+		return err
+	}
+	return vault.recordRotation(true, false)
+}
+
+// reencryptKeys decrypts the vault's keys with currentPwd and encrypts
+// them with newPwd. If iterations is non-zero, the keys' PBKDF2
+// iteration counts are also changed.
+func (vault *Vault) reencryptKeys(currentPwd []byte, newPwd []byte, iterations int) error {
 	var keyList encryptionKeys
 	keyFilePath := vault.DataDir() + "/encryptionKeys.js"
 	err := jsonutil.ReadFile(keyFilePath, &keyList)
@@ -411,20 +624,24 @@ func (vault *Vault) SetMasterPassword(currentPwd string, newPwd string) error {
 		if err != nil {
 			return fmt.Errorf("Invalid encrypted key: %v", err)
 		}
-		decryptedKey, err := decryptKey([]byte(currentPwd), encryptedKey, salt, entry.Iterations, entry.Validation)
+		decryptedKey, err := decryptKey(currentPwd, encryptedKey, salt, entry)
 		if err != nil {
 			return fmt.Errorf("Failed to decrypt main key: %v", err)
 		}
 
 		// re-encrypt key with new password
+		if iterations != 0 {
+			entry.Iterations = iterations
+		}
 		newSalt := randomBytes(8)
-		newEncryptedKey, newValidation, err := encryptKey([]byte(newPwd), decryptedKey, newSalt, entry.Iterations)
+		newEncryptedKey, newValidation, err := encryptKey(newPwd, decryptedKey, newSalt, entry)
 		if err != nil {
 			return fmt.Errorf("Failed to re-encrypt main key: %v", err)
 		}
 
 		entry.Data = []byte(fmt.Sprintf("Salted__%s%s", newSalt, newEncryptedKey))
 		entry.Validation = newValidation
+		entry.KeyFile = usesKeyFile(newPwd)
 		keyList.List[i] = entry
 	}
 
@@ -436,6 +653,46 @@ func (vault *Vault) SetMasterPassword(currentPwd string, newPwd string) error {
 	return nil
 }
 
+// ResetMasterPassword re-encrypts the vault's keys with a new
+// master password, given the decrypted keys. This is used to
+// recover a vault when the master password has been lost but
+// a copy of the keys is available. Returns an error if the keys
+// do not match those for the vault.
+func (vault *Vault) ResetMasterPassword(keys KeyDict, newPwd []byte) error {
+	var keyList encryptionKeys
+	err := jsonutil.ReadFile(vault.DataDir()+"/encryptionKeys.js", &keyList)
+	if err != nil {
+		return errors.New("Failed to read encryption key file")
+	}
+
+	for i, entry := range keyList.List {
+		key, ok := keys[entry.Level]
+		if !ok {
+			return fmt.Errorf("Missing key for security level %s", entry.Level)
+		}
+		err = validateKey(key, entry.Validation)
+		if err != nil {
+			return fmt.Errorf("Key for security level %s does not match vault: %v", entry.Level, err)
+		}
+
+		newSalt := randomBytes(8)
+		newEncryptedKey, newValidation, err := encryptKey(newPwd, key, newSalt, entry)
+		if err != nil {
+			return fmt.Errorf("Failed to re-encrypt main key: %v", err)
+		}
+		entry.Data = []byte(fmt.Sprintf("Salted__%s%s", newSalt, newEncryptedKey))
+		entry.Validation = newValidation
+		entry.KeyFile = usesKeyFile(newPwd)
+		keyList.List[i] = entry
+	}
+
+	err = saveEncryptionKeys(vault.DataDir(), keyList)
+	if err != nil {
+		return fmt.Errorf("Failed to save updated keys: %v", err)
+	}
+	return vault.recordRotation(true, false)
+}
+
 // Save a new item to the vault. The new item is given a randomly
 // generated ID.
 func (vault *Vault) AddItem(title string, itemType string, content ItemContent) (Item, error) {
@@ -470,38 +727,26 @@ func (item *Item) Remove() error {
 		return err
 	}
 	err = item.Save()
-	return err
+	if err != nil || item.vault.DryRun {
+		return err
+	}
+	// previous versions of the item would otherwise
+	// keep its content after it has been removed
+	return item.removeHistory()
 }
 
 // Remove the item's data files from the vault
 func (item *Item) removeDataFiles() error {
 	itemDataFile := item.Path()
 
-	// remove contents.js entry
-	contentsFilePath := item.vault.DataDir() + "/contents.js"
-	var contentsEntries [][]interface{}
-	err := jsonutil.ReadFile(contentsFilePath, &contentsEntries)
-	if err != nil {
-		return fmt.Errorf("Failed to read contents.js: %v", err)
-	}
-
-	foundExisting := false
-	newContentsEntries := [][]interface{}{}
-	for _, entry := range contentsEntries {
-		tmpItem := readContentsEntry(entry)
-		if tmpItem.Uuid == item.Uuid {
-			foundExisting = true
-		} else {
-			newContentsEntries = append(newContentsEntries, entry)
-		}
-	}
-	if !foundExisting {
+	if _, err := os.Stat(itemDataFile); os.IsNotExist(err) {
 		return fmt.Errorf("Entry '%s' (ID: %s) not found", item.Title, item.Uuid)
 	}
 
-	err = jsonutil.WriteFile(contentsFilePath, newContentsEntries)
+	// remove contents.js entry
+	err := item.vault.updateIndex(item.Uuid, nil)
 	if err != nil {
-		return fmt.Errorf("Failed to update contents.js: %v", err)
+		return err
 	}
 
 	// remove .1password data file
@@ -510,7 +755,7 @@ func (item *Item) removeDataFiles() error {
 		return fmt.Errorf("Failed to remove item data file: %s: %v", itemDataFile, err)
 	}
 
-	return nil
+	return item.removeHistory()
 }
 
 func (item *Item) contentsEntry() []interface{} {
@@ -561,72 +806,199 @@ func (item *Item) Save() error {
 	if len(item.Encrypted) == 0 {
 		return fmt.Errorf("Item content not set")
 	}
+	options, err := item.vault.Options()
+	if err != nil {
+		return err
+	}
+	if options.Mirror != nil {
+		return ReadOnlyError{options.Mirror.Path}
+	}
+
+	if item.vault.NormalizeUrls {
+		_, err := item.NormalizeUrls(item.vault.UpgradeUrlsToHttps)
+		if err != nil {
+			return err
+		}
+	}
 
 	item.UpdatedAt = uint64(time.Now().Unix())
 	if item.CreatedAt == 0 {
 		item.CreatedAt = item.UpdatedAt
 	}
+	return item.write()
+}
 
-	// save item to .1password file
-	itemPath := item.Path()
-	err := jsonutil.WriteFile(itemPath, item)
+// write saves the item to the vault without
+// changing its timestamps
+func (item *Item) write() error {
+	options, err := item.vault.Options()
 	if err != nil {
-		return fmt.Errorf("Failed to save item %s: %v", item.Title, err)
+		return err
+	}
+	savedItem, err := item.storedItem(options)
+	if err != nil {
+		return err
 	}
+	item.Signature = savedItem.Signature
+	item.signatureErr = nil
 
-	// update contents.js entry
-	contentsFilePath := item.vault.DataDir() + "/contents.js"
-	var contentsEntries [][]interface{}
-	err = jsonutil.ReadFile(contentsFilePath, &contentsEntries)
-	if err != nil {
-		return fmt.Errorf("Failed to read contents.js: %v", err)
-	}
-	foundExisting := false
-	for i, entry := range contentsEntries {
-		tmpItem := readContentsEntry(entry)
-		if tmpItem.Uuid == item.Uuid {
-			contentsEntries[i] = item.contentsEntry()
-			foundExisting = true
-			break
+	itemPath := item.Path()
+	_, err = os.Stat(itemPath)
+	isNew := os.IsNotExist(err)
+	change := ItemChange{Uuid: item.Uuid, Title: item.Title, TypeName: item.TypeName, Action: ItemUpdated}
+	if item.TypeName == "system.Tombstone" {
+		change.Action = ItemRemoved
+	} else if isNew {
+		change.Action = ItemAdded
+	}
+	if item.vault.DryRun {
+		return item.reportDryRunChange(change, isNew)
+	}
+
+	// save item to .1password file. Tombstones are not added
+	// to the history since they replace the item's content.
+	if !isNew && options.KeepHistory && change.Action != ItemRemoved {
+		err = item.saveRevision(savedItem)
+		if err != nil {
+			return err
 		}
 	}
-	if !foundExisting {
-		contentsEntries = append(contentsEntries, item.contentsEntry())
+	err = jsonutil.WriteFile(itemPath, savedItem)
+	if err != nil {
+		return fmt.Errorf("Failed to save item %s: %v", item.Title, err)
 	}
-	err = jsonutil.WriteFile(contentsFilePath, contentsEntries)
+
+	// update contents.js entry
+	err = item.vault.updateIndex(item.Uuid, savedItem.contentsEntry())
 	if err != nil {
-		return fmt.Errorf("Failed to update contents.js: %v", err)
+		return err
+	}
+
+	if item.vault.OnItemChange != nil {
+		item.vault.OnItemChange(change)
 	}
 
 	return nil
 }
 
+// reportDryRunChange reports a change to an item in a vault with
+// DryRun set, including the differences from the saved item
+func (item *Item) reportDryRunChange(change ItemChange, isNew bool) error {
+	if !isNew {
+		previous, err := item.vault.LoadItem(item.Uuid)
+		if err != nil {
+			return err
+		}
+		change.Title = previous.Title
+		if change.Action == ItemUpdated {
+			change.Changes, err = DiffItems(previous, *item)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if item.vault.OnItemChange != nil {
+		item.vault.OnItemChange(change)
+	}
+	return nil
+}
+
+// storedItem returns the item as it is written to its file,
+// with the title hidden and a signature added if the vault's
+// options require them
+func (item *Item) storedItem(options VaultOptions) (Item, error) {
+	var err error
+	savedItem := *item
+	if options.PrivateTitles {
+		savedItem, err = item.storePrivateTitle()
+		if err != nil {
+			return Item{}, fmt.Errorf("Failed to save item %s: %v", item.Title, err)
+		}
+	}
+	savedItem.Signature = ""
+	if options.SignItems {
+		savedItem.Signature, err = savedItem.signature()
+		if err != nil {
+			return Item{}, fmt.Errorf("Failed to sign item %s: %v", item.Title, err)
+		}
+	}
+	return savedItem, nil
+}
+
 func (vault *Vault) LoadItem(uuid string) (Item, error) {
+	return vault.loadItemFile(vault.DataDir() + "/" + uuid + ".1password")
+}
+
+// loadItemFile reads an item from a file, checking its signature
+// and revealing its title if the vault's options require it
+func (vault *Vault) loadItemFile(path string) (Item, error) {
 	item := Item{
 		vault: vault,
 	}
-	err := jsonutil.ReadFile(vault.DataDir()+"/"+uuid+".1password", &item)
+	err := jsonutil.ReadFile(path, &item)
 	if err != nil {
 		return Item{}, err
 	}
+	options, err := vault.Options()
+	if err != nil {
+		return Item{}, err
+	}
+	if vault.canVerifySignatures(options) {
+		item.signatureErr = item.verifySignature()
+	}
+	if options.PrivateTitles {
+		err = item.revealPrivateTitle()
+		if err != nil {
+			return Item{}, err
+		}
+	}
 	return item, nil
 }
 
-// Returns a list of all items in the vault.
-// Returned items have their main content still encrypted
+// Returns a list of all items in the vault, read from the item files
+// rather than from the contents.js index so that a damaged index does
+// not affect the listing. Conflicted copies of item files are skipped.
+// Returned items have their main content still encrypted.
+// If the vault has private titles, the vault must be unlocked
+// to read the items' titles. If item signing is enabled and the
+// vault is unlocked, the signature of each item is checked.
+// See Item.SignatureErr()
 func (vault *Vault) ListItems() ([]Item, error) {
+	return vault.listItems(false)
+}
+
+// listItems returns the items in the vault, including the
+// tombstones left by removed items if includeTombstones is set
+func (vault *Vault) listItems(includeTombstones bool) ([]Item, error) {
 	items := []Item{}
+	options, err := vault.Options()
+	if err != nil {
+		return items, err
+	}
+	if options.PrivateTitles && vault.IsLocked() {
+		return items, errors.New("The vault must be unlocked to list items because titles are private")
+	}
 	dirEntries, err := ioutil.ReadDir(vault.DataDir())
 	if err != nil {
 		return items, err
 	}
+	verifySignatures := vault.canVerifySignatures(options)
 	for _, item := range dirEntries {
-		if path.Ext(item.Name()) == ".1password" {
+		if path.Ext(item.Name()) == ".1password" && !conflictedCopyRegex.MatchString(item.Name()) {
 			itemData := Item{vault: vault}
 			err := jsonutil.ReadFile(vault.DataDir()+"/"+item.Name(), &itemData)
 			if err != nil {
 				fmt.Printf("Failed to read item: %s: %v\n", item.Name(), err)
-			} else if itemData.TypeName != "system.Tombstone" {
+			} else if includeTombstones || itemData.TypeName != "system.Tombstone" {
+				if verifySignatures {
+					itemData.signatureErr = itemData.verifySignature()
+				}
+				if options.PrivateTitles {
+					err = itemData.revealPrivateTitle()
+					if err != nil {
+						return items, err
+					}
+				}
 				items = append(items, itemData)
 			}
 		}
@@ -671,6 +1043,49 @@ func (item *Item) Content() (ItemContent, error) {
 	return fieldValue, nil
 }
 
+// ItemContents decrypts the content of several items. If the vault's
+// CryptoAgent implements BatchCryptoAgent, the items are decrypted
+// with a single call to the agent.
+func (vault *Vault) ItemContents(items []Item) ([]ItemContent, error) {
+	batchAgent, ok := vault.CryptoAgent.(BatchCryptoAgent)
+	if !ok {
+		contents := []ItemContent{}
+		for i := range items {
+			content, err := items[i].Content()
+			if err != nil {
+				return nil, err
+			}
+			contents = append(contents, content)
+		}
+		return contents, nil
+	}
+
+	if vault.IsLocked() {
+		return nil, errors.New("Vault is locked")
+	}
+	keyNames := []string{}
+	data := [][]byte{}
+	for _, item := range items {
+		if len(item.Encrypted) < 16 {
+			return nil, fmt.Errorf("No item data for %s", item.Title)
+		}
+		keyNames = append(keyNames, item.SecurityLevel)
+		data = append(data, item.Encrypted)
+	}
+	decrypted, err := batchAgent.DecryptBatch(keyNames, data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt items: %v", err)
+	}
+	contents := make([]ItemContent, len(items))
+	for i := range decrypted {
+		err = json.Unmarshal(decrypted[i], &contents[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return contents, nil
+}
+
 // Encrypts data using the item's encryption key
 // and stores it in item.Encrypted
 func (item *Item) SetContent(data ItemContent) error {
@@ -702,12 +1117,13 @@ func (item *Item) SetContent(data ItemContent) error {
 }
 
 // Encrypts content using the item's encryption key
-// and stores it in item.Encrypted
+// and stores it in item.Encrypted. The content must have
+// the structure expected for the item's type, see
+// ValidateContentJson()
 func (item *Item) SetContentJson(content string) error {
-	var unused interface{}
-	err := json.Unmarshal([]byte(content), &unused)
+	err := ValidateContentJson(item.TypeName, content)
 	if err != nil {
-		return fmt.Errorf("Content is not valid JSON: %v", err)
+		return err
 	}
 
 	if item.vault.IsLocked() {
@@ -763,7 +1179,7 @@ func (item *Item) Type() string {
 }
 
 func aesCbcDecrypt(key []byte, cipherText []byte, iv []byte) ([]byte, error) {
-	if len(key) != Aes128KeyLen {
+	if len(key) != Aes128KeyLen && len(key) != Aes256KeyLen {
 		return nil, fmt.Errorf("Incorrect key length")
 	}
 	if len(iv) != Aes128KeyLen {
@@ -773,6 +1189,9 @@ func aesCbcDecrypt(key []byte, cipherText []byte, iv []byte) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Failed to initialize AES cipher")
 	}
+	if len(cipherText) == 0 || len(cipherText)%AesBlockLen != 0 {
+		return nil, fmt.Errorf("Ciphertext length is not a multiple of %d", AesBlockLen)
+	}
 	cbcDecrypter := cipher.NewCBCDecrypter(aesCipher, iv)
 	plainText := make([]byte, len(cipherText))
 	cbcDecrypter.CryptBlocks(plainText, cipherText)
@@ -785,7 +1204,7 @@ func aesCbcDecrypt(key []byte, cipherText []byte, iv []byte) ([]byte, error) {
 }
 
 func aesCbcEncrypt(key []byte, plainText []byte, iv []byte) ([]byte, error) {
-	if len(key) != Aes128KeyLen {
+	if len(key) != Aes128KeyLen && len(key) != Aes256KeyLen {
 		return nil, fmt.Errorf("Incorrect key length")
 	}
 	if len(iv) != Aes128KeyLen {
@@ -813,8 +1232,11 @@ func aesStripPadding(data []byte) ([]byte, error) {
 	if len(data)%AesBlockLen != 0 {
 		return nil, fmt.Errorf("Decrypted data block length is not a multiple of %d", AesBlockLen)
 	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Decrypted data is empty")
+	}
 	paddingLen := int(data[len(data)-1])
-	if paddingLen > 16 {
+	if paddingLen == 0 || paddingLen > 16 {
 		return nil, fmt.Errorf("Invalid last block padding length: %d", paddingLen)
 	}
 	return data[:len(data)-paddingLen], nil
@@ -844,11 +1266,13 @@ func extractSaltAndCipherText(data []byte) ([]byte, []byte, error) {
 	return data[8:16], data[16:], nil
 }
 
-func encryptKey(masterPwd []byte, decryptedKey []byte, salt []byte, iterCount int) ([]byte, []byte, error) {
-	const keyLen = 32
-	derivedKey := pbkdf2.Key(masterPwd, salt, iterCount, keyLen, sha1.New)
-	aesKey := derivedKey[0:16]
-	iv := derivedKey[16:32]
+// encryptKey encrypts one of the vault's keys with a key derived from
+// the master password using the KDF and scheme specified by entry
+func encryptKey(masterPwd []byte, decryptedKey []byte, salt []byte, entry encKeyEntry) ([]byte, []byte, error) {
+	aesKey, iv, err := deriveKeyEncryptionKey(masterPwd, salt, entry)
+	if err != nil {
+		return nil, nil, err
+	}
 	encryptedKey, err := aesCbcEncrypt(aesKey, decryptedKey, iv)
 	if err != nil {
 		return nil, nil, err
@@ -865,37 +1289,47 @@ func encryptKey(masterPwd []byte, decryptedKey []byte, salt []byte, iterCount in
 	return encryptedKey, validation, nil
 }
 
-func decryptKey(masterPwd []byte, encryptedKey []byte, salt []byte, iterCount int, validation []byte) ([]byte, error) {
-	const keyLen = 32
-	derivedKey := pbkdf2.Key(masterPwd, salt, iterCount, keyLen, sha1.New)
-
-	aesKey := derivedKey[0:16]
-	iv := derivedKey[16:32]
+func decryptKey(masterPwd []byte, encryptedKey []byte, salt []byte, entry encKeyEntry) ([]byte, error) {
+	aesKey, iv, err := deriveKeyEncryptionKey(masterPwd, salt, entry)
+	if err != nil {
+		return nil, err
+	}
 	decryptedKey, err := aesCbcDecrypt(aesKey, encryptedKey, iv)
+	WipeBytes(aesKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateKey(decryptedKey, entry.Validation)
 	if err != nil {
+		WipeBytes(decryptedKey)
 		return nil, err
 	}
+	return decryptedKey, nil
+}
 
+// checks that a decrypted key matches the validation
+// data stored alongside the encrypted key
+func validateKey(decryptedKey []byte, validation []byte) error {
 	validationSalt, validationCipherText, err := extractSaltAndCipherText(validation)
 	if err != nil {
-		return nil, fmt.Errorf("Invalid validation: %v", err)
+		return fmt.Errorf("Invalid validation: %v", err)
 	}
 
 	validationAesKey, validationIv := openSslKey(decryptedKey, validationSalt)
 	decryptedValidation, err := aesCbcDecrypt(validationAesKey, validationCipherText, validationIv)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to decrypt validation: %v", err)
+		return fmt.Errorf("Failed to decrypt validation: %v", err)
 	}
 
 	if string(decryptedValidation) != string(decryptedKey) {
-		return nil, errors.New("Validation decryption failed")
+		return errors.New("Validation decryption failed")
 	}
-
-	return decryptedKey, nil
+	return nil
 }
 
 func writePlistFile(path string, in interface{}) error {
-	return jsonutil.MarshalToFile(path, in, plist.Marshal)
+	return jsonutil.MarshalToFileAtomic(path, in, plist.Marshal)
 }
 
 // derive an AES-128 key and initialization vector from an arbitrary-length
@@ -906,7 +1340,9 @@ func writePlistFile(path string, in interface{}) error {
 //
 func openSslKey(password []byte, salt []byte) (key []byte, iv []byte) {
 	const rounds = 2
-	data := append(password, salt...)
+	// copy the password so that concurrent calls with
+	// the same password do not share the appended data
+	data := append(append([]byte{}, password...), salt...)
 	md5Hashes := make([][]byte, rounds)
 
 	sum := md5.Sum(data)
@@ -950,15 +1386,7 @@ func GenPassword(length int) string {
 	}
 	for {
 		candidate := genPasswordCandidate(length)
-		hasLower := false
-		hasUpper := false
-		hasDigit := false
-		for _, ch := range candidate {
-			hasLower = hasLower || unicode.IsLower(ch)
-			hasUpper = hasUpper || unicode.IsUpper(ch)
-			hasDigit = hasDigit || unicode.IsDigit(ch)
-		}
-		if hasLower && hasUpper && hasDigit {
+		if hasPasswordCharClasses(candidate) {
 			return candidate
 		}
 	}
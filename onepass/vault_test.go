@@ -3,6 +3,7 @@ package onepass
 import (
 	"bytes"
 	"encoding/hex"
+	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
@@ -49,7 +50,7 @@ func newTestVault() (Vault, error) {
 	if err != nil {
 		return Vault{}, err
 	}
-	if err = vault.Unlock(security.MasterPwd); err != nil {
+	if err = vault.Unlock([]byte(security.MasterPwd)); err != nil {
 		return Vault{}, err
 	}
 	return vault, nil
@@ -76,7 +77,7 @@ func TestItemCrypt(t *testing.T) {
 	}
 	decrypted, err := item.Content()
 	if err != nil {
-		t.Error("error decrypting item: %v", err)
+		t.Errorf("error decrypting item: %v", err)
 	}
 	if !reflect.DeepEqual(decrypted, content) {
 		t.Errorf("input: %s, decrypted: %s", content, decrypted)
@@ -180,24 +181,171 @@ func TestSaveLoadRemoveItem(t *testing.T) {
 	}
 }
 
+func TestItemChanges(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+	actions := []string{}
+	vault.OnItemChange = func(change ItemChange) {
+		actions = append(actions, change.Action)
+	}
+	item, err := vault.AddItem("Changed item", "securenotes.SecureNote", newTestContent("site.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.Trashed = true
+	err = item.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = item.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{ItemAdded, ItemUpdated, ItemRemoved}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Unexpected item changes. Actual: %v, expected: %v", actions, expected)
+	}
+}
+
 func TestEncryptDecryptKey(t *testing.T) {
 	pwd := []byte("the-master-password")
 	randomKey := randomBytes(1024)
 	salt := randomBytes(8)
-	iterCount := 100
 
-	encryptedKey, encryptedValidation, err := encryptKey(pwd, randomKey, salt, iterCount)
+	entries := []encKeyEntry{
+		{Iterations: 100},
+		{Iterations: 100, Scheme: KeySchemeAes128Sha1},
+		{Iterations: 100, Scheme: KeySchemeAes256Sha256},
+		{Kdf: KdfScrypt, KdfParams: &KdfParams{N: 16, R: 8, P: 1}},
+		{Kdf: KdfArgon2id, KdfParams: &KdfParams{Time: 1, Memory: 64, Threads: 1}, Scheme: KeySchemeAes256Sha256},
+	}
+	for _, entry := range entries {
+		encryptedKey, encryptedValidation, err := encryptKey(pwd, randomKey, salt, entry)
+		if err != nil {
+			t.Errorf("Failed to encrypt key: %v", err)
+		}
+		entry.Validation = encryptedValidation
+
+		decryptedKey, err := decryptKey(pwd, encryptedKey, salt, entry)
+		if err != nil {
+			t.Errorf("Failed to decrypt key: %v", err)
+		}
+
+		if !bytes.Equal(randomKey, decryptedKey) {
+			t.Errorf("Decrypted key does not match original input")
+		}
+	}
+
+	// keys encrypted with one scheme should not be
+	// decryptable with another
+	entry := encKeyEntry{Iterations: 100, Scheme: KeySchemeAes256Sha256}
+	encryptedKey, encryptedValidation, _ := encryptKey(pwd, randomKey, salt, entry)
+	entry.Scheme = KeySchemeAes128Sha1
+	entry.Validation = encryptedValidation
+	_, err := decryptKey(pwd, encryptedKey, salt, entry)
+	if err == nil {
+		t.Errorf("Decrypted AES-256 key using AES-128 scheme")
+	}
+
+	for _, invalid := range []encKeyEntry{
+		{Iterations: 100, Scheme: "rot13"},
+		{Kdf: "bcrypt"},
+		{Kdf: KdfScrypt},
+		{Kdf: KdfScrypt, KdfParams: &KdfParams{N: 1000, R: 8, P: 1}},
+		{Kdf: KdfArgon2id, KdfParams: &KdfParams{Time: 1, Memory: 64}},
+	} {
+		_, _, err = encryptKey(pwd, randomKey, salt, invalid)
+		if err == nil {
+			t.Errorf("Encrypted key with invalid scheme or KDF: %+v", invalid)
+		}
+	}
+}
+
+func TestNewVaultKdf(t *testing.T) {
+	for _, kdf := range []string{KdfScrypt, KdfArgon2id} {
+		vaultDir := "test/new-vault-" + kdf + ".agilekeychain"
+		err := os.RemoveAll(vaultDir)
+		if err != nil {
+			t.Error(err)
+		}
+		security := VaultSecurity{MasterPwd: "the-master-pwd", Kdf: kdf}
+		vault, err := NewVault(vaultDir, security)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vaultKdf, params, err := vault.KeyKdf()
+		defaultParams, _ := DefaultKdfParams(kdf)
+		if err != nil || vaultKdf != kdf || params == nil || *params != defaultParams {
+			t.Errorf("Unexpected KDF %s %+v: %v", vaultKdf, params, err)
+		}
+		err = vault.SetMasterPassword([]byte(security.MasterPwd), []byte("new-pwd"))
+		if err != nil {
+			t.Fatalf("Unable to change password: %v", err)
+		}
+		err = vault.Unlock([]byte("new-pwd"))
+		if err != nil {
+			t.Errorf("Unable to unlock vault using %s: %v", kdf, err)
+		}
+		if vaultKdf, _, _ = vault.KeyKdf(); vaultKdf != kdf {
+			t.Errorf("KDF changed to %s after changing password", vaultKdf)
+		}
+		if vault.UpgradeKdf([]byte("new-pwd"), RecommendedPbkdfIterations) == nil {
+			t.Errorf("Changed PBKDF2 iterations for vault using %s", kdf)
+		}
+	}
+}
+
+func TestNewVaultAes256(t *testing.T) {
+	vaultDir := "test/new-vault-aes256.agilekeychain"
+	err := os.RemoveAll(vaultDir)
 	if err != nil {
-		t.Errorf("Failed to encrypt key: %v", err)
+		t.Error(err)
 	}
 
-	decryptedKey, err := decryptKey(pwd, encryptedKey, salt, iterCount, encryptedValidation)
+	security := VaultSecurity{
+		MasterPwd:  "the-master-pwd",
+		Iterations: 100,
+		KeyScheme:  KeySchemeAes256Sha256,
+	}
+	vault, err := NewVault(vaultDir, security)
 	if err != nil {
-		t.Errorf("Failed to decrypt key: %v", err)
+		t.Fatal(err)
+	}
+	scheme, err := vault.KeyScheme()
+	if err != nil || scheme != KeySchemeAes256Sha256 {
+		t.Errorf("Unexpected key scheme %s: %v", scheme, err)
 	}
 
-	if !bytes.Equal(randomKey, decryptedKey) {
-		t.Errorf("Decrypted key does not match original input")
+	_, err = UnlockKeys(vaultDir, []byte("wrong-pwd"))
+	if _, isDecryptErr := err.(DecryptError); !isDecryptErr {
+		t.Errorf("Expected DecryptError for wrong password, got %v", err)
+	}
+
+	err = vault.SetMasterPassword([]byte(security.MasterPwd), []byte("new-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to change password: %v", err)
+	}
+	err = vault.Unlock([]byte("new-pwd"))
+	if err != nil {
+		t.Fatalf("Unable to unlock vault: %v", err)
+	}
+	scheme, _ = vault.KeyScheme()
+	if scheme != KeySchemeAes256Sha256 {
+		t.Errorf("Key scheme changed to %s after changing password", scheme)
+	}
+
+	item := newTestItem(&vault)
+	item.SetContent(ItemContent{Notes: "test-aes256-note"})
+	err = item.Save()
+	if err != nil {
+		t.Errorf("Unable to save item in new vault: %v", err)
+	}
+
+	_, err = NewVault("test/unknown-scheme.agilekeychain", VaultSecurity{MasterPwd: "pwd", KeyScheme: "rot13"})
+	if err == nil {
+		t.Errorf("Created vault with unknown key scheme")
 	}
 }
 
@@ -216,7 +364,7 @@ func TestNewVault(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	err = vault.Unlock(security.MasterPwd)
+	err = vault.Unlock([]byte(security.MasterPwd))
 	if err != nil {
 		t.Errorf("Error unlocking new vault: %v", err)
 	}
@@ -263,7 +411,7 @@ func TestChangePass(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	err = vault.Unlock(security.MasterPwd)
+	err = vault.Unlock([]byte(security.MasterPwd))
 	if err != nil {
 		t.Error(err)
 	}
@@ -282,12 +430,12 @@ func TestChangePass(t *testing.T) {
 	}
 
 	newPwd := "new-pwd"
-	err = vault.SetMasterPassword(security.MasterPwd, newPwd)
+	err = vault.SetMasterPassword([]byte(security.MasterPwd), []byte(newPwd))
 	if err != nil {
 		t.Error(err)
 	}
 
-	err = vault.Unlock(newPwd)
+	err = vault.Unlock([]byte(newPwd))
 	if err != nil {
 		t.Error(err)
 	}
@@ -336,3 +484,115 @@ func TestGenPassword(t *testing.T) {
 		}
 	}
 }
+
+// batchCryptoAgent counts calls to DecryptBatch
+type batchCryptoAgent struct {
+	simpleCryptoAgent
+	batchCalls int
+}
+
+func (agent *batchCryptoAgent) DecryptBatch(keyNames []string, in [][]byte) ([][]byte, error) {
+	agent.batchCalls++
+	out := [][]byte{}
+	for i := range in {
+		data, err := agent.Decrypt(keyNames[i], in[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+func TestItemContents(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls := []string{"https://a.example.com", "https://b.example.com"}
+	items := []Item{}
+	for _, url := range urls {
+		content := newTestContent(url)
+		content.FormFields = []WebFormField{{Name: "email", Designation: "username", Value: "user@" + url}}
+		item, err := vault.AddItem("Test", "webforms.WebForm", content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+
+	checkContents := func(contents []ItemContent, err error) {
+		if err != nil {
+			t.Fatalf("Unable to decrypt item contents: %v", err)
+		}
+		if len(contents) != len(urls) {
+			t.Fatalf("Expected %d contents, got %d", len(urls), len(contents))
+		}
+		for i, content := range contents {
+			if content.Urls[0].Url != urls[i] || content.Username() != "user@"+urls[i] {
+				t.Errorf("Unexpected content for item %d: %v", i, content)
+			}
+		}
+	}
+	checkContents(vault.ItemContents(items))
+
+	batchAgent := &batchCryptoAgent{simpleCryptoAgent: *vault.CryptoAgent.(*simpleCryptoAgent)}
+	vault.CryptoAgent = batchAgent
+	checkContents(vault.ItemContents(items))
+	if batchAgent.batchCalls != 1 {
+		t.Errorf("Expected 1 batch decrypt call, got %d", batchAgent.batchCalls)
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	vaultDir := "test/key-file.agilekeychain"
+	err := os.RemoveAll(vaultDir)
+	if err != nil {
+		t.Error(err)
+	}
+	keyFile := os.TempDir() + "/vault-test.key"
+	err = ioutil.WriteFile(keyFile, randomBytes(64), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeyFile := os.TempDir() + "/vault-test-other.key"
+	err = ioutil.WriteFile(otherKeyFile, randomBytes(64), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	security := VaultSecurity{MasterPwd: "the-master-pwd", Iterations: 100, KeyFilePath: keyFile}
+	vault, err := NewVault(vaultDir, security)
+	if err != nil {
+		t.Fatal(err)
+	}
+	required, err := vault.RequiresKeyFile()
+	if err != nil || !required {
+		t.Errorf("Expected vault to require key file: %v", err)
+	}
+
+	if _, ok := vault.Unlock([]byte(security.MasterPwd)).(DecryptError); !ok {
+		t.Errorf("Unlocked vault without key file")
+	}
+	if _, ok := vault.UnlockWithKeyFile([]byte(security.MasterPwd), otherKeyFile).(DecryptError); !ok {
+		t.Errorf("Unlocked vault with wrong key file")
+	}
+	err = vault.UnlockWithKeyFile([]byte(security.MasterPwd), keyFile)
+	if err != nil {
+		t.Fatalf("Unable to unlock vault with key file: %v", err)
+	}
+
+	// remove the key file requirement
+	currentPwd, _ := KeyFilePassword([]byte(security.MasterPwd), keyFile)
+	err = vault.SetMasterPassword(currentPwd, []byte("new-pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if required, _ = vault.RequiresKeyFile(); required {
+		t.Errorf("Vault still requires key file after removing it")
+	}
+	err = vault.Unlock([]byte("new-pwd"))
+	if err != nil {
+		t.Errorf("Unable to unlock vault without key file: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package onepass
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// VaultProblem describes a problem found by Vault.Verify()
+type VaultProblem struct {
+	// UUID of the affected item
+	Uuid string
+	// description of the problem
+	Description string
+	// set if the problem is fixed by Vault.RebuildIndex()
+	Repairable bool
+}
+
+// Verify checks the structure of the vault's data files. It reports
+// entries in the contents.js index with no item file ('dangling'
+// entries), item files with no index entry ('orphaned' items), index
+// entries which do not match their item file, item files which cannot
+// be read, items whose encrypted data is malformed and conflicted
+// copies of item files. See ConflictedCopies(). If the vault is
+// unlocked, the data for each item is also decrypted to check its
+// padding and that it contains valid JSON.
+//
+// Problems with the index can be fixed using RebuildIndex().
+func (vault *Vault) Verify() ([]VaultProblem, error) {
+	problems := []VaultProblem{}
+	addProblem := func(uuid string, repairable bool, format string, args ...interface{}) {
+		problems = append(problems, VaultProblem{
+			Uuid:        uuid,
+			Description: fmt.Sprintf(format, args...),
+			Repairable:  repairable,
+		})
+	}
+
+	entries, err := vault.readContentsIndex()
+	indexOk := err == nil
+	if !indexOk {
+		addProblem("", true, "%v", err)
+	}
+	indexed := map[string]Item{}
+	for i, entry := range entries {
+		item, err := parseContentsEntry(entry)
+		if err != nil {
+			addProblem("", true, "Entry %d in contents.js is invalid: %v", i, err)
+			continue
+		}
+		if _, ok := indexed[item.Uuid]; ok {
+			addProblem(item.Uuid, true, "Duplicate entry in contents.js")
+		}
+		indexed[item.Uuid] = item
+	}
+
+	items, unreadable, err := vault.readItemFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, uuid := range unreadable {
+		addProblem(uuid, false, "Item file cannot be read")
+		delete(indexed, uuid)
+	}
+
+	unlocked := !vault.IsLocked()
+	folders := map[string]bool{}
+	for _, item := range items {
+		if item.TypeName == "system.folder.Regular" {
+			folders[item.Uuid] = true
+		}
+	}
+	for _, item := range items {
+		entry, ok := indexed[item.Uuid]
+		delete(indexed, item.Uuid)
+		if !ok && indexOk {
+			addProblem(item.Uuid, true, "Item file is not listed in contents.js (orphaned item)")
+		} else if ok && (entry.TypeName != item.TypeName || entry.Title != item.Title ||
+			entry.Location != item.Location || entry.UpdatedAt != item.UpdatedAt ||
+			entry.FolderUuid != item.FolderUuid || entry.Trashed != item.Trashed) {
+			addProblem(item.Uuid, true, "Entry in contents.js does not match item file")
+		}
+
+		if _, ok := ItemTypes[item.TypeName]; !ok {
+			addProblem(item.Uuid, false, "Unknown item type '%s'", item.TypeName)
+		}
+		if item.FolderUuid != "" && !folders[item.FolderUuid] {
+			addProblem(item.Uuid, false, "Folder %s does not exist", item.FolderUuid)
+		}
+		err = checkEncryptedData(item.Encrypted)
+		if err != nil {
+			addProblem(item.Uuid, false, "Invalid encrypted data: %v", err)
+			continue
+		}
+		if unlocked {
+			content, err := item.ContentJson()
+			if err == nil && !json.Valid([]byte(content)) {
+				err = fmt.Errorf("content is not valid JSON")
+			}
+			if err != nil {
+				addProblem(item.Uuid, false, "Unable to decrypt item: %v", err)
+			}
+		}
+	}
+	for uuid, _ := range indexed {
+		addProblem(uuid, true, "Entry in contents.js has no item file (dangling entry)")
+	}
+
+	conflicts, err := vault.conflictedCopyNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range conflicts {
+		uuid := conflictedCopyRegex.FindStringSubmatch(name)[1]
+		addProblem(uuid, false, "Item file has a conflicted copy '%s'", name)
+	}
+	return problems, nil
+}
+
+// checkEncryptedData checks that data has the structure of
+// OpenSSL-encrypted item data: an 8-byte 'Salted__' prefix and
+// 8-byte salt followed by a whole number of AES blocks
+func checkEncryptedData(data []byte) error {
+	if len(data) < 16 || !bytes.HasPrefix(data, []byte("Salted__")) {
+		return fmt.Errorf("missing salt")
+	}
+	cipherTextLen := len(data) - 16
+	if cipherTextLen == 0 || cipherTextLen%AesBlockLen != 0 {
+		return fmt.Errorf("length %d is not a whole number of blocks", cipherTextLen)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package onepass
+
+import (
+	"os"
+	"testing"
+
+	"github.com/robertknight/1pass/jsonutil"
+)
+
+func TestVerifyAndRebuildIndex(t *testing.T) {
+	vault, err := newTestVault()
+	if err != nil {
+		t.Fatalf("Creating test vault failed: %v", err)
+	}
+	items := []Item{}
+	for _, title := range []string{"Dangling", "Orphaned", "Corrupted", "Valid"} {
+		item, err := vault.AddItem(title, "securenotes.SecureNote", newTestContent(title+".com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+
+	problems, err := vault.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("Unexpected problems in new vault: %+v", problems)
+	}
+
+	// remove the file of the first item, the contents.js
+	// entry of the second and corrupt the data of the third
+	err = os.Remove(items[0].Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	contentsPath := vault.DataDir() + "/contents.js"
+	entries, err := vault.readContentsIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newEntries := [][]interface{}{}
+	for _, entry := range entries {
+		if entry[0] != items[1].Uuid {
+			newEntries = append(newEntries, entry)
+		}
+	}
+	jsonutil.WriteFile(contentsPath, newEntries)
+	corrupted := items[2]
+	corrupted.Encrypted = corrupted.Encrypted[0 : len(corrupted.Encrypted)-3]
+	jsonutil.WriteFile(corrupted.Path(), corrupted)
+
+	problems, err = vault.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	found := map[string]VaultProblem{}
+	for _, problem := range problems {
+		found[problem.Uuid] = problem
+	}
+	if len(problems) != 3 || !found[items[0].Uuid].Repairable || !found[items[1].Uuid].Repairable ||
+		found[items[2].Uuid].Repairable {
+		t.Fatalf("Unexpected problems: %+v", problems)
+	}
+
+	err = vault.RebuildIndex()
+	if err != nil {
+		t.Fatalf("Repairing index failed: %v", err)
+	}
+	problems, err = vault.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Uuid != items[2].Uuid {
+		t.Errorf("Unexpected problems after repair: %+v", problems)
+	}
+	listed, err := vault.ListItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 3 {
+		t.Errorf("Expected 3 items after repair, found %d", len(listed))
+	}
+}
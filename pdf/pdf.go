@@ -0,0 +1,355 @@
+// Package pdf writes simple text-only PDF documents,
+// optionally protected with a password using the
+// PDF standard security handler (128-bit AES).
+//
+// Only the features needed to produce printable
+// documents such as an emergency kit are supported:
+// lines of text in a few fixed styles which are
+// wrapped and split across A4 pages automatically.
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Style specifies how a line of text is displayed
+type Style int
+
+const (
+	Normal Style = iota
+	Heading
+	Mono
+)
+
+type styleInfo struct {
+	font     string
+	size     float64
+	leading  float64
+	maxChars int
+}
+
+// fonts and line metrics for each style. maxChars is the number
+// of characters which fit on a line, using the average (Helvetica)
+// or exact (Courier) character widths.
+var styles = map[Style]styleInfo{
+	Normal:  {font: "F1", size: 11, leading: 15, maxChars: 90},
+	Heading: {font: "F2", size: 16, leading: 24, maxChars: 55},
+	Mono:    {font: "F3", size: 10, leading: 13, maxChars: 82},
+}
+
+// A4 page size and margins, in points
+const pageWidth = 595
+const pageHeight = 842
+const pageMargin = 50
+
+type line struct {
+	text  string
+	style Style
+}
+
+// Document is a PDF document consisting of lines of text
+type Document struct {
+	lines []line
+}
+
+func NewDocument() *Document {
+	return &Document{}
+}
+
+// AddLine appends a line of text to the document. Text which is too
+// long to fit on a line is wrapped. Text may contain newlines.
+func (doc *Document) AddLine(text string, style Style) {
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, wrapped := range wrapText(paragraph, styles[style].maxChars) {
+			doc.lines = append(doc.lines, line{wrapped, style})
+		}
+	}
+}
+
+// AddSpace appends a blank line to the document
+func (doc *Document) AddSpace() {
+	doc.lines = append(doc.lines, line{"", Normal})
+}
+
+// wrapText splits text into lines of at most width characters,
+// breaking at spaces where possible
+func wrapText(text string, width int) []string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return []string{text}
+	}
+	lines := []string{}
+	for len(runes) > width {
+		split := width
+		for i := width; i > width/2; i-- {
+			if runes[i] == ' ' {
+				split = i
+				break
+			}
+		}
+		lines = append(lines, string(runes[:split]))
+		runes = []rune(strings.TrimLeft(string(runes[split:]), " "))
+	}
+	return append(lines, string(runes))
+}
+
+// escapeText encodes text as a PDF string literal using the
+// WinAnsi (Latin-1) encoding of the standard fonts.
+// Characters which cannot be represented are replaced with '?'.
+func escapeText(text string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for _, ch := range text {
+		switch {
+		case ch == '(' || ch == ')' || ch == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(byte(ch))
+		case ch < 32 || ch > 255 || (ch >= 127 && ch < 160):
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(byte(ch))
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// pages splits the document's lines into content
+// streams for each page
+func (doc *Document) pages() [][]byte {
+	pages := [][]byte{}
+	var page bytes.Buffer
+	y := float64(pageHeight - pageMargin)
+	for _, line := range doc.lines {
+		info := styles[line.style]
+		if y-info.leading < pageMargin {
+			pages = append(pages, page.Bytes())
+			page = bytes.Buffer{}
+			y = pageHeight - pageMargin
+		}
+		y -= info.leading
+		if line.text != "" {
+			fmt.Fprintf(&page, "BT /%s %g Tf %d %g Td %s Tj ET\n", info.font, info.size,
+				pageMargin, y, escapeText(line.text))
+		}
+	}
+	return append(pages, page.Bytes())
+}
+
+// Write writes the document to w in PDF format. If password is
+// not empty, the document is encrypted and the password is required
+// to open it.
+func (doc *Document) Write(w io.Writer, password string) error {
+	out := &pdfWriter{}
+	var sec *security
+	if password != "" {
+		var err error
+		sec, err = newSecurity(password)
+		if err != nil {
+			return err
+		}
+	}
+
+	pages := doc.pages()
+	const firstPageObj = 6
+	pageRefs := []string{}
+	for i := range pages {
+		pageRefs = append(pageRefs, fmt.Sprintf("%d 0 R", firstPageObj+i*2))
+	}
+
+	out.WriteString("%PDF-1.6\n%\xe2\xe3\xcf\xd3\n")
+	out.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(pages)))
+	out.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	out.object(4, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	out.object(5, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		pageObj := firstPageObj + i*2
+		out.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, pageObj+1))
+		if sec != nil {
+			var err error
+			content, err = sec.encrypt(pageObj+1, content)
+			if err != nil {
+				return err
+			}
+		}
+		out.stream(pageObj+1, content)
+	}
+
+	objCount := firstPageObj + len(pages)*2
+	trailer := fmt.Sprintf("/Size %d /Root 1 0 R", objCount)
+	if sec != nil {
+		out.object(objCount, sec.encryptDict())
+		trailer = fmt.Sprintf("/Size %d /Root 1 0 R /Encrypt %d 0 R /ID [<%x> <%x>]",
+			objCount+1, objCount, sec.id, sec.id)
+		objCount++
+	}
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", objCount)
+	for obj := 1; obj < objCount; obj++ {
+		fmt.Fprintf(out, "%010d 00000 n \n", out.offsets[obj])
+	}
+	fmt.Fprintf(out, "trailer\n<< %s >>\nstartxref\n%d\n%%%%EOF\n", trailer, xrefOffset)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pdfWriter accumulates the serialized document and records
+// the offset of each object for the cross-reference table
+type pdfWriter struct {
+	bytes.Buffer
+	offsets map[int]int
+}
+
+func (out *pdfWriter) object(num int, value string) {
+	if out.offsets == nil {
+		out.offsets = map[int]int{}
+	}
+	out.offsets[num] = out.Len()
+	fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", num, value)
+}
+
+func (out *pdfWriter) stream(num int, data []byte) {
+	out.object(num, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data))
+}
+
+// padding used to extend passwords to 32 bytes, from
+// 'Algorithm 2' in the PDF specification
+var passwordPadding = []byte{
+	0x28, 0xbf, 0x4e, 0x5e, 0x4e, 0x75, 0x8a, 0x41, 0x64, 0x00, 0x4e, 0x56, 0xff, 0xfa, 0x01, 0x08,
+	0x2e, 0x2e, 0x00, 0xb6, 0xd0, 0x68, 0x3e, 0x80, 0x2f, 0x0c, 0xa9, 0xfe, 0x64, 0x53, 0x69, 0x7a,
+}
+
+// permissions granted to users who open the document.
+// All operations are permitted.
+var permissions int32 = -4
+
+const keyLength = 16
+
+// security holds the encryption parameters for a document
+// protected with the standard security handler, revision 4
+// using AES-128 encryption
+type security struct {
+	id    []byte
+	key   []byte
+	owner []byte
+	user  []byte
+}
+
+func padPassword(password string) []byte {
+	padded := append([]byte(password), passwordPadding...)
+	return padded[:32]
+}
+
+// rc4Rounds encrypts data with RC4 20 times using key XORed with
+// the round number, as used when computing the O and U values
+func rc4Rounds(key []byte, data []byte) []byte {
+	result := append([]byte{}, data...)
+	roundKey := make([]byte, len(key))
+	for i := 0; i < 20; i++ {
+		for k := range key {
+			roundKey[k] = key[k] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(roundKey)
+		c.XORKeyStream(result, result)
+	}
+	return result
+}
+
+// hashRounds returns the MD5 hash of data, rehashed
+// a further 50 times as required for revision 3 and later
+func hashRounds(data []byte) []byte {
+	hash := md5.Sum(data)
+	for i := 0; i < 50; i++ {
+		hash = md5.Sum(hash[:keyLength])
+	}
+	return hash[:keyLength]
+}
+
+// ownerValue computes the O entry of the encryption dictionary
+// ('Algorithm 3'). The owner password is the same as the
+// user password.
+func ownerValue(password string) []byte {
+	return rc4Rounds(hashRounds(padPassword(password)), padPassword(password))
+}
+
+// fileKey computes the document encryption key ('Algorithm 2')
+func fileKey(password string, owner []byte, id []byte) []byte {
+	data := append(padPassword(password), owner...)
+	perms := make([]byte, 4)
+	binary.LittleEndian.PutUint32(perms, uint32(permissions))
+	data = append(data, perms...)
+	data = append(data, id...)
+	return hashRounds(data)
+}
+
+// userValue computes the U entry of the encryption
+// dictionary ('Algorithm 5')
+func userValue(key []byte, id []byte) []byte {
+	hash := md5.Sum(append(append([]byte{}, passwordPadding...), id...))
+	return append(rc4Rounds(key, hash[:]), make([]byte, 16)...)
+}
+
+func newSecurity(password string) (*security, error) {
+	id := make([]byte, 16)
+	_, err := io.ReadFull(rand.Reader, id)
+	if err != nil {
+		return nil, err
+	}
+	owner := ownerValue(password)
+	key := fileKey(password, owner, id)
+	return &security{
+		id:    id,
+		key:   key,
+		owner: owner,
+		user:  userValue(key, id),
+	}, nil
+}
+
+func (sec *security) encryptDict() string {
+	return fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 "+
+		"/CF << /StdCF << /Type /CryptFilter /AuthEvent /DocOpen /CFM /AESV2 /Length 16 >> >> "+
+		"/StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /P %d >>", sec.owner, sec.user, permissions)
+}
+
+// objectKey returns the key used to encrypt
+// strings and streams in a given object
+func (sec *security) objectKey(obj int) []byte {
+	data := append([]byte{}, sec.key...)
+	data = append(data, byte(obj), byte(obj>>8), byte(obj>>16), 0, 0)
+	data = append(data, []byte("sAlT")...)
+	hash := md5.Sum(data)
+	return hash[:keyLength]
+}
+
+// encrypt encrypts data in object obj using AES-128-CBC
+// with a random IV, which is prepended to the result
+func (sec *security) encrypt(obj int, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(sec.objectKey(obj))
+	if err != nil {
+		return nil, err
+	}
+	padLen := aes.BlockSize - len(data)%aes.BlockSize
+	plainText := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+
+	result := make([]byte, aes.BlockSize+len(plainText))
+	_, err = io.ReadFull(rand.Reader, result[:aes.BlockSize])
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, result[:aes.BlockSize]).CryptBlocks(result[aes.BlockSize:], plainText)
+	return result, nil
+}
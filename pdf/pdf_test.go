@@ -0,0 +1,109 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteUnencrypted(t *testing.T) {
+	doc := NewDocument()
+	doc.AddLine("Emergency Kit", Heading)
+	doc.AddLine("Password (hint)", Mono)
+	for i := 0; i < 60; i++ {
+		doc.AddLine("line "+strconv.Itoa(i), Normal)
+	}
+
+	var buf bytes.Buffer
+	err := doc.Write(&buf, "")
+	if err != nil {
+		t.Fatalf("Unable to write PDF: %v", err)
+	}
+	pdf := buf.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.6") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Errorf("Missing PDF header or trailer")
+	}
+	if !strings.Contains(pdf, "/Count 2") {
+		t.Errorf("Expected document to have two pages")
+	}
+	if !strings.Contains(pdf, `(Password \(hint\)) Tj`) {
+		t.Errorf("Expected text to be escaped")
+	}
+
+	// check that xref offsets point to the start of each object
+	startXref := regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(pdf)
+	offset, _ := strconv.Atoi(startXref[1])
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(pdf[offset:], -1)
+	for i, entry := range entries {
+		objOffset, _ := strconv.Atoi(entry[1])
+		if !strings.HasPrefix(pdf[objOffset:], strconv.Itoa(i+1)+" 0 obj") {
+			t.Errorf("Incorrect xref offset for object %d", i+1)
+		}
+	}
+}
+
+func TestWriteEncrypted(t *testing.T) {
+	doc := NewDocument()
+	doc.AddLine("secret text", Mono)
+
+	var buf bytes.Buffer
+	err := doc.Write(&buf, "kit-password")
+	if err != nil {
+		t.Fatalf("Unable to write PDF: %v", err)
+	}
+	pdf := buf.Bytes()
+	if bytes.Contains(pdf, []byte("secret text")) {
+		t.Errorf("Content stream is not encrypted")
+	}
+
+	hexValue := func(pattern string) []byte {
+		match := regexp.MustCompile(pattern + ` ?<([0-9a-f]+)>`).FindSubmatch(pdf)
+		if match == nil {
+			t.Fatalf("Missing %s entry", pattern)
+		}
+		value := make([]byte, len(match[1])/2)
+		for i := range value {
+			b, _ := strconv.ParseUint(string(match[1][i*2:i*2+2]), 16, 8)
+			value[i] = byte(b)
+		}
+		return value
+	}
+	owner := hexValue("/O")
+	user := hexValue("/U")
+	id := hexValue(`/ID \[`)
+
+	// authenticate the user password ('Algorithm 6')
+	key := fileKey("kit-password", owner, id)
+	if !bytes.Equal(userValue(key, id)[:16], user[:16]) {
+		t.Fatalf("User password does not authenticate")
+	}
+	wrongKey := fileKey("wrong-password", owner, id)
+	if bytes.Equal(userValue(wrongKey, id)[:16], user[:16]) {
+		t.Fatalf("Wrong password authenticates")
+	}
+
+	// decrypt the content stream of the first page
+	sec := security{key: key}
+	start := bytes.Index(pdf, []byte("7 0 obj"))
+	streamStart := start + bytes.Index(pdf[start:], []byte("stream\n")) + len("stream\n")
+	streamEnd := streamStart + bytes.Index(pdf[streamStart:], []byte("\nendstream"))
+	data := pdf[streamStart:streamEnd]
+	block, _ := aes.NewCipher(sec.objectKey(7))
+	plainText := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plainText, data[aes.BlockSize:])
+	if !bytes.Contains(plainText, []byte("(secret text) Tj")) {
+		t.Errorf("Unexpected decrypted content: %q", plainText)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("the quick brown fox jumps over the lazy dog", 15)
+	expected := []string{"the quick brown", "fox jumps over", "the lazy dog"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("Unexpected wrapping: %q", lines)
+	}
+}
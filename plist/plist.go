@@ -15,6 +15,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 const PlistDocTypeHeader = `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">`
@@ -48,17 +49,25 @@ func plistElement(v interface{}) PlistXmlElement {
 				continue
 			}
 
-			fieldName := field.Tag.Get("json")
+			tagParts := strings.Split(field.Tag.Get("json"), ",")
+			fieldName := tagParts[0]
 			if fieldName == "" {
 				fieldName = field.Name
 			}
+			fieldValue := value.Field(i)
+			omitEmpty := len(tagParts) > 1 && tagParts[1] == "omitempty"
+			if (omitEmpty && isEmptyValue(fieldValue)) || (fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil()) {
+				continue
+			}
 			elt.Children = append(elt.Children, PlistXmlElement{
 				XMLName: tagName("key"),
 				Value:   fieldName,
 			})
-			elt.Children = append(elt.Children, plistElement(value.Field(i).Interface()))
+			elt.Children = append(elt.Children, plistElement(fieldValue.Interface()))
 		}
 		return elt
+	case reflect.Ptr:
+		return plistElement(value.Elem().Interface())
 	case reflect.Slice:
 		if vType.Elem().Kind() == reflect.Uint8 {
 			return PlistXmlElement{
@@ -82,11 +91,29 @@ func plistElement(v interface{}) PlistXmlElement {
 			XMLName: tagName("string"),
 			Value:   value.String(),
 		}
+	case reflect.Bool:
+		return PlistXmlElement{XMLName: tagName(fmt.Sprint(value.Bool()))}
 	default:
 		panic(fmt.Sprintf("Value type '%s' not supported by PList marshalling", vType.Name()))
 	}
 }
 
+// isEmptyValue returns true for the values of fields which
+// are omitted if the 'omitempty' option is used
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Int:
+		return value.Int() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Ptr:
+		return value.IsNil()
+	}
+	return false
+}
+
 // Marshal an interface to a PList XML file.
 func Marshal(v interface{}) (data []byte, err error) {
 	defer func() {
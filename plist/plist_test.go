@@ -2,10 +2,11 @@ package plist
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"testing"
+
+	"github.com/robertknight/1pass/securetmp"
 )
 
 type nestedStruct struct {
@@ -19,7 +20,12 @@ type testFieldTypes struct {
 	DataField            []byte
 	StructField          nestedStruct
 	StructArray          []nestedStruct
-	FieldWithJsonNameTag int `json:"fieldNameFromTag"`
+	FieldWithJsonNameTag int           `json:"fieldNameFromTag"`
+	FieldWithTagOptions  string        `json:"fieldWithOptions,omitempty"`
+	OmittedEmptyField    string        `json:"omitted,omitempty"`
+	PointerField         *nestedStruct `json:"pointer,omitempty"`
+	NilPointerField      *nestedStruct
+	BoolField            bool `json:"bool"`
 	unexportedField      int
 }
 
@@ -57,27 +63,50 @@ var expectedPlist = `<?xml version="1.0" encoding="UTF-8"?>
                 </array>
                 <key>fieldNameFromTag</key>
                 <integer>23</integer>
+                <key>fieldWithOptions</key>
+                <string>options</string>
+                <key>pointer</key>
+                <dict>
+                        <key>IntField</key>
+                        <integer>3</integer>
+                        <key>StrField</key>
+                        <string>C</string>
+                </dict>
+                <key>bool</key>
+                <true></true>
         </dict>
 </plist>`
 
-func diffStrings(a, b string) string {
-	tmpFileA := os.TempDir() + "/a.txt"
-	tmpFileB := os.TempDir() + "/b.txt"
+func writeTempFile(content string) (string, error) {
+	file, err := securetmp.CreateFile("plist-test-*.txt")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
 
-	err := ioutil.WriteFile(tmpFileA, []byte(a), 0644)
+func diffStrings(a, b string) string {
+	tmpFileA, err := writeTempFile(a)
 	if err != nil {
 		return fmt.Sprintf("failed to write temp file: %v", err)
 	}
-	err = ioutil.WriteFile(tmpFileB, []byte(b), 0644)
+	defer os.Remove(tmpFileA)
+	tmpFileB, err := writeTempFile(b)
 	if err != nil {
-		return fmt.Sprintf("failed to write tmep file: %v", err)
+		return fmt.Sprintf("failed to write temp file: %v", err)
 	}
+	defer os.Remove(tmpFileB)
 
 	diffCmd := exec.Command("diff", "--ignore-space-change", "-u", tmpFileA, tmpFileB)
-	diffResult, err := diffCmd.Output()
-
-	os.Remove(tmpFileA)
-	os.Remove(tmpFileB)
+	diffResult, _ := diffCmd.Output()
 
 	return string(diffResult)
 }
@@ -96,6 +125,9 @@ func TestMarshalPlist(t *testing.T) {
 			{IntField: 2, StrField: "B"},
 		},
 		FieldWithJsonNameTag: 23,
+		FieldWithTagOptions:  "options",
+		PointerField:         &nestedStruct{IntField: 3, StrField: "C"},
+		BoolField:            true,
 	}
 	data, err := Marshal(in)
 	if err != nil {
@@ -0,0 +1,106 @@
+// Package securetmp creates temporary files and directories for
+// decrypted data in a directory which only the current user can
+// access, rather than in the shared system temporary directory
+package securetmp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// File is a temporary file created by CreateUnlinked(). Closing
+// the file removes it if it could not be unlinked when opened.
+type File struct {
+	*os.File
+	unlinked bool
+}
+
+func (file *File) Close() error {
+	err := file.File.Close()
+	if !file.unlinked {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Dir returns the private directory for temporary files, creating
+// it if necessary. This is $XDG_RUNTIME_DIR/1pass if XDG_RUNTIME_DIR
+// is set or a '1pass-<uid>' directory in the system's temporary
+// directory otherwise. An error is returned if the directory exists
+// but is a symlink or is owned by another user.
+func Dir() (string, error) {
+	var dir string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		dir = filepath.Join(runtimeDir, "1pass")
+	} else {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("1pass-%d", os.Getuid()))
+	}
+	err := os.Mkdir(dir, 0700)
+	if err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("'%s' is not a directory", dir)
+	}
+	if !isOwner(info) {
+		return "", fmt.Errorf("'%s' is owned by another user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		err = os.Chmod(dir, 0700)
+		if err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// CreateFile creates a new file which only the current user can
+// read or write in the directory returned by Dir(). pattern is
+// used to choose the file's name as with ioutil.TempFile().
+// The caller is responsible for removing the file.
+func CreateFile(pattern string) (*os.File, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	// ioutil.TempFile() creates files with mode 0600
+	return ioutil.TempFile(dir, pattern)
+}
+
+// CreateUnlinked is like CreateFile but removes the file's name
+// straight after opening it on systems which allow that, so that
+// the data is never visible to other processes and the file does
+// not outlive the process. On other systems the file is removed
+// when it is closed.
+func CreateUnlinked(pattern string) (*File, error) {
+	file, err := CreateFile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: file, unlinked: unlinkOpenFile(file)}, nil
+}
+
+// MkdirTemp creates a new directory which only the current
+// user can access in the directory returned by Dir(). The caller
+// is responsible for removing the directory.
+func MkdirTemp(prefix string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	// ioutil.TempDir() creates directories with mode 0700
+	return ioutil.TempDir(dir, prefix)
+}
+
+// Perm returns the permissions which a file created with mode perm
+// gets after applying the process' umask, for use when the mode of
+// a file is set explicitly with os.Chmod()
+func Perm(perm os.FileMode) os.FileMode {
+	return perm &^ umask
+}
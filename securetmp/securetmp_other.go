@@ -0,0 +1,20 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package securetmp
+
+import (
+	"os"
+)
+
+var umask os.FileMode = 0022
+
+func isOwner(info os.FileInfo) bool {
+	return true
+}
+
+// open files cannot be removed on other systems,
+// so they are removed when closed instead
+func unlinkOpenFile(file *os.File) bool {
+	return false
+}
@@ -0,0 +1,78 @@
+package securetmp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCreateFile(t *testing.T) {
+	runtimeDir, err := ioutil.TempDir("", "securetmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(runtimeDir)
+	defer os.Setenv("XDG_RUNTIME_DIR", os.Getenv("XDG_RUNTIME_DIR"))
+	os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	file, err := CreateFile("test-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if filepath.Dir(file.Name()) != filepath.Join(runtimeDir, "1pass") {
+		t.Errorf("File created in unexpected directory: %s", file.Name())
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(file.Name())
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Unexpected mode for temp file: %v, %v", info, err)
+	}
+	dirInfo, err := os.Stat(filepath.Dir(file.Name()))
+	if err != nil || dirInfo.Mode().Perm() != 0700 {
+		t.Errorf("Unexpected mode for temp dir: %v, %v", dirInfo, err)
+	}
+
+	// a symlink to another directory is not used
+	os.RemoveAll(filepath.Join(runtimeDir, "1pass"))
+	err = os.Symlink(os.TempDir(), filepath.Join(runtimeDir, "1pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Dir(); err == nil {
+		t.Errorf("Expected symlinked directory to be refused")
+	}
+}
+
+func TestCreateUnlinked(t *testing.T) {
+	runtimeDir, err := ioutil.TempDir("", "securetmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(runtimeDir)
+	defer os.Setenv("XDG_RUNTIME_DIR", os.Getenv("XDG_RUNTIME_DIR"))
+	os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	file, err := CreateUnlinked("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Seek(0, 0)
+	data, err := ioutil.ReadAll(file)
+	if err != nil || string(data) != "secret" {
+		t.Errorf("Unable to read back unlinked file: '%s', %v", data, err)
+	}
+	file.Close()
+	if _, err := os.Stat(file.Name()); !os.IsNotExist(err) {
+		t.Errorf("Expected temp file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package securetmp
+
+import (
+	"os"
+	"syscall"
+)
+
+// the umask can only be read by setting it, so it is read once
+// when the package is initialized before other goroutines which
+// might create files are started
+var umask = readUmask()
+
+func readUmask() os.FileMode {
+	mask := syscall.Umask(0077)
+	syscall.Umask(mask)
+	return os.FileMode(mask)
+}
+
+func isOwner(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || int(stat.Uid) == os.Getuid()
+}
+
+// unlinkOpenFile removes the name of an open file. The data remains
+// readable and writable through file until it is closed.
+func unlinkOpenFile(file *os.File) bool {
+	return os.Remove(file.Name()) == nil
+}